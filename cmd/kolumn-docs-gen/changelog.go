@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+)
+
+// schemaRevision is the provider schema as of one git tag, or the current tree
+type schemaRevision struct {
+	ref    string
+	schema *core.Schema
+}
+
+// Changelog diffs the schema committed at each release tag against the next newer tag
+// and the current schema, and writes the result as a Markdown CHANGELOG and as JSON
+func (e *DocumentationExtractor) Changelog() error {
+	revisions, err := e.taggedSchemas()
+	if err != nil {
+		return err
+	}
+	current, err := e.currentSchema()
+	if err != nil {
+		return err
+	}
+	revisions = append(revisions, schemaRevision{ref: currentRef(current), schema: current})
+	if len(revisions) < 2 {
+		return fmt.Errorf("no tagged %s found to diff against", e.config.SchemaFile)
+	}
+
+	// Newest first, like the CHANGELOG itself
+	var changelogs []*core.DocsChangelog
+	var markdown strings.Builder
+	markdown.WriteString("# Changelog\n")
+	for i := len(revisions) - 1; i > 0; i-- {
+		older, newer := revisions[i-1], revisions[i]
+		changelog := core.DiffSchemas(older.schema, newer.schema)
+		changelog.FromVersion, changelog.ToVersion = older.ref, newer.ref
+		changelogs = append(changelogs, changelog)
+		markdown.WriteString("\n" + changelog.Markdown(""))
+	}
+
+	if err := os.WriteFile(e.config.ChangelogFile, []byte(markdown.String()), 0o644); err != nil {
+		return fmt.Errorf("failed to write changelog: %w", err)
+	}
+	data, err := json.MarshalIndent(changelogs, "", "  ")
+	if err != nil {
+		return err
+	}
+	jsonFile := e.config.ChangelogJSON
+	if jsonFile == "" {
+		jsonFile = strings.TrimSuffix(e.config.ChangelogFile, filepath.Ext(e.config.ChangelogFile)) + ".json"
+	}
+	if err := os.WriteFile(jsonFile, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write changelog: %w", err)
+	}
+	if e.config.Verbose {
+		log.Printf("Changelog of %d releases written to %s and %s", len(changelogs), e.config.ChangelogFile, jsonFile)
+	}
+	return nil
+}
+
+// taggedSchemas reads the schema file at every version tag from -since on, oldest first.
+// Tags that do not contain the schema file are skipped.
+func (e *DocumentationExtractor) taggedSchemas() ([]schemaRevision, error) {
+	out, err := e.git("tag", "--list")
+	if err != nil {
+		return nil, err
+	}
+	tags := strings.Fields(string(out))
+	sort.SliceStable(tags, func(i, j int) bool { return core.CompareVersions(tags[i], tags[j]) < 0 })
+
+	var revisions []schemaRevision
+	for _, tag := range tags {
+		if e.config.ChangelogSince != "" && core.CompareVersions(tag, e.config.ChangelogSince) < 0 {
+			continue
+		}
+		data, err := e.git("show", tag+":"+filepath.ToSlash(e.config.SchemaFile))
+		if err != nil {
+			if e.config.Verbose {
+				log.Printf("Skipping tag %s: no %s", tag, e.config.SchemaFile)
+			}
+			continue
+		}
+		var schema core.Schema
+		if err := json.Unmarshal(data, &schema); err != nil {
+			return nil, fmt.Errorf("%s at %s: %w", e.config.SchemaFile, tag, err)
+		}
+		revisions = append(revisions, schemaRevision{ref: tag, schema: &schema})
+	}
+	return revisions, nil
+}
+
+// currentSchema returns the schema of -provider when set, otherwise the schema file in
+// the working tree
+func (e *DocumentationExtractor) currentSchema() (*core.Schema, error) {
+	if e.config.ProviderBinary != "" {
+		schema, _, err := e.executeProviderForDocs()
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute provider: %w", err)
+		}
+		return schema, nil
+	}
+	data, err := os.ReadFile(filepath.Join(e.config.RepoDir, e.config.SchemaFile))
+	if err != nil {
+		return nil, err
+	}
+	var schema core.Schema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("%s: %w", e.config.SchemaFile, err)
+	}
+	return &schema, nil
+}
+
+// currentRef names the current schema's section after its version, or "Unreleased"
+func currentRef(schema *core.Schema) string {
+	if schema.Version != "" {
+		return schema.Version
+	}
+	return "Unreleased"
+}
+
+// git runs a git command in the repository and returns its standard output
+func (e *DocumentationExtractor) git(args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append([]string{"-C", e.config.RepoDir}, args...)...)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+	}
+	return out, nil
+}
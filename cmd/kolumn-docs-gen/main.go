@@ -3,6 +3,8 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"crypto/sha256"
 	"encoding/json"
 	"flag"
@@ -12,12 +14,15 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
-	"plugin"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/schemabounce/kolumn/sdk/core"
+	"github.com/schemabounce/kolumn/sdk/helpers/hcl"
+	"github.com/schemabounce/kolumn/sdk/helpers/sandbox"
+	"github.com/schemabounce/kolumn/sdk/probe"
 )
 
 const (
@@ -28,12 +33,30 @@ const (
 // Config holds the command-line configuration
 type Config struct {
 	ProviderBinary string
+	MergeBinaries  []string
+	PreviousDocs   []string
 	DocsDir        string
 	ExamplesDir    string
+	FormatExamples bool
 	OutputFile     string
 	Validate       bool
 	Verbose        bool
 	NoMetadata     bool
+	SigningKey     string
+	KeyID          string
+	VerifyFile     string
+	VerifyKey      string
+	ChangelogFile  string
+	ChangelogJSON  string
+	ChangelogSince string
+	SchemaFile     string
+	RepoDir        string
+	SourceDir      string
+	Sandbox        bool
+	SandboxAllow   []sandbox.Capability
+	TrustStore     string
+	ChecksumsFile  string
+	SkipVerify     bool
 }
 
 // DocumentationExtractor handles extraction of documentation from providers
@@ -41,6 +64,8 @@ type DocumentationExtractor struct {
 	config       *Config
 	builder      *core.DocumentationBuilder
 	providerMeta core.ProviderMetadata
+	schema       *core.Schema
+	sourceDocs   *core.SourceDocs
 }
 
 func main() {
@@ -56,6 +81,22 @@ func main() {
 		builder: core.NewDocumentationBuilder(),
 	}
 
+	if config.ChangelogFile != "" {
+		if err := extractor.Changelog(); err != nil {
+			log.Fatalf("Changelog generation failed: %v", err)
+		}
+		fmt.Printf("Changelog written: %s\n", config.ChangelogFile)
+		return
+	}
+
+	if config.VerifyFile != "" {
+		if err := extractor.Verify(); err != nil {
+			log.Fatalf("Attestation verification failed: %v", err)
+		}
+		fmt.Printf("Attestation verified: %s\n", config.VerifyFile)
+		return
+	}
+
 	if err := extractor.Extract(); err != nil {
 		log.Fatalf("Documentation extraction failed: %v", err)
 	}
@@ -69,12 +110,33 @@ func parseFlags() *Config {
 	config := &Config{}
 
 	flag.StringVar(&config.ProviderBinary, "provider", "", "Path to provider binary (required)")
+	var mergeBinaries string
+	flag.StringVar(&mergeBinaries, "merge", "", "Comma-separated additional provider binaries to merge")
+	var previousDocs string
+	flag.StringVar(&previousDocs, "previous", "", "Comma-separated docs files of earlier provider versions to keep")
 	flag.StringVar(&config.DocsDir, "docs", "docs/", "Path to documentation directory")
 	flag.StringVar(&config.ExamplesDir, "examples", "examples/", "Path to examples directory")
+	flag.BoolVar(&config.FormatExamples, "fmt-examples", false, "Rewrite example files in canonical HCL formatting")
 	flag.StringVar(&config.OutputFile, "output", "provider-docs.json", "Output file path")
 	flag.BoolVar(&config.Validate, "validate", true, "Validate documentation against schema")
 	flag.BoolVar(&config.Verbose, "verbose", false, "Enable verbose logging")
 	flag.BoolVar(&config.NoMetadata, "no-metadata", false, "Skip build metadata generation")
+	flag.StringVar(&config.SigningKey, "sign-key", "", "PEM ed25519 release key used to attest schema and docs")
+	flag.StringVar(&config.KeyID, "key-id", "", "Identifier of the signing key recorded in the attestation")
+	flag.StringVar(&config.VerifyFile, "verify", "", "Verify the attestation of a generated documentation file")
+	flag.StringVar(&config.VerifyKey, "verify-key", "", "PEM ed25519 public key used with -verify")
+	flag.StringVar(&config.ChangelogFile, "changelog", "", "Write a Markdown changelog of schema changes across git tags")
+	flag.StringVar(&config.ChangelogJSON, "changelog-json", "", "Machine-readable changelog path (default: -changelog with .json)")
+	flag.StringVar(&config.ChangelogSince, "since", "", "Oldest git tag included in the changelog")
+	flag.StringVar(&config.SchemaFile, "schema-file", "schema.json", "Schema JSON path committed in the repository")
+	flag.StringVar(&config.RepoDir, "repo", ".", "Git repository holding the release tags")
+	flag.StringVar(&config.SourceDir, "source", "", "Provider Go source tree whose doc comments describe resources")
+	flag.StringVar(&config.TrustStore, "trust-store", core.DefaultTrustStoreDir(), "Directory of trusted publisher public keys (PEM ed25519)")
+	flag.StringVar(&config.ChecksumsFile, "checksums", "", "SHA256SUMS file of the provider release (default: next to the binary)")
+	flag.BoolVar(&config.SkipVerify, "insecure-skip-verify", false, "Execute provider binaries without checksum and signature verification")
+	flag.BoolVar(&config.Sandbox, "sandbox", false, "Execute the provider sandboxed: restricted environment, private working directory, no network")
+	var sandboxAllow string
+	flag.StringVar(&sandboxAllow, "sandbox-allow", "", "Comma-separated capabilities granted to the sandboxed provider: network, env, workdir, home")
 
 	var showHelp bool
 	flag.BoolVar(&showHelp, "help", false, "Show help message")
@@ -87,12 +149,42 @@ func parseFlags() *Config {
 		os.Exit(0)
 	}
 
-	if config.ProviderBinary == "" {
+	if config.VerifyFile != "" && config.VerifyKey == "" {
+		fmt.Fprintf(os.Stderr, "Error: -verify requires -verify-key\n\n")
+		printHelp()
+		os.Exit(1)
+	}
+
+	if config.ProviderBinary == "" && config.VerifyFile == "" && config.ChangelogFile == "" {
 		fmt.Fprintf(os.Stderr, "Error: -provider flag is required\n\n")
 		printHelp()
 		os.Exit(1)
 	}
 
+	caps, err := sandbox.ParseCapabilities(sandboxAllow)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: -sandbox-allow: %v\n\n", err)
+		printHelp()
+		os.Exit(1)
+	}
+	config.SandboxAllow = caps
+
+	for _, binary := range strings.Split(mergeBinaries, ",") {
+		if binary = strings.TrimSpace(binary); binary != "" {
+			config.MergeBinaries = append(config.MergeBinaries, binary)
+		}
+	}
+	if len(config.MergeBinaries) > 0 && config.SigningKey != "" {
+		fmt.Fprintf(os.Stderr, "Error: -sign-key cannot be combined with -merge; the attestation only covers the -provider binary\n\n")
+		printHelp()
+		os.Exit(1)
+	}
+	for _, path := range strings.Split(previousDocs, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			config.PreviousDocs = append(config.PreviousDocs, path)
+		}
+	}
+
 	return config
 }
 
@@ -106,14 +198,55 @@ USAGE:
     kolumn-docs-gen [OPTIONS]
 
 REQUIRED FLAGS:
-    -provider PATH      Path to the provider binary
+    -provider PATH      Path to the provider binary. It is executed in the
+                        modes of the pdk CLI contract (--version, --schema,
+                        --docs, --capabilities), which pdk.Serve implements
 
 OPTIONAL FLAGS:
     -docs PATH          Path to documentation directory (default: docs/)
     -examples PATH      Path to examples directory (default: examples/)
+    -fmt-examples       Rewrite .kl example files in place in canonical HCL
+                        formatting. Examples are always linted against the
+                        provider schema; problems are reported as warnings
+    -merge PATHS        Comma-separated additional provider binaries whose
+                        documentation is merged into the output. Cannot be
+                        combined with -sign-key
     -output PATH        Output file path (default: provider-docs.json)
+    -previous PATHS     Comma-separated docs files of earlier provider versions;
+                        their resources are kept as per-version sections with a
+                        changelog. An existing output file is always included
     -validate           Validate documentation against schema (default: true)
     -no-metadata        Skip build metadata generation
+    -sign-key PATH      Sign schema, docs and binary digests with an ed25519 key
+    -key-id ID          Key identifier recorded in the attestation
+    -verify FILE        Verify the attestation in a generated docs file; when
+                        -provider is also set, its binary and schema are checked
+    -verify-key PATH    Public key used with -verify
+    -trust-store DIR    Directory of trusted publisher keys (*.pem, *.pub);
+                        default: ~/.kolumn/trusted-keys
+    -checksums PATH     SHA256SUMS file listing the provider binaries; its
+                        detached signature is read from PATH.sig. Defaults to
+                        SHA256SUMS next to each binary
+    -insecure-skip-verify
+                        Execute provider binaries without verifying
+                        them. Binaries are otherwise only run when a trusted
+                        key signed the checksum file and their sha256 matches
+    -sandbox            Execute provider binaries with a restricted environment,
+                        a private working directory and no network (Linux)
+    -sandbox-allow CAPS Comma-separated capabilities the sandbox grants:
+                        network, env, workdir, home
+    -changelog PATH     Diff the schema file at every version tag against the
+                        next tag and the current schema (from -provider, or the
+                        working tree), and write a Markdown CHANGELOG to PATH.
+                        -provider is optional in this mode
+    -changelog-json PATH
+                        Machine-readable changelog (default: PATH with .json)
+    -since TAG          Oldest tag included in the changelog
+    -schema-file PATH   Schema JSON committed at each tag (default: schema.json)
+    -repo DIR           Git repository holding the tags (default: .)
+    -source DIR         Read resource descriptions from the Go doc comments of
+                        handler types and schema builders under DIR; they
+                        replace the descriptions in the schema
     -verbose            Enable verbose logging
     -help, -h           Show this help message
 
@@ -127,6 +260,30 @@ EXAMPLES:
                     -examples ./examples \
                     -output ./postgres-docs.json
 
+    # Combine split create and discover binaries into one registry entry
+    kolumn-docs-gen -provider ./kolumn-provider-postgres \
+                    -merge ./kolumn-provider-postgres-discover
+
+    # Sign the output and verify it later against the binary
+    kolumn-docs-gen -provider ./kolumn-provider-postgres -sign-key release.pem
+    kolumn-docs-gen -provider ./kolumn-provider-postgres \
+                    -verify provider-docs.json -verify-key release.pub.pem
+
+    # Document a local development build that has no signed checksums
+    kolumn-docs-gen -provider ./kolumn-provider-postgres -insecure-skip-verify
+
+    # Document a third-party provider without network access
+    kolumn-docs-gen -provider ./kolumn-provider-acme -sandbox
+
+    # Changelog of schema changes since v1.2.0
+    kolumn-docs-gen -changelog CHANGELOG.md -since v1.2.0
+
+    # Take resource descriptions from the provider's doc comments
+    kolumn-docs-gen -provider ./kolumn-provider-postgres -source .
+
+    # Rewrite examples in canonical formatting before generating
+    kolumn-docs-gen -provider ./kolumn-provider-postgres -fmt-examples
+
     # Generate without validation (faster)
     kolumn-docs-gen -provider ./kolumn-provider-postgres \
                     -validate=false
@@ -141,6 +298,9 @@ func (e *DocumentationExtractor) Extract() error {
 	if err := e.extractFromProvider(); err != nil {
 		return fmt.Errorf("failed to extract from provider: %w", err)
 	}
+	if err := e.mergeAdditionalProviders(); err != nil {
+		return fmt.Errorf("failed to merge provider documentation: %w", err)
+	}
 
 	// 2. Load documentation files
 	if err := e.loadDocumentationFiles(); err != nil {
@@ -152,19 +312,24 @@ func (e *DocumentationExtractor) Extract() error {
 		return fmt.Errorf("failed to load examples: %w", err)
 	}
 
-	// 4. Generate metadata
+	// 4. Keep earlier versions and their changelogs
+	if err := e.loadPreviousVersions(); err != nil {
+		return fmt.Errorf("failed to load previous versions: %w", err)
+	}
+
+	// 5. Generate metadata
 	if !e.config.NoMetadata {
 		e.generateMetadata()
 	}
 
-	// 5. Validate if requested
+	// 6. Validate if requested
 	if e.config.Validate {
 		if err := e.validateDocumentation(); err != nil {
 			return fmt.Errorf("documentation validation failed: %w", err)
 		}
 	}
 
-	// 6. Generate output
+	// 7. Generate output
 	if err := e.generateOutput(); err != nil {
 		return fmt.Errorf("failed to generate output: %w", err)
 	}
@@ -172,19 +337,71 @@ func (e *DocumentationExtractor) Extract() error {
 	return nil
 }
 
+// mergeAdditionalProviders extracts each -merge binary concurrently and merges the
+// results into the primary documentation, failing on conflicting resources
+func (e *DocumentationExtractor) mergeAdditionalProviders() error {
+	if len(e.config.MergeBinaries) == 0 {
+		return nil
+	}
+
+	results := make([]*core.UniversalProviderDocumentation, len(e.config.MergeBinaries))
+	errs := make([]error, len(e.config.MergeBinaries))
+
+	var wg sync.WaitGroup
+	for i, binary := range e.config.MergeBinaries {
+		wg.Add(1)
+		go func(i int, binary string) {
+			defer wg.Done()
+
+			config := *e.config
+			config.ProviderBinary = binary
+			sub := &DocumentationExtractor{config: &config, builder: core.NewDocumentationBuilder()}
+			if err := sub.extractFromProvider(); err != nil {
+				errs[i] = fmt.Errorf("%s: %w", binary, err)
+				return
+			}
+			results[i] = sub.builder.Build()
+		}(i, binary)
+	}
+	wg.Wait()
+
+	for i, binary := range e.config.MergeBinaries {
+		if errs[i] != nil {
+			return errs[i]
+		}
+		if err := e.builder.Merge(results[i]); err != nil {
+			return fmt.Errorf("%s: %w", binary, err)
+		}
+	}
+	return nil
+}
+
 // extractFromProvider loads the provider and extracts schema and documentation
 func (e *DocumentationExtractor) extractFromProvider() error {
 	if e.config.Verbose {
 		log.Printf("Loading provider: %s", e.config.ProviderBinary)
 	}
 
-	// For now, we'll use a simple approach - execute the provider with a special flag
-	// In a real implementation, you might load it as a plugin or use RPC
 	schema, docs, err := e.executeProviderForDocs()
 	if err != nil {
 		return fmt.Errorf("failed to execute provider: %w", err)
 	}
 
+	e.schema = schema
+
+	// Descriptions written as doc comments next to the handlers win over the schema
+	if e.config.SourceDir != "" {
+		sourceDocs, err := core.ExtractSourceDocs(e.config.SourceDir)
+		if err != nil {
+			return fmt.Errorf("failed to read doc comments: %w", err)
+		}
+		e.sourceDocs = sourceDocs
+		updated := core.ApplySourceDocs(schema, sourceDocs)
+		if e.config.Verbose {
+			log.Printf("Descriptions of %d resources taken from doc comments: %s", len(updated), strings.Join(updated, ", "))
+		}
+	}
+
 	// Extract provider metadata from schema
 	providerMeta := e.extractProviderMetadata(schema)
 	e.providerMeta = providerMeta
@@ -197,101 +414,65 @@ func (e *DocumentationExtractor) extractFromProvider() error {
 	// Extract resource documentation
 	e.extractResourceDocs(schema, docs)
 
-	return nil
-}
-
-// executeProviderForDocs executes the provider to get documentation
-func (e *DocumentationExtractor) executeProviderForDocs() (*core.Schema, *core.ProviderDocumentation, error) {
-	// Create a temporary approach - in practice, this would use proper plugin loading
-	// For now, we'll assume the provider can be executed with --docs flag
+	// Capabilities section for registry feature comparison matrices
+	var governance *core.GovernanceCapabilities
+	if docs != nil {
+		governance = docs.Governance
+	}
+	e.builder.SetCapabilities(core.BuildCapabilityDocs(schema, governance))
 
-	// Try to load as plugin first (Unix-like systems)
-	if runtime.GOOS != "windows" {
-		return e.loadProviderAsPlugin()
+	// Kolumn core versions the provider supports
+	if compat := schema.CoreCompatibility(); compat != nil {
+		e.builder.SetCompatibility(compat)
 	}
 
-	// Fallback to execution method
-	return e.executeProviderCommand()
+	return nil
 }
 
-// loadProviderAsPlugin loads the provider as a Go plugin
-func (e *DocumentationExtractor) loadProviderAsPlugin() (*core.Schema, *core.ProviderDocumentation, error) {
-	// Load the plugin
-	p, err := plugin.Open(e.config.ProviderBinary)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to load plugin: %w", err)
-	}
-
-	// Look for NewProvider function
-	symbol, err := p.Lookup("NewProvider")
-	if err != nil {
-		return nil, nil, fmt.Errorf("NewProvider function not found: %w", err)
+// executeProviderForDocs queries the provider binary through the pdk CLI contract for
+// its schema and documentation
+func (e *DocumentationExtractor) executeProviderForDocs() (*core.Schema, *core.ProviderDocumentation, error) {
+	// Never run a binary whose checksum a trusted publisher did not sign
+	if err := e.verifyProviderBinary(); err != nil {
+		return nil, nil, err
 	}
+	return e.prober().Documentation(context.Background())
+}
 
-	// Cast to function and call it
-	newProvider, ok := symbol.(func() core.Provider)
-	if !ok {
-		return nil, nil, fmt.Errorf("NewProvider has unexpected signature")
+// prober runs the provider binary, with -sandbox under the restricted environment,
+// private working directory and no network. Every experiment is enabled so flagged
+// resource types are documented (and marked).
+func (e *DocumentationExtractor) prober() *probe.Prober {
+	flags := os.Getenv(core.FeatureFlagsEnvVar)
+	if flags == "" {
+		flags = "*"
 	}
-
-	provider := newProvider()
-
-	// Get schema
-	schema, err := provider.Schema()
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to get schema: %w", err)
+	opts := probe.Options{Env: map[string]string{core.FeatureFlagsEnvVar: flags}}
+	if e.config.Sandbox {
+		opts.Sandbox = &sandbox.Options{Capabilities: e.config.SandboxAllow}
 	}
-
-	// Get documentation (try DocumentedProvider interface first)
-	var docs *core.ProviderDocumentation
-	if docProvider, ok := provider.(core.DocumentedProvider); ok {
-		docs = docProvider.Documentation()
-	} else {
-		// Fallback: create minimal documentation from schema
-		docs = &core.ProviderDocumentation{
-			Name:        schema.Name,
-			Version:     schema.Version,
-			Description: schema.Description,
-		}
-	}
-
-	// Use the documentation we already obtained
-	return schema, docs, nil
+	return probe.New(e.config.ProviderBinary, opts)
 }
 
-// executeProviderCommand executes the provider as a command
-func (e *DocumentationExtractor) executeProviderCommand() (*core.Schema, *core.ProviderDocumentation, error) {
-	// Execute provider with --schema flag to get schema
-	schemaCmd := exec.Command(e.config.ProviderBinary, "--schema")
-	schemaOutput, err := schemaCmd.Output()
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to get schema: %w", err)
+// verifyProviderBinary checks the provider binary against its signed checksum file
+// unless -insecure-skip-verify is set
+func (e *DocumentationExtractor) verifyProviderBinary() error {
+	if e.config.SkipVerify {
+		log.Printf("Warning: executing %s without verification (-insecure-skip-verify)", e.config.ProviderBinary)
+		return nil
 	}
-
-	var schema core.Schema
-	if err := json.Unmarshal(schemaOutput, &schema); err != nil {
-		return nil, nil, fmt.Errorf("failed to parse schema: %w", err)
+	trust, err := core.LoadTrustStore(e.config.TrustStore)
+	if err != nil {
+		return fmt.Errorf("failed to load trust store: %w", err)
 	}
-
-	// Execute provider with --docs flag to get documentation
-	docsCmd := exec.Command(e.config.ProviderBinary, "--docs")
-	docsOutput, err := docsCmd.Output()
+	verified, err := core.VerifyProviderBinary(e.config.ProviderBinary, e.config.ChecksumsFile, trust)
 	if err != nil {
-		// Documentation might not be implemented yet, create empty
-		docs := &core.ProviderDocumentation{
-			Name:        schema.Name,
-			Version:     schema.Version,
-			Description: schema.Description,
-		}
-		return &schema, docs, nil
+		return fmt.Errorf("provider binary verification failed (use -insecure-skip-verify for local builds): %w", err)
 	}
-
-	var docs core.ProviderDocumentation
-	if err := json.Unmarshal(docsOutput, &docs); err != nil {
-		return nil, nil, fmt.Errorf("failed to parse documentation: %w", err)
+	if e.config.Verbose {
+		log.Printf("Verified %s (%s) signed by %s", verified.Binary, verified.Digest, verified.KeyID)
 	}
-
-	return &schema, &docs, nil
+	return nil
 }
 
 // extractProviderMetadata extracts provider metadata from schema
@@ -396,25 +577,40 @@ func (e *DocumentationExtractor) generateTags(name, category string) []string {
 	return tags
 }
 
-// extractConfigurationDocs extracts configuration documentation from schema
+// extractConfigurationDocs extracts configuration documentation from schema, with
+// examples generated per authentication mode of the config schema
 func (e *DocumentationExtractor) extractConfigurationDocs(schema *core.Schema) core.ConfigurationDocumentation {
-	return core.ConfigurationDocumentation{
-		Schema: schema.ConfigSchema,
-		Examples: []*core.ConfigurationExample{
+	examples, err := core.GenerateConfigurationExamples(schema.Name, schema.ConfigSchema)
+	if err != nil {
+		log.Printf("Warning: configuration examples left out: %v", err)
+	}
+	if len(examples) == 0 {
+		examples = []*core.ConfigurationExample{
 			{
 				Name:     "basic",
 				Title:    "Basic Configuration",
-				Category: "basic",
+				Category: core.ConfigExampleCategoryBasic,
 				Config:   make(map[string]interface{}),
 			},
-		},
+		}
+	}
+	return core.ConfigurationDocumentation{
+		Schema:     schema.ConfigSchema,
+		Examples:   examples,
+		Validation: core.BuildConfigurationValidation(schema.ConfigSchema),
 	}
 }
 
 // extractResourceDocs extracts resource documentation from schema and provider docs
 func (e *DocumentationExtractor) extractResourceDocs(schema *core.Schema, docs *core.ProviderDocumentation) {
-	// Extract from ResourceTypes (new format)
-	for _, resourceType := range schema.ResourceTypes {
+	// Extract from ResourceTypes (new format), with schema mixins composed in
+	resourceTypes, err := schema.ComposedResourceTypes()
+	if err != nil {
+		log.Printf("Warning: %v; documenting resource types without mixins", err)
+		resourceTypes = schema.ResourceTypes
+	}
+	providerBackup := providerSupportsBackup(schema)
+	for _, resourceType := range resourceTypes {
 		resourceDoc := &core.ResourceDoc{
 			Type:        e.inferResourceType(resourceType.Name),
 			DisplayName: strings.Title(strings.ReplaceAll(resourceType.Name, "_", " ")),
@@ -423,22 +619,37 @@ func (e *DocumentationExtractor) extractResourceDocs(schema *core.Schema, docs *
 			Schema:      resourceType.ConfigSchema,
 			StateSchema: resourceType.StateSchema,
 			Documentation: &core.ResourceDocumentation{
-				Overview: fmt.Sprintf("Manages %s resources", resourceType.Name),
-			},
-			Examples: []*core.ResourceExample{
-				{
-					Name:     "basic",
-					Title:    fmt.Sprintf("Basic %s", resourceType.Name),
-					Category: "basic",
-					HCL:      e.generateBasicExample(resourceType.Name),
-				},
+				Overview:            fmt.Sprintf("Manages %s resources", resourceType.Name),
+				Arguments:           core.BuildArgumentDocsFromSchema(resourceType.ConfigSchema),
+				Attributes:          core.BuildAttributeDocsFromStateSchema(resourceType.StateSchema),
+				AttributesReference: core.BuildAttributeReference(resourceType.Name, resourceType.ConfigSchema, resourceType.StateSchema),
 			},
+			Examples: core.GenerateOperationExamples(
+				e.generateBasicExample(resourceType.Name, resourceType.ConfigSchema),
+				resourceType.Name,
+				exampleOperations(resourceType.Operations, providerBackup),
+			),
+		}
+		for _, example := range resourceDoc.Examples {
+			if example.Operation == core.CapabilityImport {
+				resourceDoc.Documentation.Import = example.Command
+			}
+		}
+		if overview := e.sourceOverview(resourceType.Name); overview != "" {
+			resourceDoc.Documentation.Overview = overview
 		}
 
 		if link := e.canonicalResourceLink(resourceType.Name); link != nil {
 			resourceDoc.Links = append(resourceDoc.Links, *link)
 		}
 
+		if flag, ok := schema.ExperimentalFlag(resourceType.Name); ok {
+			resourceDoc.Experimental = true
+			resourceDoc.FeatureFlag = flag
+			resourceDoc.Documentation.Overview += fmt.Sprintf(" (experimental: enable with experiments = [%q])", flag)
+		}
+		resourceDoc.BackendFeatures = schema.BackendFeatureAttributes(resourceType.Name)
+
 		e.builder.AddResource(resourceType.Name, resourceDoc)
 	}
 
@@ -454,8 +665,16 @@ func (e *DocumentationExtractor) extractResourceDocs(schema *core.Schema, docs *
 				Documentation: &core.ResourceDocumentation{
 					Overview: fmt.Sprintf("Manages %s resources", name),
 				},
+				Examples: core.GenerateOperationExamples(
+					core.GenerateObjectTypeExample("create", name, objType),
+					name,
+					exampleOperations([]string{"create", "read", "update", "delete"}, providerBackup),
+				),
 			}
 
+			if overview := e.sourceOverview(name); overview != "" {
+				resourceDoc.Documentation.Overview = overview
+			}
 			if link := e.canonicalResourceLink(name); link != nil {
 				resourceDoc.Links = append(resourceDoc.Links, *link)
 			}
@@ -476,8 +695,14 @@ func (e *DocumentationExtractor) extractResourceDocs(schema *core.Schema, docs *
 				Documentation: &core.ResourceDocumentation{
 					Overview: fmt.Sprintf("Discovers %s resources", name),
 				},
+				Examples: []*core.ResourceExample{
+					core.GenerateObjectTypeExample("discover", name, objType),
+				},
 			}
 
+			if overview := e.sourceOverview(name); overview != "" {
+				resourceDoc.Documentation.Overview = overview
+			}
 			if link := e.canonicalResourceLink(name); link != nil {
 				resourceDoc.Links = append(resourceDoc.Links, *link)
 			}
@@ -487,6 +712,17 @@ func (e *DocumentationExtractor) extractResourceDocs(schema *core.Schema, docs *
 	}
 }
 
+// sourceOverview returns the whole doc comment of a resource type read with -source
+func (e *DocumentationExtractor) sourceOverview(resourceType string) string {
+	if e.sourceDocs == nil {
+		return ""
+	}
+	if d, ok := e.sourceDocs.Resources[resourceType]; ok {
+		return d.Text
+	}
+	return ""
+}
+
 func (e *DocumentationExtractor) canonicalResourceLink(resourceName string) *core.DocumentationLink {
 	if e.providerMeta.Namespace == "" || e.providerMeta.Name == "" || e.providerMeta.Version == "" {
 		return nil
@@ -512,13 +748,54 @@ func (e *DocumentationExtractor) inferResourceType(name string) string {
 	return "create"
 }
 
-// generateBasicExample generates a basic HCL example for a resource
-func (e *DocumentationExtractor) generateBasicExample(resourceType string) string {
-	return fmt.Sprintf(`create "%s" "example" {
-  name = "example-%s"
-  
-  # Add your configuration here
-}`, resourceType, strings.ReplaceAll(resourceType, "_", "-"))
+// providerSupportsBackup reports whether the provider exposes a backup function, which
+// destroy examples then use to take a backup first
+func providerSupportsBackup(schema *core.Schema) bool {
+	for _, fn := range schema.SupportedFunctions {
+		if strings.Contains(strings.ToLower(fn), core.CapabilityBackup) {
+			return true
+		}
+	}
+	return false
+}
+
+// exampleOperations returns the operations examples are generated for, adding backup
+// when the provider can back up any resource
+func exampleOperations(operations []string, providerBackup bool) []string {
+	if !providerBackup {
+		return operations
+	}
+	return append(append([]string(nil), operations...), core.CapabilityBackup)
+}
+
+// generateBasicExample generates a runnable HCL example for a resource, filling
+// required attributes from its configuration schema
+func (e *DocumentationExtractor) generateBasicExample(resourceType string, configSchema json.RawMessage) *core.ResourceExample {
+	return core.GenerateResourceExample(e.inferResourceType(resourceType), resourceType, configSchema)
+}
+
+// loadPreviousVersions adds the documentation of earlier provider versions from the
+// -previous files and from the existing output file, which this run overwrites
+func (e *DocumentationExtractor) loadPreviousVersions() error {
+	paths := append([]string(nil), e.config.PreviousDocs...)
+	if _, err := os.Stat(e.config.OutputFile); err == nil {
+		paths = append(paths, e.config.OutputFile)
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var previous core.UniversalProviderDocumentation
+		if err := json.Unmarshal(data, &previous); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if e.config.Verbose {
+			log.Printf("Keeping documentation of version %s from %s", previous.Provider.Version, path)
+		}
+		e.builder.AddPreviousVersion(&previous)
+	}
+	return nil
 }
 
 // loadDocumentationFiles loads markdown documentation files
@@ -601,6 +878,18 @@ func (e *DocumentationExtractor) loadExamples() error {
 			return err
 		}
 
+		// Reject examples that would not parse for users
+		if _, err := hcl.Parse(content, path); err != nil {
+			return fmt.Errorf("invalid example: %w", err)
+		}
+
+		if e.config.FormatExamples {
+			if content, err = e.formatExample(path, content); err != nil {
+				return err
+			}
+		}
+		e.lintExample(path, content)
+
 		// Create example from file
 		example := &core.ProviderExample{
 			Name:        strings.TrimSuffix(d.Name(), ".kl"),
@@ -622,6 +911,51 @@ func (e *DocumentationExtractor) loadExamples() error {
 	return nil
 }
 
+// formatExample rewrites an example file in canonical formatting when it differs
+func (e *DocumentationExtractor) formatExample(path string, content []byte) ([]byte, error) {
+	formatted, err := hcl.Format(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format example %s: %w", path, err)
+	}
+	if bytes.Equal(formatted, content) {
+		return content, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, formatted, info.Mode().Perm()); err != nil {
+		return nil, fmt.Errorf("failed to rewrite example %s: %w", path, err)
+	}
+	if e.config.Verbose {
+		log.Printf("Formatted example file: %s", path)
+	}
+	return formatted, nil
+}
+
+// lintExample reports example attributes that the provider schema does not define
+func (e *DocumentationExtractor) lintExample(path string, content []byte) {
+	if e.schema == nil {
+		return
+	}
+
+	schemas := make(hcl.LintSchemas, 2*len(e.schema.ResourceTypes))
+	for _, rt := range e.schema.ResourceTypes {
+		schemas[rt.Name] = rt.ConfigSchema
+		schemas[e.schema.Name+"_"+rt.Name] = rt.ConfigSchema
+	}
+
+	issues, err := hcl.LintSource(content, path, schemas, hcl.LintOptions{SkipFormat: e.config.FormatExamples})
+	if err != nil {
+		log.Printf("Warning: failed to lint example %s: %v", path, err)
+		return
+	}
+	for _, issue := range issues {
+		log.Printf("Warning: %s", issue)
+	}
+}
+
 // inferExampleCategory infers the category of an example from its path
 func (e *DocumentationExtractor) inferExampleCategory(path string) string {
 	path = strings.ToLower(path)
@@ -648,17 +982,31 @@ func (e *DocumentationExtractor) generateMetadata() {
 		log.Printf("Generating metadata")
 	}
 
-	buildInfo := &core.BuildInfo{
-		BuildDate: time.Now().UTC(),
-		GoVersion: runtime.Version(),
-		Platform:  fmt.Sprintf("%s/%s", runtime.GOOS, runtime.GOARCH),
+	// Prefer the provider binary's embedded module list and VCS data (SBOM)
+	buildInfo, err := core.ReadBinaryBuildInfo(e.config.ProviderBinary)
+	if err != nil {
+		if e.config.Verbose {
+			log.Printf("No Go build info in provider binary, using environment: %v", err)
+		}
+		buildInfo = &core.BuildInfo{
+			GoVersion: runtime.Version(),
+			Platform:  fmt.Sprintf("%s/%s", runtime.GOOS, runtime.GOARCH),
+		}
+	}
+	if buildInfo.BuildDate.IsZero() {
+		buildInfo.BuildDate = time.Now().UTC()
 	}
 
 	// Try to get git commit hash
-	if output, err := exec.Command("git", "rev-parse", "HEAD").Output(); err == nil {
-		buildInfo.CommitHash = strings.TrimSpace(string(output))
+	if buildInfo.CommitHash == "" {
+		if output, err := exec.Command("git", "rev-parse", "HEAD").Output(); err == nil {
+			buildInfo.CommitHash = strings.TrimSpace(string(output))
+		}
 	}
 
+	// docs-gen runs inside the release pipeline, so CI identity is build-time provenance
+	buildInfo.Provenance = core.ProvenanceFromEnvironment(buildInfo.Provenance)
+
 	metadata := core.RegistryMetadata{
 		GeneratedAt:      time.Now().UTC(),
 		GeneratorVersion: version,
@@ -732,6 +1080,14 @@ func (e *DocumentationExtractor) generateOutput() error {
 	docs.Metadata.Checksum = checksum
 	docs.Metadata.Stats.TotalSize = len(jsonData)
 
+	if e.config.SigningKey != "" {
+		attestation, err := e.attest(docs)
+		if err != nil {
+			return fmt.Errorf("failed to attest documentation: %w", err)
+		}
+		docs.Metadata.Attestation = attestation
+	}
+
 	// Generate final JSON with pretty printing
 	finalData, err := json.MarshalIndent(docs, "", "  ")
 	if err != nil {
@@ -753,3 +1109,67 @@ func (e *DocumentationExtractor) generateOutput() error {
 
 	return nil
 }
+
+// attest signs the digests of the provider binary, its schema and the documentation
+func (e *DocumentationExtractor) attest(docs *core.UniversalProviderDocumentation) (*core.Attestation, error) {
+	key, err := core.LoadSigningKey(e.config.SigningKey)
+	if err != nil {
+		return nil, err
+	}
+
+	subject := core.AttestationSubject{
+		Provider: docs.Provider.Name,
+		Version:  docs.Provider.Version,
+	}
+	if subject.BinaryDigest, err = core.DigestFile(e.config.ProviderBinary); err != nil {
+		return nil, fmt.Errorf("failed to digest provider binary: %w", err)
+	}
+	if e.schema != nil {
+		if subject.SchemaDigest, err = core.DigestSchema(e.schema); err != nil {
+			return nil, fmt.Errorf("failed to digest schema: %w", err)
+		}
+	}
+	if subject.DocsDigest, err = core.DigestDocumentation(docs); err != nil {
+		return nil, fmt.Errorf("failed to digest documentation: %w", err)
+	}
+
+	if e.config.Verbose {
+		log.Printf("Signing attestation for %s %s", subject.Provider, subject.Version)
+	}
+	return core.SignAttestation(subject, key, e.config.KeyID)
+}
+
+// Verify checks the attestation embedded in a generated documentation file. When a
+// provider binary is configured, its binary and schema digests are verified as well.
+func (e *DocumentationExtractor) Verify() error {
+	key, err := core.LoadVerificationKey(e.config.VerifyKey)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(e.config.VerifyFile)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", e.config.VerifyFile, err)
+	}
+	var docs core.UniversalProviderDocumentation
+	if err := json.Unmarshal(data, &docs); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", e.config.VerifyFile, err)
+	}
+	if docs.Metadata.Attestation == nil {
+		return fmt.Errorf("%s has no attestation", e.config.VerifyFile)
+	}
+
+	attestation := docs.Metadata.Attestation
+	var schema *core.Schema
+	if e.config.ProviderBinary != "" {
+		// never execute a binary the attestation does not cover
+		if err := attestation.Verify(key, e.config.ProviderBinary, nil, nil); err != nil {
+			return fmt.Errorf("refusing to execute provider binary: %w", err)
+		}
+		if schema, _, err = e.executeProviderForDocs(); err != nil {
+			return fmt.Errorf("failed to load provider schema: %w", err)
+		}
+	}
+
+	return attestation.Verify(key, e.config.ProviderBinary, schema, &docs)
+}
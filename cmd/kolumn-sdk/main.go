@@ -0,0 +1,192 @@
+// kolumn-sdk bundles developer checks for provider authors. The vet command lints a
+// provider binary against the provider contract before it is published; the anonymize
+// command prepares discovery and state dumps for bug reports.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+	"github.com/schemabounce/kolumn/sdk/discover"
+	"github.com/schemabounce/kolumn/sdk/helpers/anonymize"
+	"github.com/schemabounce/kolumn/sdk/pdk"
+	"github.com/schemabounce/kolumn/sdk/probe"
+	"github.com/schemabounce/kolumn/sdk/state"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	switch os.Args[1] {
+	case "vet":
+		os.Exit(vet(os.Args[2:]))
+	case "anonymize":
+		os.Exit(anonymizeDump(os.Args[2:]))
+	case "help", "-h", "--help":
+		usage()
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown command %q\n\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, `kolumn-sdk - provider development checks
+
+USAGE:
+    kolumn-sdk vet -provider BINARY [-source DIR] [-format text|json] [-strict]
+    kolumn-sdk anonymize [-kind scan|state|json] [-key KEY] [-mapping FILE] [DUMP]
+
+COMMANDS:
+    vet          Check the provider's schema and registered handlers against the contract,
+                 and with -source its code for deprecated Go plugin loading
+    anonymize    Replace names and identifiers in a scan or state dump with stable
+                 pseudonyms so it can be shared in a bug report
+`)
+}
+
+// vet runs the provider's --vet mode and reports the findings. Exit status is 1 when the
+// provider violates the contract, or has warnings with -strict.
+func vet(args []string) int {
+	fs := flag.NewFlagSet("vet", flag.ContinueOnError)
+	binary := fs.String("provider", "", "Path to the provider binary (required)")
+	format := fs.String("format", "text", "Output format: text or json")
+	strict := fs.Bool("strict", false, "Fail on warnings as well as errors")
+	source := fs.String("source", "", "Provider source directory to check for deprecated Go plugin loading")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *binary == "" {
+		fmt.Fprintf(os.Stderr, "Error: -provider flag is required\n\n")
+		fs.Usage()
+		return 2
+	}
+
+	report, err := lintProvider(*binary, *source)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if *format == "json" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Println(string(data))
+	} else {
+		printReport(report)
+	}
+
+	if report.HasErrors() || (*strict && report.Warnings() > 0) {
+		return 1
+	}
+	return 0
+}
+
+// lintProvider asks the binary to lint itself and, with a source directory, adds a
+// finding for every file loading providers with the deprecated Go plugin package
+func lintProvider(binary, source string) (*core.ContractReport, error) {
+	report, err := probe.New(binary, probe.Options{}).Vet(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to run %s %s: %w", binary, pdk.FlagVet, err)
+	}
+	if source != "" {
+		findings, err := probe.FindPluginLoading(source)
+		if err != nil {
+			return nil, err
+		}
+		report.Findings = append(report.Findings, findings...)
+	}
+	return report, nil
+}
+
+func printReport(r *core.ContractReport) {
+	fmt.Printf("Provider %s %s\n", r.Provider, r.Version)
+	if !r.HandlersInspected {
+		fmt.Println("Handlers not inspected: the provider does not implement core.ContractInspector")
+	}
+	for _, f := range r.Findings {
+		fmt.Printf("  %s\n", f)
+	}
+	fmt.Printf("%d errors, %d warnings\n", r.Errors(), r.Warnings())
+}
+
+// anonymizeDump reads a scan response, state or other JSON document from a file or
+// stdin and writes the anonymized copy to stdout. The pseudonym mapping is only written
+// when asked for, to a separate file that stays with the reporter.
+func anonymizeDump(args []string) int {
+	fs := flag.NewFlagSet("anonymize", flag.ContinueOnError)
+	kind := fs.String("kind", "scan", "Dump kind: scan, state or json")
+	key := fs.String("key", "", "Pseudonym key; reuse it to correlate dumps (random when empty)")
+	mapping := fs.String("mapping", "", "Write the pseudonym mapping to this file (do not share it)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	var data []byte
+	var err error
+	if fs.NArg() > 0 {
+		data, err = os.ReadFile(fs.Arg(0))
+	} else {
+		data, err = io.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	a := anonymize.New(anonymize.Options{Key: []byte(*key)})
+	var out interface{}
+	switch *kind {
+	case "scan":
+		var resp discover.ScanResponse
+		if err = json.Unmarshal(data, &resp); err == nil {
+			out, err = discover.AnonymizeScanResponse(&resp, a)
+		}
+	case "state":
+		var st state.UniversalState
+		if err = json.Unmarshal(data, &st); err == nil {
+			out, err = state.AnonymizeState(&st, a)
+		}
+	case "json":
+		var v interface{}
+		if err = json.Unmarshal(data, &v); err == nil {
+			out = a.Value(v)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown kind %q, use scan, state or json\n", *kind)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if *mapping != "" {
+		data, err := json.MarshalIndent(a.Mapping(), "", "  ")
+		if err == nil {
+			err = os.WriteFile(*mapping, data, 0o600)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+	data, err = json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Println(string(data))
+	return 0
+}
@@ -0,0 +1,184 @@
+// kolumn-upgrade compares an installed provider with the latest release published in
+// the provider registry and reports what an upgrade will change for the current state.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+	"github.com/schemabounce/kolumn/sdk/helpers/sandbox"
+	"github.com/schemabounce/kolumn/sdk/probe"
+	"github.com/schemabounce/kolumn/sdk/state"
+)
+
+// Config holds the command-line configuration
+type Config struct {
+	ProviderBinary string
+	StateFile      string
+	RegistryURL    string
+	Namespace      string
+	Version        string
+	Format         string
+	FailOnActions  bool
+	Timeout        time.Duration
+	Sandbox        bool
+	SandboxAllow   string
+}
+
+func main() {
+	config := parseFlags()
+
+	report, err := run(config)
+	if err != nil {
+		log.Fatalf("Upgrade check failed: %v", err)
+	}
+
+	if config.Format == "json" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			log.Fatalf("Failed to encode report: %v", err)
+		}
+		fmt.Println(string(data))
+	} else {
+		printReport(report)
+	}
+
+	if config.FailOnActions && report.HasActions() {
+		os.Exit(2)
+	}
+}
+
+func parseFlags() *Config {
+	config := &Config{}
+
+	flag.StringVar(&config.ProviderBinary, "provider", "", "Path to the installed provider binary (required)")
+	flag.StringVar(&config.StateFile, "state", "", "Path to a Kolumn state file to check usage against")
+	flag.StringVar(&config.RegistryURL, "registry", core.DefaultRegistryURL, "Provider registry URL")
+	flag.StringVar(&config.Namespace, "namespace", "", "Provider namespace (inferred from the binary name by default)")
+	flag.StringVar(&config.Version, "version", "latest", "Registry version to compare against")
+	flag.StringVar(&config.Format, "format", "text", "Output format: text or json")
+	flag.BoolVar(&config.FailOnActions, "fail-on-actions", false, "Exit with status 2 when the upgrade requires user action")
+	flag.DurationVar(&config.Timeout, "timeout", 30*time.Second, "Registry request timeout")
+	flag.BoolVar(&config.Sandbox, "sandbox", false, "Run the provider binary sandboxed: restricted environment, private working directory, no network")
+	flag.StringVar(&config.SandboxAllow, "sandbox-allow", "", "Comma-separated capabilities granted to the sandboxed provider: network, env, workdir, home")
+	flag.Parse()
+
+	if config.ProviderBinary == "" {
+		fmt.Fprintf(os.Stderr, "Error: -provider flag is required\n\n")
+		flag.Usage()
+		os.Exit(1)
+	}
+	return config
+}
+
+// run loads the installed schema, state usage and registry docs and builds the report
+func run(config *Config) (*core.UpgradeReport, error) {
+	schema, err := loadSchema(config)
+	if err != nil {
+		return nil, err
+	}
+
+	namespace, name := providerIdentity(config.ProviderBinary)
+	if config.Namespace != "" {
+		namespace = config.Namespace
+	}
+
+	var usage []core.ResourceUsage
+	if config.StateFile != "" {
+		data, err := os.ReadFile(config.StateFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read state: %w", err)
+		}
+		var st state.UniversalState
+		if err := json.Unmarshal(data, &st); err != nil {
+			return nil, fmt.Errorf("failed to parse state: %w", err)
+		}
+		usage = state.ResourceUsage(&st, "")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.Timeout)
+	defer cancel()
+
+	latest, err := core.FetchRegistryDocumentation(ctx, nil, config.RegistryURL, namespace, name, config.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	return core.BuildUpgradeReport(schema, latest, usage), nil
+}
+
+// loadSchema queries the provider for its schema, sandboxed when -sandbox is set
+func loadSchema(config *Config) (*core.Schema, error) {
+	var opts probe.Options
+	if config.Sandbox {
+		caps, err := sandbox.ParseCapabilities(config.SandboxAllow)
+		if err != nil {
+			return nil, err
+		}
+		opts.Sandbox = &sandbox.Options{Capabilities: caps}
+	}
+	schema, err := probe.New(config.ProviderBinary, opts).Schema(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", config.ProviderBinary, err)
+	}
+	return schema, nil
+}
+
+// providerIdentity mirrors kolumn-docs-gen's namespace inference from the binary name
+func providerIdentity(binary string) (namespace, name string) {
+	base := filepath.Base(binary)
+	base = strings.TrimSuffix(base, filepath.Ext(base))
+	if strings.HasPrefix(base, "kolumn-provider-") {
+		return "kolumn-official", strings.TrimPrefix(base, "kolumn-provider-")
+	}
+	return "community", base
+}
+
+func printReport(r *core.UpgradeReport) {
+	fmt.Printf("Provider %s: installed %s, latest %s\n", r.Provider, r.CurrentVersion, r.LatestVersion)
+	if !r.UpgradeAvailable {
+		fmt.Println("Already up to date.")
+	}
+
+	for _, d := range r.Deprecated {
+		fmt.Printf("\nDEPRECATED %s (%d in use)\n", d.ResourceType, len(d.Instances))
+		if d.Deprecation.RemovalDate != "" {
+			fmt.Printf("  removal date: %s\n", d.Deprecation.RemovalDate)
+		}
+		if d.Deprecation.MigrationGuide != "" {
+			fmt.Printf("  migration guide: %s\n", d.Deprecation.MigrationGuide)
+		}
+		fmt.Printf("  instances: %s\n", strings.Join(d.Instances, ", "))
+	}
+
+	for _, rm := range r.Removed {
+		fmt.Printf("\nREMOVED %s is no longer provided (%s)\n", rm.ResourceType, strings.Join(rm.Instances, ", "))
+	}
+
+	for _, rename := range r.ConfigRenames {
+		fmt.Printf("\nRENAME %s: %s -> %s (%s)\n", rename.ResourceType, rename.From, rename.To, strings.Join(rename.Instances, ", "))
+	}
+
+	for _, m := range r.StateMigrations {
+		fmt.Printf("\nSTATE MIGRATION %s v%d -> v%d (%d resources)\n", m.ResourceType, m.FromVersion, m.ToVersion, len(m.Instances))
+		for _, step := range m.Steps {
+			fmt.Printf("  v%d -> v%d: %s\n", step.FromVersion, step.ToVersion, step.Description)
+		}
+	}
+
+	for _, change := range r.BreakingChanges {
+		fmt.Printf("\nBREAKING %s: %s\n", change.Version, change.Description)
+	}
+
+	if len(r.NewResources) > 0 {
+		fmt.Printf("\nNew resources: %s\n", strings.Join(r.NewResources, ", "))
+	}
+}
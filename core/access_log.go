@@ -0,0 +1,306 @@
+// Package core provides data access logging for read operations
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Access log defaults
+const (
+	DefaultAccessLogBufferSize    = 1024
+	DefaultAccessLogBatchSize     = 100
+	DefaultAccessLogFlushInterval = 5 * time.Second
+)
+
+// AccessLogEntry records one read of governed data
+type AccessLogEntry struct {
+	Timestamp       time.Time    `json:"timestamp"`
+	RequestID       string       `json:"request_id,omitempty"`
+	Tenant          string       `json:"tenant,omitempty"`
+	Operation       string       `json:"operation"`
+	ResourceType    string       `json:"resource_type"`
+	Resource        string       `json:"resource"`
+	User            *UserContext `json:"user,omitempty"`
+	Columns         []string     `json:"columns,omitempty"`         // columns read
+	Classifications []string     `json:"classifications,omitempty"` // classifications touched
+	Rows            int64        `json:"rows,omitempty"`
+	Outcome         string       `json:"outcome"` // success, failure
+	Error           string       `json:"error,omitempty"`
+}
+
+// AccessEvent describes a completed read operation to an AccessLogHook
+type AccessEvent struct {
+	Function     string
+	ResourceType string
+	Input        []byte
+	Output       []byte
+	Err          error
+}
+
+// AccessLogHook is implemented by providers to describe what a read touched. The
+// returned entry needs only Resource, Columns, Classifications and Rows; the SDK
+// fills in the request, user, tenant and outcome. Returning nil skips logging.
+type AccessLogHook interface {
+	DescribeAccess(ctx context.Context, event *AccessEvent) (*AccessLogEntry, error)
+}
+
+// AccessLogHookFunc adapts a function to AccessLogHook
+type AccessLogHookFunc func(ctx context.Context, event *AccessEvent) (*AccessLogEntry, error)
+
+// DescribeAccess implements AccessLogHook
+func (f AccessLogHookFunc) DescribeAccess(ctx context.Context, event *AccessEvent) (*AccessLogEntry, error) {
+	return f(ctx, event)
+}
+
+// AuditSink receives batches of access log entries
+type AuditSink interface {
+	WriteAccessLogs(ctx context.Context, entries []*AccessLogEntry) error
+}
+
+// AccessLogWriterConfig configures an AccessLogWriter
+type AccessLogWriterConfig struct {
+	BufferSize    int           // entries held before new ones are dropped
+	BatchSize     int           // entries per sink write
+	FlushInterval time.Duration // how often buffered entries are written
+}
+
+// AccessLogWriter buffers access log entries and writes them to an AuditSink in
+// batches from a background goroutine, so logging never blocks a read. When the
+// buffer is full new entries are dropped and counted.
+type AccessLogWriter struct {
+	sink      AuditSink
+	batchSize int
+	interval  time.Duration
+
+	entries chan *AccessLogEntry
+	flush   chan chan error
+	done    chan struct{}
+
+	closeOnce sync.Once
+	dropped   atomic.Int64
+	failed    atomic.Int64
+}
+
+// ErrAccessLogClosed is returned when writing to a closed AccessLogWriter
+var ErrAccessLogClosed = errors.New("access log writer is closed")
+
+// NewAccessLogWriter starts a writer delivering to sink
+func NewAccessLogWriter(sink AuditSink, config AccessLogWriterConfig) *AccessLogWriter {
+	if config.BufferSize <= 0 {
+		config.BufferSize = DefaultAccessLogBufferSize
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultAccessLogBatchSize
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = DefaultAccessLogFlushInterval
+	}
+
+	w := &AccessLogWriter{
+		sink:      sink,
+		batchSize: config.BatchSize,
+		interval:  config.FlushInterval,
+		entries:   make(chan *AccessLogEntry, config.BufferSize),
+		flush:     make(chan chan error),
+		done:      make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Write queues an entry without blocking; it reports false when the entry was dropped
+func (w *AccessLogWriter) Write(entry *AccessLogEntry) bool {
+	select {
+	case <-w.done:
+		w.dropped.Add(1)
+		return false
+	default:
+	}
+	select {
+	case w.entries <- entry:
+		return true
+	default:
+		w.dropped.Add(1)
+		return false
+	}
+}
+
+// Flush writes every queued entry and returns the first sink error
+func (w *AccessLogWriter) Flush(ctx context.Context) error {
+	result := make(chan error, 1)
+	select {
+	case w.flush <- result:
+	case <-w.done:
+		return ErrAccessLogClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close flushes queued entries and stops the writer
+func (w *AccessLogWriter) Close(ctx context.Context) error {
+	err := w.Flush(ctx)
+	w.closeOnce.Do(func() { close(w.done) })
+	if errors.Is(err, ErrAccessLogClosed) {
+		return nil
+	}
+	return err
+}
+
+// Dropped returns the number of entries discarded because the buffer was full
+func (w *AccessLogWriter) Dropped() int64 {
+	return w.dropped.Load()
+}
+
+// Failed returns the number of entries the sink rejected
+func (w *AccessLogWriter) Failed() int64 {
+	return w.failed.Load()
+}
+
+func (w *AccessLogWriter) run() {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	batch := make([]*AccessLogEntry, 0, w.batchSize)
+	write := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		err := w.sink.WriteAccessLogs(ctx, batch)
+		if err != nil {
+			w.failed.Add(int64(len(batch)))
+		}
+		batch = make([]*AccessLogEntry, 0, w.batchSize)
+		return err
+	}
+	drain := func() error {
+		var first error
+		for {
+			select {
+			case entry := <-w.entries:
+				batch = append(batch, entry)
+				if len(batch) >= w.batchSize {
+					if err := write(); err != nil && first == nil {
+						first = err
+					}
+				}
+			default:
+				if err := write(); err != nil && first == nil {
+					first = err
+				}
+				return first
+			}
+		}
+	}
+
+	for {
+		select {
+		case entry := <-w.entries:
+			batch = append(batch, entry)
+			if len(batch) >= w.batchSize {
+				_ = write()
+			}
+		case <-ticker.C:
+			_ = write()
+		case result := <-w.flush:
+			result <- drain()
+		case <-w.done:
+			_ = drain()
+			return
+		}
+	}
+}
+
+// AccessLogger connects a provider's AccessLogHook to an AccessLogWriter
+type AccessLogger struct {
+	Hook   AccessLogHook
+	Writer *AccessLogWriter
+
+	// Always logs every read; otherwise only requests whose security requirements
+	// ask for access logging are logged
+	Always bool
+}
+
+// accessLoggedFunctions are the dispatcher functions that read data
+var accessLoggedFunctions = map[string]bool{
+	"ReadResource": true,
+}
+
+// SetAccessLogger enables access logging of read operations
+func (d *UnifiedDispatcher) SetAccessLogger(logger *AccessLogger) {
+	d.accessLogger = logger
+}
+
+// recordAccess asks the provider's hook to describe a completed read and queues the entry
+func (d *UnifiedDispatcher) recordAccess(ctx context.Context, function string, input, output []byte, callErr error) {
+	logger := d.accessLogger
+	if logger == nil || logger.Hook == nil || logger.Writer == nil || !accessLoggedFunctions[function] {
+		return
+	}
+
+	var req struct {
+		ResourceType string `json:"resource_type"`
+		Name         string `json:"name"`
+		Metadata     struct {
+			UserContext       *UserContext `json:"user_context"`
+			GovernanceContext struct {
+				RequestContext *RequestGovernanceContext `json:"request_context"`
+			} `json:"governance_context"`
+		} `json:"metadata"`
+	}
+	if json.Unmarshal(input, &req) != nil {
+		return
+	}
+	rc := req.Metadata.GovernanceContext.RequestContext
+	required := rc != nil && rc.SecurityRequirements != nil && rc.SecurityRequirements.AccessLogging
+	if !required && !logger.Always {
+		return
+	}
+
+	entry, err := logger.Hook.DescribeAccess(ctx, &AccessEvent{
+		Function:     function,
+		ResourceType: req.ResourceType,
+		Input:        input,
+		Output:       output,
+		Err:          callErr,
+	})
+	if err != nil || entry == nil {
+		return
+	}
+
+	entry.Timestamp = clock.Now().UTC()
+	entry.Operation = function
+	if entry.ResourceType == "" {
+		entry.ResourceType = req.ResourceType
+	}
+	if entry.Resource == "" {
+		entry.Resource = req.Name
+	}
+	if tenant, ok := TenantFromContext(ctx); ok {
+		entry.Tenant = tenant
+	}
+	_, entry.User = requestUserContext(input)
+	if rc != nil {
+		entry.RequestID = rc.RequestID
+		entry.Classifications = uniqueSorted(append(entry.Classifications, rc.AppliedClassifications...))
+	}
+	entry.Outcome = "success"
+	if callErr != nil {
+		entry.Outcome = "failure"
+		entry.Error = callErr.Error()
+	}
+	logger.Writer.Write(entry)
+}
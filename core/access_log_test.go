@@ -0,0 +1,107 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type memoryAuditSink struct {
+	mu      sync.Mutex
+	batches [][]*AccessLogEntry
+	fail    bool
+}
+
+func (s *memoryAuditSink) WriteAccessLogs(ctx context.Context, entries []*AccessLogEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fail {
+		return errors.New("sink unavailable")
+	}
+	s.batches = append(s.batches, entries)
+	return nil
+}
+
+func (s *memoryAuditSink) entries() []*AccessLogEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var all []*AccessLogEntry
+	for _, b := range s.batches {
+		all = append(all, b...)
+	}
+	return all
+}
+
+type readRegistry struct{}
+
+func (readRegistry) CallHandler(ctx context.Context, objectType, method string, input []byte) ([]byte, error) {
+	return []byte(`{"rows":[{"email":"a@example.com"}]}`), nil
+}
+
+func (readRegistry) GetObjectTypes() map[string]*ObjectType {
+	return map[string]*ObjectType{"table": {Name: "table"}}
+}
+
+// TestAccessLogWriterBatching validates batching, flush and drop accounting
+func TestAccessLogWriterBatching(t *testing.T) {
+	sink := &memoryAuditSink{}
+	w := NewAccessLogWriter(sink, AccessLogWriterConfig{BatchSize: 2, FlushInterval: time.Hour})
+
+	for i := 0; i < 5; i++ {
+		w.Write(&AccessLogEntry{Resource: "users"})
+	}
+	if err := w.Flush(context.Background()); err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
+	if got := len(sink.entries()); got != 5 {
+		t.Errorf("expected 5 entries delivered, got %d", got)
+	}
+	if err := w.Close(context.Background()); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+	if w.Write(&AccessLogEntry{}) || w.Dropped() != 1 {
+		t.Errorf("expected writes after close to be dropped")
+	}
+}
+
+// TestDispatcherAccessLogging validates that reads requiring access logging are recorded
+func TestDispatcherAccessLogging(t *testing.T) {
+	sink := &memoryAuditSink{}
+	writer := NewAccessLogWriter(sink, AccessLogWriterConfig{FlushInterval: time.Hour})
+	defer writer.Close(context.Background())
+
+	hook := AccessLogHookFunc(func(ctx context.Context, event *AccessEvent) (*AccessLogEntry, error) {
+		return &AccessLogEntry{Columns: []string{"email"}, Classifications: []string{"pii"}, Rows: 1}, nil
+	})
+	d := NewUnifiedDispatcher(readRegistry{}, nil)
+	d.SetAccessLogger(&AccessLogger{Hook: hook, Writer: writer})
+
+	logged := []byte(`{"resource_type":"table","name":"users","metadata":{"governance_context":{"request_context":{
+		"request_id":"req-1","applied_classifications":["gdpr"],
+		"user_context":{"username":"alice"},
+		"security_requirements":{"access_logging":true}}}}}`)
+	unlogged := []byte(`{"resource_type":"table","name":"orders"}`)
+
+	for _, input := range [][]byte{logged, unlogged} {
+		if _, err := d.Dispatch(context.Background(), "ReadResource", input); err != nil {
+			t.Fatalf("dispatch failed: %v", err)
+		}
+	}
+	if err := writer.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := sink.entries()
+	if len(entries) != 1 {
+		t.Fatalf("expected only the read requiring access logging to be recorded, got %d", len(entries))
+	}
+	e := entries[0]
+	if e.Resource != "users" || e.RequestID != "req-1" || e.User == nil || e.User.Username != "alice" || e.Outcome != "success" {
+		t.Errorf("unexpected entry %+v", e)
+	}
+	if len(e.Classifications) != 2 || e.Classifications[0] != "gdpr" || e.Classifications[1] != "pii" {
+		t.Errorf("expected merged classifications, got %v", e.Classifications)
+	}
+}
@@ -0,0 +1,154 @@
+// Package core provides tracking of asynchronous backend operations
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/helpers/security"
+)
+
+// Operation states reported by GetOperationStatus
+const (
+	OperationPending   = "pending"
+	OperationRunning   = "running"
+	OperationSucceeded = "succeeded"
+	OperationFailed    = "failed"
+	OperationCancelled = "cancelled"
+)
+
+// OperationRef identifies a long-running backend operation a handler started instead of
+// finishing the change itself, e.g. a BigQuery job or a Snowflake task run. Handlers
+// return it from Create, Update or Delete; Kolumn records it in state and polls it with
+// GetOperationStatus until it completes, also after a provider restart.
+type OperationRef struct {
+	ID           string                 `json:"id"`             // backend operation ID
+	Kind         string                 `json:"kind,omitempty"` // e.g. bigquery_job
+	ResourceType string                 `json:"resource_type"`
+	ResourceID   string                 `json:"resource_id,omitempty"`
+	Name         string                 `json:"name,omitempty"`
+	Action       string                 `json:"action,omitempty"` // create, update or delete
+	StartedAt    time.Time              `json:"started_at"`
+	PollInterval time.Duration          `json:"poll_interval,omitempty"` // suggested delay between polls
+	Metadata     map[string]interface{} `json:"metadata,omitempty"`      // whatever the handler needs to poll
+}
+
+// Key identifies the operation across resource types
+func (r OperationRef) Key() string {
+	return r.ResourceType + "/" + r.ID
+}
+
+// Validate checks the fields needed to poll the operation
+func (r OperationRef) Validate() error {
+	if r.ID == "" {
+		return fmt.Errorf("operation id is required")
+	}
+	if r.ResourceType == "" {
+		return fmt.Errorf("operation %s: resource_type is required", r.ID)
+	}
+	return nil
+}
+
+// OperationStatus is the progress of an asynchronous operation. Once it succeeds,
+// ResourceState and Outputs are recorded for the resource like a synchronous response.
+type OperationStatus struct {
+	Operation     OperationRef           `json:"operation"`
+	State         string                 `json:"state"`
+	Progress      float64                `json:"progress,omitempty"` // 0 to 1, when the backend reports it
+	Message       string                 `json:"message,omitempty"`
+	Error         string                 `json:"error,omitempty"`
+	ResourceState map[string]interface{} `json:"resource_state,omitempty"`
+	Outputs       map[string]Output      `json:"outputs,omitempty"`
+	UpdatedAt     time.Time              `json:"updated_at"`
+}
+
+// Done reports whether the operation reached a final state
+func (s *OperationStatus) Done() bool {
+	switch s.State {
+	case OperationSucceeded, OperationFailed, OperationCancelled:
+		return true
+	}
+	return false
+}
+
+// GetOperationStatusRequest is the input of the GetOperationStatus function.
+// ResourceType defaults to the operation's resource type.
+type GetOperationStatusRequest struct {
+	ResourceType string                 `json:"resource_type,omitempty"`
+	Operation    OperationRef           `json:"operation"`
+	Metadata     map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// handleGetOperationStatus asks the resource type's handler for the progress of an
+// asynchronous operation
+func (d *UnifiedDispatcher) handleGetOperationStatus(ctx context.Context, input []byte) ([]byte, error) {
+	var req GetOperationStatusRequest
+	if err := security.SafeUnmarshal(input, &req); err != nil {
+		return nil, security.NewSecureError(
+			"invalid request format",
+			fmt.Sprintf("operation status request unmarshal failed: %v", err),
+			"INVALID_REQUEST",
+		)
+	}
+	if req.Operation.ResourceType == "" {
+		req.Operation.ResourceType = req.ResourceType
+	}
+	if req.ResourceType != "" && req.ResourceType != req.Operation.ResourceType {
+		return nil, security.NewSecureError(
+			"invalid request format",
+			fmt.Sprintf("resource_type %s does not match operation resource type %s", req.ResourceType, req.Operation.ResourceType),
+			"INVALID_REQUEST",
+		)
+	}
+	if err := req.Operation.Validate(); err != nil {
+		return nil, security.NewSecureError(
+			"invalid request format",
+			err.Error(),
+			"INVALID_REQUEST",
+		)
+	}
+	resourceType := req.Operation.ResourceType
+	if err := security.ValidateObjectType(resourceType); err != nil {
+		return nil, security.NewSecureError(
+			"invalid resource type",
+			fmt.Sprintf("resource type validation failed: %v", err),
+			"INVALID_RESOURCE_TYPE",
+		)
+	}
+	if d.authorizer != nil {
+		_, user := requestUserContext(input)
+		if err := d.authorizer.Authorize(user, "ReadResource", resourceType); err != nil {
+			return nil, err
+		}
+	}
+
+	pollInput, err := json.Marshal(map[string]interface{}{
+		"object_type": resourceType,
+		"operation":   req.Operation,
+	})
+	if err != nil {
+		return nil, security.NewSecureError(
+			"request transformation failed",
+			fmt.Sprintf("failed to transform request: %v", err),
+			"TRANSFORMATION_FAILED",
+		)
+	}
+	output, err := d.callCreateRegistry(ctx, resourceType, "poll_operation", pollInput)
+	if err != nil {
+		return nil, err
+	}
+
+	var status OperationStatus
+	if err := json.Unmarshal(output, &status); err != nil {
+		return nil, fmt.Errorf("invalid operation status response: %w", err)
+	}
+	if status.Operation.ID == "" {
+		status.Operation = req.Operation
+	}
+	if status.UpdatedAt.IsZero() {
+		status.UpdatedAt = clock.Now()
+	}
+	return json.Marshal(&status)
+}
@@ -0,0 +1,65 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/types"
+)
+
+// jobRegistry reports every operation as running and records what it was asked to poll
+type jobRegistry struct {
+	method string
+	polled OperationRef
+}
+
+func (r *jobRegistry) GetObjectTypes() map[string]*ObjectType { return nil }
+
+func (r *jobRegistry) CallHandler(ctx context.Context, objectType, method string, input []byte) ([]byte, error) {
+	r.method = method
+	var req struct {
+		Operation OperationRef `json:"operation"`
+	}
+	_ = json.Unmarshal(input, &req)
+	r.polled = req.Operation
+	return json.Marshal(OperationStatus{State: OperationRunning, Progress: 0.25})
+}
+
+// TestGetOperationStatus validates operation status requests reach the resource type's handler
+func TestGetOperationStatus(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	defer SetClock(types.ClockFunc(func() time.Time { return now }))()
+
+	registry := &jobRegistry{}
+	d := NewUnifiedDispatcher(registry, nil)
+	input := []byte(`{"operation":{"id":"job-42","kind":"bigquery_job","resource_type":"dataset","action":"create"}}`)
+	output, err := d.Dispatch(context.Background(), "GetOperationStatus", input)
+	if err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+	var status OperationStatus
+	if err := json.Unmarshal(output, &status); err != nil {
+		t.Fatal(err)
+	}
+	if registry.method != "poll_operation" || registry.polled.ID != "job-42" {
+		t.Errorf("registry polled %s %+v", registry.method, registry.polled)
+	}
+	if status.State != OperationRunning || status.Done() {
+		t.Errorf("unexpected status %+v", status)
+	}
+	if status.Operation.Key() != "dataset/job-42" || !status.UpdatedAt.Equal(now) {
+		t.Errorf("status should carry the operation and poll time: %+v", status)
+	}
+
+	for _, bad := range []string{
+		`{"operation":{"resource_type":"dataset"}}`,
+		`{"operation":{"id":"job-42"}}`,
+		`{"resource_type":"table","operation":{"id":"job-42","resource_type":"dataset"}}`,
+	} {
+		if _, err := d.Dispatch(context.Background(), "GetOperationStatus", []byte(bad)); err == nil {
+			t.Errorf("expected %s to be rejected", bad)
+		}
+	}
+}
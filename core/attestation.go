@@ -0,0 +1,234 @@
+// Package core provides hash-bound attestations for provider schema and documentation
+package core
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// AttestationVersion is the version of the attestation payload format
+const AttestationVersion = "1"
+
+// AttestationAlgorithmEd25519 is the only supported signature algorithm
+const AttestationAlgorithmEd25519 = "ed25519"
+
+// AttestationSubject binds a provider release to the digests of its artifacts
+type AttestationSubject struct {
+	Provider     string `json:"provider"`
+	Version      string `json:"version"`
+	BinaryDigest string `json:"binary_digest,omitempty"` // sha256:<hex> of the provider binary
+	SchemaDigest string `json:"schema_digest,omitempty"` // sha256:<hex> of the canonical schema JSON
+	DocsDigest   string `json:"docs_digest,omitempty"`   // sha256:<hex> of the documentation, attestation excluded
+}
+
+// Attestation is a signed AttestationSubject
+type Attestation struct {
+	Version   string             `json:"version"`
+	Subject   AttestationSubject `json:"subject"`
+	Algorithm string             `json:"algorithm"`
+	KeyID     string             `json:"key_id,omitempty"`
+	SignedAt  time.Time          `json:"signed_at"`
+	Signature string             `json:"signature"` // base64 signature over the canonical payload
+}
+
+// attestationPayload is the exact structure that is signed
+type attestationPayload struct {
+	Version  string             `json:"version"`
+	Subject  AttestationSubject `json:"subject"`
+	KeyID    string             `json:"key_id,omitempty"`
+	SignedAt time.Time          `json:"signed_at"`
+}
+
+// payload returns the canonical bytes covered by the signature
+func (a *Attestation) payload() ([]byte, error) {
+	return json.Marshal(attestationPayload{
+		Version:  a.Version,
+		Subject:  a.Subject,
+		KeyID:    a.KeyID,
+		SignedAt: a.SignedAt.UTC(),
+	})
+}
+
+// SignAttestation signs subject with the provider's release key
+func SignAttestation(subject AttestationSubject, key ed25519.PrivateKey, keyID string) (*Attestation, error) {
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid ed25519 private key size %d", len(key))
+	}
+
+	att := &Attestation{
+		Version:   AttestationVersion,
+		Subject:   subject,
+		Algorithm: AttestationAlgorithmEd25519,
+		KeyID:     keyID,
+		SignedAt:  clock.Now().UTC().Truncate(time.Second),
+	}
+
+	payload, err := att.payload()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode attestation payload: %w", err)
+	}
+	att.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(key, payload))
+	return att, nil
+}
+
+// VerifySignature checks the attestation signature without checking any digests
+func (a *Attestation) VerifySignature(key ed25519.PublicKey) error {
+	if a == nil {
+		return fmt.Errorf("attestation is missing")
+	}
+	if a.Algorithm != AttestationAlgorithmEd25519 {
+		return fmt.Errorf("unsupported attestation algorithm %q", a.Algorithm)
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid ed25519 public key size %d", len(key))
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(a.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid attestation signature encoding: %w", err)
+	}
+	payload, err := a.payload()
+	if err != nil {
+		return fmt.Errorf("failed to encode attestation payload: %w", err)
+	}
+	if !ed25519.Verify(key, payload, signature) {
+		return fmt.Errorf("attestation signature verification failed")
+	}
+	return nil
+}
+
+// Verify checks the signature and that each supplied artifact matches the digest in the
+// subject. Pass empty/nil artifacts to skip them; an artifact whose digest is absent
+// from the subject is rejected because it cannot be bound to the release.
+func (a *Attestation) Verify(key ed25519.PublicKey, binaryPath string, schema *Schema, docs *UniversalProviderDocumentation) error {
+	if err := a.VerifySignature(key); err != nil {
+		return err
+	}
+
+	check := func(name, attested string, compute func() (string, error)) error {
+		if attested == "" {
+			return fmt.Errorf("attestation does not cover the %s", name)
+		}
+		actual, err := compute()
+		if err != nil {
+			return fmt.Errorf("failed to digest %s: %w", name, err)
+		}
+		if actual != attested {
+			return fmt.Errorf("%s digest mismatch: attested %s, got %s", name, attested, actual)
+		}
+		return nil
+	}
+
+	if binaryPath != "" {
+		if err := check("provider binary", a.Subject.BinaryDigest, func() (string, error) { return DigestFile(binaryPath) }); err != nil {
+			return err
+		}
+	}
+	if schema != nil {
+		if err := check("schema", a.Subject.SchemaDigest, func() (string, error) { return DigestSchema(schema) }); err != nil {
+			return err
+		}
+	}
+	if docs != nil {
+		if err := check("documentation", a.Subject.DocsDigest, func() (string, error) { return DigestDocumentation(docs) }); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DigestFile returns the sha256 digest of a file as sha256:<hex>
+func DigestFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// DigestSchema returns the sha256 digest of the schema's JSON encoding
+func DigestSchema(schema *Schema) (string, error) {
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return "", err
+	}
+	return digestBytes(data), nil
+}
+
+// DigestDocumentation returns the sha256 digest of the documentation JSON with the
+// attestation itself excluded, so a document can carry its own attestation
+func DigestDocumentation(docs *UniversalProviderDocumentation) (string, error) {
+	unsigned := *docs
+	unsigned.Metadata.Attestation = nil
+	data, err := json.Marshal(&unsigned)
+	if err != nil {
+		return "", err
+	}
+	return digestBytes(data), nil
+}
+
+func digestBytes(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// LoadSigningKey reads a PEM encoded PKCS#8 ed25519 private key
+func LoadSigningKey(path string) (ed25519.PrivateKey, error) {
+	block, err := readPEMBlock(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key %s: %w", path, err)
+	}
+	edKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("signing key %s is %T, expected ed25519", path, key)
+	}
+	return edKey, nil
+}
+
+// LoadVerificationKey reads a PEM encoded PKIX ed25519 public key
+func LoadVerificationKey(path string) (ed25519.PublicKey, error) {
+	block, err := readPEMBlock(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse verification key %s: %w", path, err)
+	}
+	edKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("verification key %s is %T, expected ed25519", path, key)
+	}
+	return edKey, nil
+}
+
+func readPEMBlock(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key %s: %w", path, err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("key %s is not PEM encoded", path)
+	}
+	return block, nil
+}
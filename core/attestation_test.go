@@ -0,0 +1,71 @@
+package core
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestAttestationRoundTrip validates signed digests survive serialization and detect tampering
+func TestAttestationRoundTrip(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	binary := filepath.Join(t.TempDir(), "kolumn-provider-test")
+	if err := os.WriteFile(binary, []byte("binary"), 0o755); err != nil {
+		t.Fatalf("Failed to write binary: %v", err)
+	}
+
+	schema := &Schema{Name: "test", Version: "1.0.0", Type: "database", Description: "Test provider"}
+	docs := NewDocumentationBuilder().
+		SetProvider(ProviderMetadata{Name: "test", Version: "1.0.0"}).
+		AddResource("test_table", &ResourceDoc{Type: "create", Schema: json.RawMessage(`{"type": "object"}`)}).
+		Build()
+
+	subject := AttestationSubject{Provider: "test", Version: "1.0.0"}
+	if subject.BinaryDigest, err = DigestFile(binary); err != nil {
+		t.Fatalf("DigestFile failed: %v", err)
+	}
+	if subject.SchemaDigest, err = DigestSchema(schema); err != nil {
+		t.Fatalf("DigestSchema failed: %v", err)
+	}
+	if subject.DocsDigest, err = DigestDocumentation(docs); err != nil {
+		t.Fatalf("DigestDocumentation failed: %v", err)
+	}
+
+	att, err := SignAttestation(subject, priv, "release-2024")
+	if err != nil {
+		t.Fatalf("SignAttestation failed: %v", err)
+	}
+	docs.Metadata.Attestation = att
+
+	// Round trip through indented JSON as docs-gen writes it
+	data, err := json.MarshalIndent(docs, "", "  ")
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var loaded UniversalProviderDocumentation
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	if err := loaded.Metadata.Attestation.Verify(pub, binary, schema, &loaded); err != nil {
+		t.Fatalf("Expected verification to succeed, got %v", err)
+	}
+
+	loaded.Provider.Description = "tampered"
+	err = loaded.Metadata.Attestation.Verify(pub, "", nil, &loaded)
+	if err == nil || !strings.Contains(err.Error(), "documentation digest mismatch") {
+		t.Errorf("Expected documentation digest mismatch, got %v", err)
+	}
+
+	otherPub, _, _ := ed25519.GenerateKey(nil)
+	if err := att.VerifySignature(otherPub); err == nil {
+		t.Errorf("Expected signature verification with the wrong key to fail")
+	}
+}
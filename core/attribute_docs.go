@@ -0,0 +1,95 @@
+// Package core provides attribute reference documentation derived from state schemas
+package core
+
+import (
+	"encoding/json"
+)
+
+// Attribute sources
+const (
+	AttributeSourceConfig   = "config"   // set by the user and echoed into state
+	AttributeSourceComputed = "computed" // exported by the provider only
+	AttributeSourceBoth     = "both"     // configurable, and also reported back by the provider
+)
+
+// AttributeReference documents one attribute other resources can reference
+type AttributeReference struct {
+	Name        string      `json:"name"`
+	Type        interface{} `json:"type,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Sensitive   bool        `json:"sensitive,omitempty"`
+	Source      string      `json:"source"`    // one of the AttributeSource values
+	Reference   string      `json:"reference"` // e.g. postgres_table.<name>.row_count
+}
+
+// schemaProperty is the subset of a JSON schema property used for attribute docs.
+// Sensitivity is read from "sensitive", "x-sensitive" or "writeOnly".
+type schemaProperty struct {
+	Type        interface{} `json:"type"`
+	Description string      `json:"description"`
+	Sensitive   bool        `json:"sensitive"`
+	XSensitive  bool        `json:"x-sensitive"`
+	WriteOnly   bool        `json:"writeOnly"`
+}
+
+func (p schemaProperty) sensitive() bool {
+	return p.Sensitive || p.XSensitive || p.WriteOnly
+}
+
+func schemaProperties(schema json.RawMessage) map[string]schemaProperty {
+	if len(schema) == 0 {
+		return nil
+	}
+	var s struct {
+		Properties map[string]schemaProperty `json:"properties"`
+	}
+	if json.Unmarshal(schema, &s) != nil {
+		return nil
+	}
+	return s.Properties
+}
+
+// BuildAttributeReference merges a resource type's config and state schemas into the
+// sorted list of attributes that can be referenced as <type>.<name>.<attribute>. State
+// properties are the exported attributes; config properties are included as well since
+// their values are echoed into state. Descriptions and types fall back from state to
+// config, and an attribute is sensitive when either schema marks it so.
+func BuildAttributeReference(resourceType string, configSchema, stateSchema json.RawMessage) []AttributeReference {
+	config, state := schemaProperties(configSchema), schemaProperties(stateSchema)
+	names := make(map[string]bool, len(config)+len(state))
+	for name := range config {
+		names[name] = true
+	}
+	for name := range state {
+		names[name] = true
+	}
+
+	out := make([]AttributeReference, 0, len(names))
+	for _, name := range sortedBoolKeys(names) {
+		cfg, inConfig := config[name]
+		st, inState := state[name]
+		attr := AttributeReference{
+			Name:      name,
+			Type:      st.Type,
+			Sensitive: cfg.sensitive() || st.sensitive(),
+			Reference: resourceType + ".<name>." + name,
+		}
+		if attr.Type == nil {
+			attr.Type = cfg.Type
+		}
+		attr.Description = st.Description
+		if attr.Description == "" {
+			attr.Description = cfg.Description
+		}
+		switch {
+		case inConfig && inState:
+			attr.Source = AttributeSourceBoth
+		case inState:
+			attr.Source = AttributeSourceComputed
+		default:
+			attr.Source = AttributeSourceConfig
+		}
+		out = append(out, attr)
+	}
+	return out
+}
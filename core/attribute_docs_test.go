@@ -0,0 +1,38 @@
+package core
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestBuildAttributeReference validates config and state schemas merge into attributes
+func TestBuildAttributeReference(t *testing.T) {
+	config := json.RawMessage(`{"properties":{
+		"name":{"type":"string","description":"Table name"},
+		"password":{"type":"string","writeOnly":true}}}`)
+	state := json.RawMessage(`{"properties":{
+		"name":{"type":"string"},
+		"row_count":{"type":"integer","description":"Rows in the table"},
+		"dsn":{"type":"string","sensitive":true}}}`)
+
+	attrs := BuildAttributeReference("postgres_table", config, state)
+	byName := map[string]AttributeReference{}
+	for _, a := range attrs {
+		byName[a.Name] = a
+	}
+	if len(attrs) != 4 || attrs[0].Name != "dsn" {
+		t.Fatalf("unexpected attributes: %+v", attrs)
+	}
+	if a := byName["name"]; a.Source != AttributeSourceBoth || a.Description != "Table name" {
+		t.Errorf("name should merge config description: %+v", a)
+	}
+	if a := byName["row_count"]; a.Source != AttributeSourceComputed || a.Reference != "postgres_table.<name>.row_count" {
+		t.Errorf("unexpected row_count: %+v", a)
+	}
+	if !byName["dsn"].Sensitive || !byName["password"].Sensitive || byName["password"].Source != AttributeSourceConfig {
+		t.Errorf("sensitivity not propagated: %+v", attrs)
+	}
+	if docs := BuildAttributeDocsFromStateSchema(state); docs["dsn"].(map[string]interface{})["sensitive"] != true {
+		t.Errorf("attribute docs should mark sensitive attributes: %v", docs)
+	}
+}
@@ -0,0 +1,306 @@
+// Package core provides RBAC enforcement for governance roles and permissions
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// DiagnosticCodePermissionDenied is the diagnostic code for failed authorization
+const DiagnosticCodePermissionDenied = "PERMISSION_DENIED"
+
+// Diagnostic is a structured error or warning reported back to Kolumn core
+type Diagnostic struct {
+	Severity string `json:"severity"` // error, warning
+	Code     string `json:"code"`
+	Summary  string `json:"summary"`
+	Detail   string `json:"detail,omitempty"`
+	Resource string `json:"resource,omitempty"` // resource address, see types.Address
+}
+
+// PermissionDeniedError is returned when the request's roles do not permit an operation
+type PermissionDeniedError struct {
+	Diagnostic   Diagnostic `json:"diagnostic"`
+	User         string     `json:"user,omitempty"`
+	Action       string     `json:"action"`
+	ResourceType string     `json:"resource_type"`
+}
+
+// Error implements the error interface
+func (e *PermissionDeniedError) Error() string {
+	if e.Diagnostic.Detail == "" {
+		return e.Diagnostic.Summary
+	}
+	return e.Diagnostic.Summary + ": " + e.Diagnostic.Detail
+}
+
+// AuthorizationDecision explains the outcome of evaluating a request against governance RBAC
+type AuthorizationDecision struct {
+	Allowed    bool        `json:"allowed"`
+	Enforced   bool        `json:"enforced"` // false for advisory or disabled enforcement
+	Action     string      `json:"action"`
+	Role       string      `json:"role,omitempty"`       // role that granted access
+	Permission string      `json:"permission,omitempty"` // permission that granted access
+	Diagnostic *Diagnostic `json:"diagnostic,omitempty"` // set when access is denied
+}
+
+// functionActions maps unified dispatch functions to governance permission actions
+var functionActions = map[string]string{
+	"CreateResource":    "create",
+	"ReadResource":      "read",
+	"UpdateResource":    "update",
+	"DeleteResource":    "delete",
+	"DiscoverResources": "discover",
+	"DiscoverDatabase":  "discover",
+	"StartScan":         "discover",
+}
+
+// actionAliases lists actions that grant a broader set of operations
+var actionAliases = map[string][]string{
+	"write": {"create", "update", "delete"},
+	"admin": {"*"},
+	"all":   {"*"},
+}
+
+// OperationAction returns the permission action for a unified function name; other
+// values are treated as actions already and lower-cased
+func OperationAction(function string) string {
+	if action, ok := functionActions[function]; ok {
+		return action
+	}
+	return strings.ToLower(function)
+}
+
+// Authorizer evaluates UserContext roles against the RoleContext and PermissionContext
+// definitions of a GovernanceContext
+type Authorizer struct {
+	providerType string
+	governance   *GovernanceContext
+}
+
+// NewAuthorizer creates an authorizer for a provider type
+func NewAuthorizer(providerType string, governanceCtx *GovernanceContext) *Authorizer {
+	return &Authorizer{
+		providerType: providerType,
+		governance:   governanceCtx,
+	}
+}
+
+// Evaluate decides whether user may perform operation on resourceType
+func (a *Authorizer) Evaluate(user *UserContext, operation, resourceType string) *AuthorizationDecision {
+	action := OperationAction(operation)
+	decision := &AuthorizationDecision{Action: action, Enforced: a.enforced()}
+
+	// Nothing to enforce without role definitions
+	if a.governance == nil || len(a.governance.Roles) == 0 {
+		decision.Allowed = true
+		return decision
+	}
+
+	if user != nil {
+		for _, roleName := range user.Roles {
+			role, exists := a.governance.Roles[roleName]
+			if !exists {
+				continue
+			}
+			for _, permName := range role.Permissions {
+				if a.permissionGrants(permName, action, resourceType) {
+					decision.Allowed = true
+					decision.Role = roleName
+					decision.Permission = permName
+					return decision
+				}
+			}
+		}
+	}
+
+	decision.Diagnostic = a.deniedDiagnostic(user, action, resourceType)
+	return decision
+}
+
+// Authorize returns a *PermissionDeniedError when user may not perform operation on
+// resourceType and enforcement is strict
+func (a *Authorizer) Authorize(user *UserContext, operation, resourceType string) error {
+	decision := a.Evaluate(user, operation, resourceType)
+	if decision.Allowed || !decision.Enforced {
+		return nil
+	}
+	return newPermissionDeniedError(user, decision.Action, resourceType, *decision.Diagnostic)
+}
+
+// AuthorizeRequest authorizes a request governance context, including any permissions
+// the request names explicitly in RequiredPermissions
+func (a *Authorizer) AuthorizeRequest(req *RequestGovernanceContext) error {
+	if req == nil {
+		return nil
+	}
+	if err := a.Authorize(req.UserContext, req.Operation, req.ResourceType); err != nil {
+		return err
+	}
+	if !a.enforced() || a.governance == nil || len(req.RequiredPermissions) == 0 {
+		return nil
+	}
+
+	held := a.heldPermissions(req.UserContext)
+	var missing []string
+	for _, perm := range req.RequiredPermissions {
+		if !held[perm] {
+			missing = append(missing, perm)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	action := OperationAction(req.Operation)
+	diag := a.deniedDiagnostic(req.UserContext, action, req.ResourceType)
+	diag.Detail = fmt.Sprintf("missing required permissions: %s", strings.Join(missing, ", "))
+	return newPermissionDeniedError(req.UserContext, action, req.ResourceType, *diag)
+}
+
+// enforced reports whether denials should fail the request
+func (a *Authorizer) enforced() bool {
+	if a.governance == nil {
+		return true
+	}
+	switch strings.ToLower(a.governance.EnforcementLevel) {
+	case "advisory", "disabled":
+		return false
+	default:
+		return true
+	}
+}
+
+// permissionGrants reports whether a named permission covers action on resourceType.
+// Names without a PermissionContext are read inline as "action:resource".
+func (a *Authorizer) permissionGrants(name, action, resourceType string) bool {
+	if perm, exists := a.governance.Permissions[name]; exists {
+		return matchesAction(perm.Actions, action) && a.matchesResource(perm.Resources, resourceType)
+	}
+
+	if inlineAction, resource, ok := strings.Cut(name, ":"); ok {
+		return matchesAction([]string{inlineAction}, action) && a.matchesResource([]string{resource}, resourceType)
+	}
+	return false
+}
+
+// heldPermissions returns the permission names granted through the user's roles
+func (a *Authorizer) heldPermissions(user *UserContext) map[string]bool {
+	held := make(map[string]bool)
+	if user == nil {
+		return held
+	}
+	for _, roleName := range user.Roles {
+		if role, exists := a.governance.Roles[roleName]; exists {
+			for _, perm := range role.Permissions {
+				held[perm] = true
+			}
+		}
+	}
+	return held
+}
+
+// matchesResource matches resource patterns such as "*", "table", "postgres:table" or
+// "postgres:*" against a resource type
+func (a *Authorizer) matchesResource(patterns []string, resourceType string) bool {
+	for _, pattern := range patterns {
+		if provider, rest, ok := strings.Cut(pattern, ":"); ok {
+			if provider != "*" && !strings.EqualFold(provider, a.providerType) {
+				continue
+			}
+			pattern = rest
+		}
+		if pattern == "*" || pattern == resourceType {
+			return true
+		}
+		if matched, err := path.Match(pattern, resourceType); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAction matches permission actions, expanding aliases such as "write"
+func matchesAction(actions []string, action string) bool {
+	for _, candidate := range actions {
+		candidate = strings.ToLower(candidate)
+		expanded := []string{candidate}
+		if alias, ok := actionAliases[candidate]; ok {
+			expanded = alias
+		}
+		for _, a := range expanded {
+			if a == "*" || a == action {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (a *Authorizer) deniedDiagnostic(user *UserContext, action, resourceType string) *Diagnostic {
+	subject := "anonymous request"
+	var roles []string
+	if user != nil {
+		subject = "user " + user.Username
+		if user.Username == "" {
+			subject = "user " + user.UserID
+		}
+		roles = append(roles, user.Roles...)
+		sort.Strings(roles)
+	}
+
+	detail := fmt.Sprintf("%s has no role permitting %q on %q", subject, action, resourceType)
+	if len(roles) > 0 {
+		detail += fmt.Sprintf(" (roles: %s)", strings.Join(roles, ", "))
+	}
+
+	severity := "error"
+	if !a.enforced() {
+		severity = "warning"
+	}
+	return &Diagnostic{
+		Severity: severity,
+		Code:     DiagnosticCodePermissionDenied,
+		Summary:  "permission denied",
+		Detail:   detail,
+		Resource: resourceType,
+	}
+}
+
+func newPermissionDeniedError(user *UserContext, action, resourceType string, diag Diagnostic) *PermissionDeniedError {
+	err := &PermissionDeniedError{
+		Diagnostic:   diag,
+		Action:       action,
+		ResourceType: resourceType,
+	}
+	if user != nil {
+		err.User = user.UserID
+	}
+	return err
+}
+
+// requestUserContext extracts the caller's UserContext from a unified request, looking at
+// metadata.governance_context.request_context first and metadata.user_context second
+func requestUserContext(input []byte) (resourceType string, user *UserContext) {
+	var req struct {
+		ResourceType string `json:"resource_type"`
+		Metadata     struct {
+			UserContext       *UserContext `json:"user_context"`
+			GovernanceContext struct {
+				RequestContext *RequestGovernanceContext `json:"request_context"`
+			} `json:"governance_context"`
+		} `json:"metadata"`
+	}
+	if json.Unmarshal(input, &req) != nil {
+		return "", nil
+	}
+
+	user = req.Metadata.UserContext
+	if rc := req.Metadata.GovernanceContext.RequestContext; rc != nil && rc.UserContext != nil {
+		user = rc.UserContext
+	}
+	return req.ResourceType, user
+}
@@ -0,0 +1,99 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func testGovernanceRBAC(level string) *GovernanceContext {
+	return &GovernanceContext{
+		EnforcementLevel: level,
+		Roles: map[string]*RoleContext{
+			"analyst":  {Name: "analyst", Permissions: []string{"read_tables"}},
+			"engineer": {Name: "engineer", Permissions: []string{"read_tables", "write:postgres:*"}},
+		},
+		Permissions: map[string]*PermissionContext{
+			"read_tables": {Name: "read_tables", Actions: []string{"read", "discover"}, Resources: []string{"table", "view"}},
+		},
+	}
+}
+
+// TestAuthorizerEvaluate validates role and permission matching
+func TestAuthorizerEvaluate(t *testing.T) {
+	authz := NewAuthorizer("postgres", testGovernanceRBAC("strict"))
+	analyst := &UserContext{UserID: "u1", Username: "alice", Roles: []string{"analyst"}}
+	engineer := &UserContext{UserID: "u2", Username: "bob", Roles: []string{"engineer"}}
+
+	tests := []struct {
+		name         string
+		user         *UserContext
+		operation    string
+		resourceType string
+		allowed      bool
+	}{
+		{"analyst reads table", analyst, "ReadResource", "table", true},
+		{"analyst cannot create", analyst, "CreateResource", "table", false},
+		{"analyst cannot read schema", analyst, "ReadResource", "schema", false},
+		{"engineer writes via inline permission", engineer, "DeleteResource", "schema", true},
+		{"anonymous denied", nil, "ReadResource", "table", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := authz.Evaluate(tt.user, tt.operation, tt.resourceType)
+			if decision.Allowed != tt.allowed {
+				t.Errorf("expected allowed=%v, got %v", tt.allowed, decision.Allowed)
+			}
+			if !tt.allowed && (decision.Diagnostic == nil || decision.Diagnostic.Code != DiagnosticCodePermissionDenied) {
+				t.Errorf("expected PERMISSION_DENIED diagnostic, got %+v", decision.Diagnostic)
+			}
+		})
+	}
+}
+
+// TestAuthorizerAdvisory validates that advisory enforcement never fails requests
+func TestAuthorizerAdvisory(t *testing.T) {
+	authz := NewAuthorizer("postgres", testGovernanceRBAC("advisory"))
+	user := &UserContext{UserID: "u1", Roles: []string{"analyst"}}
+
+	if err := authz.Authorize(user, "CreateResource", "table"); err != nil {
+		t.Errorf("expected advisory mode to allow, got %v", err)
+	}
+	if d := authz.Evaluate(user, "CreateResource", "table").Diagnostic; d == nil || d.Severity != "warning" {
+		t.Errorf("expected warning diagnostic, got %+v", d)
+	}
+}
+
+// TestAuthorizeRequestRequiredPermissions validates explicit required permissions
+func TestAuthorizeRequestRequiredPermissions(t *testing.T) {
+	authz := NewAuthorizer("postgres", testGovernanceRBAC("strict"))
+	req := &RequestGovernanceContext{
+		Operation:           "read",
+		ResourceType:        "table",
+		UserContext:         &UserContext{UserID: "u1", Roles: []string{"analyst"}},
+		RequiredPermissions: []string{"read_tables", "read_pii"},
+	}
+
+	var denied *PermissionDeniedError
+	if err := authz.AuthorizeRequest(req); !errors.As(err, &denied) {
+		t.Fatalf("expected PermissionDeniedError, got %v", err)
+	}
+	if denied.User != "u1" || denied.Action != "read" {
+		t.Errorf("unexpected denial: %+v", denied)
+	}
+}
+
+// TestDispatchEnforcesRBAC validates that the dispatcher rejects unauthorized requests
+func TestDispatchEnforcesRBAC(t *testing.T) {
+	d := NewUnifiedDispatcher(nil, nil)
+	d.SetAuthorizer(NewAuthorizer("postgres", testGovernanceRBAC("strict")))
+
+	input := []byte(`{"resource_type":"table","name":"users","metadata":{"user_context":{"user_id":"u1","roles":["analyst"]}}}`)
+	_, err := d.Dispatch(context.Background(), "CreateResource", input)
+
+	var denied *PermissionDeniedError
+	if !errors.As(err, &denied) {
+		t.Fatalf("expected PermissionDeniedError, got %v", err)
+	}
+}
@@ -0,0 +1,257 @@
+// Package core provides backend feature detection gating resource attributes
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BackendFeaturesMetadataKey is the HealthStatus.Metadata key holding detection results
+const BackendFeaturesMetadataKey = "backend_features"
+
+// DiagnosticCodeBackendFeatureUnsupported is the diagnostic code for attributes the
+// connected backend does not support
+const DiagnosticCodeBackendFeatureUnsupported = "BACKEND_FEATURE_UNSUPPORTED"
+
+// BackendFeature is a capability that differs between backend versions or editions,
+// e.g. "partitioned_tables" or "transactional_ddl". Its probe runs once per connection,
+// and the attributes it gates are rejected when the backend lacks it.
+type BackendFeature struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+
+	// Attributes maps resource types to the configuration attributes needing the
+	// feature, e.g. {"table": ["partition_by"]}
+	Attributes map[string][]string `json:"attributes,omitempty"`
+
+	// Probe reports whether the connected backend supports the feature, e.g. by
+	// checking the server version or querying a catalog
+	Probe func(ctx context.Context) (bool, error) `json:"-"`
+}
+
+// FeatureDetection is the outcome of one feature probe
+type FeatureDetection struct {
+	Supported bool      `json:"supported"`
+	Error     string    `json:"error,omitempty"` // probe failure; the feature is then not gated
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// BackendFeatureError is returned when a configuration sets attributes whose backend
+// feature is unsupported
+type BackendFeatureError struct {
+	Diagnostic   Diagnostic `json:"diagnostic"`
+	ResourceType string     `json:"resource_type"`
+	Attributes   []string   `json:"attributes"`
+	Features     []string   `json:"features"`
+}
+
+// Error implements the error interface
+func (e *BackendFeatureError) Error() string {
+	return e.Diagnostic.Summary + ": " + e.Diagnostic.Detail
+}
+
+// BackendFeatures is the feature matrix of one provider: the declared features and
+// what was detected on the current connection. It is safe for concurrent use.
+type BackendFeatures struct {
+	features []BackendFeature // sorted by name
+
+	detectMu   sync.Mutex // serializes probing so each connection is probed once
+	mu         sync.RWMutex
+	connection string
+	results    map[string]FeatureDetection
+}
+
+// NewBackendFeatures declares a provider's backend features
+func NewBackendFeatures(features ...BackendFeature) *BackendFeatures {
+	sorted := append([]BackendFeature(nil), features...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return &BackendFeatures{features: sorted}
+}
+
+// Features returns the declared features sorted by name
+func (b *BackendFeatures) Features() []BackendFeature {
+	return append([]BackendFeature(nil), b.features...)
+}
+
+// Detect runs every probe against the connection identified by connection, e.g. the
+// backend host and database, and keeps the results. Probes run once per connection:
+// detecting the same connection again returns the kept results, while a different
+// connection replaces them. Probe failures are recorded, not returned.
+func (b *BackendFeatures) Detect(ctx context.Context, connection string) map[string]FeatureDetection {
+	b.detectMu.Lock()
+	defer b.detectMu.Unlock()
+	b.mu.RLock()
+	if b.results != nil && b.connection == connection {
+		defer b.mu.RUnlock()
+		return copyDetections(b.results)
+	}
+	b.mu.RUnlock()
+
+	results := make(map[string]FeatureDetection, len(b.features))
+	for _, feature := range b.features {
+		detection := FeatureDetection{CheckedAt: clock.Now()}
+		if feature.Probe == nil {
+			detection.Error = "no probe declared"
+		} else if supported, err := feature.Probe(ctx); err != nil {
+			detection.Error = err.Error()
+		} else {
+			detection.Supported = supported
+		}
+		results[feature.Name] = detection
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.connection, b.results = connection, results
+	return copyDetections(results)
+}
+
+// Reset forgets the detected results, e.g. after the backend was upgraded
+func (b *BackendFeatures) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.connection, b.results = "", nil
+}
+
+// Results returns the results of the last detection, nil before any
+func (b *BackendFeatures) Results() map[string]FeatureDetection {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.results == nil {
+		return nil
+	}
+	return copyDetections(b.results)
+}
+
+// Supported reports whether a feature was detected; known is false when it was not
+// probed successfully on the current connection
+func (b *BackendFeatures) Supported(name string) (supported, known bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	detection, ok := b.results[name]
+	if !ok || detection.Error != "" {
+		return false, false
+	}
+	return detection.Supported, true
+}
+
+// CheckConfig returns a *BackendFeatureError when config sets attributes whose feature
+// the backend lacks. Attributes set to null count as unset. Features not detected yet,
+// or whose probe failed, do not reject anything.
+func (b *BackendFeatures) CheckConfig(resourceType string, config map[string]interface{}) error {
+	return b.check(resourceType, func(attribute string) bool {
+		return config[attribute] != nil
+	})
+}
+
+// check rejects the attributes reported set by isSet that need an unsupported feature
+func (b *BackendFeatures) check(resourceType string, isSet func(attribute string) bool) error {
+	var attributes, features []string
+	for _, feature := range b.features {
+		gated := feature.Attributes[resourceType]
+		if len(gated) == 0 {
+			continue
+		}
+		if supported, known := b.Supported(feature.Name); supported || !known {
+			continue
+		}
+		used := false
+		for _, attribute := range gated {
+			if isSet(attribute) {
+				attributes = append(attributes, attribute)
+				used = true
+			}
+		}
+		if used {
+			features = append(features, feature.Name)
+		}
+	}
+	if len(attributes) == 0 {
+		return nil
+	}
+	attributes = uniqueSorted(attributes)
+	return &BackendFeatureError{
+		Diagnostic: Diagnostic{
+			Severity: "error",
+			Code:     DiagnosticCodeBackendFeatureUnsupported,
+			Summary:  "attribute not supported by the backend",
+			Detail: fmt.Sprintf("%s %s %s not supported by the connected backend, which lacks %s",
+				resourceType, strings.Join(attributes, ", "), pluralVerb(len(attributes)), strings.Join(features, ", ")),
+			Resource: resourceType,
+		},
+		ResourceType: resourceType,
+		Attributes:   attributes,
+		Features:     features,
+	}
+}
+
+// Annotate records the detection results in status.Metadata under
+// BackendFeaturesMetadataKey
+func (b *BackendFeatures) Annotate(status *HealthStatus) {
+	results := b.Results()
+	if results == nil || status == nil {
+		return
+	}
+	if status.Metadata == nil {
+		status.Metadata = make(map[string]interface{})
+	}
+	status.Metadata[BackendFeaturesMetadataKey] = results
+}
+
+// ApplyToSchema lists the declared features in schema.BackendFeatures so capabilities
+// and docs can show which attributes depend on the backend
+func (b *BackendFeatures) ApplyToSchema(schema *Schema) {
+	schema.BackendFeatures = b.Features()
+}
+
+// BackendFeatureAttributes maps the attributes of a resource type that need a backend
+// feature to the feature's name
+func (s *Schema) BackendFeatureAttributes(resourceType string) map[string]string {
+	var out map[string]string
+	for _, feature := range s.BackendFeatures {
+		for _, attribute := range feature.Attributes[resourceType] {
+			if out == nil {
+				out = make(map[string]string)
+			}
+			out[attribute] = feature.Name
+		}
+	}
+	return out
+}
+
+// SetBackendFeatures enables rejection of create and update requests setting attributes
+// the connected backend does not support
+func (d *UnifiedDispatcher) SetBackendFeatures(features *BackendFeatures) {
+	d.backendFeatures = features
+}
+
+// checkBackendFeatures checks the top-level config attributes of a decoded request
+func (d *UnifiedDispatcher) checkBackendFeatures(req *resourceRequest) error {
+	if d.backendFeatures == nil {
+		return nil
+	}
+	return d.backendFeatures.check(req.resourceType, func(attribute string) bool {
+		raw, ok := req.config[attribute]
+		return ok && !bytes.Equal(raw, jsonNull)
+	})
+}
+
+func copyDetections(results map[string]FeatureDetection) map[string]FeatureDetection {
+	out := make(map[string]FeatureDetection, len(results))
+	for name, detection := range results {
+		out[name] = detection
+	}
+	return out
+}
+
+func pluralVerb(n int) string {
+	if n == 1 {
+		return "is"
+	}
+	return "are"
+}
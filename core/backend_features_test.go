@@ -0,0 +1,99 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func newTestBackendFeatures(probes *int, partitioned bool) *BackendFeatures {
+	return NewBackendFeatures(
+		BackendFeature{
+			Name:       "partitioned_tables",
+			Attributes: map[string][]string{"table": {"partition_by"}},
+			Probe: func(ctx context.Context) (bool, error) {
+				*probes++
+				return partitioned, nil
+			},
+		},
+		BackendFeature{
+			Name:       "table_comments",
+			Attributes: map[string][]string{"table": {"comment"}},
+			Probe: func(ctx context.Context) (bool, error) {
+				return false, errors.New("catalog unavailable")
+			},
+		},
+	)
+}
+
+// TestBackendFeaturesDetect validates probes run once per connection and gate attributes
+func TestBackendFeaturesDetect(t *testing.T) {
+	probes := 0
+	features := newTestBackendFeatures(&probes, false)
+	config := map[string]interface{}{"partition_by": "range(created_at)", "comment": "events"}
+	if err := features.CheckConfig("table", config); err != nil {
+		t.Errorf("undetected features should not gate attributes: %v", err)
+	}
+
+	features.Detect(context.Background(), "db1")
+	results := features.Detect(context.Background(), "db1")
+	if probes != 1 {
+		t.Errorf("probe ran %d times for one connection", probes)
+	}
+	if results["table_comments"].Error == "" || results["partitioned_tables"].Supported {
+		t.Errorf("unexpected results %+v", results)
+	}
+
+	err := features.CheckConfig("table", config)
+	var unsupported *BackendFeatureError
+	if !errors.As(err, &unsupported) || len(unsupported.Attributes) != 1 || unsupported.Attributes[0] != "partition_by" {
+		t.Fatalf("expected partition_by to be rejected, got %v", err)
+	}
+	if unsupported.Diagnostic.Code != DiagnosticCodeBackendFeatureUnsupported {
+		t.Errorf("code = %q", unsupported.Diagnostic.Code)
+	}
+	if err := features.CheckConfig("table", map[string]interface{}{"partition_by": nil}); err != nil {
+		t.Errorf("null attributes count as unset: %v", err)
+	}
+
+	features.Detect(context.Background(), "db2")
+	if probes != 2 {
+		t.Errorf("a new connection should be probed again, probes = %d", probes)
+	}
+
+	status := &HealthStatus{}
+	features.Annotate(status)
+	if _, ok := status.Metadata[BackendFeaturesMetadataKey]; !ok {
+		t.Error("health status not annotated")
+	}
+}
+
+// TestBackendFeaturesDispatchAndDocs validates the dispatcher gate and the capability matrix
+func TestBackendFeaturesDispatchAndDocs(t *testing.T) {
+	probes := 0
+	features := newTestBackendFeatures(&probes, false)
+	features.Detect(context.Background(), "db1")
+	d := NewUnifiedDispatcher(&echoRegistry{}, nil)
+	d.SetBackendFeatures(features)
+
+	_, err := d.Dispatch(context.Background(), "CreateResource",
+		[]byte(`{"resource_type":"table","name":"events","config":{"partition_by":"range(created_at)"}}`))
+	var unsupported *BackendFeatureError
+	if !errors.As(err, &unsupported) {
+		t.Fatalf("expected create to be rejected, got %v", err)
+	}
+	if _, err := d.Dispatch(context.Background(), "UpdateResource",
+		[]byte(`{"resource_type":"table","resource_id":"events","name":"events","config":{"schema":"public","partition_by":null}}`)); err != nil {
+		t.Errorf("update without gated attributes: %v", err)
+	}
+
+	schema := &Schema{SupportedFunctions: []string{"CreateResource"}}
+	features.ApplyToSchema(schema)
+	if got := schema.BackendFeatureAttributes("table"); got["partition_by"] != "partitioned_tables" || got["comment"] != "table_comments" {
+		t.Errorf("BackendFeatureAttributes = %v", got)
+	}
+	docs := BuildCapabilityDocs(schema, nil)
+	if got := docs.BackendFeatures["partitioned_tables"]; len(got) != 1 || got[0] != "table.partition_by" {
+		t.Errorf("capability docs = %v", docs.BackendFeatures)
+	}
+}
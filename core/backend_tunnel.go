@@ -0,0 +1,72 @@
+// Package core ties backend tunnels into the provider lifecycle
+package core
+
+import (
+	"context"
+	"fmt"
+	"net"
+)
+
+// BackendTunnel is a tunnel a provider dials its backend through. helpers/tunnel
+// implements it for SSH bastions and SOCKS5 proxies.
+type BackendTunnel interface {
+	DialContext(ctx context.Context, network, addr string) (net.Conn, error)
+	Check(ctx context.Context) CheckResult
+	Close() error
+}
+
+// SetTunnel hands the tunnel opened in Configure to the base provider, which reports
+// it from CheckTunnel and closes it in Close. A tunnel set by an earlier Configure is
+// closed; nil removes the tunnel.
+func (bp *BaseProvider) SetTunnel(tunnel BackendTunnel) error {
+	previous := bp.tunnel
+	bp.tunnel = tunnel
+	if previous == nil || previous == tunnel {
+		return nil
+	}
+	return previous.Close()
+}
+
+// Tunnel returns the tunnel set with SetTunnel, or nil when backends are dialed directly
+func (bp *BaseProvider) Tunnel() BackendTunnel {
+	return bp.tunnel
+}
+
+// DialBackend connects to addr through the tunnel, or directly when none is set
+func (bp *BaseProvider) DialBackend(ctx context.Context, network, addr string) (net.Conn, error) {
+	if bp.tunnel == nil {
+		var d net.Dialer
+		return d.DialContext(ctx, network, addr)
+	}
+	return bp.tunnel.DialContext(ctx, network, addr)
+}
+
+// CheckTunnel adds the tunnel's health check to a HealthStatus built by the provider.
+// A failed check marks the status unhealthy.
+func (bp *BaseProvider) CheckTunnel(ctx context.Context, status *HealthStatus) {
+	if bp.tunnel == nil || status == nil {
+		return
+	}
+	result := bp.tunnel.Check(ctx)
+	if status.Checks == nil {
+		status.Checks = make(map[string]CheckResult)
+	}
+	status.Checks[result.Name] = result
+	if !result.Passed {
+		status.Healthy = false
+		status.Status = "unhealthy"
+	}
+}
+
+// closeTunnel closes the tunnel during Close
+func (bp *BaseProvider) closeTunnel() error {
+	if bp.tunnel == nil {
+		return nil
+	}
+	err := bp.tunnel.Close()
+	bp.tunnel = nil
+	if err != nil {
+		return fmt.Errorf("failed to close backend tunnel: %w", err)
+	}
+	return nil
+}
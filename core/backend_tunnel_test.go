@@ -0,0 +1,57 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+)
+
+// fakeTunnel reports a fixed health and counts Close calls
+type fakeTunnel struct {
+	healthy bool
+	closed  int
+}
+
+func (f *fakeTunnel) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	return nil, errors.New("not dialable")
+}
+
+func (f *fakeTunnel) Check(ctx context.Context) CheckResult {
+	return CheckResult{Name: "tunnel", Passed: f.healthy}
+}
+
+func (f *fakeTunnel) Close() error {
+	f.closed++
+	return nil
+}
+
+// TestBaseProviderTunnel validates tunnels are replaced, health checked and closed
+func TestBaseProviderTunnel(t *testing.T) {
+	bp := NewBaseProvider("test")
+	first := &fakeTunnel{healthy: true}
+	second := &fakeTunnel{}
+
+	if err := bp.SetTunnel(first); err != nil {
+		t.Fatal(err)
+	}
+	if err := bp.SetTunnel(second); err != nil {
+		t.Fatal(err)
+	}
+	if first.closed != 1 {
+		t.Errorf("expected the replaced tunnel to be closed, got %d closes", first.closed)
+	}
+
+	status := &HealthStatus{Healthy: true, Status: "healthy"}
+	bp.CheckTunnel(context.Background(), status)
+	if status.Healthy || status.Checks["tunnel"].Passed {
+		t.Errorf("expected a failed tunnel check to mark the status unhealthy: %+v", status)
+	}
+
+	if err := bp.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if second.closed != 1 || bp.Tunnel() != nil {
+		t.Errorf("expected Close to close the tunnel, got %d closes", second.closed)
+	}
+}
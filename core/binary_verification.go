@@ -0,0 +1,209 @@
+// Package core provides checksum and signature verification of provider binaries
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ChecksumsFileName is the checksum file published next to provider binaries, in the
+// format of sha256sum: one "<hex>  <file name>" line per release artifact
+const ChecksumsFileName = "SHA256SUMS"
+
+// ChecksumsSignatureSuffix is appended to the checksum file name for its detached
+// ed25519 signature, stored raw or base64 encoded
+const ChecksumsSignatureSuffix = ".sig"
+
+// Binary verification errors
+var (
+	ErrBinaryNotListed    = errors.New("binary is not listed in the checksum file")
+	ErrChecksumMismatch   = errors.New("binary checksum does not match the checksum file")
+	ErrUntrustedSignature = errors.New("checksum file is not signed by a trusted publisher key")
+)
+
+// TrustStore holds the publisher keys trusted to sign provider checksum files
+type TrustStore struct {
+	keys map[string]ed25519.PublicKey
+}
+
+// NewTrustStore returns an empty trust store
+func NewTrustStore() *TrustStore {
+	return &TrustStore{keys: make(map[string]ed25519.PublicKey)}
+}
+
+// DefaultTrustStoreDir is where publisher keys are read from when no directory is given
+func DefaultTrustStoreDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".kolumn", "trusted-keys")
+}
+
+// LoadTrustStore reads every PEM encoded ed25519 public key (*.pem, *.pub) in dir; each
+// key is identified by its file name without the extension. A missing directory yields
+// an empty store.
+func LoadTrustStore(dir string) (*TrustStore, error) {
+	store := NewTrustStore()
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".pem" && ext != ".pub") {
+			continue
+		}
+		key, err := LoadVerificationKey(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		store.Add(strings.TrimSuffix(entry.Name(), ext), key)
+	}
+	return store, nil
+}
+
+// Add trusts a publisher key under an identifier
+func (s *TrustStore) Add(keyID string, key ed25519.PublicKey) {
+	s.keys[keyID] = key
+}
+
+// KeyIDs returns the identifiers of the trusted keys, sorted
+func (s *TrustStore) KeyIDs() []string {
+	return sortedStringKeys(s.keys)
+}
+
+// Len returns the number of trusted keys
+func (s *TrustStore) Len() int {
+	return len(s.keys)
+}
+
+// verify returns the identifier of the trusted key that signed data
+func (s *TrustStore) verify(data, signature []byte) (string, bool) {
+	for _, keyID := range s.KeyIDs() {
+		if ed25519.Verify(s.keys[keyID], data, signature) {
+			return keyID, true
+		}
+	}
+	return "", false
+}
+
+// BinaryVerification describes a verified provider binary
+type BinaryVerification struct {
+	Binary        string `json:"binary"`
+	Digest        string `json:"digest"` // sha256:<hex>
+	ChecksumsFile string `json:"checksums_file"`
+	KeyID         string `json:"key_id"` // trusted key that signed the checksum file
+}
+
+// VerifyProviderBinary checks a provider binary against a signed checksum file before it
+// is executed or loaded: the checksum file's detached signature must verify with a key
+// in the trust store, and the binary's sha256 must match its entry. checksumsFile
+// defaults to SHA256SUMS next to the binary.
+func VerifyProviderBinary(binary, checksumsFile string, trust *TrustStore) (*BinaryVerification, error) {
+	if checksumsFile == "" {
+		checksumsFile = filepath.Join(filepath.Dir(binary), ChecksumsFileName)
+	}
+	sums, err := os.ReadFile(checksumsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checksum file: %w", err)
+	}
+	signature, err := readDetachedSignature(checksumsFile + ChecksumsSignatureSuffix)
+	if err != nil {
+		return nil, err
+	}
+	if trust == nil || trust.Len() == 0 {
+		return nil, fmt.Errorf("%w: the trust store is empty", ErrUntrustedSignature)
+	}
+	keyID, ok := trust.verify(sums, signature)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUntrustedSignature, checksumsFile)
+	}
+
+	expected, err := ParseChecksums(sums)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", checksumsFile, err)
+	}
+	want, ok := expected[filepath.Base(binary)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrBinaryNotListed, filepath.Base(binary))
+	}
+	digest, err := DigestFile(binary)
+	if err != nil {
+		return nil, err
+	}
+	if digest != "sha256:"+want {
+		return nil, fmt.Errorf("%w: %s is %s", ErrChecksumMismatch, filepath.Base(binary), digest)
+	}
+	return &BinaryVerification{Binary: binary, Digest: digest, ChecksumsFile: checksumsFile, KeyID: keyID}, nil
+}
+
+// ParseChecksums parses sha256sum output into lowercase hex digests by file name. Binary
+// mode entries ("<hex> *<file name>") are accepted.
+func ParseChecksums(data []byte) (map[string]string, error) {
+	sums := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		digest, name, ok := strings.Cut(text, " ")
+		name = strings.TrimPrefix(strings.TrimSpace(name), "*")
+		if !ok || len(digest) != 64 || name == "" {
+			return nil, fmt.Errorf("line %d: malformed checksum entry", line)
+		}
+		sums[name] = strings.ToLower(digest)
+	}
+	return sums, scanner.Err()
+}
+
+// WriteChecksums writes a SHA256SUMS file for release artifacts and, when key is set,
+// its detached signature
+func WriteChecksums(path string, artifacts []string, key ed25519.PrivateKey) error {
+	lines := make([]string, 0, len(artifacts))
+	for _, artifact := range artifacts {
+		digest, err := DigestFile(artifact)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, strings.TrimPrefix(digest, "sha256:")+"  "+filepath.Base(artifact))
+	}
+	sort.Slice(lines, func(i, j int) bool { return lines[i][66:] < lines[j][66:] })
+	data := []byte(strings.Join(lines, "\n") + "\n")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return err
+	}
+	if key == nil {
+		return nil
+	}
+	signature := base64.StdEncoding.EncodeToString(ed25519.Sign(key, data))
+	return os.WriteFile(path+ChecksumsSignatureSuffix, []byte(signature+"\n"), 0o644)
+}
+
+// readDetachedSignature reads a raw or base64 encoded ed25519 signature
+func readDetachedSignature(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checksum signature: %w", err)
+	}
+	if len(data) == ed25519.SignatureSize {
+		return data, nil
+	}
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(signature) != ed25519.SignatureSize {
+		return nil, fmt.Errorf("checksum signature %s is not an ed25519 signature", path)
+	}
+	return signature, nil
+}
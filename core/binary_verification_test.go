@@ -0,0 +1,72 @@
+package core
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestVerifyProviderBinary validates signed checksums gate provider binaries
+func TestVerifyProviderBinary(t *testing.T) {
+	dir := t.TempDir()
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	binary := filepath.Join(dir, "kolumn-provider-test")
+	other := filepath.Join(dir, "kolumn-provider-other")
+	for _, path := range []string{binary, other} {
+		if err := os.WriteFile(path, []byte(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := WriteChecksums(filepath.Join(dir, ChecksumsFileName), []string{binary}, priv); err != nil {
+		t.Fatalf("WriteChecksums: %v", err)
+	}
+
+	// the trust store reads PEM public keys named after their key ID
+	keys := t.TempDir()
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(keys, "acme.pub"), pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	trust, err := LoadTrustStore(keys)
+	if err != nil || trust.Len() != 1 {
+		t.Fatalf("LoadTrustStore = %v, %v", trust, err)
+	}
+
+	verified, err := VerifyProviderBinary(binary, "", trust)
+	if err != nil {
+		t.Fatalf("VerifyProviderBinary: %v", err)
+	}
+	if verified.KeyID != "acme" || verified.ChecksumsFile != filepath.Join(dir, ChecksumsFileName) {
+		t.Errorf("unexpected verification %+v", verified)
+	}
+
+	if _, err := VerifyProviderBinary(other, "", trust); !errors.Is(err, ErrBinaryNotListed) {
+		t.Errorf("unlisted binary: %v", err)
+	}
+	untrusted, _, _ := ed25519.GenerateKey(nil)
+	stranger := NewTrustStore()
+	stranger.Add("stranger", untrusted)
+	if _, err := VerifyProviderBinary(binary, "", stranger); !errors.Is(err, ErrUntrustedSignature) {
+		t.Errorf("untrusted signature: %v", err)
+	}
+	if _, err := VerifyProviderBinary(binary, "", NewTrustStore()); !errors.Is(err, ErrUntrustedSignature) {
+		t.Errorf("empty trust store: %v", err)
+	}
+
+	if err := os.WriteFile(binary, []byte("tampered"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyProviderBinary(binary, "", trust); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("tampered binary: %v", err)
+	}
+}
@@ -0,0 +1,167 @@
+// Package core provides build metadata, dependency SBOM and provenance collection
+package core
+
+import (
+	"debug/buildinfo"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"time"
+)
+
+// ModuleDependency is a single Go module linked into a binary
+type ModuleDependency struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+	Sum     string `json:"sum,omitempty"`     // go.sum hash (h1:...)
+	Replace string `json:"replace,omitempty"` // replacement path@version, if any
+}
+
+// BuildProvenance records where and how a binary was built, following the
+// fields of the SLSA provenance predicate
+type BuildProvenance struct {
+	BuilderID    string            `json:"builder_id,omitempty"`    // e.g. https://github.com/actions/runner
+	BuildType    string            `json:"build_type,omitempty"`    // e.g. go build
+	InvocationID string            `json:"invocation_id,omitempty"` // CI run identifier
+	SourceURI    string            `json:"source_uri,omitempty"`    // repository URL
+	VCSRevision  string            `json:"vcs_revision,omitempty"`
+	VCSTime      time.Time         `json:"vcs_time,omitempty"`
+	VCSModified  bool              `json:"vcs_modified"`
+	Settings     map[string]string `json:"settings,omitempty"` // go build settings (-tags, CGO_ENABLED, GOOS, ...)
+}
+
+// Provenance values that release pipelines can inject with
+// -ldflags "-X github.com/schemabounce/kolumn/sdk/core.BuildBuilderID=..."
+var (
+	BuildBuilderID    string
+	BuildInvocationID string
+	BuildSourceURI    string
+)
+
+// GetBuildInfo returns build metadata for the running binary, including the
+// dependency SBOM and VCS provenance embedded by the Go toolchain
+func GetBuildInfo() *BuildInfo {
+	info := &BuildInfo{
+		GoVersion: runtime.Version(),
+		Platform:  fmt.Sprintf("%s/%s", runtime.GOOS, runtime.GOARCH),
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		platform := info.Platform
+		info = BuildInfoFromDebug(bi)
+		if info.Platform == "" {
+			info.Platform = platform
+		}
+	}
+
+	if BuildBuilderID != "" || BuildInvocationID != "" || BuildSourceURI != "" {
+		if info.Provenance == nil {
+			info.Provenance = &BuildProvenance{}
+		}
+		if BuildBuilderID != "" {
+			info.Provenance.BuilderID = BuildBuilderID
+		}
+		if BuildInvocationID != "" {
+			info.Provenance.InvocationID = BuildInvocationID
+		}
+		if BuildSourceURI != "" {
+			info.Provenance.SourceURI = BuildSourceURI
+		}
+	}
+	return info
+}
+
+// ReadBinaryBuildInfo extracts build metadata from a compiled Go binary on disk
+func ReadBinaryBuildInfo(path string) (*BuildInfo, error) {
+	bi, err := buildinfo.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read build info from %s: %w", path, err)
+	}
+	return BuildInfoFromDebug(bi), nil
+}
+
+// BuildInfoFromDebug converts Go toolchain build info into BuildInfo
+func BuildInfoFromDebug(bi *debug.BuildInfo) *BuildInfo {
+	info := &BuildInfo{
+		GoVersion:     bi.GoVersion,
+		ModulePath:    bi.Main.Path,
+		ModuleVersion: bi.Main.Version,
+	}
+
+	for _, dep := range bi.Deps {
+		md := ModuleDependency{Path: dep.Path, Version: dep.Version, Sum: dep.Sum}
+		if dep.Replace != nil {
+			md.Replace = dep.Replace.Path + "@" + dep.Replace.Version
+			if dep.Replace.Sum != "" {
+				md.Sum = dep.Replace.Sum
+			}
+		}
+		info.Dependencies = append(info.Dependencies, md)
+	}
+	sort.Slice(info.Dependencies, func(i, j int) bool {
+		return info.Dependencies[i].Path < info.Dependencies[j].Path
+	})
+
+	provenance := &BuildProvenance{
+		BuildType: "go build",
+		Settings:  make(map[string]string),
+	}
+	var goos, goarch string
+	for _, setting := range bi.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			provenance.VCSRevision = setting.Value
+			info.CommitHash = setting.Value
+		case "vcs.time":
+			if t, err := time.Parse(time.RFC3339, setting.Value); err == nil {
+				provenance.VCSTime = t
+			}
+		case "vcs.modified":
+			provenance.VCSModified = setting.Value == "true"
+		case "GOOS":
+			goos = setting.Value
+			provenance.Settings[setting.Key] = setting.Value
+		case "GOARCH":
+			goarch = setting.Value
+			provenance.Settings[setting.Key] = setting.Value
+		default:
+			provenance.Settings[setting.Key] = setting.Value
+		}
+	}
+	if goos != "" && goarch != "" {
+		info.Platform = goos + "/" + goarch
+	}
+	info.Provenance = provenance
+
+	return info
+}
+
+// ProvenanceFromEnvironment fills builder identity from well-known CI environment
+// variables. Call it from build-time tooling such as docs-gen, not at runtime.
+func ProvenanceFromEnvironment(provenance *BuildProvenance) *BuildProvenance {
+	if provenance == nil {
+		provenance = &BuildProvenance{}
+	}
+
+	switch {
+	case os.Getenv("GITHUB_ACTIONS") == "true":
+		provenance.BuilderID = "https://github.com/actions/runner"
+		if server, repo := os.Getenv("GITHUB_SERVER_URL"), os.Getenv("GITHUB_REPOSITORY"); server != "" && repo != "" {
+			provenance.SourceURI = server + "/" + repo
+			if runID := os.Getenv("GITHUB_RUN_ID"); runID != "" {
+				provenance.InvocationID = fmt.Sprintf("%s/%s/actions/runs/%s/attempts/%s",
+					server, repo, runID, os.Getenv("GITHUB_RUN_ATTEMPT"))
+			}
+		}
+	case os.Getenv("GITLAB_CI") == "true":
+		provenance.BuilderID = "https://gitlab.com/gitlab-org/gitlab-runner"
+		provenance.SourceURI = os.Getenv("CI_PROJECT_URL")
+		provenance.InvocationID = os.Getenv("CI_JOB_URL")
+	default:
+		if host, err := os.Hostname(); err == nil && provenance.BuilderID == "" {
+			provenance.BuilderID = "local://" + host
+		}
+	}
+	return provenance
+}
@@ -0,0 +1,68 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"runtime/debug"
+	"testing"
+)
+
+// TestBuildInfoFromDebug validates dependencies and VCS settings are captured
+func TestBuildInfoFromDebug(t *testing.T) {
+	bi := &debug.BuildInfo{
+		GoVersion: "go1.22.0",
+		Main:      debug.Module{Path: "github.com/example/kolumn-provider-test", Version: "v1.2.3"},
+		Deps: []*debug.Module{
+			{Path: "github.com/stretchr/testify", Version: "v1.9.0", Sum: "h1:abc"},
+			{Path: "github.com/flosch/pongo2/v6", Version: "v6.0.0", Sum: "h1:old",
+				Replace: &debug.Module{Path: "../pongo2", Version: "v6.0.1", Sum: "h1:new"}},
+		},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "deadbeef"},
+			{Key: "vcs.time", Value: "2024-05-01T10:00:00Z"},
+			{Key: "vcs.modified", Value: "true"},
+			{Key: "GOOS", Value: "linux"},
+			{Key: "GOARCH", Value: "arm64"},
+			{Key: "CGO_ENABLED", Value: "0"},
+		},
+	}
+
+	info := BuildInfoFromDebug(bi)
+
+	if info.CommitHash != "deadbeef" || info.Platform != "linux/arm64" {
+		t.Errorf("Unexpected commit/platform: %s %s", info.CommitHash, info.Platform)
+	}
+	if len(info.Dependencies) != 2 || info.Dependencies[0].Path != "github.com/flosch/pongo2/v6" {
+		t.Fatalf("Expected sorted dependencies, got %+v", info.Dependencies)
+	}
+	if dep := info.Dependencies[0]; dep.Replace != "../pongo2@v6.0.1" || dep.Sum != "h1:new" {
+		t.Errorf("Expected replacement to be recorded, got %+v", dep)
+	}
+	if !info.Provenance.VCSModified || info.Provenance.Settings["CGO_ENABLED"] != "0" {
+		t.Errorf("Unexpected provenance: %+v", info.Provenance)
+	}
+}
+
+// TestGetBuildInfo validates the running binary reports its toolchain
+func TestGetBuildInfo(t *testing.T) {
+	info := GetBuildInfo()
+	if info.GoVersion == "" || info.Platform == "" {
+		t.Errorf("Expected Go version and platform, got %+v", info)
+	}
+}
+
+// TestDispatchGetBuildInfo validates build metadata is served through Dispatch
+func TestDispatchGetBuildInfo(t *testing.T) {
+	d := NewUnifiedDispatcher(nil, nil)
+	out, err := d.Dispatch(context.Background(), "GetBuildInfo", []byte(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var info BuildInfo
+	if err := json.Unmarshal(out, &info); err != nil {
+		t.Fatalf("GetBuildInfo output is not build info: %v", err)
+	}
+	if want := GetBuildInfo(); info.GoVersion != want.GoVersion || info.Platform != want.Platform {
+		t.Errorf("GetBuildInfo = %+v, want %+v", info, want)
+	}
+}
@@ -0,0 +1,166 @@
+// Package core provides structured provider capability documentation
+package core
+
+import (
+	"sort"
+	"strings"
+)
+
+// Capability names shared by every provider's capability matrix
+const (
+	CapabilityCreate   = "create"
+	CapabilityRead     = "read"
+	CapabilityUpdate   = "update"
+	CapabilityDelete   = "delete"
+	CapabilityImport   = "import"
+	CapabilityDrift    = "drift"
+	CapabilityPlan     = "plan"
+	CapabilityDiscover = "discover"
+	CapabilityBackup   = "backup"
+	CapabilityRefresh  = "refresh"
+)
+
+// capabilityFunctions maps dispatcher functions to the capability they provide
+var capabilityFunctions = map[string]string{
+	"CreateResource":     CapabilityCreate,
+	"ReadResource":       CapabilityRead,
+	"UpdateResource":     CapabilityUpdate,
+	"DeleteResource":     CapabilityDelete,
+	"DiscoverResources":  CapabilityDiscover,
+	"DiscoverDatabase":   CapabilityDiscover,
+	"RefreshAll":         CapabilityRefresh,
+	"GetOutputs":         CapabilityRead,
+	"GetOperationStatus": CapabilityRead,
+	"StartScan":          CapabilityDiscover,
+	"GetScanStatus":      CapabilityDiscover,
+	"CancelScan":         CapabilityDiscover,
+}
+
+// CapabilityDocs is the capabilities section of the registry documentation. Features
+// always lists every standard capability so registry UIs can line up providers in a
+// feature comparison matrix.
+type CapabilityDocs struct {
+	Functions    []string                `json:"functions,omitempty"`
+	Features     map[string]bool         `json:"features"`
+	Resources    map[string][]string     `json:"resources,omitempty"` // resource type -> capabilities
+	Governance   *GovernanceCapabilities `json:"governance,omitempty"`
+	Experimental []string                `json:"experimental,omitempty"` // feature flag names
+
+	// BackendFeatures lists, per backend feature, the resource attributes that need it
+	// as "type.attribute"
+	BackendFeatures map[string][]string `json:"backend_features,omitempty"`
+}
+
+// BuildCapabilityDocs derives the capabilities section from a schema's supported
+// functions and resource type operations (which list optional handlers such as import
+// and drift), plus the governance capabilities when the provider reports them
+func BuildCapabilityDocs(schema *Schema, governance *GovernanceCapabilities) *CapabilityDocs {
+	docs := &CapabilityDocs{Features: make(map[string]bool), Governance: governance}
+	for _, c := range []string{CapabilityCreate, CapabilityRead, CapabilityUpdate, CapabilityDelete,
+		CapabilityImport, CapabilityDrift, CapabilityPlan, CapabilityDiscover, CapabilityBackup, CapabilityRefresh} {
+		docs.Features[c] = false
+	}
+	if schema == nil {
+		return docs
+	}
+
+	docs.Functions = append([]string(nil), schema.SupportedFunctions...)
+	sort.Strings(docs.Functions)
+	for _, fn := range docs.Functions {
+		if c, ok := capabilityFunctions[fn]; ok {
+			docs.Features[c] = true
+		} else if strings.Contains(strings.ToLower(fn), CapabilityBackup) {
+			docs.Features[CapabilityBackup] = true
+		}
+	}
+
+	add := func(resourceType string, operations []string) {
+		if docs.Resources == nil {
+			docs.Resources = make(map[string][]string)
+		}
+		seen := stringSet(docs.Resources[resourceType])
+		for _, op := range operations {
+			op = strings.ToLower(strings.TrimSpace(op))
+			if op == "" {
+				continue
+			}
+			docs.Features[op] = true
+			if !seen[op] {
+				seen[op] = true
+				docs.Resources[resourceType] = append(docs.Resources[resourceType], op)
+			}
+		}
+		sort.Strings(docs.Resources[resourceType])
+	}
+	for _, rt := range schema.ResourceTypes {
+		add(rt.Name, rt.Operations)
+	}
+	for name := range schema.CreateObjects {
+		add(name, []string{CapabilityCreate, CapabilityRead, CapabilityUpdate, CapabilityDelete})
+	}
+	for name := range schema.DiscoverObjects {
+		add(name, []string{CapabilityDiscover})
+	}
+
+	if governance != nil {
+		docs.Features["governance.encryption"] = governance.SupportsEncryption
+		docs.Features["governance.access_controls"] = governance.SupportsAccessControls
+		docs.Features["governance.audit_logging"] = governance.SupportsAuditLogging
+		docs.Features["governance.data_masking"] = governance.SupportsDataMasking
+		docs.Features["governance.row_level_security"] = governance.SupportsRowLevelSecurity
+	}
+	for _, flag := range schema.FeatureFlags {
+		docs.Experimental = append(docs.Experimental, flag.Name)
+	}
+	sort.Strings(docs.Experimental)
+	for _, feature := range schema.BackendFeatures {
+		if docs.BackendFeatures == nil {
+			docs.BackendFeatures = make(map[string][]string)
+		}
+		attributes := []string{}
+		for resourceType, names := range feature.Attributes {
+			for _, name := range names {
+				attributes = append(attributes, resourceType+"."+name)
+			}
+		}
+		sort.Strings(attributes)
+		docs.BackendFeatures[feature.Name] = attributes
+	}
+	return docs
+}
+
+// Merge folds the capabilities of another binary of the same provider into c
+func (c *CapabilityDocs) Merge(other *CapabilityDocs) {
+	if other == nil {
+		return
+	}
+	c.Functions = mergeKeywords(c.Functions, other.Functions)
+	sort.Strings(c.Functions)
+	if c.Features == nil {
+		c.Features = make(map[string]bool, len(other.Features))
+	}
+	for feature, supported := range other.Features {
+		c.Features[feature] = c.Features[feature] || supported
+	}
+	for resourceType, caps := range other.Resources {
+		if c.Resources == nil {
+			c.Resources = make(map[string][]string)
+		}
+		merged := mergeKeywords(c.Resources[resourceType], caps)
+		sort.Strings(merged)
+		c.Resources[resourceType] = merged
+	}
+	if c.Governance == nil {
+		c.Governance = other.Governance
+	}
+	c.Experimental = mergeKeywords(c.Experimental, other.Experimental)
+	sort.Strings(c.Experimental)
+	for feature, attributes := range other.BackendFeatures {
+		if c.BackendFeatures == nil {
+			c.BackendFeatures = make(map[string][]string)
+		}
+		merged := mergeKeywords(c.BackendFeatures[feature], attributes)
+		sort.Strings(merged)
+		c.BackendFeatures[feature] = merged
+	}
+}
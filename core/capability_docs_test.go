@@ -0,0 +1,32 @@
+package core
+
+import "testing"
+
+// TestBuildCapabilityDocs validates the capability matrix derived from a schema
+func TestBuildCapabilityDocs(t *testing.T) {
+	schema := &Schema{
+		SupportedFunctions: []string{"CreateResource", "ReadResource", "CreateBackup"},
+		ResourceTypes: []ResourceTypeDefinition{
+			{Name: "table", Operations: []string{"create", "read", "Import", "drift"}},
+		},
+		FeatureFlags: []FeatureFlag{{Name: "iceberg"}},
+	}
+	docs := BuildCapabilityDocs(schema, &GovernanceCapabilities{SupportsEncryption: true})
+
+	for _, c := range []string{CapabilityCreate, CapabilityRead, CapabilityImport, CapabilityDrift, CapabilityBackup, "governance.encryption"} {
+		if !docs.Features[c] {
+			t.Errorf("expected %s to be supported", c)
+		}
+	}
+	if supported, listed := docs.Features[CapabilityDelete]; supported || !listed {
+		t.Errorf("delete should be listed as unsupported: %v", docs.Features)
+	}
+	if got := docs.Resources["table"]; len(got) != 4 || got[2] != "import" {
+		t.Errorf("unexpected table capabilities: %v", got)
+	}
+
+	docs.Merge(BuildCapabilityDocs(&Schema{SupportedFunctions: []string{"DiscoverResources"}}, nil))
+	if !docs.Features[CapabilityDiscover] || !docs.Features[CapabilityImport] {
+		t.Errorf("merge lost or missed capabilities: %v", docs.Features)
+	}
+}
@@ -0,0 +1,13 @@
+// Package core provides the clock used for timestamps in governance, audit and metrics
+package core
+
+import "github.com/schemabounce/kolumn/sdk/types"
+
+// clock supplies every timestamp and duration recorded by this package
+var clock types.ClockVar
+
+// SetClock replaces the clock used by core, or restores the system clock when nil. It
+// returns a function restoring the previous clock; tests typically defer it.
+func SetClock(c types.Clock) (restore func()) {
+	return clock.Set(c)
+}
@@ -0,0 +1,325 @@
+// Package core provides application of governance column transforms to resource configs
+package core
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// AppliedColumnTransform records one attribute a transform changed
+type AppliedColumnTransform struct {
+	Column    string      `json:"column"`
+	Attribute string      `json:"attribute"`
+	Before    interface{} `json:"before,omitempty"`
+	After     interface{} `json:"after"`
+}
+
+// ColumnTransformConflict is two transforms setting the same column attribute to
+// different values, or a combination of values that cannot hold at once
+type ColumnTransformConflict struct {
+	Column    string      `json:"column"`
+	Attribute string      `json:"attribute"`
+	First     interface{} `json:"first"`
+	Second    interface{} `json:"second"`
+	Message   string      `json:"message"`
+}
+
+// ColumnTransformConflictError reports every conflict found while applying transforms
+type ColumnTransformConflictError struct {
+	Conflicts []ColumnTransformConflict
+}
+
+func (e *ColumnTransformConflictError) Error() string {
+	messages := make([]string, len(e.Conflicts))
+	for i, c := range e.Conflicts {
+		messages[i] = c.Message
+	}
+	return "conflicting column transforms: " + strings.Join(messages, "; ")
+}
+
+// ColumnTransformEngine applies the ColumnTransforms of a governance context to
+// resource configurations for one provider type
+type ColumnTransformEngine struct {
+	providerType string
+}
+
+// NewColumnTransformEngine creates an engine that resolves provider overrides for providerType
+func NewColumnTransformEngine(providerType string) *ColumnTransformEngine {
+	return &ColumnTransformEngine{providerType: providerType}
+}
+
+// columnAttributes fixes the order attributes are applied and reported in
+var columnAttributes = []string{"type", "required", "primary_key", "unique", "nullable", "default", "classifications"}
+
+// Resolve returns a transform with this engine's provider override laid over it
+func (e *ColumnTransformEngine) Resolve(transform ColumnTransform) ColumnTransform {
+	override, ok := transform.ProviderOverrides[e.providerType]
+	if !ok {
+		return transform
+	}
+	resolved := transform
+	resolved.ProviderOverrides = nil
+	if override.Type != "" {
+		resolved.Type = override.Type
+	}
+	if len(override.Classifications) > 0 {
+		resolved.Classifications = override.Classifications
+	}
+	if override.Required != nil {
+		resolved.Required = override.Required
+	}
+	if override.PrimaryKey != nil {
+		resolved.PrimaryKey = override.PrimaryKey
+	}
+	if override.Unique != nil {
+		resolved.Unique = override.Unique
+	}
+	if override.Nullable != nil {
+		resolved.Nullable = override.Nullable
+	}
+	if override.DefaultValue != "" {
+		resolved.DefaultValue = override.DefaultValue
+	}
+	return resolved
+}
+
+// Apply applies the transforms declared for resourceName's columns in governanceCtx
+// to config and returns a new config; config itself is not modified. Columns are
+// processed in config order and each column's transforms in declaration order. When
+// two transforms disagree, nothing is applied and a *ColumnTransformConflictError is
+// returned.
+func (e *ColumnTransformEngine) Apply(resourceName string, config map[string]interface{}, governanceCtx *GovernanceContext) (map[string]interface{}, []AppliedColumnTransform, error) {
+	if governanceCtx == nil {
+		return config, nil, nil
+	}
+	object, ok := governanceCtx.DataObjects[resourceName]
+	if !ok || object == nil {
+		return config, nil, nil
+	}
+	governed := make(map[string]ColumnContext, len(object.Columns))
+	for _, col := range object.Columns {
+		governed[col.Name] = col
+	}
+
+	columns, ok := configColumns(config["columns"])
+	if !ok {
+		return config, nil, nil
+	}
+
+	var applied []AppliedColumnTransform
+	var conflicts []ColumnTransformConflict
+	for _, column := range columns {
+		name, _ := column["name"].(string)
+		colCtx, ok := governed[name]
+		if !ok || len(colCtx.Transformations) == 0 {
+			continue
+		}
+
+		changes, colConflicts := e.planColumn(name, column, colCtx)
+		conflicts = append(conflicts, colConflicts...)
+		for _, attr := range columnAttributes {
+			value, ok := changes[attr]
+			if !ok {
+				continue
+			}
+			before, existed := column[attr]
+			if existed && fmt.Sprint(before) == fmt.Sprint(value) {
+				continue
+			}
+			column[attr] = value
+			change := AppliedColumnTransform{Column: name, Attribute: attr, After: value}
+			if existed {
+				change.Before = before
+			}
+			applied = append(applied, change)
+		}
+	}
+	if len(conflicts) > 0 {
+		return nil, nil, &ColumnTransformConflictError{Conflicts: conflicts}
+	}
+
+	out := make(map[string]interface{}, len(config))
+	for k, v := range config {
+		out[k] = v
+	}
+	list := make([]interface{}, len(columns))
+	for i, c := range columns {
+		list[i] = c
+	}
+	out["columns"] = list
+	return out, applied, nil
+}
+
+// planColumn merges the applicable transforms for one column into a set of attribute
+// changes, recording conflicts between them
+func (e *ColumnTransformEngine) planColumn(name string, column map[string]interface{}, colCtx ColumnContext) (map[string]interface{}, []ColumnTransformConflict) {
+	classifications := stringSet(colCtx.Classifications)
+	for _, c := range toStringSlice(column["classifications"]) {
+		classifications[c] = true
+	}
+
+	changes := make(map[string]interface{})
+	var conflicts []ColumnTransformConflict
+	set := func(attr string, value interface{}) {
+		if existing, ok := changes[attr]; ok && existing != value {
+			conflicts = append(conflicts, ColumnTransformConflict{
+				Column: name, Attribute: attr, First: existing, Second: value,
+				Message: fmt.Sprintf("column %s: %s set to both %v and %v", name, attr, existing, value),
+			})
+			return
+		}
+		changes[attr] = value
+	}
+
+	var added []string
+	for _, raw := range colCtx.Transformations {
+		t := e.Resolve(raw)
+		if !appliesTo(t, classifications) {
+			continue
+		}
+		if t.Type != "" {
+			set("type", t.Type)
+		}
+		if t.Required != nil {
+			set("required", *t.Required)
+		}
+		if t.PrimaryKey != nil {
+			set("primary_key", *t.PrimaryKey)
+		}
+		if t.Unique != nil {
+			set("unique", *t.Unique)
+		}
+		if t.Nullable != nil {
+			set("nullable", *t.Nullable)
+		}
+		if t.DefaultValue != "" {
+			set("default", t.DefaultValue)
+		}
+	}
+	for _, c := range colCtx.Classifications {
+		if !containsString(toStringSlice(column["classifications"]), c) && !containsString(added, c) {
+			added = append(added, c)
+		}
+	}
+	if len(added) > 0 {
+		merged := append(toStringSlice(column["classifications"]), added...)
+		sort.Strings(merged)
+		changes["classifications"] = merged
+	}
+
+	if pk, _ := effectiveBool(changes, column, "primary_key"); pk {
+		if nullable, ok := effectiveBool(changes, column, "nullable"); ok && nullable {
+			conflicts = append(conflicts, ColumnTransformConflict{
+				Column: name, Attribute: "nullable", First: "primary_key=true", Second: "nullable=true",
+				Message: fmt.Sprintf("column %s: a primary key cannot be nullable", name),
+			})
+		}
+	}
+	return changes, conflicts
+}
+
+// appliesTo reports whether a transform scoped to classifications matches the column;
+// a transform without classifications always applies
+func appliesTo(t ColumnTransform, classifications map[string]bool) bool {
+	if len(t.Classifications) == 0 {
+		return true
+	}
+	for _, c := range t.Classifications {
+		if classifications[c] {
+			return true
+		}
+	}
+	return false
+}
+
+func effectiveBool(changes, column map[string]interface{}, attr string) (bool, bool) {
+	if v, ok := changes[attr].(bool); ok {
+		return v, true
+	}
+	v, ok := column[attr].(bool)
+	return v, ok
+}
+
+// configColumns copies the columns of a resource config into mutable maps
+func configColumns(raw interface{}) ([]map[string]interface{}, bool) {
+	var columns []map[string]interface{}
+	switch list := raw.(type) {
+	case []interface{}:
+		for _, item := range list {
+			m, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			columns = append(columns, m)
+		}
+	case []map[string]interface{}:
+		columns = list
+	default:
+		return nil, false
+	}
+
+	copies := make([]map[string]interface{}, len(columns))
+	for i, col := range columns {
+		c := make(map[string]interface{}, len(col))
+		for k, v := range col {
+			c[k] = v
+		}
+		copies[i] = c
+	}
+	return copies, true
+}
+
+func toStringSlice(v interface{}) []string {
+	switch list := v.(type) {
+	case []string:
+		return append([]string(nil), list...)
+	case []interface{}:
+		out := make([]string, 0, len(list))
+		for _, item := range list {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func stringSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyGovernanceRules is a default ApplyGovernanceRules for GovernanceAwareProvider
+// implementations: it applies column transforms for the resource named in
+// config["name"], then classification-driven encryption
+func (gh *GovernanceHelper) ApplyGovernanceRules(ctx context.Context, resourceType string, config map[string]interface{}, governanceCtx *GovernanceContext) (map[string]interface{}, error) {
+	name, _ := config["name"].(string)
+	transformed, _, err := NewColumnTransformEngine(gh.providerType).Apply(name, config, governanceCtx)
+	if err != nil {
+		return nil, err
+	}
+	if governanceCtx == nil {
+		return transformed, nil
+	}
+
+	requirements, err := gh.ExtractGovernanceRequirements(ctx, resourceType, transformed, governanceCtx)
+	if err != nil {
+		return nil, err
+	}
+	return gh.ApplyEncryptionRules(transformed, requirements)
+}
@@ -0,0 +1,99 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func boolPtr(b bool) *bool { return &b }
+
+func transformGovernanceContext(transforms ...ColumnTransform) *GovernanceContext {
+	return &GovernanceContext{
+		DataObjects: map[string]*DataObjectContext{
+			"users": {Name: "users", Columns: []ColumnContext{
+				{Name: "email", Classifications: []string{"pii"}, Transformations: transforms},
+			}},
+		},
+	}
+}
+
+// TestColumnTransformEngineApply validates flags, defaults and provider overrides
+func TestColumnTransformEngineApply(t *testing.T) {
+	gc := transformGovernanceContext(
+		ColumnTransform{Nullable: boolPtr(false), DefaultValue: "'unknown'"},
+		ColumnTransform{
+			Classifications:   []string{"pii"},
+			Unique:            boolPtr(true),
+			ProviderOverrides: map[string]ColumnTransform{"postgres": {Type: "citext"}},
+		},
+		ColumnTransform{Classifications: []string{"financial"}, Type: "bytea"},
+	)
+	config := map[string]interface{}{
+		"name": "users",
+		"columns": []interface{}{
+			map[string]interface{}{"name": "id", "type": "bigint"},
+			map[string]interface{}{"name": "email", "type": "text", "nullable": true},
+		},
+	}
+
+	out, applied, err := NewColumnTransformEngine("postgres").Apply("users", config, gc)
+	if err != nil {
+		t.Fatalf("apply failed: %v", err)
+	}
+	email := out["columns"].([]interface{})[1].(map[string]interface{})
+	if email["type"] != "citext" || email["nullable"] != false || email["unique"] != true || email["default"] != "'unknown'" {
+		t.Errorf("unexpected transformed column %v", email)
+	}
+	if config["columns"].([]interface{})[1].(map[string]interface{})["type"] != "text" {
+		t.Errorf("expected input config to be left unchanged")
+	}
+
+	order := []string{}
+	for _, a := range applied {
+		order = append(order, a.Attribute)
+	}
+	expected := []string{"type", "unique", "nullable", "default", "classifications"}
+	if len(order) != len(expected) {
+		t.Fatalf("expected changes %v, got %v", expected, order)
+	}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Errorf("expected deterministic order %v, got %v", expected, order)
+			break
+		}
+	}
+
+	out, _, err = NewColumnTransformEngine("mysql").Apply("users", config, gc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if email := out["columns"].([]interface{})[1].(map[string]interface{}); email["type"] != "text" {
+		t.Errorf("expected postgres override not to apply to mysql, got %v", email["type"])
+	}
+}
+
+// TestColumnTransformEngineConflicts validates conflict detection
+func TestColumnTransformEngineConflicts(t *testing.T) {
+	gc := transformGovernanceContext(
+		ColumnTransform{Nullable: boolPtr(false)},
+		ColumnTransform{Classifications: []string{"pii"}, Nullable: boolPtr(true), PrimaryKey: boolPtr(true)},
+	)
+	config := map[string]interface{}{
+		"columns": []interface{}{map[string]interface{}{"name": "email"}},
+	}
+
+	_, _, err := NewColumnTransformEngine("postgres").Apply("users", config, gc)
+	var conflictErr *ColumnTransformConflictError
+	if !errors.As(err, &conflictErr) {
+		t.Fatalf("expected conflict error, got %v", err)
+	}
+	if len(conflictErr.Conflicts) != 1 || conflictErr.Conflicts[0].Attribute != "nullable" {
+		t.Errorf("unexpected conflicts %+v", conflictErr.Conflicts)
+	}
+
+	helper := NewGovernanceHelper("postgres", nil)
+	if _, err := helper.ApplyGovernanceRules(context.Background(), "table", map[string]interface{}{"name": "users", "columns": config["columns"]}, gc); err == nil {
+		t.Errorf("expected ApplyGovernanceRules to surface the conflict")
+	}
+}
@@ -0,0 +1,316 @@
+// Package core provides negotiated compression of RPC payloads
+package core
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/schemabounce/kolumn/sdk/helpers/security"
+)
+
+// EncodingGzip is the built-in compression encoding. Other encodings are available
+// once a provider registers a codec for them with RegisterCompressionCodec.
+const EncodingGzip = "gzip"
+
+// DefaultCompressionThreshold is the response size above which payloads are compressed
+const DefaultCompressionThreshold = 32 << 10
+
+// CompressionCodec compresses RPC payloads. Compressed payloads are recognised by the
+// codec's magic bytes, which never begin a JSON document, so compressed and plain
+// payloads can share the CallFunction byte stream without an envelope.
+type CompressionCodec interface {
+	Name() string
+	Magic() []byte
+	Compress(data []byte) ([]byte, error)
+	Decompress(data []byte, limit int64) ([]byte, error)
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[string]CompressionCodec{EncodingGzip: gzipCodec{}}
+)
+
+// RegisterCompressionCodec makes a codec available for negotiation, replacing any codec
+// registered under the same name
+func RegisterCompressionCodec(codec CompressionCodec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	codecs[codec.Name()] = codec
+}
+
+// CompressionCodecs returns the names of the registered codecs
+func CompressionCodecs() []string {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	names := make([]string, 0, len(codecs))
+	for name := range codecs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func compressionCodec(name string) (CompressionCodec, bool) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	codec, ok := codecs[name]
+	return codec, ok
+}
+
+// codecForPayload returns the codec whose magic bytes start data
+func codecForPayload(data []byte) (CompressionCodec, bool) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	for _, codec := range codecs {
+		if magic := codec.Magic(); len(magic) > 0 && bytes.HasPrefix(data, magic) {
+			return codec, true
+		}
+	}
+	return nil, false
+}
+
+// gzipCodec is the built-in gzip codec
+type gzipCodec struct{}
+
+func (gzipCodec) Name() string  { return EncodingGzip }
+func (gzipCodec) Magic() []byte { return []byte{0x1f, 0x8b} }
+
+func (gzipCodec) Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gzipCodec) Decompress(data []byte, limit int64) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return readLimited(r, limit)
+}
+
+// readLimited reads r fully, failing once more than limit bytes are produced so a
+// small compressed payload cannot expand without bound
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	out, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(out)) > limit {
+		return nil, security.ErrInputTooLarge
+	}
+	return out, nil
+}
+
+// CompressionConfig enables payload compression on a dispatcher
+type CompressionConfig struct {
+	// Encodings the provider offers, most preferred first; empty offers every
+	// registered codec in name order
+	Encodings []string
+
+	// Threshold is the response size in bytes above which responses are compressed;
+	// zero uses DefaultCompressionThreshold
+	Threshold int
+}
+
+// CompressionStats reports how much compression saved
+type CompressionStats struct {
+	Encoding            string `json:"encoding,omitempty"` // negotiated without a Session, empty before the handshake
+	RequestsDecoded     int64  `json:"requests_decoded"`
+	ResponsesCompressed int64  `json:"responses_compressed"`
+	ResponsesSkipped    int64  `json:"responses_skipped"` // below the threshold or not smaller
+	UncompressedBytes   int64  `json:"uncompressed_bytes"`
+	CompressedBytes     int64  `json:"compressed_bytes"`
+}
+
+// Ratio returns uncompressed over compressed bytes for compressed responses, zero
+// when nothing was compressed
+func (s CompressionStats) Ratio() float64 {
+	if s.CompressedBytes == 0 {
+		return 0
+	}
+	return float64(s.UncompressedBytes) / float64(s.CompressedBytes)
+}
+
+// payloadCompression holds a dispatcher's compression settings and the encoding
+// negotiated by requests without a Session
+type payloadCompression struct {
+	offered   []string
+	threshold int
+
+	mu       sync.RWMutex
+	encoding string
+
+	requestsDecoded     atomic.Int64
+	responsesCompressed atomic.Int64
+	responsesSkipped    atomic.Int64
+	uncompressedBytes   atomic.Int64
+	compressedBytes     atomic.Int64
+}
+
+// SetCompression enables negotiated payload compression. The client lists the
+// encodings it accepts in the Ping handshake:
+//
+//	{"accept_encoding": ["gzip"]}
+//
+// and the Ping response names the chosen one in "content_encoding". From then on,
+// responses larger than the threshold are compressed with it. The encoding belongs to
+// the request's Session (see WithSession), so transports serving several clients
+// negotiate per connection. Compressed requests are recognised by their magic bytes
+// and always decoded.
+func (d *UnifiedDispatcher) SetCompression(config CompressionConfig) error {
+	offered := config.Encodings
+	if len(offered) == 0 {
+		offered = CompressionCodecs()
+	}
+	var available []string
+	for _, name := range offered {
+		if _, ok := compressionCodec(name); ok {
+			available = append(available, name)
+		} else if len(config.Encodings) > 0 {
+			return fmt.Errorf("compression encoding %q is not registered", name)
+		}
+	}
+	threshold := config.Threshold
+	if threshold <= 0 {
+		threshold = DefaultCompressionThreshold
+	}
+	d.compression = &payloadCompression{offered: available, threshold: threshold}
+	return nil
+}
+
+// CompressionStats returns compression metrics; the zero value when compression is off
+func (d *UnifiedDispatcher) CompressionStats() CompressionStats {
+	c := d.compression
+	if c == nil {
+		return CompressionStats{}
+	}
+	c.mu.RLock()
+	encoding := c.encoding
+	c.mu.RUnlock()
+	return CompressionStats{
+		Encoding:            encoding,
+		RequestsDecoded:     c.requestsDecoded.Load(),
+		ResponsesCompressed: c.responsesCompressed.Load(),
+		ResponsesSkipped:    c.responsesSkipped.Load(),
+		UncompressedBytes:   c.uncompressedBytes.Load(),
+		CompressedBytes:     c.compressedBytes.Load(),
+	}
+}
+
+// negotiate picks the first offered encoding the client accepts and records it on the
+// session. An empty accept list turns compression off for the session.
+func (c *payloadCompression) negotiate(session *Session, accept []string) string {
+	chosen := ""
+	for _, name := range c.offered {
+		if containsString(accept, name) {
+			chosen = name
+			break
+		}
+	}
+	if session != nil {
+		session.setEncoding(chosen)
+		return chosen
+	}
+	c.mu.Lock()
+	c.encoding = chosen
+	c.mu.Unlock()
+	return chosen
+}
+
+// sessionEncoding returns the encoding negotiated for the request's session
+func (c *payloadCompression) sessionEncoding(ctx context.Context) string {
+	if session := SessionFromContext(ctx); session != nil {
+		return session.Encoding()
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.encoding
+}
+
+// decompressRequest decodes a compressed request body; plain JSON passes through
+func (d *UnifiedDispatcher) decompressRequest(input []byte) ([]byte, error) {
+	if d.compression == nil {
+		return input, nil
+	}
+	codec, ok := codecForPayload(input)
+	if !ok {
+		return input, nil
+	}
+	decoded, err := codec.Decompress(input, security.MaxJSONSize)
+	if err != nil {
+		return nil, security.NewSecureError(
+			"invalid request format",
+			fmt.Sprintf("%s request decompression failed: %v", codec.Name(), err),
+			"INVALID_REQUEST",
+		)
+	}
+	d.compression.requestsDecoded.Add(1)
+	return decoded, nil
+}
+
+// compressResponse compresses output with the negotiated encoding when it is above the
+// threshold and compression actually makes it smaller
+func (d *UnifiedDispatcher) compressResponse(ctx context.Context, output []byte) []byte {
+	c := d.compression
+	if c == nil || len(output) == 0 {
+		return output
+	}
+	encoding := c.sessionEncoding(ctx)
+	if encoding == "" {
+		return output
+	}
+	codec, ok := compressionCodec(encoding)
+	if !ok || len(output) < c.threshold {
+		c.responsesSkipped.Add(1)
+		return output
+	}
+	compressed, err := codec.Compress(output)
+	if err != nil || len(compressed) >= len(output) {
+		c.responsesSkipped.Add(1)
+		return output
+	}
+	c.responsesCompressed.Add(1)
+	c.uncompressedBytes.Add(int64(len(output)))
+	c.compressedBytes.Add(int64(len(compressed)))
+	return compressed
+}
+
+// DecompressPayload decodes a payload produced by a negotiated codec, returning plain
+// payloads unchanged. Clients use it on CallFunction responses.
+func DecompressPayload(data []byte, limit int64) ([]byte, error) {
+	codec, ok := codecForPayload(data)
+	if !ok {
+		return data, nil
+	}
+	return codec.Decompress(data, limit)
+}
+
+// pingCompression negotiates the session encoding from a Ping request
+func (d *UnifiedDispatcher) pingCompression(ctx context.Context, input []byte, response map[string]interface{}) {
+	if d.compression == nil {
+		return
+	}
+	var req struct {
+		AcceptEncoding []string `json:"accept_encoding"`
+	}
+	if len(input) == 0 || json.Unmarshal(input, &req) != nil || req.AcceptEncoding == nil {
+		return
+	}
+	response["content_encoding"] = d.compression.negotiate(SessionFromContext(ctx), req.AcceptEncoding)
+	response["compression_threshold"] = d.compression.threshold
+}
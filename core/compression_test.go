@@ -0,0 +1,118 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// bulkyRegistry answers reads with a large, compressible state
+type bulkyRegistry struct {
+	lastInput []byte
+}
+
+func (r *bulkyRegistry) GetObjectTypes() map[string]*ObjectType { return nil }
+
+func (r *bulkyRegistry) CallHandler(ctx context.Context, objectType, method string, input []byte) ([]byte, error) {
+	r.lastInput = input
+	return json.Marshal(ReadResponse{State: map[string]interface{}{"ddl": strings.Repeat("CREATE TABLE t (id bigint);", 2000)}})
+}
+
+// TestCompressionNegotiation validates the Ping handshake, response compression above
+// the threshold and decoding of compressed requests
+func TestCompressionNegotiation(t *testing.T) {
+	ctx := context.Background()
+	registry := &bulkyRegistry{}
+	d := NewUnifiedDispatcher(registry, nil)
+	if err := d.SetCompression(CompressionConfig{Encodings: []string{"brotli"}}); err == nil {
+		t.Fatal("expected unregistered encodings to be rejected")
+	}
+	if err := d.SetCompression(CompressionConfig{Threshold: 1024}); err != nil {
+		t.Fatal(err)
+	}
+
+	read := []byte(`{"resource_type":"table","resource_id":"t1"}`)
+	out, err := d.Dispatch(ctx, "ReadResource", read)
+	if err != nil || !json.Valid(out) {
+		t.Fatalf("responses must stay plain before the handshake: %v", err)
+	}
+
+	out, err = d.Dispatch(ctx, "Ping", []byte(`{"accept_encoding":["zstd","gzip"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ping map[string]interface{}
+	if err := json.Unmarshal(out, &ping); err != nil || ping["content_encoding"] != EncodingGzip {
+		t.Fatalf("expected gzip to be negotiated, got %s", out)
+	}
+
+	out, err = d.Dispatch(ctx, "ReadResource", read)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if json.Valid(out) {
+		t.Fatal("large responses must be compressed after the handshake")
+	}
+	plain, err := DecompressPayload(out, 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp ReadResponse
+	if err := json.Unmarshal(plain, &resp); err != nil || resp.State["ddl"] == nil {
+		t.Fatalf("decompressed response is not the read response: %v", err)
+	}
+
+	compressed, _ := gzipCodec{}.Compress(read)
+	if _, err := d.Dispatch(ctx, "ReadResource", compressed); err != nil {
+		t.Fatalf("compressed request: %v", err)
+	}
+	if !strings.Contains(string(registry.lastInput), `"resource_id":"t1"`) {
+		t.Fatalf("handler saw %q, want the decoded request", registry.lastInput)
+	}
+
+	stats := d.CompressionStats()
+	if stats.Encoding != EncodingGzip || stats.ResponsesCompressed != 2 || stats.RequestsDecoded != 1 || stats.Ratio() < 10 {
+		t.Fatalf("unexpected stats %+v (ratio %.1f)", stats, stats.Ratio())
+	}
+
+	bomb, _ := gzipCodec{}.Compress([]byte(strings.Repeat(" ", 2<<20)))
+	if _, err := d.Dispatch(ctx, "ReadResource", bomb); err == nil {
+		t.Fatal("requests expanding past the JSON size limit must be rejected")
+	}
+}
+
+// TestCompressionPerSession validates one client's handshake does not compress the
+// responses of another client sharing the dispatcher
+func TestCompressionPerSession(t *testing.T) {
+	d := NewUnifiedDispatcher(&bulkyRegistry{}, nil)
+	if err := d.SetCompression(CompressionConfig{Threshold: 1024}); err != nil {
+		t.Fatal(err)
+	}
+	if offered := d.compression.offered; len(offered) != 1 || offered[0] != EncodingGzip {
+		t.Fatalf("default encodings = %v, want only registered codecs", offered)
+	}
+
+	compressing := WithSession(context.Background(), NewSession())
+	plain := WithSession(context.Background(), NewSession())
+	if _, err := d.Dispatch(compressing, "Ping", []byte(`{"accept_encoding":["gzip"]}`)); err != nil {
+		t.Fatal(err)
+	}
+	if got := SessionFromContext(compressing).Encoding(); got != EncodingGzip {
+		t.Fatalf("session encoding = %q", got)
+	}
+
+	read := []byte(`{"resource_type":"table","resource_id":"t1"}`)
+	for ctx, wantJSON := range map[context.Context]bool{compressing: false, plain: true, context.Background(): true} {
+		out, err := d.Dispatch(ctx, "ReadResource", read)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if json.Valid(out) != wantJSON {
+			t.Errorf("session %p: plain response = %v, want %v", SessionFromContext(ctx), json.Valid(out), wantJSON)
+		}
+	}
+	if stats := d.CompressionStats(); stats.Encoding != "" {
+		t.Errorf("session handshakes must not set the dispatcher encoding, got %q", stats.Encoding)
+	}
+}
@@ -0,0 +1,177 @@
+// Package core provides per-resource-type concurrency limits for the unified dispatcher
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// ConcurrencyLimits bounds how many operations on a resource type run at once
+type ConcurrencyLimits struct {
+	// MaxConcurrency caps concurrent mutating operations on the type; 0 is unlimited
+	MaxConcurrency int `json:"max_concurrency,omitempty"`
+
+	// Group names a mutual-exclusion group: operations on any type in the same group
+	// run one at a time, e.g. all DDL types of a database sharing "ddl"
+	Group string `json:"group,omitempty"`
+
+	// GroupScope is a config property whose value narrows the group, so operations only
+	// exclude each other when the value matches (e.g. "schema" serializes DDL per schema)
+	GroupScope string `json:"group_scope,omitempty"`
+}
+
+// ConcurrencyLimiter enforces the concurrency limits declared by resource types
+type ConcurrencyLimiter struct {
+	mu     sync.Mutex
+	limits map[string]ConcurrencyLimits
+	slots  map[string]chan struct{}
+}
+
+// NewConcurrencyLimiter creates a limiter from the limits declared in a schema
+func NewConcurrencyLimiter(schema *Schema) *ConcurrencyLimiter {
+	l := &ConcurrencyLimiter{limits: make(map[string]ConcurrencyLimits), slots: make(map[string]chan struct{})}
+	if schema != nil {
+		for _, rt := range schema.ResourceTypes {
+			if rt.Concurrency != nil {
+				l.SetLimits(rt.Name, *rt.Concurrency)
+			}
+		}
+	}
+	return l
+}
+
+// SetLimits declares the limits of a resource type
+func (l *ConcurrencyLimiter) SetLimits(resourceType string, limits ConcurrencyLimits) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limits[resourceType] = limits
+}
+
+// Limits returns the limits declared for a resource type
+func (l *ConcurrencyLimiter) Limits(resourceType string) (ConcurrencyLimits, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	limits, ok := l.limits[resourceType]
+	return limits, ok
+}
+
+// Acquire waits until an operation on the resource type may run and returns the
+// function that releases it. The type's own slot is taken before its group slot, in
+// the same order for every caller, so acquisitions cannot deadlock.
+func (l *ConcurrencyLimiter) Acquire(ctx context.Context, resourceType string, config map[string]interface{}) (func(), error) {
+	limits, ok := l.Limits(resourceType)
+	if !ok {
+		return func() {}, nil
+	}
+
+	var keys []string
+	var sizes []int
+	if limits.MaxConcurrency > 0 {
+		keys, sizes = append(keys, "type:"+resourceType), append(sizes, limits.MaxConcurrency)
+	}
+	if limits.Group != "" {
+		key := "group:" + limits.Group
+		if limits.GroupScope != "" {
+			key += ":" + fmt.Sprint(config[limits.GroupScope])
+		}
+		keys, sizes = append(keys, key), append(sizes, 1)
+	}
+
+	var held []chan struct{}
+	release := func() {
+		for i := len(held) - 1; i >= 0; i-- {
+			<-held[i]
+		}
+	}
+	for i, key := range keys {
+		slot := l.slot(key, sizes[i])
+		select {
+		case slot <- struct{}{}:
+			held = append(held, slot)
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+	}
+	return release, nil
+}
+
+func (l *ConcurrencyLimiter) slot(key string, size int) chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	slot, ok := l.slots[key]
+	if !ok {
+		slot = make(chan struct{}, size)
+		l.slots[key] = slot
+	}
+	return slot
+}
+
+// requestConcurrency reads a request's max_concurrency option, falling back to def and
+// capped at max
+func requestConcurrency(options map[string]interface{}, def, max int) int {
+	concurrency := def
+	if n, ok := options["max_concurrency"].(float64); ok && n >= 1 {
+		concurrency = int(min(n, float64(max)))
+	}
+	return concurrency
+}
+
+// forEachBounded calls fn for every index below n with at most limit calls running at
+// once. A slot is acquired before each goroutine starts, so large requests never hold
+// more than limit goroutines.
+func forEachBounded(n, limit int, fn func(i int)) {
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// SetConcurrencyLimiter serializes mutating operations according to the limiter
+func (d *UnifiedDispatcher) SetConcurrencyLimiter(limiter *ConcurrencyLimiter) {
+	d.limiter = limiter
+}
+
+// acquireConcurrency waits for the resource type's concurrency slots of a mutating call
+func (d *UnifiedDispatcher) acquireConcurrency(ctx context.Context, input []byte) (func(), error) {
+	if d.limiter == nil {
+		return func() {}, nil
+	}
+	var req struct {
+		ResourceType string                 `json:"resource_type"`
+		Config       map[string]interface{} `json:"config"`
+	}
+	if json.Unmarshal(input, &req) != nil || req.ResourceType == "" {
+		return func() {}, nil
+	}
+	return d.limiter.Acquire(ctx, req.ResourceType, req.Config)
+}
+
+// ConcurrencyLimiter returns the limiter built from the provider schema, for use with
+// UnifiedDispatcher.SetConcurrencyLimiter
+func (bp *BaseProvider) ConcurrencyLimiter() *ConcurrencyLimiter {
+	if bp.limiter == nil {
+		bp.limiter = NewConcurrencyLimiter(bp.schema)
+	}
+	return bp.limiter
+}
+
+// RunLimited runs fn once the resource type's concurrency limits allow it
+func (bp *BaseProvider) RunLimited(ctx context.Context, resourceType string, config map[string]interface{}, fn func() error) error {
+	release, err := bp.ConcurrencyLimiter().Acquire(ctx, resourceType, config)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return fn()
+}
@@ -0,0 +1,141 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// concurrencyRegistry records the peak number of concurrent calls per scope
+type concurrencyRegistry struct {
+	mu     sync.Mutex
+	active map[string]int
+	peak   map[string]int
+}
+
+func (r *concurrencyRegistry) GetObjectTypes() map[string]*ObjectType { return nil }
+
+func (r *concurrencyRegistry) CallHandler(ctx context.Context, objectType, method string, input []byte) ([]byte, error) {
+	var req struct {
+		Config map[string]interface{} `json:"config"`
+	}
+	_ = json.Unmarshal(input, &req)
+	scope, _ := req.Config["schema"].(string)
+
+	r.mu.Lock()
+	r.active[scope]++
+	if r.active[scope] > r.peak[scope] {
+		r.peak[scope] = r.active[scope]
+	}
+	r.mu.Unlock()
+	time.Sleep(5 * time.Millisecond)
+	r.mu.Lock()
+	r.active[scope]--
+	r.mu.Unlock()
+	return json.Marshal(CreateResponse{})
+}
+
+// TestConcurrencyLimits validates mutual-exclusion groups serialize per scope
+func TestConcurrencyLimits(t *testing.T) {
+	bp := NewBaseProvider("pg")
+	bp.SetSchema(&Schema{ResourceTypes: []ResourceTypeDefinition{
+		{Name: "table", Concurrency: &ConcurrencyLimits{Group: "ddl", GroupScope: "schema"}},
+		{Name: "view", Concurrency: &ConcurrencyLimits{Group: "ddl", GroupScope: "schema"}},
+	}})
+
+	registry := &concurrencyRegistry{active: map[string]int{}, peak: map[string]int{}}
+	d := NewUnifiedDispatcher(registry, nil)
+	d.SetConcurrencyLimiter(bp.ConcurrencyLimiter())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 12; i++ {
+		resourceType, schema := "table", "public"
+		if i%2 == 1 {
+			resourceType = "view"
+		}
+		if i%3 == 0 {
+			schema = "audit"
+		}
+		input, _ := json.Marshal(map[string]interface{}{
+			"resource_type": resourceType,
+			"name":          "obj",
+			"config":        map[string]interface{}{"schema": schema},
+		})
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := d.Dispatch(context.Background(), "CreateResource", input); err != nil {
+				t.Errorf("dispatch failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if registry.peak["public"] != 1 || registry.peak["audit"] != 1 {
+		t.Errorf("operations in the same schema overlapped: %v", registry.peak)
+	}
+
+	limiter := NewConcurrencyLimiter(nil)
+	limiter.SetLimits("bucket", ConcurrencyLimits{MaxConcurrency: 2})
+	var running, peak atomic.Int32
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := limiter.Acquire(context.Background(), "bucket", nil)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer release()
+			n := running.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(2 * time.Millisecond)
+			running.Add(-1)
+		}()
+	}
+	wg.Wait()
+	if peak.Load() > 2 {
+		t.Errorf("max concurrency exceeded: %d", peak.Load())
+	}
+}
+
+// TestForEachBounded validates no more than limit goroutines are started at once
+func TestForEachBounded(t *testing.T) {
+	var active, peak, calls atomic.Int32
+	release := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		forEachBounded(100, 4, func(i int) {
+			calls.Add(1)
+			n := active.Add(1)
+			for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+			}
+			<-release
+			active.Add(-1)
+		})
+	}()
+
+	// with every slot held, no further goroutine may start
+	for calls.Load() < 4 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if got := calls.Load(); got != 4 {
+		t.Fatalf("%d calls started with 4 slots held", got)
+	}
+	close(release)
+	<-done
+	if calls.Load() != 100 || peak.Load() > 4 {
+		t.Errorf("calls = %d, peak = %d", calls.Load(), peak.Load())
+	}
+}
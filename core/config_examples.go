@@ -0,0 +1,319 @@
+// Package core provides provider configuration examples derived from config schemas
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Configuration example categories, used by the registry UI to group examples
+const (
+	ConfigExampleCategoryBasic      = "basic"      // required settings only
+	ConfigExampleCategoryAdvanced   = "advanced"   // every setting
+	ConfigExampleCategoryProduction = "production" // credentials from the environment
+)
+
+// ConfigAuthMode is one way of authenticating a provider. Modes are read from the
+// oneOf (or anyOf) branches of the config schema, each requiring its own credentials:
+//
+//	"oneOf": [
+//	  {"title": "Password", "required": ["username", "password"]},
+//	  {"title": "IAM", "x-auth-mode": "iam", "required": ["aws_region"]}
+//	]
+//
+// The mode name comes from x-auth-mode, the title, or the required fields.
+type ConfigAuthMode struct {
+	Name        string   `json:"name"`
+	Title       string   `json:"title"`
+	Description string   `json:"description,omitempty"`
+	Required    []string `json:"required,omitempty"` // needed in addition to the schema's required fields
+}
+
+// configExampleSchema is the subset of a provider config schema used for examples.
+// A property's x-env names the environment variable the provider reads it from.
+type configExampleSchema struct {
+	Properties map[string]*configExampleProperty `json:"properties"`
+	Required   []string                          `json:"required"`
+	OneOf      []configAuthBranch                `json:"oneOf"`
+	AnyOf      []configAuthBranch                `json:"anyOf"`
+}
+
+type configExampleProperty struct {
+	examplePropertySchema
+	Description string `json:"description"`
+	Sensitive   bool   `json:"sensitive"`
+	XSensitive  bool   `json:"x-sensitive"`
+	WriteOnly   bool   `json:"writeOnly"`
+	Deprecated  bool   `json:"deprecated"`
+	Env         string `json:"x-env"`
+}
+
+type configAuthBranch struct {
+	Mode        string   `json:"x-auth-mode"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Required    []string `json:"required"`
+}
+
+func parseConfigExampleSchema(schema json.RawMessage) (*configExampleSchema, error) {
+	s := &configExampleSchema{}
+	if len(schema) == 0 {
+		return s, nil
+	}
+	if err := json.Unmarshal(schema, s); err != nil {
+		return nil, fmt.Errorf("invalid config schema: %w", err)
+	}
+	return s, nil
+}
+
+// ConfigAuthModes returns the authentication modes of a provider config schema, or a
+// single unnamed mode when the schema declares none
+func ConfigAuthModes(schema json.RawMessage) ([]ConfigAuthMode, error) {
+	s, err := parseConfigExampleSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+	return s.authModes(), nil
+}
+
+func (s *configExampleSchema) authModes() []ConfigAuthMode {
+	branches := s.OneOf
+	if len(branches) == 0 {
+		branches = s.AnyOf
+	}
+	if len(branches) == 0 {
+		return []ConfigAuthMode{{}}
+	}
+	modes := make([]ConfigAuthMode, 0, len(branches))
+	for _, b := range branches {
+		mode := ConfigAuthMode{Name: b.Mode, Title: b.Title, Description: b.Description, Required: b.Required}
+		if mode.Name == "" {
+			mode.Name = exampleSlug(b.Title)
+		}
+		if mode.Name == "" {
+			mode.Name = strings.Join(b.Required, "_")
+		}
+		if mode.Title == "" {
+			mode.Title = strings.Title(strings.ReplaceAll(mode.Name, "_", " "))
+		}
+		modes = append(modes, mode)
+	}
+	return modes
+}
+
+// GenerateConfigurationExamples builds provider configuration examples from the config
+// schema: for every authentication mode, one with the required settings only, one with
+// every setting the mode accepts, and, when properties declare x-env, one reading those
+// from the environment. Each example is validated against the schema; invalid examples,
+// which point at inconsistent defaults, enums or examples in the schema, are left out
+// and described in the returned error.
+func GenerateConfigurationExamples(providerName string, schema json.RawMessage) ([]*ConfigurationExample, error) {
+	s, err := parseConfigExampleSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	var examples []*ConfigurationExample
+	var problems []error
+	add := func(example *ConfigurationExample, mode ConfigAuthMode, env map[string]bool) {
+		if issues := s.validate(mode, example.Config, env); len(issues) > 0 {
+			problems = append(problems, fmt.Errorf("example %s: %s", example.Name, strings.Join(issues, "; ")))
+			return
+		}
+		examples = append(examples, example)
+	}
+
+	for _, mode := range s.authModes() {
+		basic, prefix, suffix := "basic", "", ""
+		if mode.Name != "" {
+			basic, prefix, suffix = mode.Name, mode.Name+"-", " with "+mode.Title+" authentication"
+		}
+		var notes []string
+		if mode.Description != "" {
+			notes = append(notes, mode.Description)
+		}
+
+		required := s.exampleValues(s.requiredFields(mode))
+		add(&ConfigurationExample{
+			Name:        basic,
+			Title:       "Minimal " + providerName + " configuration" + suffix,
+			Description: "Only the required settings",
+			Category:    ConfigExampleCategoryBasic,
+			Config:      required,
+			Notes:       notes,
+		}, mode, nil)
+
+		add(&ConfigurationExample{
+			Name:        prefix + "full",
+			Title:       "Complete " + providerName + " configuration" + suffix,
+			Description: "Every setting, with its default or an example value",
+			Category:    ConfigExampleCategoryAdvanced,
+			Config:      s.exampleValues(s.modeFields(mode)),
+			Notes:       notes,
+		}, mode, nil)
+
+		env := make(map[string]bool)
+		envNotes := append([]string(nil), notes...)
+		for _, name := range s.modeFields(mode) {
+			if prop := s.Properties[name]; prop != nil && prop.Env != "" {
+				env[name] = true
+				envNotes = append(envNotes, fmt.Sprintf("Set %s to provide %s", prop.Env, name))
+			}
+		}
+		if len(env) == 0 {
+			continue
+		}
+		fromEnv := make(map[string]interface{}, len(required))
+		for name, value := range required {
+			if !env[name] {
+				fromEnv[name] = value
+			}
+		}
+		add(&ConfigurationExample{
+			Name:        prefix + "environment",
+			Title:       "Environment-based " + providerName + " configuration" + suffix,
+			Description: "Credentials and connection settings read from the environment instead of the configuration file",
+			Category:    ConfigExampleCategoryProduction,
+			Config:      fromEnv,
+			Notes:       envNotes,
+		}, mode, env)
+	}
+	return examples, errors.Join(problems...)
+}
+
+// BuildConfigurationValidation lists the required, optional and sensitive settings of
+// a provider config schema. Settings required by only some authentication modes are
+// optional.
+func BuildConfigurationValidation(schema json.RawMessage) *ConfigurationValidation {
+	s, err := parseConfigExampleSchema(schema)
+	if err != nil || len(s.Properties) == 0 {
+		return nil
+	}
+	required := stringSet(s.Required)
+	v := &ConfigurationValidation{RequiredFields: uniqueSorted(s.Required)}
+	for _, name := range sortedStringKeys(s.Properties) {
+		prop := s.Properties[name]
+		if !required[name] {
+			v.OptionalFields = append(v.OptionalFields, name)
+		}
+		if prop.Sensitive || prop.XSensitive || prop.WriteOnly {
+			v.SensitiveFields = append(v.SensitiveFields, name)
+		}
+	}
+	return v
+}
+
+// requiredFields are the fields a mode needs, sorted
+func (s *configExampleSchema) requiredFields(mode ConfigAuthMode) []string {
+	return uniqueSorted(append(append([]string(nil), s.Required...), mode.Required...))
+}
+
+// modeFields are the non-deprecated fields a mode accepts, sorted: every property
+// except those only other modes require
+func (s *configExampleSchema) modeFields(mode ConfigAuthMode) []string {
+	own := stringSet(s.requiredFields(mode))
+	others := make(map[string]bool)
+	for _, m := range s.authModes() {
+		if m.Name != mode.Name {
+			for _, field := range m.Required {
+				others[field] = true
+			}
+		}
+	}
+	fields := s.requiredFields(mode)
+	for _, name := range sortedStringKeys(s.Properties) {
+		if own[name] || others[name] || s.Properties[name].Deprecated {
+			continue
+		}
+		fields = append(fields, name)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func (s *configExampleSchema) exampleValues(fields []string) map[string]interface{} {
+	values := make(map[string]interface{}, len(fields))
+	for _, name := range fields {
+		prop := s.Properties[name]
+		if prop == nil {
+			values[name] = exampleScalarValue(name, "string", nil)
+			continue
+		}
+		values[name] = exampleValueForSchema(name, &prop.examplePropertySchema)
+	}
+	return values
+}
+
+// validate checks an example config: the mode's required fields are set or read from
+// the environment, and every value is a declared property of the right type and enum
+func (s *configExampleSchema) validate(mode ConfigAuthMode, config map[string]interface{}, env map[string]bool) []string {
+	var issues []string
+	for _, field := range s.requiredFields(mode) {
+		if _, ok := config[field]; !ok && !env[field] {
+			issues = append(issues, fmt.Sprintf("required setting %q is missing", field))
+		}
+	}
+	if len(s.Properties) == 0 {
+		return issues
+	}
+	for _, name := range sortedStringKeys(config) {
+		prop, ok := s.Properties[name]
+		if !ok {
+			issues = append(issues, fmt.Sprintf("setting %q is not in the schema", name))
+			continue
+		}
+		propType, _ := prop.Type.(string)
+		if !configValueMatchesType(config[name], propType) {
+			issues = append(issues, fmt.Sprintf("setting %q must be of type %s", name, propType))
+		}
+		if len(prop.Enum) > 0 && !configValueInEnum(config[name], prop.Enum) {
+			issues = append(issues, fmt.Sprintf("setting %q is not one of %v", name, prop.Enum))
+		}
+	}
+	return issues
+}
+
+// configValueMatchesType reports whether a decoded JSON value has a JSON Schema type
+func configValueMatchesType(value interface{}, schemaType string) bool {
+	switch schemaType {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == float64(int64(f))
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	}
+	return true
+}
+
+func configValueInEnum(value interface{}, enum []interface{}) bool {
+	for _, allowed := range enum {
+		if fmt.Sprint(allowed) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// exampleSlug lowercases a title into an example name
+func exampleSlug(title string) string {
+	fields := strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+	return strings.Join(fields, "_")
+}
@@ -0,0 +1,87 @@
+package core
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestGenerateConfigurationExamples validates examples per authentication mode
+func TestGenerateConfigurationExamples(t *testing.T) {
+	schema := json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"host":       {"type": "string", "x-env": "PGHOST"},
+			"port":       {"type": "integer", "default": 5432},
+			"sslmode":    {"type": "string", "enum": ["require", "disable"]},
+			"username":   {"type": "string"},
+			"password":   {"type": "string", "sensitive": true, "x-env": "PGPASSWORD"},
+			"aws_region": {"type": "string"},
+			"legacy":     {"type": "string", "deprecated": true}
+		},
+		"required": ["host"],
+		"oneOf": [
+			{"title": "Password", "description": "Log in with a database user", "required": ["username", "password"]},
+			{"title": "IAM", "x-auth-mode": "iam", "required": ["aws_region"]}
+		]
+	}`)
+
+	examples, err := GenerateConfigurationExamples("postgres", schema)
+	if err != nil {
+		t.Fatalf("GenerateConfigurationExamples: %v", err)
+	}
+	byName := make(map[string]*ConfigurationExample)
+	var names []string
+	for _, example := range examples {
+		byName[example.Name] = example
+		names = append(names, example.Name)
+	}
+	if got := strings.Join(names, ","); got != "password,password-full,password-environment,iam,iam-full,iam-environment" {
+		t.Fatalf("unexpected examples: %s", got)
+	}
+
+	basic := byName["password"]
+	if basic.Category != ConfigExampleCategoryBasic || len(basic.Config) != 3 || basic.Config["password"] != "changeme" {
+		t.Errorf("unexpected required-only example: %+v", basic)
+	}
+	full := byName["iam-full"]
+	if full.Category != ConfigExampleCategoryAdvanced || full.Config["port"] != float64(5432) || full.Config["sslmode"] != "require" {
+		t.Errorf("unexpected full example: %+v", full)
+	}
+	for _, field := range []string{"username", "password", "legacy"} {
+		if _, ok := full.Config[field]; ok {
+			t.Errorf("iam-full should not set %s", field)
+		}
+	}
+	env := byName["password-environment"]
+	if env.Category != ConfigExampleCategoryProduction || len(env.Config) != 1 || env.Config["username"] == nil {
+		t.Errorf("unexpected environment example: %+v", env)
+	}
+	if len(env.Notes) != 3 || env.Notes[1] != "Set PGHOST to provide host" {
+		t.Errorf("unexpected environment notes: %v", env.Notes)
+	}
+
+	validation := BuildConfigurationValidation(schema)
+	if strings.Join(validation.RequiredFields, ",") != "host" || strings.Join(validation.SensitiveFields, ",") != "password" {
+		t.Errorf("unexpected validation: %+v", validation)
+	}
+}
+
+// TestGenerateConfigurationExamplesInvalid validates examples contradicting the schema are dropped
+func TestGenerateConfigurationExamplesInvalid(t *testing.T) {
+	schema := json.RawMessage(`{
+		"properties": {
+			"host":    {"type": "string"},
+			"retries": {"type": "integer", "default": "three"}
+		},
+		"required": ["host"]
+	}`)
+
+	examples, err := GenerateConfigurationExamples("demo", schema)
+	if err == nil || !strings.Contains(err.Error(), `example full: setting "retries" must be of type integer`) {
+		t.Fatalf("expected the full example to be rejected, got %v", err)
+	}
+	if len(examples) != 1 || examples[0].Name != "basic" || examples[0].Config["host"] != "localhost" {
+		t.Errorf("unexpected examples: %+v", examples)
+	}
+}
@@ -0,0 +1,259 @@
+// Package core provides the validate-config mode for debugging provider credentials
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/helpers/hcl"
+)
+
+// ValidateConfigCommand is the argument that switches a provider binary into
+// validate-config mode:
+//
+//	if len(os.Args) > 1 && os.Args[1] == core.ValidateConfigCommand {
+//		os.Exit(core.RunValidateConfigCommand(provider, os.Args[2:], os.Stdout))
+//	}
+const ValidateConfigCommand = "validate-config"
+
+// Config check step statuses
+const (
+	ConfigCheckPassed  = "passed"
+	ConfigCheckFailed  = "failed"
+	ConfigCheckSkipped = "skipped"
+)
+
+// ConfigCheckStep is the outcome of one validate-config step
+type ConfigCheckStep struct {
+	Name     string        `json:"name"`
+	Status   string        `json:"status"`
+	Message  string        `json:"message,omitempty"`
+	Duration time.Duration `json:"duration"`
+	Details  interface{}   `json:"details,omitempty"`
+}
+
+// ConfigCheckReport is the structured result of validate-config
+type ConfigCheckReport struct {
+	Provider   string            `json:"provider"`
+	Version    string            `json:"version,omitempty"`
+	ConfigFile string            `json:"config_file,omitempty"`
+	Success    bool              `json:"success"`
+	CheckedAt  time.Time         `json:"checked_at"`
+	Steps      []ConfigCheckStep `json:"steps"`
+}
+
+// CheckProviderConfig validates config against the provider config schema, runs
+// Configure, checks the provider answers Ping and, for an EnterpriseProvider, runs
+// HealthCheck to reach the backend, without creating, reading or modifying any
+// resource. Ping does not contact the backend. The provider is closed afterwards.
+func CheckProviderConfig(ctx context.Context, provider Provider, config map[string]interface{}) *ConfigCheckReport {
+	report := &ConfigCheckReport{CheckedAt: clock.Now().UTC(), Success: true}
+	failed := false
+
+	run := func(name string, fn func() (string, interface{}, error)) {
+		if failed {
+			report.Steps = append(report.Steps, ConfigCheckStep{Name: name, Status: ConfigCheckSkipped, Message: "skipped after earlier failure"})
+			return
+		}
+		start := clock.Now()
+		msg, details, err := fn()
+		step := ConfigCheckStep{Name: name, Status: ConfigCheckPassed, Message: msg, Duration: clock.Since(start), Details: details}
+		if err != nil {
+			step.Status = ConfigCheckFailed
+			step.Message = err.Error()
+			failed = true
+			report.Success = false
+		}
+		report.Steps = append(report.Steps, step)
+	}
+
+	var schema *Schema
+	run("schema", func() (string, interface{}, error) {
+		s, err := provider.Schema()
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to load provider schema: %w", err)
+		}
+		schema = s
+		report.Provider, report.Version = s.Name, s.Version
+		return fmt.Sprintf("%s %s", s.Name, s.Version), nil, nil
+	})
+
+	run("validate", func() (string, interface{}, error) {
+		result := schema.ValidateProviderConfig(config)
+		for i := range result.Errors {
+			result.Errors[i].Value = nil // never echo credentials
+		}
+		for i := range result.Warnings {
+			result.Warnings[i].Value = nil
+		}
+		if !result.Valid {
+			return "", result, fmt.Errorf("configuration has %d error(s)", len(result.Errors))
+		}
+		return fmt.Sprintf("%d warning(s)", len(result.Warnings)), result, nil
+	})
+
+	run("configure", func() (string, interface{}, error) {
+		if err := provider.Configure(ctx, config); err != nil {
+			return "", nil, fmt.Errorf("configure failed: %w", err)
+		}
+		return "provider configured", nil, nil
+	})
+	defer provider.Close()
+
+	run("ping", func() (string, interface{}, error) {
+		output, err := provider.CallFunction(ctx, "Ping", []byte("{}"))
+		if err != nil {
+			return "", nil, fmt.Errorf("ping failed: %w", err)
+		}
+		var resp map[string]interface{}
+		if json.Unmarshal(output, &resp) == nil {
+			if ok, isBool := resp["success"].(bool); isBool && !ok {
+				return "", resp, fmt.Errorf("ping reported failure")
+			}
+		}
+		return "provider responding", resp, nil
+	})
+
+	if enterprise, ok := provider.(EnterpriseProvider); ok {
+		run("health", func() (string, interface{}, error) {
+			status, err := enterprise.HealthCheck(ctx)
+			if err != nil {
+				return "", nil, fmt.Errorf("health check failed: %w", err)
+			}
+			if !status.Healthy {
+				return "", status, fmt.Errorf("provider reports %s", status.Status)
+			}
+			return status.Status, status, nil
+		})
+	}
+
+	return report
+}
+
+// LoadProviderConfigFile reads provider configuration from a .json file or from the
+// first provider block of a .kl/.hcl file
+func LoadProviderConfigFile(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		var config map[string]interface{}
+		if err := json.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		return config, nil
+	}
+
+	file, err := hcl.Parse(data, path)
+	if err != nil {
+		return nil, err
+	}
+	blocks := file.BlocksOfType(hcl.BlockProvider)
+	if len(blocks) == 0 {
+		return nil, fmt.Errorf("%s contains no provider block", path)
+	}
+	return blocks[0].Body(), nil
+}
+
+// RunValidateConfigCommand implements the validate-config mode of a provider binary and
+// returns the process exit code: 0 when every check passed, 1 when a check failed and
+// 2 for usage errors
+func RunValidateConfigCommand(provider Provider, args []string, stdout io.Writer) int {
+	fs := flag.NewFlagSet(ValidateConfigCommand, flag.ContinueOnError)
+	fs.SetOutput(stdout)
+	configFile := fs.String("config", "", "Path to provider configuration (.json, .kl or .hcl)")
+	format := fs.String("format", "text", "Output format: text or json")
+	timeout := fs.Duration("timeout", 30*time.Second, "Overall timeout for configure and health checks")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *configFile == "" && fs.NArg() > 0 {
+		*configFile = fs.Arg(0)
+	}
+	if *configFile == "" {
+		fmt.Fprintln(stdout, "Error: -config is required")
+		fs.Usage()
+		return 2
+	}
+
+	config, err := LoadProviderConfigFile(*configFile)
+	if err != nil {
+		fmt.Fprintf(stdout, "Error: %v\n", err)
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	report := CheckProviderConfig(ctx, provider, config)
+	report.ConfigFile = *configFile
+
+	if *format == "json" {
+		data, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Fprintln(stdout, string(data))
+	} else {
+		report.WriteText(stdout)
+	}
+
+	if !report.Success {
+		return 1
+	}
+	return 0
+}
+
+// WriteText renders the report for terminals
+func (r *ConfigCheckReport) WriteText(w io.Writer) {
+	fmt.Fprintf(w, "Provider %s %s", r.Provider, r.Version)
+	if r.ConfigFile != "" {
+		fmt.Fprintf(w, " (config: %s)", r.ConfigFile)
+	}
+	fmt.Fprintln(w)
+
+	symbols := map[string]string{ConfigCheckPassed: "✓", ConfigCheckFailed: "✗", ConfigCheckSkipped: "-"}
+	for _, step := range r.Steps {
+		fmt.Fprintf(w, "  %s %-10s %s", symbols[step.Status], step.Name, step.Message)
+		if step.Status != ConfigCheckSkipped {
+			fmt.Fprintf(w, " (%s)", step.Duration.Round(time.Millisecond))
+		}
+		fmt.Fprintln(w)
+
+		if result, ok := step.Details.(*ConfigValidationResult); ok {
+			for _, fe := range result.Errors {
+				fmt.Fprintf(w, "      error   %s: %s\n", fe.Field, fe.Error)
+				if fe.Suggestion != "" {
+					fmt.Fprintf(w, "              %s\n", fe.Suggestion)
+				}
+			}
+			for _, fe := range result.Warnings {
+				fmt.Fprintf(w, "      warning %s: %s\n", fe.Field, fe.Error)
+			}
+		}
+	}
+
+	if r.Success && r.checkedBackend() {
+		fmt.Fprintln(w, "Configuration is valid and the backend is reachable.")
+	} else if r.Success {
+		fmt.Fprintln(w, "Configuration is valid; the provider has no health check to reach the backend.")
+	} else {
+		fmt.Fprintln(w, "Configuration check failed.")
+	}
+}
+
+// checkedBackend reports whether a health check reached the backend
+func (r *ConfigCheckReport) checkedBackend() bool {
+	for _, step := range r.Steps {
+		if step.Name == "health" && step.Status == ConfigCheckPassed {
+			return true
+		}
+	}
+	return false
+}
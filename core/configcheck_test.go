@@ -0,0 +1,197 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type configCheckProvider struct {
+	schema       *Schema
+	configureErr error
+	configured   map[string]interface{}
+	calls        []string
+	closed       bool
+}
+
+func (p *configCheckProvider) Configure(ctx context.Context, config map[string]interface{}) error {
+	p.configured = config
+	return p.configureErr
+}
+
+func (p *configCheckProvider) Schema() (*Schema, error) {
+	if p.schema != nil {
+		return p.schema, nil
+	}
+	return &Schema{Name: "fake", Version: "1.2.3"}, nil
+}
+
+func (p *configCheckProvider) CallFunction(ctx context.Context, function string, input []byte) ([]byte, error) {
+	p.calls = append(p.calls, function)
+	return []byte(`{"success":true,"status":"healthy"}`), nil
+}
+
+func (p *configCheckProvider) Close() error {
+	p.closed = true
+	return nil
+}
+
+// TestCheckProviderConfig validates that validate-config only configures and pings
+func TestCheckProviderConfig(t *testing.T) {
+	provider := &configCheckProvider{}
+	report := CheckProviderConfig(context.Background(), provider, map[string]interface{}{"host": "localhost"})
+
+	if !report.Success {
+		t.Fatalf("expected success, got %+v", report.Steps)
+	}
+	if report.Provider != "fake" || report.Version != "1.2.3" {
+		t.Errorf("unexpected provider identity %s %s", report.Provider, report.Version)
+	}
+	if len(provider.calls) != 1 || provider.calls[0] != "Ping" {
+		t.Errorf("expected only Ping to be called, got %v", provider.calls)
+	}
+	if !provider.closed {
+		t.Errorf("expected provider to be closed")
+	}
+}
+
+// TestCheckProviderConfigConfigureFailure validates skipping after a failed step
+func TestCheckProviderConfigConfigureFailure(t *testing.T) {
+	provider := &configCheckProvider{configureErr: errors.New("password authentication failed")}
+	report := CheckProviderConfig(context.Background(), provider, map[string]interface{}{})
+
+	if report.Success {
+		t.Fatalf("expected failure")
+	}
+	statuses := map[string]string{}
+	for _, step := range report.Steps {
+		statuses[step.Name] = step.Status
+	}
+	if statuses["configure"] != ConfigCheckFailed || statuses["ping"] != ConfigCheckSkipped {
+		t.Errorf("unexpected statuses %v", statuses)
+	}
+	if len(provider.calls) != 0 {
+		t.Errorf("expected no function calls, got %v", provider.calls)
+	}
+}
+
+// TestRunValidateConfigCommand validates HCL config loading and JSON output
+func TestRunValidateConfigCommand(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "provider.kl")
+	src := "provider \"fake\" {\n  host = \"db.internal\"\n  port = 5432\n}\n"
+	if err := os.WriteFile(path, []byte(src), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	provider := &configCheckProvider{}
+	var out bytes.Buffer
+	code := RunValidateConfigCommand(provider, []string{"-config", path, "-format", "json"}, &out)
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, out.String())
+	}
+	if provider.configured["host"] != "db.internal" {
+		t.Errorf("expected provider block to be used, got %v", provider.configured)
+	}
+
+	var report ConfigCheckReport
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("invalid JSON report: %v", err)
+	}
+	if report.ConfigFile != path {
+		t.Errorf("expected config file %s, got %s", path, report.ConfigFile)
+	}
+
+	out.Reset()
+	if code := RunValidateConfigCommand(provider, nil, &out); code != 2 {
+		t.Errorf("expected usage error, got %d", code)
+	}
+	if !strings.Contains(out.String(), "-config is required") {
+		t.Errorf("expected usage message, got %q", out.String())
+	}
+}
+
+// configCheckSchema declares resource types next to the provider config schema
+func configCheckSchema() *Schema {
+	return &Schema{
+		Name:    "fake",
+		Version: "1.2.3",
+		ConfigSchema: json.RawMessage(`{
+			"properties": {
+				"host":     {"type": "string"},
+				"port":     {"type": "integer"},
+				"ssl_mode": {"type": "string", "enum": ["disable", "require"]}
+			},
+			"required": ["host"]
+		}`),
+		ResourceTypes: []ResourceTypeDefinition{{
+			Name:         "table",
+			Operations:   []string{"create", "read"},
+			ConfigSchema: json.RawMessage(`{"properties": {"schema": {"type": "string"}}, "required": ["schema"]}`),
+		}},
+	}
+}
+
+// TestCheckProviderConfigSchema validates the provider config is checked against the
+// provider config schema, not the resource type schemas
+func TestCheckProviderConfigSchema(t *testing.T) {
+	tests := []struct {
+		name   string
+		config map[string]interface{}
+		errors []string
+	}{
+		{"valid", map[string]interface{}{"host": "db.internal", "port": float64(5432), "ssl_mode": "require"}, nil},
+		{"missing host", map[string]interface{}{"port": float64(5432)}, []string{"host"}},
+		{"wrong types", map[string]interface{}{"host": "db.internal", "port": "5432", "ssl_mode": "prefer"}, []string{"port", "ssl_mode"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &configCheckProvider{schema: configCheckSchema()}
+			report := CheckProviderConfig(context.Background(), provider, tt.config)
+			result := report.Steps[1].Details.(*ConfigValidationResult)
+
+			var fields []string
+			for _, fe := range result.Errors {
+				fields = append(fields, fe.Field)
+			}
+			if strings.Join(fields, ",") != strings.Join(tt.errors, ",") {
+				t.Errorf("expected errors for %v, got %+v", tt.errors, result.Errors)
+			}
+			if report.Success != (len(tt.errors) == 0) {
+				t.Errorf("expected success %v, got %+v", len(tt.errors) == 0, report.Steps)
+			}
+		})
+	}
+
+	bp := NewBaseProvider("fake")
+	bp.SetSchema(configCheckSchema())
+	bp.EnableMultiTenancy(nil)
+	if err := bp.ConfigureTenant(context.Background(), "acme", map[string]interface{}{"host": "acme.internal"}); err != nil {
+		t.Errorf("expected a valid tenant config to be accepted, got %v", err)
+	}
+	if err := bp.ConfigureTenant(context.Background(), "globex", map[string]interface{}{}); err == nil {
+		t.Errorf("expected a tenant config without host to be rejected")
+	}
+}
+
+// TestConfigCheckReportWriteText validates the summary only claims the backend is
+// reachable after a health check
+func TestConfigCheckReportWriteText(t *testing.T) {
+	report := CheckProviderConfig(context.Background(), &configCheckProvider{}, map[string]interface{}{})
+	var out bytes.Buffer
+	report.WriteText(&out)
+	if !strings.Contains(out.String(), "provider responding") || strings.Contains(out.String(), "backend is reachable") {
+		t.Errorf("expected Ping not to be reported as reaching the backend:\n%s", out.String())
+	}
+
+	report.Steps = append(report.Steps, ConfigCheckStep{Name: "health", Status: ConfigCheckPassed})
+	out.Reset()
+	report.WriteText(&out)
+	if !strings.Contains(out.String(), "backend is reachable") {
+		t.Errorf("expected a passed health check to report the backend reachable:\n%s", out.String())
+	}
+}
@@ -199,11 +199,21 @@ type CreateResponse struct {
 	ResourceID string                 `json:"resource_id"`
 	State      map[string]interface{} `json:"state"`
 
+	// Outputs are named values the resource publishes, e.g. a connection string
+	Outputs map[string]Output `json:"outputs,omitempty"`
+
+	// Operation is set when the backend finishes the change asynchronously; State and
+	// Outputs are then taken from the operation's final status
+	Operation *OperationRef `json:"operation,omitempty"`
+
 	// Operation metadata
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
-	Warnings []string               `json:"warnings,omitempty"`
+	Warnings []Warning              `json:"warnings,omitempty"`
 	Duration time.Duration          `json:"duration,omitempty"`
 
+	// ResponseMetadata is attached by the dispatcher; handlers may pre-fill backend IDs
+	ResponseMetadata *ResponseMetadata `json:"response_metadata,omitempty"`
+
 	// Status
 	Success bool   `json:"success"`
 	Message string `json:"message,omitempty"`
@@ -214,6 +224,10 @@ type ReadRequest struct {
 	ObjectType string `json:"object_type"`
 	ResourceID string `json:"resource_id"`
 	Name       string `json:"name"`
+
+	// Consistency is how fresh the read must be; nil means ReadStrong. Handlers may
+	// use it to read from replicas or their own caches.
+	Consistency *ReadConsistency `json:"consistency,omitempty"`
 }
 
 // ReadResponse represents the result of a read operation
@@ -222,6 +236,10 @@ type ReadResponse struct {
 	Metadata     map[string]interface{} `json:"metadata,omitempty"`
 	NotFound     bool                   `json:"not_found"`
 	LastModified time.Time              `json:"last_modified,omitempty"`
+	CachedAt     *time.Time             `json:"cached_at,omitempty"` // set when served from the dispatcher read cache
+	Outputs      map[string]Output      `json:"outputs,omitempty"`
+
+	ResponseMetadata *ResponseMetadata `json:"response_metadata,omitempty"`
 }
 
 // UpdateRequest represents a request to update a managed resource
@@ -245,11 +263,17 @@ type UpdateOptions struct {
 // UpdateResponse represents the result of an update operation
 type UpdateResponse struct {
 	NewState map[string]interface{} `json:"new_state"`
+	Outputs  map[string]Output      `json:"outputs,omitempty"`
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
-	Warnings []string               `json:"warnings,omitempty"`
+	Warnings []Warning              `json:"warnings,omitempty"`
 	Changes  []PropertyChange       `json:"changes,omitempty"`
 	Duration time.Duration          `json:"duration,omitempty"`
 	Replaced bool                   `json:"replaced"` // true if resource was recreated
+
+	// Operation is set when the backend finishes the change asynchronously
+	Operation *OperationRef `json:"operation,omitempty"`
+
+	ResponseMetadata *ResponseMetadata `json:"response_metadata,omitempty"`
 }
 
 // PropertyChange represents a change to a specific property
@@ -280,11 +304,16 @@ type DeleteOptions struct {
 
 // DeleteResponse represents the result of a delete operation
 type DeleteResponse struct {
-	Warnings []string      `json:"warnings,omitempty"`
+	Warnings []Warning     `json:"warnings,omitempty"`
 	BackupID string        `json:"backup_id,omitempty"`
 	Duration time.Duration `json:"duration,omitempty"`
 	Success  bool          `json:"success"`
 	Message  string        `json:"message,omitempty"`
+
+	// Operation is set when the backend finishes the deletion asynchronously
+	Operation *OperationRef `json:"operation,omitempty"`
+
+	ResponseMetadata *ResponseMetadata `json:"response_metadata,omitempty"`
 }
 
 // =============================================================================
@@ -425,9 +454,12 @@ type PlanResponse struct {
 	Changes  []PlannedChange   `json:"changes"`
 	Valid    bool              `json:"valid"`
 	Summary  *PlanSummary      `json:"summary"`
-	Warnings []string          `json:"warnings,omitempty"`
+	Warnings []Warning         `json:"warnings,omitempty"`
 	Errors   []ValidationError `json:"errors,omitempty"`
 	Duration time.Duration     `json:"duration,omitempty"`
+
+	// PlannedState is the desired config with declared defaults injected
+	PlannedState map[string]interface{} `json:"planned_state,omitempty"`
 }
 
 // PlannedChange represents a planned change to a resource
@@ -440,6 +472,8 @@ type PlannedChange struct {
 	RiskLevel       string        `json:"risk_level"` // low, medium, high, critical
 	Description     string        `json:"description"`
 	EstimatedTime   time.Duration `json:"estimated_time,omitempty"`
+	FromDefault     bool          `json:"from_default,omitempty"`   // value computed from a declared default
+	DefaultSource   string        `json:"default_source,omitempty"` // where the default came from, see DefaultProvenance
 }
 
 // PlanSummary provides high-level plan statistics
@@ -449,6 +483,9 @@ type PlanSummary struct {
 	RequiresReplace bool           `json:"requires_replace"`
 	EstimatedTime   time.Duration  `json:"estimated_time"`
 	RiskLevel       string         `json:"risk_level"`
+
+	// Timing summarizes the response metadata of the operations the plan ran
+	Timing *TimingSummary `json:"timing,omitempty"`
 }
 
 // PlanResource represents a resource being evaluated during plan operations.
@@ -468,6 +505,9 @@ type PlanResourceSummary struct {
 	Action         string                 `json:"action"`
 	Reason         string                 `json:"reason,omitempty"`
 	ConfigSnapshot map[string]interface{} `json:"config_snapshot,omitempty"`
+
+	// ResponseMetadata is the timing of the operation that evaluated the resource
+	ResponseMetadata *ResponseMetadata `json:"response_metadata,omitempty"`
 }
 
 // ImportRequest represents a request to import existing resources
@@ -491,7 +531,7 @@ type ImportResponse struct {
 	State        map[string]interface{} `json:"state"`
 	Config       map[string]interface{} `json:"config,omitempty"` // generated config
 	Dependencies []string               `json:"dependencies,omitempty"`
-	Warnings     []string               `json:"warnings,omitempty"`
+	Warnings     []Warning              `json:"warnings,omitempty"`
 	Success      bool                   `json:"success"`
 	Message      string                 `json:"message,omitempty"`
 }
@@ -0,0 +1,277 @@
+// Package core provides static checks of a provider's schema against its registered handlers
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Contract lint rules
+const (
+	LintRuleMissingHandler      = "missing-handler"      // declared operation without a handler method
+	LintRuleUndeclaredHandler   = "undeclared-handler"   // registered handler whose type the schema omits
+	LintRuleStateID             = "state-id"             // managed resource state without an id property
+	LintRuleLegacyField         = "legacy-field"         // deprecated schema field in use
+	LintRuleUnsupportedFunction = "unsupported-function" // function used but not in SupportedFunctions
+	LintRuleInvalidSchema       = "invalid-schema"       // schema that cannot be composed or parsed
+	LintRulePluginLoading       = "plugin-loading"       // source loading providers with the deprecated Go plugin package
+)
+
+// Contract finding severities
+const (
+	LintSeverityError   = "error"
+	LintSeverityWarning = "warning"
+)
+
+// ContractFinding is one contract violation found by LintContract
+type ContractFinding struct {
+	Rule         string `json:"rule"`
+	Severity     string `json:"severity"`
+	ResourceType string `json:"resource_type,omitempty"`
+	Message      string `json:"message"`
+}
+
+// String renders the finding on one line, e.g. "error table [state-id]: ..."
+func (f ContractFinding) String() string {
+	subject := "schema"
+	if f.ResourceType != "" {
+		subject = f.ResourceType
+	}
+	return fmt.Sprintf("%s %s [%s]: %s", f.Severity, subject, f.Rule, f.Message)
+}
+
+// ContractReport is the result of linting a provider
+type ContractReport struct {
+	Provider string            `json:"provider"`
+	Version  string            `json:"version,omitempty"`
+	Findings []ContractFinding `json:"findings"`
+
+	// HandlersInspected is false when only the schema was available, so handler rules
+	// were skipped
+	HandlersInspected bool `json:"handlers_inspected"`
+}
+
+// Errors counts error findings
+func (r *ContractReport) Errors() int {
+	return r.count(LintSeverityError)
+}
+
+// Warnings counts warning findings
+func (r *ContractReport) Warnings() int {
+	return r.count(LintSeverityWarning)
+}
+
+// HasErrors reports whether the provider violates the contract
+func (r *ContractReport) HasErrors() bool {
+	return r.Errors() > 0
+}
+
+func (r *ContractReport) count(severity string) int {
+	n := 0
+	for _, f := range r.Findings {
+		if f.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
+
+// OperationReporter is implemented by handler registries that can tell which operations
+// the handler of an object type implements; create.Registry and discover.Registry do
+type OperationReporter interface {
+	Operations(objectType string) []string
+}
+
+// ContractInspector is implemented by providers that expose their registered handlers
+// to the contract linter, usually by returning UnifiedDispatcher.HandlerOperations
+type ContractInspector interface {
+	HandlerOperations() map[string][]string
+}
+
+// handlerOperations are the resource type operations served by a handler method; other
+// operations, e.g. backup, are implemented by the provider itself
+var handlerOperations = map[string]bool{
+	"create": true, "read": true, "update": true, "delete": true, "plan": true,
+	"import": true, "validate": true, "drift": true,
+	"discover": true, "scan": true, "analyze": true, "query": true,
+}
+
+// operationFunctions maps resource type operations to the dispatcher function core calls
+var operationFunctions = map[string]string{
+	"create":   "CreateResource",
+	"read":     "ReadResource",
+	"update":   "UpdateResource",
+	"delete":   "DeleteResource",
+	"discover": "DiscoverResources",
+}
+
+// Operations assumed for registries that do not implement OperationReporter
+var (
+	defaultCreateOperations   = []string{"create", "read", "update", "delete", "plan"}
+	defaultDiscoverOperations = []string{"discover", "scan", "analyze", "query"}
+)
+
+// HandlerOperations returns the operations implemented by every registered handler,
+// keyed by object type
+func (d *UnifiedDispatcher) HandlerOperations() map[string][]string {
+	operations := make(map[string][]string)
+	collect := func(registry interface {
+		GetObjectTypes() map[string]*ObjectType
+	}, defaults []string) {
+		reporter, _ := registry.(OperationReporter)
+		for name := range registry.GetObjectTypes() {
+			ops := defaults
+			if reporter != nil {
+				ops = reporter.Operations(name)
+			}
+			operations[name] = uniqueSorted(append(operations[name], ops...))
+		}
+	}
+	if d.createRegistry != nil {
+		collect(d.createRegistry, defaultCreateOperations)
+	}
+	if d.discoverRegistry != nil {
+		collect(d.discoverRegistry, defaultDiscoverOperations)
+	}
+	return operations
+}
+
+// LintContract checks a schema against the dispatcher's registered handlers
+func (d *UnifiedDispatcher) LintContract(schema *Schema) *ContractReport {
+	return LintContract(schema, d.HandlerOperations())
+}
+
+// LintContract checks a provider schema for contract violations: resource type
+// operations without a handler method, managed resource state schemas without an id
+// property, deprecated legacy fields, and functions the schema relies on but leaves out
+// of SupportedFunctions. handlers maps object types to the operations their handler
+// implements (see UnifiedDispatcher.HandlerOperations); when nil, handler rules are
+// skipped. Findings are ordered by resource type and rule.
+func LintContract(schema *Schema, handlers map[string][]string) *ContractReport {
+	report := &ContractReport{HandlersInspected: handlers != nil}
+	if schema == nil {
+		report.add(LintRuleInvalidSchema, LintSeverityError, "", "provider returned no schema")
+		return report
+	}
+	report.Provider = schema.Name
+	report.Version = schema.Version
+
+	supported := stringSet(schema.SupportedFunctions)
+	declared := make(map[string]bool, len(schema.ResourceTypes))
+	for _, rt := range schema.ResourceTypes {
+		declared[rt.Name] = true
+		composed, err := schema.ComposeResourceType(rt)
+		if err != nil {
+			report.add(LintRuleInvalidSchema, LintSeverityError, rt.Name, err.Error())
+			composed = rt
+		}
+		operations := uniqueSorted(lowerAll(rt.Operations))
+
+		if handlers != nil {
+			report.lintHandlers(rt.Name, operations, handlers)
+		}
+		if containsString(operations, "create") {
+			report.lintStateID(composed)
+		}
+		for _, op := range operations {
+			if fn, ok := operationFunctions[op]; ok && !supported[fn] {
+				report.add(LintRuleUnsupportedFunction, LintSeverityError, rt.Name,
+					fmt.Sprintf("operation %s needs %s, which is not in SupportedFunctions", op, fn))
+			}
+		}
+	}
+
+	for _, name := range sortedStringKeys(handlers) {
+		if !declared[name] {
+			report.add(LintRuleUndeclaredHandler, LintSeverityWarning, name,
+				"a handler is registered but the schema declares no resource type for it")
+		}
+	}
+
+	if len(schema.CreateObjects) > 0 {
+		report.add(LintRuleLegacyField, LintSeverityWarning, "", "create_objects is deprecated, declare resource_types instead")
+	}
+	if len(schema.DiscoverObjects) > 0 {
+		report.add(LintRuleLegacyField, LintSeverityWarning, "", "discover_objects is deprecated, declare resource_types instead")
+	}
+	if len(schema.Functions) > 0 {
+		report.add(LintRuleLegacyField, LintSeverityWarning, "", "functions is deprecated, list supported_functions instead")
+		for _, fn := range sortedStringKeys(schema.Functions) {
+			if !supported[fn] {
+				report.add(LintRuleUnsupportedFunction, LintSeverityError, "",
+					fmt.Sprintf("function %s is declared in functions but not in SupportedFunctions", fn))
+			}
+		}
+	}
+
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		a, b := report.Findings[i], report.Findings[j]
+		if a.ResourceType != b.ResourceType {
+			return a.ResourceType < b.ResourceType
+		}
+		return a.Rule < b.Rule
+	})
+	return report
+}
+
+// lintHandlers checks the operations of a resource type against its handler
+func (r *ContractReport) lintHandlers(resourceType string, operations []string, handlers map[string][]string) {
+	var needed []string
+	for _, op := range operations {
+		if handlerOperations[op] {
+			needed = append(needed, op)
+		}
+	}
+	if len(needed) == 0 {
+		return
+	}
+	implemented, registered := handlers[resourceType]
+	if !registered {
+		r.add(LintRuleMissingHandler, LintSeverityError, resourceType,
+			fmt.Sprintf("declares %s but no handler is registered", strings.Join(needed, ", ")))
+		return
+	}
+	have := stringSet(implemented)
+	for _, op := range needed {
+		if !have[op] {
+			r.add(LintRuleMissingHandler, LintSeverityError, resourceType,
+				fmt.Sprintf("declares %s but its handler does not implement it", op))
+		}
+	}
+}
+
+// lintStateID checks that a managed resource's state schema declares id
+func (r *ContractReport) lintStateID(rt ResourceTypeDefinition) {
+	var state struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+	}
+	if len(rt.StateSchema) > 0 {
+		if err := json.Unmarshal(rt.StateSchema, &state); err != nil {
+			r.add(LintRuleInvalidSchema, LintSeverityError, rt.Name, fmt.Sprintf("state schema is not a JSON object: %v", err))
+			return
+		}
+	}
+	if len(state.Properties) == 0 {
+		r.add(LintRuleStateID, LintSeverityWarning, rt.Name, "state schema declares no properties, so the id is not documented")
+		return
+	}
+	if _, ok := state.Properties["id"]; !ok {
+		r.add(LintRuleStateID, LintSeverityError, rt.Name, "state schema has no id property")
+	}
+}
+
+func (r *ContractReport) add(rule, severity, resourceType, message string) {
+	r.Findings = append(r.Findings, ContractFinding{Rule: rule, Severity: severity, ResourceType: resourceType, Message: message})
+}
+
+func lowerAll(values []string) []string {
+	lowered := make([]string, 0, len(values))
+	for _, v := range values {
+		if v = strings.ToLower(strings.TrimSpace(v)); v != "" {
+			lowered = append(lowered, v)
+		}
+	}
+	return lowered
+}
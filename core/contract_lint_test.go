@@ -0,0 +1,96 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+// lintRegistry registers table without drift detection and an undeclared view
+type lintRegistry struct{}
+
+func (lintRegistry) GetObjectTypes() map[string]*ObjectType {
+	return map[string]*ObjectType{"table": {Name: "table"}, "view": {Name: "view"}}
+}
+
+func (lintRegistry) CallHandler(ctx context.Context, objectType, method string, input []byte) ([]byte, error) {
+	return nil, nil
+}
+
+func (lintRegistry) Operations(objectType string) []string {
+	return []string{"create", "read", "update", "delete", "plan", "import"}
+}
+
+func findingRules(report *ContractReport) map[string]string {
+	rules := make(map[string]string)
+	for _, f := range report.Findings {
+		rules[f.ResourceType+"/"+f.Rule] = f.Severity
+	}
+	return rules
+}
+
+// TestLintContract validates every contract rule against a dispatcher's handlers
+func TestLintContract(t *testing.T) {
+	schema := &Schema{
+		Name:               "acme",
+		SupportedFunctions: []string{"CreateResource", "ReadResource", "UpdateResource", "Ping"},
+		ResourceTypes: []ResourceTypeDefinition{
+			{Name: "table", Operations: []string{"create", "read", "update", "delete", "drift"},
+				StateSchema: json.RawMessage(`{"type":"object","properties":{"name":{"type":"string"}}}`)},
+			{Name: "topic", Operations: []string{"create", "read"},
+				StateSchema: json.RawMessage(`{"properties":{"id":{"type":"string"}}}`)},
+			{Name: "backup", Operations: []string{"backup"}},
+		},
+		Functions: map[string]*Function{"Snapshot": {Description: "legacy"}},
+	}
+
+	report := NewUnifiedDispatcher(lintRegistry{}, nil).LintContract(schema)
+	if !report.HandlersInspected || report.Provider != "acme" {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	rules := findingRules(report)
+	want := map[string]string{
+		"table/missing-handler":      LintSeverityError,   // drift not implemented
+		"table/state-id":             LintSeverityError,   // properties without id
+		"table/unsupported-function": LintSeverityError,   // delete without DeleteResource
+		"topic/missing-handler":      LintSeverityError,   // nothing registered
+		"view/undeclared-handler":    LintSeverityWarning, // registered, not declared
+		"/legacy-field":              LintSeverityWarning,
+		"/unsupported-function":      LintSeverityError, // Snapshot
+	}
+	for key, severity := range want {
+		if rules[key] != severity {
+			t.Errorf("%s = %q, want %q (findings %v)", key, rules[key], severity, report.Findings)
+		}
+	}
+	if _, ok := rules["topic/state-id"]; ok {
+		t.Error("topic declares id and should pass the state rule")
+	}
+	if _, ok := rules["backup/missing-handler"]; ok {
+		t.Error("operations without handler methods should not need a handler")
+	}
+	if report.Findings[0].ResourceType != "" || report.Findings[len(report.Findings)-1].ResourceType != "view" {
+		t.Errorf("findings are not ordered by resource type: %v", report.Findings)
+	}
+}
+
+// TestLintContractSchemaOnly validates handler rules are skipped without handlers
+func TestLintContractSchemaOnly(t *testing.T) {
+	schema := &Schema{
+		Name:               "acme",
+		SupportedFunctions: []string{"CreateResource", "ReadResource"},
+		ResourceTypes: []ResourceTypeDefinition{{Name: "table", Operations: []string{"create", "read"},
+			StateSchema: json.RawMessage(`{"properties":{"id":{}}}`)}},
+	}
+	report := LintContract(schema, nil)
+	if report.HandlersInspected || len(report.Findings) != 0 || report.HasErrors() {
+		t.Errorf("clean schema reported %v", report.Findings)
+	}
+
+	// BuildCompatibleSchema output still uses the legacy fields and empty state schemas
+	compat := NewUnifiedDispatcher(lintRegistry{}, nil).BuildCompatibleSchema("acme", "1.0.0", "test", "")
+	report = LintContract(compat, nil)
+	if report.HasErrors() || report.Warnings() == 0 {
+		t.Errorf("compatible schema should only warn: %v", report.Findings)
+	}
+}
@@ -0,0 +1,125 @@
+// Package core provides the Kolumn core versions a provider is compatible with
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/schemabounce/kolumn/sdk/helpers/security"
+)
+
+// CoreVersionError is returned when Kolumn core is outside a provider's supported range
+type CoreVersionError struct {
+	Version string
+	Min     string
+	Max     string
+}
+
+func (e *CoreVersionError) Error() string {
+	return fmt.Sprintf("kolumn core %s is not supported, provider requires %s", e.Version, CoreVersionRange(e.Min, e.Max))
+}
+
+// CoreVersionRange renders a supported core version range, e.g. ">= 1.2.0, <= 1.4".
+// A maximum with fewer components allows every patch or minor release below it.
+func CoreVersionRange(min, max string) string {
+	var parts []string
+	if min != "" {
+		parts = append(parts, ">= "+min)
+	}
+	if max != "" {
+		parts = append(parts, "<= "+max)
+	}
+	if len(parts) == 0 {
+		return "any"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// CheckCoreVersion reports whether a Kolumn core version is within [min, max]. The
+// maximum is compared only to as many components as it has, so "1.4" accepts 1.4.7.
+// An empty version or bound is not checked.
+func CheckCoreVersion(version, min, max string) error {
+	if version == "" {
+		return nil
+	}
+	if min != "" && CompareVersions(version, min) < 0 {
+		return &CoreVersionError{Version: version, Min: min, Max: max}
+	}
+	if max != "" {
+		truncated := versionParts(version)
+		if n := len(versionParts(max)); len(truncated) > n {
+			truncated = truncated[:n]
+		}
+		if compareVersionParts(truncated, versionParts(max)) > 0 {
+			return &CoreVersionError{Version: version, Min: min, Max: max}
+		}
+	}
+	return nil
+}
+
+// ValidateCoreVersionRange checks that min is not above max
+func ValidateCoreVersionRange(min, max string) error {
+	if min != "" && max != "" && CheckCoreVersion(min, "", max) != nil {
+		return fmt.Errorf("min core version %s is above max core version %s", min, max)
+	}
+	return nil
+}
+
+// CheckCoreVersion reports whether the schema supports a Kolumn core version
+func (s *Schema) CheckCoreVersion(version string) error {
+	return CheckCoreVersion(version, s.MinCoreVersion, s.MaxCoreVersion)
+}
+
+// CoreCompatibility returns the registry compatibility section for the schema's core
+// version range, or nil when the schema declares none
+func (s *Schema) CoreCompatibility() *CompatibilityInfo {
+	if s.MinCoreVersion == "" && s.MaxCoreVersion == "" {
+		return nil
+	}
+	return &CompatibilityInfo{
+		KolumnVersion:  CoreVersionRange(s.MinCoreVersion, s.MaxCoreVersion),
+		MinCoreVersion: s.MinCoreVersion,
+		MaxCoreVersion: s.MaxCoreVersion,
+	}
+}
+
+// SetCoreVersions declares the Kolumn core versions the provider supports. Ping
+// handshakes from a core outside the range are rejected and the range is published in
+// the schema built by BuildCompatibleSchema.
+func (d *UnifiedDispatcher) SetCoreVersions(min, max string) error {
+	if err := ValidateCoreVersionRange(min, max); err != nil {
+		return err
+	}
+	d.minCoreVersion, d.maxCoreVersion = min, max
+	d.InvalidateSchema()
+	return nil
+}
+
+// pingCoreVersion checks the core version a Ping handshake announces:
+//
+//	{"core_version": "1.3.2"}
+//
+// and reports the supported range in the response
+func (d *UnifiedDispatcher) pingCoreVersion(input []byte, response map[string]interface{}) error {
+	if d.minCoreVersion == "" && d.maxCoreVersion == "" {
+		return nil
+	}
+	response["min_core_version"] = d.minCoreVersion
+	response["max_core_version"] = d.maxCoreVersion
+
+	var req struct {
+		CoreVersion string `json:"core_version"`
+	}
+	if len(input) == 0 || json.Unmarshal(input, &req) != nil {
+		return nil
+	}
+	if err := CheckCoreVersion(req.CoreVersion, d.minCoreVersion, d.maxCoreVersion); err != nil {
+		return security.NewSecureError(
+			"incompatible kolumn core version",
+			err.Error(),
+			"INCOMPATIBLE_CORE_VERSION",
+		)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/schemabounce/kolumn/sdk/helpers/security"
+)
+
+// TestCheckCoreVersion validates inclusive bounds and maximums with fewer components
+func TestCheckCoreVersion(t *testing.T) {
+	cases := []struct {
+		version string
+		ok      bool
+	}{
+		{"1.2.0", true},
+		{"v1.3.9-rc1", true},
+		{"1.4.7", true},
+		{"1.1.9", false},
+		{"1.5.0", false},
+		{"2.0.0", false},
+		{"", true},
+	}
+	for _, c := range cases {
+		err := CheckCoreVersion(c.version, "1.2.0", "1.4")
+		if (err == nil) != c.ok {
+			t.Errorf("CheckCoreVersion(%q) = %v, want ok=%v", c.version, err, c.ok)
+		}
+		var versionErr *CoreVersionError
+		if err != nil && !errors.As(err, &versionErr) {
+			t.Errorf("expected a CoreVersionError, got %T", err)
+		}
+	}
+	if err := ValidateCoreVersionRange("1.5.0", "1.4"); err == nil {
+		t.Error("expected min above max to be rejected")
+	}
+	if got := CoreVersionRange("1.2.0", ""); got != ">= 1.2.0" {
+		t.Errorf("CoreVersionRange = %q", got)
+	}
+}
+
+// TestPingCoreVersion validates the handshake rejects unsupported cores and publishes the range
+func TestPingCoreVersion(t *testing.T) {
+	ctx := context.Background()
+	d := NewUnifiedDispatcher(nil, nil)
+	if err := d.SetCoreVersions("1.2.0", "1.4"); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := d.Dispatch(ctx, "Ping", []byte(`{"core_version":"1.3.0"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ping map[string]interface{}
+	if err := json.Unmarshal(out, &ping); err != nil || ping["min_core_version"] != "1.2.0" || ping["max_core_version"] != "1.4" {
+		t.Fatalf("unexpected ping response %s", out)
+	}
+
+	_, err = d.Dispatch(ctx, "Ping", []byte(`{"core_version":"2.0.0"}`))
+	var secureErr *security.SecureError
+	if !errors.As(err, &secureErr) || secureErr.Code != "INCOMPATIBLE_CORE_VERSION" {
+		t.Fatalf("expected INCOMPATIBLE_CORE_VERSION, got %v", err)
+	}
+
+	schema := d.CachedSchema("postgres", "1.0.0", "database", "")
+	compat := schema.CoreCompatibility()
+	if compat == nil || compat.KolumnVersion != ">= 1.2.0, <= 1.4" || compat.MaxCoreVersion != "1.4" {
+		t.Errorf("unexpected compatibility %+v", compat)
+	}
+}
@@ -0,0 +1,132 @@
+// Package core provides injection of declared attribute defaults during plan
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// PropertyDefaults returns the declared default of every property that has one. A
+// Property.Default wins over a default set on its enhanced validation rules (the
+// schema builder's ValidationRuleBuilder.Default).
+func PropertyDefaults(properties map[string]*Property) map[string]interface{} {
+	defaults := make(map[string]interface{})
+	for name, prop := range properties {
+		if prop == nil {
+			continue
+		}
+		if prop.Default != nil {
+			defaults[name] = prop.Default
+			continue
+		}
+		if prop.Enhanced == nil {
+			continue
+		}
+		for _, rule := range prop.Enhanced.Rules {
+			if rule.Default != nil {
+				defaults[name] = rule.Default
+				break
+			}
+		}
+	}
+	return defaults
+}
+
+// SchemaDefaults returns the defaults of the top-level properties of a JSON Schema
+func SchemaDefaults(schema json.RawMessage) map[string]interface{} {
+	defaults := make(map[string]interface{})
+	var s struct {
+		Properties map[string]struct {
+			Default interface{} `json:"default"`
+		} `json:"properties"`
+	}
+	if len(schema) == 0 || json.Unmarshal(schema, &s) != nil {
+		return defaults
+	}
+	for name, prop := range s.Properties {
+		if prop.Default != nil {
+			defaults[name] = prop.Default
+		}
+	}
+	return defaults
+}
+
+// InjectDefaults returns a copy of config with defaults filled in for absent or null
+// attributes, plus the attributes that were injected. config is not modified.
+func InjectDefaults(config, defaults map[string]interface{}) (map[string]interface{}, map[string]interface{}) {
+	planned := make(map[string]interface{}, len(config)+len(defaults))
+	for k, v := range config {
+		planned[k] = v
+	}
+	injected := make(map[string]interface{})
+	for name, value := range defaults {
+		if current, ok := planned[name]; ok && current != nil {
+			continue
+		}
+		planned[name] = value
+		injected[name] = value
+	}
+	return planned, injected
+}
+
+// RecordDefaults marks the changes in resp that set an injected schema default as
+// FromDefault. Defaults applied to a resource being created get their own
+// create change so the plan shows which values the provider chose; these
+// annotations are not counted in the plan summary.
+func RecordDefaults(resp *PlanResponse, injected map[string]interface{}) {
+	RecordDefaultProvenance(resp, SchemaDefaultProvenance(injected))
+}
+
+// RecordDefaultProvenance is RecordDefaults for defaults of any source: changes to a
+// defaulted attribute, or to the object holding it, are marked FromDefault with the
+// default's DefaultSource
+func RecordDefaultProvenance(resp *PlanResponse, provenance []DefaultProvenance) {
+	if resp == nil || len(provenance) == 0 {
+		return
+	}
+
+	recorded := make(map[string]bool, len(provenance))
+	creating := false
+	for i := range resp.Changes {
+		change := &resp.Changes[i]
+		if change.Action == "create" && change.Property == "" {
+			creating = true
+			continue
+		}
+		if change.Property == "" {
+			continue
+		}
+		for _, p := range provenance {
+			if p.Path == change.Property || strings.HasPrefix(p.Path, change.Property+".") {
+				change.FromDefault = true
+				if change.DefaultSource == "" {
+					change.DefaultSource = p.String()
+				}
+				recorded[p.Path] = true
+			}
+		}
+	}
+	if !creating {
+		return
+	}
+
+	for _, p := range provenance {
+		if recorded[p.Path] {
+			continue
+		}
+		description := fmt.Sprintf("%s defaults to %v", p.Path, p.Value)
+		if p.Source != DefaultSourceSchema {
+			description += " from " + p.String()
+		}
+		resp.Changes = append(resp.Changes, PlannedChange{
+			Action:        "create",
+			Property:      p.Path,
+			NewValue:      p.Value,
+			RiskLevel:     "low",
+			Description:   description,
+			FromDefault:   true,
+			DefaultSource: p.String(),
+		})
+	}
+}
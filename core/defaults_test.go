@@ -0,0 +1,63 @@
+package core
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestInjectDefaults validates absent and null attributes receive declared defaults
+func TestInjectDefaults(t *testing.T) {
+	properties := map[string]*Property{
+		"port":     {Type: "integer", Default: 5432},
+		"ssl_mode": {Type: "string", Enhanced: &EnhancedValidation{Rules: []ConfigValidationRule{NewValidationRule("ssl_mode").Default("require").Build()}}},
+		"comment":  {Type: "string", Default: "managed"},
+		"name":     {Type: "string"},
+	}
+	config := map[string]interface{}{"name": "orders", "comment": nil, "port": 6432}
+
+	planned, injected := InjectDefaults(config, PropertyDefaults(properties))
+
+	if planned["port"] != 6432 {
+		t.Errorf("explicit value should be kept, got %v", planned["port"])
+	}
+	if planned["ssl_mode"] != "require" || planned["comment"] != "managed" {
+		t.Errorf("defaults not injected: %v", planned)
+	}
+	if len(injected) != 2 || injected["ssl_mode"] != "require" || injected["comment"] != "managed" {
+		t.Errorf("unexpected injected set: %v", injected)
+	}
+	if config["comment"] != nil || len(config) != 3 {
+		t.Errorf("input config was modified: %v", config)
+	}
+}
+
+// TestSchemaDefaults validates defaults are read from a JSON Schema
+func TestSchemaDefaults(t *testing.T) {
+	defaults := SchemaDefaults(json.RawMessage(`{"properties":{"replicas":{"type":"integer","default":3},"name":{"type":"string"}}}`))
+	if len(defaults) != 1 || defaults["replicas"] != float64(3) {
+		t.Errorf("unexpected defaults: %v", defaults)
+	}
+}
+
+// TestRecordDefaults validates defaulted values are marked in the plan
+func TestRecordDefaults(t *testing.T) {
+	injected := map[string]interface{}{"port": 5432, "ssl_mode": "require"}
+
+	create := &PlanResponse{Changes: []PlannedChange{{Action: "create"}}}
+	RecordDefaults(create, injected)
+	if len(create.Changes) != 3 {
+		t.Fatalf("expected a change per default, got %+v", create.Changes)
+	}
+	if c := create.Changes[1]; c.Property != "port" || !c.FromDefault || c.NewValue != 5432 {
+		t.Errorf("unexpected default change: %+v", c)
+	}
+
+	update := &PlanResponse{Changes: []PlannedChange{
+		{Action: "update", Property: "port", NewValue: 5432},
+		{Action: "update", Property: "name", NewValue: "orders"},
+	}}
+	RecordDefaults(update, injected)
+	if len(update.Changes) != 2 || !update.Changes[0].FromDefault || update.Changes[1].FromDefault {
+		t.Errorf("unexpected update changes: %+v", update.Changes)
+	}
+}
@@ -0,0 +1,244 @@
+// Package core provides multi-type discovery fan-out for the unified dispatcher
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/schemabounce/kolumn/sdk/helpers/security"
+)
+
+// DefaultDiscoverConcurrency bounds how many discover handlers run at once during a
+// multi-type DiscoverResources request
+const DefaultDiscoverConcurrency = 8
+
+// Limits of a multi-type DiscoverResources request: max_concurrency is capped at
+// MaxDiscoverConcurrency and requests naming more than MaxDiscoverTypes object types
+// are rejected
+const (
+	MaxDiscoverConcurrency = 32
+	MaxDiscoverTypes       = 256
+)
+
+// AggregatedDiscoverResponse merges scan responses from several object types. Objects,
+// Summary and Warnings use the same JSON shape as a single-type scan response.
+type AggregatedDiscoverResponse struct {
+	Objects       []json.RawMessage               `json:"objects"`
+	Summary       *AggregatedDiscoverSummary      `json:"summary"`
+	TypeSummaries map[string]*DiscoverTypeSummary `json:"type_summaries"`
+	Warnings      []string                        `json:"warnings,omitempty"`
+}
+
+// AggregatedDiscoverSummary totals all object types in a multi-type discovery
+type AggregatedDiscoverSummary struct {
+	TotalObjects int            `json:"total_objects"`
+	ObjectTypes  map[string]int `json:"object_types"` // count by type
+	Duration     string         `json:"duration"`
+	Errors       int            `json:"errors,omitempty"`
+}
+
+// DiscoverTypeSummary reports the outcome of discovery for one object type
+type DiscoverTypeSummary struct {
+	ObjectType string          `json:"object_type"`
+	Objects    int             `json:"objects"`
+	Duration   string          `json:"duration"`
+	Error      string          `json:"error,omitempty"`
+	Summary    json.RawMessage `json:"summary,omitempty"` // handler's own summary
+}
+
+// discoverTypesRequest is the multi-type form of a DiscoverResources request
+type discoverTypesRequest struct {
+	ResourceType  string                 `json:"resource_type"`
+	ObjectTypes   []string               `json:"object_types"`
+	ResourceTypes []string               `json:"resource_types"`
+	Filters       interface{}            `json:"filters,omitempty"`
+	Options       map[string]interface{} `json:"options,omitempty"`
+}
+
+// requestedTypes returns the object types a DiscoverResources request names and
+// whether the request needs fan-out (more than one type, or a "*" wildcard)
+func (r *discoverTypesRequest) requestedTypes() ([]string, bool) {
+	types := append(append([]string(nil), r.ObjectTypes...), r.ResourceTypes...)
+	if r.ResourceType != "" {
+		types = append(types, r.ResourceType)
+	}
+	for _, t := range types {
+		if t == "*" {
+			return []string{"*"}, true
+		}
+	}
+	return types, len(types) > 1
+}
+
+// isMultiTypeDiscover reports whether a DiscoverResources input needs fan-out
+func isMultiTypeDiscover(input []byte) bool {
+	var req discoverTypesRequest
+	if json.Unmarshal(input, &req) != nil {
+		return false
+	}
+	_, multi := req.requestedTypes()
+	return multi
+}
+
+// discoverMultiple fans a discovery request out to every requested discover handler
+// concurrently and merges their responses. Each type is tier gated, feature flag gated
+// and authorized like a single-type request; denied types are skipped and reported in
+// their type summary.
+func (d *UnifiedDispatcher) discoverMultiple(ctx context.Context, input []byte, req *discoverTypesRequest, types []string) ([]byte, error) {
+	if d.discoverRegistry == nil {
+		return nil, security.NewSecureError(
+			"registry not available",
+			"no discover registry available for multi-type discovery",
+			"REGISTRY_NOT_FOUND",
+		)
+	}
+
+	registered := d.discoverRegistry.GetObjectTypes()
+	if len(types) == 1 && types[0] == "*" {
+		// a wildcard only covers types whose experimental flag is enabled
+		types = types[:0]
+		for name := range registered {
+			if d.featureFlags == nil || d.featureFlags.Allows(name) {
+				types = append(types, name)
+			}
+		}
+	}
+	types = uniqueSorted(types)
+	if len(types) > MaxDiscoverTypes {
+		return nil, security.NewSecureError(
+			"too many resource types",
+			fmt.Sprintf("multi-type discovery of %d types exceeds the limit of %d", len(types), MaxDiscoverTypes),
+			"INVALID_REQUEST",
+		)
+	}
+
+	for _, t := range types {
+		if err := security.ValidateObjectType(t); err != nil {
+			return nil, security.NewSecureError(
+				"invalid resource type",
+				fmt.Sprintf("resource type validation failed: %v", err),
+				"INVALID_RESOURCE_TYPE",
+			)
+		}
+	}
+
+	concurrency := requestConcurrency(req.Options, DefaultDiscoverConcurrency, MaxDiscoverConcurrency)
+
+	_, user := requestUserContext(input)
+	gate := d.requestTierGate(input, &tierRequest{})
+	start := clock.Now()
+	results := make([]*DiscoverTypeSummary, len(types))
+	objects := make([][]json.RawMessage, len(types))
+	warnings := make([][]string, len(types))
+
+	forEachBounded(len(types), concurrency, func(i int) {
+		objectType := types[i]
+		typeStart := clock.Now()
+		summary := &DiscoverTypeSummary{ObjectType: objectType}
+		results[i] = summary
+		defer func() { summary.Duration = clock.Since(typeStart).String() }()
+
+		if _, exists := registered[objectType]; !exists {
+			summary.Error = "no discover handler registered"
+			return
+		}
+		if err := gate.Check(ctx, objectType); err != nil {
+			summary.Error = err.Error()
+			return
+		}
+		if d.featureFlags != nil {
+			if err := d.featureFlags.Check(objectType); err != nil {
+				summary.Error = err.Error()
+				return
+			}
+		}
+		if d.authorizer != nil {
+			if err := d.authorizer.Authorize(user, "DiscoverResources", objectType); err != nil {
+				summary.Error = err.Error()
+				return
+			}
+		}
+
+		scanReq := map[string]interface{}{
+			"object_type":  objectType,
+			"object_types": []string{objectType},
+		}
+		if req.Filters != nil {
+			scanReq["filters"] = req.Filters
+		}
+		if req.Options != nil {
+			scanReq["options"] = req.Options
+		}
+		scanInput, err := json.Marshal(scanReq)
+		if err != nil {
+			summary.Error = err.Error()
+			return
+		}
+
+		output, err := d.discoverRegistry.CallHandler(ctx, objectType, "scan", scanInput)
+		if err != nil {
+			summary.Error = err.Error()
+			return
+		}
+
+		var resp struct {
+			Objects  []json.RawMessage `json:"objects"`
+			Summary  json.RawMessage   `json:"summary"`
+			Warnings []string          `json:"warnings"`
+		}
+		if err := json.Unmarshal(output, &resp); err != nil {
+			summary.Error = fmt.Sprintf("invalid scan response: %v", err)
+			return
+		}
+		objects[i] = resp.Objects
+		warnings[i] = resp.Warnings
+		summary.Objects = len(resp.Objects)
+		if len(resp.Summary) > 0 && string(resp.Summary) != "null" {
+			summary.Summary = resp.Summary
+		}
+	})
+
+	merged := &AggregatedDiscoverResponse{
+		Objects:       []json.RawMessage{},
+		TypeSummaries: make(map[string]*DiscoverTypeSummary, len(types)),
+		Summary:       &AggregatedDiscoverSummary{ObjectTypes: make(map[string]int, len(types))},
+	}
+	for i, summary := range results {
+		merged.TypeSummaries[summary.ObjectType] = summary
+		merged.Objects = append(merged.Objects, objects[i]...)
+		merged.Summary.ObjectTypes[summary.ObjectType] = summary.Objects
+		merged.Summary.TotalObjects += summary.Objects
+		for _, w := range warnings[i] {
+			merged.Warnings = append(merged.Warnings, fmt.Sprintf("%s: %s", summary.ObjectType, w))
+		}
+		if summary.Error != "" {
+			merged.Summary.Errors++
+			merged.Warnings = append(merged.Warnings, fmt.Sprintf("%s: discovery failed: %s", summary.ObjectType, summary.Error))
+		}
+	}
+	merged.Summary.Duration = clock.Since(start).String()
+
+	if len(types) > 0 && merged.Summary.Errors == len(types) {
+		return nil, security.NewSecureError(
+			"discovery failed",
+			fmt.Sprintf("discovery failed for all %d object types", len(types)),
+			"DISCOVERY_FAILED",
+		)
+	}
+	return json.Marshal(merged)
+}
+
+func uniqueSorted(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if v != "" && !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
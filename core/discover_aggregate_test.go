@@ -0,0 +1,130 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+type fakeDiscoverRegistry struct {
+	mu      sync.Mutex
+	types   map[string]*ObjectType
+	failing map[string]bool
+	calls   []string
+}
+
+func (r *fakeDiscoverRegistry) GetObjectTypes() map[string]*ObjectType {
+	return r.types
+}
+
+func (r *fakeDiscoverRegistry) CallHandler(ctx context.Context, objectType, method string, input []byte) ([]byte, error) {
+	r.mu.Lock()
+	r.calls = append(r.calls, objectType)
+	r.mu.Unlock()
+
+	if r.failing[objectType] {
+		return nil, fmt.Errorf("connection reset")
+	}
+	return []byte(fmt.Sprintf(`{"objects":[{"id":"%[1]s-1","type":"%[1]s"},{"id":"%[1]s-2","type":"%[1]s"}],"summary":{"total_objects":2}}`, objectType)), nil
+}
+
+func newFakeDiscoverRegistry(failing ...string) *fakeDiscoverRegistry {
+	r := &fakeDiscoverRegistry{
+		types: map[string]*ObjectType{
+			"table":  {Name: "table"},
+			"view":   {Name: "view"},
+			"schema": {Name: "schema"},
+		},
+		failing: map[string]bool{},
+	}
+	for _, t := range failing {
+		r.failing[t] = true
+	}
+	return r
+}
+
+// TestDiscoverResourcesWildcard validates fan-out to every registered handler
+func TestDiscoverResourcesWildcard(t *testing.T) {
+	registry := newFakeDiscoverRegistry("view")
+	d := NewUnifiedDispatcher(nil, registry)
+
+	output, err := d.Dispatch(context.Background(), "DiscoverResources", []byte(`{"resource_type":"*"}`))
+	if err != nil {
+		t.Fatalf("dispatch failed: %v", err)
+	}
+
+	var resp AggregatedDiscoverResponse
+	if err := json.Unmarshal(output, &resp); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if len(registry.calls) != 3 {
+		t.Errorf("expected 3 handler calls, got %v", registry.calls)
+	}
+	if resp.Summary.TotalObjects != 4 || len(resp.Objects) != 4 {
+		t.Errorf("expected 4 objects, got %d (%d)", resp.Summary.TotalObjects, len(resp.Objects))
+	}
+	if resp.Summary.Errors != 1 || resp.TypeSummaries["view"].Error == "" {
+		t.Errorf("expected view failure to be reported, got %+v", resp.TypeSummaries["view"])
+	}
+	if resp.TypeSummaries["table"].Objects != 2 {
+		t.Errorf("expected per-type summary for table, got %+v", resp.TypeSummaries["table"])
+	}
+}
+
+// TestDiscoverResourcesObjectTypes validates explicit type lists and unknown types
+func TestDiscoverResourcesObjectTypes(t *testing.T) {
+	registry := newFakeDiscoverRegistry()
+	d := NewUnifiedDispatcher(nil, registry)
+
+	output, err := d.Dispatch(context.Background(), "DiscoverResources", []byte(`{"object_types":["table","index"]}`))
+	if err != nil {
+		t.Fatalf("dispatch failed: %v", err)
+	}
+
+	var resp AggregatedDiscoverResponse
+	if err := json.Unmarshal(output, &resp); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if len(registry.calls) != 1 || registry.calls[0] != "table" {
+		t.Errorf("expected only table handler to run, got %v", registry.calls)
+	}
+	if resp.TypeSummaries["index"] == nil || resp.TypeSummaries["index"].Error == "" {
+		t.Errorf("expected unknown type to be reported")
+	}
+}
+
+// TestDiscoverResourcesAllFail validates that total failure is an error
+func TestDiscoverResourcesAllFail(t *testing.T) {
+	d := NewUnifiedDispatcher(nil, newFakeDiscoverRegistry("table", "view"))
+
+	if _, err := d.Dispatch(context.Background(), "DiscoverResources", []byte(`{"object_types":["table","view"]}`)); err == nil {
+		t.Fatalf("expected error when every type fails")
+	}
+}
+
+// TestDiscoverResourcesLimits validates the type count and max_concurrency caps
+func TestDiscoverResourcesLimits(t *testing.T) {
+	registry := newFakeDiscoverRegistry()
+	d := NewUnifiedDispatcher(nil, registry)
+
+	types := make([]string, MaxDiscoverTypes+1)
+	for i := range types {
+		types[i] = fmt.Sprintf("type_%d", i)
+	}
+	input, _ := json.Marshal(map[string]interface{}{"object_types": types})
+	if _, err := d.Dispatch(context.Background(), "DiscoverResources", input); err == nil {
+		t.Fatalf("expected more than %d types to be rejected", MaxDiscoverTypes)
+	}
+	if len(registry.calls) != 0 {
+		t.Errorf("no handler should run for a rejected request, got %v", registry.calls)
+	}
+
+	if got := requestConcurrency(map[string]interface{}{"max_concurrency": 1e9}, DefaultDiscoverConcurrency, MaxDiscoverConcurrency); got != MaxDiscoverConcurrency {
+		t.Errorf("max_concurrency = %d, want the cap %d", got, MaxDiscoverConcurrency)
+	}
+	if got := requestConcurrency(map[string]interface{}{"max_concurrency": 0.5}, DefaultDiscoverConcurrency, MaxDiscoverConcurrency); got != DefaultDiscoverConcurrency {
+		t.Errorf("max_concurrency = %d, want the default %d", got, DefaultDiscoverConcurrency)
+	}
+}
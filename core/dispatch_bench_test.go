@@ -0,0 +1,156 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/schemabounce/kolumn/sdk/helpers/security"
+)
+
+// echoRegistry returns a fixed response so benchmarks measure dispatch alone
+type echoRegistry struct {
+	last []byte
+}
+
+func (r *echoRegistry) GetObjectTypes() map[string]*ObjectType {
+	return map[string]*ObjectType{"table": {Name: "table", Properties: map[string]*Property{
+		"schema": {Type: "string"}, "columns": {Type: "array"}, "comment": {Type: "string"},
+	}}}
+}
+
+func (r *echoRegistry) CallHandler(ctx context.Context, objectType, method string, input []byte) ([]byte, error) {
+	r.last = input
+	return []byte(`{"success":true}`), nil
+}
+
+// dispatchBenchRequests are representative requests for each resource operation
+var dispatchBenchRequests = map[string]string{
+	"CreateResource": `{"resource_type":"table","name":"users","config":{"schema":"public","comment":"accounts",` +
+		`"columns":[{"name":"id","type":"bigint"},{"name":"email","type":"text"}]},"dependencies":["postgres.schema.public"]}`,
+	"ReadResource":   `{"resource_type":"table","resource_id":"public.users","name":"users"}`,
+	"UpdateResource": `{"resource_type":"table","resource_id":"public.users","name":"users","config":{"schema":"public","comment":"all accounts"},"current_state":{"comment":"accounts"}}`,
+	"DeleteResource": `{"resource_type":"table","resource_id":"public.users","name":"users","state":{"schema":"public"}}`,
+}
+
+func benchmarkDispatch(b *testing.B, function string) {
+	d := NewUnifiedDispatcher(&echoRegistry{}, nil)
+	input := []byte(dispatchBenchRequests[function])
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := d.Dispatch(ctx, function, input); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDispatchCreate(b *testing.B) { benchmarkDispatch(b, "CreateResource") }
+func BenchmarkDispatchRead(b *testing.B)   { benchmarkDispatch(b, "ReadResource") }
+func BenchmarkDispatchUpdate(b *testing.B) { benchmarkDispatch(b, "UpdateResource") }
+func BenchmarkDispatchDelete(b *testing.B) { benchmarkDispatch(b, "DeleteResource") }
+
+// TestDispatchAllocationBudget validates resource operations stay within the
+// allocations recorded for the current SDK version in testdata/dispatch_allocs.json.
+// Record a budget for each new version from the benchmark results.
+func TestDispatchAllocationBudget(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocation budgets are recorded without the race detector")
+	}
+	data, err := os.ReadFile("testdata/dispatch_allocs.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var budgets map[string]map[string]float64
+	if err := json.Unmarshal(data, &budgets); err != nil {
+		t.Fatal(err)
+	}
+	budget, ok := budgets[SDKVersion]
+	if !ok {
+		t.Skipf("no allocation budget recorded for SDK %s", SDKVersion)
+	}
+
+	d := NewUnifiedDispatcher(&echoRegistry{}, nil)
+	ctx := context.Background()
+	for _, function := range sortedStringKeys(dispatchBenchRequests) {
+		input := []byte(dispatchBenchRequests[function])
+		allocs := testing.AllocsPerRun(100, func() {
+			if _, err := d.Dispatch(ctx, function, input); err != nil {
+				t.Fatal(err)
+			}
+		})
+		if limit, ok := budget[function]; !ok {
+			t.Errorf("%s: no allocation budget for SDK %s (measured %.0f)", function, SDKVersion, allocs)
+		} else if allocs > limit {
+			t.Errorf("%s: %.0f allocations per call, budget is %.0f", function, allocs, limit)
+		}
+	}
+}
+
+// TestDispatchRegistryInput validates the registry receives the same request the
+// map-based transformation produced
+func TestDispatchRegistryInput(t *testing.T) {
+	registry := &echoRegistry{}
+	d := NewUnifiedDispatcher(registry, nil)
+	ctx := context.Background()
+
+	cases := map[string]map[string]interface{}{
+		"CreateResource": {
+			"object_type": "table", "name": "users", "dependencies": []interface{}{"postgres.schema.public"},
+			"config": map[string]interface{}{"schema": "public", "comment": "accounts", "columns": []interface{}{
+				map[string]interface{}{"name": "id", "type": "bigint"}, map[string]interface{}{"name": "email", "type": "text"},
+			}},
+		},
+		"ReadResource": {"object_type": "table", "resource_id": "public.users", "name": "users"},
+		"UpdateResource": {
+			"object_type": "table", "resource_id": "public.users", "name": "users",
+			"config":        map[string]interface{}{"schema": "public", "comment": "all accounts"},
+			"current_state": map[string]interface{}{"comment": "accounts"},
+		},
+		"DeleteResource": {"object_type": "table", "resource_id": "public.users", "name": "users", "state": map[string]interface{}{"schema": "public"}},
+	}
+	for function, want := range cases {
+		if _, err := d.Dispatch(ctx, function, []byte(dispatchBenchRequests[function])); err != nil {
+			t.Fatalf("%s: %v", function, err)
+		}
+		var got map[string]interface{}
+		if err := json.Unmarshal(registry.last, &got); err != nil {
+			t.Fatalf("%s: registry input is not JSON: %v", function, err)
+		}
+		wantJSON, _ := json.Marshal(want)
+		gotJSON, _ := json.Marshal(got)
+		if string(gotJSON) != string(wantJSON) {
+			t.Errorf("%s: registry input = %s, want %s", function, gotJSON, wantJSON)
+		}
+	}
+
+	// missing required fields are passed as null
+	if _, err := d.Dispatch(ctx, "DeleteResource", []byte(`{"resource_type":"table"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(registry.last); got != `{"object_type":"table","resource_id":null,"name":null}` {
+		t.Errorf("registry input = %s", got)
+	}
+
+	for input, code := range map[string]string{
+		`[]`:                                    "INVALID_REQUEST",
+		`{"resource_type":7}`:                   "MISSING_RESOURCE_TYPE",
+		`{"resource_type":"../etc"}`:            "INVALID_RESOURCE_TYPE",
+		`{"resource_type":"table","config":{}}`: "",
+	} {
+		_, err := d.Dispatch(ctx, "CreateResource", []byte(input))
+		var got string
+		var secureErr *security.SecureError
+		if errors.As(err, &secureErr) {
+			got = secureErr.Code
+		} else if err != nil {
+			got = err.Error()
+		}
+		if got != code {
+			t.Errorf("CreateResource(%s) error code = %q, want %q", input, got, code)
+		}
+	}
+}
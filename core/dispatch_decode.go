@@ -0,0 +1,188 @@
+// Package core provides allocation-conscious decoding of resource operation requests
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/schemabounce/kolumn/sdk/helpers/security"
+)
+
+// maxPooledRequestBuffer keeps unusually large requests from pinning memory in the pool
+const maxPooledRequestBuffer = 64 << 10
+
+// registryField is a field copied from a resource request into the registry request.
+// Required fields are written as null when absent, optional ones are omitted.
+type registryField struct {
+	name     string
+	key      string // pre-encoded `,"name":`
+	required bool
+}
+
+func newRegistryFields(required []string, optional ...string) []registryField {
+	fields := make([]registryField, 0, len(required)+len(optional))
+	for i, names := range [][]string{required, optional} {
+		for _, name := range names {
+			fields = append(fields, registryField{name: name, key: `,"` + name + `":`, required: i == 0})
+		}
+	}
+	return fields
+}
+
+// Fields each operation passes to its handler, besides object_type
+var (
+	createRegistryFields = newRegistryFields([]string{"name", "config"}, "dependencies", "options", "metadata")
+	readRegistryFields   = newRegistryFields([]string{"resource_id", "name"}, "consistency")
+	updateRegistryFields = newRegistryFields([]string{"resource_id", "name", "config"}, "current_state", "options")
+	deleteRegistryFields = newRegistryFields([]string{"resource_id", "name"}, "state", "options")
+)
+
+var jsonNull = []byte("null")
+
+// resourceDecoder is compiled once per registered resource type: the type has passed
+// ValidateObjectType, its object_type value is pre-encoded, and its config map is sized
+// from the schema
+type resourceDecoder struct {
+	objectType []byte
+	configHint int
+	generation uint64
+}
+
+// resourceRequest is a decoded CreateResource, ReadResource, UpdateResource or
+// DeleteResource request. Fields stay raw JSON so they are copied into the registry
+// request without being decoded into interface{} values and marshaled again. Requests
+// are pooled; release returns one once its registry request has been built.
+type resourceRequest struct {
+	resourceType string
+	decoder      *resourceDecoder
+	fields       map[string]json.RawMessage
+	config       map[string]json.RawMessage
+	buf          bytes.Buffer
+}
+
+var resourceRequestPool = sync.Pool{
+	New: func() interface{} {
+		return &resourceRequest{fields: make(map[string]json.RawMessage, 8)}
+	},
+}
+
+func (r *resourceRequest) release() {
+	if r.buf.Cap() > maxPooledRequestBuffer {
+		return
+	}
+	clear(r.fields)
+	clear(r.config)
+	r.resourceType, r.decoder = "", nil
+	r.buf.Reset()
+	resourceRequestPool.Put(r)
+}
+
+// decodeResourceRequest validates and decodes a resource operation request. operation
+// prefixes error details, e.g. "create". Callers must release the returned request.
+func (d *UnifiedDispatcher) decodeResourceRequest(input []byte, operation string, validateConfig bool) (*resourceRequest, error) {
+	req := resourceRequestPool.Get().(*resourceRequest)
+
+	// SECURITY: Use safe unmarshaling with size and depth limits
+	if err := security.SafeUnmarshal(input, &req.fields); err != nil {
+		req.release()
+		return nil, security.NewSecureError(
+			"invalid request format",
+			fmt.Sprintf("%s request unmarshal failed: %v", operation, err),
+			"INVALID_REQUEST",
+		)
+	}
+
+	raw := req.fields["resource_type"]
+	if len(raw) == 0 || raw[0] != '"' || json.Unmarshal(raw, &req.resourceType) != nil {
+		req.release()
+		return nil, security.NewSecureError(
+			"invalid request format",
+			"missing resource_type in request",
+			"MISSING_RESOURCE_TYPE",
+		)
+	}
+
+	decoder, err := d.resourceDecoder(req.resourceType)
+	if err != nil {
+		req.release()
+		return nil, security.NewSecureError(
+			"invalid resource type",
+			fmt.Sprintf("resource type validation failed: %v", err),
+			"INVALID_RESOURCE_TYPE",
+		)
+	}
+	req.decoder = decoder
+
+	// SECURITY: Validate request configuration size. Values were size and depth checked
+	// with the rest of the request above, so only the top-level keys are decoded.
+	if config := req.fields["config"]; validateConfig && len(config) > 0 && config[0] == '{' {
+		if req.config == nil {
+			req.config = make(map[string]json.RawMessage, decoder.configHint)
+		}
+		err := json.Unmarshal(config, &req.config)
+		if err == nil {
+			err = (&security.InputSizeValidator{}).ValidateRawConfigSize(req.config)
+		}
+		if err != nil {
+			req.release()
+			return nil, security.NewSecureError(
+				"request too large",
+				fmt.Sprintf("%s request config validation failed: %v", operation, err),
+				"REQUEST_TOO_LARGE",
+			)
+		}
+	}
+	return req, nil
+}
+
+// registryInput renders the registry request: object_type followed by fields copied
+// verbatim from the resource request. The result does not alias the pooled buffer.
+func (r *resourceRequest) registryInput(fields []registryField) []byte {
+	r.buf.WriteString(`{"object_type":`)
+	r.buf.Write(r.decoder.objectType)
+	for _, field := range fields {
+		value, ok := r.fields[field.name]
+		if !ok {
+			if !field.required {
+				continue
+			}
+			value = jsonNull
+		}
+		r.buf.WriteString(field.key)
+		r.buf.Write(value)
+	}
+	r.buf.WriteByte('}')
+	return append([]byte(nil), r.buf.Bytes()...)
+}
+
+// resourceDecoder returns the compiled decoder for a resource type. Decoders are cached
+// only for registered types so arbitrary request values cannot grow the cache, and are
+// recompiled when a registry implementing SchemaGenerationer changes.
+func (d *UnifiedDispatcher) resourceDecoder(resourceType string) (*resourceDecoder, error) {
+	var generation uint64
+	if g, ok := d.createRegistry.(SchemaGenerationer); ok {
+		generation = g.Generation()
+	}
+	if cached, ok := d.decoders.Load(resourceType); ok && cached.(*resourceDecoder).generation == generation {
+		return cached.(*resourceDecoder), nil
+	}
+
+	if err := security.ValidateObjectType(resourceType); err != nil {
+		return nil, err
+	}
+	objectType, err := json.Marshal(resourceType)
+	if err != nil {
+		return nil, err
+	}
+	decoder := &resourceDecoder{objectType: objectType, generation: generation}
+	if d.createRegistry == nil {
+		return decoder, nil
+	}
+	if ot, ok := d.createRegistry.GetObjectTypes()[resourceType]; ok && ot != nil {
+		decoder.configHint = len(ot.Properties)
+		d.decoders.Store(resourceType, decoder)
+	}
+	return decoder, nil
+}
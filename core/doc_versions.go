@@ -0,0 +1,226 @@
+// Package core provides multi-version provider documentation and changelog diffs
+package core
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// DefaultRetainedDocVersions is how many previous versions the builder keeps
+const DefaultRetainedDocVersions = 10
+
+// VersionedDocumentation is the documentation of one earlier provider version
+type VersionedDocumentation struct {
+	Version   string                  `json:"version"`
+	Resources map[string]*ResourceDoc `json:"resources"`
+
+	// Changelog describes what changed from this version to the next newer one
+	Changelog *DocsChangelog `json:"changelog,omitempty"`
+}
+
+// DocsChangelog lists the differences between two documented provider versions
+type DocsChangelog struct {
+	FromVersion         string                         `json:"from_version"`
+	ToVersion           string                         `json:"to_version"`
+	AddedResources      []string                       `json:"added_resources,omitempty"`
+	RemovedResources    []string                       `json:"removed_resources,omitempty"`
+	DeprecatedResources []string                       `json:"deprecated_resources,omitempty"`
+	ChangedResources    map[string]*ResourceSchemaDiff `json:"changed_resources,omitempty"`
+}
+
+// Empty reports whether the versions document identical resources
+func (c *DocsChangelog) Empty() bool {
+	return len(c.AddedResources) == 0 && len(c.RemovedResources) == 0 &&
+		len(c.DeprecatedResources) == 0 && len(c.ChangedResources) == 0
+}
+
+// ResourceSchemaDiff lists the argument and attribute changes of one resource
+type ResourceSchemaDiff struct {
+	AddedArguments    []string `json:"added_arguments,omitempty"`
+	RemovedArguments  []string `json:"removed_arguments,omitempty"`
+	ChangedArguments  []string `json:"changed_arguments,omitempty"`
+	AddedAttributes   []string `json:"added_attributes,omitempty"`
+	RemovedAttributes []string `json:"removed_attributes,omitempty"`
+	ChangedAttributes []string `json:"changed_attributes,omitempty"`
+
+	// Properties newly marked "deprecated": true in the JSON schema
+	DeprecatedArguments  []string `json:"deprecated_arguments,omitempty"`
+	DeprecatedAttributes []string `json:"deprecated_attributes,omitempty"`
+}
+
+// DiffDocumentation compares the resources of two documented versions; arguments come
+// from each resource's config schema and attributes from its state schema
+func DiffDocumentation(older, newer *UniversalProviderDocumentation) *DocsChangelog {
+	changelog := &DocsChangelog{}
+	var oldResources, newResources map[string]*ResourceDoc
+	if older != nil {
+		changelog.FromVersion = older.Provider.Version
+		oldResources = older.Resources
+	}
+	if newer != nil {
+		changelog.ToVersion = newer.Provider.Version
+		newResources = newer.Resources
+	}
+	diffResources(changelog, oldResources, newResources)
+	return changelog
+}
+
+func diffResources(changelog *DocsChangelog, older, newer map[string]*ResourceDoc) {
+	for name := range newer {
+		if _, ok := older[name]; !ok {
+			changelog.AddedResources = append(changelog.AddedResources, name)
+		}
+	}
+	for name, after := range newer {
+		if before, ok := older[name]; ok && !resourceDeprecated(before) && resourceDeprecated(after) {
+			changelog.DeprecatedResources = append(changelog.DeprecatedResources, name)
+		}
+	}
+	for name, before := range older {
+		after, ok := newer[name]
+		if !ok {
+			changelog.RemovedResources = append(changelog.RemovedResources, name)
+			continue
+		}
+		diff := &ResourceSchemaDiff{}
+		diff.AddedArguments, diff.RemovedArguments, diff.ChangedArguments = diffSchemaProperties(before.Schema, after.Schema)
+		diff.AddedAttributes, diff.RemovedAttributes, diff.ChangedAttributes = diffSchemaProperties(before.StateSchema, after.StateSchema)
+		diff.DeprecatedArguments = newlyDeprecatedProperties(before.Schema, after.Schema)
+		diff.DeprecatedAttributes = newlyDeprecatedProperties(before.StateSchema, after.StateSchema)
+		diff.ChangedArguments = withoutStrings(diff.ChangedArguments, diff.DeprecatedArguments)
+		diff.ChangedAttributes = withoutStrings(diff.ChangedAttributes, diff.DeprecatedAttributes)
+		if string(mustMarshal(diff)) != "{}" {
+			if changelog.ChangedResources == nil {
+				changelog.ChangedResources = make(map[string]*ResourceSchemaDiff)
+			}
+			changelog.ChangedResources[name] = diff
+		}
+	}
+	sort.Strings(changelog.AddedResources)
+	sort.Strings(changelog.RemovedResources)
+	sort.Strings(changelog.DeprecatedResources)
+}
+
+// withoutStrings returns values minus the ones in remove, keeping their order
+func withoutStrings(values, remove []string) []string {
+	if len(remove) == 0 {
+		return values
+	}
+	removed := stringSet(remove)
+	var kept []string
+	for _, v := range values {
+		if !removed[v] {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
+
+// deprecationSchema is the subset of JSON Schema that marks deprecations
+type deprecationSchema struct {
+	Deprecated bool                          `json:"deprecated"`
+	Properties map[string]*deprecationSchema `json:"properties"`
+}
+
+// resourceDeprecated reports whether a resource carries deprecation info or its config
+// schema is marked deprecated
+func resourceDeprecated(doc *ResourceDoc) bool {
+	if doc == nil {
+		return false
+	}
+	var s deprecationSchema
+	_ = json.Unmarshal(doc.Schema, &s)
+	return doc.Deprecation != nil || s.Deprecated
+}
+
+// newlyDeprecatedProperties lists the top-level properties deprecated in after but not before
+func newlyDeprecatedProperties(before, after json.RawMessage) []string {
+	var a, b deprecationSchema
+	_ = json.Unmarshal(before, &a)
+	_ = json.Unmarshal(after, &b)
+	var deprecated []string
+	for name, def := range b.Properties {
+		if old, ok := a.Properties[name]; ok && def != nil && def.Deprecated && (old == nil || !old.Deprecated) {
+			deprecated = append(deprecated, name)
+		}
+	}
+	sort.Strings(deprecated)
+	return deprecated
+}
+
+// diffSchemaProperties compares the top-level properties of two JSON schemas
+func diffSchemaProperties(before, after json.RawMessage) (added, removed, changed []string) {
+	var a, b struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+	}
+	_ = json.Unmarshal(before, &a)
+	_ = json.Unmarshal(after, &b)
+	for name, def := range b.Properties {
+		old, ok := a.Properties[name]
+		switch {
+		case !ok:
+			added = append(added, name)
+		case string(mustMarshal(jsonValue(old))) != string(mustMarshal(jsonValue(def))):
+			changed = append(changed, name)
+		}
+	}
+	for name := range a.Properties {
+		if _, ok := b.Properties[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	sort.Strings(changed)
+	return added, removed, changed
+}
+
+// AddPreviousVersion keeps the documentation of an earlier provider version, along
+// with any versions it kept itself. Versions are ordered newest first, a version is
+// kept once, the current version is never added as a previous one, and only the
+// newest DefaultRetainedDocVersions are retained. When the documentation is built,
+// each kept version's changelog describes the step to the next newer version.
+func (b *DocumentationBuilder) AddPreviousVersion(previous *UniversalProviderDocumentation) *DocumentationBuilder {
+	if previous == nil {
+		return b
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	candidates := append([]*VersionedDocumentation{{
+		Version:   previous.Provider.Version,
+		Resources: previous.Resources,
+	}}, previous.Versions...)
+
+	seen := make(map[string]bool, len(b.docs.Versions))
+	for _, v := range b.docs.Versions {
+		seen[v.Version] = true
+	}
+	for _, v := range candidates {
+		if v == nil || v.Version == "" || seen[v.Version] || v.Version == b.docs.Provider.Version {
+			continue
+		}
+		seen[v.Version] = true
+		b.docs.Versions = append(b.docs.Versions, &VersionedDocumentation{Version: v.Version, Resources: v.Resources})
+	}
+
+	sort.SliceStable(b.docs.Versions, func(i, j int) bool {
+		return CompareVersions(b.docs.Versions[i].Version, b.docs.Versions[j].Version) > 0
+	})
+	if len(b.docs.Versions) > DefaultRetainedDocVersions {
+		b.docs.Versions = b.docs.Versions[:DefaultRetainedDocVersions]
+	}
+	return b
+}
+
+// updateChangelogs recomputes the changelog of every kept version against the next
+// newer one; callers must hold b.mu
+func (b *DocumentationBuilder) updateChangelogs() {
+	newerVersion, newerResources := b.docs.Provider.Version, b.docs.Resources
+	for _, v := range b.docs.Versions {
+		changelog := &DocsChangelog{FromVersion: v.Version, ToVersion: newerVersion}
+		diffResources(changelog, v.Resources, newerResources)
+		v.Changelog = changelog
+		newerVersion, newerResources = v.Version, v.Resources
+	}
+}
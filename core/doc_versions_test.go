@@ -0,0 +1,50 @@
+package core
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func versionedDocs(version string, resources map[string]*ResourceDoc) *UniversalProviderDocumentation {
+	return &UniversalProviderDocumentation{Provider: ProviderMetadata{Version: version}, Resources: resources}
+}
+
+// TestDocumentationVersions validates previous versions are kept with changelogs
+func TestDocumentationVersions(t *testing.T) {
+	v1 := versionedDocs("1.0.0", map[string]*ResourceDoc{
+		"table": {Schema: json.RawMessage(`{"properties":{"name":{"type":"string"}}}`)},
+		"index": {},
+	})
+	v2 := versionedDocs("1.1.0", map[string]*ResourceDoc{
+		"table": {
+			Schema:      json.RawMessage(`{"properties":{"name":{"type":"string","description":"Table name"},"schema":{"type":"string"}}}`),
+			StateSchema: json.RawMessage(`{"properties":{"row_count":{"type":"integer"}}}`),
+		},
+		"index": {},
+	})
+	v2.Versions = []*VersionedDocumentation{{Version: "1.0.0", Resources: v1.Resources}}
+
+	b := NewDocumentationBuilder()
+	b.SetProvider(ProviderMetadata{Version: "2.0.0"})
+	b.AddResource("table", v2.Resources["table"])
+	b.AddResource("view", &ResourceDoc{})
+	b.AddPreviousVersion(v2).AddPreviousVersion(v1)
+
+	docs := b.Build()
+	if len(docs.Versions) != 2 || docs.Versions[0].Version != "1.1.0" || docs.Versions[1].Version != "1.0.0" {
+		t.Fatalf("unexpected versions: %+v", docs.Versions)
+	}
+
+	latest := docs.Versions[0].Changelog
+	if latest.ToVersion != "2.0.0" || len(latest.AddedResources) != 1 || latest.AddedResources[0] != "view" ||
+		len(latest.RemovedResources) != 1 || latest.RemovedResources[0] != "index" || len(latest.ChangedResources) != 0 {
+		t.Errorf("unexpected 1.1.0 -> 2.0.0 changelog: %+v", latest)
+	}
+
+	older := docs.Versions[1].Changelog
+	diff := older.ChangedResources["table"]
+	if older.ToVersion != "1.1.0" || diff == nil || len(diff.AddedArguments) != 1 || diff.AddedArguments[0] != "schema" ||
+		len(diff.ChangedArguments) != 1 || len(diff.AddedAttributes) != 1 {
+		t.Errorf("unexpected 1.0.0 -> 1.1.0 changelog: %+v %+v", older, diff)
+	}
+}
@@ -3,10 +3,14 @@ package core
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -21,6 +25,10 @@ type UniversalProviderDocumentation struct {
 	Compatibility  *CompatibilityInfo         `json:"compatibility,omitempty"`
 	Metadata       RegistryMetadata           `json:"metadata"`
 	SearchMetadata *SearchMetadata            `json:"search_metadata,omitempty"`
+	Capabilities   *CapabilityDocs            `json:"capabilities,omitempty"`
+
+	// Versions holds the documentation of earlier provider versions, newest first
+	Versions []*VersionedDocumentation `json:"versions,omitempty"`
 }
 
 // ProviderMetadata contains core information about the provider
@@ -95,6 +103,34 @@ type ResourceDoc struct {
 	Examples      []*ResourceExample      `json:"examples,omitempty"`
 	Relationships []*ResourceRelationship `json:"relationships,omitempty"`
 	Links         []DocumentationLink     `json:"links,omitempty"`
+
+	// Experimental resources are gated behind a provider feature flag
+	Experimental bool   `json:"experimental,omitempty"`
+	FeatureFlag  string `json:"feature_flag,omitempty"`
+
+	// BackendFeatures maps attributes accepted only by some backends to the backend
+	// feature they need
+	BackendFeatures map[string]string `json:"backend_features,omitempty"`
+
+	// Lifecycle information consumed by upgrade tooling
+	Deprecation     *DeprecationInfo `json:"deprecation,omitempty"`
+	ConfigRenames   []ConfigRename   `json:"config_renames,omitempty"`
+	StateVersion    int              `json:"state_version,omitempty"`
+	StateMigrations []StateMigration `json:"state_migrations,omitempty"`
+}
+
+// ConfigRename records a configuration key that was renamed in a provider release
+type ConfigRename struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Since string `json:"since,omitempty"` // provider version that introduced the rename
+}
+
+// StateMigration describes an automatic state upgrade between state schema versions
+type StateMigration struct {
+	FromVersion int    `json:"from_version"`
+	ToVersion   int    `json:"to_version"`
+	Description string `json:"description"`
 }
 
 // ResourceDocumentation contains resource-specific documentation
@@ -107,6 +143,9 @@ type ResourceDocumentation struct {
 	BestPractices   []string               `json:"best_practices,omitempty"`
 	CommonPitfalls  []string               `json:"common_pitfalls,omitempty"`
 	Troubleshooting []string               `json:"troubleshooting,omitempty"`
+
+	// AttributesReference lists every attribute other resources can reference
+	AttributesReference []AttributeReference `json:"attributes_reference,omitempty"`
 }
 
 // ResourceExample shows how to use a specific resource
@@ -121,6 +160,11 @@ type ResourceExample struct {
 	ExpectedOutputs map[string]interface{} `json:"expected_outputs,omitempty"`
 	Validated       bool                   `json:"validated,omitempty"`
 	Tags            []string               `json:"tags,omitempty"`
+
+	// Operation is the resource operation the example demonstrates and Command the CLI
+	// invocation that runs it against the configuration in HCL
+	Operation string `json:"operation,omitempty"`
+	Command   string `json:"command,omitempty"`
 }
 
 // ResourceRelationship describes relationships between resources
@@ -161,6 +205,8 @@ type GettingStartedGuide struct {
 // CompatibilityInfo contains version and platform compatibility
 type CompatibilityInfo struct {
 	KolumnVersion      string            `json:"kolumn_version,omitempty"`
+	MinCoreVersion     string            `json:"min_core_version,omitempty"`
+	MaxCoreVersion     string            `json:"max_core_version,omitempty"`
 	SDKVersion         string            `json:"sdk_version,omitempty"`
 	SupportedPlatforms []string          `json:"supported_platforms,omitempty"`
 	Dependencies       []string          `json:"dependencies,omitempty"`
@@ -183,14 +229,19 @@ type RegistryMetadata struct {
 	BuildInfo        *BuildInfo          `json:"build_info,omitempty"`
 	Validation       *ValidationResult   `json:"validation,omitempty"`
 	Stats            *DocumentationStats `json:"stats,omitempty"`
+	Attestation      *Attestation        `json:"attestation,omitempty"`
 }
 
 // BuildInfo contains build environment information
 type BuildInfo struct {
-	CommitHash string    `json:"commit_hash,omitempty"`
-	BuildDate  time.Time `json:"build_date,omitempty"`
-	GoVersion  string    `json:"go_version,omitempty"`
-	Platform   string    `json:"platform,omitempty"`
+	CommitHash    string             `json:"commit_hash,omitempty"`
+	BuildDate     time.Time          `json:"build_date,omitempty"`
+	GoVersion     string             `json:"go_version,omitempty"`
+	Platform      string             `json:"platform,omitempty"`
+	ModulePath    string             `json:"module_path,omitempty"`
+	ModuleVersion string             `json:"module_version,omitempty"`
+	Dependencies  []ModuleDependency `json:"dependencies,omitempty"` // SBOM summary
+	Provenance    *BuildProvenance   `json:"provenance,omitempty"`
 }
 
 // ValidationResult contains validation results
@@ -234,7 +285,7 @@ func BuildMinimalDocumentation(namespace, name, version, category, description s
 	})
 	b.SetConfiguration(ConfigurationDocumentation{Schema: configSchema})
 	b.SetMetadata(RegistryMetadata{
-		GeneratedAt:      time.Now().UTC(),
+		GeneratedAt:      clock.Now().UTC(),
 		GeneratorVersion: "kolumn-docs-gen-lite-0.1.0",
 		SchemaVersion:    "1.0.0",
 	})
@@ -390,6 +441,7 @@ func ApplyHeuristicSchemas(providerName string, docs *UniversalProviderDocumenta
 		if attrs := BuildAttributeDocsFromStateSchema(rd.StateSchema); attrs != nil {
 			rd.Documentation.Attributes = attrs
 		}
+		rd.Documentation.AttributesReference = BuildAttributeReference(name, rd.Schema, rd.StateSchema)
 		if category != "" {
 			rd.Category = category
 		}
@@ -532,17 +584,12 @@ func BuildAttributeDocsFromStateSchema(stateSchema json.RawMessage) map[string]i
 	if len(stateSchema) == 0 {
 		return nil
 	}
-	var s struct {
-		Properties map[string]struct {
-			Type        interface{} `json:"type"`
-			Description string      `json:"description"`
-		} `json:"properties"`
-	}
-	if err := json.Unmarshal(stateSchema, &s); err != nil || len(s.Properties) == 0 {
+	properties := schemaProperties(stateSchema)
+	if len(properties) == 0 {
 		return nil
 	}
 	out := map[string]interface{}{}
-	for k, v := range s.Properties {
+	for k, v := range properties {
 		entry := map[string]interface{}{}
 		if v.Type != nil {
 			entry["type"] = v.Type
@@ -552,6 +599,9 @@ func BuildAttributeDocsFromStateSchema(stateSchema json.RawMessage) map[string]i
 		} else {
 			entry["description"] = "Auto-generated attribute"
 		}
+		if v.sensitive() {
+			entry["sensitive"] = true
+		}
 		out[k] = entry
 	}
 	return out
@@ -569,8 +619,10 @@ type DocumentationGenerator interface {
 	GenerateSearchMetadata(docs *UniversalProviderDocumentation) *SearchMetadata
 }
 
-// DocumentationBuilder helps build documentation incrementally
+// DocumentationBuilder helps build documentation incrementally. It is safe for
+// concurrent use so several extractors can feed one builder.
 type DocumentationBuilder struct {
+	mu   sync.Mutex
 	docs *UniversalProviderDocumentation
 }
 
@@ -580,7 +632,7 @@ func NewDocumentationBuilder() *DocumentationBuilder {
 		docs: &UniversalProviderDocumentation{
 			Resources: make(map[string]*ResourceDoc),
 			Metadata: RegistryMetadata{
-				GeneratedAt:   time.Now().UTC(),
+				GeneratedAt:   clock.Now().UTC(),
 				SchemaVersion: "1.0.0",
 			},
 		},
@@ -589,54 +641,142 @@ func NewDocumentationBuilder() *DocumentationBuilder {
 
 // SetProvider sets the provider metadata
 func (b *DocumentationBuilder) SetProvider(metadata ProviderMetadata) *DocumentationBuilder {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	b.docs.Provider = metadata
 	return b
 }
 
 // SetConfiguration sets the configuration documentation
 func (b *DocumentationBuilder) SetConfiguration(config ConfigurationDocumentation) *DocumentationBuilder {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	b.docs.Configuration = config
 	return b
 }
 
 // AddResource adds a resource to the documentation
 func (b *DocumentationBuilder) AddResource(name string, resource *ResourceDoc) *DocumentationBuilder {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	b.docs.Resources[name] = resource
 	return b
 }
 
 // AddExample adds a provider example
 func (b *DocumentationBuilder) AddExample(example *ProviderExample) *DocumentationBuilder {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	b.docs.Examples = append(b.docs.Examples, example)
 	return b
 }
 
 // SetGettingStarted sets the getting started guide
 func (b *DocumentationBuilder) SetGettingStarted(guide *GettingStartedGuide) *DocumentationBuilder {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	b.docs.GettingStarted = guide
 	return b
 }
 
 // SetCompatibility sets the compatibility information
 func (b *DocumentationBuilder) SetCompatibility(compat *CompatibilityInfo) *DocumentationBuilder {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	b.docs.Compatibility = compat
 	return b
 }
 
 // SetMetadata sets the registry metadata
 func (b *DocumentationBuilder) SetMetadata(metadata RegistryMetadata) *DocumentationBuilder {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	b.docs.Metadata = metadata
 	return b
 }
 
+// SetCapabilities sets the capabilities section
+func (b *DocumentationBuilder) SetCapabilities(capabilities *CapabilityDocs) *DocumentationBuilder {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.docs.Capabilities = capabilities
+	return b
+}
+
 // SetSearchMetadata sets the search metadata
 func (b *DocumentationBuilder) SetSearchMetadata(search *SearchMetadata) *DocumentationBuilder {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	b.docs.SearchMetadata = search
 	return b
 }
 
-// Build returns the complete documentation
+// Build returns a deep copy of the complete documentation, so callers may modify it
+// while other goroutines keep feeding the builder
 func (b *DocumentationBuilder) Build() *UniversalProviderDocumentation {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return deepCopyValue(reflect.ValueOf(b.build())).Interface().(*UniversalProviderDocumentation)
+}
+
+// deepCopyValue returns a copy of v sharing no pointers, maps or slices with it.
+// Unexported struct fields are copied shallowly.
+func deepCopyValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Elem().Type())
+		c.Elem().Set(deepCopyValue(v.Elem()))
+		return c
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(deepCopyValue(v.Elem()))
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			c.SetMapIndex(iter.Key(), deepCopyValue(iter.Value()))
+		}
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopyValue(v.Index(i)))
+		}
+		return c
+	case reflect.Array:
+		c := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopyValue(v.Index(i)))
+		}
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if c.Field(i).CanSet() {
+				c.Field(i).Set(deepCopyValue(v.Field(i)))
+			}
+		}
+		return c
+	default:
+		return v
+	}
+}
+
+// build updates stats and returns the documentation; callers must hold b.mu
+func (b *DocumentationBuilder) build() *UniversalProviderDocumentation {
 	// Update stats
 	if b.docs.Metadata.Stats == nil {
 		b.docs.Metadata.Stats = &DocumentationStats{}
@@ -651,16 +791,128 @@ func (b *DocumentationBuilder) Build() *UniversalProviderDocumentation {
 			b.docs.Metadata.Stats.ExampleCount += len(resource.Examples)
 		}
 	}
+	b.updateChangelogs()
 
 	return b.docs
 }
 
 // ToJSON converts the documentation to JSON
 func (b *DocumentationBuilder) ToJSON() ([]byte, error) {
-	return json.MarshalIndent(b.Build(), "", "  ")
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return json.MarshalIndent(b.build(), "", "  ")
 }
 
 // FromJSON loads documentation from JSON
 func (b *DocumentationBuilder) FromJSON(data []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	return json.Unmarshal(data, b.docs)
 }
+
+// DocumentationMergeError lists the conflicts that prevented a merge
+type DocumentationMergeError struct {
+	Conflicts []string
+}
+
+// Error implements the error interface
+func (e *DocumentationMergeError) Error() string {
+	return fmt.Sprintf("documentation merge conflict: %s", strings.Join(e.Conflicts, "; "))
+}
+
+// Merge combines documentation produced by another provider binary (for example a
+// separate discover binary) into this builder. Resources defined by both sides must be
+// identical, and provider identity must match when both sides set it. On conflict
+// nothing is merged and a *DocumentationMergeError is returned.
+func (b *DocumentationBuilder) Merge(other *UniversalProviderDocumentation) error {
+	if other == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var conflicts []string
+	mine, theirs := b.docs.Provider, other.Provider
+	if mine.Namespace != "" && theirs.Namespace != "" && mine.Namespace != theirs.Namespace {
+		conflicts = append(conflicts, fmt.Sprintf("provider namespace %q != %q", mine.Namespace, theirs.Namespace))
+	}
+	if mine.Name != "" && theirs.Name != "" && mine.Name != theirs.Name {
+		conflicts = append(conflicts, fmt.Sprintf("provider name %q != %q", mine.Name, theirs.Name))
+	}
+	if mine.Version != "" && theirs.Version != "" && mine.Version != theirs.Version {
+		conflicts = append(conflicts, fmt.Sprintf("provider version %q != %q", mine.Version, theirs.Version))
+	}
+
+	names := make([]string, 0, len(other.Resources))
+	for name := range other.Resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		existing, exists := b.docs.Resources[name]
+		if exists && string(mustMarshal(existing)) != string(mustMarshal(other.Resources[name])) {
+			conflicts = append(conflicts, fmt.Sprintf("resource %q is defined differently by both sides", name))
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return &DocumentationMergeError{Conflicts: conflicts}
+	}
+
+	if b.docs.Provider.Name == "" {
+		b.docs.Provider = other.Provider
+	}
+	if len(b.docs.Configuration.Schema) == 0 && len(b.docs.Configuration.Examples) == 0 {
+		b.docs.Configuration = other.Configuration
+	}
+	for _, name := range names {
+		b.docs.Resources[name] = other.Resources[name]
+	}
+
+	seen := make(map[string]bool, len(b.docs.Examples))
+	for _, example := range b.docs.Examples {
+		if example != nil {
+			seen[example.Name] = true
+		}
+	}
+	for _, example := range other.Examples {
+		if example != nil && !seen[example.Name] {
+			b.docs.Examples = append(b.docs.Examples, example)
+			seen[example.Name] = true
+		}
+	}
+
+	if b.docs.GettingStarted == nil {
+		b.docs.GettingStarted = other.GettingStarted
+	}
+	if b.docs.Compatibility == nil {
+		b.docs.Compatibility = other.Compatibility
+	}
+	if b.docs.SearchMetadata == nil {
+		b.docs.SearchMetadata = other.SearchMetadata
+	} else if other.SearchMetadata != nil {
+		b.docs.SearchMetadata.Keywords = mergeKeywords(b.docs.SearchMetadata.Keywords, other.SearchMetadata.Keywords)
+	}
+	if b.docs.Capabilities == nil {
+		b.docs.Capabilities = other.Capabilities
+	} else {
+		b.docs.Capabilities.Merge(other.Capabilities)
+	}
+
+	return nil
+}
+
+// mergeKeywords returns the union of two keyword lists preserving order
+func mergeKeywords(a, b []string) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	out := make([]string, 0, len(a)+len(b))
+	for _, k := range append(append([]string{}, a...), b...) {
+		if !seen[k] {
+			seen[k] = true
+			out = append(out, k)
+		}
+	}
+	return out
+}
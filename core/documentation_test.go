@@ -0,0 +1,116 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// TestDocumentationBuilderMerge validates resources and examples from split binaries are combined
+func TestDocumentationBuilderMerge(t *testing.T) {
+	b := NewDocumentationBuilder().
+		SetProvider(ProviderMetadata{Namespace: "kolumn", Name: "postgres", Version: "1.0.0"}).
+		AddResource("postgres_table", &ResourceDoc{Type: "create", Operations: []string{"create"}}).
+		AddExample(&ProviderExample{Name: "basic"})
+
+	other := &UniversalProviderDocumentation{
+		Provider: ProviderMetadata{Namespace: "kolumn", Name: "postgres", Version: "1.0.0"},
+		Resources: map[string]*ResourceDoc{
+			"postgres_table":          {Type: "create", Operations: []string{"create"}},
+			"postgres_existing_table": {Type: "discover", Operations: []string{"scan"}},
+		},
+		Examples: []*ProviderExample{{Name: "basic"}, {Name: "discovery"}},
+	}
+
+	if err := b.Merge(other); err != nil {
+		t.Fatalf("Expected merge to succeed, got %v", err)
+	}
+
+	docs := b.Build()
+	if len(docs.Resources) != 2 {
+		t.Errorf("Expected 2 resources, got %d", len(docs.Resources))
+	}
+	if len(docs.Examples) != 2 {
+		t.Errorf("Expected duplicate examples to be skipped, got %d", len(docs.Examples))
+	}
+}
+
+// TestDocumentationBuilderMergeConflict validates conflicting resources abort the merge
+func TestDocumentationBuilderMergeConflict(t *testing.T) {
+	b := NewDocumentationBuilder().
+		AddResource("postgres_table", &ResourceDoc{Type: "create", Description: "Tables"})
+
+	other := &UniversalProviderDocumentation{
+		Resources: map[string]*ResourceDoc{
+			"postgres_table": {Type: "create", Description: "Something else"},
+			"postgres_view":  {Type: "create"},
+		},
+	}
+
+	err := b.Merge(other)
+	var mergeErr *DocumentationMergeError
+	if !errors.As(err, &mergeErr) || len(mergeErr.Conflicts) != 1 {
+		t.Fatalf("Expected a single merge conflict, got %v", err)
+	}
+	if _, exists := b.Build().Resources["postgres_view"]; exists {
+		t.Errorf("Expected no partial merge on conflict")
+	}
+}
+
+// TestDocumentationBuilderConcurrentMerge validates concurrent merges are safe
+func TestDocumentationBuilderConcurrentMerge(t *testing.T) {
+	b := NewDocumentationBuilder()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("resource_%d", i)
+			_ = b.Merge(&UniversalProviderDocumentation{
+				Resources: map[string]*ResourceDoc{name: {Type: "create"}},
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	if got := len(b.Build().Resources); got != 20 {
+		t.Errorf("Expected 20 resources, got %d", got)
+	}
+}
+
+// TestDocumentationBuilderBuildCopies validates Build results do not alias builder state
+func TestDocumentationBuilderBuildCopies(t *testing.T) {
+	b := NewDocumentationBuilder().
+		AddResource("postgres_table", &ResourceDoc{Type: "create", Operations: []string{"create"}}).
+		AddExample(&ProviderExample{Name: "basic", ExpectedOutputs: map[string]interface{}{"port": 5432}})
+
+	docs := b.Build()
+	docs.Resources["postgres_table"].Operations[0] = "delete"
+	docs.Resources["postgres_view"] = &ResourceDoc{Type: "create"}
+	docs.Examples[0].Name = "changed"
+
+	again := b.Build()
+	if again.Resources["postgres_table"].Operations[0] != "create" || len(again.Resources) != 1 {
+		t.Errorf("Expected builder resources to be unaffected, got %+v", again.Resources)
+	}
+	if again.Examples[0].Name != "basic" || again.Examples[0].ExpectedOutputs["port"] != 5432 {
+		t.Errorf("Expected builder examples to be unaffected, got %+v", again.Examples[0])
+	}
+}
+
+// TestDocumentationBuilderMergeNilExample validates nil examples are skipped rather than dereferenced
+func TestDocumentationBuilderMergeNilExample(t *testing.T) {
+	b := NewDocumentationBuilder().AddExample(nil).AddExample(&ProviderExample{Name: "basic"})
+
+	err := b.Merge(&UniversalProviderDocumentation{
+		Examples: []*ProviderExample{nil, {Name: "basic"}, {Name: "discovery"}},
+	})
+	if err != nil {
+		t.Fatalf("Expected merge to succeed, got %v", err)
+	}
+	if got := len(b.Build().Examples); got != 3 {
+		t.Errorf("Expected the nil and two named examples, got %d", got)
+	}
+}
@@ -0,0 +1,177 @@
+// Package core provides precedence-based merging of encryption configuration
+package core
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Encryption configuration sources, from highest to lowest precedence:
+//
+//  1. request: SecurityRequirements.EncryptionConfig of the request context
+//  2. classification: ProviderEnforcementRules.EncryptionConfig of each applied
+//     classification for this provider; between classifications the stricter level
+//     wins (secret > restricted > confidential > internal > public), then the name
+//     that sorts first
+//  3. provider default: defaults registered with GovernanceHelper.SetEncryptionDefaults
+//
+// Encryption is required when the request or any classification requires it; a
+// request can add or change settings but cannot turn required encryption off.
+const (
+	EncryptionSourceRequest         = "request"
+	EncryptionSourceClassification  = "classification"
+	EncryptionSourceProviderDefault = "provider_default"
+)
+
+// DiagnosticCodeEncryptionConflict is the diagnostic code for conflicting encryption settings
+const DiagnosticCodeEncryptionConflict = "ENCRYPTION_CONFIG_CONFLICT"
+
+// EncryptionOverride records a setting that replaced a lower-precedence value
+type EncryptionOverride struct {
+	Key              string `json:"key"`
+	Value            string `json:"value"`
+	Source           string `json:"source"`
+	OverriddenValue  string `json:"overridden_value"`
+	OverriddenSource string `json:"overridden_source"`
+}
+
+// EncryptionMergeResult is the merged encryption configuration for a resource
+type EncryptionMergeResult struct {
+	Required    bool                 `json:"required"`
+	Config      map[string]string    `json:"config"`
+	Sources     map[string]string    `json:"sources"` // key -> source that set it
+	Overrides   []EncryptionOverride `json:"overrides,omitempty"`
+	Diagnostics []Diagnostic         `json:"diagnostics,omitempty"`
+}
+
+// classificationRank orders classification levels by strictness
+func classificationRank(level string) int {
+	switch level {
+	case "secret", "top_secret":
+		return 5
+	case "restricted":
+		return 4
+	case "confidential":
+		return 3
+	case "internal":
+		return 2
+	case "public":
+		return 1
+	}
+	return 0
+}
+
+// classificationSource names the classification that contributed a setting
+func classificationSource(name string) string {
+	return EncryptionSourceClassification + ":" + name
+}
+
+// MergeEncryptionConfig merges provider defaults, classification enforcement and
+// request-level encryption settings in documented precedence order
+func MergeEncryptionConfig(governanceCtx *GovernanceContext, providerType string, classifications []string, providerDefaults map[string]string) *EncryptionMergeResult {
+	result := &EncryptionMergeResult{
+		Config:  make(map[string]string),
+		Sources: make(map[string]string),
+	}
+	set := func(key, value, source string) {
+		if previous, ok := result.Config[key]; ok && previous != value {
+			result.Overrides = append(result.Overrides, EncryptionOverride{
+				Key: key, Value: value, Source: source,
+				OverriddenValue: previous, OverriddenSource: result.Sources[key],
+			})
+		}
+		result.Config[key] = value
+		result.Sources[key] = source
+	}
+
+	for _, key := range sortedStringKeys(providerDefaults) {
+		set(key, providerDefaults[key], EncryptionSourceProviderDefault)
+	}
+
+	required, config, sources, conflicts := classificationEncryption(governanceCtx, providerType, classifications)
+	result.Required = required
+	for _, key := range sortedStringKeys(config) {
+		set(key, config[key], sources[key])
+	}
+	result.Diagnostics = append(result.Diagnostics, conflicts...)
+
+	if governanceCtx != nil && governanceCtx.RequestContext != nil {
+		if sr := governanceCtx.RequestContext.SecurityRequirements; sr != nil {
+			result.Required = result.Required || sr.EncryptionRequired
+			for _, key := range sortedStringKeys(sr.EncryptionConfig) {
+				value := sr.EncryptionConfig[key]
+				if previous, ok := config[key]; ok && previous != value {
+					result.Diagnostics = append(result.Diagnostics, Diagnostic{
+						Severity: "warning",
+						Code:     DiagnosticCodeEncryptionConflict,
+						Summary:  fmt.Sprintf("request overrides classification encryption setting %q", key),
+						Detail:   fmt.Sprintf("%s requires %q; the request sets %q", sources[key], previous, value),
+					})
+				}
+				set(key, value, EncryptionSourceRequest)
+			}
+		}
+	}
+	return result
+}
+
+// classificationEncryption merges the encryption settings of applied classifications.
+// Settings from a stricter classification win; a disagreement is reported as a
+// warning diagnostic.
+func classificationEncryption(governanceCtx *GovernanceContext, providerType string, classifications []string) (bool, map[string]string, map[string]string, []Diagnostic) {
+	config := make(map[string]string)
+	sources := make(map[string]string)
+	if governanceCtx == nil {
+		return false, config, sources, nil
+	}
+
+	type contribution struct {
+		name  string
+		rank  int
+		rules *ProviderEnforcementRules
+	}
+	var contributions []contribution
+	for _, name := range uniqueSorted(classifications) {
+		classCtx, ok := governanceCtx.Classifications[name]
+		if !ok || classCtx == nil {
+			continue
+		}
+		rules, ok := classCtx.ProviderEnforcement[providerType]
+		if !ok || rules == nil || !rules.EncryptionRequired {
+			continue
+		}
+		contributions = append(contributions, contribution{name: name, rank: classificationRank(classCtx.Level), rules: rules})
+	}
+	sort.SliceStable(contributions, func(i, j int) bool { return contributions[i].rank > contributions[j].rank })
+
+	var diagnostics []Diagnostic
+	for _, c := range contributions {
+		for _, key := range sortedStringKeys(c.rules.EncryptionConfig) {
+			value := c.rules.EncryptionConfig[key]
+			existing, ok := config[key]
+			if !ok {
+				config[key] = value
+				sources[key] = classificationSource(c.name)
+				continue
+			}
+			if existing != value {
+				diagnostics = append(diagnostics, Diagnostic{
+					Severity: "warning",
+					Code:     DiagnosticCodeEncryptionConflict,
+					Summary:  fmt.Sprintf("classifications disagree on encryption setting %q", key),
+					Detail:   fmt.Sprintf("using %q from %s over %q from %s", existing, sources[key], value, classificationSource(c.name)),
+				})
+			}
+		}
+	}
+	return len(contributions) > 0, config, sources, diagnostics
+}
+
+func sortedStringKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,87 @@
+package core
+
+import (
+	"context"
+	"testing"
+)
+
+func encryptionGovernanceContext() *GovernanceContext {
+	return &GovernanceContext{
+		Classifications: map[string]*ClassificationContext{
+			"pii": {Name: "pii", Level: "confidential", ProviderEnforcement: map[string]*ProviderEnforcementRules{
+				"postgres": {EncryptionRequired: true, EncryptionConfig: map[string]string{"algorithm": "aes-128", "key_rotation": "90d"}},
+			}},
+			"pci": {Name: "pci", Level: "restricted", ProviderEnforcement: map[string]*ProviderEnforcementRules{
+				"postgres": {EncryptionRequired: true, EncryptionConfig: map[string]string{"algorithm": "aes-256"}},
+			}},
+		},
+	}
+}
+
+// TestMergeEncryptionConfigPrecedence validates request > classification > provider default
+func TestMergeEncryptionConfigPrecedence(t *testing.T) {
+	gc := encryptionGovernanceContext()
+	gc.RequestContext = &RequestGovernanceContext{SecurityRequirements: &SecurityRequirements{
+		EncryptionConfig: map[string]string{"key_rotation": "30d", "key_id": "kms-123"},
+	}}
+	defaults := map[string]string{"algorithm": "aes-128", "mode": "gcm", "key_id": "default"}
+
+	result := MergeEncryptionConfig(gc, "postgres", []string{"pii", "pci"}, defaults)
+	if !result.Required {
+		t.Errorf("expected classifications to require encryption")
+	}
+
+	expected := map[string][2]string{
+		"algorithm":    {"aes-256", "classification:pci"}, // stricter classification wins
+		"key_rotation": {"30d", EncryptionSourceRequest},
+		"key_id":       {"kms-123", EncryptionSourceRequest},
+		"mode":         {"gcm", EncryptionSourceProviderDefault},
+	}
+	for key, want := range expected {
+		if result.Config[key] != want[0] || result.Sources[key] != want[1] {
+			t.Errorf("%s: expected %s from %s, got %s from %s", key, want[0], want[1], result.Config[key], result.Sources[key])
+		}
+	}
+
+	// pci vs pii on algorithm, and request vs pii on key_rotation
+	if len(result.Diagnostics) != 2 {
+		t.Fatalf("expected 2 conflict diagnostics, got %+v", result.Diagnostics)
+	}
+	for _, d := range result.Diagnostics {
+		if d.Code != DiagnosticCodeEncryptionConflict || d.Severity != "warning" {
+			t.Errorf("unexpected diagnostic %+v", d)
+		}
+	}
+}
+
+// TestMergeEncryptionConfigRequestCannotDisable validates that requests only add requirements
+func TestMergeEncryptionConfigRequestCannotDisable(t *testing.T) {
+	gc := encryptionGovernanceContext()
+	gc.RequestContext = &RequestGovernanceContext{SecurityRequirements: &SecurityRequirements{EncryptionRequired: false}}
+
+	if !MergeEncryptionConfig(gc, "postgres", []string{"pii"}, nil).Required {
+		t.Errorf("expected classification requirement to hold")
+	}
+	if MergeEncryptionConfig(gc, "kafka", []string{"pii"}, nil).Required {
+		t.Errorf("expected no requirement for a provider without enforcement")
+	}
+}
+
+// TestExtractGovernanceRequirementsEncryption validates the merge is used for requirements
+func TestExtractGovernanceRequirementsEncryption(t *testing.T) {
+	helper := NewGovernanceHelper("postgres", nil)
+	helper.SetEncryptionDefaults(map[string]string{"mode": "gcm"})
+
+	requirements, err := helper.ExtractGovernanceRequirements(context.Background(), "table", map[string]interface{}{
+		"classifications": []interface{}{"pii", "pci"},
+	}, encryptionGovernanceContext())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !requirements.EncryptionRequired || requirements.EncryptionConfig["algorithm"] != "aes-256" || requirements.EncryptionConfig["mode"] != "gcm" {
+		t.Errorf("unexpected requirements %+v", requirements.EncryptionConfig)
+	}
+	if len(requirements.EncryptionDiagnostics) != 1 {
+		t.Errorf("expected classification conflict diagnostic, got %+v", requirements.EncryptionDiagnostics)
+	}
+}
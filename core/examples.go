@@ -0,0 +1,313 @@
+// Package core provides schema-driven example generation for provider documentation
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// exampleStringHints maps common property names to plausible example values
+var exampleStringHints = map[string]string{
+	"host":        "localhost",
+	"hostname":    "localhost",
+	"endpoint":    "https://api.example.com",
+	"url":         "https://example.com",
+	"email":       "admin@example.com",
+	"username":    "admin",
+	"user":        "admin",
+	"password":    "changeme",
+	"database":    "app",
+	"schema":      "public",
+	"region":      "us-east-1",
+	"description": "Managed by Kolumn",
+	"comment":     "Managed by Kolumn",
+}
+
+// exampleNumberHints maps common numeric property names to plausible example values
+var exampleNumberHints = map[string]float64{
+	"port":    5432,
+	"timeout": 30,
+	"ttl":     3600,
+}
+
+// examplePropertySchema is the subset of JSON Schema used to derive example values
+type examplePropertySchema struct {
+	Type       interface{}                       `json:"type"`
+	Default    interface{}                       `json:"default"`
+	Enum       []interface{}                     `json:"enum"`
+	Examples   []interface{}                     `json:"examples"`
+	Example    interface{}                       `json:"example"`
+	Minimum    *float64                          `json:"minimum"`
+	Properties map[string]*examplePropertySchema `json:"properties"`
+	Required   []string                          `json:"required"`
+	Items      *examplePropertySchema            `json:"items"`
+}
+
+// ExampleValuesFromSchema derives plausible values for every required property of a
+// JSON Schema. Values come from examples, enums or defaults when the schema provides
+// them, otherwise from the property type and name.
+func ExampleValuesFromSchema(schema json.RawMessage) map[string]interface{} {
+	values := make(map[string]interface{})
+	if len(schema) == 0 {
+		return values
+	}
+
+	var s examplePropertySchema
+	if err := json.Unmarshal(schema, &s); err != nil {
+		return values
+	}
+
+	for _, name := range s.Required {
+		prop := s.Properties[name]
+		if prop == nil {
+			prop = &examplePropertySchema{Type: "string"}
+		}
+		values[name] = exampleValueForSchema(name, prop)
+	}
+	return values
+}
+
+// exampleValueForSchema picks a value for a single JSON Schema property
+func exampleValueForSchema(name string, prop *examplePropertySchema) interface{} {
+	switch {
+	case len(prop.Examples) > 0:
+		return prop.Examples[0]
+	case prop.Example != nil:
+		return prop.Example
+	case len(prop.Enum) > 0:
+		return prop.Enum[0]
+	case prop.Default != nil:
+		return prop.Default
+	}
+
+	propType, _ := prop.Type.(string)
+	if types, ok := prop.Type.([]interface{}); ok && len(types) > 0 {
+		propType, _ = types[0].(string)
+	}
+
+	switch propType {
+	case "object":
+		nested := make(map[string]interface{})
+		for _, field := range prop.Required {
+			child := prop.Properties[field]
+			if child == nil {
+				child = &examplePropertySchema{Type: "string"}
+			}
+			nested[field] = exampleValueForSchema(field, child)
+		}
+		return nested
+	case "array":
+		if prop.Items == nil {
+			return []interface{}{}
+		}
+		return []interface{}{exampleValueForSchema(singularName(name), prop.Items)}
+	default:
+		return exampleScalarValue(name, propType, prop.Minimum)
+	}
+}
+
+// ExampleValuesFromObjectType derives plausible values for the required properties of a
+// legacy ObjectType using property examples, enum validation and defaults.
+func ExampleValuesFromObjectType(obj *ObjectType) map[string]interface{} {
+	values := make(map[string]interface{})
+	if obj == nil {
+		return values
+	}
+
+	for _, name := range obj.Required {
+		prop := obj.Properties[name]
+		if prop == nil {
+			values[name] = exampleScalarValue(name, "string", nil)
+			continue
+		}
+
+		switch {
+		case len(prop.Examples) > 0:
+			values[name] = coerceExampleString(prop.Examples[0], prop.Type)
+		case prop.Validation != nil && len(prop.Validation.Enum) > 0:
+			values[name] = prop.Validation.Enum[0]
+		case prop.Validation != nil && prop.Validation.Example != "":
+			values[name] = coerceExampleString(prop.Validation.Example, prop.Type)
+		case prop.Default != nil:
+			values[name] = prop.Default
+		default:
+			var minimum *float64
+			if prop.Validation != nil {
+				minimum = prop.Validation.Minimum
+			}
+			values[name] = exampleScalarValue(name, prop.Type, minimum)
+		}
+	}
+	return values
+}
+
+// exampleScalarValue returns a type-appropriate value, preferring name-based hints
+func exampleScalarValue(name, propType string, minimum *float64) interface{} {
+	key := strings.ToLower(name)
+	switch propType {
+	case "integer", "number":
+		if v, ok := exampleNumberHints[key]; ok {
+			return v
+		}
+		if minimum != nil {
+			return *minimum
+		}
+		return float64(1)
+	case "boolean", "bool":
+		return true
+	case "array", "list":
+		return []interface{}{}
+	case "object", "map":
+		return map[string]interface{}{}
+	default:
+		if v, ok := exampleStringHints[key]; ok {
+			return v
+		}
+		return "example-" + strings.ReplaceAll(key, "_", "-")
+	}
+}
+
+// coerceExampleString converts a string example into the property's declared type
+func coerceExampleString(example, propType string) interface{} {
+	switch propType {
+	case "integer", "number":
+		if f, err := strconv.ParseFloat(example, 64); err == nil {
+			return f
+		}
+	case "boolean", "bool":
+		if b, err := strconv.ParseBool(example); err == nil {
+			return b
+		}
+	}
+	return example
+}
+
+// singularName strips a trailing "s" so list items get sensible hint lookups
+func singularName(name string) string {
+	if len(name) > 1 && strings.HasSuffix(name, "s") {
+		return strings.TrimSuffix(name, "s")
+	}
+	return name
+}
+
+// RenderExampleHCL renders a Kolumn block with attributes sorted and aligned the
+// way `kolumn fmt` would print them.
+func RenderExampleHCL(blockType, resourceType, name string, values map[string]interface{}) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %q %q {\n", blockType, resourceType, name)
+	writeExampleBody(&b, values, 1)
+	b.WriteString("}")
+	return b.String()
+}
+
+// writeExampleBody writes sorted, aligned attributes at the given indentation depth
+func writeExampleBody(b *strings.Builder, values map[string]interface{}, depth int) {
+	keys := make([]string, 0, len(values))
+	width := 0
+	for k := range values {
+		keys = append(keys, k)
+		if len(k) > width {
+			width = len(k)
+		}
+	}
+	sort.Strings(keys)
+
+	indent := strings.Repeat("  ", depth)
+	for _, k := range keys {
+		fmt.Fprintf(b, "%s%-*s = ", indent, width, k)
+		writeExampleValue(b, values[k], depth)
+		b.WriteString("\n")
+	}
+}
+
+// writeExampleValue writes a single HCL value literal
+func writeExampleValue(b *strings.Builder, value interface{}, depth int) {
+	switch v := value.(type) {
+	case nil:
+		b.WriteString("null")
+	case string:
+		b.WriteString(strconv.Quote(v))
+	case bool:
+		b.WriteString(strconv.FormatBool(v))
+	case float64:
+		b.WriteString(strconv.FormatFloat(v, 'f', -1, 64))
+	case int, int32, int64:
+		fmt.Fprintf(b, "%d", v)
+	case []interface{}:
+		b.WriteString("[")
+		for i, item := range v {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			writeExampleValue(b, item, depth)
+		}
+		b.WriteString("]")
+	case map[string]interface{}:
+		if len(v) == 0 {
+			b.WriteString("{}")
+			return
+		}
+		b.WriteString("{\n")
+		writeExampleBody(b, v, depth+1)
+		b.WriteString(strings.Repeat("  ", depth) + "}")
+	default:
+		b.WriteString(strconv.Quote(fmt.Sprint(v)))
+	}
+}
+
+// GenerateResourceExample builds a runnable "basic" example for a resource type whose
+// required attributes are filled from its configuration JSON Schema. Schemas without
+// properties fall back to a name-only example.
+func GenerateResourceExample(blockType, resourceType string, configSchema json.RawMessage) *ResourceExample {
+	values := ExampleValuesFromSchema(configSchema)
+	if len(values) == 0 && !schemaHasProperties(configSchema) {
+		values["name"] = "example-" + strings.ReplaceAll(resourceType, "_", "-")
+	}
+
+	return &ResourceExample{
+		Name:        "basic",
+		Title:       fmt.Sprintf("Basic %s", resourceType),
+		Description: fmt.Sprintf("Minimal %s configuration with all required attributes", resourceType),
+		Category:    "basic",
+		UseCase:     fmt.Sprintf("Create a simple %s", resourceType),
+		HCL:         RenderExampleHCL(blockType, resourceType, "example", values),
+		Operation:   exampleOperation(blockType),
+	}
+}
+
+// GenerateObjectTypeExample mirrors GenerateResourceExample for legacy ObjectType schemas
+func GenerateObjectTypeExample(blockType, objectType string, obj *ObjectType) *ResourceExample {
+	values := ExampleValuesFromObjectType(obj)
+	if len(values) == 0 && (obj == nil || len(obj.Properties) == 0) {
+		values["name"] = "example-" + strings.ReplaceAll(objectType, "_", "-")
+	}
+
+	return &ResourceExample{
+		Name:        "basic",
+		Title:       fmt.Sprintf("Basic %s", objectType),
+		Description: fmt.Sprintf("Minimal %s configuration with all required attributes", objectType),
+		Category:    "basic",
+		UseCase:     fmt.Sprintf("Create a simple %s", objectType),
+		HCL:         RenderExampleHCL(blockType, objectType, "example", values),
+		Operation:   exampleOperation(blockType),
+	}
+}
+
+// exampleOperation is the operation a basic example of the block type demonstrates
+func exampleOperation(blockType string) string {
+	if blockType == "discover" {
+		return CapabilityDiscover
+	}
+	return CapabilityCreate
+}
+
+// schemaHasProperties reports whether a JSON Schema declares any properties
+func schemaHasProperties(schema json.RawMessage) bool {
+	var s struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+	}
+	return len(schema) > 0 && json.Unmarshal(schema, &s) == nil && len(s.Properties) > 0
+}
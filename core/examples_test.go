@@ -0,0 +1,87 @@
+package core
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestExampleValuesFromSchema validates required attributes are filled from schema hints
+func TestExampleValuesFromSchema(t *testing.T) {
+	schema := json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"name":     {"type": "string"},
+			"port":     {"type": "integer"},
+			"mode":     {"type": "string", "enum": ["read", "write"]},
+			"replicas": {"type": "integer", "minimum": 3},
+			"owner":    {"type": "string", "examples": ["analytics"]},
+			"optional": {"type": "string"}
+		},
+		"required": ["name", "port", "mode", "replicas", "owner"]
+	}`)
+
+	values := ExampleValuesFromSchema(schema)
+
+	expected := map[string]interface{}{
+		"name":     "example-name",
+		"port":     float64(5432),
+		"mode":     "read",
+		"replicas": float64(3),
+		"owner":    "analytics",
+	}
+	if len(values) != len(expected) {
+		t.Fatalf("Expected %d values, got %d: %v", len(expected), len(values), values)
+	}
+	for k, v := range expected {
+		if values[k] != v {
+			t.Errorf("Expected %s=%v, got %v", k, v, values[k])
+		}
+	}
+}
+
+// TestGenerateResourceExample validates rendered HCL is sorted and aligned
+func TestGenerateResourceExample(t *testing.T) {
+	schema := json.RawMessage(`{
+		"properties": {
+			"table":   {"type": "string"},
+			"enabled": {"type": "boolean"},
+			"columns": {"type": "array", "items": {"type": "object", "properties": {"name": {"type": "string"}}, "required": ["name"]}}
+		},
+		"required": ["table", "enabled", "columns"]
+	}`)
+
+	example := GenerateResourceExample("create", "postgres_table", schema)
+
+	want := `create "postgres_table" "example" {
+  columns = [{
+    name = "example-name"
+  }]
+  enabled = true
+  table   = "example-table"
+}`
+	if example.HCL != want {
+		t.Errorf("Unexpected HCL:\n%s\nwant:\n%s", example.HCL, want)
+	}
+}
+
+// TestGenerateObjectTypeExample validates legacy ObjectType schemas are supported
+func TestGenerateObjectTypeExample(t *testing.T) {
+	obj := &ObjectType{
+		Properties: map[string]*Property{
+			"host": {Type: "string"},
+			"size": {Type: "integer", Examples: []string{"10"}},
+		},
+		Required: []string{"host", "size"},
+	}
+
+	example := GenerateObjectTypeExample("create", "bucket", obj)
+	if !strings.Contains(example.HCL, `host = "localhost"`) || !strings.Contains(example.HCL, "size = 10") {
+		t.Errorf("Unexpected HCL:\n%s", example.HCL)
+	}
+
+	empty := GenerateObjectTypeExample("discover", "existing_bucket", nil)
+	if !strings.Contains(empty.HCL, `name = "example-existing-bucket"`) {
+		t.Errorf("Expected name fallback, got:\n%s", empty.HCL)
+	}
+}
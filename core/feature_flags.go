@@ -0,0 +1,245 @@
+// Package core provides feature flags gating experimental resource types and functions
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// FeatureFlagsConfigKey is the provider config key listing enabled experiments, e.g.
+// experiments = ["iceberg_tables"] or experiments = { iceberg_tables = true }
+const FeatureFlagsConfigKey = "experiments"
+
+// FeatureFlagsEnvVar enables experiments from the environment: a comma separated list
+// of flag names, or "*" for every flag (used by docs generation)
+const FeatureFlagsEnvVar = "KOLUMN_EXPERIMENTS"
+
+// DiagnosticCodeFeatureNotEnabled is the diagnostic code for calls gated by a disabled flag
+const DiagnosticCodeFeatureNotEnabled = "FEATURE_NOT_ENABLED"
+
+// FeatureFlag gates experimental resource types and functions. Gated items are hidden
+// from the schema and rejected by the dispatcher until the flag is enabled.
+type FeatureFlag struct {
+	Name          string   `json:"name"`
+	Description   string   `json:"description,omitempty"`
+	ResourceTypes []string `json:"resource_types,omitempty"`
+	Functions     []string `json:"functions,omitempty"`
+	Enabled       bool     `json:"enabled"`
+}
+
+// FeatureNotEnabledError is returned when a call uses an item behind a disabled flag
+type FeatureNotEnabledError struct {
+	Diagnostic Diagnostic `json:"diagnostic"`
+	Flag       string     `json:"flag"`
+	Item       string     `json:"item"`
+}
+
+// Error implements the error interface
+func (e *FeatureNotEnabledError) Error() string {
+	return e.Diagnostic.Summary + ": " + e.Diagnostic.Detail
+}
+
+// FeatureFlags holds a provider's flags and which of them are enabled
+type FeatureFlags struct {
+	mu    sync.RWMutex
+	flags map[string]*FeatureFlag
+}
+
+// NewFeatureFlags registers flags, enabling any named in KOLUMN_EXPERIMENTS
+func NewFeatureFlags(flags ...FeatureFlag) *FeatureFlags {
+	f := &FeatureFlags{flags: make(map[string]*FeatureFlag, len(flags))}
+	for _, flag := range flags {
+		flag := flag
+		f.flags[flag.Name] = &flag
+	}
+	for _, name := range strings.Split(os.Getenv(FeatureFlagsEnvVar), ",") {
+		switch name = strings.TrimSpace(name); name {
+		case "":
+		case "*":
+			for _, flag := range f.flags {
+				flag.Enabled = true
+			}
+		default:
+			if flag, ok := f.flags[name]; ok {
+				flag.Enabled = true
+			}
+		}
+	}
+	return f
+}
+
+// SetEnabled turns a flag on or off
+func (f *FeatureFlags) SetEnabled(name string, enabled bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	flag, ok := f.flags[name]
+	if !ok {
+		return fmt.Errorf("unknown feature flag %q", name)
+	}
+	flag.Enabled = enabled
+	return nil
+}
+
+// Enabled reports whether a flag is enabled
+func (f *FeatureFlags) Enabled(name string) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	flag, ok := f.flags[name]
+	return ok && flag.Enabled
+}
+
+// Configure enables the flags listed under the "experiments" key of a provider config.
+// Unknown flag names are an error so typos do not silently leave a feature off.
+func (f *FeatureFlags) Configure(config map[string]interface{}) error {
+	settings := map[string]bool{}
+	switch v := config[FeatureFlagsConfigKey].(type) {
+	case nil:
+		return nil
+	case []string:
+		for _, name := range v {
+			settings[name] = true
+		}
+	case []interface{}:
+		for _, item := range v {
+			name, ok := item.(string)
+			if !ok {
+				return fmt.Errorf("%s must list flag names, got %T", FeatureFlagsConfigKey, item)
+			}
+			settings[name] = true
+		}
+	case map[string]interface{}:
+		for name, item := range v {
+			enabled, ok := item.(bool)
+			if !ok {
+				return fmt.Errorf("%s.%s must be a boolean, got %T", FeatureFlagsConfigKey, name, item)
+			}
+			settings[name] = enabled
+		}
+	default:
+		return fmt.Errorf("%s must be a list or map of flag names, got %T", FeatureFlagsConfigKey, v)
+	}
+
+	for _, name := range sortedBoolKeys(settings) {
+		if err := f.SetEnabled(name, settings[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flags returns every flag sorted by name
+func (f *FeatureFlags) Flags() []FeatureFlag {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	flags := make([]FeatureFlag, 0, len(f.flags))
+	for _, flag := range f.flags {
+		flags = append(flags, *flag)
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Name < flags[j].Name })
+	return flags
+}
+
+// FlagFor returns the flag gating a resource type or function
+func (f *FeatureFlags) FlagFor(item string) (FeatureFlag, bool) {
+	if f == nil {
+		return FeatureFlag{}, false
+	}
+	for _, flag := range f.Flags() {
+		if containsString(flag.ResourceTypes, item) || containsString(flag.Functions, item) {
+			return flag, true
+		}
+	}
+	return FeatureFlag{}, false
+}
+
+// Allows reports whether item is ungated or its flag is enabled
+func (f *FeatureFlags) Allows(item string) bool {
+	flag, gated := f.FlagFor(item)
+	return !gated || flag.Enabled
+}
+
+// Check returns a *FeatureNotEnabledError when item is behind a disabled flag
+func (f *FeatureFlags) Check(item string) error {
+	flag, gated := f.FlagFor(item)
+	if !gated || flag.Enabled {
+		return nil
+	}
+	return &FeatureNotEnabledError{
+		Diagnostic: Diagnostic{
+			Severity: "error",
+			Code:     DiagnosticCodeFeatureNotEnabled,
+			Summary:  "experimental feature not enabled",
+			Detail:   fmt.Sprintf("%s is experimental; add %q to the provider's %s to enable it", item, flag.Name, FeatureFlagsConfigKey),
+			Resource: item,
+		},
+		Flag: flag.Name,
+		Item: item,
+	}
+}
+
+// FilterSchema returns a copy of schema without the functions and resource types of
+// disabled flags. The copy lists every flag in FeatureFlags so tooling can mark gated
+// items as experimental.
+func (f *FeatureFlags) FilterSchema(schema *Schema) *Schema {
+	filtered := *schema
+	filtered.SupportedFunctions = make([]string, 0, len(schema.SupportedFunctions))
+	for _, fn := range schema.SupportedFunctions {
+		if f.Allows(fn) {
+			filtered.SupportedFunctions = append(filtered.SupportedFunctions, fn)
+		}
+	}
+	filtered.ResourceTypes = make([]ResourceTypeDefinition, 0, len(schema.ResourceTypes))
+	for _, rt := range schema.ResourceTypes {
+		if f.Allows(rt.Name) {
+			filtered.ResourceTypes = append(filtered.ResourceTypes, rt)
+		}
+	}
+	filtered.FeatureFlags = f.Flags()
+	return &filtered
+}
+
+// ExperimentalFlag returns the flag gating a resource type or function listed in the
+// schema's FeatureFlags
+func (s *Schema) ExperimentalFlag(item string) (string, bool) {
+	for _, flag := range s.FeatureFlags {
+		if containsString(flag.ResourceTypes, item) || containsString(flag.Functions, item) {
+			return flag.Name, true
+		}
+	}
+	return "", false
+}
+
+// SetFeatureFlags enables feature flag gating of dispatched functions and resource types
+func (d *UnifiedDispatcher) SetFeatureFlags(flags *FeatureFlags) {
+	d.featureFlags = flags
+}
+
+// checkFeatureFlags rejects functions and resource types behind a disabled flag
+func (d *UnifiedDispatcher) checkFeatureFlags(function string, input []byte) error {
+	if d.featureFlags == nil {
+		return nil
+	}
+	if err := d.featureFlags.Check(function); err != nil {
+		return err
+	}
+	var req struct {
+		ResourceType string `json:"resource_type"`
+	}
+	if json.Unmarshal(input, &req) == nil && req.ResourceType != "" {
+		return d.featureFlags.Check(req.ResourceType)
+	}
+	return nil
+}
+
+func sortedBoolKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,115 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func newTestFeatureFlags() *FeatureFlags {
+	return NewFeatureFlags(FeatureFlag{
+		Name:          "iceberg",
+		ResourceTypes: []string{"iceberg_table"},
+		Functions:     []string{"DiscoverDatabase"},
+	})
+}
+
+// TestFeatureFlagsFilterSchema validates gated items are hidden until their flag is enabled
+func TestFeatureFlagsFilterSchema(t *testing.T) {
+	t.Setenv(FeatureFlagsEnvVar, "")
+	flags := newTestFeatureFlags()
+	schema := &Schema{
+		SupportedFunctions: []string{"CreateResource", "DiscoverDatabase"},
+		ResourceTypes:      []ResourceTypeDefinition{{Name: "table"}, {Name: "iceberg_table"}},
+	}
+
+	filtered := flags.FilterSchema(schema)
+	if len(filtered.SupportedFunctions) != 1 || len(filtered.ResourceTypes) != 1 || filtered.ResourceTypes[0].Name != "table" {
+		t.Errorf("gated items should be hidden: %+v", filtered)
+	}
+	if len(schema.ResourceTypes) != 2 {
+		t.Error("FilterSchema modified its input")
+	}
+
+	if err := flags.Configure(map[string]interface{}{"experiments": []interface{}{"iceberg"}}); err != nil {
+		t.Fatalf("Configure: %v", err)
+	}
+	filtered = flags.FilterSchema(schema)
+	if len(filtered.ResourceTypes) != 2 || len(filtered.SupportedFunctions) != 2 {
+		t.Errorf("enabled flag should expose items: %+v", filtered)
+	}
+	if flag, ok := filtered.ExperimentalFlag("iceberg_table"); !ok || flag != "iceberg" {
+		t.Errorf("ExperimentalFlag = %q, %v", flag, ok)
+	}
+}
+
+// TestFeatureFlagsConfigure validates config and environment handling
+func TestFeatureFlagsConfigure(t *testing.T) {
+	t.Setenv(FeatureFlagsEnvVar, "")
+	flags := newTestFeatureFlags()
+	if err := flags.Configure(map[string]interface{}{"experiments": []interface{}{"icebreg"}}); err == nil {
+		t.Error("expected error for unknown flag")
+	}
+	if err := flags.Configure(map[string]interface{}{"experiments": map[string]interface{}{"iceberg": true}}); err != nil || !flags.Enabled("iceberg") {
+		t.Errorf("map form should enable the flag: %v", err)
+	}
+
+	t.Setenv(FeatureFlagsEnvVar, "*")
+	if !newTestFeatureFlags().Enabled("iceberg") {
+		t.Error("KOLUMN_EXPERIMENTS=* should enable every flag")
+	}
+}
+
+// TestDispatcherFeatureFlags validates dispatch rejects gated resource types
+func TestDispatcherFeatureFlags(t *testing.T) {
+	t.Setenv(FeatureFlagsEnvVar, "")
+	d := NewUnifiedDispatcher(nil, nil)
+	d.SetFeatureFlags(newTestFeatureFlags())
+
+	_, err := d.Dispatch(context.Background(), "CreateResource", []byte(`{"resource_type":"iceberg_table","name":"events"}`))
+	var notEnabled *FeatureNotEnabledError
+	if !errors.As(err, &notEnabled) || notEnabled.Flag != "iceberg" || notEnabled.Diagnostic.Code != DiagnosticCodeFeatureNotEnabled {
+		t.Fatalf("expected FeatureNotEnabledError, got %v", err)
+	}
+}
+
+// TestDispatcherFeatureFlagsMultiTypeDiscovery validates gated types are left out of
+// wildcard discovery and rejected when named among several types
+func TestDispatcherFeatureFlagsMultiTypeDiscovery(t *testing.T) {
+	t.Setenv(FeatureFlagsEnvVar, "")
+	registry := newFakeDiscoverRegistry()
+	registry.types["iceberg_table"] = &ObjectType{Name: "iceberg_table"}
+	d := NewUnifiedDispatcher(nil, registry)
+	flags := newTestFeatureFlags()
+	d.SetFeatureFlags(flags)
+
+	discover := func(input string) AggregatedDiscoverResponse {
+		t.Helper()
+		output, err := d.Dispatch(context.Background(), "DiscoverResources", []byte(input))
+		if err != nil {
+			t.Fatalf("%s: %v", input, err)
+		}
+		var resp AggregatedDiscoverResponse
+		if err := json.Unmarshal(output, &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	if resp := discover(`{"object_types":["*"]}`); resp.TypeSummaries["iceberg_table"] != nil || len(resp.TypeSummaries) != 3 {
+		t.Errorf("expected the wildcard to skip gated types, got %v", resp.Summary.ObjectTypes)
+	}
+	resp := discover(`{"object_types":["table","iceberg_table"]}`)
+	if summary := resp.TypeSummaries["iceberg_table"]; summary == nil || summary.Objects != 0 || !strings.Contains(summary.Error, "experimental") {
+		t.Errorf("expected the gated type to be reported, got %+v", summary)
+	}
+
+	if err := flags.SetEnabled("iceberg", true); err != nil {
+		t.Fatal(err)
+	}
+	if resp := discover(`{"object_types":["*"]}`); resp.Summary.ObjectTypes["iceberg_table"] != 2 {
+		t.Errorf("expected enabled experimental types to be discovered, got %v", resp.Summary.ObjectTypes)
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"time"
+
+	"github.com/schemabounce/kolumn/sdk/helpers/ids"
 )
 
 // =============================================================================
@@ -318,6 +320,8 @@ type GovernanceCapabilities struct {
 type GovernanceHelper struct {
 	providerType string
 	capabilities *GovernanceCapabilities
+	decisions    *GovernanceDecisionCache
+	encryption   map[string]string
 }
 
 // NewGovernanceHelper creates a new governance helper for a provider
@@ -325,9 +329,48 @@ func NewGovernanceHelper(providerType string, capabilities *GovernanceCapabiliti
 	return &GovernanceHelper{
 		providerType: providerType,
 		capabilities: capabilities,
+		decisions:    NewGovernanceDecisionCache(DefaultGovernanceDecisionTTL),
 	}
 }
 
+// SetDecisionCache replaces the helper's decision cache, e.g. with one shared by
+// several providers in the same process
+func (gh *GovernanceHelper) SetDecisionCache(cache *GovernanceDecisionCache) {
+	gh.decisions = cache
+}
+
+// DecisionCache returns the cache used to resolve classification decisions
+func (gh *GovernanceHelper) DecisionCache() *GovernanceDecisionCache {
+	return gh.decisions
+}
+
+// SetEncryptionDefaults sets the provider's default encryption settings, the lowest
+// precedence source in MergeEncryptionConfig
+func (gh *GovernanceHelper) SetEncryptionDefaults(defaults map[string]string) {
+	gh.encryption = defaults
+}
+
+// ResolveEncryption merges request, classification and provider default encryption
+// settings for classifications
+func (gh *GovernanceHelper) ResolveEncryption(classifications []string, governanceCtx *GovernanceContext) *EncryptionMergeResult {
+	return MergeEncryptionConfig(governanceCtx, gh.providerType, classifications, gh.encryption)
+}
+
+// ConfigureGovernance records a new governance context, invalidating cached decisions
+func (gh *GovernanceHelper) ConfigureGovernance(governanceCtx *GovernanceContext) {
+	if gh.decisions != nil {
+		gh.decisions.Configure(governanceCtx)
+	}
+}
+
+// Decide returns the cached governance decision for classifications on a resource type
+func (gh *GovernanceHelper) Decide(resourceType string, classifications []string, governanceCtx *GovernanceContext) *GovernanceDecision {
+	if gh.decisions == nil {
+		return ResolveGovernanceDecision(governanceCtx, gh.providerType, resourceType, uniqueSorted(classifications))
+	}
+	return gh.decisions.Decide(governanceCtx, gh.providerType, resourceType, classifications)
+}
+
 // ExtractGovernanceRequirements extracts governance requirements for a resource
 func (gh *GovernanceHelper) ExtractGovernanceRequirements(
 	ctx context.Context,
@@ -354,11 +397,21 @@ func (gh *GovernanceHelper) ExtractGovernanceRequirements(
 
 	// Extract classification-based requirements
 	if classifications, ok := config["classifications"]; ok {
-		if err := gh.extractClassificationRequirements(classifications, governanceCtx, requirements); err != nil {
+		if err := gh.extractClassificationRequirements(resourceType, classifications, governanceCtx, requirements); err != nil {
 			return nil, err
 		}
 	}
 
+	// Merge encryption settings in precedence order (request > classification > provider default)
+	encryption := gh.ResolveEncryption(toStringSlice(config["classifications"]), governanceCtx)
+	if encryption.Required {
+		requirements.EncryptionRequired = true
+	}
+	if requirements.EncryptionRequired {
+		requirements.EncryptionConfig = encryption.Config
+	}
+	requirements.EncryptionDiagnostics = encryption.Diagnostics
+
 	// Extract provider-specific enforcement rules
 	if err := gh.extractProviderSpecificRules(governanceCtx, requirements); err != nil {
 		return nil, err
@@ -377,6 +430,9 @@ type ResourceGovernanceRequirements struct {
 	AuditRequirements  []string          `json:"audit_requirements"`
 	CustomRules        map[string]string `json:"custom_rules"`
 
+	// Conflicts found while merging encryption settings
+	EncryptionDiagnostics []Diagnostic `json:"encryption_diagnostics,omitempty"`
+
 	// Column-specific requirements
 	ColumnRequirements map[string]*ColumnGovernanceRequirements `json:"column_requirements"`
 }
@@ -433,31 +489,29 @@ func (gh *GovernanceHelper) extractColumnRequirements(
 
 // extractClassificationRequirements extracts requirements based on classifications
 func (gh *GovernanceHelper) extractClassificationRequirements(
+	resourceType string,
 	classifications interface{},
 	governanceCtx *GovernanceContext,
 	requirements *ResourceGovernanceRequirements,
 ) error {
 
-	if classArray, ok := classifications.([]interface{}); ok {
-		for _, c := range classArray {
-			if cStr, ok := c.(string); ok {
-				if classCtx, exists := governanceCtx.Classifications[cStr]; exists {
-					// Extract provider-specific enforcement rules
-					if enforcement, exists := classCtx.ProviderEnforcement[gh.providerType]; exists {
-						if enforcement.EncryptionRequired {
-							requirements.EncryptionRequired = true
-							for k, v := range enforcement.EncryptionConfig {
-								requirements.EncryptionConfig[k] = v
-							}
-						}
-
-						requirements.AuditRequirements = append(requirements.AuditRequirements, enforcement.AuditRequirements...)
-					}
-				}
-			}
+	classArray, ok := classifications.([]interface{})
+	if !ok {
+		return nil
+	}
+	names := make([]string, 0, len(classArray))
+	for _, c := range classArray {
+		if cStr, ok := c.(string); ok {
+			names = append(names, cStr)
 		}
 	}
 
+	decision := gh.Decide(resourceType, names, governanceCtx)
+	if decision.EncryptionRequired {
+		requirements.EncryptionRequired = true
+	}
+	requirements.AuditRequirements = append(requirements.AuditRequirements, decision.AuditRequirements...)
+
 	return nil
 }
 
@@ -475,14 +529,7 @@ func (gh *GovernanceHelper) extractProviderSpecificRules(
 
 // requiresAudit checks if classifications require audit logging
 func (gh *GovernanceHelper) requiresAudit(classifications []string, governanceCtx *GovernanceContext) bool {
-	for _, classification := range classifications {
-		if classCtx, exists := governanceCtx.Classifications[classification]; exists {
-			if classCtx.Level == "confidential" || classCtx.Level == "restricted" || classCtx.Level == "secret" {
-				return true
-			}
-		}
-	}
-	return false
+	return gh.Decide("column", classifications, governanceCtx).AuditRequired
 }
 
 // ValidateComplianceFramework validates compliance with a specific framework
@@ -650,16 +697,21 @@ func (gh *GovernanceHelper) GenerateAuditEvent(
 	outcome string,
 	details map[string]interface{},
 ) *AuditEvent {
+	var operation *OperationContext
+	if op, ok := OperationFromContext(ctx); ok {
+		operation = &op
+	}
 
 	return &AuditEvent{
 		EventID:      generateEventID(),
 		EventType:    "governance_enforcement",
-		Timestamp:    time.Now(),
+		Timestamp:    clock.Now(),
 		ProviderType: gh.providerType,
 		Action:       action,
 		Resource:     resource,
 		Outcome:      outcome,
 		Details:      details,
+		Operation:    operation,
 	}
 }
 
@@ -673,11 +725,12 @@ type AuditEvent struct {
 	Resource     string                 `json:"resource"`
 	Outcome      string                 `json:"outcome"`
 	Details      map[string]interface{} `json:"details"`
+	Operation    *OperationContext      `json:"operation,omitempty"` // operation the event was raised under
 }
 
-// generateEventID generates a unique event ID
+// generateEventID generates a unique, time-ordered event ID
 func generateEventID() string {
-	return fmt.Sprintf("gov_evt_%d", time.Now().UnixNano())
+	return ids.Prefixed("gov_evt")
 }
 
 // =============================================================================
@@ -0,0 +1,191 @@
+// Package core provides a cache of resolved governance decisions
+package core
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultGovernanceDecisionTTL is how long a resolved governance decision is reused
+const DefaultGovernanceDecisionTTL = 5 * time.Minute
+
+// GovernanceDecision is what a set of classifications requires of one provider type
+// and resource type. Cached decisions are shared and must be treated as read-only.
+type GovernanceDecision struct {
+	ProviderType         string            `json:"provider_type"`
+	ResourceType         string            `json:"resource_type"`
+	Classifications      []string          `json:"classifications"`
+	EncryptionRequired   bool              `json:"encryption_required"`
+	EncryptionConfig     map[string]string `json:"encryption_config"`
+	AccessRestrictions   []string          `json:"access_restrictions"`
+	AuditRequirements    []string          `json:"audit_requirements"`
+	AuditRequired        bool              `json:"audit_required"`
+	CustomRules          map[string]string `json:"custom_rules"`
+	ComplianceFrameworks []string          `json:"compliance_frameworks"`
+	Unknown              []string          `json:"unknown,omitempty"` // classifications not in the context
+}
+
+// GovernanceDecisionCacheStats reports cache effectiveness
+type GovernanceDecisionCacheStats struct {
+	Hits          int64 `json:"hits"`
+	Misses        int64 `json:"misses"`
+	Entries       int   `json:"entries"`
+	Invalidations int64 `json:"invalidations"`
+}
+
+type governanceDecisionEntry struct {
+	decision *GovernanceDecision
+	expires  time.Time
+}
+
+// GovernanceDecisionCache caches governance decisions keyed by classification set,
+// provider type and resource type. Entries expire after the TTL and are all dropped
+// when a different GovernanceContext is configured, so one cache can safely be shared
+// by several providers in the same process.
+type GovernanceDecisionCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu            sync.Mutex
+	governance    *GovernanceContext
+	entries       map[string]*governanceDecisionEntry
+	hits          int64
+	misses        int64
+	invalidations int64
+}
+
+// NewGovernanceDecisionCache creates a cache; ttl <= 0 uses DefaultGovernanceDecisionTTL
+func NewGovernanceDecisionCache(ttl time.Duration) *GovernanceDecisionCache {
+	if ttl <= 0 {
+		ttl = DefaultGovernanceDecisionTTL
+	}
+	return &GovernanceDecisionCache{
+		ttl:     ttl,
+		now:     clock.Now,
+		entries: make(map[string]*governanceDecisionEntry),
+	}
+}
+
+// Configure sets the governance context decisions are resolved against, invalidating
+// every cached decision when it differs from the current one
+func (c *GovernanceDecisionCache) Configure(governanceCtx *GovernanceContext) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.configureLocked(governanceCtx)
+}
+
+func (c *GovernanceDecisionCache) configureLocked(governanceCtx *GovernanceContext) {
+	if c.governance == governanceCtx {
+		return
+	}
+	c.governance = governanceCtx
+	c.invalidateLocked()
+}
+
+// Invalidate drops every cached decision
+func (c *GovernanceDecisionCache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.invalidateLocked()
+}
+
+func (c *GovernanceDecisionCache) invalidateLocked() {
+	if len(c.entries) == 0 {
+		return
+	}
+	c.entries = make(map[string]*governanceDecisionEntry)
+	c.invalidations++
+}
+
+// Decide returns the decision for classifications on a provider and resource type,
+// resolving it against governanceCtx on a miss. Passing a governance context other
+// than the configured one reconfigures the cache.
+func (c *GovernanceDecisionCache) Decide(governanceCtx *GovernanceContext, providerType, resourceType string, classifications []string) *GovernanceDecision {
+	classifications = uniqueSorted(classifications)
+	key := governanceDecisionKey(providerType, resourceType, classifications)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.configureLocked(governanceCtx)
+
+	now := c.now()
+	if entry, ok := c.entries[key]; ok && now.Before(entry.expires) {
+		c.hits++
+		return entry.decision
+	}
+
+	c.misses++
+	decision := ResolveGovernanceDecision(governanceCtx, providerType, resourceType, classifications)
+	c.entries[key] = &governanceDecisionEntry{decision: decision, expires: now.Add(c.ttl)}
+	return decision
+}
+
+// Stats returns hit, miss and invalidation counts
+func (c *GovernanceDecisionCache) Stats() GovernanceDecisionCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return GovernanceDecisionCacheStats{
+		Hits:          c.hits,
+		Misses:        c.misses,
+		Entries:       len(c.entries),
+		Invalidations: c.invalidations,
+	}
+}
+
+func governanceDecisionKey(providerType, resourceType string, classifications []string) string {
+	return providerType + "\x00" + resourceType + "\x00" + strings.Join(classifications, "\x00")
+}
+
+// ResolveGovernanceDecision evaluates classifications against a governance context
+// without caching
+func ResolveGovernanceDecision(governanceCtx *GovernanceContext, providerType, resourceType string, classifications []string) *GovernanceDecision {
+	decision := &GovernanceDecision{
+		ProviderType:       providerType,
+		ResourceType:       resourceType,
+		Classifications:    classifications,
+		EncryptionConfig:   make(map[string]string),
+		AccessRestrictions: []string{},
+		AuditRequirements:  []string{},
+		CustomRules:        make(map[string]string),
+	}
+	if governanceCtx == nil {
+		return decision
+	}
+
+	frameworks := make(map[string]bool)
+	for _, name := range classifications {
+		classCtx, ok := governanceCtx.Classifications[name]
+		if !ok || classCtx == nil {
+			decision.Unknown = append(decision.Unknown, name)
+			continue
+		}
+
+		if classCtx.Level == "confidential" || classCtx.Level == "restricted" || classCtx.Level == "secret" {
+			decision.AuditRequired = true
+		}
+		for framework := range classCtx.ComplianceFrameworks {
+			frameworks[framework] = true
+		}
+
+		enforcement, ok := classCtx.ProviderEnforcement[providerType]
+		if !ok || enforcement == nil {
+			continue
+		}
+		decision.AccessRestrictions = append(decision.AccessRestrictions, enforcement.AccessRestrictions...)
+		decision.AuditRequirements = append(decision.AuditRequirements, enforcement.AuditRequirements...)
+		for k, v := range enforcement.CustomRules {
+			decision.CustomRules[k] = v
+		}
+	}
+
+	decision.EncryptionRequired, decision.EncryptionConfig, _, _ = classificationEncryption(governanceCtx, providerType, classifications)
+	decision.AccessRestrictions = uniqueSorted(decision.AccessRestrictions)
+	decision.AuditRequirements = uniqueSorted(decision.AuditRequirements)
+	for framework := range frameworks {
+		decision.ComplianceFrameworks = append(decision.ComplianceFrameworks, framework)
+	}
+	sort.Strings(decision.ComplianceFrameworks)
+	return decision
+}
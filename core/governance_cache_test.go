@@ -0,0 +1,81 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func testGovernanceContext() *GovernanceContext {
+	return &GovernanceContext{
+		Classifications: map[string]*ClassificationContext{
+			"pii": {
+				Name:  "pii",
+				Level: "confidential",
+				ProviderEnforcement: map[string]*ProviderEnforcementRules{
+					"postgres": {
+						EncryptionRequired: true,
+						EncryptionConfig:   map[string]string{"algorithm": "aes-256"},
+						AuditRequirements:  []string{"log_access"},
+					},
+				},
+				ComplianceFrameworks: map[string]*ComplianceFrameworkMapping{"GDPR": {Framework: "GDPR"}},
+			},
+			"internal": {Name: "internal", Level: "internal"},
+		},
+	}
+}
+
+// TestGovernanceDecisionCache validates hits, key normalization and TTL expiry
+func TestGovernanceDecisionCache(t *testing.T) {
+	cache := NewGovernanceDecisionCache(time.Minute)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	gc := testGovernanceContext()
+
+	first := cache.Decide(gc, "postgres", "table", []string{"pii", "internal"})
+	if !first.EncryptionRequired || !first.AuditRequired || first.EncryptionConfig["algorithm"] != "aes-256" {
+		t.Errorf("unexpected decision %+v", first)
+	}
+	if len(first.ComplianceFrameworks) != 1 || first.ComplianceFrameworks[0] != "GDPR" {
+		t.Errorf("expected GDPR framework, got %v", first.ComplianceFrameworks)
+	}
+
+	second := cache.Decide(gc, "postgres", "table", []string{"internal", "pii", "pii"})
+	if second != first {
+		t.Errorf("expected classification order and duplicates not to affect the key")
+	}
+	if other := cache.Decide(gc, "kafka", "table", []string{"pii"}); other.EncryptionRequired {
+		t.Errorf("expected postgres enforcement not to apply to kafka")
+	}
+
+	stats := cache.Stats()
+	if stats.Hits != 1 || stats.Misses != 2 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if cache.Decide(gc, "postgres", "table", []string{"pii", "internal"}) == first {
+		t.Errorf("expected expired decision to be re-resolved")
+	}
+}
+
+// TestGovernanceDecisionCacheInvalidation validates invalidation on a new context
+func TestGovernanceDecisionCacheInvalidation(t *testing.T) {
+	helper := NewGovernanceHelper("postgres", nil)
+	gc := testGovernanceContext()
+
+	if !helper.Decide("table", []string{"pii"}, gc).EncryptionRequired {
+		t.Fatalf("expected encryption for pii")
+	}
+
+	updated := testGovernanceContext()
+	updated.Classifications["pii"].ProviderEnforcement["postgres"].EncryptionRequired = false
+	helper.ConfigureGovernance(updated)
+
+	if helper.DecisionCache().Stats().Invalidations != 1 {
+		t.Errorf("expected configuring a new context to invalidate the cache")
+	}
+	if helper.Decide("table", []string{"pii"}, updated).EncryptionRequired {
+		t.Errorf("expected decision from the new context")
+	}
+}
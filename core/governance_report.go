@@ -0,0 +1,300 @@
+// Package core provides pre-apply governance reports for planned resources
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Governance dry-run rule identifiers
+const (
+	GovernanceRuleEncryptionDisabled     = "encryption_disabled"
+	GovernanceRuleUnprotectedColumn      = "unprotected_sensitive_column"
+	GovernanceRuleUnknownClassification  = "unknown_classification"
+	GovernanceRuleAuditedResourceDeleted = "audited_resource_deleted"
+)
+
+// PlannedResource is a resource from a plan, before it is applied
+type PlannedResource struct {
+	Address         string                 `json:"address"` // types.Address form, e.g. postgres_table.users
+	ResourceType    string                 `json:"resource_type"`
+	Name            string                 `json:"name"`
+	Operation       string                 `json:"operation"` // create, update, delete
+	Config          map[string]interface{} `json:"config"`
+	Classifications []string               `json:"classifications,omitempty"` // in addition to config["classifications"]
+}
+
+// ResourceGovernanceReport is what governance will do to one planned resource
+type ResourceGovernanceReport struct {
+	Address              string                `json:"address"`
+	ResourceType         string                `json:"resource_type"`
+	Operation            string                `json:"operation"`
+	Classifications      []string              `json:"classifications"`
+	Encryption           bool                  `json:"encryption"`
+	EncryptionConfig     map[string]string     `json:"encryption_config,omitempty"`
+	EncryptedColumns     []string              `json:"encrypted_columns,omitempty"`
+	MaskedColumns        []string              `json:"masked_columns,omitempty"`
+	Audit                bool                  `json:"audit"`
+	AuditRequirements    []string              `json:"audit_requirements,omitempty"`
+	AccessRestrictions   []string              `json:"access_restrictions,omitempty"`
+	ComplianceFrameworks []string              `json:"compliance_frameworks,omitempty"`
+	Violations           []GovernanceViolation `json:"violations,omitempty"`
+	Warnings             []GovernanceWarning   `json:"warnings,omitempty"`
+}
+
+// GovernanceReportSummary totals a governance dry-run
+type GovernanceReportSummary struct {
+	Resources  int `json:"resources"`
+	Encrypted  int `json:"encrypted"`
+	Masked     int `json:"masked"`
+	Audited    int `json:"audited"`
+	Violations int `json:"violations"`
+	Warnings   int `json:"warnings"`
+}
+
+// GovernanceReport is a consolidated pre-apply governance report for a plan
+type GovernanceReport struct {
+	ProviderType     string                      `json:"provider_type"`
+	EnforcementLevel string                      `json:"enforcement_level"`
+	Compliant        bool                        `json:"compliant"` // no error-level violations
+	Resources        []*ResourceGovernanceReport `json:"resources"`
+	Summary          GovernanceReportSummary     `json:"summary"`
+}
+
+// DryRun evaluates governance for a set of planned resources without changing them.
+// Violations are errors under strict enforcement and warnings under advisory
+// enforcement; with enforcement disabled they are still reported but never make the
+// plan non-compliant.
+func (gh *GovernanceHelper) DryRun(resources []*PlannedResource, governanceCtx *GovernanceContext) *GovernanceReport {
+	report := &GovernanceReport{
+		ProviderType: gh.providerType,
+		Compliant:    true,
+		Resources:    make([]*ResourceGovernanceReport, 0, len(resources)),
+	}
+	level := "strict"
+	if governanceCtx != nil && governanceCtx.EnforcementLevel != "" {
+		level = governanceCtx.EnforcementLevel
+	}
+	report.EnforcementLevel = level
+	violationLevel := "error"
+	if level == "advisory" || level == "disabled" {
+		violationLevel = "warning"
+	}
+
+	sorted := append([]*PlannedResource(nil), resources...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Address < sorted[j].Address })
+
+	for _, resource := range sorted {
+		rr := gh.dryRunResource(resource, governanceCtx, violationLevel)
+		report.Resources = append(report.Resources, rr)
+
+		report.Summary.Resources++
+		if rr.Encryption || len(rr.EncryptedColumns) > 0 {
+			report.Summary.Encrypted++
+		}
+		if len(rr.MaskedColumns) > 0 {
+			report.Summary.Masked++
+		}
+		if rr.Audit {
+			report.Summary.Audited++
+		}
+		report.Summary.Violations += len(rr.Violations)
+		report.Summary.Warnings += len(rr.Warnings)
+		for _, v := range rr.Violations {
+			if v.Level == "error" {
+				report.Compliant = false
+			}
+		}
+	}
+	return report
+}
+
+func (gh *GovernanceHelper) dryRunResource(resource *PlannedResource, governanceCtx *GovernanceContext, violationLevel string) *ResourceGovernanceReport {
+	operation := resource.Operation
+	if operation == "" {
+		operation = "create"
+	}
+	classifications := append([]string(nil), resource.Classifications...)
+	if list, ok := resource.Config["classifications"].([]interface{}); ok {
+		for _, c := range list {
+			if s, ok := c.(string); ok {
+				classifications = append(classifications, s)
+			}
+		}
+	}
+
+	decision := gh.Decide(resource.ResourceType, classifications, governanceCtx)
+	rr := &ResourceGovernanceReport{
+		Address:              resource.Address,
+		ResourceType:         resource.ResourceType,
+		Operation:            operation,
+		Classifications:      decision.Classifications,
+		Encryption:           decision.EncryptionRequired,
+		Audit:                decision.AuditRequired || len(decision.AuditRequirements) > 0,
+		AuditRequirements:    decision.AuditRequirements,
+		AccessRestrictions:   decision.AccessRestrictions,
+		ComplianceFrameworks: decision.ComplianceFrameworks,
+	}
+	if len(decision.EncryptionConfig) > 0 {
+		rr.EncryptionConfig = decision.EncryptionConfig
+	}
+
+	for _, name := range decision.Unknown {
+		rr.Warnings = append(rr.Warnings, GovernanceWarning{
+			Rule:    GovernanceRuleUnknownClassification,
+			Message: fmt.Sprintf("classification %q is not defined in the governance context", name),
+			Field:   "classifications",
+		})
+	}
+
+	if operation == "delete" {
+		if rr.Audit {
+			rr.Warnings = append(rr.Warnings, GovernanceWarning{
+				Rule:    GovernanceRuleAuditedResourceDeleted,
+				Message: "resource holds audited data and will be deleted",
+				Impact:  "audit trail for this resource ends",
+			})
+		}
+		return rr
+	}
+
+	if decision.EncryptionRequired && encryptionDisabled(resource.Config["encryption"]) {
+		rr.Violations = append(rr.Violations, GovernanceViolation{
+			Rule:        GovernanceRuleEncryptionDisabled,
+			Level:       violationLevel,
+			Message:     fmt.Sprintf("classifications %s require encryption but the configuration disables it", strings.Join(decision.Classifications, ", ")),
+			Field:       "encryption",
+			Remediation: "remove the encryption override or change the resource's classifications",
+		})
+	}
+
+	for _, col := range plannedColumns(resource, governanceCtx) {
+		colDecision := gh.Decide("column", col.Classifications, governanceCtx)
+		encrypted := col.EncryptionMethod != "" && col.EncryptionMethod != "none"
+		if colDecision.EncryptionRequired && col.EncryptionMethod == "none" {
+			rr.Violations = append(rr.Violations, GovernanceViolation{
+				Rule:    GovernanceRuleEncryptionDisabled,
+				Level:   violationLevel,
+				Message: fmt.Sprintf("column %s requires encryption but encryption_method is none", col.Name),
+				Field:   "columns." + col.Name,
+			})
+		}
+		if colDecision.EncryptionRequired && col.EncryptionMethod == "" {
+			encrypted = true
+		}
+		if encrypted {
+			rr.EncryptedColumns = append(rr.EncryptedColumns, col.Name)
+		}
+		if col.MaskingRule != "" {
+			rr.MaskedColumns = append(rr.MaskedColumns, col.Name)
+		}
+		if colDecision.AuditRequired {
+			rr.Audit = true
+			if !encrypted && col.MaskingRule == "" {
+				rr.Warnings = append(rr.Warnings, GovernanceWarning{
+					Rule:       GovernanceRuleUnprotectedColumn,
+					Message:    fmt.Sprintf("column %s holds sensitive data but is neither encrypted nor masked", col.Name),
+					Field:      "columns." + col.Name,
+					Suggestion: "add a masking_rule or encryption_method",
+				})
+			}
+		}
+	}
+	sort.Strings(rr.EncryptedColumns)
+	sort.Strings(rr.MaskedColumns)
+	return rr
+}
+
+// plannedColumns merges a resource's configured columns with the column context the
+// governance context holds for the data object of the same name
+func plannedColumns(resource *PlannedResource, governanceCtx *GovernanceContext) []ColumnContext {
+	var columns []ColumnContext
+	if raw, ok := resource.Config["columns"]; ok {
+		if data, err := json.Marshal(raw); err == nil {
+			_ = json.Unmarshal(data, &columns)
+		}
+	}
+	if governanceCtx == nil {
+		return columns
+	}
+	object, ok := governanceCtx.DataObjects[resource.Name]
+	if !ok || object == nil {
+		return columns
+	}
+
+	index := make(map[string]int, len(columns))
+	for i, col := range columns {
+		index[col.Name] = i
+	}
+	for _, governed := range object.Columns {
+		i, exists := index[governed.Name]
+		if !exists {
+			continue
+		}
+		col := &columns[i]
+		col.Classifications = append(col.Classifications, governed.Classifications...)
+		if col.EncryptionMethod == "" {
+			col.EncryptionMethod = governed.EncryptionMethod
+		}
+		if col.MaskingRule == "" {
+			col.MaskingRule = governed.MaskingRule
+		}
+	}
+	return columns
+}
+
+func encryptionDisabled(v interface{}) bool {
+	switch value := v.(type) {
+	case bool:
+		return !value
+	case string:
+		return value == "none" || value == "disabled" || value == "false"
+	case map[string]interface{}:
+		if enabled, ok := value["enabled"].(bool); ok {
+			return !enabled
+		}
+	}
+	return false
+}
+
+// WriteText renders the report as the governance section of a plan
+func (r *GovernanceReport) WriteText(w io.Writer) {
+	fmt.Fprintf(w, "Governance (%s enforcement): %d resources, %d encrypted, %d masked, %d audited\n",
+		r.EnforcementLevel, r.Summary.Resources, r.Summary.Encrypted, r.Summary.Masked, r.Summary.Audited)
+
+	for _, rr := range r.Resources {
+		var actions []string
+		if rr.Encryption {
+			actions = append(actions, "encrypt")
+		}
+		if len(rr.EncryptedColumns) > 0 {
+			actions = append(actions, "encrypt columns "+strings.Join(rr.EncryptedColumns, ", "))
+		}
+		if len(rr.MaskedColumns) > 0 {
+			actions = append(actions, "mask "+strings.Join(rr.MaskedColumns, ", "))
+		}
+		if rr.Audit {
+			actions = append(actions, "audit")
+		}
+		if len(actions) == 0 && len(rr.Violations) == 0 && len(rr.Warnings) == 0 {
+			continue
+		}
+		if len(actions) == 0 {
+			actions = append(actions, "no governance actions")
+		}
+		fmt.Fprintf(w, "  %s (%s): %s\n", rr.Address, rr.Operation, strings.Join(actions, "; "))
+		for _, v := range rr.Violations {
+			fmt.Fprintf(w, "    %s: %s [%s]\n", v.Level, v.Message, v.Rule)
+		}
+		for _, warning := range rr.Warnings {
+			fmt.Fprintf(w, "    warning: %s [%s]\n", warning.Message, warning.Rule)
+		}
+	}
+
+	if !r.Compliant {
+		fmt.Fprintf(w, "%d governance violation(s) must be resolved before apply\n", r.Summary.Violations)
+	}
+}
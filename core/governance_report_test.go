@@ -0,0 +1,68 @@
+package core
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestGovernanceDryRun validates encryption, masking, audit and violation reporting
+func TestGovernanceDryRun(t *testing.T) {
+	gc := testGovernanceContext()
+	gc.DataObjects = map[string]*DataObjectContext{
+		"users": {Name: "users", Columns: []ColumnContext{
+			{Name: "ssn", MaskingRule: "last4"},
+		}},
+	}
+	helper := NewGovernanceHelper("postgres", nil)
+
+	resources := []*PlannedResource{
+		{
+			Address: "postgres_table.users", ResourceType: "table", Name: "users",
+			Config: map[string]interface{}{
+				"columns": []interface{}{
+					map[string]interface{}{"name": "id"},
+					map[string]interface{}{"name": "email", "classifications": []interface{}{"pii"}},
+					map[string]interface{}{"name": "ssn", "classifications": []interface{}{"pii"}, "encryption_method": "none"},
+				},
+			},
+		},
+		{
+			Address: "postgres_table.events", ResourceType: "table", Name: "events",
+			Config: map[string]interface{}{
+				"classifications": []interface{}{"pii", "unheard_of"},
+				"encryption":      false,
+			},
+		},
+	}
+
+	report := helper.DryRun(resources, gc)
+	if report.Compliant {
+		t.Fatalf("expected strict violations to make the plan non-compliant")
+	}
+	if report.Resources[0].Address != "postgres_table.events" {
+		t.Errorf("expected resources sorted by address")
+	}
+
+	events, users := report.Resources[0], report.Resources[1]
+	if !events.Encryption || len(events.Violations) != 1 || events.Violations[0].Rule != GovernanceRuleEncryptionDisabled {
+		t.Errorf("unexpected events report %+v", events)
+	}
+	if len(events.Warnings) != 1 || events.Warnings[0].Rule != GovernanceRuleUnknownClassification {
+		t.Errorf("expected unknown classification warning, got %+v", events.Warnings)
+	}
+	if strings.Join(users.EncryptedColumns, ",") != "email" || strings.Join(users.MaskedColumns, ",") != "ssn" || !users.Audit {
+		t.Errorf("unexpected users report %+v", users)
+	}
+
+	var buf bytes.Buffer
+	report.WriteText(&buf)
+	if !strings.Contains(buf.String(), "postgres_table.users (create): encrypt columns email; mask ssn; audit") {
+		t.Errorf("unexpected text report:\n%s", buf.String())
+	}
+
+	gc.EnforcementLevel = "advisory"
+	if !helper.DryRun(resources, gc).Compliant {
+		t.Errorf("expected advisory enforcement to stay compliant")
+	}
+}
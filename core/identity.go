@@ -0,0 +1,219 @@
+// Package core provides session-scoped credentials and impersonation
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ActionImpersonate is the permission action required to run a request as another role
+const ActionImpersonate = "impersonate"
+
+// ErrImpersonationNotAllowed is returned when a request asks to run as another identity
+// but no authorizer is configured to validate it
+var ErrImpersonationNotAllowed = errors.New("impersonation requires governance authorization")
+
+// RunAs is a per-request credential override, e.g. "run this apply as role X"
+type RunAs struct {
+	Role        string            `json:"role,omitempty"`        // database/provider role to assume
+	Username    string            `json:"username,omitempty"`    // login to connect as
+	Credentials map[string]string `json:"credentials,omitempty"` // password, token, etc.
+}
+
+// EffectiveIdentity is the identity an operation runs under
+type EffectiveIdentity struct {
+	Principal   string            `json:"principal"`          // requesting user
+	Role        string            `json:"role,omitempty"`     // assumed role
+	Username    string            `json:"username,omitempty"` // login override
+	Tenant      string            `json:"tenant,omitempty"`
+	Credentials map[string]string `json:"-"`
+}
+
+// Impersonating reports whether the identity differs from the provider's configured one
+func (id *EffectiveIdentity) Impersonating() bool {
+	return id != nil && (id.Role != "" || id.Username != "" || len(id.Credentials) > 0)
+}
+
+// Key returns a stable connection pool key for the identity. Credentials contribute only
+// through a hash so keys are safe to log.
+func (id *EffectiveIdentity) Key() string {
+	if id == nil {
+		return "default"
+	}
+
+	h := sha256.New()
+	keys := make([]string, 0, len(id.Credentials))
+	for k := range id.Credentials {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(h, "%s=%s\x00", k, id.Credentials[k])
+	}
+
+	return strings.Join([]string{
+		id.Tenant,
+		id.Role,
+		id.Username,
+		hex.EncodeToString(h.Sum(nil))[:16],
+	}, "|")
+}
+
+// identityContextKey is the context key holding the effective identity
+type identityContextKey struct{}
+
+// WithIdentity returns a context carrying the effective identity
+func WithIdentity(ctx context.Context, identity *EffectiveIdentity) context.Context {
+	return context.WithValue(ctx, identityContextKey{}, identity)
+}
+
+// IdentityFromContext returns the effective identity, or nil when the request runs
+// with the provider's configured credentials
+func IdentityFromContext(ctx context.Context) *EffectiveIdentity {
+	identity, _ := ctx.Value(identityContextKey{}).(*EffectiveIdentity)
+	return identity
+}
+
+// AuthorizeImpersonation checks that user holds an "impersonate" permission whose
+// resources cover the target role (or username when no role is given). Unlike
+// Authorize it fails closed: advisory enforcement, a governance context without roles
+// and wildcard actions such as "admin" do not grant impersonation.
+func (a *Authorizer) AuthorizeImpersonation(user *UserContext, runAs *RunAs) error {
+	if runAs == nil {
+		return nil
+	}
+	target := runAs.Role
+	if target == "" {
+		target = runAs.Username
+	}
+	if a.grantsImpersonation(user, target) {
+		return nil
+	}
+	diag := a.deniedDiagnostic(user, ActionImpersonate, target)
+	diag.Severity = "error"
+	return newPermissionDeniedError(user, ActionImpersonate, target, *diag)
+}
+
+// grantsImpersonation reports whether one of user's roles holds a permission naming
+// the impersonate action explicitly for target
+func (a *Authorizer) grantsImpersonation(user *UserContext, target string) bool {
+	if a.governance == nil || user == nil || target == "" {
+		return false
+	}
+	for name := range a.heldPermissions(user) {
+		if perm, exists := a.governance.Permissions[name]; exists {
+			for _, action := range perm.Actions {
+				if strings.EqualFold(action, ActionImpersonate) && a.matchesResource(perm.Resources, target) {
+					return true
+				}
+			}
+		} else if action, resource, ok := strings.Cut(name, ":"); ok &&
+			strings.EqualFold(action, ActionImpersonate) && a.matchesResource([]string{resource}, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// identityFromRequest attaches the effective identity requested through run_as, after
+// validating it against governance permissions
+func identityFromRequest(ctx context.Context, authorizer *Authorizer, input []byte) (context.Context, error) {
+	var req struct {
+		RunAs *RunAs `json:"run_as"`
+	}
+	if len(input) == 0 || json.Unmarshal(input, &req) != nil || req.RunAs == nil {
+		return ctx, nil
+	}
+	if authorizer == nil {
+		return nil, ErrImpersonationNotAllowed
+	}
+
+	_, user := requestUserContext(input)
+	if err := authorizer.AuthorizeImpersonation(user, req.RunAs); err != nil {
+		return nil, err
+	}
+
+	identity := &EffectiveIdentity{
+		Role:        req.RunAs.Role,
+		Username:    req.RunAs.Username,
+		Credentials: req.RunAs.Credentials,
+	}
+	if user != nil {
+		identity.Principal = user.UserID
+	}
+	identity.Tenant, _ = TenantFromContext(ctx)
+	return WithIdentity(ctx, identity), nil
+}
+
+// =============================================================================
+// IDENTITY-KEYED CONNECTION POOLS
+// =============================================================================
+
+// IdentityPoolFactory opens a connection pool that authenticates as identity. A nil
+// identity means the provider's configured credentials.
+type IdentityPoolFactory func(ctx context.Context, identity *EffectiveIdentity) (io.Closer, error)
+
+// IdentityPools keeps one connection pool per effective identity so least-privilege
+// sessions never share connections with more privileged ones
+type IdentityPools struct {
+	mu      sync.Mutex
+	pools   map[string]io.Closer
+	factory IdentityPoolFactory
+}
+
+// NewIdentityPools creates an identity-keyed pool set
+func NewIdentityPools(factory IdentityPoolFactory) *IdentityPools {
+	return &IdentityPools{
+		pools:   make(map[string]io.Closer),
+		factory: factory,
+	}
+}
+
+// Get returns the pool for the request's effective identity, opening it on first use
+func (p *IdentityPools) Get(ctx context.Context) (io.Closer, error) {
+	identity := IdentityFromContext(ctx)
+	key := identity.Key()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if pool, exists := p.pools[key]; exists {
+		return pool, nil
+	}
+	pool, err := p.factory(ctx, identity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open connection pool for identity %s: %w", key, err)
+	}
+	p.pools[key] = pool
+	return pool, nil
+}
+
+// Len returns the number of open pools
+func (p *IdentityPools) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.pools)
+}
+
+// Close closes every pool
+func (p *IdentityPools) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var errs []error
+	for key, pool := range p.pools {
+		if err := pool.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("identity %s: %w", key, err))
+		}
+	}
+	p.pools = make(map[string]io.Closer)
+	return errors.Join(errs...)
+}
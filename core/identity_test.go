@@ -0,0 +1,121 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+// TestIdentityFromRequest validates run_as authorization against impersonate permissions
+func TestIdentityFromRequest(t *testing.T) {
+	gov := &GovernanceContext{
+		Roles: map[string]*RoleContext{
+			"deployer": {Name: "deployer", Permissions: []string{"assume_readonly"}},
+		},
+		Permissions: map[string]*PermissionContext{
+			"assume_readonly": {Name: "assume_readonly", Actions: []string{ActionImpersonate}, Resources: []string{"readonly_*"}},
+		},
+	}
+	authz := NewAuthorizer("postgres", gov)
+
+	allowed := []byte(`{"run_as":{"role":"readonly_app"},"metadata":{"user_context":{"user_id":"u1","roles":["deployer"]}}}`)
+	ctx, err := identityFromRequest(context.Background(), authz, allowed)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	identity := IdentityFromContext(ctx)
+	if identity == nil || identity.Role != "readonly_app" || identity.Principal != "u1" {
+		t.Fatalf("unexpected identity: %+v", identity)
+	}
+
+	denied := []byte(`{"run_as":{"role":"superuser"},"metadata":{"user_context":{"user_id":"u1","roles":["deployer"]}}}`)
+	var permErr *PermissionDeniedError
+	if _, err := identityFromRequest(context.Background(), authz, denied); !errors.As(err, &permErr) {
+		t.Errorf("expected PermissionDeniedError, got %v", err)
+	}
+
+	if _, err := identityFromRequest(context.Background(), nil, allowed); !errors.Is(err, ErrImpersonationNotAllowed) {
+		t.Errorf("expected ErrImpersonationNotAllowed, got %v", err)
+	}
+}
+
+// TestAuthorizeImpersonationFailsClosed validates impersonation needs an explicit grant
+// whatever the enforcement level or role definitions
+func TestAuthorizeImpersonationFailsClosed(t *testing.T) {
+	deployer := &UserContext{UserID: "u1", Roles: []string{"deployer"}}
+	runAs := &RunAs{Role: "readonly_app"}
+	tests := []struct {
+		name    string
+		gov     *GovernanceContext
+		user    *UserContext
+		allowed bool
+	}{
+		{name: "no governance", user: deployer},
+		{name: "no roles", gov: &GovernanceContext{}, user: deployer},
+		{name: "advisory without grant", user: deployer, gov: &GovernanceContext{
+			EnforcementLevel: "advisory",
+			Roles:            map[string]*RoleContext{"deployer": {Permissions: []string{"read:*"}}},
+		}},
+		{name: "disabled without grant", user: deployer, gov: &GovernanceContext{
+			EnforcementLevel: "disabled",
+			Roles:            map[string]*RoleContext{"deployer": {Permissions: []string{"read:*"}}},
+		}},
+		{name: "wildcard action", user: deployer, gov: &GovernanceContext{
+			Roles:       map[string]*RoleContext{"deployer": {Permissions: []string{"everything"}}},
+			Permissions: map[string]*PermissionContext{"everything": {Actions: []string{"admin"}, Resources: []string{"*"}}},
+		}},
+		{name: "anonymous", gov: &GovernanceContext{
+			Roles: map[string]*RoleContext{"deployer": {Permissions: []string{"impersonate:*"}}},
+		}},
+		{name: "inline grant", user: deployer, allowed: true, gov: &GovernanceContext{
+			EnforcementLevel: "advisory",
+			Roles:            map[string]*RoleContext{"deployer": {Permissions: []string{"impersonate:readonly_*"}}},
+		}},
+		{name: "named grant", user: deployer, allowed: true, gov: &GovernanceContext{
+			Roles:       map[string]*RoleContext{"deployer": {Permissions: []string{"assume"}}},
+			Permissions: map[string]*PermissionContext{"assume": {Actions: []string{"Impersonate"}, Resources: []string{"readonly_app"}}},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewAuthorizer("postgres", tt.gov).AuthorizeImpersonation(tt.user, runAs)
+			var permErr *PermissionDeniedError
+			if tt.allowed && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			} else if !tt.allowed && (!errors.As(err, &permErr) || permErr.Diagnostic.Severity != "error") {
+				t.Errorf("expected PermissionDeniedError, got %v", err)
+			}
+		})
+	}
+}
+
+// TestIdentityPoolsKeyedByIdentity validates that identities never share pools
+func TestIdentityPoolsKeyedByIdentity(t *testing.T) {
+	opened := 0
+	pools := NewIdentityPools(func(ctx context.Context, identity *EffectiveIdentity) (io.Closer, error) {
+		opened++
+		return closerFunc(func() error { return nil }), nil
+	})
+
+	base := context.Background()
+	reader := WithIdentity(base, &EffectiveIdentity{Role: "reader", Credentials: map[string]string{"password": "a"}})
+	writer := WithIdentity(base, &EffectiveIdentity{Role: "writer", Credentials: map[string]string{"password": "b"}})
+
+	for _, ctx := range []context.Context{base, reader, writer, reader} {
+		if _, err := pools.Get(ctx); err != nil {
+			t.Fatalf("get: %v", err)
+		}
+	}
+	if opened != 3 || pools.Len() != 3 {
+		t.Errorf("expected 3 pools, opened %d, len %d", opened, pools.Len())
+	}
+
+	key := IdentityFromContext(reader).Key()
+	if key == IdentityFromContext(writer).Key() {
+		t.Errorf("expected distinct keys")
+	}
+	if err := pools.Close(); err != nil || pools.Len() != 0 {
+		t.Errorf("expected pools to be closed, err=%v len=%d", err, pools.Len())
+	}
+}
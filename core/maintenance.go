@@ -0,0 +1,360 @@
+// Package core provides maintenance windows restricting when disruptive operations run
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Diagnostic codes for operations outside their maintenance windows
+const (
+	DiagnosticCodeMaintenanceWindowClosed = "MAINTENANCE_WINDOW_CLOSED"
+	DiagnosticCodeOperationDeferred       = "OPERATION_DEFERRED"
+)
+
+// MaintenanceOverrideField is the request field that runs an operation outside its
+// maintenance windows, e.g. for an emergency fix
+const MaintenanceOverrideField = "override_maintenance_window"
+
+// Maintenance modes
+const (
+	MaintenanceBlock = "block" // reject the operation
+	MaintenanceDefer = "defer" // reject it as deferred until the next window opens
+)
+
+// defaultDisruptiveFunctions are the functions a policy restricts when it lists none
+var defaultDisruptiveFunctions = []string{"UpdateResource", "DeleteResource"}
+
+// MaintenanceWindow is a recurring period in which disruptive operations may run. Start
+// is a five-field cron expression (minute hour day-of-month month day-of-week) giving
+// the times the window opens, e.g. "0 2 * * SAT" for 02:00 every Saturday.
+type MaintenanceWindow struct {
+	Start    string        `json:"start"`
+	Duration time.Duration `json:"duration"`
+	Timezone string        `json:"timezone,omitempty"` // IANA name; UTC when empty
+
+	cron     *cronSchedule
+	location *time.Location
+}
+
+// MaintenancePolicy restricts functions on matching resource types to its windows
+type MaintenancePolicy struct {
+	Name          string              `json:"name"`
+	ResourceTypes []string            `json:"resource_types,omitempty"` // path.Match patterns; empty matches every type
+	Functions     []string            `json:"functions,omitempty"`      // defaults to UpdateResource and DeleteResource
+	Windows       []MaintenanceWindow `json:"windows"`
+	Mode          string              `json:"mode,omitempty"` // block (default) or defer
+}
+
+// MaintenanceWindowError is returned for a disruptive operation outside its windows
+type MaintenanceWindowError struct {
+	Diagnostic Diagnostic `json:"diagnostic"`
+	Policy     string     `json:"policy"`
+	Deferred   bool       `json:"deferred"`
+	NextWindow time.Time  `json:"next_window,omitempty"` // zero when no window opens within a year
+}
+
+// Error implements the error interface
+func (e *MaintenanceWindowError) Error() string {
+	return e.Diagnostic.Summary + ": " + e.Diagnostic.Detail
+}
+
+// MaintenanceSchedule holds a provider's maintenance policies
+type MaintenanceSchedule struct {
+	policies []MaintenancePolicy
+}
+
+// NewMaintenanceSchedule validates policies and parses their windows
+func NewMaintenanceSchedule(policies ...MaintenancePolicy) (*MaintenanceSchedule, error) {
+	s := &MaintenanceSchedule{policies: make([]MaintenancePolicy, len(policies))}
+	for i, policy := range policies {
+		switch policy.Mode {
+		case "":
+			policy.Mode = MaintenanceBlock
+		case MaintenanceBlock, MaintenanceDefer:
+		default:
+			return nil, fmt.Errorf("maintenance policy %s: unknown mode %q", policy.Name, policy.Mode)
+		}
+		if len(policy.Windows) == 0 {
+			return nil, fmt.Errorf("maintenance policy %s: no windows", policy.Name)
+		}
+		if len(policy.Functions) == 0 {
+			policy.Functions = defaultDisruptiveFunctions
+		}
+		windows := make([]MaintenanceWindow, len(policy.Windows))
+		for j, window := range policy.Windows {
+			if err := window.parse(); err != nil {
+				return nil, fmt.Errorf("maintenance policy %s: %w", policy.Name, err)
+			}
+			windows[j] = window
+		}
+		policy.Windows = windows
+		s.policies[i] = policy
+	}
+	return s, nil
+}
+
+func (w *MaintenanceWindow) parse() error {
+	if w.Duration <= 0 || w.Duration > 7*24*time.Hour {
+		return fmt.Errorf("window %q: duration must be positive and at most 7 days", w.Start)
+	}
+	cron, err := parseCron(w.Start)
+	if err != nil {
+		return fmt.Errorf("window %q: %w", w.Start, err)
+	}
+	location := time.UTC
+	if w.Timezone != "" {
+		if location, err = time.LoadLocation(w.Timezone); err != nil {
+			return fmt.Errorf("window %q: %w", w.Start, err)
+		}
+	}
+	w.cron, w.location = cron, location
+	return nil
+}
+
+// Open reports whether the window is open at t
+func (w MaintenanceWindow) Open(t time.Time) bool {
+	local := t.In(w.location).Truncate(time.Minute)
+	for start := local; local.Sub(start) < w.Duration; start = start.Add(-time.Minute) {
+		if w.cron.matches(start) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextOpen returns when the window next opens after t, or false when it does not open
+// within a year
+func (w MaintenanceWindow) NextOpen(t time.Time) (time.Time, bool) {
+	return w.cron.next(t.In(w.location))
+}
+
+// String describes the window, e.g. "0 2 * * SAT for 4h0m0s UTC"
+func (w MaintenanceWindow) String() string {
+	tz := w.Timezone
+	if tz == "" {
+		tz = "UTC"
+	}
+	return fmt.Sprintf("%s for %s %s", w.Start, w.Duration, tz)
+}
+
+// Check returns a *MaintenanceWindowError when function on resourceType is restricted
+// by a policy none of whose windows is open at now
+func (s *MaintenanceSchedule) Check(now time.Time, function, resourceType string) error {
+	if s == nil {
+		return nil
+	}
+	for _, policy := range s.policies {
+		if !policy.applies(function, resourceType) {
+			continue
+		}
+		open := false
+		var next time.Time
+		described := make([]string, len(policy.Windows))
+		for i, window := range policy.Windows {
+			described[i] = window.String()
+			if window.Open(now) {
+				open = true
+				break
+			}
+			if t, ok := window.NextOpen(now); ok && (next.IsZero() || t.Before(next)) {
+				next = t
+			}
+		}
+		if open {
+			continue
+		}
+
+		subject := function
+		if resourceType != "" {
+			subject += " on " + resourceType
+		}
+		detail := fmt.Sprintf("%s is only allowed during the %s maintenance windows (%s)", subject, policy.Name, strings.Join(described, "; "))
+		if !next.IsZero() {
+			detail += "; next window opens " + next.UTC().Format(time.RFC3339)
+		}
+		detail += fmt.Sprintf("; set %s to run it now", MaintenanceOverrideField)
+
+		diagnostic := Diagnostic{
+			Severity: "error",
+			Code:     DiagnosticCodeMaintenanceWindowClosed,
+			Summary:  "outside maintenance window",
+			Detail:   detail,
+			Resource: resourceType,
+		}
+		if policy.Mode == MaintenanceDefer {
+			diagnostic.Severity = "warning"
+			diagnostic.Code = DiagnosticCodeOperationDeferred
+			diagnostic.Summary = "operation deferred to maintenance window"
+		}
+		return &MaintenanceWindowError{
+			Diagnostic: diagnostic,
+			Policy:     policy.Name,
+			Deferred:   policy.Mode == MaintenanceDefer,
+			NextWindow: next,
+		}
+	}
+	return nil
+}
+
+func (p MaintenancePolicy) applies(function, resourceType string) bool {
+	if !containsString(p.Functions, function) {
+		return false
+	}
+	if len(p.ResourceTypes) == 0 {
+		return true
+	}
+	for _, pattern := range p.ResourceTypes {
+		if matched, err := path.Match(pattern, resourceType); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// SetMaintenanceSchedule restricts disruptive functions to maintenance windows
+func (d *UnifiedDispatcher) SetMaintenanceSchedule(schedule *MaintenanceSchedule) {
+	d.maintenance = schedule
+}
+
+// checkMaintenance rejects restricted functions outside their windows unless the request
+// sets the override field
+func (d *UnifiedDispatcher) checkMaintenance(function string, input []byte) error {
+	if d.maintenance == nil {
+		return nil
+	}
+	var req struct {
+		ResourceType string `json:"resource_type"`
+		Override     bool   `json:"override_maintenance_window"`
+	}
+	_ = json.Unmarshal(input, &req)
+	if req.Override {
+		return nil
+	}
+	return d.maintenance.Check(clock.Now(), function, req.ResourceType)
+}
+
+// cronSchedule is a parsed five-field cron expression
+type cronSchedule struct {
+	minute, hour, dom, month, dow [64]bool
+	domAny, dowAny                bool
+}
+
+var cronNames = map[string]int{
+	"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6,
+	"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+	"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+}
+
+func parseCron(expr string) (*cronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression needs 5 fields, got %d", len(fields))
+	}
+	c := &cronSchedule{domAny: fields[2] == "*", dowAny: fields[4] == "*"}
+	for i, spec := range []struct {
+		set      *[64]bool
+		min, max int
+	}{{&c.minute, 0, 59}, {&c.hour, 0, 23}, {&c.dom, 1, 31}, {&c.month, 1, 12}, {&c.dow, 0, 7}} {
+		if err := parseCronField(fields[i], spec.min, spec.max, spec.set); err != nil {
+			return nil, fmt.Errorf("field %d %q: %w", i+1, fields[i], err)
+		}
+	}
+	if c.dow[7] {
+		c.dow[0] = true // 7 is Sunday too
+	}
+	return c, nil
+}
+
+func parseCronField(field string, min, max int, set *[64]bool) error {
+	value := func(s string) (int, error) {
+		if n, ok := cronNames[strings.ToUpper(s)]; ok {
+			return n, nil
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || n < min || n > max {
+			return 0, fmt.Errorf("%q is not between %d and %d", s, min, max)
+		}
+		return n, nil
+	}
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return fmt.Errorf("invalid step in %q", part)
+			}
+			step, part = n, part[:i]
+		}
+		lo, hi := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if lo, err = value(bounds[0]); err != nil {
+				return err
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				if hi, err = value(bounds[1]); err != nil {
+					return err
+				}
+			} else if step > 1 {
+				hi = max
+			}
+			if hi < lo {
+				return fmt.Errorf("range %q is reversed", part)
+			}
+		}
+		for n := lo; n <= hi; n += step {
+			set[n] = true
+		}
+	}
+	return nil
+}
+
+// matches reports whether t is a start time; day-of-month and day-of-week are ORed
+// when both are restricted, as in cron
+func (c *cronSchedule) matches(t time.Time) bool {
+	if !c.minute[t.Minute()] || !c.hour[t.Hour()] || !c.month[int(t.Month())] {
+		return false
+	}
+	return c.dayMatches(t)
+}
+
+func (c *cronSchedule) dayMatches(t time.Time) bool {
+	dom, dow := c.dom[t.Day()], c.dow[int(t.Weekday())]
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dow
+	case c.dowAny:
+		return dom
+	}
+	return dom || dow
+}
+
+// next returns the first start time after t, searching a year ahead
+func (c *cronSchedule) next(t time.Time) (time.Time, bool) {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(1, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case !c.month[int(t.Month())]:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case !c.hour[t.Hour()]:
+			t = t.Truncate(time.Hour).Add(time.Hour)
+		case !c.minute[t.Minute()]:
+			t = t.Add(time.Minute)
+		default:
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,115 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/types"
+)
+
+func newTestMaintenanceSchedule(t *testing.T, mode string) *MaintenanceSchedule {
+	t.Helper()
+	schedule, err := NewMaintenanceSchedule(MaintenancePolicy{
+		Name:          "weekend",
+		ResourceTypes: []string{"*table"},
+		Windows:       []MaintenanceWindow{{Start: "0 2 * * SAT,SUN", Duration: 4 * time.Hour}},
+		Mode:          mode,
+	})
+	if err != nil {
+		t.Fatalf("NewMaintenanceSchedule: %v", err)
+	}
+	return schedule
+}
+
+// TestMaintenanceWindowOpen validates window membership and the next opening
+func TestMaintenanceWindowOpen(t *testing.T) {
+	schedule := newTestMaintenanceSchedule(t, "")
+	window := schedule.policies[0].Windows[0]
+
+	saturday := time.Date(2024, 1, 6, 3, 30, 0, 0, time.UTC)
+	if !window.Open(saturday) {
+		t.Error("window should be open at 03:30 Saturday")
+	}
+	if window.Open(saturday.Add(3 * time.Hour)) {
+		t.Error("window should be closed at 06:30 Saturday")
+	}
+	next, ok := window.NextOpen(time.Date(2024, 1, 8, 12, 0, 0, 0, time.UTC))
+	if want := time.Date(2024, 1, 13, 2, 0, 0, 0, time.UTC); !ok || !next.Equal(want) {
+		t.Errorf("NextOpen = %v, %v; want %v", next, ok, want)
+	}
+}
+
+// TestMaintenanceScheduleCheck validates blocking, deferral and unrestricted operations
+func TestMaintenanceScheduleCheck(t *testing.T) {
+	monday := time.Date(2024, 1, 8, 12, 0, 0, 0, time.UTC)
+
+	err := newTestMaintenanceSchedule(t, "").Check(monday, "DeleteResource", "table")
+	var closed *MaintenanceWindowError
+	if !errors.As(err, &closed) || closed.Deferred || closed.Diagnostic.Code != DiagnosticCodeMaintenanceWindowClosed {
+		t.Fatalf("expected blocking MaintenanceWindowError, got %v", err)
+	}
+	if !strings.Contains(closed.Error(), "2024-01-13T02:00:00Z") || !strings.Contains(closed.Error(), MaintenanceOverrideField) {
+		t.Errorf("diagnostic should name the next window and the override: %s", closed.Error())
+	}
+
+	err = newTestMaintenanceSchedule(t, MaintenanceDefer).Check(monday, "UpdateResource", "external_table")
+	if !errors.As(err, &closed) || !closed.Deferred || closed.Diagnostic.Code != DiagnosticCodeOperationDeferred {
+		t.Fatalf("expected deferred MaintenanceWindowError, got %v", err)
+	}
+
+	schedule := newTestMaintenanceSchedule(t, "")
+	if err := schedule.Check(monday, "CreateResource", "table"); err != nil {
+		t.Errorf("creates are not disruptive by default: %v", err)
+	}
+	if err := schedule.Check(monday, "DeleteResource", "view"); err != nil {
+		t.Errorf("unmatched resource type should pass: %v", err)
+	}
+	if err := schedule.Check(time.Date(2024, 1, 7, 5, 59, 0, 0, time.UTC), "DeleteResource", "table"); err != nil {
+		t.Errorf("operation inside the window should pass: %v", err)
+	}
+}
+
+// TestParseCron validates cron field syntax
+func TestParseCron(t *testing.T) {
+	c, err := parseCron("*/15 9-17 1,15 * MON-FRI")
+	if err != nil {
+		t.Fatalf("parseCron: %v", err)
+	}
+	if !c.minute[45] || c.minute[50] || !c.hour[17] || c.hour[18] {
+		t.Error("minute step or hour range parsed wrong")
+	}
+	// day-of-month and day-of-week are ORed when both are restricted
+	if !c.matches(time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)) || !c.matches(time.Date(2024, 1, 9, 9, 0, 0, 0, time.UTC)) {
+		t.Error("expected a match on the 15th and on a Tuesday")
+	}
+	if c.matches(time.Date(2024, 1, 13, 9, 0, 0, 0, time.UTC)) {
+		t.Error("Saturday the 13th should not match")
+	}
+
+	for _, bad := range []string{"* * * *", "60 * * * *", "* * * * FUNDAY", "5-1 * * * *", "*/0 * * * *"} {
+		if _, err := parseCron(bad); err == nil {
+			t.Errorf("parseCron(%q) should fail", bad)
+		}
+	}
+}
+
+// TestDispatcherMaintenanceWindow validates dispatch holds disruptive operations unless overridden
+func TestDispatcherMaintenanceWindow(t *testing.T) {
+	defer SetClock(types.ClockFunc(func() time.Time { return time.Date(2024, 1, 8, 12, 0, 0, 0, time.UTC) }))()
+	d := NewUnifiedDispatcher(nil, nil)
+	d.SetMaintenanceSchedule(newTestMaintenanceSchedule(t, ""))
+
+	_, err := d.Dispatch(context.Background(), "DeleteResource", []byte(`{"resource_type":"table","name":"events"}`))
+	var closed *MaintenanceWindowError
+	if !errors.As(err, &closed) {
+		t.Fatalf("expected MaintenanceWindowError, got %v", err)
+	}
+
+	_, err = d.Dispatch(context.Background(), "DeleteResource", []byte(`{"resource_type":"table","name":"events","override_maintenance_window":true}`))
+	if errors.As(err, &closed) {
+		t.Errorf("override should bypass the window: %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+// Package core provides operation metadata carried through the call stack
+package core
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/schemabounce/kolumn/sdk/types"
+)
+
+// OperationContext describes the operation a call belongs to, so logging, retry and
+// audit helpers can enrich their output without extra parameters on every function
+type OperationContext struct {
+	Operation string        `json:"operation"` // function name, e.g. CreateResource
+	Address   types.Address `json:"address"`   // resource being operated on, if any
+	Attempt   int           `json:"attempt"`   // 1-based attempt number
+	DryRun    bool          `json:"dry_run,omitempty"`
+}
+
+// Fields returns the operation as flat log fields. Empty values are omitted.
+func (o OperationContext) Fields() map[string]interface{} {
+	fields := map[string]interface{}{"operation": o.Operation}
+	if o.Address.Type != "" {
+		fields["resource_address"] = o.Address.String()
+	}
+	if o.Attempt > 0 {
+		fields["attempt"] = o.Attempt
+	}
+	if o.DryRun {
+		fields["dry_run"] = true
+	}
+	return fields
+}
+
+// operationContextKey is the context key holding the OperationContext
+type operationContextKey struct{}
+
+// WithOperationContext returns a context carrying op. An attempt of zero is stored as 1.
+func WithOperationContext(ctx context.Context, op OperationContext) context.Context {
+	if op.Attempt < 1 {
+		op.Attempt = 1
+	}
+	return context.WithValue(ctx, operationContextKey{}, op)
+}
+
+// OperationFromContext returns the operation the context belongs to
+func OperationFromContext(ctx context.Context) (OperationContext, bool) {
+	op, ok := ctx.Value(operationContextKey{}).(OperationContext)
+	return op, ok
+}
+
+// WithAttempt returns a context whose operation carries the given attempt number. Retry
+// loops call it before each attempt; without an operation in ctx, ctx is returned as is.
+func WithAttempt(ctx context.Context, attempt int) context.Context {
+	op, ok := OperationFromContext(ctx)
+	if !ok {
+		return ctx
+	}
+	op.Attempt = attempt
+	return WithOperationContext(ctx, op)
+}
+
+// withRequestOperation records the dispatched function, the addressed resource and the
+// dry-run flag of a request. An attempt number set by a retrying caller is kept.
+func withRequestOperation(ctx context.Context, function string, input []byte) context.Context {
+	var req struct {
+		ResourceType string `json:"resource_type"`
+		ObjectType   string `json:"object_type"`
+		Name         string `json:"name"`
+		DryRun       bool   `json:"dry_run"`
+	}
+	_ = json.Unmarshal(input, &req)
+
+	op := OperationContext{Operation: function, DryRun: req.DryRun, Attempt: 1}
+	if previous, ok := OperationFromContext(ctx); ok && previous.Operation == function {
+		op.Attempt = previous.Attempt
+	}
+	resourceType := req.ResourceType
+	if resourceType == "" {
+		resourceType = req.ObjectType
+	}
+	if resourceType != "" {
+		op.Address = types.Address{Type: resourceType, Name: req.Name}
+	}
+	return WithOperationContext(ctx, op)
+}
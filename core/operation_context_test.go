@@ -0,0 +1,42 @@
+package core
+
+import (
+	"context"
+	"testing"
+)
+
+// TestOperationContext validates request metadata and attempts travel through ctx
+func TestOperationContext(t *testing.T) {
+	ctx := withRequestOperation(context.Background(), "UpdateResource",
+		[]byte(`{"resource_type":"postgres_table","name":"users","dry_run":true}`))
+
+	op, ok := OperationFromContext(ctx)
+	if !ok {
+		t.Fatal("operation missing from context")
+	}
+	if op.Operation != "UpdateResource" || op.Address.String() != "postgres_table.users" || !op.DryRun || op.Attempt != 1 {
+		t.Fatalf("unexpected operation %+v", op)
+	}
+
+	retried := WithAttempt(ctx, 3)
+	if op, _ := OperationFromContext(retried); op.Attempt != 3 {
+		t.Fatalf("attempt = %d, want 3", op.Attempt)
+	}
+	if op, _ := OperationFromContext(withRequestOperation(retried, "UpdateResource", nil)); op.Attempt != 3 {
+		t.Fatalf("re-dispatch must keep the caller's attempt, got %d", op.Attempt)
+	}
+
+	fields := op.Fields()
+	if fields["resource_address"] != "postgres_table.users" || fields["dry_run"] != true {
+		t.Fatalf("unexpected fields %v", fields)
+	}
+
+	event := NewGovernanceHelper("postgres", nil).GenerateAuditEvent(retried, "mask", "users", "allowed", nil)
+	if event.Operation == nil || event.Operation.Attempt != 3 {
+		t.Fatalf("audit event must carry the operation, got %+v", event.Operation)
+	}
+
+	if _, ok := OperationFromContext(WithAttempt(context.Background(), 2)); ok {
+		t.Fatal("WithAttempt must not invent an operation")
+	}
+}
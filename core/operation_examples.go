@@ -0,0 +1,76 @@
+// Package core provides per-operation documentation examples
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/schemabounce/kolumn/sdk/types"
+)
+
+// Example categories of generated per-operation examples
+const (
+	ExampleCategoryImport  = "import"
+	ExampleCategoryDrift   = "drift"
+	ExampleCategoryDestroy = "destroy"
+)
+
+// GenerateOperationExamples builds the documentation examples for a resource type from
+// the operations it supports: the basic example, plus an import command, a drift check
+// and a destroy that takes a backup first when the resource supports those operations.
+// Each operation example carries the basic configuration it applies to and the CLI
+// invocation in Command.
+func GenerateOperationExamples(basic *ResourceExample, resourceType string, operations []string) []*ResourceExample {
+	examples := []*ResourceExample{basic}
+
+	supported := make(map[string]bool, len(operations))
+	for _, op := range operations {
+		supported[strings.ToLower(strings.TrimSpace(op))] = true
+	}
+	address := types.NewAddress("", resourceType, "example").String()
+
+	if supported[CapabilityImport] {
+		examples = append(examples, &ResourceExample{
+			Name:        "import",
+			Title:       fmt.Sprintf("Import an existing %s", resourceType),
+			Description: fmt.Sprintf("Bring a %s created outside Kolumn under management, then plan to confirm the configuration matches", resourceType),
+			Category:    ExampleCategoryImport,
+			Operation:   CapabilityImport,
+			UseCase:     fmt.Sprintf("Adopt an existing %s", resourceType),
+			HCL:         basic.HCL,
+			Command:     fmt.Sprintf("kolumn import %s <id>\nkolumn plan -target=%s", address, address),
+		})
+	}
+	if supported[CapabilityDrift] {
+		examples = append(examples, &ResourceExample{
+			Name:        "drift-check",
+			Title:       fmt.Sprintf("Check %s for drift", resourceType),
+			Description: fmt.Sprintf("Compare the live %s with its configuration and state without changing it", resourceType),
+			Category:    ExampleCategoryDrift,
+			Operation:   CapabilityDrift,
+			UseCase:     fmt.Sprintf("Detect out-of-band changes to a %s", resourceType),
+			HCL:         basic.HCL,
+			Command:     fmt.Sprintf("kolumn drift -target=%s -detailed-exitcode", address),
+		})
+	}
+	if supported[CapabilityDelete] {
+		example := &ResourceExample{
+			Name:        "destroy",
+			Title:       fmt.Sprintf("Destroy a %s", resourceType),
+			Description: fmt.Sprintf("Delete the %s and remove it from state", resourceType),
+			Category:    ExampleCategoryDestroy,
+			Operation:   CapabilityDelete,
+			UseCase:     fmt.Sprintf("Remove a %s that is no longer needed", resourceType),
+			HCL:         basic.HCL,
+			Command:     fmt.Sprintf("kolumn destroy -target=%s", address),
+		}
+		if supported[CapabilityBackup] {
+			example.Name = "destroy-with-backup"
+			example.Title = fmt.Sprintf("Destroy a %s with a backup", resourceType)
+			example.Description = fmt.Sprintf("Back up the %s, then delete it and remove it from state; the backup can restore it later", resourceType)
+			example.Command = fmt.Sprintf("kolumn destroy -target=%s -backup", address)
+		}
+		examples = append(examples, example)
+	}
+	return examples
+}
@@ -0,0 +1,38 @@
+package core
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestGenerateOperationExamples validates examples follow the operations a resource supports
+func TestGenerateOperationExamples(t *testing.T) {
+	schema := json.RawMessage(`{"properties": {"name": {"type": "string"}}, "required": ["name"]}`)
+	basic := GenerateResourceExample("create", "postgres_table", schema)
+
+	examples := GenerateOperationExamples(basic, "postgres_table", []string{"create", "read", "Import", "drift", "delete", "backup"})
+	byName := make(map[string]*ResourceExample)
+	for _, example := range examples {
+		byName[example.Name] = example
+		if example.HCL != basic.HCL {
+			t.Errorf("%s example should apply to the basic configuration", example.Name)
+		}
+	}
+	if len(examples) != 4 || byName["basic"].Operation != CapabilityCreate {
+		t.Fatalf("unexpected examples: %v", byName)
+	}
+	if got := byName["import"].Command; got != "kolumn import postgres_table.example <id>\nkolumn plan -target=postgres_table.example" {
+		t.Errorf("import command = %q", got)
+	}
+	if got := byName["drift-check"].Command; got != "kolumn drift -target=postgres_table.example -detailed-exitcode" {
+		t.Errorf("drift command = %q", got)
+	}
+	if got := byName["destroy-with-backup"].Command; got != "kolumn destroy -target=postgres_table.example -backup" {
+		t.Errorf("destroy command = %q", got)
+	}
+
+	examples = GenerateOperationExamples(basic, "postgres_table", []string{"create", "delete"})
+	if len(examples) != 2 || examples[1].Name != "destroy" || examples[1].Command != "kolumn destroy -target=postgres_table.example" {
+		t.Errorf("destroy without backup support: %+v", examples[1])
+	}
+}
@@ -0,0 +1,114 @@
+// Package core provides resource outputs and the GetOutputs function
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/schemabounce/kolumn/sdk/helpers/security"
+)
+
+// RedactedOutputValue replaces sensitive output values unless they are requested
+const RedactedOutputValue = "(sensitive)"
+
+// Output is a named value a resource publishes for other resources and for users, e.g. a
+// connection string or endpoint URL. Handlers return outputs from Create, Read and Update.
+type Output struct {
+	Value       interface{} `json:"value"`
+	Sensitive   bool        `json:"sensitive,omitempty"`
+	Description string      `json:"description,omitempty"`
+}
+
+// RedactOutputs returns a copy of outputs with sensitive values replaced
+func RedactOutputs(outputs map[string]Output) map[string]Output {
+	if outputs == nil {
+		return nil
+	}
+	redacted := make(map[string]Output, len(outputs))
+	for name, output := range outputs {
+		if output.Sensitive {
+			output.Value = RedactedOutputValue
+		}
+		redacted[name] = output
+	}
+	return redacted
+}
+
+// GetOutputsRequest is the input of the GetOutputs function
+type GetOutputsRequest struct {
+	Resources []RefreshTarget `json:"resources"`
+
+	// Names limits the outputs returned; empty returns every output
+	Names []string `json:"names,omitempty"`
+
+	// IncludeSensitive returns sensitive values instead of redacting them, e.g. when
+	// the caller resolves references between resources
+	IncludeSensitive bool `json:"include_sensitive,omitempty"`
+
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// ResourceOutputs are the outputs read from one resource
+type ResourceOutputs struct {
+	ResourceType string            `json:"resource_type"`
+	ResourceID   string            `json:"resource_id"`
+	Name         string            `json:"name"`
+	Outputs      map[string]Output `json:"outputs,omitempty"`
+	NotFound     bool              `json:"not_found,omitempty"`
+	Error        string            `json:"error,omitempty"`
+}
+
+// GetOutputsResponse is the output of the GetOutputs function
+type GetOutputsResponse struct {
+	Results []ResourceOutputs `json:"results"`
+}
+
+// handleGetOutputs reads the requested resources and returns the outputs their handlers
+// publish. Reads are authorized and gated like RefreshAll; a failed read is reported in
+// its result.
+func (d *UnifiedDispatcher) handleGetOutputs(ctx context.Context, input []byte) ([]byte, error) {
+	var req GetOutputsRequest
+	if err := security.SafeUnmarshal(input, &req); err != nil {
+		return nil, security.NewSecureError(
+			"invalid request format",
+			fmt.Sprintf("get outputs request unmarshal failed: %v", err),
+			"INVALID_REQUEST",
+		)
+	}
+
+	results := make([]ResourceOutputs, len(req.Resources))
+	forEachBounded(len(req.Resources), DefaultRefreshConcurrency, func(i int) {
+		results[i] = d.outputsOf(ctx, req.Resources[i], &req)
+	})
+	return json.Marshal(&GetOutputsResponse{Results: results})
+}
+
+func (d *UnifiedDispatcher) outputsOf(ctx context.Context, target RefreshTarget, req *GetOutputsRequest) ResourceOutputs {
+	result := ResourceOutputs{ResourceType: target.ResourceType, ResourceID: target.ResourceID, Name: target.Name}
+	read, err := d.readTarget(ctx, target, req.Metadata, nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if read.NotFound {
+		result.NotFound = true
+		return result
+	}
+
+	outputs := read.Outputs
+	if len(req.Names) > 0 {
+		wanted := stringSet(req.Names)
+		outputs = make(map[string]Output, len(req.Names))
+		for name, output := range read.Outputs {
+			if wanted[name] {
+				outputs[name] = output
+			}
+		}
+	}
+	if !req.IncludeSensitive {
+		outputs = RedactOutputs(outputs)
+	}
+	result.Outputs = outputs
+	return result
+}
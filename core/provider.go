@@ -7,8 +7,10 @@ package core
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/schemabounce/kolumn/sdk/helpers/security"
@@ -23,9 +25,15 @@ const (
 
 	// Protocol versioning (semantic versioning)
 	ProtocolVersion           = "1.0.0" // Current protocol version (semantic)
-	ProtocolVersionInt        = 1       // Legacy for go-plugin (maintain for backward compatibility)
 	MinCompatibleProtocol     = "1.0.0" // Minimum protocol version this SDK supports
 	DeprecationWarningVersion = "0.9.0" // Warn if provider uses this or older
+
+	// ProtocolVersionInt is the go-plugin handshake version, kept for backward
+	// compatibility.
+	//
+	// Deprecated: providers are no longer loaded as Go plugins; hosts query them over
+	// the CLI contract with the probe package.
+	ProtocolVersionInt = 1
 )
 
 // ProtocolVersionInfo provides detailed protocol compatibility information
@@ -65,7 +73,8 @@ type Provider interface {
 
 	// CallFunction executes a provider function with unified dispatch
 	// Supports function names: CreateResource, ReadResource, UpdateResource, DeleteResource,
-	// DiscoverResources, DiscoverDatabase, Ping
+	// DiscoverResources, DiscoverDatabase, RefreshAll, GetOutputs, GetOperationStatus,
+	// GetBuildInfo, Ping
 	CallFunction(ctx context.Context, function string, input []byte) ([]byte, error)
 
 	// Close cleans up provider resources
@@ -115,6 +124,20 @@ type Schema struct {
 	ResourceTypes      []ResourceTypeDefinition `json:"resource_types"`      // Resource types this provider manages
 	ConfigSchema       json.RawMessage          `json:"config_schema"`       // JSON schema for provider config
 
+	// Schema mixins local to this provider, referenced by ResourceTypeDefinition.Mixins
+	Mixins map[string]*SchemaMixin `json:"mixins,omitempty"`
+
+	// Feature flags gating experimental functions and resource types
+	FeatureFlags []FeatureFlag `json:"feature_flags,omitempty"`
+
+	// Backend features gating resource attributes, detected per connection
+	BackendFeatures []BackendFeature `json:"backend_features,omitempty"`
+
+	// Kolumn core versions the provider supports, checked at the Ping handshake; empty
+	// bounds are open (see CheckCoreVersion)
+	MinCoreVersion string `json:"min_core_version,omitempty"`
+	MaxCoreVersion string `json:"max_core_version,omitempty"`
+
 	// Legacy fields for backward compatibility (deprecated - use ResourceTypes instead)
 	CreateObjects   map[string]*ObjectType `json:"create_objects,omitempty"`
 	DiscoverObjects map[string]*ObjectType `json:"discover_objects,omitempty"`
@@ -126,11 +149,15 @@ type Schema struct {
 // ResourceTypeDefinition describes a resource type the provider can manage
 // This matches the core expectation exactly
 type ResourceTypeDefinition struct {
-	Name         string          `json:"name"`          // Resource type name (table, topic, bucket, etc.)
-	Description  string          `json:"description"`   // Human readable description
-	ConfigSchema json.RawMessage `json:"config_schema"` // JSON schema for resource config
-	StateSchema  json.RawMessage `json:"state_schema"`  // JSON schema for resource state
-	Operations   []string        `json:"operations"`    // Supported operations (create, read, update, delete)
+	Name         string          `json:"name"`             // Resource type name (table, topic, bucket, etc.)
+	Description  string          `json:"description"`      // Human readable description
+	ConfigSchema json.RawMessage `json:"config_schema"`    // JSON schema for resource config
+	StateSchema  json.RawMessage `json:"state_schema"`     // JSON schema for resource state
+	Operations   []string        `json:"operations"`       // Supported operations (create, read, update, delete)
+	Mixins       []string        `json:"mixins,omitempty"` // Schema mixins composed into the schemas (see SchemaMixin)
+
+	// Concurrency limits mutating operations on this type (see ConcurrencyLimiter)
+	Concurrency *ConcurrencyLimits `json:"concurrency,omitempty"`
 }
 
 // ObjectType defines a specific object type the provider supports
@@ -275,6 +302,27 @@ func (c *secureConfig) GetSanitized() map[string]interface{} {
 type UnifiedDispatcher struct {
 	createRegistry   CreateRegistry
 	discoverRegistry DiscoverRegistry
+	authorizer       *Authorizer
+	tenants          *TenantRegistry
+	accessLogger     *AccessLogger
+	tierGate         *TierGate
+	featureFlags     *FeatureFlags
+	backendFeatures  *BackendFeatures
+	scanJobs         *ScanJobs
+	slo              *SLOTracker
+	resourceDefaults *ResourceDefaults
+	maintenance      *MaintenanceSchedule
+	limiter          *ConcurrencyLimiter
+	readCache        *ReadCache
+	compression      *payloadCompression
+	serialization    *serializationCompat
+	decoders         sync.Map // resource type -> *resourceDecoder
+	minCoreVersion   string
+	maxCoreVersion   string
+
+	schemaMu   sync.Mutex
+	schemaKey  [4]string
+	schemaMemo *SchemaMemo
 }
 
 // CreateRegistry interface for create operations
@@ -297,20 +345,48 @@ func NewUnifiedDispatcher(createReg CreateRegistry, discoverReg DiscoverRegistry
 	}
 }
 
-// Dispatch handles unified function calls and routes them to appropriate registries
-func (d *UnifiedDispatcher) Dispatch(ctx context.Context, function string, input []byte) ([]byte, error) {
-	// SECURITY: Validate function name against allowed functions
-	allowedFunctions := map[string]bool{
-		"CreateResource":    true,
-		"ReadResource":      true,
-		"UpdateResource":    true,
-		"DeleteResource":    true,
-		"DiscoverResources": true,
-		"DiscoverDatabase":  true,
-		"Ping":              true,
+// Close stops the dispatcher's background work: running scan jobs and retries of their
+// final saves. Providers call it from their own Close.
+func (d *UnifiedDispatcher) Close() error {
+	if d.scanJobs != nil {
+		return d.scanJobs.Close()
 	}
+	return nil
+}
 
-	if !allowedFunctions[function] {
+// SetAuthorizer enables RBAC enforcement of resource operations
+func (d *UnifiedDispatcher) SetAuthorizer(authorizer *Authorizer) {
+	d.authorizer = authorizer
+}
+
+// dispatchFunctions are the functions Dispatch routes
+var dispatchFunctions = map[string]bool{
+	"CreateResource":     true,
+	"ReadResource":       true,
+	"UpdateResource":     true,
+	"DeleteResource":     true,
+	"DiscoverResources":  true,
+	"DiscoverDatabase":   true,
+	"RefreshAll":         true,
+	"GetOutputs":         true,
+	"GetOperationStatus": true,
+	"StartScan":          true,
+	"GetScanStatus":      true,
+	"CancelScan":         true,
+	"GetBuildInfo":       true,
+	"Ping":               true,
+}
+
+// IsDispatchFunction reports whether Dispatch routes function, e.g. so servers label
+// metrics only with known function names
+func IsDispatchFunction(function string) bool {
+	return dispatchFunctions[function]
+}
+
+// Dispatch handles unified function calls and routes them to appropriate registries
+func (d *UnifiedDispatcher) Dispatch(ctx context.Context, function string, input []byte) (output []byte, err error) {
+	// SECURITY: Validate function name against allowed functions
+	if !dispatchFunctions[function] {
 		return nil, security.NewSecureError(
 			"operation not supported",
 			fmt.Sprintf("function not allowed: %s", function),
@@ -318,300 +394,276 @@ func (d *UnifiedDispatcher) Dispatch(ctx context.Context, function string, input
 		)
 	}
 
-	// Route to appropriate handler with security validation
-	switch function {
-	case "CreateResource":
-		return d.handleCreateResource(ctx, input)
-	case "ReadResource":
-		return d.handleReadResource(ctx, input)
-	case "UpdateResource":
-		return d.handleUpdateResource(ctx, input)
-	case "DeleteResource":
-		return d.handleDeleteResource(ctx, input)
-	case "DiscoverResources":
-		return d.handleDiscoverResources(ctx, input)
-	case "DiscoverDatabase":
-		return d.handleDiscoverDatabase(ctx, input)
-	case "Ping":
-		return d.handlePing(ctx, input)
-	default:
-		// This should never be reached due to validation above
-		return nil, security.NewSecureError(
-			"operation not supported",
-			fmt.Sprintf("unexpected function: %s", function),
-			"UNEXPECTED_FUNCTION",
-		)
+	// Track the call against its service level objective
+	if d.slo != nil {
+		start := clock.Now()
+		defer func() {
+			d.slo.Observe(function, clock.Since(start), err)
+		}()
 	}
-}
 
-func (d *UnifiedDispatcher) handleCreateResource(ctx context.Context, input []byte) ([]byte, error) {
-	// SECURITY: Use safe unmarshaling with size and depth limits
-	var unifiedReq map[string]interface{}
-	if err := security.SafeUnmarshal(input, &unifiedReq); err != nil {
-		return nil, security.NewSecureError(
-			"invalid request format",
-			fmt.Sprintf("create request unmarshal failed: %v", err),
-			"INVALID_REQUEST",
-		)
+	// Decode compressed requests and compress large responses once a codec is negotiated
+	input, err = d.decompressRequest(input)
+	if err != nil {
+		return nil, err
+	}
+	if function != "Ping" {
+		defer func() {
+			if err == nil {
+				output = d.compressResponse(ctx, output)
+			}
+		}()
 	}
 
-	resourceType, ok := unifiedReq["resource_type"].(string)
-	if !ok {
-		return nil, security.NewSecureError(
-			"invalid request format",
-			"missing resource_type in request",
-			"MISSING_RESOURCE_TYPE",
-		)
+	// Adapt payloads to the field casing and legacy fields the core negotiated
+	if d.serialization != nil && function != "Ping" {
+		input, err = d.serialization.decodeRequest(input)
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			if err == nil {
+				output = d.serialization.encodeResponse(output)
+			}
+		}()
 	}
 
-	// SECURITY: Validate resource type
-	if err := security.ValidateObjectType(resourceType); err != nil {
+	// SECURITY: Carry the request tenant in ctx and reject tenant spoofing
+	ctx, err = tenantFromRequest(ctx, input)
+	if err != nil {
 		return nil, security.NewSecureError(
-			"invalid resource type",
-			fmt.Sprintf("resource type validation failed: %v", err),
-			"INVALID_RESOURCE_TYPE",
+			"tenant access denied",
+			err.Error(),
+			"TENANT_ACCESS_DENIED",
 		)
 	}
 
-	// SECURITY: Validate request configuration size
-	if config, ok := unifiedReq["config"].(map[string]interface{}); ok {
-		validator := &security.InputSizeValidator{}
-		if err := validator.ValidateConfigSize(config); err != nil {
-			return nil, security.NewSecureError(
-				"request too large",
-				fmt.Sprintf("create request config validation failed: %v", err),
-				"REQUEST_TOO_LARGE",
-			)
+	// SECURITY: Bind resource operations to the tenant's isolated state and ownership records
+	if d.tenants != nil && tenantResourceFunctions[function] {
+		exit, tenantErr := d.enterTenant(ctx, function, input)
+		if tenantErr != nil {
+			return nil, tenantErr
 		}
+		defer func() { exit(output, err) }()
 	}
 
-	// Transform unified request format to create registry format
-	createReq := map[string]interface{}{
-		"object_type": resourceType, // Transform resource_type -> object_type
-		"name":        unifiedReq["name"],
-		"config":      unifiedReq["config"],
+	// Reject functions and resource types above the caller's governance tier
+	if err := d.checkTier(ctx, function, input); err != nil {
+		return nil, err
 	}
 
-	// Include optional fields if present
-	if deps, ok := unifiedReq["dependencies"]; ok {
-		createReq["dependencies"] = deps
+	// Reject experimental functions and resource types whose flag is off
+	if err := d.checkFeatureFlags(function, input); err != nil {
+		return nil, err
 	}
-	if options, ok := unifiedReq["options"]; ok {
-		createReq["options"] = options
+
+	// SECURITY: Enforce governance roles before touching any resource
+	// (multi-type discovery, RefreshAll and GetOutputs authorize each resource type during fan-out,
+	// GetOperationStatus the operation's resource type)
+	_, isResourceOp := functionActions[function]
+	if (function == "DiscoverResources" || function == "StartScan") && isMultiTypeDiscover(input) {
+		isResourceOp = false
 	}
-	if metadata, ok := unifiedReq["metadata"]; ok {
-		createReq["metadata"] = metadata
+	if isResourceOp && d.authorizer != nil {
+		resourceType, user := requestUserContext(input)
+		if function == "DiscoverDatabase" && resourceType == "" {
+			resourceType = "database"
+		}
+		if err := d.authorizer.Authorize(user, function, resourceType); err != nil {
+			return nil, err
+		}
 	}
 
-	// Marshal the transformed request
-	transformedInput, err := json.Marshal(createReq)
-	if err != nil {
-		return nil, security.NewSecureError(
-			"request transformation failed",
-			fmt.Sprintf("failed to transform request: %v", err),
-			"TRANSFORMATION_FAILED",
-		)
+	// Hold disruptive operations until their maintenance window opens
+	if err := d.checkMaintenance(function, input); err != nil {
+		return nil, err
 	}
 
-	if d.createRegistry != nil {
-		return d.createRegistry.CallHandler(ctx, resourceType, "create", transformedInput)
+	// SECURITY: Validate run_as credential overrides before they reach any pool
+	ctx, err = identityFromRequest(ctx, d.authorizer, input)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, security.NewSecureError(
-		"registry not available",
-		fmt.Sprintf("no create registry available for resource type: %s", resourceType),
-		"REGISTRY_NOT_FOUND",
-	)
-}
+	// Let logging, retry and audit helpers see which operation they run under
+	ctx = withRequestOperation(ctx, function, input)
 
-func (d *UnifiedDispatcher) handleReadResource(ctx context.Context, input []byte) ([]byte, error) {
-	// SECURITY: Use safe unmarshaling with size and depth limits
-	var unifiedReq map[string]interface{}
-	if err := security.SafeUnmarshal(input, &unifiedReq); err != nil {
-		return nil, security.NewSecureError(
-			"invalid request format",
-			fmt.Sprintf("read request unmarshal failed: %v", err),
-			"INVALID_REQUEST",
-		)
+	// Serialize mutating operations per the resource type's declared concurrency
+	if function == "CreateResource" || function == "UpdateResource" || function == "DeleteResource" {
+		release, err := d.acquireConcurrency(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
 	}
 
-	resourceType, ok := unifiedReq["resource_type"].(string)
-	if !ok {
-		return nil, security.NewSecureError(
-			"invalid request format",
-			"missing resource_type in request",
-			"MISSING_RESOURCE_TYPE",
-		)
+	// Time resource operations and collect backend identifiers for response metadata
+	if responseMetadataFunctions[function] {
+		var recorder *responseRecorder
+		ctx, recorder = withResponseRecorder(ctx)
+		start := clock.Now()
+		defer func() {
+			if err == nil {
+				output = recorder.attach(output, clock.Since(start))
+			}
+		}()
 	}
 
-	// SECURITY: Validate resource type
-	if err := security.ValidateObjectType(resourceType); err != nil {
+	// Route to appropriate handler with security validation
+	switch function {
+	case "CreateResource":
+		output, err := d.handleCreateResource(ctx, input)
+		d.invalidateReads(input, err)
+		return output, err
+	case "ReadResource":
+		output, err := d.handleReadResource(ctx, input)
+		d.recordAccess(ctx, function, input, output, err)
+		return output, err
+	case "UpdateResource":
+		output, err := d.handleUpdateResource(ctx, input)
+		d.invalidateReads(input, err)
+		return output, err
+	case "DeleteResource":
+		output, err := d.handleDeleteResource(ctx, input)
+		d.invalidateReads(input, err)
+		return output, err
+	case "DiscoverResources":
+		return d.handleDiscoverResources(ctx, input)
+	case "DiscoverDatabase":
+		return d.handleDiscoverDatabase(ctx, input)
+	case "RefreshAll":
+		return d.handleRefreshAll(ctx, input)
+	case "GetOutputs":
+		return d.handleGetOutputs(ctx, input)
+	case "GetOperationStatus":
+		return d.handleGetOperationStatus(ctx, input)
+	case "StartScan":
+		return d.handleStartScan(ctx, input)
+	case "GetScanStatus":
+		return d.handleGetScanStatus(ctx, input)
+	case "CancelScan":
+		return d.handleCancelScan(ctx, input)
+	case "GetBuildInfo":
+		return json.Marshal(GetBuildInfo())
+	case "Ping":
+		return d.handlePing(ctx, input)
+	default:
+		// This should never be reached due to validation above
 		return nil, security.NewSecureError(
-			"invalid resource type",
-			fmt.Sprintf("resource type validation failed: %v", err),
-			"INVALID_RESOURCE_TYPE",
+			"operation not supported",
+			fmt.Sprintf("unexpected function: %s", function),
+			"UNEXPECTED_FUNCTION",
 		)
 	}
+}
 
-	// Transform unified request format to create registry format
-	readReq := map[string]interface{}{
-		"object_type": resourceType, // Transform resource_type -> object_type
-		"resource_id": unifiedReq["resource_id"],
-		"name":        unifiedReq["name"],
-	}
-
-	transformedInput, err := json.Marshal(readReq)
+func (d *UnifiedDispatcher) handleCreateResource(ctx context.Context, input []byte) ([]byte, error) {
+	req, err := d.decodeResourceRequest(input, "create", true)
 	if err != nil {
-		return nil, security.NewSecureError(
-			"request transformation failed",
-			fmt.Sprintf("failed to transform request: %v", err),
-			"TRANSFORMATION_FAILED",
-		)
+		return nil, err
 	}
-
-	if d.createRegistry != nil {
-		return d.createRegistry.CallHandler(ctx, resourceType, "read", transformedInput)
+	defer req.release()
+	if d.resourceDefaults != nil {
+		if err := d.applyResourceDefaults(req); err != nil {
+			return nil, err
+		}
+	}
+	if err := d.checkBackendFeatures(req); err != nil {
+		return nil, err
 	}
 
-	return nil, security.NewSecureError(
-		"registry not available",
-		fmt.Sprintf("no create registry available for resource type: %s", resourceType),
-		"REGISTRY_NOT_FOUND",
-	)
+	// Transform unified request format to create registry format
+	return d.callCreateRegistry(ctx, req.resourceType, "create", req.registryInput(createRegistryFields))
 }
 
-func (d *UnifiedDispatcher) handleUpdateResource(ctx context.Context, input []byte) ([]byte, error) {
-	// SECURITY: Use safe unmarshaling with size and depth limits
-	var unifiedReq map[string]interface{}
-	if err := security.SafeUnmarshal(input, &unifiedReq); err != nil {
-		return nil, security.NewSecureError(
-			"invalid request format",
-			fmt.Sprintf("update request unmarshal failed: %v", err),
-			"INVALID_REQUEST",
-		)
+func (d *UnifiedDispatcher) handleReadResource(ctx context.Context, input []byte) ([]byte, error) {
+	req, err := d.decodeResourceRequest(input, "read", false)
+	if err != nil {
+		return nil, err
 	}
+	defer req.release()
+	resourceType := req.resourceType
 
-	resourceType, ok := unifiedReq["resource_type"].(string)
-	if !ok {
+	var consistency *ReadConsistency
+	if raw, ok := req.fields["consistency"]; ok {
+		if err := json.Unmarshal(raw, &consistency); err != nil {
+			return nil, security.NewSecureError(
+				"invalid read consistency",
+				err.Error(),
+				"INVALID_CONSISTENCY",
+			)
+		}
+	}
+	if err := consistency.Validate(); err != nil {
 		return nil, security.NewSecureError(
-			"invalid request format",
-			"missing resource_type in request",
-			"MISSING_RESOURCE_TYPE",
+			"invalid read consistency",
+			err.Error(),
+			"INVALID_CONSISTENCY",
 		)
 	}
 
-	// SECURITY: Validate resource type
-	if err := security.ValidateObjectType(resourceType); err != nil {
-		return nil, security.NewSecureError(
-			"invalid resource type",
-			fmt.Sprintf("resource type validation failed: %v", err),
-			"INVALID_RESOURCE_TYPE",
-		)
+	// Serve reads that accept cached data without touching the backend
+	var cacheKey string
+	if d.readCache != nil {
+		var resourceID, name interface{}
+		_ = json.Unmarshal(req.fields["resource_id"], &resourceID)
+		_ = json.Unmarshal(req.fields["name"], &name)
+		cacheKey = readCacheKey(ctx, input, resourceID, name)
+		if output, ok := d.cachedRead(resourceType, cacheKey, consistency); ok {
+			return output, nil
+		}
 	}
 
-	// SECURITY: Validate request configuration size
-	if config, ok := unifiedReq["config"].(map[string]interface{}); ok {
-		validator := &security.InputSizeValidator{}
-		if err := validator.ValidateConfigSize(config); err != nil {
+	// The handler receives the validated consistency rather than the raw value
+	delete(req.fields, "consistency")
+	if consistency != nil {
+		normalized, err := json.Marshal(consistency)
+		if err != nil {
 			return nil, security.NewSecureError(
-				"request too large",
-				fmt.Sprintf("update request config validation failed: %v", err),
-				"REQUEST_TOO_LARGE",
+				"request transformation failed",
+				fmt.Sprintf("failed to transform request: %v", err),
+				"TRANSFORMATION_FAILED",
 			)
 		}
+		req.fields["consistency"] = normalized
 	}
 
-	// Transform unified request format to create registry format
-	updateReq := map[string]interface{}{
-		"object_type": resourceType, // Transform resource_type -> object_type
-		"resource_id": unifiedReq["resource_id"],
-		"name":        unifiedReq["name"],
-		"config":      unifiedReq["config"],
-	}
-
-	// Include optional fields if present
-	if currentState, ok := unifiedReq["current_state"]; ok {
-		updateReq["current_state"] = currentState
-	}
-	if options, ok := unifiedReq["options"]; ok {
-		updateReq["options"] = options
+	output, err := d.callCreateRegistry(ctx, resourceType, "read", req.registryInput(readRegistryFields))
+	if err == nil && d.readCache != nil {
+		d.readCache.Put(resourceType, cacheKey, output)
 	}
+	return output, err
+}
 
-	transformedInput, err := json.Marshal(updateReq)
+func (d *UnifiedDispatcher) handleUpdateResource(ctx context.Context, input []byte) ([]byte, error) {
+	req, err := d.decodeResourceRequest(input, "update", true)
 	if err != nil {
-		return nil, security.NewSecureError(
-			"request transformation failed",
-			fmt.Sprintf("failed to transform request: %v", err),
-			"TRANSFORMATION_FAILED",
-		)
+		return nil, err
 	}
-
-	if d.createRegistry != nil {
-		return d.createRegistry.CallHandler(ctx, resourceType, "update", transformedInput)
+	defer req.release()
+	if err := d.checkBackendFeatures(req); err != nil {
+		return nil, err
 	}
 
-	return nil, security.NewSecureError(
-		"registry not available",
-		fmt.Sprintf("no create registry available for resource type: %s", resourceType),
-		"REGISTRY_NOT_FOUND",
-	)
+	// Transform unified request format to create registry format
+	return d.callCreateRegistry(ctx, req.resourceType, "update", req.registryInput(updateRegistryFields))
 }
 
 func (d *UnifiedDispatcher) handleDeleteResource(ctx context.Context, input []byte) ([]byte, error) {
-	// SECURITY: Use safe unmarshaling with size and depth limits
-	var unifiedReq map[string]interface{}
-	if err := security.SafeUnmarshal(input, &unifiedReq); err != nil {
-		return nil, security.NewSecureError(
-			"invalid request format",
-			fmt.Sprintf("delete request unmarshal failed: %v", err),
-			"INVALID_REQUEST",
-		)
-	}
-
-	resourceType, ok := unifiedReq["resource_type"].(string)
-	if !ok {
-		return nil, security.NewSecureError(
-			"invalid request format",
-			"missing resource_type in request",
-			"MISSING_RESOURCE_TYPE",
-		)
-	}
-
-	// SECURITY: Validate resource type
-	if err := security.ValidateObjectType(resourceType); err != nil {
-		return nil, security.NewSecureError(
-			"invalid resource type",
-			fmt.Sprintf("resource type validation failed: %v", err),
-			"INVALID_RESOURCE_TYPE",
-		)
+	req, err := d.decodeResourceRequest(input, "delete", false)
+	if err != nil {
+		return nil, err
 	}
+	defer req.release()
 
 	// Transform unified request format to create registry format
-	deleteReq := map[string]interface{}{
-		"object_type": resourceType, // Transform resource_type -> object_type
-		"resource_id": unifiedReq["resource_id"],
-		"name":        unifiedReq["name"],
-	}
-
-	// Include optional fields if present
-	if state, ok := unifiedReq["state"]; ok {
-		deleteReq["state"] = state
-	}
-	if options, ok := unifiedReq["options"]; ok {
-		deleteReq["options"] = options
-	}
-
-	transformedInput, err := json.Marshal(deleteReq)
-	if err != nil {
-		return nil, security.NewSecureError(
-			"request transformation failed",
-			fmt.Sprintf("failed to transform request: %v", err),
-			"TRANSFORMATION_FAILED",
-		)
-	}
+	return d.callCreateRegistry(ctx, req.resourceType, "delete", req.registryInput(deleteRegistryFields))
+}
 
+// callCreateRegistry passes a transformed request to the create registry
+func (d *UnifiedDispatcher) callCreateRegistry(ctx context.Context, resourceType, method string, input []byte) ([]byte, error) {
 	if d.createRegistry != nil {
-		return d.createRegistry.CallHandler(ctx, resourceType, "delete", transformedInput)
+		return d.createRegistry.CallHandler(ctx, resourceType, method, input)
 	}
 
 	return nil, security.NewSecureError(
@@ -632,6 +684,16 @@ func (d *UnifiedDispatcher) handleDiscoverResources(ctx context.Context, input [
 		)
 	}
 
+	// Multiple object types or a "*" wildcard fan out to every matching handler
+	var typesReq discoverTypesRequest
+	if err := json.Unmarshal(input, &typesReq); err == nil {
+		if types, multi := typesReq.requestedTypes(); multi {
+			return d.discoverMultiple(ctx, input, &typesReq, types)
+		} else if len(types) == 1 {
+			unifiedReq["resource_type"] = types[0]
+		}
+	}
+
 	resourceType, ok := unifiedReq["resource_type"].(string)
 	if !ok {
 		return nil, security.NewSecureError(
@@ -689,6 +751,13 @@ func (d *UnifiedDispatcher) handlePing(ctx context.Context, input []byte) ([]byt
 		"success": true,
 		"status":  "healthy",
 	}
+	if err := d.pingCoreVersion(input, response); err != nil {
+		return nil, err
+	}
+	if err := d.pingSerialization(input, response); err != nil {
+		return nil, err
+	}
+	d.pingCompression(ctx, input, response)
 	return json.Marshal(response)
 }
 
@@ -777,6 +846,9 @@ func (d *UnifiedDispatcher) BuildCompatibleSchema(name, version, providerType, d
 		Type:         providerType,
 		Description:  description,
 		ConfigSchema: json.RawMessage(`{}`), // Basic config schema
+
+		MinCoreVersion: d.minCoreVersion,
+		MaxCoreVersion: d.maxCoreVersion,
 	}
 
 	// Build supported functions
@@ -1054,6 +1126,7 @@ type ProviderDocumentation struct {
 	DiscoverObjects map[string]*ObjectDocumentation `json:"discover_objects"`
 	Examples        []*ProviderExample              `json:"examples,omitempty"`
 	Links           []DocumentationLink             `json:"links,omitempty"`
+	Governance      *GovernanceCapabilities         `json:"governance,omitempty"` // for the capabilities section
 }
 
 // ObjectDocumentation contains object-specific documentation
@@ -1077,6 +1150,7 @@ type HealthStatus struct {
 	Uptime    time.Duration          `json:"uptime"`
 	Checks    map[string]CheckResult `json:"checks,omitempty"`
 	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+	Warnings  []string               `json:"warnings,omitempty"` // e.g. error budgets running out
 }
 
 // CheckResult represents the result of a health check
@@ -1117,7 +1191,14 @@ func (s *Schema) ValidateConfig(config map[string]interface{}) *ConfigValidation
 	}
 
 	// Convert ResourceTypeDefinition config schemas to validation rules
-	for _, resourceType := range s.ResourceTypes {
+	resourceTypes, err := s.ComposedResourceTypes()
+	if err != nil {
+		result := validator.Validate(config)
+		result.Valid = false
+		result.Errors = append(result.Errors, FieldError{Field: "resource_types", Error: err.Error(), Severity: "error"})
+		return result
+	}
+	for _, resourceType := range resourceTypes {
 		rules := s.convertResourceTypeToValidationRules(resourceType)
 		validator.AddRules(rules)
 	}
@@ -1125,6 +1206,62 @@ func (s *Schema) ValidateConfig(config map[string]interface{}) *ConfigValidation
 	return validator.Validate(config)
 }
 
+// ValidateProviderConfig validates a provider configuration against ConfigSchema, the
+// JSON schema of the provider block. Unlike ValidateConfig it ignores resource type
+// schemas, which describe resource requests rather than provider settings.
+func (s *Schema) ValidateProviderConfig(config map[string]interface{}) *ConfigValidationResult {
+	validator := NewValidator(s.Name)
+	schema, err := parseConfigExampleSchema(s.ConfigSchema)
+	if err != nil {
+		result := validator.Validate(config)
+		result.Valid = false
+		result.Errors = append(result.Errors, FieldError{Field: "config_schema", Error: err.Error(), Severity: "error"})
+		return result
+	}
+
+	required := stringSet(schema.Required)
+	for _, name := range sortedStringKeys(schema.Properties) {
+		prop := schema.Properties[name]
+		rule := ConfigValidationRule{
+			Field:       name,
+			Required:    required[name],
+			Type:        validationRuleType(prop.Type),
+			Description: prop.Description,
+		}
+		for _, v := range prop.Enum {
+			rule.Enum = append(rule.Enum, fmt.Sprintf("%v", v))
+		}
+		validator.AddRule(rule)
+		delete(required, name)
+	}
+	// required settings without a property declaration
+	for _, name := range sortedBoolKeys(required) {
+		validator.AddRule(ConfigValidationRule{Field: name, Required: true})
+	}
+	return validator.Validate(config)
+}
+
+// validationRuleType maps a JSON schema type to a ConfigValidationRule type; types the
+// validator cannot check map to "" and are not type checked
+func validationRuleType(schemaType interface{}) string {
+	switch schemaType {
+	case "string":
+		return "string"
+	case "integer":
+		return "int"
+	case "number":
+		return "float"
+	case "boolean":
+		return "bool"
+	case "array":
+		return "slice"
+	case "object":
+		return "map"
+	default:
+		return ""
+	}
+}
+
 // convertPropertyToValidationRule converts a Property to a ConfigValidationRule
 func (s *Schema) convertPropertyToValidationRule(objType, propName string, prop *Property) ConfigValidationRule {
 	rule := ConfigValidationRule{
@@ -1161,6 +1298,10 @@ func (s *Schema) convertPropertyToValidationRule(objType, propName string, prop
 		}
 	}
 
+	if prop.Enhanced != nil {
+		rule.DocLinks = append([]string(nil), prop.Enhanced.DocLinks...)
+	}
+
 	// Use enhanced validation if available
 	if prop.Enhanced != nil && len(prop.Enhanced.Rules) > 0 {
 		// Use the first enhanced rule as the primary rule
@@ -1175,6 +1316,7 @@ func (s *Schema) convertPropertyToValidationRule(objType, propName string, prop
 		rule.Suggestion = enhancedRule.Suggestion
 		rule.Example = enhancedRule.Example
 		rule.Custom = enhancedRule.Custom
+		rule.DocLinks = append(rule.DocLinks, enhancedRule.DocLinks...)
 	}
 
 	return rule
@@ -1305,6 +1447,10 @@ type BaseProvider struct {
 	schema    *Schema
 	config    map[string]interface{}
 	validator *Validator
+	tenants   *TenantRegistry
+	limiter   *ConcurrencyLimiter
+	probe     *ProbeResult
+	tunnel    BackendTunnel
 }
 
 // NewBaseProvider creates a new base provider instance
@@ -1314,9 +1460,10 @@ func NewBaseProvider(name string) *BaseProvider {
 	}
 }
 
-// SetSchema sets the provider schema
+// SetSchema sets the provider schema and the concurrency limits its resource types declare
 func (bp *BaseProvider) SetSchema(schema *Schema) {
 	bp.schema = schema
+	bp.limiter = NewConcurrencyLimiter(schema)
 }
 
 // GetSchema returns the provider schema (for use in internal validation)
@@ -1415,3 +1562,60 @@ func (bp *BaseProvider) GetConfig() map[string]interface{} {
 func (bp *BaseProvider) GetValidator() *Validator {
 	return bp.validator
 }
+
+// EnableMultiTenancy isolates configuration, connection pools and metrics per tenant.
+// factory opens a tenant's pool on first use and may be nil for providers without pools.
+func (bp *BaseProvider) EnableMultiTenancy(factory TenantPoolFactory) {
+	bp.tenants = NewTenantRegistry(factory)
+}
+
+// Tenants returns the tenant registry, or nil when multi-tenancy is disabled
+func (bp *BaseProvider) Tenants() *TenantRegistry {
+	return bp.tenants
+}
+
+// ConfigureTenant validates and stores a tenant's configuration
+func (bp *BaseProvider) ConfigureTenant(ctx context.Context, tenantID string, config map[string]interface{}) error {
+	if bp.tenants == nil {
+		return fmt.Errorf("multi-tenancy is not enabled")
+	}
+
+	var result *ConfigValidationResult
+	if bp.schema != nil {
+		result = bp.schema.ValidateProviderConfig(config)
+	} else {
+		result = bp.validator.Validate(config)
+	}
+	if result != nil && !result.Valid {
+		messages := make([]string, 0, len(result.Errors))
+		for _, fe := range result.Errors {
+			messages = append(messages, fmt.Sprintf("%s: %s", fe.Field, fe.Error))
+		}
+		return fmt.Errorf("invalid configuration for tenant %q: %s", tenantID, strings.Join(messages, "; "))
+	}
+
+	return bp.tenants.Configure(tenantID, config)
+}
+
+// Tenant returns the isolated state for the tenant carried in ctx
+func (bp *BaseProvider) Tenant(ctx context.Context) (*Tenant, error) {
+	if bp.tenants == nil {
+		return nil, fmt.Errorf("multi-tenancy is not enabled")
+	}
+	return bp.tenants.Tenant(ctx)
+}
+
+// Close releases resources held by the base provider: the backend tunnel and every
+// tenant pool
+func (bp *BaseProvider) Close() error {
+	var errs []error
+	if err := bp.closeTunnel(); err != nil {
+		errs = append(errs, err)
+	}
+	if bp.tenants != nil {
+		if err := bp.tenants.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
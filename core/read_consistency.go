@@ -0,0 +1,275 @@
+// Package core provides per-request read consistency and the dispatcher read cache
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Read consistency modes
+const (
+	// ReadStrong always reads from the backend; the default for requests without a mode
+	ReadStrong = "strong"
+
+	// ReadCached serves a cached read younger than the cache TTL (or MaxAge when smaller)
+	ReadCached = "cached"
+
+	// ReadStaleOK serves any cached read younger than MaxAge, even past the cache TTL;
+	// MaxAge zero accepts any age
+	ReadStaleOK = "stale_ok"
+)
+
+// DefaultReadCacheTTL is how long a cached read satisfies ReadCached requests
+const DefaultReadCacheTTL = 30 * time.Second
+
+// ReadConsistency selects how fresh a read must be. Plans typically ask for cached
+// reads; imports and applies must use strong reads so they act on the backend's
+// current state.
+//
+// On the wire the max age is either a Go duration string or a number of seconds:
+//
+//	{"mode": "stale_ok", "max_age": "10m"}
+//	{"mode": "stale_ok", "max_age_seconds": 600}
+type ReadConsistency struct {
+	Mode   string
+	MaxAge time.Duration
+}
+
+// readConsistencyJSON is the wire form of ReadConsistency
+type readConsistencyJSON struct {
+	Mode          string          `json:"mode"`
+	MaxAge        json.RawMessage `json:"max_age,omitempty"`
+	MaxAgeSeconds *float64        `json:"max_age_seconds,omitempty"`
+}
+
+// UnmarshalJSON reads max_age as a duration string such as "30s", or max_age_seconds
+// as a number of seconds. A bare number in max_age is rejected since its unit would be
+// ambiguous.
+func (c *ReadConsistency) UnmarshalJSON(data []byte) error {
+	var wire readConsistencyJSON
+	if err := json.Unmarshal(data, &wire); err != nil {
+		return err
+	}
+	*c = ReadConsistency{Mode: wire.Mode}
+	hasMaxAge := len(wire.MaxAge) > 0 && string(wire.MaxAge) != "null"
+	if hasMaxAge {
+		var s string
+		if err := json.Unmarshal(wire.MaxAge, &s); err != nil {
+			return fmt.Errorf(`read consistency max_age must be a duration such as "30s"; use max_age_seconds for a number of seconds`)
+		}
+		maxAge, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("invalid read consistency max_age: %w", err)
+		}
+		c.MaxAge = maxAge
+	}
+	if wire.MaxAgeSeconds != nil {
+		if hasMaxAge {
+			return fmt.Errorf("read consistency accepts max_age or max_age_seconds, not both")
+		}
+		c.MaxAge = time.Duration(*wire.MaxAgeSeconds * float64(time.Second))
+	}
+	return nil
+}
+
+// MarshalJSON writes max_age as a duration string
+func (c ReadConsistency) MarshalJSON() ([]byte, error) {
+	wire := struct {
+		Mode   string `json:"mode"`
+		MaxAge string `json:"max_age,omitempty"`
+	}{Mode: c.Mode}
+	if c.MaxAge != 0 {
+		wire.MaxAge = c.MaxAge.String()
+	}
+	return json.Marshal(wire)
+}
+
+// Validate checks the mode and max age
+func (c *ReadConsistency) Validate() error {
+	if c == nil {
+		return nil
+	}
+	switch c.Mode {
+	case "", ReadStrong, ReadCached, ReadStaleOK:
+	default:
+		return fmt.Errorf("unknown read consistency %q, expected %s, %s or %s", c.Mode, ReadStrong, ReadCached, ReadStaleOK)
+	}
+	if c.MaxAge < 0 {
+		return fmt.Errorf("read consistency max_age must not be negative")
+	}
+	return nil
+}
+
+// ConsistencyMode returns the requested mode, ReadStrong when none was given
+func (r *ReadRequest) ConsistencyMode() string {
+	if r == nil || r.Consistency == nil || r.Consistency.Mode == "" {
+		return ReadStrong
+	}
+	return r.Consistency.Mode
+}
+
+// ReadCacheStats reports cache effectiveness
+type ReadCacheStats struct {
+	Hits          int64 `json:"hits"`
+	Misses        int64 `json:"misses"`
+	Entries       int   `json:"entries"`
+	Invalidations int64 `json:"invalidations"`
+}
+
+type readCacheEntry struct {
+	output []byte
+	stored time.Time
+}
+
+// ReadCache keeps ReadResource responses so requests that accept cached or stale reads
+// skip the backend. Entries are keyed by tenant, identity and resource, and every
+// entry of a resource type is dropped when a resource of that type is created,
+// updated or deleted through the dispatcher.
+type ReadCache struct {
+	ttl time.Duration
+
+	mu            sync.Mutex
+	entries       map[string]map[string]*readCacheEntry // resource type -> key -> entry
+	hits          int64
+	misses        int64
+	invalidations int64
+}
+
+// NewReadCache creates a cache; ttl <= 0 uses DefaultReadCacheTTL
+func NewReadCache(ttl time.Duration) *ReadCache {
+	if ttl <= 0 {
+		ttl = DefaultReadCacheTTL
+	}
+	return &ReadCache{ttl: ttl, entries: make(map[string]map[string]*readCacheEntry)}
+}
+
+// Get returns a cached read satisfying consistency. Strong reads never hit.
+func (c *ReadCache) Get(resourceType, key string, consistency *ReadConsistency) ([]byte, time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[resourceType][key]
+	if !ok || !c.acceptable(entry, consistency) {
+		c.misses++
+		return nil, time.Time{}, false
+	}
+	c.hits++
+	return entry.output, entry.stored, true
+}
+
+func (c *ReadCache) acceptable(entry *readCacheEntry, consistency *ReadConsistency) bool {
+	if consistency == nil {
+		return false
+	}
+	age := clock.Since(entry.stored)
+	switch consistency.Mode {
+	case ReadCached:
+		limit := c.ttl
+		if consistency.MaxAge > 0 && consistency.MaxAge < limit {
+			limit = consistency.MaxAge
+		}
+		return age < limit
+	case ReadStaleOK:
+		return consistency.MaxAge == 0 || age <= consistency.MaxAge
+	}
+	return false
+}
+
+// Put stores a read response
+func (c *ReadCache) Put(resourceType, key string, output []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	byKey := c.entries[resourceType]
+	if byKey == nil {
+		byKey = make(map[string]*readCacheEntry)
+		c.entries[resourceType] = byKey
+	}
+	byKey[key] = &readCacheEntry{output: output, stored: clock.Now()}
+}
+
+// InvalidateType drops every cached read of a resource type
+func (c *ReadCache) InvalidateType(resourceType string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries[resourceType]) > 0 {
+		c.invalidations++
+	}
+	delete(c.entries, resourceType)
+}
+
+// Invalidate drops every cached read
+func (c *ReadCache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) > 0 {
+		c.invalidations++
+	}
+	c.entries = make(map[string]map[string]*readCacheEntry)
+}
+
+// Stats returns hit, miss and invalidation counts
+func (c *ReadCache) Stats() ReadCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entries := 0
+	for _, byKey := range c.entries {
+		entries += len(byKey)
+	}
+	return ReadCacheStats{Hits: c.hits, Misses: c.misses, Entries: entries, Invalidations: c.invalidations}
+}
+
+// SetReadCache enables caching of ReadResource responses for requests that ask for
+// cached or stale reads
+func (d *UnifiedDispatcher) SetReadCache(cache *ReadCache) {
+	d.readCache = cache
+}
+
+// readCacheKey scopes a read to the tenant, effective identity and user that made it,
+// since masking and credentials can change what a read returns
+func readCacheKey(ctx context.Context, input []byte, resourceID, name interface{}) string {
+	tenant, _ := TenantFromContext(ctx)
+	_, user := requestUserContext(input)
+	userID := ""
+	if user != nil {
+		userID = user.UserID
+	}
+	return fmt.Sprintf("%s\x00%s\x00%s\x00%v\x00%v", tenant, IdentityFromContext(ctx).Key(), userID, resourceID, name)
+}
+
+// cachedRead returns a cached response marked with cached_at when consistency allows it
+func (d *UnifiedDispatcher) cachedRead(resourceType, key string, consistency *ReadConsistency) ([]byte, bool) {
+	if d.readCache == nil {
+		return nil, false
+	}
+	output, stored, ok := d.readCache.Get(resourceType, key, consistency)
+	if !ok {
+		return nil, false
+	}
+	var resp map[string]interface{}
+	if err := json.Unmarshal(output, &resp); err != nil {
+		return nil, false
+	}
+	resp["cached_at"] = stored.UTC()
+	marked, err := json.Marshal(resp)
+	if err != nil {
+		return nil, false
+	}
+	return marked, true
+}
+
+// invalidateReads drops cached reads after a successful mutation of resourceType
+func (d *UnifiedDispatcher) invalidateReads(input []byte, err error) {
+	if d.readCache == nil || err != nil {
+		return
+	}
+	var req struct {
+		ResourceType string `json:"resource_type"`
+	}
+	if json.Unmarshal(input, &req) == nil && req.ResourceType != "" {
+		d.readCache.InvalidateType(req.ResourceType)
+	}
+}
@@ -0,0 +1,119 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/types"
+)
+
+// countingRegistry answers reads with a counter so cache hits are observable
+type countingRegistry struct {
+	reads       int
+	consistency []string
+}
+
+func (r *countingRegistry) GetObjectTypes() map[string]*ObjectType { return nil }
+
+func (r *countingRegistry) CallHandler(ctx context.Context, objectType, method string, input []byte) ([]byte, error) {
+	if method != "read" {
+		return json.Marshal(map[string]interface{}{"success": true})
+	}
+	var req ReadRequest
+	_ = json.Unmarshal(input, &req)
+	r.reads++
+	r.consistency = append(r.consistency, req.ConsistencyMode())
+	return json.Marshal(ReadResponse{State: map[string]interface{}{"reads": r.reads}})
+}
+
+// TestReadConsistency validates strong, cached and stale-ok reads against the cache
+func TestReadConsistency(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	defer SetClock(types.ClockFunc(func() time.Time { return now }))()
+
+	registry := &countingRegistry{}
+	d := NewUnifiedDispatcher(registry, nil)
+	d.SetReadCache(NewReadCache(time.Minute))
+
+	read := func(consistency string) ReadResponse {
+		t.Helper()
+		input := `{"resource_type":"table","resource_id":"t1"` + consistency + `}`
+		out, err := d.Dispatch(context.Background(), "ReadResource", []byte(input))
+		if err != nil {
+			t.Fatalf("ReadResource: %v", err)
+		}
+		var resp ReadResponse
+		if err := json.Unmarshal(out, &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	read("") // strong by default, fills the cache
+	if resp := read(`,"consistency":{"mode":"cached"}`); resp.CachedAt == nil || registry.reads != 1 {
+		t.Fatalf("cached read must be served from cache, backend reads = %d", registry.reads)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if read(`,"consistency":{"mode":"cached"}`); registry.reads != 2 {
+		t.Fatalf("cached read past the TTL must hit the backend, reads = %d", registry.reads)
+	}
+
+	now = now.Add(5 * time.Minute)
+	if resp := read(`,"consistency":{"mode":"stale_ok","max_age":"10m"}`); resp.CachedAt == nil || registry.reads != 2 {
+		t.Fatalf("stale read within max age must be served from cache, reads = %d", registry.reads)
+	}
+	if read(`,"consistency":{"mode":"strong"}`); registry.reads != 3 {
+		t.Fatalf("strong read must bypass the cache, reads = %d", registry.reads)
+	}
+	if got := registry.consistency[len(registry.consistency)-1]; got != ReadStrong {
+		t.Fatalf("handler saw consistency %q, want strong", got)
+	}
+
+	if _, err := d.Dispatch(context.Background(), "UpdateResource", []byte(`{"resource_type":"table","name":"t1","config":{}}`)); err != nil {
+		t.Fatalf("UpdateResource: %v", err)
+	}
+	if read(`,"consistency":{"mode":"stale_ok"}`); registry.reads != 4 {
+		t.Fatalf("updates must invalidate cached reads, reads = %d", registry.reads)
+	}
+
+	if _, err := d.Dispatch(context.Background(), "ReadResource", []byte(`{"resource_type":"table","consistency":{"mode":"eventual"}}`)); err == nil {
+		t.Fatal("unknown consistency modes must be rejected")
+	}
+	for _, consistency := range []string{`"cached"`, `{"mode":7}`, `[]`,
+		`{"mode":"stale_ok","max_age":600}`, `{"mode":"stale_ok","max_age":"ten minutes"}`,
+		`{"mode":"stale_ok","max_age":"10m","max_age_seconds":600}`, `{"mode":"stale_ok","max_age_seconds":-1}`} {
+		input := `{"resource_type":"table","resource_id":"t1","consistency":` + consistency + `}`
+		if _, err := d.Dispatch(context.Background(), "ReadResource", []byte(input)); err == nil {
+			t.Fatalf("malformed consistency %s must be rejected", consistency)
+		}
+	}
+}
+
+// TestReadConsistencyJSON validates the max age units accepted on the wire
+func TestReadConsistencyJSON(t *testing.T) {
+	for input, want := range map[string]time.Duration{
+		`{"mode":"stale_ok"}`:                          0,
+		`{"mode":"stale_ok","max_age":"90s"}`:          90 * time.Second,
+		`{"mode":"stale_ok","max_age_seconds":600}`:    10 * time.Minute,
+		`{"mode":"stale_ok","max_age_seconds":0.25}`:   250 * time.Millisecond,
+		`{"mode":"stale_ok","max_age":null}`:           0,
+		`{"mode":"cached","max_age":"1h30m","x":true}`: 90 * time.Minute,
+	} {
+		var c ReadConsistency
+		if err := json.Unmarshal([]byte(input), &c); err != nil || c.MaxAge != want {
+			t.Errorf("%s: max age = %s, %v, want %s", input, c.MaxAge, err, want)
+		}
+	}
+
+	out, err := json.Marshal(&ReadConsistency{Mode: ReadStaleOK, MaxAge: 10 * time.Minute})
+	if err != nil || string(out) != `{"mode":"stale_ok","max_age":"10m0s"}` {
+		t.Errorf("Marshal = %s, %v", out, err)
+	}
+	var roundTrip ReadConsistency
+	if err := json.Unmarshal(out, &roundTrip); err != nil || roundTrip.MaxAge != 10*time.Minute {
+		t.Errorf("round trip = %+v, %v", roundTrip, err)
+	}
+}
@@ -0,0 +1,141 @@
+// Package core provides validation of reference expressions against resource schemas
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/schemabounce/kolumn/sdk/types"
+)
+
+// ReferenceError reports a reference the target resource type cannot satisfy
+type ReferenceError struct {
+	Reference  types.Reference
+	Reason     string
+	Suggestion string // closest valid attribute, when one is near
+}
+
+func (e *ReferenceError) Error() string {
+	msg := fmt.Sprintf("invalid reference %s: %s", e.Reference, e.Reason)
+	if e.Suggestion != "" {
+		msg += fmt.Sprintf(" (did you mean %q?)", e.Suggestion)
+	}
+	return msg
+}
+
+// referenceSchema is the subset of a JSON schema needed to follow an attribute path
+type referenceSchema struct {
+	Type       interface{}                 `json:"type"`
+	Properties map[string]*referenceSchema `json:"properties"`
+	Items      *referenceSchema            `json:"items"`
+}
+
+// scalar reports whether the schema only allows values without nested attributes
+func (r *referenceSchema) scalar() bool {
+	switch t := r.Type.(type) {
+	case string:
+		return t != "object" && t != "array"
+	case []interface{}:
+		for _, item := range t {
+			if item == "object" || item == "array" {
+				return false
+			}
+		}
+		return len(t) > 0
+	}
+	return false
+}
+
+// ValidateReference checks that the referenced resource type exists and exports the
+// referenced attribute path. Attributes are looked up in the state schema, then in the
+// config schema since configured values are echoed into state. Object schemas without
+// declared properties accept any nested path, and outputs are not declared in schemas
+// so output references only need a known resource type.
+func (s *Schema) ValidateReference(ref types.Reference) error {
+	rt, ok := s.referencedType(ref.Address.Type)
+	if !ok {
+		names := make([]string, 0, len(s.ResourceTypes))
+		for _, def := range s.ResourceTypes {
+			names = append(names, def.Name)
+		}
+		suggestion, _ := ClosestMatch(ref.Address.Type, names)
+		return &ReferenceError{Reference: ref, Reason: fmt.Sprintf("unknown resource type %q", ref.Address.Type), Suggestion: suggestion}
+	}
+	if len(ref.Path) == 0 {
+		return &ReferenceError{Reference: ref, Reason: "missing attribute"}
+	}
+	if ref.IsOutput() {
+		return nil
+	}
+
+	var state, config referenceSchema
+	_ = json.Unmarshal(rt.StateSchema, &state)
+	_ = json.Unmarshal(rt.ConfigSchema, &config)
+	root := &state
+	if _, declared := state.Properties[ref.Path[0]]; !declared && config.Properties[ref.Path[0]] != nil {
+		root = &config
+	}
+	return walkReference(ref, root)
+}
+
+// referencedType returns a resource type definition with its mixins composed
+func (s *Schema) referencedType(name string) (ResourceTypeDefinition, bool) {
+	for _, rt := range s.ResourceTypes {
+		if rt.Name != name {
+			continue
+		}
+		if len(rt.Mixins) > 0 {
+			if composed, err := s.ComposeResourceType(rt); err == nil {
+				return composed, true
+			}
+		}
+		return rt, true
+	}
+	return ResourceTypeDefinition{}, false
+}
+
+// walkReference follows the reference path through nested properties and items
+func walkReference(ref types.Reference, node *referenceSchema) error {
+	for i, part := range ref.Path {
+		if node == nil {
+			return nil
+		}
+		if node.scalar() {
+			return &ReferenceError{Reference: ref, Reason: fmt.Sprintf("%s has no nested attributes", displayReferencePath(ref.Path[:i]))}
+		}
+		if node.Properties == nil && node.Items == nil {
+			return nil // free-form value
+		}
+		if _, err := strconv.Atoi(part); err == nil {
+			if node.Items == nil {
+				return &ReferenceError{Reference: ref, Reason: fmt.Sprintf("%s is not a list", displayReferencePath(ref.Path[:i]))}
+			}
+			node = node.Items
+			continue
+		}
+		next, ok := node.Properties[part]
+		if !ok {
+			reason := fmt.Sprintf("resource type %s has no attribute %s", ref.Address.Type, displayReferencePath(ref.Path[:i+1]))
+			suggestion, _ := ClosestMatch(part, sortedStringKeys(node.Properties))
+			return &ReferenceError{Reference: ref, Reason: reason, Suggestion: suggestion}
+		}
+		node = next
+	}
+	return nil
+}
+
+func displayReferencePath(path []string) string {
+	if len(path) == 0 {
+		return "the resource"
+	}
+	out := path[0]
+	for _, part := range path[1:] {
+		if _, err := strconv.Atoi(part); err == nil {
+			out += "[" + part + "]"
+		} else {
+			out += "." + part
+		}
+	}
+	return out
+}
@@ -0,0 +1,50 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/schemabounce/kolumn/sdk/types"
+)
+
+// TestValidateReference validates attribute paths are checked against state schemas
+func TestValidateReference(t *testing.T) {
+	schema := &Schema{ResourceTypes: []ResourceTypeDefinition{{
+		Name:         "table",
+		ConfigSchema: json.RawMessage(`{"properties":{"comment":{"type":"string"}}}`),
+		StateSchema: json.RawMessage(`{"properties":{
+			"row_count":{"type":"integer"},
+			"columns":{"type":"array","items":{"properties":{"name":{"type":"string"}}}},
+			"labels":{"type":"object"}
+		}}`),
+	}}}
+	users := types.NewAddress("postgres", "table", "users")
+
+	for _, path := range [][]string{
+		{"row_count"},
+		{"columns", "0", "name"},
+		{"labels", "team"},
+		{"comment"},
+		{types.OutputsAttribute, "anything"},
+	} {
+		if err := schema.ValidateReference(types.NewReference(users, path...)); err != nil {
+			t.Errorf("ValidateReference(%v): %v", path, err)
+		}
+	}
+
+	var refErr *ReferenceError
+	err := schema.ValidateReference(types.NewReference(users, "row_cuont"))
+	if !errors.As(err, &refErr) || refErr.Suggestion != "row_count" {
+		t.Errorf("expected suggestion for misspelled attribute, got %v", err)
+	}
+	if err := schema.ValidateReference(types.NewReference(users, "columns", "0", "type")); !errors.As(err, &refErr) {
+		t.Errorf("expected error for undeclared nested attribute, got %v", err)
+	}
+	if err := schema.ValidateReference(types.NewReference(users, "row_count", "0")); !errors.As(err, &refErr) {
+		t.Errorf("expected error for indexing a scalar, got %v", err)
+	}
+	if err := schema.ValidateReference(types.NewReference(types.NewAddress("postgres", "tabel", "users"), "id")); !errors.As(err, &refErr) || refErr.Suggestion != "table" {
+		t.Errorf("expected unknown type error with suggestion, got %v", err)
+	}
+}
@@ -0,0 +1,233 @@
+// Package core provides bulk state refresh for the unified dispatcher
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/schemabounce/kolumn/sdk/helpers/security"
+)
+
+// DefaultRefreshConcurrency bounds how many reads run at once during RefreshAll and
+// GetOutputs
+const DefaultRefreshConcurrency = 8
+
+// MaxRefreshConcurrency caps the max_concurrency option of RefreshAll
+const MaxRefreshConcurrency = 32
+
+// Refresh outcomes
+const (
+	RefreshUnchanged = "unchanged"
+	RefreshChanged   = "changed"
+	RefreshMissing   = "missing"
+	RefreshFailed    = "failed"
+)
+
+// RefreshTarget is a resource recorded in state that RefreshAll should re-read
+type RefreshTarget struct {
+	ResourceType string                 `json:"resource_type"`
+	ResourceID   string                 `json:"resource_id"`
+	Name         string                 `json:"name"`
+	State        map[string]interface{} `json:"state,omitempty"` // state as recorded
+}
+
+// RefreshAllRequest is the input of the RefreshAll function. When ResourceTypes is set
+// only resources of those types are refreshed.
+type RefreshAllRequest struct {
+	ResourceTypes []string               `json:"resource_types,omitempty"`
+	Resources     []RefreshTarget        `json:"resources"`
+	Options       map[string]interface{} `json:"options,omitempty"` // max_concurrency
+	Metadata      map[string]interface{} `json:"metadata,omitempty"`
+
+	// Consistency applies to every read; plans can accept cached reads here
+	Consistency *ReadConsistency `json:"consistency,omitempty"`
+}
+
+// RefreshResult is the outcome of refreshing one resource
+type RefreshResult struct {
+	ResourceType  string                 `json:"resource_type"`
+	ResourceID    string                 `json:"resource_id"`
+	Name          string                 `json:"name"`
+	Status        string                 `json:"status"`
+	State         map[string]interface{} `json:"state,omitempty"` // state as read
+	ChangedFields []string               `json:"changed_fields,omitempty"`
+	Error         string                 `json:"error,omitempty"`
+}
+
+// RefreshSummary counts refresh outcomes
+type RefreshSummary struct {
+	Total     int    `json:"total"`
+	Unchanged int    `json:"unchanged"`
+	Changed   int    `json:"changed"`
+	Missing   int    `json:"missing"`
+	Failed    int    `json:"failed"`
+	Duration  string `json:"duration"`
+}
+
+// RefreshAllResponse is the output of the RefreshAll function
+type RefreshAllResponse struct {
+	Results []RefreshResult `json:"results"`
+	Summary RefreshSummary  `json:"summary"`
+}
+
+// handleRefreshAll re-reads every requested resource with bounded parallelism. Each
+// read is authorized and gated like an individual ReadResource call; a failed read is
+// reported in its result rather than failing the whole refresh.
+func (d *UnifiedDispatcher) handleRefreshAll(ctx context.Context, input []byte) ([]byte, error) {
+	var req RefreshAllRequest
+	if err := security.SafeUnmarshal(input, &req); err != nil {
+		return nil, security.NewSecureError(
+			"invalid request format",
+			fmt.Sprintf("refresh request unmarshal failed: %v", err),
+			"INVALID_REQUEST",
+		)
+	}
+
+	targets := req.Resources
+	if len(req.ResourceTypes) > 0 {
+		wanted := stringSet(req.ResourceTypes)
+		targets = targets[:0:0]
+		for _, t := range req.Resources {
+			if wanted[t.ResourceType] {
+				targets = append(targets, t)
+			}
+		}
+	}
+
+	concurrency := requestConcurrency(req.Options, DefaultRefreshConcurrency, MaxRefreshConcurrency)
+
+	start := clock.Now()
+	results := make([]RefreshResult, len(targets))
+	forEachBounded(len(targets), concurrency, func(i int) {
+		results[i] = d.refreshOne(ctx, targets[i], req.Metadata, req.Consistency)
+	})
+
+	resp := &RefreshAllResponse{Results: results}
+	resp.Summary.Total = len(results)
+	for _, r := range results {
+		switch r.Status {
+		case RefreshUnchanged:
+			resp.Summary.Unchanged++
+		case RefreshChanged:
+			resp.Summary.Changed++
+		case RefreshMissing:
+			resp.Summary.Missing++
+		default:
+			resp.Summary.Failed++
+		}
+	}
+	resp.Summary.Duration = clock.Since(start).String()
+	return json.Marshal(resp)
+}
+
+// refreshOne reads a single resource and compares it with its recorded state
+func (d *UnifiedDispatcher) refreshOne(ctx context.Context, target RefreshTarget, metadata map[string]interface{}, consistency *ReadConsistency) RefreshResult {
+	result := RefreshResult{ResourceType: target.ResourceType, ResourceID: target.ResourceID, Name: target.Name}
+	fail := func(err error) RefreshResult {
+		result.Status = RefreshFailed
+		result.Error = err.Error()
+		return result
+	}
+
+	read, err := d.readTarget(ctx, target, metadata, consistency)
+	if err != nil {
+		return fail(err)
+	}
+	if read.NotFound {
+		result.Status = RefreshMissing
+		return result
+	}
+
+	result.State = read.State
+	result.ChangedFields = changedStateFields(target.State, read.State)
+	result.Status = RefreshUnchanged
+	if len(result.ChangedFields) > 0 {
+		result.Status = RefreshChanged
+	}
+	return result
+}
+
+// readTarget reads a resource recorded in state, authorized and gated like an individual
+// ReadResource call
+func (d *UnifiedDispatcher) readTarget(ctx context.Context, target RefreshTarget, metadata map[string]interface{}, consistency *ReadConsistency) (*ReadResponse, error) {
+	readReq := map[string]interface{}{
+		"resource_type": target.ResourceType,
+		"resource_id":   target.ResourceID,
+		"name":          target.Name,
+	}
+	if metadata != nil {
+		readReq["metadata"] = metadata
+	}
+	if consistency != nil {
+		readReq["consistency"] = consistency
+	}
+	readInput, err := json.Marshal(readReq)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.tenants != nil {
+		if err := d.checkTenantResource(ctx, target.ResourceType+"/"+target.ResourceID); err != nil {
+			return nil, err
+		}
+	}
+	if err := d.checkTier(ctx, "ReadResource", readInput); err != nil {
+		return nil, err
+	}
+	if err := d.checkFeatureFlags("ReadResource", readInput); err != nil {
+		return nil, err
+	}
+	if d.authorizer != nil {
+		_, user := requestUserContext(readInput)
+		if err := d.authorizer.Authorize(user, "ReadResource", target.ResourceType); err != nil {
+			return nil, err
+		}
+	}
+
+	output, err := d.handleReadResource(ctx, readInput)
+	d.recordAccess(ctx, "ReadResource", readInput, output, err)
+	if err != nil {
+		return nil, err
+	}
+
+	var read ReadResponse
+	if err := json.Unmarshal(output, &read); err != nil {
+		return nil, fmt.Errorf("invalid read response: %w", err)
+	}
+	return &read, nil
+}
+
+// changedStateFields lists the top-level keys whose values differ, comparing values
+// in their JSON form so numeric types decoded differently compare equal
+func changedStateFields(before, after map[string]interface{}) []string {
+	keys := make(map[string]bool, len(before)+len(after))
+	for k := range before {
+		keys[k] = true
+	}
+	for k := range after {
+		keys[k] = true
+	}
+	var changed []string
+	for k := range keys {
+		if !reflect.DeepEqual(jsonValue(before[k]), jsonValue(after[k])) {
+			changed = append(changed, k)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+func jsonValue(v interface{}) interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out interface{}
+	if json.Unmarshal(data, &out) != nil {
+		return v
+	}
+	return out
+}
@@ -0,0 +1,95 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// refreshRegistry serves reads from a fixed set of live states and tracks concurrency
+type refreshRegistry struct {
+	live    map[string]map[string]interface{}
+	active  atomic.Int32
+	maxSeen atomic.Int32
+}
+
+func (r *refreshRegistry) GetObjectTypes() map[string]*ObjectType { return nil }
+
+func (r *refreshRegistry) CallHandler(ctx context.Context, objectType, method string, input []byte) ([]byte, error) {
+	n := r.active.Add(1)
+	defer r.active.Add(-1)
+	for {
+		seen := r.maxSeen.Load()
+		if n <= seen || r.maxSeen.CompareAndSwap(seen, n) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	var req ReadRequest
+	_ = json.Unmarshal(input, &req)
+	if req.ResourceID == "broken" {
+		return nil, fmt.Errorf("connection refused")
+	}
+	state, ok := r.live[req.ResourceID]
+	return json.Marshal(ReadResponse{State: state, NotFound: !ok})
+}
+
+// TestRefreshAll validates changed, missing and failed resources are summarized
+func TestRefreshAll(t *testing.T) {
+	registry := &refreshRegistry{live: map[string]map[string]interface{}{
+		"t1": {"name": "orders", "rows": 10},
+		"t2": {"name": "users", "rows": 99},
+		"t3": {"name": "events"},
+	}}
+	for i := 0; i < 10; i++ {
+		registry.live[fmt.Sprintf("v%d", i)] = map[string]interface{}{"name": "v"}
+	}
+	d := NewUnifiedDispatcher(registry, nil)
+
+	req := RefreshAllRequest{
+		ResourceTypes: []string{"table"},
+		Options:       map[string]interface{}{"max_concurrency": 3},
+		Resources: []RefreshTarget{
+			{ResourceType: "table", ResourceID: "t1", State: map[string]interface{}{"name": "orders", "rows": 10}},
+			{ResourceType: "table", ResourceID: "t2", State: map[string]interface{}{"name": "users", "rows": 1}},
+			{ResourceType: "table", ResourceID: "gone", State: map[string]interface{}{"name": "old"}},
+			{ResourceType: "table", ResourceID: "broken"},
+			{ResourceType: "view", ResourceID: "t3"},
+		},
+	}
+	for i := 0; i < 10; i++ {
+		req.Resources = append(req.Resources, RefreshTarget{ResourceType: "table", ResourceID: fmt.Sprintf("v%d", i), State: map[string]interface{}{"name": "v"}})
+	}
+	input, _ := json.Marshal(req)
+
+	output, err := d.Dispatch(context.Background(), "RefreshAll", input)
+	if err != nil {
+		t.Fatalf("dispatch failed: %v", err)
+	}
+	var resp RefreshAllResponse
+	if err := json.Unmarshal(output, &resp); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+
+	s := resp.Summary
+	if s.Total != 14 || s.Unchanged != 11 || s.Changed != 1 || s.Missing != 1 || s.Failed != 1 {
+		t.Errorf("unexpected summary: %+v", s)
+	}
+	byID := map[string]RefreshResult{}
+	for _, r := range resp.Results {
+		byID[r.ResourceID] = r
+	}
+	if r := byID["t2"]; r.Status != RefreshChanged || len(r.ChangedFields) != 1 || r.ChangedFields[0] != "rows" {
+		t.Errorf("unexpected t2 result: %+v", r)
+	}
+	if _, ok := byID["t3"]; ok {
+		t.Error("view should have been filtered out by resource_types")
+	}
+	if max := registry.maxSeen.Load(); max > 3 {
+		t.Errorf("concurrency exceeded limit: %d", max)
+	}
+}
@@ -0,0 +1,285 @@
+// Package core provides operator-supplied resource defaults files with provenance
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/schemabounce/kolumn/sdk/helpers/hcl"
+	"github.com/schemabounce/kolumn/sdk/helpers/security"
+	"gopkg.in/yaml.v3"
+)
+
+// AppliedDefaultsMetadataKey is the CreateRequest.Metadata key listing the
+// DefaultProvenance of every attribute the dispatcher filled from resource defaults
+const AppliedDefaultsMetadataKey = "applied_defaults"
+
+// DefaultSourceSchema is the provenance source of defaults declared in the schema
+const DefaultSourceSchema = "schema"
+
+// ResourceDefaultsWildcard is the resource type whose defaults apply to every type
+const ResourceDefaultsWildcard = "*"
+
+// resourceDefaultsBlock is the HCL block of a defaults file: defaults "kafka_topic" { ... }
+const resourceDefaultsBlock = "defaults"
+
+// DefaultProvenance records where a defaulted attribute came from
+type DefaultProvenance struct {
+	Path   string      `json:"path"` // attribute path, e.g. "replication_factor" or "tags.team"
+	Value  interface{} `json:"value"`
+	Source string      `json:"source"`          // defaults file, or DefaultSourceSchema
+	Scope  string      `json:"scope,omitempty"` // resource type of the defaults, or "*"
+}
+
+// String describes the origin, e.g. "defaults.yaml (kafka_topic)" or "schema"
+func (p DefaultProvenance) String() string {
+	if p.Scope == "" {
+		return p.Source
+	}
+	return fmt.Sprintf("%s (%s)", p.Source, p.Scope)
+}
+
+// ResourceDefaults are default attributes operators supply per resource type, e.g. a
+// default tablespace or replication factor, merged into create requests before
+// validation. A defaults file maps resource types, or "*" for every type, to
+// attributes, in YAML or JSON:
+//
+//	"*":
+//	  tags: {managed_by: kolumn}
+//	kafka_topic:
+//	  replication_factor: 3
+//
+// or in HCL (.kl, .hcl):
+//
+//	defaults "postgres_table" {
+//	  tablespace = "fast_ssd"
+//	}
+//
+// Precedence, highest first: values set in the request, defaults for the resource
+// type, defaults for "*", then defaults declared in the schema. Among files, later
+// ones win. Nested objects are merged attribute by attribute.
+type ResourceDefaults struct {
+	files []resourceDefaultsFile // in load order
+}
+
+type resourceDefaultsFile struct {
+	source string
+	types  map[string]map[string]interface{}
+}
+
+// LoadResourceDefaults reads defaults files; later files take precedence
+func LoadResourceDefaults(paths ...string) (*ResourceDefaults, error) {
+	defaults := &ResourceDefaults{}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read defaults file: %w", err)
+		}
+		parsed, err := ParseResourceDefaults(data, path)
+		if err != nil {
+			return nil, err
+		}
+		defaults.files = append(defaults.files, parsed.files...)
+	}
+	return defaults, nil
+}
+
+// ParseResourceDefaults parses one defaults file; the format follows the extension of
+// source, and is YAML unless it is .json, .kl or .hcl
+func ParseResourceDefaults(data []byte, source string) (*ResourceDefaults, error) {
+	raw := make(map[string]interface{})
+	switch strings.ToLower(filepath.Ext(source)) {
+	case ".json":
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", source, err)
+		}
+	case ".kl", ".hcl":
+		file, err := hcl.Parse(data, source)
+		if err != nil {
+			return nil, err
+		}
+		for _, block := range file.Blocks {
+			if block.Type != resourceDefaultsBlock || len(block.Labels) != 1 {
+				return nil, fmt.Errorf("%s:%s: expected defaults \"<resource type>\" blocks", source, block.Pos)
+			}
+			raw[block.Labels[0]] = block.Body()
+		}
+	default:
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", source, err)
+		}
+	}
+
+	// Values are normalized to what JSON decoding yields, like request configs
+	normalized, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", source, err)
+	}
+	var byType map[string]interface{}
+	if err := json.Unmarshal(normalized, &byType); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", source, err)
+	}
+	file := resourceDefaultsFile{source: source, types: make(map[string]map[string]interface{}, len(byType))}
+	for resourceType, values := range byType {
+		attributes, ok := values.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: defaults for %s must be a mapping of attributes", source, resourceType)
+		}
+		file.types[resourceType] = attributes
+	}
+	return &ResourceDefaults{files: []resourceDefaultsFile{file}}, nil
+}
+
+// ResourceTypes returns the resource types with defaults, sorted; "*" included
+func (d *ResourceDefaults) ResourceTypes() []string {
+	var types []string
+	for _, file := range d.files {
+		types = append(types, sortedStringKeys(file.types)...)
+	}
+	return uniqueSorted(types)
+}
+
+// Apply returns a copy of config with the defaults of resourceType filled in for
+// absent or null attributes, and the provenance of every filled attribute. config is
+// not modified.
+func (d *ResourceDefaults) Apply(resourceType string, config map[string]interface{}) (map[string]interface{}, []DefaultProvenance) {
+	merged := make(map[string]interface{}, len(config))
+	for k, v := range config {
+		merged[k] = v
+	}
+	if d == nil {
+		return merged, nil
+	}
+	var provenance []DefaultProvenance
+	for _, scope := range []string{resourceType, ResourceDefaultsWildcard} {
+		for i := len(d.files) - 1; i >= 0; i-- {
+			if values, ok := d.files[i].types[scope]; ok {
+				fillResourceDefaults(merged, values, "", DefaultProvenance{Source: d.files[i].source, Scope: scope}, &provenance)
+			}
+		}
+		if resourceType == ResourceDefaultsWildcard {
+			break
+		}
+	}
+	sort.Slice(provenance, func(i, j int) bool { return provenance[i].Path < provenance[j].Path })
+	return merged, provenance
+}
+
+// fillResourceDefaults sets absent attributes of dst from defaults, descending into
+// objects both sides define; objects of dst are copied before they are changed
+func fillResourceDefaults(dst, defaults map[string]interface{}, prefix string, origin DefaultProvenance, provenance *[]DefaultProvenance) {
+	for _, name := range sortedStringKeys(defaults) {
+		value := defaults[name]
+		current, ok := dst[name]
+		switch {
+		case !ok || current == nil:
+			dst[name] = value
+			p := origin
+			p.Path, p.Value = prefix+name, value
+			*provenance = append(*provenance, p)
+		default:
+			currentObject, isObject := current.(map[string]interface{})
+			defaultObject, defaultIsObject := value.(map[string]interface{})
+			if !isObject || !defaultIsObject {
+				continue
+			}
+			copied := make(map[string]interface{}, len(currentObject))
+			for k, v := range currentObject {
+				copied[k] = v
+			}
+			dst[name] = copied
+			fillResourceDefaults(copied, defaultObject, prefix+name+".", origin, provenance)
+		}
+	}
+}
+
+// SchemaDefaultProvenance describes defaults injected from the schema by InjectDefaults
+func SchemaDefaultProvenance(injected map[string]interface{}) []DefaultProvenance {
+	provenance := make([]DefaultProvenance, 0, len(injected))
+	for _, name := range sortedStringKeys(injected) {
+		provenance = append(provenance, DefaultProvenance{Path: name, Value: injected[name], Source: DefaultSourceSchema})
+	}
+	return provenance
+}
+
+// SetResourceDefaults merges defaults into the config of every CreateResource request
+// before the backend feature checks and the handler's validation. The provenance of
+// the filled attributes is passed to the handler under AppliedDefaultsMetadataKey.
+func (d *UnifiedDispatcher) SetResourceDefaults(defaults *ResourceDefaults) {
+	d.resourceDefaults = defaults
+}
+
+// applyResourceDefaults rewrites the config and metadata of a create request. The
+// merged config is size checked again because defaults can grow it past the limit
+// decodeResourceRequest enforced.
+func (d *UnifiedDispatcher) applyResourceDefaults(req *resourceRequest) error {
+	var config map[string]interface{}
+	if raw := req.fields["config"]; len(raw) > 0 {
+		if err := json.Unmarshal(raw, &config); err != nil {
+			// non-object configs are left to the handler's validation
+			return nil
+		}
+	}
+	merged, provenance := d.resourceDefaults.Apply(req.resourceType, config)
+	if len(provenance) == 0 {
+		return nil
+	}
+
+	var metadata map[string]interface{}
+	if raw := req.fields["metadata"]; len(raw) > 0 {
+		if err := json.Unmarshal(raw, &metadata); err != nil {
+			return security.NewSecureError(
+				"invalid request format",
+				fmt.Sprintf("create request metadata must be an object: %v", err),
+				"INVALID_REQUEST",
+			)
+		}
+	}
+	if metadata == nil {
+		metadata = make(map[string]interface{})
+	}
+	metadata[AppliedDefaultsMetadataKey] = provenance
+
+	encodedConfig, err := json.Marshal(merged)
+	if err != nil {
+		return defaultsTransformationError(err)
+	}
+	encodedMetadata, err := json.Marshal(metadata)
+	if err != nil {
+		return defaultsTransformationError(err)
+	}
+	mergedConfig := make(map[string]json.RawMessage, len(merged))
+	if err := json.Unmarshal(encodedConfig, &mergedConfig); err != nil {
+		return defaultsTransformationError(err)
+	}
+	if err := (&security.InputSizeValidator{}).ValidateRawConfigSize(mergedConfig); err != nil {
+		return security.NewSecureError(
+			"request too large",
+			fmt.Sprintf("create request config with defaults validation failed: %v", err),
+			"REQUEST_TOO_LARGE",
+		)
+	}
+
+	req.fields["config"], req.fields["metadata"] = encodedConfig, encodedMetadata
+	if req.config == nil {
+		req.config = mergedConfig
+		return nil
+	}
+	clear(req.config)
+	for k, v := range mergedConfig {
+		req.config[k] = v
+	}
+	return nil
+}
+
+func defaultsTransformationError(err error) error {
+	return security.NewSecureError(
+		"request transformation failed",
+		fmt.Sprintf("failed to apply resource defaults: %v", err),
+		"TRANSFORMATION_FAILED",
+	)
+}
@@ -0,0 +1,162 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/schemabounce/kolumn/sdk/helpers/security"
+)
+
+// TestResourceDefaultsPrecedence validates request values, type and wildcard defaults and file order
+func TestResourceDefaultsPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "defaults.yaml")
+	site := filepath.Join(dir, "site.kl")
+	if err := os.WriteFile(base, []byte(`"*":
+  tags:
+    managed_by: kolumn
+    team: platform
+  comment: managed
+kafka_topic:
+  replication_factor: 2
+  partitions: 6
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(site, []byte(`defaults "kafka_topic" {
+  replication_factor = 3
+}
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	defaults, err := LoadResourceDefaults(base, site)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if types := defaults.ResourceTypes(); len(types) != 2 || types[0] != "*" || types[1] != "kafka_topic" {
+		t.Errorf("ResourceTypes = %v", types)
+	}
+
+	config := map[string]interface{}{"partitions": float64(12), "comment": nil, "tags": map[string]interface{}{"team": "data"}}
+	merged, provenance := defaults.Apply("kafka_topic", config)
+	if merged["partitions"] != float64(12) || merged["replication_factor"] != float64(3) || merged["comment"] != "managed" {
+		t.Errorf("merged = %v", merged)
+	}
+	tags := merged["tags"].(map[string]interface{})
+	if tags["team"] != "data" || tags["managed_by"] != "kolumn" {
+		t.Errorf("tags = %v", tags)
+	}
+	if len(config["tags"].(map[string]interface{})) != 1 || config["comment"] != nil {
+		t.Errorf("input config was modified: %v", config)
+	}
+
+	want := []DefaultProvenance{
+		{Path: "comment", Value: "managed", Source: base, Scope: "*"},
+		{Path: "replication_factor", Value: float64(3), Source: site, Scope: "kafka_topic"},
+		{Path: "tags.managed_by", Value: "kolumn", Source: base, Scope: "*"},
+	}
+	if len(provenance) != len(want) {
+		t.Fatalf("provenance = %+v", provenance)
+	}
+	for i := range want {
+		if provenance[i] != want[i] {
+			t.Errorf("provenance[%d] = %+v, want %+v", i, provenance[i], want[i])
+		}
+	}
+
+	if _, err := ParseResourceDefaults([]byte(`{"kafka_topic": 3}`), "bad.json"); err == nil {
+		t.Error("expected non-mapping defaults to be rejected")
+	}
+}
+
+// TestRecordDefaultProvenance validates plan changes name the source of their defaults
+func TestRecordDefaultProvenance(t *testing.T) {
+	provenance := []DefaultProvenance{
+		{Path: "replication_factor", Value: 3, Source: "defaults.yaml", Scope: "kafka_topic"},
+		{Path: "tags.team", Value: "platform", Source: "defaults.yaml", Scope: "*"},
+	}
+	update := &PlanResponse{Changes: []PlannedChange{{Action: "update", Property: "tags"}}}
+	RecordDefaultProvenance(update, provenance)
+	if c := update.Changes[0]; !c.FromDefault || c.DefaultSource != "defaults.yaml (*)" || len(update.Changes) != 1 {
+		t.Errorf("update changes = %+v", update.Changes)
+	}
+
+	create := &PlanResponse{Changes: []PlannedChange{{Action: "create"}}}
+	RecordDefaultProvenance(create, provenance)
+	if len(create.Changes) != 3 {
+		t.Fatalf("create changes = %+v", create.Changes)
+	}
+	if c := create.Changes[1]; c.Property != "replication_factor" || c.DefaultSource != "defaults.yaml (kafka_topic)" ||
+		c.Description != "replication_factor defaults to 3 from defaults.yaml (kafka_topic)" {
+		t.Errorf("default change = %+v", c)
+	}
+}
+
+// TestDispatcherAppliesResourceDefaults validates create requests reach the handler with defaults and provenance
+func TestDispatcherAppliesResourceDefaults(t *testing.T) {
+	defaults, err := ParseResourceDefaults([]byte(`{"table": {"comment": "managed", "schema": "public"}}`), "defaults.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry := &echoRegistry{}
+	d := NewUnifiedDispatcher(registry, nil)
+	d.SetResourceDefaults(defaults)
+
+	if _, err := d.Dispatch(context.Background(), "CreateResource",
+		[]byte(`{"resource_type":"table","name":"users","config":{"schema":"sales"},"metadata":{"run":"42"}}`)); err != nil {
+		t.Fatal(err)
+	}
+	var req CreateRequest
+	if err := json.Unmarshal(registry.last, &req); err != nil {
+		t.Fatal(err)
+	}
+	if req.Config["schema"] != "sales" || req.Config["comment"] != "managed" || req.Metadata["run"] != "42" {
+		t.Errorf("request = %+v", req)
+	}
+	applied, _ := req.Metadata[AppliedDefaultsMetadataKey].([]interface{})
+	if len(applied) != 1 || applied[0].(map[string]interface{})["path"] != "comment" {
+		t.Errorf("applied defaults = %v", req.Metadata[AppliedDefaultsMetadataKey])
+	}
+}
+
+// TestDispatcherRejectsInvalidDefaultedRequests validates malformed metadata and configs
+// grown past the size limit by defaults are rejected
+func TestDispatcherRejectsInvalidDefaultedRequests(t *testing.T) {
+	defaults, err := ParseResourceDefaults([]byte(`{"table": {"comment": "managed"}}`), "defaults.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := NewUnifiedDispatcher(&echoRegistry{}, nil)
+	d.SetResourceDefaults(defaults)
+
+	_, err = d.Dispatch(context.Background(), "CreateResource",
+		[]byte(`{"resource_type":"table","name":"users","config":{"schema":"sales"},"metadata":"run-42"}`))
+	var secureErr *security.SecureError
+	if !errors.As(err, &secureErr) || secureErr.Code != "INVALID_REQUEST" {
+		t.Errorf("expected INVALID_REQUEST for non-object metadata, got %v", err)
+	}
+
+	large := make(map[string]interface{}, security.MaxArrayItems)
+	for i := 0; i < security.MaxArrayItems; i++ {
+		large[fmt.Sprintf("attr_%d", i)] = i
+	}
+	data, err := json.Marshal(map[string]interface{}{"table": large})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if defaults, err = ParseResourceDefaults(data, "defaults.json"); err != nil {
+		t.Fatal(err)
+	}
+	d.SetResourceDefaults(defaults)
+	_, err = d.Dispatch(context.Background(), "CreateResource",
+		[]byte(`{"resource_type":"table","name":"users","config":{"schema":"sales"}}`))
+	if !errors.As(err, &secureErr) || secureErr.Code != "REQUEST_TOO_LARGE" {
+		t.Errorf("expected REQUEST_TOO_LARGE for a config grown by defaults, got %v", err)
+	}
+}
@@ -0,0 +1,225 @@
+// Package core provides timing and backend identifiers attached to operation responses
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ResponseMetadataKey is the response field holding ResponseMetadata
+const ResponseMetadataKey = "response_metadata"
+
+// ResponseMetadata describes how a resource operation ran. The dispatcher measures
+// Duration and attaches the metadata to every CreateResource, ReadResource,
+// UpdateResource and DeleteResource response; backend identifiers and rate limits are
+// recorded by handlers or client middleware through the request context.
+type ResponseMetadata struct {
+	Duration           time.Duration `json:"duration"`
+	BackendRequestIDs  []string      `json:"backend_request_ids,omitempty"` // e.g. query or request IDs to quote to support
+	RateLimitRemaining *int          `json:"rate_limit_remaining,omitempty"`
+	RateLimitReset     *time.Time    `json:"rate_limit_reset,omitempty"`
+}
+
+// String renders the metadata on one line, e.g. "1.2s, request q-1, 40 requests left"
+func (m *ResponseMetadata) String() string {
+	parts := []string{m.Duration.Round(time.Millisecond).String()}
+	if len(m.BackendRequestIDs) == 1 {
+		parts = append(parts, "request "+m.BackendRequestIDs[0])
+	} else if len(m.BackendRequestIDs) > 1 {
+		parts = append(parts, "requests "+strings.Join(m.BackendRequestIDs, ", "))
+	}
+	if m.RateLimitRemaining != nil {
+		parts = append(parts, fmt.Sprintf("%d requests left", *m.RateLimitRemaining))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// responseMetadataField is the quoted key searched for in handler responses
+var responseMetadataField = []byte(`"` + ResponseMetadataKey + `"`)
+
+// responseMetadataFunctions are the dispatcher functions whose responses carry metadata
+var responseMetadataFunctions = map[string]bool{
+	"CreateResource": true,
+	"ReadResource":   true,
+	"UpdateResource": true,
+	"DeleteResource": true,
+}
+
+// responseRecorder collects metadata reported while a request runs. It is the request
+// context itself, saving dispatch an allocation per call.
+type responseRecorder struct {
+	context.Context
+	mu       sync.Mutex
+	metadata ResponseMetadata
+}
+
+// responseRecorderKey is the context key resolving to the request's responseRecorder
+type responseRecorderKey struct{}
+
+// Value implements context.Context
+func (r *responseRecorder) Value(key interface{}) interface{} {
+	if key == (responseRecorderKey{}) {
+		return r
+	}
+	return r.Context.Value(key)
+}
+
+// withResponseRecorder returns a context collecting metadata for one response
+func withResponseRecorder(ctx context.Context) (context.Context, *responseRecorder) {
+	recorder := &responseRecorder{Context: ctx}
+	return recorder, recorder
+}
+
+// RecordBackendRequestID notes an identifier the backend assigned to a call made for the
+// current request. It does nothing outside a dispatched resource operation.
+func RecordBackendRequestID(ctx context.Context, id string) {
+	recorder, ok := ctx.Value(responseRecorderKey{}).(*responseRecorder)
+	if !ok || id == "" {
+		return
+	}
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	if !containsString(recorder.metadata.BackendRequestIDs, id) {
+		recorder.metadata.BackendRequestIDs = append(recorder.metadata.BackendRequestIDs, id)
+	}
+}
+
+// RecordRateLimit notes the rate-limit budget the backend reported; the latest report
+// wins. A zero reset time is not recorded.
+func RecordRateLimit(ctx context.Context, remaining int, reset time.Time) {
+	recorder, ok := ctx.Value(responseRecorderKey{}).(*responseRecorder)
+	if !ok {
+		return
+	}
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	recorder.metadata.RateLimitRemaining = &remaining
+	if !reset.IsZero() {
+		recorder.metadata.RateLimitReset = &reset
+	}
+}
+
+// attach merges the recorded metadata and the measured duration into a JSON object
+// response. Identifiers and rate limits a handler set itself are kept; responses that
+// are not objects are returned unchanged.
+func (r *responseRecorder) attach(output []byte, duration time.Duration) []byte {
+	// Most handlers leave the field to the SDK, so it is appended without decoding the
+	// response, keeping dispatch within its allocation budget
+	trimmed := bytes.TrimSpace(output)
+	if len(trimmed) < 2 || trimmed[0] != '{' || trimmed[len(trimmed)-1] != '}' {
+		return output
+	}
+	if !bytes.Contains(trimmed, responseMetadataField) {
+		r.mu.Lock()
+		metadata := r.metadata
+		r.mu.Unlock()
+		body := bytes.TrimSpace(trimmed[1 : len(trimmed)-1])
+		merged := make([]byte, 0, len(body)+len(responseMetadataField)+64)
+		merged = append(merged, '{')
+		if len(body) > 0 {
+			merged = append(append(merged, body...), ',')
+		}
+		merged = append(append(merged, responseMetadataField...), ':')
+		if len(metadata.BackendRequestIDs) == 0 && metadata.RateLimitRemaining == nil {
+			merged = append(merged, `{"duration":`...)
+			merged = strconv.AppendInt(merged, int64(duration), 10)
+			return append(merged, '}', '}')
+		}
+		metadata.Duration = duration
+		raw, err := json.Marshal(&metadata)
+		if err != nil {
+			return output
+		}
+		return append(append(merged, raw...), '}')
+	}
+
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(output, &fields) != nil || fields == nil {
+		return output
+	}
+	var metadata ResponseMetadata
+	_ = json.Unmarshal(fields[ResponseMetadataKey], &metadata)
+	r.mu.Lock()
+	for _, id := range r.metadata.BackendRequestIDs {
+		if !containsString(metadata.BackendRequestIDs, id) {
+			metadata.BackendRequestIDs = append(metadata.BackendRequestIDs, id)
+		}
+	}
+	if metadata.RateLimitRemaining == nil {
+		metadata.RateLimitRemaining = r.metadata.RateLimitRemaining
+		metadata.RateLimitReset = r.metadata.RateLimitReset
+	}
+	r.mu.Unlock()
+	metadata.Duration = duration
+
+	raw, err := json.Marshal(&metadata)
+	if err != nil {
+		return output
+	}
+	fields[ResponseMetadataKey] = raw
+	merged, err := json.Marshal(fields)
+	if err != nil {
+		return output
+	}
+	return merged
+}
+
+// ResponseMetadataFromOutput extracts the metadata attached to a dispatcher response
+func ResponseMetadataFromOutput(output []byte) (*ResponseMetadata, bool) {
+	var response struct {
+		Metadata *ResponseMetadata `json:"response_metadata"`
+	}
+	if json.Unmarshal(output, &response) != nil || response.Metadata == nil {
+		return nil, false
+	}
+	return response.Metadata, true
+}
+
+// ResourceTiming is the response metadata of one resource operation in a plan or apply
+type ResourceTiming struct {
+	ResourceType string           `json:"resource_type"`
+	Name         string           `json:"name"`
+	Operation    string           `json:"operation"`
+	Metadata     ResponseMetadata `json:"metadata"`
+}
+
+// TimingSummary aggregates per-resource timing for plan and apply summaries
+type TimingSummary struct {
+	Total     time.Duration    `json:"total"`     // sum of resource durations
+	Resources []ResourceTiming `json:"resources"` // slowest first
+
+	// RateLimitRemaining is the lowest budget any backend reported
+	RateLimitRemaining *int `json:"rate_limit_remaining,omitempty"`
+}
+
+// SummarizeTimings orders timings slowest first and totals their durations
+func SummarizeTimings(timings []ResourceTiming) *TimingSummary {
+	summary := &TimingSummary{Resources: append([]ResourceTiming(nil), timings...)}
+	sort.SliceStable(summary.Resources, func(i, j int) bool {
+		return summary.Resources[i].Metadata.Duration > summary.Resources[j].Metadata.Duration
+	})
+	for _, timing := range summary.Resources {
+		summary.Total += timing.Metadata.Duration
+		if remaining := timing.Metadata.RateLimitRemaining; remaining != nil {
+			if summary.RateLimitRemaining == nil || *remaining < *summary.RateLimitRemaining {
+				summary.RateLimitRemaining = remaining
+			}
+		}
+	}
+	return summary
+}
+
+// Slowest returns at most n timings, slowest first
+func (s *TimingSummary) Slowest(n int) []ResourceTiming {
+	if n > len(s.Resources) {
+		n = len(s.Resources)
+	}
+	return s.Resources[:n]
+}
@@ -0,0 +1,90 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/types"
+)
+
+// slowRegistry advances the clock and reports backend identifiers like client middleware
+type slowRegistry struct {
+	now *time.Time
+}
+
+func (r *slowRegistry) GetObjectTypes() map[string]*ObjectType { return nil }
+
+func (r *slowRegistry) CallHandler(ctx context.Context, objectType, method string, input []byte) ([]byte, error) {
+	*r.now = r.now.Add(1500 * time.Millisecond)
+	RecordBackendRequestID(ctx, "query-1")
+	RecordBackendRequestID(ctx, "query-2")
+	RecordBackendRequestID(ctx, "query-1")
+	RecordRateLimit(ctx, 40, time.Time{})
+	return json.Marshal(CreateResponse{
+		ResourceID:       "users",
+		Success:          true,
+		ResponseMetadata: &ResponseMetadata{BackendRequestIDs: []string{"handler-7"}},
+	})
+}
+
+// TestResponseMetadataAttached validates resource responses carry duration and backend identifiers
+func TestResponseMetadataAttached(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	defer SetClock(types.ClockFunc(func() time.Time { return now }))()
+
+	d := NewUnifiedDispatcher(&slowRegistry{now: &now}, nil)
+	output, err := d.Dispatch(context.Background(), "CreateResource", []byte(`{"resource_type":"table","name":"users","config":{}}`))
+	if err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+
+	var response CreateResponse
+	if err := json.Unmarshal(output, &response); err != nil || response.ResourceID != "users" {
+		t.Fatalf("response = %s, %v", output, err)
+	}
+	metadata, ok := ResponseMetadataFromOutput(output)
+	if !ok {
+		t.Fatalf("no response metadata in %s", output)
+	}
+	if metadata.Duration != 1500*time.Millisecond {
+		t.Errorf("duration = %s, want 1.5s", metadata.Duration)
+	}
+	if got := metadata.BackendRequestIDs; len(got) != 3 || got[0] != "handler-7" || got[1] != "query-1" || got[2] != "query-2" {
+		t.Errorf("backend request IDs = %v", got)
+	}
+	if metadata.RateLimitRemaining == nil || *metadata.RateLimitRemaining != 40 {
+		t.Errorf("rate limit remaining = %v", metadata.RateLimitRemaining)
+	}
+	if got := metadata.String(); got != "1.5s, requests handler-7, query-1, query-2, 40 requests left" {
+		t.Errorf("String() = %q", got)
+	}
+
+	// outside a dispatched operation recording is a no-op
+	RecordBackendRequestID(context.Background(), "ignored")
+	RecordRateLimit(context.Background(), 1, time.Time{})
+}
+
+// TestSummarizeTimings validates timings are ordered slowest first with the lowest rate limit
+func TestSummarizeTimings(t *testing.T) {
+	low, high := 3, 90
+	summary := SummarizeTimings([]ResourceTiming{
+		{ResourceType: "table", Name: "a", Operation: "create", Metadata: ResponseMetadata{Duration: time.Second, RateLimitRemaining: &high}},
+		{ResourceType: "table", Name: "b", Operation: "update", Metadata: ResponseMetadata{Duration: 4 * time.Second, RateLimitRemaining: &low}},
+		{ResourceType: "view", Name: "c", Operation: "create", Metadata: ResponseMetadata{Duration: 2 * time.Second}},
+	})
+	if summary.Total != 7*time.Second {
+		t.Errorf("total = %s, want 7s", summary.Total)
+	}
+	slowest := summary.Slowest(2)
+	if len(slowest) != 2 || slowest[0].Name != "b" || slowest[1].Name != "c" {
+		t.Errorf("slowest = %+v", slowest)
+	}
+	if summary.RateLimitRemaining == nil || *summary.RateLimitRemaining != 3 {
+		t.Errorf("rate limit remaining = %v, want 3", summary.RateLimitRemaining)
+	}
+	if len(summary.Slowest(10)) != 3 {
+		t.Error("Slowest should cap at the number of resources")
+	}
+}
@@ -0,0 +1,481 @@
+// Package core provides long-running discovery jobs with progress and cancellation
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/helpers/ids"
+	"github.com/schemabounce/kolumn/sdk/helpers/security"
+)
+
+// DefaultScanJobSaveInterval is how often a running job's progress is persisted
+const DefaultScanJobSaveInterval = 5 * time.Second
+
+// Bounds of the backoff between retries of a job's final save; the first retry waits
+// the save interval, but never less than the minimum
+const (
+	minScanJobSaveRetryDelay = time.Second
+	maxScanJobSaveRetryDelay = time.Minute
+)
+
+// ErrScanJobNotFound is returned by a ScanJobStore that has no job with the given ID
+var ErrScanJobNotFound = errors.New("scan job not found")
+
+// ScanJob is a discovery run started with StartScan. State is one of the Operation
+// states. PartialResults holds what the handlers reported so far and Result the
+// DiscoverResources response once the job succeeded.
+type ScanJob struct {
+	ID             string          `json:"id"`
+	State          string          `json:"state"`
+	Progress       float64         `json:"progress,omitempty"` // 0 to 1, when handlers report it
+	Message        string          `json:"message,omitempty"`
+	ObjectsFound   int             `json:"objects_found"`
+	PartialResults []interface{}   `json:"partial_results,omitempty"`
+	Result         json.RawMessage `json:"result,omitempty"`
+	Error          string          `json:"error,omitempty"`
+	StartedAt      time.Time       `json:"started_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+	FinishedAt     *time.Time      `json:"finished_at,omitempty"`
+}
+
+// Done reports whether the job reached a final state
+func (j *ScanJob) Done() bool {
+	switch j.State {
+	case OperationSucceeded, OperationFailed, OperationCancelled:
+		return true
+	}
+	return false
+}
+
+func (j *ScanJob) clone(withPartial bool) *ScanJob {
+	c := *j
+	if withPartial {
+		c.PartialResults = append([]interface{}(nil), j.PartialResults...)
+	} else {
+		c.PartialResults = nil
+	}
+	return &c
+}
+
+// ScanJobStore persists scan jobs so tooling can poll them after the request that
+// started them returned, and after a provider restart; state.NewScanJobStore keeps
+// them in the state backend
+type ScanJobStore interface {
+	SaveScanJob(ctx context.Context, job *ScanJob) error
+	LoadScanJob(ctx context.Context, id string) (*ScanJob, error)
+}
+
+// MemoryScanJobStore keeps scan jobs in memory
+type MemoryScanJobStore struct {
+	mu   sync.Mutex
+	jobs map[string]*ScanJob
+}
+
+// NewMemoryScanJobStore creates an empty in-memory store
+func NewMemoryScanJobStore() *MemoryScanJobStore {
+	return &MemoryScanJobStore{jobs: make(map[string]*ScanJob)}
+}
+
+// SaveScanJob implements ScanJobStore
+func (s *MemoryScanJobStore) SaveScanJob(ctx context.Context, job *ScanJob) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[job.ID] = job.clone(true)
+	return nil
+}
+
+// LoadScanJob implements ScanJobStore
+func (s *MemoryScanJobStore) LoadScanJob(ctx context.Context, id string) (*ScanJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, ErrScanJobNotFound
+	}
+	return job.clone(true), nil
+}
+
+// ScanProgress is a progress update reported by a discover handler running in a job
+type ScanProgress struct {
+	Progress float64       // 0 to 1; zero keeps the previous value
+	Message  string        // e.g. "scanning schema analytics"
+	Objects  []interface{} // objects found since the previous update
+}
+
+type scanJobKey struct{}
+
+// ReportScanProgress reports progress of the scan job ctx belongs to. Discover
+// handlers call it between batches; outside a job it does nothing.
+func ReportScanProgress(ctx context.Context, update ScanProgress) {
+	if run, ok := ctx.Value(scanJobKey{}).(*scanJobRun); ok {
+		run.report(update)
+	}
+}
+
+// ScanJobs runs discovery requests in the background for StartScan, GetScanStatus and
+// CancelScan. Jobs are owned by the provider process that started them: a job the
+// store reports as running but no process owns was interrupted by a restart and is
+// reported as failed.
+type ScanJobs struct {
+	store        ScanJobStore
+	saveInterval time.Duration
+	onSaveError  func(jobID string, err error)
+
+	mu   sync.Mutex
+	runs map[string]*scanJobRun
+
+	stop     chan struct{} // closed by Close
+	stopOnce sync.Once
+	workers  sync.WaitGroup // running jobs and final save retries
+}
+
+// NewScanJobs creates a job runner persisting to store; nil keeps jobs in memory
+func NewScanJobs(store ScanJobStore) *ScanJobs {
+	if store == nil {
+		store = NewMemoryScanJobStore()
+	}
+	return &ScanJobs{
+		store:        store,
+		saveInterval: DefaultScanJobSaveInterval,
+		onSaveError:  logScanJobSaveError,
+		runs:         make(map[string]*scanJobRun),
+		stop:         make(chan struct{}),
+	}
+}
+
+// Close cancels the running jobs and stops retrying final saves, waiting until both
+// returned. Jobs whose final state was not saved are lost; no new job can start.
+func (s *ScanJobs) Close() error {
+	s.stopOnce.Do(func() { close(s.stop) })
+	s.mu.Lock()
+	for _, run := range s.runs {
+		run.cancel()
+	}
+	s.mu.Unlock()
+	s.workers.Wait()
+	return nil
+}
+
+// SetSaveInterval changes how often running jobs persist their progress
+func (s *ScanJobs) SetSaveInterval(interval time.Duration) {
+	s.saveInterval = interval
+}
+
+// SetSaveErrorHandler replaces the default logging of failed job saves
+func (s *ScanJobs) SetSaveErrorHandler(handler func(jobID string, err error)) {
+	if handler == nil {
+		handler = logScanJobSaveError
+	}
+	s.onSaveError = handler
+}
+
+func logScanJobSaveError(jobID string, err error) {
+	log.Printf("failed to save scan job %s: %v", jobID, err)
+}
+
+// SetScanJobs enables the StartScan, GetScanStatus and CancelScan functions. The jobs
+// are closed with the dispatcher (see UnifiedDispatcher.Close).
+func (d *UnifiedDispatcher) SetScanJobs(jobs *ScanJobs) {
+	d.scanJobs = jobs
+}
+
+type scanJobRun struct {
+	jobs   *ScanJobs
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu       sync.Mutex
+	job      *ScanJob
+	lastSave time.Time
+}
+
+// Start runs scan in the background and returns the new job. The job context keeps
+// the values of ctx but not its cancellation, so the job outlives the request.
+func (s *ScanJobs) Start(ctx context.Context, scan func(ctx context.Context) ([]byte, error)) (*ScanJob, error) {
+	if s.closed() {
+		return nil, errScanJobsClosed
+	}
+	now := clock.Now()
+	job := &ScanJob{ID: ids.Prefixed("scan"), State: OperationRunning, StartedAt: now, UpdatedAt: now}
+	if err := s.store.SaveScanJob(ctx, job); err != nil {
+		return nil, fmt.Errorf("failed to save scan job: %w", err)
+	}
+
+	jobCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	run := &scanJobRun{jobs: s, cancel: cancel, done: make(chan struct{}), job: job, lastSave: now}
+	s.mu.Lock()
+	if s.closed() {
+		s.mu.Unlock()
+		cancel()
+		return nil, errScanJobsClosed
+	}
+	s.runs[job.ID] = run
+	s.workers.Add(1)
+	s.mu.Unlock()
+
+	go func() {
+		defer s.workers.Done()
+		defer close(run.done)
+		defer cancel()
+		result, err := scan(context.WithValue(jobCtx, scanJobKey{}, run))
+		if saveErr := run.finish(jobCtx.Err(), result, err); saveErr != nil {
+			// the final state lives only in this process until a save succeeds,
+			// so keep serving it from runs while the save is retried
+			s.workers.Add(1)
+			go run.retryFinalSave()
+			return
+		}
+		s.forget(job.ID)
+	}()
+	return job.clone(false), nil
+}
+
+// errScanJobsClosed is returned when a job is started after Close
+var errScanJobsClosed = errors.New("scan jobs are closed")
+
+func (s *ScanJobs) closed() bool {
+	select {
+	case <-s.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// forget drops a finished run once its final state is persisted
+func (s *ScanJobs) forget(id string) {
+	s.mu.Lock()
+	delete(s.runs, id)
+	s.mu.Unlock()
+}
+
+// Status returns a job; partial results are included when withPartial is set
+func (s *ScanJobs) Status(ctx context.Context, id string, withPartial bool) (*ScanJob, error) {
+	s.mu.Lock()
+	run, ok := s.runs[id]
+	s.mu.Unlock()
+	if ok {
+		run.mu.Lock()
+		defer run.mu.Unlock()
+		return run.job.clone(withPartial), nil
+	}
+
+	job, err := s.store.LoadScanJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !job.Done() {
+		job.State = OperationFailed
+		job.Error = "scan job was interrupted by a provider restart"
+	}
+	return job.clone(withPartial), nil
+}
+
+// Cancel stops a running job and waits until its handlers returned. Cancelling a
+// finished job returns it unchanged.
+func (s *ScanJobs) Cancel(ctx context.Context, id string) (*ScanJob, error) {
+	s.mu.Lock()
+	run, ok := s.runs[id]
+	s.mu.Unlock()
+	if !ok {
+		return s.Status(ctx, id, false)
+	}
+	run.cancel()
+	select {
+	case <-run.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	return run.job.clone(false), nil
+}
+
+// Wait blocks until a job started by this process finished and returns it
+func (s *ScanJobs) Wait(ctx context.Context, id string) (*ScanJob, error) {
+	s.mu.Lock()
+	run, ok := s.runs[id]
+	s.mu.Unlock()
+	if ok {
+		select {
+		case <-run.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return s.Status(ctx, id, false)
+}
+
+func (r *scanJobRun) report(update ScanProgress) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.job.Done() {
+		return
+	}
+	if update.Progress > 0 {
+		r.job.Progress = update.Progress
+	}
+	if update.Message != "" {
+		r.job.Message = update.Message
+	}
+	r.job.PartialResults = append(r.job.PartialResults, update.Objects...)
+	r.job.ObjectsFound = len(r.job.PartialResults)
+	r.job.UpdatedAt = clock.Now()
+	if clock.Since(r.lastSave) >= r.jobs.saveInterval {
+		// progress saves are not fatal to the scan; the next update retries
+		_ = r.save()
+	}
+}
+
+// finish records the job's final state and returns the error of persisting it
+func (r *scanJobRun) finish(cancelled error, result []byte, err error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := clock.Now()
+	r.job.UpdatedAt, r.job.FinishedAt = now, &now
+	switch {
+	case cancelled != nil:
+		r.job.State = OperationCancelled
+		r.job.Message = "cancelled"
+	case err != nil:
+		r.job.State = OperationFailed
+		r.job.Error = err.Error()
+	default:
+		r.job.State = OperationSucceeded
+		r.job.Progress = 1
+		r.job.Result = result
+		var resp struct {
+			Objects []json.RawMessage `json:"objects"`
+		}
+		if json.Unmarshal(result, &resp) == nil && resp.Objects != nil {
+			r.job.ObjectsFound = len(resp.Objects)
+		}
+	}
+	return r.save()
+}
+
+// retryFinalSave persists a finished job with backoff until the store accepts it or the
+// jobs are closed, then drops the run so Status reads the job from the store
+func (r *scanJobRun) retryFinalSave() {
+	defer r.jobs.workers.Done()
+	delay := max(r.jobs.saveInterval, minScanJobSaveRetryDelay)
+	for {
+		select {
+		case <-clock.After(delay):
+		case <-r.jobs.stop:
+			return
+		}
+		r.mu.Lock()
+		err := r.save()
+		r.mu.Unlock()
+		if err == nil {
+			r.jobs.forget(r.job.ID)
+			return
+		}
+		if delay *= 2; delay > maxScanJobSaveRetryDelay {
+			delay = maxScanJobSaveRetryDelay
+		}
+	}
+}
+
+// save persists the job; the caller must hold the lock. Failures are reported to
+// the save error handler and returned; lastSave only advances on success so the
+// next progress update retries.
+func (r *scanJobRun) save() error {
+	if err := r.jobs.store.SaveScanJob(context.Background(), r.job.clone(true)); err != nil {
+		r.jobs.onSaveError(r.job.ID, err)
+		return err
+	}
+	r.lastSave = clock.Now()
+	return nil
+}
+
+// scanJobRequest is the input of GetScanStatus and CancelScan
+type scanJobRequest struct {
+	JobID          string `json:"job_id"`
+	IncludePartial bool   `json:"include_partial,omitempty"`
+}
+
+// handleStartScan starts a DiscoverResources request as a background job
+func (d *UnifiedDispatcher) handleStartScan(ctx context.Context, input []byte) ([]byte, error) {
+	if d.scanJobs == nil {
+		return nil, scanJobsNotEnabled()
+	}
+	// reject malformed requests now rather than in the job
+	var req map[string]interface{}
+	if err := security.SafeUnmarshal(input, &req); err != nil {
+		return nil, security.NewSecureError(
+			"invalid request format",
+			fmt.Sprintf("start scan request unmarshal failed: %v", err),
+			"INVALID_REQUEST",
+		)
+	}
+	job, err := d.scanJobs.Start(ctx, func(ctx context.Context) ([]byte, error) {
+		return d.handleDiscoverResources(ctx, input)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(job)
+}
+
+// handleGetScanStatus reports the progress of a scan job
+func (d *UnifiedDispatcher) handleGetScanStatus(ctx context.Context, input []byte) ([]byte, error) {
+	req, err := d.decodeScanJobRequest(input)
+	if err != nil {
+		return nil, err
+	}
+	job, err := d.scanJobs.Status(ctx, req.JobID, req.IncludePartial)
+	if err != nil {
+		return nil, scanJobError(req.JobID, err)
+	}
+	return json.Marshal(job)
+}
+
+// handleCancelScan stops a scan job
+func (d *UnifiedDispatcher) handleCancelScan(ctx context.Context, input []byte) ([]byte, error) {
+	req, err := d.decodeScanJobRequest(input)
+	if err != nil {
+		return nil, err
+	}
+	job, err := d.scanJobs.Cancel(ctx, req.JobID)
+	if err != nil {
+		return nil, scanJobError(req.JobID, err)
+	}
+	return json.Marshal(job)
+}
+
+func (d *UnifiedDispatcher) decodeScanJobRequest(input []byte) (*scanJobRequest, error) {
+	if d.scanJobs == nil {
+		return nil, scanJobsNotEnabled()
+	}
+	var req scanJobRequest
+	if err := security.SafeUnmarshal(input, &req); err != nil {
+		return nil, security.NewSecureError(
+			"invalid request format",
+			fmt.Sprintf("scan job request unmarshal failed: %v", err),
+			"INVALID_REQUEST",
+		)
+	}
+	if req.JobID == "" {
+		return nil, security.NewSecureError("invalid request format", "missing job_id in request", "INVALID_REQUEST")
+	}
+	return &req, nil
+}
+
+func scanJobsNotEnabled() error {
+	return security.NewSecureError("operation not supported", "scan jobs are not enabled", "SCAN_JOBS_NOT_ENABLED")
+}
+
+func scanJobError(id string, err error) error {
+	if errors.Is(err, ErrScanJobNotFound) {
+		return security.NewSecureError("scan job not found", fmt.Sprintf("scan job %s not found", id), "SCAN_JOB_NOT_FOUND")
+	}
+	return err
+}
@@ -0,0 +1,243 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// slowScanRegistry reports a batch of objects, then blocks until released or cancelled
+type slowScanRegistry struct {
+	reported chan struct{}
+	release  chan struct{}
+}
+
+func (r *slowScanRegistry) GetObjectTypes() map[string]*ObjectType {
+	return map[string]*ObjectType{"table": {Name: "table"}}
+}
+
+func (r *slowScanRegistry) CallHandler(ctx context.Context, objectType, method string, input []byte) ([]byte, error) {
+	ReportScanProgress(ctx, ScanProgress{Progress: 0.5, Message: "scanning public", Objects: []interface{}{map[string]interface{}{"id": "users"}}})
+	r.reported <- struct{}{}
+	select {
+	case <-r.release:
+		return []byte(`{"objects":[{"id":"users"},{"id":"orders"}]}`), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func dispatchScanJob(t *testing.T, d *UnifiedDispatcher, function, input string) *ScanJob {
+	t.Helper()
+	output, err := d.Dispatch(context.Background(), function, []byte(input))
+	if err != nil {
+		t.Fatalf("%s: %v", function, err)
+	}
+	var job ScanJob
+	if err := json.Unmarshal(output, &job); err != nil {
+		t.Fatalf("%s: %v", function, err)
+	}
+	return &job
+}
+
+// TestScanJobLifecycle validates progress, partial results, completion and persistence
+func TestScanJobLifecycle(t *testing.T) {
+	registry := &slowScanRegistry{reported: make(chan struct{}, 1), release: make(chan struct{})}
+	store := NewMemoryScanJobStore()
+	d := NewUnifiedDispatcher(nil, registry)
+	d.SetScanJobs(NewScanJobs(store))
+
+	started := dispatchScanJob(t, d, "StartScan", `{"resource_type":"table"}`)
+	if started.ID == "" || started.State != OperationRunning {
+		t.Fatalf("unexpected started job: %+v", started)
+	}
+	<-registry.reported
+
+	status := dispatchScanJob(t, d, "GetScanStatus", `{"job_id":"`+started.ID+`","include_partial":true}`)
+	if status.Progress != 0.5 || status.Message != "scanning public" || status.ObjectsFound != 1 || len(status.PartialResults) != 1 {
+		t.Fatalf("unexpected progress: %+v", status)
+	}
+
+	close(registry.release)
+	done, err := d.scanJobs.Wait(context.Background(), started.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if done.State != OperationSucceeded || done.ObjectsFound != 2 || done.FinishedAt == nil || len(done.Result) == 0 {
+		t.Fatalf("unexpected finished job: %+v", done)
+	}
+
+	// a new process finds the finished job in the store
+	restarted := NewUnifiedDispatcher(nil, registry)
+	restarted.SetScanJobs(NewScanJobs(store))
+	if persisted := dispatchScanJob(t, restarted, "GetScanStatus", `{"job_id":"`+started.ID+`"}`); persisted.State != OperationSucceeded {
+		t.Fatalf("unexpected persisted job: %+v", persisted)
+	}
+	if _, err := restarted.Dispatch(context.Background(), "GetScanStatus", []byte(`{"job_id":"scan-missing"}`)); err == nil {
+		t.Fatal("expected unknown job to fail")
+	}
+}
+
+// TestScanJobCancel validates cancellation and jobs interrupted by a restart
+func TestScanJobCancel(t *testing.T) {
+	registry := &slowScanRegistry{reported: make(chan struct{}, 1), release: make(chan struct{})}
+	store := NewMemoryScanJobStore()
+	d := NewUnifiedDispatcher(nil, registry)
+	d.SetScanJobs(NewScanJobs(store))
+
+	started := dispatchScanJob(t, d, "StartScan", `{"resource_type":"table"}`)
+	<-registry.reported
+	cancelled := dispatchScanJob(t, d, "CancelScan", `{"job_id":"`+started.ID+`"}`)
+	if cancelled.State != OperationCancelled || cancelled.FinishedAt == nil {
+		t.Fatalf("unexpected cancelled job: %+v", cancelled)
+	}
+
+	running := &ScanJob{ID: "scan-orphan", State: OperationRunning}
+	_ = store.SaveScanJob(context.Background(), running)
+	if orphan := dispatchScanJob(t, d, "GetScanStatus", `{"job_id":"scan-orphan"}`); orphan.State != OperationFailed || orphan.Error == "" {
+		t.Fatalf("expected an orphaned job to be reported as failed: %+v", orphan)
+	}
+
+	if _, err := NewUnifiedDispatcher(nil, registry).Dispatch(context.Background(), "StartScan", []byte(`{"resource_type":"table"}`)); err == nil {
+		t.Fatal("expected StartScan to fail without scan jobs")
+	}
+}
+
+// flakyScanJobStore fails saves of finished jobs until healed
+type flakyScanJobStore struct {
+	*MemoryScanJobStore
+
+	mu     sync.Mutex
+	broken bool
+}
+
+func (s *flakyScanJobStore) SaveScanJob(ctx context.Context, job *ScanJob) error {
+	s.mu.Lock()
+	broken := s.broken
+	s.mu.Unlock()
+	if broken && job.Done() {
+		return errors.New("state backend unavailable")
+	}
+	return s.MemoryScanJobStore.SaveScanJob(ctx, job)
+}
+
+func (s *flakyScanJobStore) heal() {
+	s.mu.Lock()
+	s.broken = false
+	s.mu.Unlock()
+}
+
+// timerClock reports every wait it is asked for and fires it when the test says so
+type timerClock struct {
+	waits chan time.Duration
+	fire  chan time.Time
+}
+
+func newTimerClock() *timerClock {
+	return &timerClock{waits: make(chan time.Duration, 10), fire: make(chan time.Time)}
+}
+
+func (c *timerClock) Now() time.Time { return time.Now() }
+
+func (c *timerClock) After(d time.Duration) <-chan time.Time {
+	c.waits <- d
+	return c.fire
+}
+
+// startBrokenScanJob runs a job to completion against a store failing its final save
+func startBrokenScanJob(t *testing.T) (*ScanJobs, *flakyScanJobStore, *UnifiedDispatcher, *ScanJob) {
+	t.Helper()
+	registry := &slowScanRegistry{reported: make(chan struct{}, 1), release: make(chan struct{})}
+	store := &flakyScanJobStore{MemoryScanJobStore: NewMemoryScanJobStore(), broken: true}
+	jobs := NewScanJobs(store)
+	jobs.SetSaveInterval(0)
+	saveErrors := make(chan string, 100)
+	jobs.SetSaveErrorHandler(func(jobID string, err error) {
+		select {
+		case saveErrors <- jobID:
+		default:
+		}
+	})
+	d := NewUnifiedDispatcher(nil, registry)
+	d.SetScanJobs(jobs)
+
+	started := dispatchScanJob(t, d, "StartScan", `{"resource_type":"table"}`)
+	<-registry.reported
+	close(registry.release)
+	if done, err := jobs.Wait(context.Background(), started.ID); err != nil || done.State != OperationSucceeded {
+		t.Fatalf("unexpected finished job: %+v, %v", done, err)
+	}
+	if id := <-saveErrors; id != started.ID {
+		t.Fatalf("expected save error for %s, got %s", started.ID, id)
+	}
+	return jobs, store, d, started
+}
+
+// TestScanJobFinalSaveRetry validates a finished job is kept until its final state is
+// saved, retrying with backoff on the core clock
+func TestScanJobFinalSaveRetry(t *testing.T) {
+	clk := newTimerClock()
+	defer SetClock(clk)()
+	jobs, store, d, started := startBrokenScanJob(t)
+	defer jobs.Close()
+
+	// the unsaved final state is still served from this process
+	if status := dispatchScanJob(t, d, "GetScanStatus", `{"job_id":"`+started.ID+`"}`); status.State != OperationSucceeded {
+		t.Fatalf("expected succeeded job while the save is retried: %+v", status)
+	}
+
+	// a zero save interval still waits the minimum delay, then backs off
+	for _, want := range []time.Duration{minScanJobSaveRetryDelay, 2 * minScanJobSaveRetryDelay} {
+		if got := <-clk.waits; got != want {
+			t.Fatalf("retry delay = %s, want %s", got, want)
+		}
+		if want == 2*minScanJobSaveRetryDelay {
+			store.heal()
+		}
+		clk.fire <- time.Now()
+	}
+
+	if _, err := jobs.Wait(context.Background(), started.ID); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		persisted, err := store.LoadScanJob(context.Background(), started.ID)
+		if err == nil && persisted.State == OperationSucceeded {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("final state was never persisted: %+v, %v", persisted, err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestScanJobsClose validates Close stops final save retries and new jobs
+func TestScanJobsClose(t *testing.T) {
+	clk := newTimerClock()
+	defer SetClock(clk)()
+	jobs, _, d, _ := startBrokenScanJob(t)
+	<-clk.waits
+
+	closed := make(chan error, 1)
+	go func() { closed <- d.Close() }()
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close did not stop the final save retry")
+	}
+
+	if _, err := jobs.Start(context.Background(), func(ctx context.Context) ([]byte, error) { return nil, nil }); err == nil {
+		t.Error("expected starting a job after Close to fail")
+	}
+	if err := jobs.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+// Package core provides memoized provider schemas
+package core
+
+import (
+	"sync"
+)
+
+// SchemaGenerationer is implemented by registries that can report when their
+// contents change. The create and discover registries bump their generation on every
+// RegisterHandler call.
+type SchemaGenerationer interface {
+	Generation() uint64
+}
+
+// SchemaMemo memoizes a schema builder. Schema() is called for every plan and
+// validation, and rebuilding it from registries allocates for every resource type, so
+// providers build it once and call Invalidate when what it is built from changes.
+//
+// The returned schema is shared between callers and must not be modified.
+type SchemaMemo struct {
+	build func() (*Schema, error)
+
+	mu     sync.Mutex
+	schema *Schema
+	stamp  []uint64
+}
+
+// NewSchemaMemo memoizes build
+func NewSchemaMemo(build func() (*Schema, error)) *SchemaMemo {
+	return &SchemaMemo{build: build}
+}
+
+// Get returns the memoized schema, building it on first use. Errors are not cached.
+func (m *SchemaMemo) Get() (*Schema, error) {
+	return m.get(nil)
+}
+
+// get rebuilds when stamp differs from the stamp of the memoized schema
+func (m *SchemaMemo) get(stamp []uint64) (*Schema, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.schema != nil && sameStamp(m.stamp, stamp) {
+		return m.schema, nil
+	}
+	schema, err := m.build()
+	if err != nil {
+		return nil, err
+	}
+	m.schema, m.stamp = schema, stamp
+	return schema, nil
+}
+
+// Invalidate drops the memoized schema so the next Get rebuilds it
+func (m *SchemaMemo) Invalidate() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.schema, m.stamp = nil, nil
+}
+
+func sameStamp(a, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// CachedSchema returns BuildCompatibleSchema memoized. The schema is rebuilt when the
+// arguments change, when a registry implementing SchemaGenerationer registers a new
+// handler, or after InvalidateSchema. The returned schema must not be modified.
+func (d *UnifiedDispatcher) CachedSchema(name, version, providerType, description string) *Schema {
+	d.schemaMu.Lock()
+	key := [4]string{name, version, providerType, description}
+	if d.schemaMemo == nil || d.schemaKey != key {
+		d.schemaKey = key
+		d.schemaMemo = NewSchemaMemo(func() (*Schema, error) {
+			return d.BuildCompatibleSchema(name, version, providerType, description), nil
+		})
+	}
+	memo := d.schemaMemo
+	d.schemaMu.Unlock()
+
+	schema, _ := memo.get(d.registryStamp())
+	return schema
+}
+
+// InvalidateSchema drops the schema memoized by CachedSchema. Providers call it after
+// changing registries that do not implement SchemaGenerationer.
+func (d *UnifiedDispatcher) InvalidateSchema() {
+	d.schemaMu.Lock()
+	defer d.schemaMu.Unlock()
+	if d.schemaMemo != nil {
+		d.schemaMemo.Invalidate()
+	}
+}
+
+// registryStamp captures the generations of both registries
+func (d *UnifiedDispatcher) registryStamp() []uint64 {
+	stamp := make([]uint64, 2)
+	if g, ok := d.createRegistry.(SchemaGenerationer); ok {
+		stamp[0] = g.Generation()
+	}
+	if g, ok := d.discoverRegistry.(SchemaGenerationer); ok {
+		stamp[1] = g.Generation()
+	}
+	return stamp
+}
@@ -0,0 +1,96 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+// generationRegistry is a registry that reports a generation like create.Registry
+type generationRegistry struct {
+	types      map[string]*ObjectType
+	generation uint64
+}
+
+func newGenerationRegistry(n int) *generationRegistry {
+	r := &generationRegistry{types: make(map[string]*ObjectType)}
+	for i := 0; i < n; i++ {
+		r.add(fmt.Sprintf("type_%03d", i))
+	}
+	return r
+}
+
+func (r *generationRegistry) add(name string) {
+	r.types[name] = &ObjectType{Name: name, Description: "generated " + name, Type: CREATE}
+	r.generation++
+}
+
+func (r *generationRegistry) Generation() uint64 { return r.generation }
+
+func (r *generationRegistry) GetObjectTypes() map[string]*ObjectType {
+	out := make(map[string]*ObjectType, len(r.types))
+	for k, v := range r.types {
+		out[k] = v
+	}
+	return out
+}
+
+func (r *generationRegistry) CallHandler(ctx context.Context, objectType, method string, input []byte) ([]byte, error) {
+	return nil, nil
+}
+
+// TestCachedSchema validates memoization and invalidation on handler registration
+func TestCachedSchema(t *testing.T) {
+	registry := newGenerationRegistry(3)
+	d := NewUnifiedDispatcher(registry, nil)
+
+	first := d.CachedSchema("p", "1.0.0", "database", "test")
+	if second := d.CachedSchema("p", "1.0.0", "database", "test"); second != first {
+		t.Fatal("expected the memoized schema to be reused")
+	}
+	if other := d.CachedSchema("p", "1.1.0", "database", "test"); other == first || other.Version != "1.1.0" {
+		t.Fatal("expected a rebuild when the arguments change")
+	}
+
+	before := d.CachedSchema("p", "1.1.0", "database", "test")
+	registry.add("late_type")
+	after := d.CachedSchema("p", "1.1.0", "database", "test")
+	if after == before || len(after.ResourceTypes) != 4 {
+		t.Fatalf("expected registering a handler to rebuild the schema, got %d types", len(after.ResourceTypes))
+	}
+
+	d.InvalidateSchema()
+	if rebuilt := d.CachedSchema("p", "1.1.0", "database", "test"); rebuilt == after {
+		t.Fatal("expected InvalidateSchema to force a rebuild")
+	}
+
+	calls := 0
+	memo := NewSchemaMemo(func() (*Schema, error) { calls++; return &Schema{}, nil })
+	memo.Get()
+	memo.Get()
+	memo.Invalidate()
+	memo.Get()
+	if calls != 2 {
+		t.Fatalf("expected 2 builds, got %d", calls)
+	}
+}
+
+func BenchmarkBuildCompatibleSchema(b *testing.B) {
+	d := NewUnifiedDispatcher(newGenerationRegistry(200), newGenerationRegistry(50))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		d.BuildCompatibleSchema("p", "1.0.0", "database", "bench")
+	}
+}
+
+func BenchmarkCachedSchema(b *testing.B) {
+	d := NewUnifiedDispatcher(newGenerationRegistry(200), newGenerationRegistry(50))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		d.CachedSchema("p", "1.0.0", "database", "bench")
+	}
+}
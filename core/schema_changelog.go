@@ -0,0 +1,109 @@
+// Package core provides changelogs generated from provider schema diffs
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DiffSchemas compares the resource types of two provider schemas, with schema mixins
+// composed in. Resource types whose mixins cannot be composed are compared as declared.
+func DiffSchemas(older, newer *Schema) *DocsChangelog {
+	changelog := &DocsChangelog{}
+	var oldResources, newResources map[string]*ResourceDoc
+	if older != nil {
+		changelog.FromVersion = older.Version
+		oldResources = schemaResourceDocs(older)
+	}
+	if newer != nil {
+		changelog.ToVersion = newer.Version
+		newResources = schemaResourceDocs(newer)
+	}
+	diffResources(changelog, oldResources, newResources)
+	return changelog
+}
+
+// schemaResourceDocs maps a schema's resource types to the documentation diffs compare
+func schemaResourceDocs(schema *Schema) map[string]*ResourceDoc {
+	resourceTypes, err := schema.ComposedResourceTypes()
+	if err != nil {
+		resourceTypes = schema.ResourceTypes
+	}
+	docs := make(map[string]*ResourceDoc, len(resourceTypes))
+	for _, rt := range resourceTypes {
+		docs[rt.Name] = &ResourceDoc{
+			Description: rt.Description,
+			Operations:  rt.Operations,
+			Schema:      rt.ConfigSchema,
+			StateSchema: rt.StateSchema,
+		}
+	}
+	return docs
+}
+
+// Markdown renders the changelog as a CHANGELOG section with Added, Changed, Deprecated
+// and Removed subsections. title heads the section and defaults to the newer version.
+func (c *DocsChangelog) Markdown(title string) string {
+	if title == "" {
+		title = c.ToVersion
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s\n\n", title)
+	if c.FromVersion != "" {
+		fmt.Fprintf(&b, "Schema changes since %s.\n\n", c.FromVersion)
+	}
+	if c.Empty() {
+		b.WriteString("No schema changes.\n")
+		return b.String()
+	}
+
+	var added, changed, deprecated, removed []string
+	for _, name := range c.AddedResources {
+		added = append(added, fmt.Sprintf("Resource `%s`", name))
+	}
+	for _, name := range c.DeprecatedResources {
+		deprecated = append(deprecated, fmt.Sprintf("Resource `%s`", name))
+	}
+	for _, name := range c.RemovedResources {
+		removed = append(removed, fmt.Sprintf("Resource `%s`", name))
+	}
+	names := make([]string, 0, len(c.ChangedResources))
+	for name := range c.ChangedResources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		diff := c.ChangedResources[name]
+		item := func(kind string, props []string) []string {
+			var items []string
+			for _, p := range props {
+				items = append(items, fmt.Sprintf("`%s`: %s `%s`", name, kind, p))
+			}
+			return items
+		}
+		added = append(added, item("argument", diff.AddedArguments)...)
+		added = append(added, item("attribute", diff.AddedAttributes)...)
+		changed = append(changed, item("argument", diff.ChangedArguments)...)
+		changed = append(changed, item("attribute", diff.ChangedAttributes)...)
+		deprecated = append(deprecated, item("argument", diff.DeprecatedArguments)...)
+		deprecated = append(deprecated, item("attribute", diff.DeprecatedAttributes)...)
+		removed = append(removed, item("argument", diff.RemovedArguments)...)
+		removed = append(removed, item("attribute", diff.RemovedAttributes)...)
+	}
+
+	for _, section := range []struct {
+		heading string
+		items   []string
+	}{{"Added", added}, {"Changed", changed}, {"Deprecated", deprecated}, {"Removed", removed}} {
+		if len(section.items) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "### %s\n\n", section.heading)
+		for _, item := range section.items {
+			fmt.Fprintf(&b, "- %s\n", item)
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,50 @@
+package core
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestDiffSchemas validates added, changed, deprecated and removed resources and arguments
+func TestDiffSchemas(t *testing.T) {
+	older := &Schema{Version: "1.0.0", ResourceTypes: []ResourceTypeDefinition{
+		{Name: "table", ConfigSchema: json.RawMessage(`{"properties": {"name": {"type": "string"}, "owner": {"type": "string"}}}`)},
+		{Name: "index"},
+		{Name: "sequence"},
+	}}
+	newer := &Schema{Version: "1.1.0", ResourceTypes: []ResourceTypeDefinition{
+		{Name: "table", ConfigSchema: json.RawMessage(`{"properties": {"name": {"type": "integer"}, "owner": {"type": "string", "deprecated": true}, "comment": {"type": "string"}}}`)},
+		{Name: "sequence", ConfigSchema: json.RawMessage(`{"deprecated": true}`)},
+		{Name: "view"},
+	}}
+
+	changelog := DiffSchemas(older, newer)
+	if changelog.FromVersion != "1.0.0" || changelog.ToVersion != "1.1.0" {
+		t.Errorf("versions = %s -> %s", changelog.FromVersion, changelog.ToVersion)
+	}
+	diff := changelog.ChangedResources["table"]
+	if diff == nil || len(diff.ChangedArguments) != 1 || diff.ChangedArguments[0] != "name" ||
+		len(diff.DeprecatedArguments) != 1 || diff.DeprecatedArguments[0] != "owner" {
+		t.Fatalf("unexpected table diff: %+v", diff)
+	}
+	if len(changelog.DeprecatedResources) != 1 || changelog.DeprecatedResources[0] != "sequence" {
+		t.Errorf("deprecated resources = %v", changelog.DeprecatedResources)
+	}
+
+	md := changelog.Markdown("")
+	for _, want := range []string{
+		"## 1.1.0",
+		"### Added\n\n- Resource `view`\n- `table`: argument `comment`\n",
+		"### Changed\n\n- `table`: argument `name`\n",
+		"### Deprecated\n\n- Resource `sequence`\n- `table`: argument `owner`\n",
+		"### Removed\n\n- Resource `index`\n",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown is missing %q:\n%s", want, md)
+		}
+	}
+	if got := DiffSchemas(newer, newer).Markdown("Unreleased"); !strings.Contains(got, "No schema changes.") {
+		t.Errorf("unexpected markdown for identical schemas:\n%s", got)
+	}
+}
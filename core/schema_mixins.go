@@ -0,0 +1,263 @@
+// Package core provides reusable schema fragments composed into resource types
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Built-in schema mixins
+const (
+	MixinMetadata   = "metadata"
+	MixinTags       = "tags"
+	MixinTimestamps = "timestamps"
+
+	// MixinApplyStrategy adds the apply_strategy attribute to resource types whose
+	// handlers support blue/green or shadow updates
+	MixinApplyStrategy = "apply_strategy"
+)
+
+// SchemaMixin is a named set of JSON Schema properties shared by several resource
+// types. ResourceTypeDefinition.Mixins references mixins by name; composing the
+// resource type merges the mixin's properties into its config and state schemas.
+type SchemaMixin struct {
+	Name             string                     `json:"name"`
+	Description      string                     `json:"description,omitempty"`
+	ConfigProperties map[string]json.RawMessage `json:"config_properties,omitempty"`
+	ConfigRequired   []string                   `json:"config_required,omitempty"`
+	StateProperties  map[string]json.RawMessage `json:"state_properties,omitempty"`
+}
+
+// SchemaMixinConflictError is returned when two mixins of a resource type define the
+// same property differently
+type SchemaMixinConflictError struct {
+	ResourceType string
+	Property     string
+	Mixins       []string
+}
+
+func (e *SchemaMixinConflictError) Error() string {
+	return fmt.Sprintf("resource type %s: mixins %s define property %q differently",
+		e.ResourceType, strings.Join(e.Mixins, " and "), e.Property)
+}
+
+var (
+	schemaMixinsMu sync.RWMutex
+	schemaMixins   = map[string]*SchemaMixin{
+		MixinMetadata: {
+			Name:        MixinMetadata,
+			Description: "Common descriptive metadata",
+			ConfigProperties: map[string]json.RawMessage{
+				"description": json.RawMessage(`{"type":"string","description":"Human readable description of the resource"}`),
+				"owner":       json.RawMessage(`{"type":"string","description":"Team or person responsible for the resource"}`),
+				"labels":      json.RawMessage(`{"type":"object","additionalProperties":{"type":"string"},"description":"Free-form key/value labels"}`),
+			},
+		},
+		MixinTags: {
+			Name:        MixinTags,
+			Description: "Key/value tags",
+			ConfigProperties: map[string]json.RawMessage{
+				"tags": json.RawMessage(`{"type":"object","additionalProperties":{"type":"string"},"description":"Tags applied to the resource"}`),
+			},
+			StateProperties: map[string]json.RawMessage{
+				"tags": json.RawMessage(`{"type":"object","additionalProperties":{"type":"string"},"description":"Tags applied to the resource"}`),
+			},
+		},
+		MixinTimestamps: {
+			Name:        MixinTimestamps,
+			Description: "Creation and modification timestamps",
+			StateProperties: map[string]json.RawMessage{
+				"created_at": json.RawMessage(`{"type":"string","format":"date-time","description":"When the resource was created"}`),
+				"updated_at": json.RawMessage(`{"type":"string","format":"date-time","description":"When the resource was last modified"}`),
+			},
+		},
+		MixinApplyStrategy: {
+			Name:        MixinApplyStrategy,
+			Description: "Selection of how updates are rolled out",
+			ConfigProperties: map[string]json.RawMessage{
+				"apply_strategy": json.RawMessage(`{"type":"string","enum":["in_place","blue_green","shadow"],"default":"in_place","description":"How updates are applied: in place, blue/green (create new, swap, retire old) or shadow (copy, validate, promote)"}`),
+			},
+		},
+	}
+)
+
+// RegisterSchemaMixin adds or replaces a mixin available to every schema
+func RegisterSchemaMixin(mixin *SchemaMixin) {
+	schemaMixinsMu.Lock()
+	defer schemaMixinsMu.Unlock()
+	schemaMixins[mixin.Name] = mixin
+}
+
+// LookupSchemaMixin returns the mixin registered under name
+func LookupSchemaMixin(name string) (*SchemaMixin, bool) {
+	schemaMixinsMu.RLock()
+	defer schemaMixinsMu.RUnlock()
+	mixin, ok := schemaMixins[name]
+	return mixin, ok
+}
+
+// lookupMixin resolves a mixin name, preferring mixins declared on the schema itself
+func (s *Schema) lookupMixin(name string) (*SchemaMixin, bool) {
+	if s != nil {
+		if mixin, ok := s.Mixins[name]; ok && mixin != nil {
+			return mixin, true
+		}
+	}
+	return LookupSchemaMixin(name)
+}
+
+// ComposeResourceType returns rt with the properties of its mixins merged into its
+// config and state schemas. Mixins are applied in order; properties the resource type
+// defines itself take precedence over mixin properties. Composing an already composed
+// resource type returns it unchanged.
+func (s *Schema) ComposeResourceType(rt ResourceTypeDefinition) (ResourceTypeDefinition, error) {
+	if len(rt.Mixins) == 0 {
+		return rt, nil
+	}
+
+	mixins := make([]*SchemaMixin, 0, len(rt.Mixins))
+	for _, name := range rt.Mixins {
+		mixin, ok := s.lookupMixin(name)
+		if !ok {
+			return rt, fmt.Errorf("resource type %s: unknown schema mixin %q", rt.Name, name)
+		}
+		mixins = append(mixins, mixin)
+	}
+
+	config, err := composeSchemaProperties(rt.Name, rt.ConfigSchema, mixins, func(m *SchemaMixin) (map[string]json.RawMessage, []string) {
+		return m.ConfigProperties, m.ConfigRequired
+	})
+	if err != nil {
+		return rt, err
+	}
+	state, err := composeSchemaProperties(rt.Name, rt.StateSchema, mixins, func(m *SchemaMixin) (map[string]json.RawMessage, []string) {
+		return m.StateProperties, nil
+	})
+	if err != nil {
+		return rt, err
+	}
+
+	composed := rt
+	composed.ConfigSchema = config
+	composed.StateSchema = state
+	composed.Mixins = append([]string(nil), rt.Mixins...)
+	return composed, nil
+}
+
+// ComposedResourceTypes returns every resource type with its mixins applied
+func (s *Schema) ComposedResourceTypes() ([]ResourceTypeDefinition, error) {
+	composed := make([]ResourceTypeDefinition, len(s.ResourceTypes))
+	for i, rt := range s.ResourceTypes {
+		c, err := s.ComposeResourceType(rt)
+		if err != nil {
+			return nil, err
+		}
+		composed[i] = c
+	}
+	return composed, nil
+}
+
+// ComposeMixins applies mixins to the schema's resource types in place, so docs,
+// validation and example generation all see the composed schemas
+func (s *Schema) ComposeMixins() error {
+	composed, err := s.ComposedResourceTypes()
+	if err != nil {
+		return err
+	}
+	s.ResourceTypes = composed
+	return nil
+}
+
+// composeSchemaProperties merges mixin properties into a JSON Schema object
+func composeSchemaProperties(resourceType string, schema json.RawMessage, mixins []*SchemaMixin, parts func(*SchemaMixin) (map[string]json.RawMessage, []string)) (json.RawMessage, error) {
+	contributes := false
+	for _, m := range mixins {
+		if props, _ := parts(m); len(props) > 0 {
+			contributes = true
+			break
+		}
+	}
+	if !contributes {
+		return schema, nil
+	}
+
+	doc := map[string]json.RawMessage{}
+	if len(bytes.TrimSpace(schema)) > 0 {
+		if err := json.Unmarshal(schema, &doc); err != nil {
+			return nil, fmt.Errorf("resource type %s: schema is not a JSON object: %w", resourceType, err)
+		}
+	}
+	if _, ok := doc["type"]; !ok {
+		doc["type"] = json.RawMessage(`"object"`)
+	}
+	properties := map[string]json.RawMessage{}
+	if raw, ok := doc["properties"]; ok {
+		if err := json.Unmarshal(raw, &properties); err != nil {
+			return nil, fmt.Errorf("resource type %s: invalid properties: %w", resourceType, err)
+		}
+	}
+	var required []string
+	if raw, ok := doc["required"]; ok {
+		if err := json.Unmarshal(raw, &required); err != nil {
+			return nil, fmt.Errorf("resource type %s: invalid required list: %w", resourceType, err)
+		}
+	}
+
+	own := make(map[string]bool, len(properties))
+	for name := range properties {
+		own[name] = true
+	}
+	origin := map[string]string{}
+	for _, m := range mixins {
+		props, req := parts(m)
+		names := make([]string, 0, len(props))
+		for name := range props {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if own[name] {
+				continue
+			}
+			if previous, ok := origin[name]; ok {
+				if !sameJSON(properties[name], props[name]) {
+					return nil, &SchemaMixinConflictError{ResourceType: resourceType, Property: name, Mixins: []string{previous, m.Name}}
+				}
+				continue
+			}
+			properties[name] = props[name]
+			origin[name] = m.Name
+		}
+		for _, name := range req {
+			if !containsString(required, name) {
+				required = append(required, name)
+			}
+		}
+	}
+
+	var err error
+	if doc["properties"], err = json.Marshal(properties); err != nil {
+		return nil, err
+	}
+	if len(required) > 0 {
+		if doc["required"], err = json.Marshal(required); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(doc)
+}
+
+// sameJSON reports whether two JSON documents are equal ignoring formatting
+func sameJSON(a, b json.RawMessage) bool {
+	var x, y interface{}
+	if json.Unmarshal(a, &x) != nil || json.Unmarshal(b, &y) != nil {
+		return bytes.Equal(a, b)
+	}
+	ja, _ := json.Marshal(x)
+	jb, _ := json.Marshal(y)
+	return bytes.Equal(ja, jb)
+}
@@ -0,0 +1,100 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestComposeResourceTypeMergesMixins validates mixin properties reach config and state schemas
+func TestComposeResourceTypeMergesMixins(t *testing.T) {
+	schema := &Schema{
+		ResourceTypes: []ResourceTypeDefinition{{
+			Name:         "table",
+			ConfigSchema: json.RawMessage(`{"type":"object","properties":{"name":{"type":"string"},"owner":{"type":"string","description":"Owning schema"}},"required":["name"]}`),
+			Mixins:       []string{MixinMetadata, MixinTags, MixinTimestamps},
+		}},
+	}
+
+	if err := schema.ComposeMixins(); err != nil {
+		t.Fatalf("ComposeMixins: %v", err)
+	}
+	rt := schema.ResourceTypes[0]
+
+	args := BuildArgumentDocsFromSchema(rt.ConfigSchema)
+	for _, name := range []string{"name", "description", "labels", "tags"} {
+		if _, ok := args[name]; !ok {
+			t.Errorf("config schema missing %s: %s", name, rt.ConfigSchema)
+		}
+	}
+	owner := args["owner"].(map[string]interface{})
+	if owner["description"] != "Owning schema" {
+		t.Errorf("resource type's own property should win, got %v", owner["description"])
+	}
+
+	attrs := BuildAttributeDocsFromStateSchema(rt.StateSchema)
+	for _, name := range []string{"created_at", "updated_at", "tags"} {
+		if _, ok := attrs[name]; !ok {
+			t.Errorf("state schema missing %s: %s", name, rt.StateSchema)
+		}
+	}
+
+	again, err := schema.ComposeResourceType(rt)
+	if err != nil || string(again.ConfigSchema) != string(rt.ConfigSchema) {
+		t.Errorf("composition should be idempotent: %v\n%s\n%s", err, again.ConfigSchema, rt.ConfigSchema)
+	}
+}
+
+// TestComposeResourceTypeRequiredAndExamples validates mixin required properties flow into examples
+func TestComposeResourceTypeRequiredAndExamples(t *testing.T) {
+	schema := &Schema{
+		Mixins: map[string]*SchemaMixin{
+			"ownership": {
+				Name:             "ownership",
+				ConfigProperties: map[string]json.RawMessage{"team": json.RawMessage(`{"type":"string","examples":["data-platform"]}`)},
+				ConfigRequired:   []string{"team"},
+			},
+		},
+	}
+	rt, err := schema.ComposeResourceType(ResourceTypeDefinition{
+		Name:         "topic",
+		ConfigSchema: json.RawMessage(`{"properties":{"name":{"type":"string"}},"required":["name"]}`),
+		Mixins:       []string{"ownership"},
+	})
+	if err != nil {
+		t.Fatalf("ComposeResourceType: %v", err)
+	}
+
+	example := GenerateResourceExample("create", "topic", rt.ConfigSchema)
+	if !strings.Contains(example.HCL, `team = "data-platform"`) {
+		t.Errorf("example missing mixin attribute:\n%s", example.HCL)
+	}
+}
+
+// TestComposeResourceTypeErrors validates unknown mixins and conflicting definitions are reported
+func TestComposeResourceTypeErrors(t *testing.T) {
+	schema := &Schema{
+		Mixins: map[string]*SchemaMixin{
+			"labels_v2": {
+				Name:             "labels_v2",
+				ConfigProperties: map[string]json.RawMessage{"labels": json.RawMessage(`{"type":"array"}`)},
+			},
+		},
+	}
+
+	if _, err := schema.ComposeResourceType(ResourceTypeDefinition{Name: "t", Mixins: []string{"missing"}}); err == nil {
+		t.Error("expected error for unknown mixin")
+	}
+
+	_, err := schema.ComposeResourceType(ResourceTypeDefinition{Name: "t", Mixins: []string{MixinMetadata, "labels_v2"}})
+	var conflict *SchemaMixinConflictError
+	if !errors.As(err, &conflict) || conflict.Property != "labels" {
+		t.Fatalf("expected labels conflict, got %v", err)
+	}
+
+	schema.ResourceTypes = []ResourceTypeDefinition{{Name: "t", Mixins: []string{"missing"}}}
+	if result := schema.ValidateConfig(map[string]interface{}{}); result.Valid {
+		t.Error("ValidateConfig should fail for an unresolvable mixin")
+	}
+}
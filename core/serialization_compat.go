@@ -0,0 +1,278 @@
+// Package core provides serialization compatibility modes negotiated with Kolumn core
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/schemabounce/kolumn/sdk/helpers/security"
+)
+
+// Field casings of RPC payloads
+const (
+	CasingSnake = "snake_case" // the SDK's native casing
+	CasingCamel = "camelCase"
+)
+
+// SerializationMode is how payloads are shaped for one core version
+type SerializationMode struct {
+	// Casing of envelope field names; empty means CasingSnake
+	Casing string `json:"field_casing,omitempty"`
+
+	// LegacyFields renders warnings as the plain strings older cores decode and copies
+	// response fields to their legacy names listed in Aliases
+	LegacyFields bool `json:"legacy_fields,omitempty"`
+
+	// Aliases maps response fields to the legacy names also populated with
+	// LegacyFields, e.g. {"resource_id": "id"}
+	Aliases map[string]string `json:"-"`
+}
+
+// SerializationRule selects a mode for cores up to MaxCoreVersion; the maximum is
+// compared only to as many components as it has (see CheckCoreVersion)
+type SerializationRule struct {
+	MaxCoreVersion string
+	Mode           SerializationMode
+}
+
+// serializationNestedFields are envelope objects whose own field names are adapted.
+// Config, state, metadata and outputs hold user data and keep their keys.
+var serializationNestedFields = map[string]bool{
+	"response_metadata": true,
+	"operation":         true,
+	"options":           true,
+	"warnings":          true,
+	"diagnostics":       true,
+}
+
+// serializationCompat holds a dispatcher's rules and the negotiated mode
+type serializationCompat struct {
+	rules []SerializationRule
+
+	mu   sync.RWMutex
+	mode SerializationMode
+}
+
+// SetSerializationCompat enables negotiated serialization modes. Cores announce their
+// version, and optionally the mode they want, in the Ping handshake:
+//
+//	{"core_version": "1.1.0", "field_casing": "camelCase", "legacy_fields": true}
+//
+// An announced mode wins; otherwise the first rule whose MaxCoreVersion covers the
+// core version applies, and cores matching no rule get the native snake_case payloads.
+// The Ping response reports the chosen mode in "field_casing" and "legacy_fields".
+// The handshake itself is always snake_case so both sides can read it.
+func (d *UnifiedDispatcher) SetSerializationCompat(rules ...SerializationRule) error {
+	for _, rule := range rules {
+		if err := validateSerializationMode(rule.Mode); err != nil {
+			return err
+		}
+		if err := ValidateCoreVersionRange("", rule.MaxCoreVersion); err != nil {
+			return err
+		}
+	}
+	d.serialization = &serializationCompat{rules: rules}
+	return nil
+}
+
+// SerializationMode returns the negotiated mode; the zero mode before the handshake or
+// when compatibility modes are off
+func (d *UnifiedDispatcher) SerializationMode() SerializationMode {
+	if d.serialization == nil {
+		return SerializationMode{}
+	}
+	d.serialization.mu.RLock()
+	defer d.serialization.mu.RUnlock()
+	return d.serialization.mode
+}
+
+func validateSerializationMode(mode SerializationMode) error {
+	switch mode.Casing {
+	case "", CasingSnake, CasingCamel:
+		return nil
+	}
+	return fmt.Errorf("unknown field casing %q, use %s or %s", mode.Casing, CasingSnake, CasingCamel)
+}
+
+// pingSerialization negotiates the session mode from a Ping request
+func (d *UnifiedDispatcher) pingSerialization(input []byte, response map[string]interface{}) error {
+	s := d.serialization
+	if s == nil {
+		return nil
+	}
+	var req struct {
+		CoreVersion  string `json:"core_version"`
+		FieldCasing  string `json:"field_casing"`
+		LegacyFields *bool  `json:"legacy_fields"`
+	}
+	if len(input) > 0 {
+		_ = json.Unmarshal(input, &req)
+	}
+
+	var mode SerializationMode
+	if req.CoreVersion != "" {
+		for _, rule := range s.rules {
+			if CheckCoreVersion(req.CoreVersion, "", rule.MaxCoreVersion) == nil {
+				mode = rule.Mode
+				break
+			}
+		}
+	}
+	if req.FieldCasing != "" {
+		mode.Casing = req.FieldCasing
+	}
+	if req.LegacyFields != nil {
+		mode.LegacyFields = *req.LegacyFields
+	}
+	if err := validateSerializationMode(mode); err != nil {
+		return security.NewSecureError("unsupported serialization mode", err.Error(), "INVALID_REQUEST")
+	}
+	if mode.Casing == "" {
+		mode.Casing = CasingSnake
+	}
+
+	s.mu.Lock()
+	s.mode = mode
+	s.mu.Unlock()
+	response["field_casing"] = mode.Casing
+	response["legacy_fields"] = mode.LegacyFields
+	return nil
+}
+
+// decodeRequest converts a request from the negotiated casing to snake_case
+func (s *serializationCompat) decodeRequest(input []byte) ([]byte, error) {
+	s.mu.RLock()
+	mode := s.mode
+	s.mu.RUnlock()
+	if mode.Casing != CasingCamel || len(input) == 0 {
+		return input, nil
+	}
+	var fields map[string]json.RawMessage
+	if err := security.SafeUnmarshal(input, &fields); err != nil {
+		return nil, security.NewSecureError(
+			"invalid request format",
+			fmt.Sprintf("camelCase request decoding failed: %v", err),
+			"INVALID_REQUEST",
+		)
+	}
+	return json.Marshal(renameFields(fields, SnakeCase))
+}
+
+// encodeResponse shapes a response for the negotiated mode; responses that are not
+// JSON objects are returned unchanged
+func (s *serializationCompat) encodeResponse(output []byte) []byte {
+	s.mu.RLock()
+	mode := s.mode
+	s.mu.RUnlock()
+	if (mode.Casing != CasingCamel && !mode.LegacyFields) || len(output) == 0 || output[0] != '{' {
+		return output
+	}
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(output, &fields) != nil {
+		return output
+	}
+	if mode.LegacyFields {
+		populateLegacyFields(fields, mode.Aliases)
+	}
+	if mode.Casing == CasingCamel {
+		fields = renameFields(fields, CamelCase)
+	}
+	adapted, err := json.Marshal(fields)
+	if err != nil {
+		return output
+	}
+	return adapted
+}
+
+// populateLegacyFields renders warnings as strings and fills aliased fields
+func populateLegacyFields(fields map[string]json.RawMessage, aliases map[string]string) {
+	if raw, ok := fields["warnings"]; ok {
+		var warnings []Warning
+		if json.Unmarshal(raw, &warnings) == nil {
+			messages := make([]string, len(warnings))
+			for i, w := range warnings {
+				messages[i] = w.String()
+			}
+			if data, err := json.Marshal(messages); err == nil {
+				fields["warnings"] = data
+			}
+		}
+	}
+	for field, legacy := range aliases {
+		if value, ok := fields[field]; ok {
+			if _, taken := fields[legacy]; !taken {
+				fields[legacy] = value
+			}
+		}
+	}
+}
+
+// renameFields renames the envelope fields of a payload, descending into the nested
+// envelope objects and arrays of objects listed in serializationNestedFields
+func renameFields(fields map[string]json.RawMessage, rename func(string) string) map[string]json.RawMessage {
+	renamed := make(map[string]json.RawMessage, len(fields))
+	for key, value := range fields {
+		if serializationNestedFields[SnakeCase(key)] {
+			value = renameNested(value, rename)
+		}
+		renamed[rename(key)] = value
+	}
+	return renamed
+}
+
+func renameNested(value json.RawMessage, rename func(string) string) json.RawMessage {
+	var object map[string]json.RawMessage
+	if json.Unmarshal(value, &object) == nil && object != nil {
+		if data, err := json.Marshal(renameFields(object, rename)); err == nil {
+			return data
+		}
+		return value
+	}
+	var items []json.RawMessage
+	if json.Unmarshal(value, &items) == nil {
+		for i, item := range items {
+			items[i] = renameNested(item, rename)
+		}
+		if data, err := json.Marshal(items); err == nil {
+			return data
+		}
+	}
+	return value
+}
+
+// SnakeCase converts a camelCase field name to snake_case; runs of capitals are one
+// word, so resourceID becomes resource_id
+func SnakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			prevLower := i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]))
+			nextLower := i > 0 && i+1 < len(runes) && unicode.IsUpper(runes[i-1]) && unicode.IsLower(runes[i+1])
+			if prevLower || nextLower {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// CamelCase converts a snake_case field name to camelCase
+func CamelCase(name string) string {
+	parts := strings.Split(name, "_")
+	var b strings.Builder
+	b.WriteString(parts[0])
+	for _, part := range parts[1:] {
+		if part == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
@@ -0,0 +1,129 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+// casingRegistry records the registry request and answers with a warning
+type casingRegistry struct {
+	request map[string]interface{}
+}
+
+func (r *casingRegistry) GetObjectTypes() map[string]*ObjectType { return nil }
+
+func (r *casingRegistry) CallHandler(ctx context.Context, objectType, method string, input []byte) ([]byte, error) {
+	_ = json.Unmarshal(input, &r.request)
+	return json.Marshal(CreateResponse{
+		ResourceID: "users",
+		State:      map[string]interface{}{"owner_role": "app"},
+		Operation:  &OperationRef{ID: "op-1", ResourceType: "table"},
+		Warnings:   []Warning{NewWarning(WarningCodeDefaultApplied, "engine defaulted").AtPath("engine")},
+		Success:    true,
+	})
+}
+
+func ping(t *testing.T, d *UnifiedDispatcher, input string) map[string]interface{} {
+	t.Helper()
+	output, err := d.Dispatch(context.Background(), "Ping", []byte(input))
+	if err != nil {
+		t.Fatalf("ping: %v", err)
+	}
+	var response map[string]interface{}
+	_ = json.Unmarshal(output, &response)
+	return response
+}
+
+// TestSerializationCompatCamelCase validates camelCase cores get adapted envelopes but untouched user data
+func TestSerializationCompatCamelCase(t *testing.T) {
+	registry := &casingRegistry{}
+	d := NewUnifiedDispatcher(registry, nil)
+	if err := d.SetSerializationCompat(SerializationRule{MaxCoreVersion: "1.2", Mode: SerializationMode{Casing: CasingCamel}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if resp := ping(t, d, `{"core_version":"1.2.9"}`); resp["field_casing"] != CasingCamel {
+		t.Fatalf("core 1.2.9 should negotiate camelCase: %v", resp)
+	}
+	output, err := d.Dispatch(context.Background(), "CreateResource",
+		[]byte(`{"resourceType":"table","name":"users","config":{"owner_role":"app"}}`))
+	if err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+	if registry.request["object_type"] != "table" {
+		t.Errorf("camelCase request was not decoded: %v", registry.request)
+	}
+	if config, _ := registry.request["config"].(map[string]interface{}); config["owner_role"] != "app" {
+		t.Errorf("config keys must be kept: %v", registry.request["config"])
+	}
+
+	var resp map[string]interface{}
+	_ = json.Unmarshal(output, &resp)
+	if resp["resourceId"] != "users" || resp["resource_id"] != nil || resp["responseMetadata"] == nil {
+		t.Errorf("response envelope not camelCase: %s", output)
+	}
+	if op, _ := resp["operation"].(map[string]interface{}); op["resourceType"] != "table" {
+		t.Errorf("nested envelope not camelCase: %v", resp["operation"])
+	}
+	if state, _ := resp["state"].(map[string]interface{}); state["owner_role"] != "app" {
+		t.Errorf("state keys must be kept: %v", resp["state"])
+	}
+
+	if resp := ping(t, d, `{"core_version":"1.3.0"}`); resp["field_casing"] != CasingSnake {
+		t.Errorf("newer cores should get snake_case: %v", resp)
+	}
+	output, _ = d.Dispatch(context.Background(), "CreateResource", []byte(`{"resource_type":"table","name":"users","config":{}}`))
+	if err := json.Unmarshal(output, &CreateResponse{}); err != nil || !json.Valid(output) || d.SerializationMode().Casing != CasingSnake {
+		t.Errorf("snake_case response = %s", output)
+	}
+}
+
+// TestSerializationCompatLegacyFields validates legacy warnings and aliases for old cores
+func TestSerializationCompatLegacyFields(t *testing.T) {
+	d := NewUnifiedDispatcher(&casingRegistry{}, nil)
+	_ = d.SetSerializationCompat(SerializationRule{
+		MaxCoreVersion: "1.0",
+		Mode:           SerializationMode{LegacyFields: true, Aliases: map[string]string{"resource_id": "id"}},
+	})
+	if resp := ping(t, d, `{"core_version":"1.0.4"}`); resp["legacy_fields"] != true {
+		t.Fatalf("legacy fields not negotiated: %v", resp)
+	}
+	output, err := d.Dispatch(context.Background(), "CreateResource", []byte(`{"resource_type":"table","name":"users","config":{}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var legacy struct {
+		ID       string   `json:"id"`
+		Warnings []string `json:"warnings"`
+	}
+	if err := json.Unmarshal(output, &legacy); err != nil || legacy.ID != "users" || legacy.Warnings[0] != "engine: engine defaulted" {
+		t.Errorf("legacy response = %s (%v)", output, err)
+	}
+
+	// an explicit announcement overrides the version rules
+	if resp := ping(t, d, `{"core_version":"1.0.4","legacy_fields":false,"field_casing":"camelCase"}`); resp["legacy_fields"] != false || resp["field_casing"] != CasingCamel {
+		t.Errorf("announced mode ignored: %v", resp)
+	}
+	if _, err := d.Dispatch(context.Background(), "Ping", []byte(`{"field_casing":"kebab-case"}`)); err == nil {
+		t.Error("unknown casings should be rejected")
+	}
+}
+
+// TestFieldNameCasing validates snake and camel conversions
+func TestFieldNameCasing(t *testing.T) {
+	for snake, camel := range map[string]string{"resource_id": "resourceId", "object_type": "objectType", "name": "name", "max_core_version": "maxCoreVersion"} {
+		if got := CamelCase(snake); got != camel {
+			t.Errorf("CamelCase(%s) = %s", snake, got)
+		}
+		if got := SnakeCase(camel); got != snake {
+			t.Errorf("SnakeCase(%s) = %s", camel, got)
+		}
+	}
+	if got := SnakeCase("resourceID"); got != "resource_id" {
+		t.Errorf("SnakeCase(resourceID) = %s", got)
+	}
+	if got := SnakeCase("HTTPServerURL"); got != "http_server_url" {
+		t.Errorf("SnakeCase(HTTPServerURL) = %s", got)
+	}
+}
@@ -0,0 +1,48 @@
+// Package core provides per-connection session state for negotiated settings
+package core
+
+import (
+	"context"
+	"sync"
+)
+
+// Session holds what one client connection negotiated in its Ping handshake, so a
+// transport serving several clients from one dispatcher, such as a mux, keeps each
+// client's settings apart. Requests without a session share the dispatcher's state.
+type Session struct {
+	mu       sync.RWMutex
+	encoding string
+}
+
+// NewSession creates the state of a new client connection
+func NewSession() *Session {
+	return &Session{}
+}
+
+// Encoding returns the compression encoding negotiated on the session
+func (s *Session) Encoding() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.encoding
+}
+
+func (s *Session) setEncoding(encoding string) {
+	s.mu.Lock()
+	s.encoding = encoding
+	s.mu.Unlock()
+}
+
+// sessionContextKey is the context key holding the client session
+type sessionContextKey struct{}
+
+// WithSession returns a context carrying the client session
+func WithSession(ctx context.Context, session *Session) context.Context {
+	return context.WithValue(ctx, sessionContextKey{}, session)
+}
+
+// SessionFromContext returns the client session, or nil when the transport serves a
+// single client
+func SessionFromContext(ctx context.Context) *Session {
+	session, _ := ctx.Value(sessionContextKey{}).(*Session)
+	return session
+}
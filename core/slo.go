@@ -0,0 +1,401 @@
+// Package core provides service level objectives and error budget tracking per function
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/helpers/security"
+)
+
+// SLOMetadataKey is the HealthStatus.Metadata key holding the SLO statuses
+const SLOMetadataKey = "slo"
+
+// SLO tracking defaults
+const (
+	DefaultSLOWindow        = 24 * time.Hour
+	DefaultSLOFastBurnRate  = 6.0  // over the short window, spends half the budget in it
+	DefaultSLOBudgetWarning = 0.25 // warn when less than a quarter of the budget is left
+	DefaultSLOMinCalls      = 20   // calls in a window before it can raise warnings
+)
+
+// sloBuckets is the resolution of the rolling window; the short window used for fast
+// burn detection is the most recent sloShortBuckets of them
+const (
+	sloBuckets      = 120
+	sloShortBuckets = sloBuckets / 12
+)
+
+// SLObjective is the objective of one dispatcher function. Function "*" applies to
+// every function without an objective of its own; each is still tracked separately.
+type SLObjective struct {
+	Function    string  `json:"function"`
+	SuccessRate float64 `json:"success_rate"` // e.g. 0.999
+
+	// Latency, when set, adds a latency objective: LatencyTarget of the calls, by
+	// default SuccessRate, finish within Latency
+	Latency       time.Duration `json:"latency,omitempty"`
+	LatencyTarget float64       `json:"latency_target,omitempty"`
+
+	// Window is the rolling window the budget is computed over, DefaultSLOWindow when zero
+	Window time.Duration `json:"window,omitempty"`
+}
+
+// Validate checks the objective's targets and window
+func (o SLObjective) Validate() error {
+	if o.Function == "" {
+		return errors.New("slo objective needs a function or \"*\"")
+	}
+	if o.SuccessRate <= 0 || o.SuccessRate >= 1 {
+		return fmt.Errorf("slo objective %s: success rate %g must be between 0 and 1", o.Function, o.SuccessRate)
+	}
+	if o.Latency < 0 {
+		return fmt.Errorf("slo objective %s: latency must not be negative", o.Function)
+	}
+	if o.LatencyTarget < 0 || o.LatencyTarget >= 1 {
+		return fmt.Errorf("slo objective %s: latency target %g must be between 0 and 1", o.Function, o.LatencyTarget)
+	}
+	if o.Window != 0 && o.Window < time.Minute {
+		return fmt.Errorf("slo objective %s: window must be at least a minute", o.Function)
+	}
+	return nil
+}
+
+func (o SLObjective) withDefaults(function string) SLObjective {
+	o.Function = function
+	if o.Window == 0 {
+		o.Window = DefaultSLOWindow
+	}
+	if o.Latency > 0 && o.LatencyTarget == 0 {
+		o.LatencyTarget = o.SuccessRate
+	}
+	return o
+}
+
+// SLOStatus is the state of one function's objective over its window. A burn rate of
+// 1 spends exactly the error budget over the window; ShortBurnRate covers the last
+// twelfth of the window and catches sudden outages the full window still absorbs.
+type SLOStatus struct {
+	Function             string      `json:"function"`
+	Objective            SLObjective `json:"objective"`
+	Calls                uint64      `json:"calls"`
+	Failures             uint64      `json:"failures"`
+	SlowCalls            uint64      `json:"slow_calls,omitempty"`
+	SuccessRate          float64     `json:"success_rate"`
+	LatencyCompliance    float64     `json:"latency_compliance,omitempty"` // only with a latency objective
+	ErrorBudgetRemaining float64     `json:"error_budget_remaining"`       // 0 to 1
+	BurnRate             float64     `json:"burn_rate"`
+	ShortBurnRate        float64     `json:"short_burn_rate"`
+	Exhausting           bool        `json:"exhausting,omitempty"`
+	Warning              string      `json:"warning,omitempty"`
+}
+
+// SLOTracker tracks success rates and latencies per function against objectives. It
+// is safe for concurrent use; set it on the dispatcher with SetSLOTracker or have the
+// pdk Mux observe calls into it, but not both.
+type SLOTracker struct {
+	// CountsAsFailure decides which errors spend the error budget; nil uses IsSLOFailure
+	CountsAsFailure func(err error) bool
+
+	// FastBurnRate, BudgetWarning and MinCalls tune the health warnings; zero values
+	// use the defaults
+	FastBurnRate  float64
+	BudgetWarning float64
+	MinCalls      uint64
+
+	objectives map[string]SLObjective
+
+	mu     sync.Mutex
+	series map[string]*sloSeries
+}
+
+type sloBucket struct {
+	slot                  int64
+	calls, failures, slow uint64
+}
+
+type sloSeries struct {
+	objective SLObjective
+	buckets   [sloBuckets]sloBucket
+}
+
+// NewSLOTracker creates a tracker for objectives; functions without an objective, and
+// without a "*" objective, are not tracked
+func NewSLOTracker(objectives ...SLObjective) (*SLOTracker, error) {
+	t := &SLOTracker{objectives: make(map[string]SLObjective), series: make(map[string]*sloSeries)}
+	for _, o := range objectives {
+		if err := o.Validate(); err != nil {
+			return nil, err
+		}
+		if _, exists := t.objectives[o.Function]; exists {
+			return nil, fmt.Errorf("duplicate slo objective for %s", o.Function)
+		}
+		t.objectives[o.Function] = o
+	}
+	return t, nil
+}
+
+// SetSLOTracker records every dispatched call in tracker
+func (d *UnifiedDispatcher) SetSLOTracker(tracker *SLOTracker) {
+	d.slo = tracker
+}
+
+// Observe records one call of function
+func (t *SLOTracker) Observe(function string, duration time.Duration, err error) {
+	failed := false
+	if err != nil {
+		if t.CountsAsFailure != nil {
+			failed = t.CountsAsFailure(err)
+		} else {
+			failed = IsSLOFailure(err)
+		}
+	}
+	now := clock.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := t.seriesFor(function)
+	if s == nil {
+		return
+	}
+	b := s.bucket(now)
+	b.calls++
+	if failed {
+		b.failures++
+	}
+	if s.objective.Latency > 0 && duration > s.objective.Latency {
+		b.slow++
+	}
+}
+
+// seriesFor returns the series of function, creating it from its objective; the
+// caller must hold the lock
+func (t *SLOTracker) seriesFor(function string) *sloSeries {
+	if s, ok := t.series[function]; ok {
+		return s
+	}
+	o, ok := t.objectives[function]
+	if !ok {
+		if o, ok = t.objectives["*"]; !ok {
+			return nil
+		}
+	}
+	s := &sloSeries{objective: o.withDefaults(function)}
+	t.series[function] = s
+	return s
+}
+
+func (s *sloSeries) slot(now time.Time) int64 {
+	return now.UnixNano() / int64(s.objective.Window/sloBuckets)
+}
+
+func (s *sloSeries) bucket(now time.Time) *sloBucket {
+	slot := s.slot(now)
+	b := &s.buckets[slot%sloBuckets]
+	if b.slot != slot {
+		*b = sloBucket{slot: slot}
+	}
+	return b
+}
+
+// totals sums the most recent n buckets
+func (s *sloSeries) totals(now time.Time, n int64) (total sloBucket) {
+	current := s.slot(now)
+	for _, b := range s.buckets {
+		if b.slot > current-n && b.slot <= current {
+			total.calls += b.calls
+			total.failures += b.failures
+			total.slow += b.slow
+		}
+	}
+	return total
+}
+
+// burnRate is the rate at which calls spend the budget of both objectives
+func (o SLObjective) burnRate(b sloBucket) float64 {
+	if b.calls == 0 {
+		return 0
+	}
+	rate := float64(b.failures) / float64(b.calls) / (1 - o.SuccessRate)
+	if o.Latency > 0 {
+		rate = math.Max(rate, float64(b.slow)/float64(b.calls)/(1-o.LatencyTarget))
+	}
+	return rate
+}
+
+// Statuses returns the status of every tracked function, sorted by function
+func (t *SLOTracker) Statuses() []SLOStatus {
+	now := clock.Now()
+	fastBurn, budgetWarning, minCalls := t.FastBurnRate, t.BudgetWarning, t.MinCalls
+	if fastBurn == 0 {
+		fastBurn = DefaultSLOFastBurnRate
+	}
+	if budgetWarning == 0 {
+		budgetWarning = DefaultSLOBudgetWarning
+	}
+	if minCalls == 0 {
+		minCalls = DefaultSLOMinCalls
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	statuses := make([]SLOStatus, 0, len(t.series))
+	for _, function := range sortedStringKeys(t.series) {
+		s := t.series[function]
+		o := s.objective
+		window, short := s.totals(now, sloBuckets), s.totals(now, sloShortBuckets)
+		status := SLOStatus{
+			Function:      function,
+			Objective:     o,
+			Calls:         window.calls,
+			Failures:      window.failures,
+			SlowCalls:     window.slow,
+			SuccessRate:   1,
+			BurnRate:      o.burnRate(window),
+			ShortBurnRate: o.burnRate(short),
+		}
+		if window.calls > 0 {
+			status.SuccessRate = 1 - float64(window.failures)/float64(window.calls)
+		}
+		if o.Latency > 0 {
+			status.LatencyCompliance = 1
+			if window.calls > 0 {
+				status.LatencyCompliance = 1 - float64(window.slow)/float64(window.calls)
+			}
+		}
+		status.ErrorBudgetRemaining = math.Max(0, 1-status.BurnRate)
+
+		switch {
+		case window.calls >= minCalls && status.ErrorBudgetRemaining < budgetWarning:
+			status.Warning = fmt.Sprintf("%s has %.0f%% of its error budget left over %s (success rate %.4g, objective %g)",
+				function, status.ErrorBudgetRemaining*100, o.Window, status.SuccessRate, o.SuccessRate)
+		case short.calls >= minCalls && status.ShortBurnRate >= fastBurn:
+			status.Warning = fmt.Sprintf("%s is burning its error budget %.1fx faster than sustainable over the last %s",
+				function, status.ShortBurnRate, o.Window/sloBuckets*sloShortBuckets)
+		}
+		status.Exhausting = status.Warning != ""
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// Annotate records the statuses in status.Metadata under SLOMetadataKey and adds a
+// warning for every function exhausting its error budget
+func (t *SLOTracker) Annotate(status *HealthStatus) {
+	if t == nil || status == nil {
+		return
+	}
+	statuses := t.Statuses()
+	if status.Metadata == nil {
+		status.Metadata = make(map[string]interface{})
+	}
+	status.Metadata[SLOMetadataKey] = statuses
+	for _, s := range statuses {
+		if s.Warning != "" {
+			status.Warnings = append(status.Warnings, s.Warning)
+		}
+	}
+}
+
+// WriteSLOMetrics writes the statuses of trackers, keyed by provider name, in
+// Prometheus text format
+func WriteSLOMetrics(w io.Writer, trackers map[string]*SLOTracker) error {
+	type row struct {
+		provider string
+		status   SLOStatus
+	}
+	var rows []row
+	for _, provider := range sortedStringKeys(trackers) {
+		for _, s := range trackers[provider].Statuses() {
+			rows = append(rows, row{provider, s})
+		}
+	}
+
+	var b strings.Builder
+	gauge := func(name, help string, value func(s SLOStatus) float64) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+		for _, r := range rows {
+			fmt.Fprintf(&b, "%s{provider=%q,function=%q} %g\n", name, r.provider, r.status.Function, value(r.status))
+		}
+	}
+	gauge("kolumn_provider_slo_objective", "Success rate objective.", func(s SLOStatus) float64 {
+		return s.Objective.SuccessRate
+	})
+	gauge("kolumn_provider_slo_success_ratio", "Success rate over the objective window.", func(s SLOStatus) float64 {
+		return s.SuccessRate
+	})
+	gauge("kolumn_provider_slo_error_budget_remaining", "Fraction of the error budget left over the objective window.", func(s SLOStatus) float64 {
+		return s.ErrorBudgetRemaining
+	})
+	fmt.Fprintf(&b, "# HELP kolumn_provider_slo_burn_rate Error budget burn rate; 1 spends the budget exactly over the window.\n# TYPE kolumn_provider_slo_burn_rate gauge\n")
+	for _, r := range rows {
+		fmt.Fprintf(&b, "kolumn_provider_slo_burn_rate{provider=%q,function=%q,window=\"long\"} %g\n", r.provider, r.status.Function, r.status.BurnRate)
+		fmt.Fprintf(&b, "kolumn_provider_slo_burn_rate{provider=%q,function=%q,window=\"short\"} %g\n", r.provider, r.status.Function, r.status.ShortBurnRate)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// sloCallerErrorCodes are error codes caused by the request rather than the provider
+var sloCallerErrorCodes = stringSet([]string{
+	"PERMISSION_DENIED", "REQUEST_TOO_LARGE", "FEATURE_NOT_ENABLED", "UPGRADE_REQUIRED",
+	"MAINTENANCE_WINDOW_CLOSED", "OPERATION_DEFERRED", "INCOMPATIBLE_CORE_VERSION",
+	"BACKEND_FEATURE_UNSUPPORTED", "UNEXPECTED_FUNCTION", "NOT_IMPLEMENTED",
+	"SCAN_JOBS_NOT_ENABLED", "SCAN_JOB_NOT_FOUND",
+})
+
+// IsSLOFailure reports whether err spends the error budget. Cancelled requests and
+// errors caused by the request, such as invalid input, denied access, disabled
+// features and closed maintenance windows, do not.
+func IsSLOFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	code := sloErrorCode(err)
+	switch {
+	case code == "":
+		return true
+	case strings.HasPrefix(code, "INVALID_"), strings.HasPrefix(code, "MISSING_"), strings.HasSuffix(code, "_ACCESS_DENIED"):
+		return false
+	}
+	return !sloCallerErrorCodes[code]
+}
+
+// sloErrorCode returns the code of a secure or diagnostic error
+func sloErrorCode(err error) string {
+	var (
+		secErr      *security.SecureError
+		permErr     *PermissionDeniedError
+		flagErr     *FeatureNotEnabledError
+		tierErr     *UpgradeRequiredError
+		maintErr    *MaintenanceWindowError
+		featureErr  *BackendFeatureError
+		versionErr  *CoreVersionError
+		validateErr *ValidationError
+	)
+	switch {
+	case errors.As(err, &secErr):
+		return secErr.Code
+	case errors.As(err, &permErr):
+		return permErr.Diagnostic.Code
+	case errors.As(err, &flagErr):
+		return flagErr.Diagnostic.Code
+	case errors.As(err, &tierErr):
+		return tierErr.Diagnostic.Code
+	case errors.As(err, &maintErr):
+		return maintErr.Diagnostic.Code
+	case errors.As(err, &featureErr):
+		return featureErr.Diagnostic.Code
+	case errors.As(err, &versionErr):
+		return "INCOMPATIBLE_CORE_VERSION"
+	case errors.As(err, &validateErr):
+		return "INVALID_REQUEST"
+	}
+	return ""
+}
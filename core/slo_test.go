@@ -0,0 +1,160 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/helpers/security"
+	"github.com/schemabounce/kolumn/sdk/types"
+)
+
+// TestIsSLOFailure validates that only provider-side errors spend the error budget
+func TestIsSLOFailure(t *testing.T) {
+	cases := map[error]bool{
+		nil:                           false,
+		context.Canceled:              false,
+		errors.New("connection lost"): true,
+		security.NewSecureError("invalid request format", "bad json", "INVALID_REQUEST"):                     false,
+		security.NewSecureError("tenant access denied", "spoofed", "TENANT_ACCESS_DENIED"):                   false,
+		security.NewSecureError("transformation failed", "handler", "TRANSFORMATION_FAILED"):                 true,
+		fmt.Errorf("wrapped: %w", &PermissionDeniedError{Diagnostic: Diagnostic{Code: "PERMISSION_DENIED"}}): false,
+		&ValidationError{Code: "TYPE_MISMATCH", Message: "bad type"}:                                         false,
+	}
+	for err, want := range cases {
+		if got := IsSLOFailure(err); got != want {
+			t.Errorf("IsSLOFailure(%v) = %v, want %v", err, got, want)
+		}
+	}
+}
+
+// TestSLOTrackerBudget validates burn rates, budget warnings and the rolling window
+func TestSLOTrackerBudget(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	defer SetClock(types.ClockFunc(func() time.Time { return now }))()
+
+	if _, err := NewSLOTracker(SLObjective{Function: "Ping", SuccessRate: 1}); err == nil {
+		t.Fatal("expected a success rate of 1 to be rejected")
+	}
+	tracker, err := NewSLOTracker(
+		SLObjective{Function: "*", SuccessRate: 0.99},
+		SLObjective{Function: "ReadResource", SuccessRate: 0.9, Latency: 100 * time.Millisecond, Window: time.Hour},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 100 creates with one failure spend the whole 1% budget
+	for i := 0; i < 100; i++ {
+		var err error
+		if i == 0 {
+			err = errors.New("backend unavailable")
+		}
+		tracker.Observe("CreateResource", time.Millisecond, err)
+	}
+	// 40 reads, 4 slow: 10% slow calls spend the latency budget
+	for i := 0; i < 40; i++ {
+		duration := 10 * time.Millisecond
+		if i < 4 {
+			duration = time.Second
+		}
+		tracker.Observe("ReadResource", duration, nil)
+	}
+	tracker.Observe("Ping", time.Millisecond, security.NewSecureError("bad", "bad", "INVALID_REQUEST"))
+
+	statuses := tracker.Statuses()
+	if len(statuses) != 3 || statuses[0].Function != "CreateResource" || statuses[2].Function != "ReadResource" {
+		t.Fatalf("unexpected statuses: %+v", statuses)
+	}
+	create, ping, read := statuses[0], statuses[1], statuses[2]
+	if create.Failures != 1 || create.SuccessRate != 0.99 || create.ErrorBudgetRemaining > 1e-9 || !create.Exhausting {
+		t.Errorf("create status = %+v", create)
+	}
+	if ping.Failures != 0 || ping.BurnRate != 0 || ping.Exhausting {
+		t.Errorf("ping status = %+v", ping)
+	}
+	if read.SlowCalls != 4 || read.LatencyCompliance != 0.9 || !read.Exhausting || read.Objective.LatencyTarget != 0.9 {
+		t.Errorf("read status = %+v", read)
+	}
+
+	status := &HealthStatus{Healthy: true}
+	tracker.Annotate(status)
+	if len(status.Warnings) != 2 || !strings.HasPrefix(status.Warnings[0], "CreateResource has 0% of its error budget left") {
+		t.Errorf("warnings = %v", status.Warnings)
+	}
+	if _, ok := status.Metadata[SLOMetadataKey].([]SLOStatus); !ok || !status.Healthy {
+		t.Errorf("health status = %+v", status)
+	}
+
+	// the read window is an hour, so its calls expire while creates are still counted
+	now = now.Add(2 * time.Hour)
+	statuses = tracker.Statuses()
+	if statuses[0].Calls != 100 || statuses[2].Calls != 0 || statuses[2].Exhausting {
+		t.Errorf("after two hours: %+v", statuses)
+	}
+}
+
+// TestSLOTrackerFastBurn validates that an outage is reported before the window's budget is gone
+func TestSLOTrackerFastBurn(t *testing.T) {
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	defer SetClock(types.ClockFunc(func() time.Time { return now }))()
+
+	tracker, err := NewSLOTracker(SLObjective{Function: "DiscoverResources", SuccessRate: 0.9})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 1000; i++ {
+		tracker.Observe("DiscoverResources", time.Millisecond, nil)
+	}
+	now = now.Add(20 * time.Hour)
+	for i := 0; i < 40; i++ {
+		tracker.Observe("DiscoverResources", time.Millisecond, errors.New("timeout"))
+	}
+
+	status := tracker.Statuses()[0]
+	if status.ErrorBudgetRemaining < 0.25 || status.ShortBurnRate < 9.99 || !status.Exhausting {
+		t.Fatalf("status = %+v", status)
+	}
+	if !strings.Contains(status.Warning, "10.0x faster") {
+		t.Errorf("warning = %q", status.Warning)
+	}
+}
+
+// TestSLOTrackerDispatch validates the dispatcher records calls and metrics are exported
+func TestSLOTrackerDispatch(t *testing.T) {
+	tracker, err := NewSLOTracker(SLObjective{Function: "CreateResource", SuccessRate: 0.999})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := NewUnifiedDispatcher(&echoRegistry{}, nil)
+	d.SetSLOTracker(tracker)
+
+	if _, err := d.Dispatch(context.Background(), "CreateResource", []byte(dispatchBenchRequests["CreateResource"])); err != nil {
+		t.Fatal(err)
+	}
+	_, _ = d.Dispatch(context.Background(), "CreateResource", []byte(`{`))
+	_, _ = d.Dispatch(context.Background(), "Ping", []byte(`{}`))
+
+	statuses := tracker.Statuses()
+	if len(statuses) != 1 || statuses[0].Calls != 2 || statuses[0].Failures != 0 {
+		t.Fatalf("statuses = %+v", statuses)
+	}
+
+	var b bytes.Buffer
+	if err := WriteSLOMetrics(&b, map[string]*SLOTracker{"postgres": tracker}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"# TYPE kolumn_provider_slo_burn_rate gauge",
+		`kolumn_provider_slo_error_budget_remaining{provider="postgres",function="CreateResource"} 1`,
+		`kolumn_provider_slo_burn_rate{provider="postgres",function="CreateResource",window="long"} 0`,
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("metrics lack %s:\n%s", want, b.String())
+		}
+	}
+}
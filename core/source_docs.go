@@ -0,0 +1,386 @@
+// Package core provides extraction of resource documentation from provider Go source
+package core
+
+import (
+	"go/ast"
+	"go/doc"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// SourceDocDirective marks a declaration documenting a resource type whose handler or
+// schema builder cannot be found by name, e.g. "//kolumn:resource table"
+const SourceDocDirective = "//kolumn:resource"
+
+// Where a resource description was found, strongest first
+const (
+	SourceDocFromDirective = "directive"
+	SourceDocFromHandler   = "handler"
+	SourceDocFromSchema    = "schema"
+)
+
+// SourceDoc is the doc comment documenting one resource type
+type SourceDoc struct {
+	ResourceType string `json:"resource_type"`
+	Symbol       string `json:"symbol"` // documented declaration, e.g. handlers.TableHandler
+	File         string `json:"file"`
+	Line         int    `json:"line"`
+	Origin       string `json:"origin"`  // SourceDocFrom* constant
+	Summary      string `json:"summary"` // first sentence
+	Text         string `json:"text"`    // whole comment
+}
+
+// SourceDocs are the doc comments extracted from a provider's source tree
+type SourceDocs struct {
+	Package   string                `json:"package,omitempty"` // doc of the main package
+	Resources map[string]*SourceDoc `json:"resources"`
+}
+
+// sourceDecl is a documented top-level declaration
+type sourceDecl struct {
+	symbol  string
+	pos     token.Position
+	doc     *ast.CommentGroup
+	returns ast.Expr // first result of a function
+}
+
+// sourcePackage holds the declarations of one package directory
+type sourcePackage struct {
+	name  string
+	files []*ast.File
+	decls map[string]sourceDecl // type, func and var names
+}
+
+// ExtractSourceDocs reads the Go doc comments of a provider's handler types and schema
+// builders so resource descriptions can live next to the code. A resource type is
+// documented by, in order of precedence:
+//
+//   - a declaration whose comment carries the //kolumn:resource directive
+//   - the handler type passed to RegisterHandler("type", ...), or the constructor
+//     returning it
+//   - the function or variable declaring ResourceTypeDefinition{Name: "type"}, or the
+//     schema argument of RegisterHandler
+//
+// Resource types must be string literals to be recognised. Test files, vendor and
+// testdata directories are skipped.
+func ExtractSourceDocs(root string) (*SourceDocs, error) {
+	fset := token.NewFileSet()
+	packages := make(map[string]*sourcePackage)
+	var order []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != root && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+		file, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+		if err != nil {
+			return err
+		}
+		dir := filepath.Dir(path)
+		pkg, ok := packages[dir]
+		if !ok {
+			pkg = &sourcePackage{name: file.Name.Name, decls: make(map[string]sourceDecl)}
+			packages[dir] = pkg
+			order = append(order, dir)
+		}
+		pkg.files = append(pkg.files, file)
+		collectDecls(fset, pkg, file)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Declarations are resolved by package name, which is enough for handler packages
+	// imported under their own name
+	byPackage := make(map[string]*sourcePackage, len(packages))
+	for _, dir := range order {
+		if _, taken := byPackage[packages[dir].name]; !taken {
+			byPackage[packages[dir].name] = packages[dir]
+		}
+	}
+
+	docs := &SourceDocs{Resources: make(map[string]*SourceDoc)}
+	for _, dir := range order {
+		pkg := packages[dir]
+		for _, file := range pkg.files {
+			if pkg.name == "main" && file.Doc != nil && docs.Package == "" {
+				docs.Package = strings.TrimSpace(file.Doc.Text())
+			}
+			collectResourceDocs(pkg, byPackage, file, docs)
+		}
+	}
+	for _, d := range docs.Resources {
+		if rel, err := filepath.Rel(root, d.File); err == nil {
+			d.File = filepath.ToSlash(rel)
+		}
+	}
+	return docs, nil
+}
+
+// collectDecls records the documented types, functions and variables of a file
+func collectDecls(fset *token.FileSet, pkg *sourcePackage, file *ast.File) {
+	add := func(name string, node ast.Node, returns ast.Expr, comments ...*ast.CommentGroup) {
+		for _, doc := range comments {
+			if doc != nil {
+				pkg.decls[name] = sourceDecl{symbol: pkg.name + "." + name, pos: fset.Position(node.Pos()), doc: doc, returns: returns}
+				return
+			}
+		}
+	}
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Recv == nil {
+				var returns ast.Expr
+				if results := decl.Type.Results; results != nil && len(results.List) > 0 {
+					returns = results.List[0].Type
+				}
+				add(decl.Name.Name, decl, returns, decl.Doc)
+			}
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					add(spec.Name.Name, spec, nil, spec.Doc, decl.Doc)
+				case *ast.ValueSpec:
+					for _, name := range spec.Names {
+						add(name.Name, spec, nil, spec.Doc, decl.Doc)
+					}
+				}
+			}
+		}
+	}
+}
+
+// collectResourceDocs finds the declarations documenting resource types in a file
+func collectResourceDocs(pkg *sourcePackage, byPackage map[string]*sourcePackage, file *ast.File, docs *SourceDocs) {
+	offer := func(resourceType, origin string, decl sourceDecl, ok bool) {
+		if !ok || resourceType == "" || decl.doc == nil {
+			return
+		}
+		text := strings.TrimSpace(decl.doc.Text())
+		if text == "" {
+			return
+		}
+		if existing, found := docs.Resources[resourceType]; found && sourceDocRank(existing.Origin) <= sourceDocRank(origin) {
+			return
+		}
+		docs.Resources[resourceType] = &SourceDoc{
+			ResourceType: resourceType,
+			Symbol:       decl.symbol,
+			File:         decl.pos.Filename,
+			Line:         decl.pos.Line,
+			Origin:       origin,
+			Summary:      new(doc.Package).Synopsis(text),
+			Text:         text,
+		}
+	}
+	var lookup func(from *sourcePackage, expr ast.Expr, follow bool) (sourceDecl, bool)
+	lookup = func(from *sourcePackage, expr ast.Expr, follow bool) (sourceDecl, bool) {
+		owner, name := declName(expr)
+		if name == "" {
+			return sourceDecl{}, false
+		}
+		target := from
+		if owner != "" {
+			if target = byPackage[owner]; target == nil {
+				return sourceDecl{}, false
+			}
+		}
+		decl, ok := target.decls[name]
+		// A constructor is documented by the type it returns, when that type is ours
+		if ok && follow && decl.returns != nil {
+			if returned, found := lookup(target, decl.returns, false); found && returned.returns == nil {
+				return returned, true
+			}
+		}
+		return decl, ok
+	}
+
+	for _, decl := range file.Decls {
+		// Directives on any top-level declaration
+		var comments []*ast.CommentGroup
+		var declared string
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			comments = append(comments, decl.Doc)
+			if decl.Recv == nil {
+				declared = decl.Name.Name
+			}
+		case *ast.GenDecl:
+			comments = append(comments, decl.Doc)
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					comments = append(comments, spec.Doc)
+					if declared == "" {
+						declared = spec.Name.Name
+					}
+				case *ast.ValueSpec:
+					comments = append(comments, spec.Doc)
+					if declared == "" && len(spec.Names) > 0 {
+						declared = spec.Names[0].Name
+					}
+				}
+			}
+		}
+		for _, group := range comments {
+			if resourceType := directiveResourceType(group); resourceType != "" {
+				d, ok := pkg.decls[declared]
+				offer(resourceType, SourceDocFromDirective, d, ok)
+			}
+		}
+
+		enclosing, hasEnclosing := pkg.decls[declared]
+		ast.Inspect(decl, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.CallExpr:
+				if _, name := declName(n.Fun); name != "RegisterHandler" || len(n.Args) < 2 {
+					return true
+				}
+				resourceType := stringLiteral(n.Args[0])
+				d, ok := lookup(pkg, n.Args[1], true)
+				offer(resourceType, SourceDocFromHandler, d, ok)
+				if len(n.Args) > 2 {
+					d, ok = lookup(pkg, n.Args[2], false)
+					offer(resourceType, SourceDocFromSchema, d, ok)
+				}
+			case *ast.CompositeLit:
+				var definitions []*ast.CompositeLit
+				if list, ok := n.Type.(*ast.ArrayType); ok {
+					// element types may be elided in []ResourceTypeDefinition{{...}}
+					if _, name := declName(list.Elt); name == "ResourceTypeDefinition" {
+						for _, elt := range n.Elts {
+							if lit, ok := elt.(*ast.CompositeLit); ok && lit.Type == nil {
+								definitions = append(definitions, lit)
+							}
+						}
+					}
+				} else if _, name := declName(n.Type); name == "ResourceTypeDefinition" {
+					definitions = append(definitions, n)
+				}
+				for _, lit := range definitions {
+					offer(definitionName(lit), SourceDocFromSchema, enclosing, hasEnclosing)
+				}
+			}
+			return true
+		})
+	}
+}
+
+// declName resolves the declaration an expression refers to: a type in a composite
+// literal, a constructor or schema builder call, or a variable
+func declName(expr ast.Expr) (pkg, name string) {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return "", e.Name
+	case *ast.SelectorExpr:
+		if x, ok := e.X.(*ast.Ident); ok {
+			return x.Name, e.Sel.Name
+		}
+		// method calls such as registry.RegisterHandler
+		return "", e.Sel.Name
+	case *ast.StarExpr:
+		return declName(e.X)
+	case *ast.UnaryExpr:
+		return declName(e.X)
+	case *ast.CompositeLit:
+		return declName(e.Type)
+	case *ast.CallExpr:
+		return declName(e.Fun)
+	case *ast.ParenExpr:
+		return declName(e.X)
+	}
+	return "", ""
+}
+
+// definitionName returns the Name field of a ResourceTypeDefinition literal
+func definitionName(lit *ast.CompositeLit) string {
+	for _, elt := range lit.Elts {
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			if key, ok := kv.Key.(*ast.Ident); ok && key.Name == "Name" {
+				return stringLiteral(kv.Value)
+			}
+		}
+	}
+	return ""
+}
+
+func directiveResourceType(group *ast.CommentGroup) string {
+	if group == nil {
+		return ""
+	}
+	for _, c := range group.List {
+		if rest, ok := strings.CutPrefix(c.Text, SourceDocDirective); ok && (rest == "" || rest[0] == ' ') {
+			return strings.TrimSpace(rest)
+		}
+	}
+	return ""
+}
+
+func stringLiteral(expr ast.Expr) string {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return ""
+	}
+	s, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return ""
+	}
+	return s
+}
+
+func sourceDocRank(origin string) int {
+	switch origin {
+	case SourceDocFromDirective:
+		return 0
+	case SourceDocFromHandler:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// ApplySourceDocs replaces the descriptions of documented resource types with the
+// summary of their doc comment, and fills an empty provider description from the main
+// package doc. It returns the resource types it updated.
+func ApplySourceDocs(schema *Schema, docs *SourceDocs) []string {
+	if schema == nil || docs == nil {
+		return nil
+	}
+	var updated []string
+	for i := range schema.ResourceTypes {
+		if d, ok := docs.Resources[schema.ResourceTypes[i].Name]; ok {
+			schema.ResourceTypes[i].Description = d.Summary
+			updated = append(updated, schema.ResourceTypes[i].Name)
+		}
+	}
+	for _, objects := range []map[string]*ObjectType{schema.CreateObjects, schema.DiscoverObjects} {
+		for name, obj := range objects {
+			if d, ok := docs.Resources[name]; ok && obj != nil {
+				obj.Description = d.Summary
+				if !containsString(updated, name) {
+					updated = append(updated, name)
+				}
+			}
+		}
+	}
+	if schema.Description == "" && docs.Package != "" {
+		schema.Description = new(doc.Package).Synopsis(docs.Package)
+	}
+	return uniqueSorted(updated)
+}
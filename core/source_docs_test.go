@@ -0,0 +1,58 @@
+package core
+
+import (
+	"testing"
+)
+
+// TestExtractSourceDocs validates handler, constructor, schema builder and directive docs
+func TestExtractSourceDocs(t *testing.T) {
+	docs, err := ExtractSourceDocs("testdata/sourcedocs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if docs.Package != "Kolumn provider for Acme databases. It manages tables and topics." {
+		t.Errorf("package doc = %q", docs.Package)
+	}
+
+	want := map[string]struct{ symbol, origin, summary string }{
+		"table":    {"handlers.TableHandler", SourceDocFromHandler, "TableHandler manages Acme tables, including columns and indexes."},
+		"topic":    {"handlers.TopicHandler", SourceDocFromHandler, "TopicHandler manages event topics."},
+		"view":     {"main.resourceTypes", SourceDocFromSchema, "resourceTypes declares the view resource type."},
+		"sequence": {"main.sequenceDocs", SourceDocFromDirective, "Sequences generate unique numbers."},
+	}
+	if len(docs.Resources) != len(want) {
+		t.Errorf("found %d resources, want %d", len(docs.Resources), len(want))
+	}
+	for resourceType, w := range want {
+		d, ok := docs.Resources[resourceType]
+		if !ok {
+			t.Errorf("%s: not documented", resourceType)
+			continue
+		}
+		if d.Symbol != w.symbol || d.Origin != w.origin || d.Summary != w.summary {
+			t.Errorf("%s = %+v", resourceType, d)
+		}
+	}
+	if table := docs.Resources["table"]; table.File != "handlers/table.go" || table.Line != 6 {
+		t.Errorf("table position = %s:%d", table.File, table.Line)
+	}
+}
+
+// TestApplySourceDocs validates doc comments replace schema descriptions
+func TestApplySourceDocs(t *testing.T) {
+	docs := &SourceDocs{
+		Package:   "Kolumn provider for Acme databases. More detail.",
+		Resources: map[string]*SourceDoc{"table": {Summary: "Acme tables."}},
+	}
+	schema := &Schema{
+		ResourceTypes: []ResourceTypeDefinition{{Name: "table", Description: "stale"}, {Name: "topic", Description: "kept"}},
+		CreateObjects: map[string]*ObjectType{"table": {Description: "stale"}},
+	}
+	updated := ApplySourceDocs(schema, docs)
+	if len(updated) != 1 || schema.ResourceTypes[0].Description != "Acme tables." || schema.ResourceTypes[1].Description != "kept" {
+		t.Errorf("updated %v: %+v", updated, schema.ResourceTypes)
+	}
+	if schema.CreateObjects["table"].Description != "Acme tables." || schema.Description != "Kolumn provider for Acme databases." {
+		t.Errorf("legacy or provider description not updated: %+v", schema)
+	}
+}
@@ -0,0 +1,206 @@
+// Package core provides the optional startup self-benchmark and capability probe
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// StartupProbeMetadataKey is the HealthStatus.Metadata key holding the probe result
+const StartupProbeMetadataKey = "startup_probe"
+
+// DefaultProbeSamples is the number of round trips measured when none is configured
+const DefaultProbeSamples = 5
+
+// StartupProbe measures the backend once at startup so the provider can adapt to it,
+// e.g. by sizing concurrency limits to the server's connection limit. Every callback
+// is optional; failures are recorded in the result instead of failing startup.
+type StartupProbe struct {
+	// Ping performs one backend round trip, e.g. SELECT 1
+	Ping func(ctx context.Context) error
+
+	// Samples is the number of round trips to time; zero uses DefaultProbeSamples
+	Samples int
+
+	// ConnectionLimit returns the number of connections the backend accepts from this
+	// provider, e.g. max_connections minus reserved superuser slots
+	ConnectionLimit func(ctx context.Context) (int, error)
+
+	// Features detect backend capabilities by name, e.g. "server_version" or
+	// "supports_concurrent_index"
+	Features map[string]func(ctx context.Context) (interface{}, error)
+
+	// Timeout bounds the whole probe; zero means no timeout beyond ctx
+	Timeout time.Duration
+}
+
+// LatencyStats summarises measured round trips
+type LatencyStats struct {
+	Samples int           `json:"samples"`
+	Min     time.Duration `json:"min"`
+	Avg     time.Duration `json:"avg"`
+	Max     time.Duration `json:"max"`
+}
+
+// ProbeResult is what a StartupProbe measured
+type ProbeResult struct {
+	StartedAt       time.Time              `json:"started_at"`
+	Duration        time.Duration          `json:"duration"`
+	RoundTrip       *LatencyStats          `json:"round_trip,omitempty"`
+	ConnectionLimit int                    `json:"connection_limit,omitempty"`
+	Features        map[string]interface{} `json:"features,omitempty"`
+	Errors          map[string]string      `json:"errors,omitempty"` // probe step -> error
+}
+
+// Run executes the probe
+func (p *StartupProbe) Run(ctx context.Context) *ProbeResult {
+	if p.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.Timeout)
+		defer cancel()
+	}
+
+	result := &ProbeResult{StartedAt: clock.Now()}
+	fail := func(step string, err error) {
+		if result.Errors == nil {
+			result.Errors = make(map[string]string)
+		}
+		result.Errors[step] = err.Error()
+	}
+
+	if p.Ping != nil {
+		samples := p.Samples
+		if samples <= 0 {
+			samples = DefaultProbeSamples
+		}
+		var stats LatencyStats
+		var total time.Duration
+		for i := 0; i < samples; i++ {
+			start := clock.Now()
+			if err := p.Ping(ctx); err != nil {
+				fail("round_trip", err)
+				break
+			}
+			elapsed := clock.Since(start)
+			if stats.Samples == 0 || elapsed < stats.Min {
+				stats.Min = elapsed
+			}
+			if elapsed > stats.Max {
+				stats.Max = elapsed
+			}
+			total += elapsed
+			stats.Samples++
+		}
+		if stats.Samples > 0 {
+			stats.Avg = total / time.Duration(stats.Samples)
+			result.RoundTrip = &stats
+		}
+	}
+
+	if p.ConnectionLimit != nil {
+		if limit, err := p.ConnectionLimit(ctx); err != nil {
+			fail("connection_limit", err)
+		} else {
+			result.ConnectionLimit = limit
+		}
+	}
+
+	for _, name := range sortedStringKeys(p.Features) {
+		value, err := p.Features[name](ctx)
+		if err != nil {
+			fail("feature:"+name, err)
+			continue
+		}
+		if result.Features == nil {
+			result.Features = make(map[string]interface{})
+		}
+		result.Features[name] = value
+	}
+
+	result.Duration = clock.Since(result.StartedAt)
+	return result
+}
+
+// Feature returns a detected feature value
+func (r *ProbeResult) Feature(name string) (interface{}, bool) {
+	if r == nil {
+		return nil, false
+	}
+	value, ok := r.Features[name]
+	return value, ok
+}
+
+// SuggestedConcurrency derives a concurrency limit for mutating operations: a quarter of
+// the backend's connection limit, halved when round trips are slow (over 50ms on
+// average), and never more than ceiling or less than 1. Without a measured connection
+// limit ceiling is returned.
+func (r *ProbeResult) SuggestedConcurrency(ceiling int) int {
+	if ceiling < 1 {
+		ceiling = 1
+	}
+	if r == nil || r.ConnectionLimit <= 0 {
+		return ceiling
+	}
+	n := r.ConnectionLimit / 4
+	if r.RoundTrip != nil && r.RoundTrip.Avg > 50*time.Millisecond {
+		n /= 2
+	}
+	if n < 1 {
+		n = 1
+	}
+	if n > ceiling {
+		n = ceiling
+	}
+	return n
+}
+
+// Annotate records the result in status.Metadata under StartupProbeMetadataKey
+func (r *ProbeResult) Annotate(status *HealthStatus) {
+	if r == nil || status == nil {
+		return
+	}
+	if status.Metadata == nil {
+		status.Metadata = make(map[string]interface{})
+	}
+	status.Metadata[StartupProbeMetadataKey] = r
+}
+
+// Summary describes the result in one line for startup logs
+func (r *ProbeResult) Summary() string {
+	if r == nil {
+		return "startup probe not run"
+	}
+	summary := "startup probe:"
+	if r.RoundTrip != nil {
+		summary += fmt.Sprintf(" round trip avg %s (%d samples)", r.RoundTrip.Avg, r.RoundTrip.Samples)
+	}
+	if r.ConnectionLimit > 0 {
+		summary += fmt.Sprintf(", connection limit %d", r.ConnectionLimit)
+	}
+	if len(r.Features) > 0 {
+		summary += fmt.Sprintf(", features %v", sortedStringKeys(r.Features))
+	}
+	if len(r.Errors) > 0 {
+		summary += fmt.Sprintf(", failed %v", sortedStringKeys(r.Errors))
+	}
+	return summary
+}
+
+// RunStartupProbe runs probe and keeps the result for StartupProbeResult and
+// AnnotateHealth. Providers call it at the end of Configure when they opt in.
+func (bp *BaseProvider) RunStartupProbe(ctx context.Context, probe *StartupProbe) *ProbeResult {
+	result := probe.Run(ctx)
+	bp.probe = result
+	return result
+}
+
+// StartupProbeResult returns the result of the last RunStartupProbe, nil when none ran
+func (bp *BaseProvider) StartupProbeResult() *ProbeResult {
+	return bp.probe
+}
+
+// AnnotateHealth adds the startup probe result to a HealthStatus built by the provider
+func (bp *BaseProvider) AnnotateHealth(status *HealthStatus) {
+	bp.probe.Annotate(status)
+}
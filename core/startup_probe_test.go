@@ -0,0 +1,68 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/types"
+)
+
+// TestStartupProbe validates latency measurement, capability detection and recording
+// into HealthStatus.Metadata
+func TestStartupProbe(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	defer SetClock(types.ClockFunc(func() time.Time { return now }))()
+
+	pings := 0
+	probe := &StartupProbe{
+		Samples: 3,
+		Ping: func(ctx context.Context) error {
+			pings++
+			now = now.Add(time.Duration(pings) * 10 * time.Millisecond)
+			return nil
+		},
+		ConnectionLimit: func(ctx context.Context) (int, error) { return 100, nil },
+		Features: map[string]func(ctx context.Context) (interface{}, error){
+			"server_version": func(ctx context.Context) (interface{}, error) { return "16.2", nil },
+			"logical_replication": func(ctx context.Context) (interface{}, error) {
+				return nil, errors.New("permission denied")
+			},
+		},
+	}
+
+	bp := NewBaseProvider("test")
+	result := bp.RunStartupProbe(context.Background(), probe)
+
+	if result.RoundTrip == nil || result.RoundTrip.Samples != 3 ||
+		result.RoundTrip.Min != 10*time.Millisecond || result.RoundTrip.Max != 30*time.Millisecond ||
+		result.RoundTrip.Avg != 20*time.Millisecond {
+		t.Fatalf("unexpected round trip stats %+v", result.RoundTrip)
+	}
+	if v, ok := result.Feature("server_version"); !ok || v != "16.2" {
+		t.Fatalf("expected server_version feature, got %v", result.Features)
+	}
+	if result.Errors["feature:logical_replication"] != "permission denied" {
+		t.Fatalf("expected failed feature probe to be recorded, got %v", result.Errors)
+	}
+	if got := result.SuggestedConcurrency(10); got != 10 {
+		t.Fatalf("SuggestedConcurrency(10) = %d, want 10", got)
+	}
+	if got := result.SuggestedConcurrency(50); got != 25 {
+		t.Fatalf("SuggestedConcurrency(50) = %d, want 25", got)
+	}
+
+	status := &HealthStatus{Healthy: true}
+	bp.AnnotateHealth(status)
+	if status.Metadata[StartupProbeMetadataKey] != result {
+		t.Fatal("expected the probe result in HealthStatus.Metadata")
+	}
+
+	var unprobed BaseProvider
+	empty := &HealthStatus{}
+	unprobed.AnnotateHealth(empty)
+	if empty.Metadata != nil {
+		t.Fatal("providers without a probe must leave metadata untouched")
+	}
+}
@@ -0,0 +1,348 @@
+// Package core provides resource type schemas generated from Go struct tags
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Struct tags read by SchemaFromStruct
+const (
+	StructTagKolumn      = "kolumn"
+	StructTagDescription = "description"
+)
+
+// Options of the kolumn struct tag
+const (
+	StructTagRequired   = "required"   // required in the config schema
+	StructTagComputed   = "computed"   // set by the provider; state schema only
+	StructTagWriteOnly  = "write_only" // never echoed into state; config schema only, marked writeOnly
+	StructTagSensitive  = "sensitive"  // marked sensitive in both schemas
+	StructTagDeprecated = "deprecated" // marked deprecated in both schemas
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// SchemaFromStruct generates the config and state JSON schemas of a resource type
+// from a struct, typically the one its handler decodes requests into, so the schemas
+// cannot drift from it:
+//
+//	type Topic struct {
+//	    Name        string            `kolumn:"name,required" description:"Topic name"`
+//	    Partitions  int               `kolumn:"partitions,default=1,min=1"`
+//	    Cleanup     string            `kolumn:"cleanup_policy,enum=delete|compact"`
+//	    Password    string            `kolumn:"sasl_password,write_only,sensitive"`
+//	    Tags        map[string]string `kolumn:"tags"`
+//	    TopicID     string            `kolumn:"topic_id,computed"`
+//	}
+//
+//	config, state, err := core.SchemaFromStruct(Topic{})
+//
+// The kolumn tag names the attribute, falling back to the json tag and then the field
+// name; "-" skips the field. Its options are required, computed, write_only, sensitive,
+// deprecated, default=V, enum=A|B, min=N and max=N. The description tag documents the
+// attribute. Nested structs become objects, slices arrays, maps with string keys objects
+// of additionalProperties, and embedded structs are flattened like encoding/json does.
+func SchemaFromStruct(v interface{}) (configSchema, stateSchema json.RawMessage, err error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, nil, fmt.Errorf("schema from struct: %v is not a struct", reflect.TypeOf(v))
+	}
+
+	g := &structSchemaGenerator{seen: make(map[reflect.Type]bool)}
+	config, err := g.object(t, structSchemaConfig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("schema from struct %s: %w", t, err)
+	}
+	state, err := g.object(t, structSchemaState)
+	if err != nil {
+		return nil, nil, fmt.Errorf("schema from struct %s: %w", t, err)
+	}
+	if configSchema, err = json.Marshal(config); err != nil {
+		return nil, nil, err
+	}
+	if stateSchema, err = json.Marshal(state); err != nil {
+		return nil, nil, err
+	}
+	return configSchema, stateSchema, nil
+}
+
+// structSchemaKind selects which schema of a resource type is generated
+type structSchemaKind int
+
+const (
+	structSchemaConfig structSchemaKind = iota
+	structSchemaState
+)
+
+// structField is one attribute parsed from a struct field and its tags
+type structField struct {
+	name        string
+	typ         reflect.Type
+	description string
+	required    bool
+	computed    bool
+	writeOnly   bool
+	sensitive   bool
+	deprecated  bool
+	options     map[string]string // default, enum, min and max
+}
+
+type structSchemaGenerator struct {
+	seen map[reflect.Type]bool // structs being generated, to reject recursive types
+}
+
+// object generates the object schema of a struct
+func (g *structSchemaGenerator) object(t reflect.Type, kind structSchemaKind) (map[string]interface{}, error) {
+	if g.seen[t] {
+		return nil, fmt.Errorf("%s is recursive", t)
+	}
+	g.seen[t] = true
+	defer delete(g.seen, t)
+
+	fields, err := structFields(t)
+	if err != nil {
+		return nil, err
+	}
+	properties := make(map[string]interface{}, len(fields))
+	var required []string
+	for _, field := range fields {
+		if (kind == structSchemaConfig && field.computed) || (kind == structSchemaState && field.writeOnly) {
+			continue
+		}
+		property, err := g.property(field.typ, kind)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", field.name, err)
+		}
+		if err := field.annotate(property); err != nil {
+			return nil, fmt.Errorf("%s: %w", field.name, err)
+		}
+		properties[field.name] = property
+		if kind == structSchemaConfig && field.required {
+			required = append(required, field.name)
+		}
+	}
+
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema, nil
+}
+
+// property generates the schema of a field's type
+func (g *structSchemaGenerator) property(t reflect.Type, kind structSchemaKind) (map[string]interface{}, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}, nil
+	case t == rawMessageType:
+		return map[string]interface{}{}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}, nil
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}, nil
+	case reflect.Interface:
+		return map[string]interface{}{}, nil
+	case reflect.Struct:
+		return g.object(t, kind)
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			// encoding/json writes byte slices as base64 strings
+			return map[string]interface{}{"type": "string", "contentEncoding": "base64"}, nil
+		}
+		items, err := g.property(t.Elem(), kind)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "array", "items": items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("map keys must be strings, got %s", t.Key())
+		}
+		values, err := g.property(t.Elem(), kind)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "object", "additionalProperties": values}, nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", t)
+}
+
+// structFields returns the attributes of a struct in field order, flattening embedded
+// structs without a name of their own
+func structFields(t reflect.Type) ([]structField, error) {
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, hasTag := f.Tag.Lookup(StructTagKolumn)
+		if !hasTag {
+			// json options such as omitempty are not kolumn options
+			tag, _, _ = strings.Cut(f.Tag.Get("json"), ",")
+		}
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+
+		if f.Anonymous && name == "" {
+			embedded := f.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				nested, err := structFields(embedded)
+				if err != nil {
+					return nil, err
+				}
+				fields = append(fields, nested...)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		field := structField{name: name, typ: f.Type, description: f.Tag.Get(StructTagDescription)}
+		if options != "" {
+			if err := field.parseOptions(options); err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// parseOptions reads the options of a kolumn tag after the attribute name
+func (f *structField) parseOptions(options string) error {
+	for _, option := range strings.Split(options, ",") {
+		key, value, hasValue := strings.Cut(strings.TrimSpace(option), "=")
+		switch {
+		case key == StructTagRequired && !hasValue:
+			f.required = true
+		case key == StructTagComputed && !hasValue:
+			f.computed = true
+		case key == StructTagWriteOnly && !hasValue:
+			f.writeOnly = true
+		case key == StructTagSensitive && !hasValue:
+			f.sensitive = true
+		case key == StructTagDeprecated && !hasValue:
+			f.deprecated = true
+		case (key == "default" || key == "enum" || key == "min" || key == "max") && hasValue:
+			if f.options == nil {
+				f.options = make(map[string]string)
+			}
+			f.options[key] = value
+		default:
+			return fmt.Errorf("unknown kolumn tag option %q", option)
+		}
+	}
+	if f.required && f.computed {
+		return fmt.Errorf("%s and %s are exclusive", StructTagRequired, StructTagComputed)
+	}
+	if f.computed && f.writeOnly {
+		return fmt.Errorf("%s and %s are exclusive", StructTagComputed, StructTagWriteOnly)
+	}
+	return nil
+}
+
+// annotate adds the field's description, flags and options to its property schema
+func (f *structField) annotate(property map[string]interface{}) error {
+	if f.description != "" {
+		property["description"] = f.description
+	}
+	if f.sensitive {
+		property["sensitive"] = true
+	}
+	if f.writeOnly {
+		property["writeOnly"] = true
+	}
+	if f.computed {
+		property["readOnly"] = true
+	}
+	if f.deprecated {
+		property["deprecated"] = true
+	}
+
+	for _, key := range sortedStringKeys(f.options) {
+		value := f.options[key]
+		switch key {
+		case "default":
+			parsed, err := parseStructTagValue(f.typ, value)
+			if err != nil {
+				return fmt.Errorf("invalid default: %w", err)
+			}
+			property["default"] = parsed
+		case "enum":
+			var enum []interface{}
+			for _, member := range strings.Split(value, "|") {
+				parsed, err := parseStructTagValue(f.typ, member)
+				if err != nil {
+					return fmt.Errorf("invalid enum: %w", err)
+				}
+				enum = append(enum, parsed)
+			}
+			property["enum"] = enum
+		case "min", "max":
+			bound, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("invalid %s %q", key, value)
+			}
+			keyword, ok := structTagBounds[key][fmt.Sprint(property["type"])]
+			if !ok {
+				return fmt.Errorf("%s does not apply to %s", key, f.typ)
+			}
+			property[keyword] = bound
+		}
+	}
+	return nil
+}
+
+// structTagBounds maps the min and max options to the JSON schema keyword of each type
+var structTagBounds = map[string]map[string]string{
+	"min": {"integer": "minimum", "number": "minimum", "string": "minLength", "array": "minItems"},
+	"max": {"integer": "maximum", "number": "maximum", "string": "maxLength", "array": "maxItems"},
+}
+
+// parseStructTagValue parses a default or enum value as the field's type
+func parseStructTagValue(t reflect.Type, value string) (interface{}, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return value, nil
+	case reflect.Bool:
+		return strconv.ParseBool(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.ParseInt(value, 10, 64)
+	case reflect.Float32, reflect.Float64:
+		return strconv.ParseFloat(value, 64)
+	}
+	return nil, fmt.Errorf("values of %s cannot be set in a tag", t)
+}
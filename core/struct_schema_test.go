@@ -0,0 +1,119 @@
+package core
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+type structSchemaAudit struct {
+	CreatedAt time.Time `kolumn:"created_at,computed"`
+}
+
+type structSchemaTopic struct {
+	structSchemaAudit
+	Name       string            `kolumn:"name,required" description:"Topic name"`
+	Partitions int               `kolumn:"partitions,default=1,min=1,max=64"`
+	Cleanup    string            `kolumn:"cleanup_policy,enum=delete|compact"`
+	Password   string            `kolumn:"sasl_password,write_only,sensitive"`
+	Tags       map[string]string `json:"tags,omitempty"`
+	Replicas   []struct {
+		Broker int `kolumn:"broker,required"`
+	} `kolumn:"replicas"`
+	Retention  *float64 `kolumn:"retention_hours,deprecated"`
+	TopicID    string   `kolumn:"topic_id,computed"`
+	Internal   string   `kolumn:"-"`
+	unexported string
+}
+
+// TestSchemaFromStruct validates config and state schemas generated from struct tags
+func TestSchemaFromStruct(t *testing.T) {
+	configSchema, stateSchema, err := SchemaFromStruct(&structSchemaTopic{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var config map[string]interface{}
+	if err := json.Unmarshal(configSchema, &config); err != nil {
+		t.Fatal(err)
+	}
+	properties := config["properties"].(map[string]interface{})
+	if len(properties) != 7 || properties["topic_id"] != nil || properties["created_at"] != nil {
+		t.Errorf("config properties = %v", properties)
+	}
+	if required := config["required"].([]interface{}); len(required) != 1 || required[0] != "name" {
+		t.Errorf("required = %v", required)
+	}
+	if name := properties["name"].(map[string]interface{}); name["type"] != "string" || name["description"] != "Topic name" {
+		t.Errorf("name = %v", name)
+	}
+	partitions := properties["partitions"].(map[string]interface{})
+	if partitions["type"] != "integer" || partitions["default"] != float64(1) || partitions["minimum"] != float64(1) || partitions["maximum"] != float64(64) {
+		t.Errorf("partitions = %v", partitions)
+	}
+	if enum := properties["cleanup_policy"].(map[string]interface{})["enum"].([]interface{}); len(enum) != 2 || enum[1] != "compact" {
+		t.Errorf("enum = %v", enum)
+	}
+	if password := properties["sasl_password"].(map[string]interface{}); password["writeOnly"] != true || password["sensitive"] != true {
+		t.Errorf("sasl_password = %v", password)
+	}
+	if tags := properties["tags"].(map[string]interface{}); tags["type"] != "object" || tags["additionalProperties"].(map[string]interface{})["type"] != "string" {
+		t.Errorf("tags = %v", tags)
+	}
+	items := properties["replicas"].(map[string]interface{})["items"].(map[string]interface{})
+	if items["required"].([]interface{})[0] != "broker" {
+		t.Errorf("replicas items = %v", items)
+	}
+	if retention := properties["retention_hours"].(map[string]interface{}); retention["type"] != "number" || retention["deprecated"] != true {
+		t.Errorf("retention_hours = %v", retention)
+	}
+
+	// the generated schemas drive the same defaults and docs as hand-written ones
+	if defaults := SchemaDefaults(configSchema); len(defaults) != 1 || defaults["partitions"] != float64(1) {
+		t.Errorf("SchemaDefaults = %v", defaults)
+	}
+	refs := make(map[string]AttributeReference)
+	for _, ref := range BuildAttributeReference("kafka_topic", configSchema, stateSchema) {
+		refs[ref.Name] = ref
+	}
+	if refs["topic_id"].Source != AttributeSourceComputed || refs["created_at"].Source != AttributeSourceComputed ||
+		refs["name"].Source != AttributeSourceBoth || refs["sasl_password"].Source != AttributeSourceConfig || !refs["sasl_password"].Sensitive {
+		t.Errorf("attribute reference = %+v", refs)
+	}
+	if strings.Contains(string(stateSchema), `"required"`) {
+		t.Errorf("state schema has required attributes: %s", stateSchema)
+	}
+}
+
+// TestSchemaFromStructErrors validates invalid structs and tags are rejected
+func TestSchemaFromStructErrors(t *testing.T) {
+	type recursive struct {
+		Children []recursive `kolumn:"children"`
+	}
+	cases := map[string]interface{}{
+		"not a struct": "topic",
+		"nil":          nil,
+		"unknown option": struct {
+			Name string `kolumn:"name,requird"`
+		}{},
+		"bad default": struct {
+			Partitions int `kolumn:"partitions,default=many"`
+		}{},
+		"bound on bool": struct {
+			Enabled bool `kolumn:"enabled,min=1"`
+		}{},
+		"required and computed": struct {
+			ID string `kolumn:"id,required,computed"`
+		}{},
+		"unsupported type": struct {
+			Events chan string `kolumn:"events"`
+		}{},
+		"recursive": recursive{},
+	}
+	for name, v := range cases {
+		if _, _, err := SchemaFromStruct(v); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+// Package core provides closest-match suggestions for validation errors
+package core
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Levenshtein returns the edit distance between two strings
+func Levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// ClosestMatch returns the candidate nearest to value. Case-only differences always
+// match; otherwise the edit distance must be at most a third of the value's length
+// (minimum 2) so unrelated values are not suggested.
+func ClosestMatch(value string, candidates []string) (string, bool) {
+	best, bestDistance := "", -1
+	for _, c := range candidates {
+		if strings.EqualFold(c, value) {
+			return c, true
+		}
+		d := Levenshtein(strings.ToLower(value), strings.ToLower(c))
+		if bestDistance < 0 || d < bestDistance {
+			best, bestDistance = c, d
+		}
+	}
+	limit := max(2, utf8.RuneCountInString(value)/3)
+	if bestDistance < 0 || bestDistance > limit {
+		return "", false
+	}
+	return best, true
+}
+
+// patternCandidates are normalizations tried when a value does not match a pattern
+var patternCandidates = []func(string) string{
+	strings.TrimSpace,
+	strings.ToLower,
+	strings.ToUpper,
+	func(s string) string { return identifierize(s, "_") },
+	func(s string) string { return identifierize(s, "-") },
+}
+
+// identifierize lowercases s and joins its words with sep
+func identifierize(s, sep string) string {
+	fields := strings.FieldsFunc(strings.ToLower(strings.TrimSpace(s)), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+	return strings.Join(fields, sep)
+}
+
+// SuggestPatternMatch returns a normalization of value that matches pattern, such as
+// the value lowercased or with spaces replaced by underscores
+func SuggestPatternMatch(value, pattern string) (string, bool) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", false
+	}
+	for _, normalize := range patternCandidates {
+		if candidate := normalize(value); candidate != "" && candidate != value && re.MatchString(candidate) {
+			return candidate, true
+		}
+	}
+	return "", false
+}
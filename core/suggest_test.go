@@ -0,0 +1,92 @@
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestClosestMatch validates near misses are suggested and unrelated values are not
+func TestClosestMatch(t *testing.T) {
+	if d := Levenshtein("kitten", "sitting"); d != 3 {
+		t.Errorf("Levenshtein(kitten, sitting) = %d, want 3", d)
+	}
+
+	candidates := []string{"require", "verify-ca", "verify-full", "disable"}
+	cases := map[string]string{
+		"requre":     "require",
+		"REQUIRE":    "require",
+		"verify-ful": "verify-full",
+		"postgres":   "",
+	}
+	for value, want := range cases {
+		got, ok := ClosestMatch(value, candidates)
+		if want == "" {
+			if ok {
+				t.Errorf("ClosestMatch(%q) = %q, want no match", value, got)
+			}
+			continue
+		}
+		if !ok || got != want {
+			t.Errorf("ClosestMatch(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
+
+// TestValidatorEnumSuggestion validates enum errors carry did-you-mean, allowed set and doc link
+func TestValidatorEnumSuggestion(t *testing.T) {
+	rule := NewValidationRule("ssl_mode").Type("string").Enum("require", "verify-full", "disable").
+		DocLink("https://docs.example.com/ssl").Build()
+	result := NewValidator("postgres").AddRule(rule).Validate(map[string]interface{}{"ssl_mode": "requird"})
+
+	if result.Valid || len(result.Errors) != 1 {
+		t.Fatalf("expected one error, got %+v", result)
+	}
+	fieldErr := result.Errors[0]
+	if fieldErr.DidYouMean != "require" || len(fieldErr.Allowed) != 3 || fieldErr.DocLink != "https://docs.example.com/ssl" {
+		t.Errorf("unexpected field error: %+v", fieldErr)
+	}
+
+	diagnostics := result.Diagnostics()
+	if len(diagnostics) != 1 {
+		t.Fatalf("expected one diagnostic, got %d", len(diagnostics))
+	}
+	d := diagnostics[0]
+	if d.Code != "INVALID_ENUM_VALUE" || d.Resource != "ssl_mode" {
+		t.Errorf("unexpected diagnostic: %+v", d)
+	}
+	for _, want := range []string{`Did you mean "require"?`, "require, verify-full, disable", "https://docs.example.com/ssl"} {
+		if !strings.Contains(d.Detail, want) {
+			t.Errorf("diagnostic detail %q missing %q", d.Detail, want)
+		}
+	}
+}
+
+// TestValidatorPatternSuggestion validates pattern errors suggest a matching normalization
+func TestValidatorPatternSuggestion(t *testing.T) {
+	rule := NewValidationRule("table").Type("string").Pattern(`^[a-z_][a-z0-9_]*$`).Build()
+	result := NewValidator("postgres").AddRule(rule).Validate(map[string]interface{}{"table": "Order Items"})
+
+	if len(result.Errors) != 1 || result.Errors[0].DidYouMean != "order_items" {
+		t.Fatalf("expected order_items suggestion, got %+v", result.Errors)
+	}
+}
+
+// TestPropertyDocLinks validates EnhancedValidation.DocLinks reach validation errors
+func TestPropertyDocLinks(t *testing.T) {
+	schema := &Schema{Name: "postgres", CreateObjects: map[string]*ObjectType{
+		"table": {Properties: map[string]*Property{
+			"mode": {Type: "string", Enhanced: &EnhancedValidation{
+				Rules:    []ConfigValidationRule{{Type: "string", Enum: []string{"append", "replace"}}},
+				DocLinks: []string{"https://docs.example.com/table#mode"},
+			}},
+		}},
+	}}
+
+	result := schema.ValidateConfig(map[string]interface{}{"table.mode": "apend"})
+	if len(result.Errors) != 1 {
+		t.Fatalf("expected one error, got %+v", result.Errors)
+	}
+	if e := result.Errors[0]; e.DidYouMean != "append" || e.DocLink != "https://docs.example.com/table#mode" {
+		t.Errorf("unexpected error: %+v", e)
+	}
+}
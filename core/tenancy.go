@@ -0,0 +1,475 @@
+// Package core provides per-tenant isolation for providers serving many customers
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/core/auth"
+	"github.com/schemabounce/kolumn/sdk/helpers/security"
+)
+
+// tenantContextKey is the context key holding the effective tenant ID
+type tenantContextKey struct{}
+
+// tenantIDPattern restricts tenant IDs to safe identifier characters
+var tenantIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.:-]{0,127}$`)
+
+var (
+	// ErrTenantRequired is returned when multi-tenancy is enabled and a request has no tenant
+	ErrTenantRequired = errors.New("tenant identifier is required")
+
+	// ErrTenantNotConfigured is returned for tenants that were never configured
+	ErrTenantNotConfigured = errors.New("tenant is not configured")
+
+	// ErrCrossTenantAccess is returned when a request tries to act as a tenant it is not authenticated for
+	ErrCrossTenantAccess = errors.New("resource belongs to another tenant")
+)
+
+// WithTenant returns a context carrying the tenant ID
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenantID)
+}
+
+// TenantFromContext returns the tenant for a request. An explicit WithTenant value wins;
+// otherwise the organization ID from the authenticated claims is used.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	if tenantID, ok := ctx.Value(tenantContextKey{}).(string); ok && tenantID != "" {
+		return tenantID, true
+	}
+	if info, ok := auth.FromAuth(ctx); ok && info.Claims.OrgID != "" {
+		return info.Claims.OrgID, true
+	}
+	return "", false
+}
+
+// ValidateTenantID checks that a tenant ID is a safe identifier
+func ValidateTenantID(tenantID string) error {
+	if !tenantIDPattern.MatchString(tenantID) {
+		return fmt.Errorf("invalid tenant identifier %q", tenantID)
+	}
+	return nil
+}
+
+// tenantFromRequest attaches a tenant_id carried in a unified request to ctx. The request
+// tenant must be backed by an authenticated principal: it is rejected when the caller is
+// unauthenticated, when its claims do not name the tenant, or when it disagrees with the
+// tenant already established for ctx.
+func tenantFromRequest(ctx context.Context, input []byte) (context.Context, error) {
+	var req struct {
+		TenantID string `json:"tenant_id"`
+	}
+	if len(input) == 0 || json.Unmarshal(input, &req) != nil || req.TenantID == "" {
+		return ctx, nil
+	}
+
+	if err := ValidateTenantID(req.TenantID); err != nil {
+		return nil, err
+	}
+	if existing, ok := TenantFromContext(ctx); ok {
+		if existing != req.TenantID {
+			return nil, fmt.Errorf("%w: request tenant %q does not match authenticated tenant %q",
+				ErrCrossTenantAccess, req.TenantID, existing)
+		}
+		return ctx, nil
+	}
+	info, ok := auth.FromAuth(ctx)
+	if !ok {
+		return nil, fmt.Errorf("%w: request tenant %q has no authenticated principal",
+			ErrCrossTenantAccess, req.TenantID)
+	}
+	if !claimsAllowTenant(info.Claims, req.TenantID) {
+		return nil, fmt.Errorf("%w: principal %q is not entitled to tenant %q",
+			ErrCrossTenantAccess, info.Claims.Subject, req.TenantID)
+	}
+	return WithTenant(ctx, req.TenantID), nil
+}
+
+// TenantEntitlementPrefix marks entitlements granting access to a tenant, e.g. "tenant:acme"
+const TenantEntitlementPrefix = "tenant:"
+
+// claimsAllowTenant reports whether a principal without an organization may act as
+// tenantID: its workspace must be the tenant or it must hold a tenant entitlement
+func claimsAllowTenant(claims auth.Claims, tenantID string) bool {
+	if claims.WorkspaceID == tenantID {
+		return true
+	}
+	for _, entitlement := range claims.Entitlements {
+		if entitlement == TenantEntitlementPrefix+tenantID {
+			return true
+		}
+	}
+	return false
+}
+
+// tenantResourceFunctions are the operations whose resources are tracked per tenant
+var tenantResourceFunctions = map[string]bool{
+	"CreateResource": true,
+	"ReadResource":   true,
+	"UpdateResource": true,
+	"DeleteResource": true,
+}
+
+// tenantResourceKey identifies a resource within a tenant from a request or response
+func tenantResourceKey(resourceType string, payload []byte) string {
+	var ids struct {
+		ResourceID string `json:"resource_id"`
+	}
+	if json.Unmarshal(payload, &ids) != nil || ids.ResourceID == "" {
+		return ""
+	}
+	return resourceType + "/" + ids.ResourceID
+}
+
+// SetTenantRegistry enforces tenant isolation on resource operations. Each operation must
+// carry a configured tenant, is counted in that tenant's metrics, and claims, checks or
+// releases the resource in the tenant's ownership records. Resources another tenant owns
+// are denied, including RefreshAll and GetOutputs targets.
+func (d *UnifiedDispatcher) SetTenantRegistry(registry *TenantRegistry) {
+	d.tenants = registry
+}
+
+// enterTenant resolves the request's tenant and checks access to an existing resource.
+// The returned func records the operation and updates ownership once it completes.
+func (d *UnifiedDispatcher) enterTenant(ctx context.Context, function string, input []byte) (func(output []byte, err error), error) {
+	tenant, err := d.tenants.Tenant(ctx)
+	if err != nil {
+		return nil, security.NewSecureError("tenant access denied", err.Error(), "TENANT_ACCESS_DENIED")
+	}
+
+	resourceType, _ := requestUserContext(input)
+	key := tenantResourceKey(resourceType, input)
+	if function != "CreateResource" && key != "" {
+		if err := d.checkTenantResource(ctx, key); err != nil {
+			return nil, err
+		}
+	}
+
+	start := clock.Now()
+	return func(output []byte, err error) {
+		tenant.RecordOperation(function, clock.Since(start), err)
+		if err != nil {
+			return
+		}
+		switch function {
+		case "CreateResource":
+			if created := tenantResourceKey(resourceType, output); created != "" {
+				tenant.claim(created)
+			}
+		case "DeleteResource":
+			if key != "" {
+				tenant.release(key)
+			}
+		}
+	}, nil
+}
+
+// checkTenantResource denies access to a resource another tenant owns
+func (d *UnifiedDispatcher) checkTenantResource(ctx context.Context, key string) error {
+	if err := d.tenants.CheckResourceAccess(ctx, key); err != nil {
+		return security.NewSecureError("tenant access denied", err.Error(), "TENANT_ACCESS_DENIED")
+	}
+	return nil
+}
+
+// =============================================================================
+// TENANT REGISTRY
+// =============================================================================
+
+// TenantPoolFactory opens the connection pool (or client) for a tenant. It is called
+// lazily on first use with the tenant's own configuration.
+type TenantPoolFactory func(ctx context.Context, tenantID string, config map[string]interface{}) (io.Closer, error)
+
+// Tenant holds the isolated configuration, connection pool and metrics for one tenant
+type Tenant struct {
+	ID string
+
+	mu        sync.Mutex
+	config    map[string]interface{}
+	pool      io.Closer
+	factory   TenantPoolFactory
+	metrics   TenantMetrics
+	resources map[string]struct{}
+}
+
+// TenantMetrics are operation counters kept separately for each tenant
+type TenantMetrics struct {
+	Requests      int64            `json:"requests"`
+	Errors        int64            `json:"errors"`
+	TotalDuration time.Duration    `json:"total_duration"`
+	ByOperation   map[string]int64 `json:"by_operation,omitempty"`
+	LastRequest   time.Time        `json:"last_request,omitempty"`
+}
+
+// Config returns a copy of the tenant's configuration
+func (t *Tenant) Config() map[string]interface{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	config := make(map[string]interface{}, len(t.config))
+	for k, v := range t.config {
+		config[k] = v
+	}
+	return config
+}
+
+// Pool returns the tenant's connection pool, opening it on first use
+func (t *Tenant) Pool(ctx context.Context) (io.Closer, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.pool != nil {
+		return t.pool, nil
+	}
+	if t.factory == nil {
+		return nil, fmt.Errorf("no connection pool factory registered for tenant %q", t.ID)
+	}
+
+	pool, err := t.factory(ctx, t.ID, t.config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open connection pool for tenant %q: %w", t.ID, err)
+	}
+	t.pool = pool
+	return pool, nil
+}
+
+// RecordOperation adds one operation to the tenant's metrics
+func (t *Tenant) RecordOperation(operation string, duration time.Duration, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.metrics.Requests++
+	if err != nil {
+		t.metrics.Errors++
+	}
+	t.metrics.TotalDuration += duration
+	t.metrics.LastRequest = clock.Now()
+	if t.metrics.ByOperation == nil {
+		t.metrics.ByOperation = make(map[string]int64)
+	}
+	t.metrics.ByOperation[operation]++
+}
+
+// Metrics returns a snapshot of the tenant's metrics
+func (t *Tenant) Metrics() TenantMetrics {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	snapshot := t.metrics
+	snapshot.ByOperation = make(map[string]int64, len(t.metrics.ByOperation))
+	for k, v := range t.metrics.ByOperation {
+		snapshot.ByOperation[k] = v
+	}
+	return snapshot
+}
+
+// OwnsResource reports whether the tenant has claimed resourceID
+func (t *Tenant) OwnsResource(resourceID string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	_, ok := t.resources[resourceID]
+	return ok
+}
+
+// Resources returns the tenant's claimed resource IDs in sorted order
+func (t *Tenant) Resources() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ids := make([]string, 0, len(t.resources))
+	for id := range t.resources {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// claim records that the tenant owns resourceID
+func (t *Tenant) claim(resourceID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.resources == nil {
+		t.resources = make(map[string]struct{})
+	}
+	t.resources[resourceID] = struct{}{}
+}
+
+// release drops the tenant's ownership of resourceID
+func (t *Tenant) release(resourceID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.resources, resourceID)
+}
+
+// close releases the tenant's pool
+func (t *Tenant) close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.pool == nil {
+		return nil
+	}
+	err := t.pool.Close()
+	t.pool = nil
+	return err
+}
+
+// TenantRegistry isolates per-tenant state and guards resource ownership. Ownership is
+// kept inside each Tenant, so tenants backed by separate databases may use the same
+// resource IDs without conflicting.
+type TenantRegistry struct {
+	mu      sync.RWMutex
+	tenants map[string]*Tenant
+	factory TenantPoolFactory
+}
+
+// NewTenantRegistry creates a tenant registry using factory to open tenant pools
+func NewTenantRegistry(factory TenantPoolFactory) *TenantRegistry {
+	return &TenantRegistry{
+		tenants: make(map[string]*Tenant),
+		factory: factory,
+	}
+}
+
+// Configure registers or reconfigures a tenant. Reconfiguring closes the existing pool
+// so the next request reconnects with the new settings.
+func (r *TenantRegistry) Configure(tenantID string, config map[string]interface{}) error {
+	if err := ValidateTenantID(tenantID); err != nil {
+		return err
+	}
+
+	copied := make(map[string]interface{}, len(config))
+	for k, v := range config {
+		copied[k] = v
+	}
+
+	tenant := &Tenant{ID: tenantID, config: copied, factory: r.factory}
+
+	r.mu.Lock()
+	existing := r.tenants[tenantID]
+	if existing != nil {
+		tenant.metrics = existing.Metrics()
+		for _, resourceID := range existing.Resources() {
+			tenant.claim(resourceID)
+		}
+	}
+	r.tenants[tenantID] = tenant
+	r.mu.Unlock()
+
+	if existing != nil {
+		return existing.close()
+	}
+	return nil
+}
+
+// Tenant returns the tenant for the request context
+func (r *TenantRegistry) Tenant(ctx context.Context) (*Tenant, error) {
+	tenantID, ok := TenantFromContext(ctx)
+	if !ok {
+		return nil, ErrTenantRequired
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tenant, exists := r.tenants[tenantID]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrTenantNotConfigured, tenantID)
+	}
+	return tenant, nil
+}
+
+// Remove closes and forgets a tenant and its resource ownership records
+func (r *TenantRegistry) Remove(tenantID string) error {
+	r.mu.Lock()
+	tenant := r.tenants[tenantID]
+	delete(r.tenants, tenantID)
+	r.mu.Unlock()
+
+	if tenant == nil {
+		return nil
+	}
+	return tenant.close()
+}
+
+// TenantIDs returns the configured tenant IDs in sorted order
+func (r *TenantRegistry) TenantIDs() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := make([]string, 0, len(r.tenants))
+	for id := range r.tenants {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// ClaimResource records that the request's tenant owns resourceID
+func (r *TenantRegistry) ClaimResource(ctx context.Context, resourceID string) error {
+	tenant, err := r.Tenant(ctx)
+	if err != nil {
+		return err
+	}
+	tenant.claim(resourceID)
+	return nil
+}
+
+// CheckResourceAccess fails unless the request carries a configured tenant and no other
+// tenant owns resourceID. A resource both tenants created in their own databases stays
+// accessible to each; one claimed only by another tenant is denied.
+func (r *TenantRegistry) CheckResourceAccess(ctx context.Context, resourceID string) error {
+	tenant, err := r.Tenant(ctx)
+	if err != nil {
+		return err
+	}
+	if tenant.OwnsResource(resourceID) {
+		return nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for id, other := range r.tenants {
+		if id != tenant.ID && other.OwnsResource(resourceID) {
+			return fmt.Errorf("%w: %s", ErrCrossTenantAccess, resourceID)
+		}
+	}
+	return nil
+}
+
+// ReleaseResource drops the request tenant's ownership record after a resource is deleted
+func (r *TenantRegistry) ReleaseResource(ctx context.Context, resourceID string) error {
+	tenant, err := r.Tenant(ctx)
+	if err != nil {
+		return err
+	}
+	tenant.release(resourceID)
+	return nil
+}
+
+// Close closes every tenant pool
+func (r *TenantRegistry) Close() error {
+	r.mu.Lock()
+	tenants := make([]*Tenant, 0, len(r.tenants))
+	for _, tenant := range r.tenants {
+		tenants = append(tenants, tenant)
+	}
+	r.mu.Unlock()
+
+	var errs []error
+	for _, tenant := range tenants {
+		if err := tenant.close(); err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", tenant.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,270 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/core/auth"
+	"github.com/schemabounce/kolumn/sdk/helpers/security"
+)
+
+type closerFunc func() error
+
+func (f closerFunc) Close() error { return f() }
+
+// TestTenantFromContext validates explicit tenants and the auth claims fallback
+func TestTenantFromContext(t *testing.T) {
+	if _, ok := TenantFromContext(context.Background()); ok {
+		t.Fatalf("expected no tenant on empty context")
+	}
+
+	ctx := auth.WithAuth(context.Background(), auth.AuthInfo{Claims: auth.Claims{OrgID: "org-1"}})
+	if got, _ := TenantFromContext(ctx); got != "org-1" {
+		t.Errorf("expected org-1 from claims, got %q", got)
+	}
+
+	if got, _ := TenantFromContext(WithTenant(ctx, "org-2")); got != "org-2" {
+		t.Errorf("expected explicit tenant to win, got %q", got)
+	}
+}
+
+// TestTenantFromRequestRejectsSpoofing validates that a request cannot switch tenants
+func TestTenantFromRequestRejectsSpoofing(t *testing.T) {
+	ctx := auth.WithAuth(context.Background(), auth.AuthInfo{Claims: auth.Claims{OrgID: "org-1"}})
+
+	if _, err := tenantFromRequest(ctx, []byte(`{"tenant_id":"org-2"}`)); !errors.Is(err, ErrCrossTenantAccess) {
+		t.Errorf("expected ErrCrossTenantAccess, got %v", err)
+	}
+	if _, err := tenantFromRequest(context.Background(), []byte(`{"tenant_id":"../etc"}`)); err == nil {
+		t.Errorf("expected invalid tenant id to be rejected")
+	}
+	if _, err := tenantFromRequest(context.Background(), []byte(`{"tenant_id":"org-2"}`)); !errors.Is(err, ErrCrossTenantAccess) {
+		t.Errorf("expected unauthenticated request tenant to be rejected, got %v", err)
+	}
+
+	subject := auth.WithAuth(context.Background(), auth.AuthInfo{Claims: auth.Claims{Subject: "svc"}})
+	if _, err := tenantFromRequest(subject, []byte(`{"tenant_id":"org-2"}`)); !errors.Is(err, ErrCrossTenantAccess) {
+		t.Errorf("expected a principal without tenant claims to be rejected, got %v", err)
+	}
+	entitled := auth.WithAuth(context.Background(), auth.AuthInfo{Claims: auth.Claims{
+		Subject: "svc", Entitlements: []string{"tenant:org-2"},
+	}})
+	if got, err := tenantFromRequest(entitled, []byte(`{"tenant_id":"org-2"}`)); err != nil {
+		t.Errorf("unexpected error for entitled principal: %v", err)
+	} else if id, _ := TenantFromContext(got); id != "org-2" {
+		t.Errorf("expected org-2, got %q", id)
+	}
+	workspace := auth.WithAuth(context.Background(), auth.AuthInfo{Claims: auth.Claims{Subject: "svc", WorkspaceID: "org-3"}})
+	if _, err := tenantFromRequest(workspace, []byte(`{"tenant_id":"org-3"}`)); err != nil {
+		t.Errorf("unexpected error for workspace principal: %v", err)
+	}
+
+	got, err := tenantFromRequest(ctx, []byte(`{"tenant_id":"org-1"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id, _ := TenantFromContext(got); id != "org-1" {
+		t.Errorf("expected org-1, got %q", id)
+	}
+}
+
+// TestTenantIsolation validates per-tenant pools, metrics and resource ownership
+func TestTenantIsolation(t *testing.T) {
+	opened := map[string]int{}
+	closed := map[string]int{}
+	bp := NewBaseProvider("test")
+	bp.EnableMultiTenancy(func(ctx context.Context, tenantID string, config map[string]interface{}) (io.Closer, error) {
+		opened[tenantID]++
+		return closerFunc(func() error { closed[tenantID]++; return nil }), nil
+	})
+
+	for _, id := range []string{"acme", "globex"} {
+		if err := bp.ConfigureTenant(context.Background(), id, map[string]interface{}{"host": id + ".internal"}); err != nil {
+			t.Fatalf("configure %s: %v", id, err)
+		}
+	}
+
+	acme := WithTenant(context.Background(), "acme")
+	globex := WithTenant(context.Background(), "globex")
+
+	tenant, err := bp.Tenant(acme)
+	if err != nil {
+		t.Fatalf("tenant: %v", err)
+	}
+	if tenant.Config()["host"] != "acme.internal" {
+		t.Errorf("expected acme config, got %v", tenant.Config())
+	}
+	if _, err := tenant.Pool(acme); err != nil {
+		t.Fatalf("pool: %v", err)
+	}
+	if _, err := tenant.Pool(acme); err != nil {
+		t.Fatalf("pool: %v", err)
+	}
+	if opened["acme"] != 1 || opened["globex"] != 0 {
+		t.Errorf("expected one lazily opened acme pool, got %v", opened)
+	}
+
+	tenant.RecordOperation("CreateResource", time.Millisecond, nil)
+	if other, _ := bp.Tenant(globex); other.Metrics().Requests != 0 {
+		t.Errorf("expected metrics to be isolated per tenant")
+	}
+
+	if _, err := bp.Tenant(WithTenant(context.Background(), "initech")); !errors.Is(err, ErrTenantNotConfigured) {
+		t.Errorf("expected ErrTenantNotConfigured, got %v", err)
+	}
+
+	// the same resource ID in separate tenant databases does not conflict
+	for _, ctx := range []context.Context{acme, globex} {
+		if err := bp.Tenants().ClaimResource(ctx, "table/users"); err != nil {
+			t.Fatalf("claim: %v", err)
+		}
+		if err := bp.Tenants().CheckResourceAccess(ctx, "table/users"); err != nil {
+			t.Errorf("check: %v", err)
+		}
+	}
+	if err := bp.Tenants().ReleaseResource(globex, "table/users"); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	if other, _ := bp.Tenant(globex); other.OwnsResource("table/users") {
+		t.Errorf("expected globex to release its resource")
+	}
+	if !tenant.OwnsResource("table/users") {
+		t.Errorf("expected release by globex to leave acme's resource claimed")
+	}
+	if err := bp.Tenants().CheckResourceAccess(WithTenant(context.Background(), "initech"), "table/users"); !errors.Is(err, ErrTenantNotConfigured) {
+		t.Errorf("expected ErrTenantNotConfigured, got %v", err)
+	}
+
+	if err := bp.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if closed["acme"] != 1 {
+		t.Errorf("expected acme pool to be closed, got %v", closed)
+	}
+}
+
+// tenantRegistry returns a fixed resource ID from create so ownership can be tracked
+type tenantRegistry struct {
+	echoRegistry
+	resourceID string
+}
+
+func (r *tenantRegistry) CallHandler(ctx context.Context, objectType, method string, input []byte) ([]byte, error) {
+	r.last = input
+	resourceID := r.resourceID
+	if resourceID == "" {
+		resourceID = "public.users"
+	}
+	return []byte(`{"resource_id":"` + resourceID + `","state":{}}`), nil
+}
+
+// TestDispatchEnforcesTenancy validates resource operations run against the request tenant
+func TestDispatchEnforcesTenancy(t *testing.T) {
+	registry := NewTenantRegistry(nil)
+	for _, id := range []string{"acme", "globex"} {
+		if err := registry.Configure(id, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	d := NewUnifiedDispatcher(&tenantRegistry{}, nil)
+	d.SetTenantRegistry(registry)
+
+	create := []byte(`{"resource_type":"table","name":"users","config":{"schema":"public"}}`)
+	if _, err := d.Dispatch(context.Background(), "CreateResource", create); err == nil {
+		t.Fatalf("expected a request without a tenant to be rejected")
+	}
+	if _, err := d.Dispatch(WithTenant(context.Background(), "initech"), "CreateResource", create); err == nil {
+		t.Fatalf("expected an unconfigured tenant to be rejected")
+	}
+
+	acme := WithTenant(context.Background(), "acme")
+	globex := WithTenant(context.Background(), "globex")
+	for _, ctx := range []context.Context{acme, globex} {
+		if _, err := d.Dispatch(ctx, "CreateResource", create); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+
+	acmeTenant, _ := registry.Tenant(acme)
+	globexTenant, _ := registry.Tenant(globex)
+	if !acmeTenant.OwnsResource("table/public.users") || !globexTenant.OwnsResource("table/public.users") {
+		t.Fatalf("expected both tenants to own their own public.users, got %v and %v",
+			acmeTenant.Resources(), globexTenant.Resources())
+	}
+
+	remove := []byte(`{"resource_type":"table","resource_id":"public.users","name":"users"}`)
+	if _, err := d.Dispatch(globex, "DeleteResource", remove); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if globexTenant.OwnsResource("table/public.users") || !acmeTenant.OwnsResource("table/public.users") {
+		t.Errorf("expected delete to release only globex's resource")
+	}
+
+	if metrics := acmeTenant.Metrics(); metrics.Requests != 1 || metrics.ByOperation["CreateResource"] != 1 {
+		t.Errorf("unexpected acme metrics: %+v", metrics)
+	}
+	if metrics := globexTenant.Metrics(); metrics.Requests != 2 || metrics.ByOperation["DeleteResource"] != 1 {
+		t.Errorf("unexpected globex metrics: %+v", metrics)
+	}
+}
+
+// TestDispatchDeniesCrossTenantAccess validates a tenant cannot reach resources another
+// tenant created, directly or through RefreshAll and GetOutputs
+func TestDispatchDeniesCrossTenantAccess(t *testing.T) {
+	registry := NewTenantRegistry(nil)
+	for _, id := range []string{"acme", "globex"} {
+		if err := registry.Configure(id, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	d := NewUnifiedDispatcher(&tenantRegistry{resourceID: "public.orders"}, nil)
+	d.SetTenantRegistry(registry)
+
+	acme := WithTenant(context.Background(), "acme")
+	globex := WithTenant(context.Background(), "globex")
+	if _, err := d.Dispatch(acme, "CreateResource", []byte(`{"resource_type":"table","name":"orders","config":{}}`)); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	target := `{"resource_type":"table","resource_id":"public.orders","name":"orders"}`
+	for _, function := range []string{"ReadResource", "UpdateResource", "DeleteResource"} {
+		input := []byte(target)
+		if function == "UpdateResource" {
+			input = []byte(`{"resource_type":"table","resource_id":"public.orders","name":"orders","config":{}}`)
+		}
+		_, err := d.Dispatch(globex, function, input)
+		var secureErr *security.SecureError
+		if !errors.As(err, &secureErr) || secureErr.Code != "TENANT_ACCESS_DENIED" {
+			t.Errorf("%s: expected TENANT_ACCESS_DENIED, got %v", function, err)
+		}
+		if _, err := d.Dispatch(acme, function, input); err != nil && function != "DeleteResource" {
+			t.Errorf("%s: expected the owning tenant to be allowed, got %v", function, err)
+		}
+	}
+
+	// acme deleted its resource above, so claim it again for the bulk reads
+	if _, err := d.Dispatch(acme, "CreateResource", []byte(`{"resource_type":"table","name":"orders","config":{}}`)); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	for _, function := range []string{"RefreshAll", "GetOutputs"} {
+		out, err := d.Dispatch(globex, function, []byte(`{"resources":[`+target+`]}`))
+		if err != nil {
+			t.Fatalf("%s: %v", function, err)
+		}
+		var resp struct {
+			Results []struct {
+				Error string `json:"error"`
+			} `json:"results"`
+		}
+		if err := json.Unmarshal(out, &resp); err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Results) != 1 || resp.Results[0].Error != "tenant access denied" {
+			t.Errorf("%s: expected the target to be denied, got %s", function, out)
+		}
+	}
+}
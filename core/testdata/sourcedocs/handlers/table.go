@@ -0,0 +1,14 @@
+package handlers
+
+// TableHandler manages Acme tables, including columns and indexes.
+//
+// Dropping a table deletes its data.
+type TableHandler struct{}
+
+// NewTableHandler creates a TableHandler
+func NewTableHandler() *TableHandler {
+	return &TableHandler{}
+}
+
+// TopicHandler manages event topics.
+type TopicHandler struct{}
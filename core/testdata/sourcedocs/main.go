@@ -0,0 +1,31 @@
+// Kolumn provider for Acme databases. It manages tables and topics.
+package main
+
+import (
+	"acme/handlers"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+	"github.com/schemabounce/kolumn/sdk/create"
+)
+
+func main() {
+	registry := create.NewRegistry()
+	registry.RegisterHandler("table", handlers.NewTableHandler(), tableSchema())
+	registry.RegisterHandler("topic", &handlers.TopicHandler{}, nil)
+	_ = resourceTypes()
+}
+
+// tableSchema declares the table configuration
+func tableSchema() *core.ObjectType {
+	return &core.ObjectType{Name: "table", Type: core.CREATE}
+}
+
+// resourceTypes declares the view resource type. Views are read-only.
+func resourceTypes() []core.ResourceTypeDefinition {
+	return []core.ResourceTypeDefinition{{Name: "view", Operations: []string{"read"}}}
+}
+
+// Sequences generate unique numbers.
+//
+//kolumn:resource sequence
+type sequenceDocs struct{}
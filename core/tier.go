@@ -0,0 +1,255 @@
+// Package core provides feature gating by governance tier
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/schemabounce/kolumn/sdk/core/auth"
+)
+
+// Governance tiers, lowest to highest
+const (
+	TierFree       = "free"
+	TierPro        = "pro"
+	TierEnterprise = "enterprise"
+
+	// TierDisabled in a tier limitation turns a feature off for every tier
+	TierDisabled = "disabled"
+)
+
+// DiagnosticCodeUpgradeRequired is the diagnostic code for features above the caller's tier
+const DiagnosticCodeUpgradeRequired = "UPGRADE_REQUIRED"
+
+// tierRanks orders tiers; aliases map onto the same rank
+var tierRanks = map[string]int{
+	TierFree:       0,
+	"community":    0,
+	TierPro:        1,
+	"team":         1,
+	TierEnterprise: 2,
+}
+
+// TierRank returns the rank of a tier, or -1 for an unknown tier
+func TierRank(tier string) int {
+	if rank, ok := tierRanks[strings.ToLower(tier)]; ok {
+		return rank
+	}
+	return -1
+}
+
+// UpgradeRequiredError is returned when a function or feature is not available at the
+// caller's tier
+type UpgradeRequiredError struct {
+	Diagnostic   Diagnostic `json:"diagnostic"`
+	Feature      string     `json:"feature"`
+	CurrentTier  string     `json:"current_tier"`
+	RequiredTier string     `json:"required_tier"`
+}
+
+// Error implements the error interface
+func (e *UpgradeRequiredError) Error() string {
+	return e.Diagnostic.Summary + ": " + e.Diagnostic.Detail
+}
+
+// TierGate decides which functions, resource types and features a tier may use.
+// Requirements map a name to the minimum tier (or TierDisabled); names not listed are
+// available to every tier.
+type TierGate struct {
+	requirements map[string]string
+	defaultTier  string
+}
+
+// NewTierGate creates a gate from the provider's own requirements, e.g.
+// {"DiscoverDatabase": "enterprise", "row_level_security": "pro"}
+func NewTierGate(requirements map[string]string) *TierGate {
+	g := &TierGate{requirements: make(map[string]string, len(requirements)), defaultTier: TierFree}
+	for name, tier := range requirements {
+		g.requirements[name] = tier
+	}
+	return g
+}
+
+// SetDefaultTier sets the tier assumed when the caller's tier is unknown
+func (g *TierGate) SetDefaultTier(tier string) {
+	g.defaultTier = tier
+}
+
+// WithLimitations returns a gate that also applies GovernanceContext.TierLimitations.
+// A limitation can raise a requirement but never lower the provider's own.
+func (g *TierGate) WithLimitations(limitations map[string]string) *TierGate {
+	if len(limitations) == 0 {
+		return g
+	}
+	merged := NewTierGate(g.requirements)
+	merged.defaultTier = g.defaultTier
+	for name, tier := range limitations {
+		existing, ok := merged.requirements[name]
+		if !ok || stricterTier(tier, existing) {
+			merged.requirements[name] = tier
+		}
+	}
+	return merged
+}
+
+func stricterTier(a, b string) bool {
+	if strings.EqualFold(b, TierDisabled) {
+		return false
+	}
+	if strings.EqualFold(a, TierDisabled) {
+		return true
+	}
+	return TierRank(a) > TierRank(b)
+}
+
+// Tier returns the caller's tier from the auth claims in ctx, or the default tier
+func (g *TierGate) Tier(ctx context.Context) string {
+	if info, ok := auth.FromAuth(ctx); ok && info.Claims.Tier != "" {
+		return strings.ToLower(info.Claims.Tier)
+	}
+	return g.defaultTier
+}
+
+// Allowed reports whether tier may use feature. Entitlements naming the feature grant
+// it regardless of tier, except for disabled features.
+func (g *TierGate) Allowed(tier, feature string, entitlements ...string) bool {
+	required, gated := g.requirements[feature]
+	if !gated {
+		return true
+	}
+	if strings.EqualFold(required, TierDisabled) {
+		return false
+	}
+	for _, e := range entitlements {
+		if e == feature {
+			return true
+		}
+	}
+	return TierRank(tier) >= TierRank(required)
+}
+
+// Check returns an *UpgradeRequiredError when the caller in ctx may not use feature
+func (g *TierGate) Check(ctx context.Context, feature string) error {
+	if g == nil {
+		return nil
+	}
+	tier := g.Tier(ctx)
+	var entitlements []string
+	if info, ok := auth.FromAuth(ctx); ok {
+		entitlements = info.Claims.Entitlements
+	}
+	if g.Allowed(tier, feature, entitlements...) {
+		return nil
+	}
+
+	required := g.requirements[feature]
+	detail := fmt.Sprintf("%s requires the %s tier; current tier is %s", feature, required, tier)
+	if strings.EqualFold(required, TierDisabled) {
+		detail = fmt.Sprintf("%s is disabled by governance tier limitations", feature)
+	}
+	return &UpgradeRequiredError{
+		Diagnostic: Diagnostic{
+			Severity: "error",
+			Code:     DiagnosticCodeUpgradeRequired,
+			Summary:  "upgrade required",
+			Detail:   detail,
+			Resource: feature,
+		},
+		Feature:      feature,
+		CurrentTier:  tier,
+		RequiredTier: required,
+	}
+}
+
+// FilterFeatures returns the features tier may use, preserving order
+func (g *TierGate) FilterFeatures(tier string, features []string) []string {
+	filtered := make([]string, 0, len(features))
+	for _, f := range features {
+		if g.Allowed(tier, f) {
+			filtered = append(filtered, f)
+		}
+	}
+	return filtered
+}
+
+// FilterSchema returns a copy of schema advertising only the functions and resource
+// types tier may use
+func (g *TierGate) FilterSchema(tier string, schema *Schema) *Schema {
+	filtered := *schema
+	filtered.SupportedFunctions = g.FilterFeatures(tier, schema.SupportedFunctions)
+	filtered.ResourceTypes = make([]ResourceTypeDefinition, 0, len(schema.ResourceTypes))
+	for _, rt := range schema.ResourceTypes {
+		if g.Allowed(tier, rt.Name) {
+			filtered.ResourceTypes = append(filtered.ResourceTypes, rt)
+		}
+	}
+	return &filtered
+}
+
+// governanceCapabilityFeatures names the GovernanceCapabilities flags that can be gated
+var governanceCapabilityFeatures = map[string]func(*GovernanceCapabilities){
+	"encryption":         func(c *GovernanceCapabilities) { c.SupportsEncryption = false; c.EncryptionMethods = nil },
+	"access_controls":    func(c *GovernanceCapabilities) { c.SupportsAccessControls = false },
+	"audit_logging":      func(c *GovernanceCapabilities) { c.SupportsAuditLogging = false },
+	"data_masking":       func(c *GovernanceCapabilities) { c.SupportsDataMasking = false },
+	"row_level_security": func(c *GovernanceCapabilities) { c.SupportsRowLevelSecurity = false },
+}
+
+// FilterGovernanceCapabilities returns a copy of caps with the capabilities tier may
+// not use switched off. Gate keys are encryption, access_controls, audit_logging,
+// data_masking, row_level_security and custom feature names.
+func (g *TierGate) FilterGovernanceCapabilities(tier string, caps *GovernanceCapabilities) *GovernanceCapabilities {
+	filtered := *caps
+	for feature, disable := range governanceCapabilityFeatures {
+		if !g.Allowed(tier, feature) {
+			disable(&filtered)
+		}
+	}
+	filtered.CustomGovernanceFeatures = g.FilterFeatures(tier, caps.CustomGovernanceFeatures)
+	return &filtered
+}
+
+// SetTierGate enables tier gating of dispatched functions and resource types
+func (d *UnifiedDispatcher) SetTierGate(gate *TierGate) {
+	d.tierGate = gate
+}
+
+// tierRequest holds the fields of a request that tier gating reads
+type tierRequest struct {
+	ResourceType string `json:"resource_type"`
+	Metadata     struct {
+		GovernanceContext struct {
+			TierLimitations map[string]string `json:"tier_limitations"`
+		} `json:"governance_context"`
+	} `json:"metadata"`
+}
+
+// requestTierGate returns the dispatcher's gate with the request's tier_limitations
+// applied, decoding the request into req, or nil when tier gating is disabled
+func (d *UnifiedDispatcher) requestTierGate(input []byte, req *tierRequest) *TierGate {
+	if d.tierGate == nil {
+		return nil
+	}
+	_ = json.Unmarshal(input, req)
+	return d.tierGate.WithLimitations(req.Metadata.GovernanceContext.TierLimitations)
+}
+
+// checkTier gates a dispatched function and its resource type, applying any
+// tier_limitations carried in the request's governance context. Multi-type discovery
+// gates each fanned-out type in discoverMultiple.
+func (d *UnifiedDispatcher) checkTier(ctx context.Context, function string, input []byte) error {
+	if d.tierGate == nil {
+		return nil
+	}
+	var req tierRequest
+	gate := d.requestTierGate(input, &req)
+	if err := gate.Check(ctx, function); err != nil {
+		return err
+	}
+	if req.ResourceType != "" {
+		return gate.Check(ctx, req.ResourceType)
+	}
+	return nil
+}
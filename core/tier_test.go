@@ -0,0 +1,137 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/schemabounce/kolumn/sdk/core/auth"
+)
+
+// TestTierGateCheck validates tier ranks, entitlements and upgrade diagnostics
+func TestTierGateCheck(t *testing.T) {
+	gate := NewTierGate(map[string]string{"DiscoverDatabase": TierEnterprise, "masking_policy": TierPro})
+
+	free := context.Background()
+	pro := auth.WithAuth(context.Background(), auth.AuthInfo{Claims: auth.Claims{Tier: "Pro"}})
+	entitled := auth.WithAuth(context.Background(), auth.AuthInfo{Claims: auth.Claims{Tier: "free", Entitlements: []string{"DiscoverDatabase"}}})
+
+	err := gate.Check(free, "masking_policy")
+	var upgrade *UpgradeRequiredError
+	if !errors.As(err, &upgrade) {
+		t.Fatalf("expected upgrade error, got %v", err)
+	}
+	if upgrade.Diagnostic.Code != DiagnosticCodeUpgradeRequired || upgrade.RequiredTier != TierPro || upgrade.CurrentTier != TierFree {
+		t.Errorf("unexpected upgrade error %+v", upgrade)
+	}
+
+	if err := gate.Check(pro, "masking_policy"); err != nil {
+		t.Errorf("expected pro tier to use masking_policy: %v", err)
+	}
+	if err := gate.Check(pro, "DiscoverDatabase"); err == nil {
+		t.Errorf("expected pro tier to be denied an enterprise function")
+	}
+	if err := gate.Check(entitled, "DiscoverDatabase"); err != nil {
+		t.Errorf("expected entitlement to grant the function: %v", err)
+	}
+	if err := gate.Check(free, "CreateResource"); err != nil {
+		t.Errorf("expected ungated functions to be allowed: %v", err)
+	}
+}
+
+// TestTierGateLimitations validates that governance limitations only tighten gating
+func TestTierGateLimitations(t *testing.T) {
+	gate := NewTierGate(map[string]string{"DiscoverDatabase": TierEnterprise}).WithLimitations(map[string]string{
+		"DiscoverDatabase": TierPro,
+		"view":             TierDisabled,
+	})
+
+	if gate.Allowed(TierPro, "DiscoverDatabase") {
+		t.Errorf("expected limitation not to lower the provider's requirement")
+	}
+	if gate.Allowed(TierEnterprise, "view", "view") {
+		t.Errorf("expected disabled features to ignore tier and entitlements")
+	}
+
+	schema := &Schema{
+		SupportedFunctions: []string{"CreateResource", "DiscoverDatabase"},
+		ResourceTypes:      []ResourceTypeDefinition{{Name: "table"}, {Name: "view"}},
+	}
+	filtered := gate.FilterSchema(TierFree, schema)
+	if len(filtered.SupportedFunctions) != 1 || len(filtered.ResourceTypes) != 1 || filtered.ResourceTypes[0].Name != "table" {
+		t.Errorf("unexpected filtered schema %+v", filtered)
+	}
+	if len(schema.SupportedFunctions) != 2 {
+		t.Errorf("expected original schema to be unchanged")
+	}
+
+	caps := gate.WithLimitations(map[string]string{"data_masking": TierEnterprise}).FilterGovernanceCapabilities(TierPro, &GovernanceCapabilities{
+		SupportsDataMasking:  true,
+		SupportsEncryption:   true,
+		SupportsAuditLogging: true,
+	})
+	if caps.SupportsDataMasking || !caps.SupportsEncryption {
+		t.Errorf("unexpected filtered capabilities %+v", caps)
+	}
+}
+
+// TestDispatcherTierGate validates rejection of gated functions and resource types
+func TestDispatcherTierGate(t *testing.T) {
+	d := NewUnifiedDispatcher(readRegistry{}, nil)
+	d.SetTierGate(NewTierGate(map[string]string{"table": TierEnterprise}))
+
+	_, err := d.Dispatch(context.Background(), "ReadResource", []byte(`{"resource_type":"table","name":"users"}`))
+	var upgrade *UpgradeRequiredError
+	if !errors.As(err, &upgrade) || upgrade.Feature != "table" {
+		t.Fatalf("expected upgrade error for table, got %v", err)
+	}
+
+	ctx := auth.WithAuth(context.Background(), auth.AuthInfo{Claims: auth.Claims{Tier: "enterprise"}})
+	if _, err := d.Dispatch(ctx, "ReadResource", []byte(`{"resource_type":"table","name":"users"}`)); err != nil {
+		t.Errorf("expected enterprise tier to read tables: %v", err)
+	}
+
+	limited := []byte(`{"resource_type":"table","name":"users","metadata":{"governance_context":{"tier_limitations":{"ReadResource":"disabled"}}}}`)
+	if _, err := d.Dispatch(ctx, "ReadResource", limited); err == nil {
+		t.Errorf("expected request tier limitations to disable ReadResource")
+	}
+}
+
+// TestDispatcherTierGateMultiTypeDiscovery validates gated types are skipped when discovery
+// fans out to several types or a wildcard
+func TestDispatcherTierGateMultiTypeDiscovery(t *testing.T) {
+	d := NewUnifiedDispatcher(nil, newFakeDiscoverRegistry())
+	d.SetTierGate(NewTierGate(map[string]string{"view": TierEnterprise}))
+
+	for _, input := range []string{`{"object_types":["*"]}`, `{"object_types":["table","view"]}`} {
+		output, err := d.Dispatch(context.Background(), "DiscoverResources", []byte(input))
+		if err != nil {
+			t.Fatalf("%s: %v", input, err)
+		}
+		var resp AggregatedDiscoverResponse
+		if err := json.Unmarshal(output, &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Summary.ObjectTypes["view"] != 0 || resp.Summary.ObjectTypes["table"] != 2 {
+			t.Errorf("%s: expected views to be withheld, got %v", input, resp.Summary.ObjectTypes)
+		}
+		if summary := resp.TypeSummaries["view"]; summary == nil || !strings.Contains(summary.Error, "requires the enterprise tier") {
+			t.Errorf("%s: expected the gated type to be reported, got %+v", input, summary)
+		}
+	}
+
+	ctx := auth.WithAuth(context.Background(), auth.AuthInfo{Claims: auth.Claims{Tier: "enterprise"}})
+	output, err := d.Dispatch(ctx, "DiscoverResources", []byte(`{"object_types":["*"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp AggregatedDiscoverResponse
+	if err := json.Unmarshal(output, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Summary.ObjectTypes["view"] != 2 {
+		t.Errorf("expected enterprise tier to discover views, got %v", resp.Summary.ObjectTypes)
+	}
+}
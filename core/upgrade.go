@@ -0,0 +1,243 @@
+// Package core provides the provider upgrade assistant
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultRegistryURL is the registry host serving provider documentation payloads
+const DefaultRegistryURL = "https://schemabounce.com"
+
+// ResourceUsage summarizes how a resource type is used in the current state
+type ResourceUsage struct {
+	ResourceType string   `json:"resource_type"`
+	Instances    []string `json:"instances"`               // resource names or IDs
+	ConfigKeys   []string `json:"config_keys,omitempty"`   // union of keys present in config/state
+	StateVersion int      `json:"state_version,omitempty"` // state schema version recorded in state
+	Provider     string   `json:"provider,omitempty"`
+}
+
+// UpgradeReport describes what changes when moving to the latest provider release
+type UpgradeReport struct {
+	Provider         string                  `json:"provider"`
+	CurrentVersion   string                  `json:"current_version"`
+	LatestVersion    string                  `json:"latest_version"`
+	UpgradeAvailable bool                    `json:"upgrade_available"`
+	Deprecated       []DeprecatedResourceUse `json:"deprecated,omitempty"`
+	Removed          []RemovedResourceUse    `json:"removed,omitempty"`
+	ConfigRenames    []RequiredConfigRename  `json:"config_renames,omitempty"`
+	StateMigrations  []PendingStateMigration `json:"state_migrations,omitempty"`
+	BreakingChanges  []*BreakingChange       `json:"breaking_changes,omitempty"`
+	NewResources     []string                `json:"new_resources,omitempty"`
+}
+
+// DeprecatedResourceUse is a deprecated resource type that is still in use
+type DeprecatedResourceUse struct {
+	ResourceType string           `json:"resource_type"`
+	Instances    []string         `json:"instances"`
+	Deprecation  *DeprecationInfo `json:"deprecation"`
+}
+
+// RemovedResourceUse is a resource type in use that the latest release no longer ships
+type RemovedResourceUse struct {
+	ResourceType string   `json:"resource_type"`
+	Instances    []string `json:"instances"`
+}
+
+// RequiredConfigRename is a renamed configuration key that the user's config still uses
+type RequiredConfigRename struct {
+	ResourceType string   `json:"resource_type"`
+	From         string   `json:"from"`
+	To           string   `json:"to"`
+	Since        string   `json:"since,omitempty"`
+	Instances    []string `json:"instances"`
+}
+
+// PendingStateMigration lists the state migrations that will run for a resource type
+type PendingStateMigration struct {
+	ResourceType string           `json:"resource_type"`
+	FromVersion  int              `json:"from_version"`
+	ToVersion    int              `json:"to_version"`
+	Steps        []StateMigration `json:"steps"`
+	Instances    []string         `json:"instances"`
+}
+
+// HasActions reports whether the user must act before or during the upgrade
+func (r *UpgradeReport) HasActions() bool {
+	return len(r.Deprecated) > 0 || len(r.Removed) > 0 || len(r.ConfigRenames) > 0 || len(r.BreakingChanges) > 0
+}
+
+// FetchRegistryDocumentation downloads the documentation payload for a provider version
+// ("latest" is accepted by the registry) from the documentation API
+func FetchRegistryDocumentation(ctx context.Context, client *http.Client, registryURL, namespace, name, version string) (*UniversalProviderDocumentation, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if registryURL == "" {
+		registryURL = DefaultRegistryURL
+	}
+	url := strings.TrimSuffix(registryURL, "/") + CanonicalProviderDocsAPIPath(namespace, name, version)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch registry documentation: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("registry returned %s for %s: %s", resp.Status, url, strings.TrimSpace(string(body)))
+	}
+
+	var docs UniversalProviderDocumentation
+	if err := json.NewDecoder(resp.Body).Decode(&docs); err != nil {
+		return nil, fmt.Errorf("failed to decode registry documentation: %w", err)
+	}
+	return &docs, nil
+}
+
+// BuildUpgradeReport compares the running provider's schema and current state usage
+// with the latest registry documentation
+func BuildUpgradeReport(current *Schema, latest *UniversalProviderDocumentation, usage []ResourceUsage) *UpgradeReport {
+	report := &UpgradeReport{
+		Provider:       latest.Provider.Name,
+		CurrentVersion: current.Version,
+		LatestVersion:  latest.Provider.Version,
+	}
+	if report.Provider == "" {
+		report.Provider = current.Name
+	}
+	report.UpgradeAvailable = CompareVersions(report.LatestVersion, report.CurrentVersion) > 0
+
+	currentTypes := make(map[string]bool)
+	for _, rt := range current.ResourceTypes {
+		currentTypes[rt.Name] = true
+	}
+	for name := range current.CreateObjects {
+		currentTypes[name] = true
+	}
+	for name := range current.DiscoverObjects {
+		currentTypes[name] = true
+	}
+
+	sorted := append([]ResourceUsage(nil), usage...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ResourceType < sorted[j].ResourceType })
+
+	for _, use := range sorted {
+		// a null document is as good as none, so the type counts as removed
+		doc := latest.Resources[use.ResourceType]
+		if doc == nil {
+			report.Removed = append(report.Removed, RemovedResourceUse{ResourceType: use.ResourceType, Instances: use.Instances})
+			continue
+		}
+
+		if doc.Deprecation != nil {
+			report.Deprecated = append(report.Deprecated, DeprecatedResourceUse{
+				ResourceType: use.ResourceType,
+				Instances:    use.Instances,
+				Deprecation:  doc.Deprecation,
+			})
+		}
+
+		keys := make(map[string]bool, len(use.ConfigKeys))
+		for _, k := range use.ConfigKeys {
+			keys[k] = true
+		}
+		for _, rename := range doc.ConfigRenames {
+			if keys[rename.From] {
+				report.ConfigRenames = append(report.ConfigRenames, RequiredConfigRename{
+					ResourceType: use.ResourceType,
+					From:         rename.From,
+					To:           rename.To,
+					Since:        rename.Since,
+					Instances:    use.Instances,
+				})
+			}
+		}
+
+		if doc.StateVersion > use.StateVersion {
+			pending := PendingStateMigration{
+				ResourceType: use.ResourceType,
+				FromVersion:  use.StateVersion,
+				ToVersion:    doc.StateVersion,
+				Instances:    use.Instances,
+			}
+			for _, step := range doc.StateMigrations {
+				if step.FromVersion >= use.StateVersion && step.ToVersion <= doc.StateVersion {
+					pending.Steps = append(pending.Steps, step)
+				}
+			}
+			sort.Slice(pending.Steps, func(i, j int) bool { return pending.Steps[i].FromVersion < pending.Steps[j].FromVersion })
+			report.StateMigrations = append(report.StateMigrations, pending)
+		}
+	}
+
+	for name, doc := range latest.Resources {
+		if doc != nil && !currentTypes[name] {
+			report.NewResources = append(report.NewResources, name)
+		}
+	}
+	sort.Strings(report.NewResources)
+
+	if latest.Compatibility != nil {
+		for _, change := range latest.Compatibility.BreakingChanges {
+			if change != nil && CompareVersions(change.Version, report.CurrentVersion) > 0 &&
+				CompareVersions(change.Version, report.LatestVersion) <= 0 {
+				report.BreakingChanges = append(report.BreakingChanges, change)
+			}
+		}
+	}
+
+	return report
+}
+
+// CompareVersions compares two dotted versions (a leading "v" and pre-release
+// suffixes are ignored) and returns -1, 0 or 1
+func CompareVersions(a, b string) int {
+	return compareVersionParts(versionParts(a), versionParts(b))
+}
+
+func versionParts(v string) []int {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	var parts []int
+	for _, p := range strings.Split(v, ".") {
+		n, _ := strconv.Atoi(p)
+		parts = append(parts, n)
+	}
+	return parts
+}
+
+func compareVersionParts(a, b []int) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
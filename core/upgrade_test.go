@@ -0,0 +1,131 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestBuildUpgradeReport validates deprecations, renames, migrations and removals are reported
+func TestBuildUpgradeReport(t *testing.T) {
+	current := &Schema{
+		Name:    "postgres",
+		Version: "1.2.0",
+		ResourceTypes: []ResourceTypeDefinition{
+			{Name: "postgres_table"}, {Name: "postgres_legacy_view"}, {Name: "postgres_gone"},
+		},
+	}
+	latest := &UniversalProviderDocumentation{
+		Provider: ProviderMetadata{Name: "postgres", Version: "2.0.0"},
+		Resources: map[string]*ResourceDoc{
+			"postgres_table": {
+				ConfigRenames: []ConfigRename{{From: "tablespace_name", To: "tablespace", Since: "2.0.0"}},
+				StateVersion:  3,
+				StateMigrations: []StateMigration{
+					{FromVersion: 2, ToVersion: 3, Description: "split column defaults"},
+					{FromVersion: 1, ToVersion: 2, Description: "normalize constraint names"},
+				},
+			},
+			"postgres_legacy_view": {Deprecation: &DeprecationInfo{RemovalDate: "2026-01-01"}},
+			"postgres_policy":      {},
+		},
+		Compatibility: &CompatibilityInfo{BreakingChanges: []*BreakingChange{
+			{Version: "1.0.0", Description: "old"},
+			{Version: "2.0.0", Description: "drop implicit cascade"},
+		}},
+	}
+	usage := []ResourceUsage{
+		{ResourceType: "postgres_table", Instances: []string{"users"}, ConfigKeys: []string{"name", "tablespace_name"}, StateVersion: 1},
+		{ResourceType: "postgres_legacy_view", Instances: []string{"report"}},
+		{ResourceType: "postgres_gone", Instances: []string{"x"}},
+	}
+
+	report := BuildUpgradeReport(current, latest, usage)
+
+	if !report.UpgradeAvailable || !report.HasActions() {
+		t.Fatalf("Expected an upgrade with actions, got %+v", report)
+	}
+	if len(report.Deprecated) != 1 || report.Deprecated[0].ResourceType != "postgres_legacy_view" {
+		t.Errorf("Unexpected deprecations: %+v", report.Deprecated)
+	}
+	if len(report.Removed) != 1 || report.Removed[0].ResourceType != "postgres_gone" {
+		t.Errorf("Unexpected removals: %+v", report.Removed)
+	}
+	if len(report.ConfigRenames) != 1 || report.ConfigRenames[0].To != "tablespace" {
+		t.Errorf("Unexpected renames: %+v", report.ConfigRenames)
+	}
+	if len(report.StateMigrations) != 1 || len(report.StateMigrations[0].Steps) != 2 ||
+		report.StateMigrations[0].Steps[0].FromVersion != 1 {
+		t.Errorf("Unexpected state migrations: %+v", report.StateMigrations)
+	}
+	if len(report.BreakingChanges) != 1 || report.BreakingChanges[0].Version != "2.0.0" {
+		t.Errorf("Unexpected breaking changes: %+v", report.BreakingChanges)
+	}
+	if len(report.NewResources) != 1 || report.NewResources[0] != "postgres_policy" {
+		t.Errorf("Unexpected new resources: %+v", report.NewResources)
+	}
+}
+
+// TestBuildUpgradeReportNullDocs validates null documents from the registry are
+// treated as missing rather than dereferenced
+func TestBuildUpgradeReportNullDocs(t *testing.T) {
+	var latest UniversalProviderDocumentation
+	doc := `{"provider":{"name":"postgres","version":"2.0.0"},"resources":{"x":null,"postgres_table":{}},` +
+		`"compatibility":{"breaking_changes":[null,{"version":"2.0.0"}]}}`
+	if err := json.Unmarshal([]byte(doc), &latest); err != nil {
+		t.Fatal(err)
+	}
+	current := &Schema{Name: "postgres", Version: "1.0.0"}
+	usage := []ResourceUsage{{ResourceType: "x", Instances: []string{"a", "b"}}, {ResourceType: "postgres_table", Instances: []string{"users"}}}
+
+	report := BuildUpgradeReport(current, &latest, usage)
+	if len(report.Removed) != 1 || report.Removed[0].ResourceType != "x" {
+		t.Errorf("Expected x to be reported removed, got %+v", report.Removed)
+	}
+	if len(report.NewResources) != 1 || report.NewResources[0] != "postgres_table" {
+		t.Errorf("Unexpected new resources: %+v", report.NewResources)
+	}
+	if len(report.BreakingChanges) != 1 {
+		t.Errorf("Unexpected breaking changes: %+v", report.BreakingChanges)
+	}
+}
+
+// TestFetchRegistryDocumentation validates the documentation API path is used
+func TestFetchRegistryDocumentation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != CanonicalProviderDocsAPIPath("kolumn-official", "postgres", "latest") {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(UniversalProviderDocumentation{Provider: ProviderMetadata{Version: "2.0.0"}})
+	}))
+	defer server.Close()
+
+	docs, err := FetchRegistryDocumentation(context.Background(), server.Client(), server.URL, "kolumn-official", "postgres", "latest")
+	if err != nil || docs.Provider.Version != "2.0.0" {
+		t.Fatalf("Unexpected result: %+v %v", docs, err)
+	}
+
+	if _, err := FetchRegistryDocumentation(context.Background(), server.Client(), server.URL, "x", "y", "z"); err == nil {
+		t.Errorf("Expected error for missing provider")
+	}
+}
+
+// TestCompareVersions validates dotted version ordering
+func TestCompareVersions(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"v1.10.0", "1.9.3", 1},
+		{"1.0", "1.0.0", 0},
+		{"2.0.0-rc1", "2.0.1", -1},
+	}
+	for _, c := range cases {
+		if got := CompareVersions(c.a, c.b); got != c.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+}
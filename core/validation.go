@@ -12,17 +12,18 @@ import (
 type ConfigValidationRule struct {
 	Field       string                  `json:"field"`
 	Required    bool                    `json:"required"`
-	Type        string                  `json:"type"`        // "string", "int", "bool", "float", "slice", "map"
-	Pattern     string                  `json:"pattern"`     // Regex pattern for strings
-	Min         interface{}             `json:"min"`         // Minimum value (for numbers) or length (for strings/slices)
-	Max         interface{}             `json:"max"`         // Maximum value (for numbers) or length (for strings/slices)
-	Enum        []string                `json:"enum"`        // Valid enum values
-	Default     interface{}             `json:"default"`     // Default value if not provided
-	Custom      func(interface{}) error `json:"-"`           // Custom validation function
-	ErrorMsg    string                  `json:"error_msg"`   // Custom error message
-	Suggestion  string                  `json:"suggestion"`  // Suggestion for fixing the error
-	Example     string                  `json:"example"`     // Example of correct value
-	Description string                  `json:"description"` // Field description
+	Type        string                  `json:"type"`                // "string", "int", "bool", "float", "slice", "map"
+	Pattern     string                  `json:"pattern"`             // Regex pattern for strings
+	Min         interface{}             `json:"min"`                 // Minimum value (for numbers) or length (for strings/slices)
+	Max         interface{}             `json:"max"`                 // Maximum value (for numbers) or length (for strings/slices)
+	Enum        []string                `json:"enum"`                // Valid enum values
+	Default     interface{}             `json:"default"`             // Default value if not provided
+	Custom      func(interface{}) error `json:"-"`                   // Custom validation function
+	ErrorMsg    string                  `json:"error_msg"`           // Custom error message
+	Suggestion  string                  `json:"suggestion"`          // Suggestion for fixing the error
+	Example     string                  `json:"example"`             // Example of correct value
+	Description string                  `json:"description"`         // Field description
+	DocLinks    []string                `json:"doc_links,omitempty"` // Documentation for the field
 }
 
 // FieldError represents a validation error for a specific field
@@ -32,10 +33,41 @@ type FieldError struct {
 	Error      string      `json:"error"`
 	Suggestion string      `json:"suggestion"`
 	Example    string      `json:"example"`
-	Line       int         `json:"line,omitempty"`   // Line number in source file
-	Column     int         `json:"column,omitempty"` // Column number in source file
-	Severity   string      `json:"severity"`         // "error", "warning", "info"
-	Code       string      `json:"code"`             // Error code for programmatic handling
+	Line       int         `json:"line,omitempty"`         // Line number in source file
+	Column     int         `json:"column,omitempty"`       // Column number in source file
+	Severity   string      `json:"severity"`               // "error", "warning", "info"
+	Code       string      `json:"code"`                   // Error code for programmatic handling
+	DidYouMean string      `json:"did_you_mean,omitempty"` // Closest valid value
+	Allowed    []string    `json:"allowed,omitempty"`      // Valid values for enum errors
+	DocLink    string      `json:"doc_link,omitempty"`     // Documentation for the field
+}
+
+// Diagnostic converts the field error to a structured diagnostic. The detail carries
+// the did-you-mean suggestion, the allowed values and the documentation link.
+func (e FieldError) Diagnostic() Diagnostic {
+	var detail []string
+	if e.DidYouMean != "" {
+		detail = append(detail, fmt.Sprintf("Did you mean %q?", e.DidYouMean))
+	}
+	if len(e.Allowed) > 0 {
+		detail = append(detail, "Allowed values: "+strings.Join(e.Allowed, ", "))
+	} else if e.Suggestion != "" {
+		detail = append(detail, e.Suggestion)
+	}
+	if e.DocLink != "" {
+		detail = append(detail, "See "+e.DocLink)
+	}
+	severity := e.Severity
+	if severity == "" {
+		severity = "error"
+	}
+	return Diagnostic{
+		Severity: severity,
+		Code:     e.Code,
+		Summary:  e.Error,
+		Detail:   strings.Join(detail, " "),
+		Resource: e.Field,
+	}
 }
 
 // ConfigValidationResult contains the results of validating a configuration
@@ -52,6 +84,18 @@ type Validator struct {
 	providerName string
 }
 
+// Diagnostics returns the errors and warnings as structured diagnostics
+func (r *ConfigValidationResult) Diagnostics() []Diagnostic {
+	diagnostics := make([]Diagnostic, 0, len(r.Errors)+len(r.Warnings))
+	for _, e := range r.Errors {
+		diagnostics = append(diagnostics, e.Diagnostic())
+	}
+	for _, w := range r.Warnings {
+		diagnostics = append(diagnostics, w.Diagnostic())
+	}
+	return diagnostics
+}
+
 // NewValidator creates a new validator for a provider
 func NewValidator(providerName string) *Validator {
 	return &Validator{
@@ -167,7 +211,7 @@ func (v *Validator) validateField(rule ConfigValidationRule, config map[string]i
 		if str, ok := value.(string); ok {
 			matched, err := regexp.MatchString(rule.Pattern, str)
 			if err != nil || !matched {
-				return &FieldError{
+				fieldErr := &FieldError{
 					Field:      rule.Field,
 					Value:      value,
 					Error:      fmt.Sprintf("Field '%s' does not match required pattern: %s", rule.Field, rule.Pattern),
@@ -175,7 +219,15 @@ func (v *Validator) validateField(rule ConfigValidationRule, config map[string]i
 					Example:    rule.Example,
 					Severity:   "error",
 					Code:       "PATTERN_MISMATCH",
+					DocLink:    firstDocLink(rule),
 				}
+				if candidate, ok := SuggestPatternMatch(str, rule.Pattern); ok {
+					fieldErr.DidYouMean = candidate
+					if fieldErr.Suggestion == "" {
+						fieldErr.Suggestion = fmt.Sprintf("Did you mean %q?", candidate)
+					}
+				}
+				return fieldErr
 			}
 		}
 	}
@@ -196,7 +248,7 @@ func (v *Validator) validateField(rule ConfigValidationRule, config map[string]i
 	// Enum validation
 	if len(rule.Enum) > 0 {
 		if err := v.validateEnum(rule, value); err != nil {
-			return &FieldError{
+			fieldErr := &FieldError{
 				Field:      rule.Field,
 				Value:      value,
 				Error:      err.Error(),
@@ -204,7 +256,14 @@ func (v *Validator) validateField(rule ConfigValidationRule, config map[string]i
 				Example:    rule.Example,
 				Severity:   "error",
 				Code:       "INVALID_ENUM_VALUE",
+				Allowed:    append([]string(nil), rule.Enum...),
+				DocLink:    firstDocLink(rule),
+			}
+			if match, ok := ClosestMatch(fmt.Sprintf("%v", value), rule.Enum); ok {
+				fieldErr.DidYouMean = match
+				fieldErr.Suggestion = fmt.Sprintf("Did you mean %q? %s", match, fieldErr.Suggestion)
 			}
+			return fieldErr
 		}
 	}
 
@@ -230,6 +289,13 @@ func (v *Validator) validateField(rule ConfigValidationRule, config map[string]i
 	return nil
 }
 
+func firstDocLink(rule ConfigValidationRule) string {
+	if len(rule.DocLinks) > 0 {
+		return rule.DocLinks[0]
+	}
+	return ""
+}
+
 // validateType validates the type of a field value
 func (v *Validator) validateType(rule ConfigValidationRule, value interface{}) error {
 	valueType := reflect.TypeOf(value)
@@ -461,6 +527,12 @@ func (b *ValidationRuleBuilder) Default(value interface{}) *ValidationRuleBuilde
 	return b
 }
 
+// DocLink adds a documentation link shown with validation errors
+func (b *ValidationRuleBuilder) DocLink(url string) *ValidationRuleBuilder {
+	b.rule.DocLinks = append(b.rule.DocLinks, url)
+	return b
+}
+
 // Custom adds a custom validation function
 func (b *ValidationRuleBuilder) Custom(fn func(interface{}) error) *ValidationRuleBuilder {
 	b.rule.Custom = fn
@@ -0,0 +1,113 @@
+// Package core provides structured warnings shared by operation responses
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Common warning codes
+const (
+	WarningCodeGeneric          = "WARNING"
+	WarningCodeDeprecated       = "DEPRECATED"
+	WarningCodeDefaultApplied   = "DEFAULT_APPLIED"
+	WarningCodeDataLoss         = "POTENTIAL_DATA_LOSS"
+	WarningCodeAttributeIgnored = "ATTRIBUTE_IGNORED"
+	WarningCodeDriftDetected    = "DRIFT_DETECTED"
+)
+
+// Warning is a non-fatal condition reported with an operation result. Core renders
+// warnings separately from errors, pointing at Path and linking DocLink.
+type Warning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Path    string `json:"path,omitempty"`     // attribute path, e.g. columns[2].type
+	DocLink string `json:"doc_link,omitempty"` // documentation explaining the warning
+}
+
+// NewWarning creates a warning with a formatted message
+func NewWarning(code, format string, args ...interface{}) Warning {
+	return Warning{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// AtPath returns a copy of the warning pointing at an attribute path
+func (w Warning) AtPath(path string) Warning {
+	w.Path = path
+	return w
+}
+
+// WithDocLink returns a copy of the warning linking to documentation
+func (w Warning) WithDocLink(link string) Warning {
+	w.DocLink = link
+	return w
+}
+
+// String renders the warning on one line
+func (w Warning) String() string {
+	var b strings.Builder
+	if w.Path != "" {
+		b.WriteString(w.Path + ": ")
+	}
+	b.WriteString(w.Message)
+	if w.DocLink != "" {
+		b.WriteString(" (see " + w.DocLink + ")")
+	}
+	return b.String()
+}
+
+// Diagnostic converts the warning to a warning-severity diagnostic
+func (w Warning) Diagnostic() Diagnostic {
+	d := Diagnostic{Severity: "warning", Code: w.Code, Summary: w.Message, Resource: w.Path}
+	if w.DocLink != "" {
+		d.Detail = "See " + w.DocLink
+	}
+	return d
+}
+
+// UnmarshalJSON accepts both the structured form and the plain strings older
+// providers send
+func (w *Warning) UnmarshalJSON(data []byte) error {
+	var message string
+	if err := json.Unmarshal(data, &message); err == nil {
+		*w = Warning{Code: WarningCodeGeneric, Message: message}
+		return nil
+	}
+	type plain Warning
+	var p plain
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	*w = Warning(p)
+	return nil
+}
+
+// WarningsFromStrings converts plain warning messages to generic warnings
+func WarningsFromStrings(messages []string) []Warning {
+	if len(messages) == 0 {
+		return nil
+	}
+	warnings := make([]Warning, len(messages))
+	for i, m := range messages {
+		warnings[i] = Warning{Code: WarningCodeGeneric, Message: m}
+	}
+	return warnings
+}
+
+// WarningMessages renders warnings as strings, e.g. for log output
+func WarningMessages(warnings []Warning) []string {
+	messages := make([]string, len(warnings))
+	for i, w := range warnings {
+		messages[i] = w.String()
+	}
+	return messages
+}
+
+// WarningDiagnostics converts warnings to diagnostics
+func WarningDiagnostics(warnings []Warning) []Diagnostic {
+	diagnostics := make([]Diagnostic, len(warnings))
+	for i, w := range warnings {
+		diagnostics[i] = w.Diagnostic()
+	}
+	return diagnostics
+}
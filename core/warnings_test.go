@@ -0,0 +1,52 @@
+package core
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestWarningJSON validates structured warnings round-trip and legacy strings still decode
+func TestWarningJSON(t *testing.T) {
+	resp := CreateResponse{
+		Success: true,
+		Warnings: []Warning{
+			NewWarning(WarningCodeDeprecated, "%s is deprecated", "tablespace").
+				AtPath("tablespace").WithDocLink("https://docs.example.com/tablespaces"),
+		},
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var decoded CreateResponse
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(decoded.Warnings) != 1 || decoded.Warnings[0] != resp.Warnings[0] {
+		t.Errorf("round trip mismatch: %+v", decoded.Warnings)
+	}
+
+	var legacy DeleteResponse
+	if err := json.Unmarshal([]byte(`{"success":true,"warnings":["backup skipped"]}`), &legacy); err != nil {
+		t.Fatalf("unmarshal legacy: %v", err)
+	}
+	if len(legacy.Warnings) != 1 || legacy.Warnings[0].Code != WarningCodeGeneric || legacy.Warnings[0].Message != "backup skipped" {
+		t.Errorf("legacy warning not decoded: %+v", legacy.Warnings)
+	}
+}
+
+// TestWarningRendering validates string and diagnostic forms
+func TestWarningRendering(t *testing.T) {
+	w := NewWarning(WarningCodeDataLoss, "dropping column %s", "email").AtPath("columns[2]").WithDocLink("https://docs.example.com/drops")
+
+	if got, want := w.String(), "columns[2]: dropping column email (see https://docs.example.com/drops)"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	d := w.Diagnostic()
+	if d.Severity != "warning" || d.Code != WarningCodeDataLoss || d.Resource != "columns[2]" {
+		t.Errorf("unexpected diagnostic: %+v", d)
+	}
+	if msgs := WarningMessages(WarningsFromStrings([]string{"a", "b"})); len(msgs) != 2 || msgs[1] != "b" {
+		t.Errorf("unexpected messages: %v", msgs)
+	}
+}
@@ -0,0 +1,12 @@
+package create
+
+import "github.com/schemabounce/kolumn/sdk/types"
+
+// clock supplies the durations recorded by apply strategies
+var clock types.ClockVar
+
+// SetClock replaces the clock used by create, or restores the system clock when nil. It
+// returns a function restoring the previous clock.
+func SetClock(c types.Clock) (restore func()) {
+	return clock.Set(c)
+}
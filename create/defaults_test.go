@@ -0,0 +1,38 @@
+package create
+
+import (
+	"context"
+	"testing"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+)
+
+// TestPlanRecordsDefaultProvenance validates resource defaults win over schema defaults and are traced in the plan
+func TestPlanRecordsDefaultProvenance(t *testing.T) {
+	defaults, err := core.ParseResourceDefaults([]byte("topic:\n  replication_factor: 3\n"), "defaults.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := NewAdvancedHandler("topic")
+	handler.schema.Properties["replication_factor"] = &core.Property{Type: "integer", Default: 1}
+	handler.schema.Properties["retention_ms"] = &core.Property{Type: "integer", Default: 604800000}
+	handler.AddPlanner(NewDefaultPlanner("topic"))
+	handler.SetResourceDefaults(defaults)
+
+	resp, err := handler.Plan(context.Background(), &PlanRequest{ObjectType: "topic", Name: "orders", DesiredConfig: map[string]interface{}{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.PlannedState["replication_factor"] != float64(3) || resp.PlannedState["retention_ms"] != 604800000 {
+		t.Errorf("planned state = %v", resp.PlannedState)
+	}
+	sources := make(map[string]string)
+	for _, change := range resp.Changes {
+		if change.FromDefault {
+			sources[change.Property] = change.DefaultSource
+		}
+	}
+	if sources["replication_factor"] != "defaults.yaml (topic)" || sources["retention_ms"] != core.DefaultSourceSchema {
+		t.Errorf("default sources = %v", sources)
+	}
+}
@@ -85,6 +85,7 @@ type ImportResponse struct {
 	State        map[string]interface{} `json:"state"`
 	Config       map[string]interface{} `json:"config"`
 	Dependencies []string               `json:"dependencies,omitempty"`
+	Warnings     []core.Warning         `json:"warnings,omitempty"`
 }
 
 // GetStateRequest specifies which object state to retrieve
@@ -121,8 +122,9 @@ type ValidationIssue struct {
 
 // Registry manages CREATE object handlers
 type Registry struct {
-	handlers map[string]ObjectHandler
-	schemas  map[string]*core.ObjectType
+	handlers   map[string]ObjectHandler
+	schemas    map[string]*core.ObjectType
+	generation uint64
 }
 
 // NewRegistry creates a new CREATE object registry
@@ -141,9 +143,16 @@ func (r *Registry) RegisterHandler(objectType string, handler ObjectHandler, sch
 
 	r.handlers[objectType] = handler
 	r.schemas[objectType] = schema
+	r.generation++
 	return nil
 }
 
+// Generation changes whenever a handler is registered, so cached schemas built from
+// the registry know when to rebuild (see core.SchemaGenerationer)
+func (r *Registry) Generation() uint64 {
+	return r.generation
+}
+
 // GetHandler returns the handler for an object type
 func (r *Registry) GetHandler(objectType string) (ObjectHandler, bool) {
 	handler, exists := r.handlers[objectType]
@@ -165,6 +174,33 @@ func (r *Registry) GetObjectTypes() map[string]*core.ObjectType {
 	return result
 }
 
+// Operations lists the operations the handler of an object type implements, for the
+// contract linter (see core.LintContract). Optional operations are detected from the
+// interfaces the handler satisfies.
+func (r *Registry) Operations(objectType string) []string {
+	handler, exists := r.handlers[objectType]
+	if !exists {
+		return nil
+	}
+	operations := []string{"create", "read", "update", "delete", "plan"}
+	if _, ok := handler.(interface {
+		Validate(ctx context.Context, req *ValidateRequest) (*ValidateResponse, error)
+	}); ok {
+		operations = append(operations, "validate")
+	}
+	if _, ok := handler.(interface {
+		Import(ctx context.Context, req *ImportRequest) (*ImportResponse, error)
+	}); ok {
+		operations = append(operations, "import")
+	}
+	if _, ok := handler.(interface {
+		DetectDrift(ctx context.Context, req *core.DriftRequest) (*core.DriftResponse, error)
+	}); ok {
+		operations = append(operations, "drift")
+	}
+	return operations
+}
+
 // CallHandler executes a handler method by name with comprehensive security validation
 func (r *Registry) CallHandler(ctx context.Context, objectType, method string, input []byte) ([]byte, error) {
 	// SECURITY: Validate object type to prevent injection
@@ -277,7 +313,7 @@ func (r *Registry) CallHandler(ctx context.Context, objectType, method string, i
 			return nil, secErr
 		}
 
-		resp, err := handler.Update(ctx, &req)
+		resp, err := applyUpdate(ctx, objectType, handler, &req)
 		if err != nil {
 			secErr := security.NewSecureError(
 				"operation failed",
@@ -341,8 +377,37 @@ func (r *Registry) CallHandler(ctx context.Context, objectType, method string, i
 			)
 			return nil, secErr
 		}
+		checkQuotas(ctx, handler, &req, resp)
 		return json.Marshal(resp)
 
+	case "poll_operation":
+		poller, ok := handler.(OperationPoller)
+		if !ok {
+			return nil, security.NewSecureError(
+				"operation not supported",
+				fmt.Sprintf("object type %s does not report asynchronous operations", objectType),
+				"OPERATION_POLLING_UNSUPPORTED",
+			)
+		}
+		var req pollOperationRequest
+		if err := security.SafeUnmarshal(input, &req); err != nil {
+			return nil, security.NewSecureError(
+				"invalid request format",
+				fmt.Sprintf("poll operation request unmarshal failed: %v", err),
+				"INVALID_REQUEST",
+			)
+		}
+
+		status, err := poller.PollOperation(ctx, &req.Operation)
+		if err != nil {
+			return nil, security.NewSecureError(
+				"operation failed",
+				fmt.Sprintf("poll operation failed: %v", err),
+				"OPERATION_FAILED",
+			)
+		}
+		return json.Marshal(status)
+
 	default:
 		// This should never be reached due to method validation above
 		secErr := security.NewSecureError(
@@ -366,6 +431,7 @@ type AdvancedHandler struct {
 	planners       []Planner
 	importers      []Importer
 	driftDetectors []DriftDetector
+	defaults       *core.ResourceDefaults
 }
 
 // NewAdvancedHandler creates a new AdvancedHandler for the specified object type
@@ -411,6 +477,12 @@ func (h *AdvancedHandler) AddDriftDetector(detector DriftDetector) {
 	h.driftDetectors = append(h.driftDetectors, detector)
 }
 
+// SetResourceDefaults applies operator-supplied resource defaults in Plan, ahead of the
+// schema's declared defaults
+func (h *AdvancedHandler) SetResourceDefaults(defaults *core.ResourceDefaults) {
+	h.defaults = defaults
+}
+
 // ValidateConfig validates configuration using all registered validators
 func (h *AdvancedHandler) ValidateConfig(config map[string]interface{}) error {
 	for _, validator := range h.validators {
@@ -441,12 +513,20 @@ func (h *AdvancedHandler) Delete(ctx context.Context, req *DeleteRequest) (*Dele
 }
 
 func (h *AdvancedHandler) Plan(ctx context.Context, req *PlanRequest) (*PlanResponse, error) {
+	// Inject resource defaults, then schema defaults, for attributes the config leaves unset
+	config, provenance := req.DesiredConfig, []core.DefaultProvenance(nil)
+	if h.defaults != nil {
+		config, provenance = h.defaults.Apply(h.objectType, config)
+	}
+	desired, injected := core.InjectDefaults(config, core.PropertyDefaults(h.schema.Properties))
+	provenance = append(provenance, core.SchemaDefaultProvenance(injected)...)
+
 	// Use registered planners
 	for _, planner := range h.planners {
 		coreResp, err := planner.Plan(ctx, &core.PlanRequest{
 			ObjectType:    req.ObjectType,
 			Name:          req.Name,
-			DesiredConfig: req.DesiredConfig,
+			DesiredConfig: desired,
 			CurrentState:  req.CurrentState,
 		})
 		if err != nil {
@@ -464,13 +544,18 @@ func (h *AdvancedHandler) Plan(ctx context.Context, req *PlanRequest) (*PlanResp
 				RequiresReplace: change.RequiresReplace,
 				RiskLevel:       change.RiskLevel,
 				Description:     change.Description,
+				FromDefault:     change.FromDefault,
+				DefaultSource:   change.DefaultSource,
 			}
 		}
 
-		return &PlanResponse{
-			Summary: coreResp.Summary,
-			Changes: changes,
-		}, nil
+		resp := &PlanResponse{
+			Summary:      coreResp.Summary,
+			Changes:      changes,
+			PlannedState: desired,
+		}
+		core.RecordDefaultProvenance(resp, provenance)
+		return resp, nil
 	}
 
 	// Default implementation
@@ -480,7 +565,8 @@ func (h *AdvancedHandler) Plan(ctx context.Context, req *PlanRequest) (*PlanResp
 			RiskLevel:       "low",
 			TotalChanges:    0,
 		},
-		Changes: []PlannedChange{},
+		Changes:      []PlannedChange{},
+		PlannedState: desired,
 	}, nil
 }
 
@@ -0,0 +1,20 @@
+package create
+
+import (
+	"context"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+)
+
+// OperationPoller is implemented by handlers whose backend finishes some changes
+// asynchronously. Such handlers return an OperationRef from Create, Update or Delete and
+// report its progress here; Kolumn keeps polling until the status is final.
+type OperationPoller interface {
+	PollOperation(ctx context.Context, ref *core.OperationRef) (*core.OperationStatus, error)
+}
+
+// pollOperationRequest is the registry input of the poll_operation method
+type pollOperationRequest struct {
+	ObjectType string            `json:"object_type"`
+	Operation  core.OperationRef `json:"operation"`
+}
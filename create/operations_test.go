@@ -0,0 +1,49 @@
+package create
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+)
+
+// jobHandler finishes changes through backend jobs that complete on the second poll
+type jobHandler struct {
+	strategyHandler
+	polls int
+}
+
+func (h *jobHandler) PollOperation(ctx context.Context, ref *core.OperationRef) (*core.OperationStatus, error) {
+	h.polls++
+	if h.polls < 2 {
+		return &core.OperationStatus{Operation: *ref, State: core.OperationRunning, Progress: 0.5}, nil
+	}
+	return &core.OperationStatus{Operation: *ref, State: core.OperationSucceeded, ResourceState: map[string]interface{}{"rows": 10}}, nil
+}
+
+// TestPollOperation validates poll_operation reaches handlers implementing OperationPoller
+func TestPollOperation(t *testing.T) {
+	registry := NewRegistry()
+	_ = registry.RegisterHandler("job_table", &jobHandler{}, &core.ObjectType{Name: "job_table", Type: core.CREATE})
+	_ = registry.RegisterHandler("table", &strategyHandler{}, &core.ObjectType{Name: "table", Type: core.CREATE})
+
+	input := []byte(`{"object_type":"job_table","operation":{"id":"job-1","resource_type":"job_table"}}`)
+	for _, want := range []string{core.OperationRunning, core.OperationSucceeded} {
+		out, err := registry.CallHandler(context.Background(), "job_table", "poll_operation", input)
+		if err != nil {
+			t.Fatalf("poll_operation: %v", err)
+		}
+		var status core.OperationStatus
+		if err := json.Unmarshal(out, &status); err != nil {
+			t.Fatal(err)
+		}
+		if status.State != want || status.Operation.ID != "job-1" {
+			t.Errorf("status = %+v, want state %s", status, want)
+		}
+	}
+
+	if _, err := registry.CallHandler(context.Background(), "table", "poll_operation", input); err == nil {
+		t.Error("handlers without OperationPoller should reject poll_operation")
+	}
+}
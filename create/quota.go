@@ -0,0 +1,137 @@
+package create
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+)
+
+// Quota diagnostic codes added to plan responses
+const (
+	QuotaCodeExceeded    = "QUOTA_EXCEEDED"
+	QuotaCodeNearLimit   = "QUOTA_NEAR_LIMIT"
+	QuotaCodeUnavailable = "QUOTA_UNAVAILABLE"
+)
+
+// QuotaWarnRatio is the share of a limit at which a plan warns that the quota is nearly used
+const QuotaWarnRatio = 0.9
+
+// Quota is a backend limit, its current usage and what a planned change would consume
+type Quota struct {
+	Name      string `json:"name"`            // e.g. max_connections, topics_per_cluster
+	Scope     string `json:"scope,omitempty"` // what the limit applies to, e.g. a cluster or account ID
+	Limit     int64  `json:"limit"`           // 0 means unlimited
+	Used      int64  `json:"used"`
+	Requested int64  `json:"requested"` // units the change adds; negative when it frees capacity
+	Unit      string `json:"unit,omitempty"`
+}
+
+// QuotaInspector is implemented by handlers that can report backend usage against limits.
+// Plans of such handlers are flagged when the change would exceed a quota.
+type QuotaInspector interface {
+	// InspectQuotas returns the quotas the planned change draws on
+	InspectQuotas(ctx context.Context, req *PlanRequest) ([]Quota, error)
+}
+
+// QuotaLedger accumulates the capacity requested by every plan of one run, so resources
+// that each fit a quota alone are still flagged when together they exceed it
+type QuotaLedger struct {
+	mu      sync.Mutex
+	pending map[string]int64
+}
+
+// NewQuotaLedger creates an empty ledger
+func NewQuotaLedger() *QuotaLedger {
+	return &QuotaLedger{pending: make(map[string]int64)}
+}
+
+// Pending returns the capacity earlier plans requested from a quota
+func (l *QuotaLedger) Pending(name, scope string) int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.pending[name+"\x00"+scope]
+}
+
+// reserve adds requested to the quota and returns what was pending before it
+func (l *QuotaLedger) reserve(q Quota) int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	key := q.Name + "\x00" + q.Scope
+	before := l.pending[key]
+	l.pending[key] = before + q.Requested
+	return before
+}
+
+type quotaLedgerKey struct{}
+
+// WithQuotaLedger returns a context whose plans share ledger
+func WithQuotaLedger(ctx context.Context, ledger *QuotaLedger) context.Context {
+	return context.WithValue(ctx, quotaLedgerKey{}, ledger)
+}
+
+// checkQuotas flags a plan whose change would exceed the handler's quotas. Inspection
+// failures only warn, since limits are advisory at plan time.
+func checkQuotas(ctx context.Context, handler ObjectHandler, req *PlanRequest, resp *PlanResponse) {
+	inspector, ok := handler.(QuotaInspector)
+	if !ok || resp == nil {
+		return
+	}
+	quotas, err := inspector.InspectQuotas(ctx, req)
+	if err != nil {
+		resp.Warnings = append(resp.Warnings, core.NewWarning(QuotaCodeUnavailable, "could not check backend quotas: %v", err))
+		return
+	}
+	ledger, _ := ctx.Value(quotaLedgerKey{}).(*QuotaLedger)
+	subject := req.ObjectType
+	if req.Name != "" {
+		subject += " " + req.Name
+	}
+
+	for _, q := range quotas {
+		var pending int64
+		if ledger != nil {
+			pending = ledger.reserve(q)
+		}
+		if q.Limit <= 0 || q.Requested <= 0 {
+			continue
+		}
+		projected := q.Used + pending + q.Requested
+		switch {
+		case projected > q.Limit:
+			resp.Valid = false
+			resp.Errors = append(resp.Errors, core.ValidationError{
+				Code:       QuotaCodeExceeded,
+				Message:    fmt.Sprintf("%s would exceed quota %s: %d of %d%s", subject, q.describe(), projected, q.Limit, q.unit()),
+				Severity:   "error",
+				Suggestion: "raise the backend limit or free capacity before applying",
+				Context: map[string]interface{}{
+					"quota":     q.Name,
+					"scope":     q.Scope,
+					"limit":     q.Limit,
+					"used":      q.Used,
+					"pending":   pending,
+					"requested": q.Requested,
+				},
+			})
+		case float64(projected) >= QuotaWarnRatio*float64(q.Limit):
+			resp.Warnings = append(resp.Warnings, core.NewWarning(QuotaCodeNearLimit,
+				"%s brings quota %s to %d of %d%s", subject, q.describe(), projected, q.Limit, q.unit()))
+		}
+	}
+}
+
+func (q Quota) describe() string {
+	if q.Scope == "" {
+		return q.Name
+	}
+	return q.Name + " (" + q.Scope + ")"
+}
+
+func (q Quota) unit() string {
+	if q.Unit == "" {
+		return ""
+	}
+	return " " + q.Unit
+}
@@ -0,0 +1,79 @@
+package create
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+)
+
+// quotaHandler reports a topic quota and plans one new topic per resource
+type quotaHandler struct {
+	strategyHandler
+	used    int64
+	limit   int64
+	inspect error
+}
+
+func (h *quotaHandler) InspectQuotas(ctx context.Context, req *PlanRequest) ([]Quota, error) {
+	if h.inspect != nil {
+		return nil, h.inspect
+	}
+	return []Quota{{Name: "topics_per_cluster", Scope: "cluster-1", Limit: h.limit, Used: h.used, Requested: 1}}, nil
+}
+
+func planThroughRegistry(t *testing.T, ctx context.Context, handler ObjectHandler, name string) *PlanResponse {
+	t.Helper()
+	registry := NewRegistry()
+	if err := registry.RegisterHandler("topic", handler, &core.ObjectType{Name: "topic", Type: core.CREATE}); err != nil {
+		t.Fatalf("RegisterHandler: %v", err)
+	}
+	out, err := registry.CallHandler(ctx, "topic", "plan", []byte(`{"object_type":"topic","name":"`+name+`"}`))
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	var resp PlanResponse
+	if err := json.Unmarshal(out, &resp); err != nil {
+		t.Fatalf("decode plan: %v", err)
+	}
+	return &resp
+}
+
+// TestPlanFlagsExceededQuota validates plans over a backend limit carry a quota error
+func TestPlanFlagsExceededQuota(t *testing.T) {
+	resp := planThroughRegistry(t, context.Background(), &quotaHandler{used: 10, limit: 10}, "orders")
+	if resp.Valid || len(resp.Errors) != 1 || resp.Errors[0].Code != QuotaCodeExceeded {
+		t.Fatalf("expected a quota error, got %+v", resp)
+	}
+
+	resp = planThroughRegistry(t, context.Background(), &quotaHandler{used: 8, limit: 10}, "orders")
+	if len(resp.Errors) != 0 || len(resp.Warnings) != 1 || resp.Warnings[0].Code != QuotaCodeNearLimit {
+		t.Errorf("expected a near-limit warning, got %+v", resp)
+	}
+
+	resp = planThroughRegistry(t, context.Background(), &quotaHandler{inspect: errors.New("forbidden")}, "orders")
+	if len(resp.Errors) != 0 || len(resp.Warnings) != 1 || resp.Warnings[0].Code != QuotaCodeUnavailable {
+		t.Errorf("inspection failure should only warn, got %+v", resp)
+	}
+}
+
+// TestQuotaLedgerAccumulates validates resources planned together share quota headroom
+func TestQuotaLedgerAccumulates(t *testing.T) {
+	ledger := NewQuotaLedger()
+	ctx := WithQuotaLedger(context.Background(), ledger)
+	handler := &quotaHandler{used: 3, limit: 5}
+
+	for _, name := range []string{"a", "b"} {
+		if resp := planThroughRegistry(t, ctx, handler, name); len(resp.Errors) != 0 {
+			t.Fatalf("topic %s should fit: %+v", name, resp.Errors)
+		}
+	}
+	if resp := planThroughRegistry(t, ctx, handler, "c"); len(resp.Errors) != 1 {
+		t.Fatalf("third topic should exceed the shared quota: %+v", resp)
+	}
+	if pending := ledger.Pending("topics_per_cluster", "cluster-1"); pending != 3 {
+		t.Errorf("Pending = %d, want 3", pending)
+	}
+}
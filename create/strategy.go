@@ -0,0 +1,315 @@
+package create
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+	"github.com/schemabounce/kolumn/sdk/helpers/ids"
+)
+
+// ApplyStrategy names how an update is rolled out
+type ApplyStrategy string
+
+const (
+	// StrategyInPlace calls the handler's Update directly
+	StrategyInPlace ApplyStrategy = "in_place"
+
+	// StrategyBlueGreen creates a new object next to the live one, swaps it in and
+	// retires the old one
+	StrategyBlueGreen ApplyStrategy = "blue_green"
+
+	// StrategyShadow creates a copy with the change applied, validates it and promotes
+	// it in place of the live object
+	StrategyShadow ApplyStrategy = "shadow"
+)
+
+// StrategyConfigKey is the resource config attribute selecting the apply strategy. It is
+// removed from the config before the handler sees it.
+const StrategyConfigKey = core.MixinApplyStrategy
+
+// StrategyMetadataKey is the UpdateResponse.Metadata key recording the steps a strategy ran
+const StrategyMetadataKey = "apply_strategy"
+
+// BlueGreenHandler is implemented by handlers whose objects can be rolled out blue/green
+type BlueGreenHandler interface {
+	// CreateGreen creates the updated object under name, alongside the live one
+	CreateGreen(ctx context.Context, req *UpdateRequest, name string) (map[string]interface{}, error)
+
+	// Swap makes green the live object, e.g. by renaming or repointing an alias, and
+	// returns the state of the now-live object
+	Swap(ctx context.Context, req *UpdateRequest, green map[string]interface{}) (map[string]interface{}, error)
+
+	// Retire removes the previous live object once the swap succeeded
+	Retire(ctx context.Context, req *UpdateRequest) error
+
+	// Discard removes a green object that was never swapped in
+	Discard(ctx context.Context, req *UpdateRequest, green map[string]interface{}) error
+}
+
+// ShadowHandler is implemented by handlers whose objects can be changed through a shadow copy
+type ShadowHandler interface {
+	// CreateShadow copies the live object under name with the change applied
+	CreateShadow(ctx context.Context, req *UpdateRequest, name string) (map[string]interface{}, error)
+
+	// ValidateShadow checks the copy, e.g. row counts or constraint checks, before promotion
+	ValidateShadow(ctx context.Context, req *UpdateRequest, shadow map[string]interface{}) error
+
+	// Promote replaces the live object with the shadow and returns the new live state
+	Promote(ctx context.Context, req *UpdateRequest, shadow map[string]interface{}) (map[string]interface{}, error)
+
+	// DropShadow removes the shadow copy; it is called after promotion and on rollback
+	DropShadow(ctx context.Context, req *UpdateRequest, shadow map[string]interface{}) error
+}
+
+// StrategyProgress reports one step of a strategy
+type StrategyProgress struct {
+	Strategy ApplyStrategy `json:"strategy"`
+	Step     string        `json:"step"`
+	Index    int           `json:"index"` // 1-based
+	Total    int           `json:"total"`
+	Status   string        `json:"status"` // started, completed, failed, rolled_back
+	Error    string        `json:"error,omitempty"`
+}
+
+// StrategyProgressFunc receives strategy progress
+type StrategyProgressFunc func(StrategyProgress)
+
+type strategyProgressKey struct{}
+
+// WithStrategyProgress returns a context whose strategy updates report to fn
+func WithStrategyProgress(ctx context.Context, fn StrategyProgressFunc) context.Context {
+	return context.WithValue(ctx, strategyProgressKey{}, fn)
+}
+
+// StrategyRecord is what a strategy did, stored in the update response metadata
+type StrategyRecord struct {
+	Strategy   ApplyStrategy      `json:"strategy"`
+	Candidate  string             `json:"candidate"` // name of the green or shadow object
+	Steps      []StrategyProgress `json:"steps"`
+	RolledBack bool               `json:"rolled_back,omitempty"`
+	Duration   time.Duration      `json:"duration"`
+}
+
+// StrategyError is a failed strategy; Record shows how far it got and whether the
+// partial change was rolled back
+type StrategyError struct {
+	Record StrategyRecord
+	Err    error
+}
+
+func (e *StrategyError) Error() string { return e.Err.Error() }
+
+func (e *StrategyError) Unwrap() error { return e.Err }
+
+// SelectStrategy reads the strategy chosen by config, defaulting to in place
+func SelectStrategy(config map[string]interface{}) (ApplyStrategy, error) {
+	raw, ok := config[StrategyConfigKey]
+	if !ok || raw == nil || raw == "" {
+		return StrategyInPlace, nil
+	}
+	s, _ := raw.(string)
+	switch strategy := ApplyStrategy(s); strategy {
+	case StrategyInPlace, StrategyBlueGreen, StrategyShadow:
+		return strategy, nil
+	}
+	return "", fmt.Errorf("unknown %s %v: use %s, %s or %s", StrategyConfigKey, raw, StrategyInPlace, StrategyBlueGreen, StrategyShadow)
+}
+
+// SupportedStrategies lists the strategies a handler opts into
+func SupportedStrategies(handler ObjectHandler) []ApplyStrategy {
+	strategies := []ApplyStrategy{StrategyInPlace}
+	if _, ok := handler.(BlueGreenHandler); ok {
+		strategies = append(strategies, StrategyBlueGreen)
+	}
+	if _, ok := handler.(ShadowHandler); ok {
+		strategies = append(strategies, StrategyShadow)
+	}
+	return strategies
+}
+
+// applyUpdate runs an update with the strategy its config selects
+func applyUpdate(ctx context.Context, objectType string, handler ObjectHandler, req *UpdateRequest) (*UpdateResponse, error) {
+	strategy, err := SelectStrategy(req.Config)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := req.Config[StrategyConfigKey]; ok {
+		config := make(map[string]interface{}, len(req.Config))
+		for k, v := range req.Config {
+			if k != StrategyConfigKey {
+				config[k] = v
+			}
+		}
+		req.Config = config
+	}
+	if req.Options != nil && req.Options.DryRun {
+		strategy = StrategyInPlace // handlers own dry runs
+	}
+
+	switch strategy {
+	case StrategyBlueGreen:
+		bg, ok := handler.(BlueGreenHandler)
+		if !ok {
+			return nil, fmt.Errorf("object type %s does not support the %s strategy", objectType, strategy)
+		}
+		return runBlueGreen(ctx, bg, req)
+	case StrategyShadow:
+		sh, ok := handler.(ShadowHandler)
+		if !ok {
+			return nil, fmt.Errorf("object type %s does not support the %s strategy", objectType, strategy)
+		}
+		return runShadow(ctx, sh, req)
+	}
+	return handler.Update(ctx, req)
+}
+
+// strategyRun tracks the steps of one strategy execution
+type strategyRun struct {
+	record   StrategyRecord
+	progress StrategyProgressFunc
+	total    int
+	start    time.Time
+}
+
+func newStrategyRun(ctx context.Context, strategy ApplyStrategy, req *UpdateRequest, total int) *strategyRun {
+	progress, _ := ctx.Value(strategyProgressKey{}).(StrategyProgressFunc)
+	name := req.Name
+	if name == "" {
+		name = req.ResourceID
+	}
+	suffix := "green"
+	if strategy == StrategyShadow {
+		suffix = "shadow"
+	}
+	return &strategyRun{
+		record: StrategyRecord{
+			Strategy:  strategy,
+			Candidate: fmt.Sprintf("%s_%s_%s", name, suffix, strings.ToLower(ids.ULID()[20:])),
+		},
+		progress: progress,
+		total:    total,
+		start:    clock.Now(),
+	}
+}
+
+// step runs fn as the next step and records its outcome
+func (r *strategyRun) step(name string, fn func() error) error {
+	update := StrategyProgress{Strategy: r.record.Strategy, Step: name, Index: len(r.record.Steps) + 1, Total: r.total}
+	r.report(update, "started", nil)
+	err := fn()
+	status := "completed"
+	if err != nil {
+		status = "failed"
+	}
+	r.report(update, status, err)
+	if err != nil {
+		return fmt.Errorf("%s %s failed: %w", r.record.Strategy, name, err)
+	}
+	return nil
+}
+
+// rollback runs a compensating step after cause and returns the failure
+func (r *strategyRun) rollback(name string, cause error, fn func() error) error {
+	update := StrategyProgress{Strategy: r.record.Strategy, Step: name, Index: len(r.record.Steps) + 1, Total: r.total}
+	err := fn()
+	status := "rolled_back"
+	if err != nil {
+		status = "failed"
+		cause = fmt.Errorf("%w; rollback %s failed: %v", cause, name, err)
+	}
+	r.record.RolledBack = err == nil
+	r.report(update, status, err)
+	return r.fail(cause)
+}
+
+// fail wraps err with the steps run so far
+func (r *strategyRun) fail(err error) error {
+	r.record.Duration = clock.Since(r.start)
+	return &StrategyError{Record: r.record, Err: err}
+}
+
+func (r *strategyRun) report(update StrategyProgress, status string, err error) {
+	update.Status = status
+	if err != nil {
+		update.Error = err.Error()
+	}
+	if status != "started" {
+		r.record.Steps = append(r.record.Steps, update)
+	}
+	if r.progress != nil {
+		r.progress(update)
+	}
+}
+
+// finish builds the update response for a strategy that reached the new live state
+func (r *strategyRun) finish(state map[string]interface{}, warnings []core.Warning) *UpdateResponse {
+	r.record.Duration = clock.Since(r.start)
+	return &UpdateResponse{
+		NewState: state,
+		Metadata: map[string]interface{}{StrategyMetadataKey: r.record},
+		Warnings: warnings,
+		Duration: r.record.Duration,
+		Replaced: true,
+	}
+}
+
+// runBlueGreen creates green, swaps it in and retires blue. A failed swap discards
+// green; a failed retire leaves the update applied with a warning.
+func runBlueGreen(ctx context.Context, handler BlueGreenHandler, req *UpdateRequest) (*UpdateResponse, error) {
+	run := newStrategyRun(ctx, StrategyBlueGreen, req, 3)
+
+	var green, live map[string]interface{}
+	if err := run.step("create_green", func() (err error) {
+		green, err = handler.CreateGreen(ctx, req, run.record.Candidate)
+		return err
+	}); err != nil {
+		return nil, run.fail(err)
+	}
+	if err := run.step("swap", func() (err error) {
+		live, err = handler.Swap(ctx, req, green)
+		return err
+	}); err != nil {
+		return nil, run.rollback("discard_green", err, func() error {
+			return handler.Discard(context.WithoutCancel(ctx), req, green)
+		})
+	}
+
+	var warnings []core.Warning
+	if err := run.step("retire_blue", func() error { return handler.Retire(ctx, req) }); err != nil {
+		warnings = append(warnings, core.NewWarning("STRATEGY_RETIRE_FAILED", "previous object was left in place: %v", err))
+	}
+	return run.finish(live, warnings), nil
+}
+
+// runShadow creates a shadow copy, validates and promotes it, then drops the copy. A
+// failed validation or promotion drops the shadow and leaves the live object untouched.
+func runShadow(ctx context.Context, handler ShadowHandler, req *UpdateRequest) (*UpdateResponse, error) {
+	run := newStrategyRun(ctx, StrategyShadow, req, 4)
+
+	var shadow, live map[string]interface{}
+	if err := run.step("create_shadow", func() (err error) {
+		shadow, err = handler.CreateShadow(ctx, req, run.record.Candidate)
+		return err
+	}); err != nil {
+		return nil, run.fail(err)
+	}
+	drop := func() error { return handler.DropShadow(context.WithoutCancel(ctx), req, shadow) }
+	if err := run.step("validate", func() error { return handler.ValidateShadow(ctx, req, shadow) }); err != nil {
+		return nil, run.rollback("drop_shadow", err, drop)
+	}
+	if err := run.step("promote", func() (err error) {
+		live, err = handler.Promote(ctx, req, shadow)
+		return err
+	}); err != nil {
+		return nil, run.rollback("drop_shadow", err, drop)
+	}
+
+	var warnings []core.Warning
+	if err := run.step("drop_shadow", drop); err != nil {
+		warnings = append(warnings, core.NewWarning("STRATEGY_CLEANUP_FAILED", "shadow copy %s was left in place: %v", run.record.Candidate, err))
+	}
+	return run.finish(live, warnings), nil
+}
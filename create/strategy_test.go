@@ -0,0 +1,192 @@
+package create
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+)
+
+// strategyHandler records the strategy calls made against it
+type strategyHandler struct {
+	calls    []string
+	failStep string
+	config   map[string]interface{}
+}
+
+func (h *strategyHandler) record(step string) error {
+	h.calls = append(h.calls, step)
+	if step == h.failStep {
+		return errors.New(step + " refused")
+	}
+	return nil
+}
+
+func (h *strategyHandler) Create(ctx context.Context, req *CreateRequest) (*CreateResponse, error) {
+	return &CreateResponse{}, nil
+}
+
+func (h *strategyHandler) Read(ctx context.Context, req *ReadRequest) (*ReadResponse, error) {
+	return &ReadResponse{}, nil
+}
+
+func (h *strategyHandler) Update(ctx context.Context, req *UpdateRequest) (*UpdateResponse, error) {
+	h.config = req.Config
+	return &UpdateResponse{NewState: req.Config}, h.record("update")
+}
+
+func (h *strategyHandler) Delete(ctx context.Context, req *DeleteRequest) (*DeleteResponse, error) {
+	return &DeleteResponse{}, nil
+}
+
+func (h *strategyHandler) Plan(ctx context.Context, req *PlanRequest) (*PlanResponse, error) {
+	return &PlanResponse{}, nil
+}
+
+func (h *strategyHandler) CreateGreen(ctx context.Context, req *UpdateRequest, name string) (map[string]interface{}, error) {
+	h.config = req.Config
+	return map[string]interface{}{"name": name}, h.record("create_green")
+}
+
+func (h *strategyHandler) Swap(ctx context.Context, req *UpdateRequest, green map[string]interface{}) (map[string]interface{}, error) {
+	return map[string]interface{}{"name": req.Name, "from": green["name"]}, h.record("swap")
+}
+
+func (h *strategyHandler) Retire(ctx context.Context, req *UpdateRequest) error {
+	return h.record("retire")
+}
+
+func (h *strategyHandler) Discard(ctx context.Context, req *UpdateRequest, green map[string]interface{}) error {
+	return h.record("discard")
+}
+
+// shadowHandler adds the shadow strategy to strategyHandler
+type shadowHandler struct {
+	strategyHandler
+}
+
+func (h *shadowHandler) CreateShadow(ctx context.Context, req *UpdateRequest, name string) (map[string]interface{}, error) {
+	return map[string]interface{}{"name": name}, h.record("create_shadow")
+}
+
+func (h *shadowHandler) ValidateShadow(ctx context.Context, req *UpdateRequest, shadow map[string]interface{}) error {
+	return h.record("validate")
+}
+
+func (h *shadowHandler) Promote(ctx context.Context, req *UpdateRequest, shadow map[string]interface{}) (map[string]interface{}, error) {
+	return map[string]interface{}{"name": req.Name}, h.record("promote")
+}
+
+func (h *shadowHandler) DropShadow(ctx context.Context, req *UpdateRequest, shadow map[string]interface{}) error {
+	return h.record("drop_shadow")
+}
+
+func updateInput(t *testing.T, strategy string) []byte {
+	t.Helper()
+	input, err := json.Marshal(map[string]interface{}{
+		"object_type": "table",
+		"name":        "orders",
+		"config":      map[string]interface{}{"name": "orders", StrategyConfigKey: strategy},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return input
+}
+
+func newStrategyRegistry(t *testing.T, handler ObjectHandler) *Registry {
+	t.Helper()
+	registry := NewRegistry()
+	if err := registry.RegisterHandler("table", handler, &core.ObjectType{Name: "table", Type: core.CREATE}); err != nil {
+		t.Fatal(err)
+	}
+	return registry
+}
+
+// TestBlueGreenStrategy validates the blue/green steps, progress reporting and
+// rollback of a failed swap
+func TestBlueGreenStrategy(t *testing.T) {
+	handler := &strategyHandler{}
+	registry := newStrategyRegistry(t, handler)
+
+	var progress []string
+	ctx := WithStrategyProgress(context.Background(), func(p StrategyProgress) {
+		progress = append(progress, p.Step+":"+p.Status)
+	})
+	output, err := registry.CallHandler(ctx, "table", "update", updateInput(t, "blue_green"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(handler.calls, ",") != "create_green,swap,retire" {
+		t.Errorf("unexpected calls %v", handler.calls)
+	}
+	if _, ok := handler.config[StrategyConfigKey]; ok {
+		t.Error("strategy selection must not reach the handler config")
+	}
+	if len(progress) != 6 || progress[5] != "retire_blue:completed" {
+		t.Errorf("unexpected progress %v", progress)
+	}
+	var resp struct {
+		Replaced bool `json:"replaced"`
+		Metadata struct {
+			Strategy StrategyRecord `json:"apply_strategy"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(output, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Replaced || len(resp.Metadata.Strategy.Steps) != 3 || !strings.HasPrefix(resp.Metadata.Strategy.Candidate, "orders_green_") {
+		t.Errorf("unexpected response %s", output)
+	}
+
+	handler.calls, handler.failStep = nil, "swap"
+	_, err = applyUpdate(context.Background(), "table", handler, &UpdateRequest{Name: "orders", Config: map[string]interface{}{StrategyConfigKey: "blue_green"}})
+	var strategyErr *StrategyError
+	if !errors.As(err, &strategyErr) || !strategyErr.Record.RolledBack {
+		t.Fatalf("expected a rolled back strategy error, got %v", err)
+	}
+	if strings.Join(handler.calls, ",") != "create_green,swap,discard" {
+		t.Errorf("unexpected calls %v", handler.calls)
+	}
+
+	if _, err := registry.CallHandler(ctx, "table", "update", updateInput(t, "shadow")); err == nil {
+		t.Error("expected an error for a strategy the handler does not support")
+	}
+}
+
+// TestShadowStrategy validates the shadow steps and that a failed validation drops
+// the shadow without promoting it
+func TestShadowStrategy(t *testing.T) {
+	handler := &shadowHandler{}
+	resp, err := applyUpdate(context.Background(), "table", handler, &UpdateRequest{Name: "orders", Config: map[string]interface{}{StrategyConfigKey: "shadow"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(handler.calls, ",") != "create_shadow,validate,promote,drop_shadow" || !resp.Replaced {
+		t.Errorf("unexpected calls %v", handler.calls)
+	}
+
+	handler.calls, handler.failStep = nil, "validate"
+	_, err = applyUpdate(context.Background(), "table", handler, &UpdateRequest{Name: "orders", Config: map[string]interface{}{StrategyConfigKey: "shadow"}})
+	var strategyErr *StrategyError
+	if !errors.As(err, &strategyErr) || !strategyErr.Record.RolledBack {
+		t.Fatalf("expected a rolled back strategy error, got %v", err)
+	}
+	if strings.Join(handler.calls, ",") != "create_shadow,validate,drop_shadow" {
+		t.Errorf("unexpected calls %v", handler.calls)
+	}
+
+	handler.calls, handler.failStep = nil, ""
+	if _, err := applyUpdate(context.Background(), "table", handler, &UpdateRequest{Config: map[string]interface{}{}}); err != nil || handler.calls[0] != "update" {
+		t.Errorf("no strategy must update in place: %v %v", err, handler.calls)
+	}
+	if _, err := SelectStrategy(map[string]interface{}{StrategyConfigKey: "rolling"}); err == nil {
+		t.Error("expected an error for an unknown strategy")
+	}
+	if got := SupportedStrategies(handler); len(got) != 3 {
+		t.Errorf("unexpected strategies %v", got)
+	}
+}
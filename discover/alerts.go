@@ -0,0 +1,752 @@
+// Package discover provides evaluation of monitoring alert rules
+package discover
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Alert severities accepted in AlertRule.Severity
+const (
+	AlertSeverityCritical = "critical"
+	AlertSeverityWarning  = "warning"
+	AlertSeverityInfo     = "info"
+)
+
+// ScanAlertMetrics are the metrics NewScanAlertInput provides, usable in conditions
+// such as "errors > 0" or "object_types.table >= 500". Nested maps are read with dots.
+var ScanAlertMetrics = []string{
+	"total_objects", // objects the scan reported in its summary
+	"objects",       // objects in the response
+	"errors",        // scan errors in the summary
+	"warnings",      // number of warnings
+	"object_types",  // count by type
+	"systems",       // count by system
+	"metadata",      // response metadata
+	"added",         // with a baseline: objects added since it
+	"removed",       // with a baseline: objects removed since it
+	"changed",       // with a baseline: objects whose properties changed
+	"changes_by_type",
+}
+
+// AlertObjectFields are the object fields usable inside count(), e.g.
+// count(type == "table" && properties.row_count > 1000000) > 0
+var AlertObjectFields = []string{"id", "name", "type", "category", "discovered", "properties", "tags", "source", "metadata"}
+
+// AlertInput is what alert conditions are evaluated against
+type AlertInput struct {
+	Metrics map[string]interface{} // named values; nested maps are read with dots
+	Objects []*DiscoveredObject    // objects counted by count()
+}
+
+// NewScanAlertInput builds the metrics of a scan and, when a baseline diff is given,
+// of the changes since the baseline
+func NewScanAlertInput(resp *ScanResponse, diff *ScanDiff) *AlertInput {
+	input := &AlertInput{Metrics: make(map[string]interface{})}
+	if resp != nil {
+		input.Objects = resp.Objects
+		input.Metrics["objects"] = float64(len(resp.Objects))
+		input.Metrics["warnings"] = float64(len(resp.Warnings))
+		input.Metrics["metadata"] = normalizeMap(resp.Metadata)
+		if s := resp.Summary; s != nil {
+			input.Metrics["total_objects"] = float64(s.TotalObjects)
+			input.Metrics["errors"] = float64(s.Errors)
+			input.Metrics["object_types"] = normalizeMap(s.ObjectTypes)
+			input.Metrics["systems"] = normalizeMap(s.Systems)
+		}
+	}
+	if diff != nil {
+		input.Metrics["added"] = float64(diff.Totals.Added)
+		input.Metrics["removed"] = float64(diff.Totals.Removed)
+		input.Metrics["changed"] = float64(diff.Totals.Changed)
+		input.Metrics["changes_by_type"] = normalizeMap(diff.ByType)
+	}
+	return input
+}
+
+// AlertCondition is a parsed alert condition. The language compares values with
+// ==, !=, <, <=, > and >=, combines comparisons with && (and), || (or) and ! (not),
+// and groups them with parentheses. Values are numbers, "strings", true, false, null,
+// dotted metric names and count(condition), the number of objects matching a condition
+// over their fields. Metrics that are absent evaluate to null, which is equal only to
+// null and neither smaller nor larger than anything.
+type AlertCondition struct {
+	source string
+	root   alertNode
+}
+
+// ParseAlertCondition parses and type-checks a condition. Metric names are checked
+// against metrics, and field names inside count() against AlertObjectFields, by their
+// first segment; a nil metrics list accepts any name.
+func ParseAlertCondition(condition string, metrics []string) (*AlertCondition, error) {
+	tokens, err := lexAlert(condition)
+	if err != nil {
+		return nil, err
+	}
+	p := &alertParser{tokens: tokens, metrics: metrics}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != alertEOF {
+		return nil, fmt.Errorf("unexpected %q at offset %d", tok.text, tok.pos)
+	}
+	if root.kind() != kindBool {
+		return nil, fmt.Errorf("condition %q is not a comparison", condition)
+	}
+	return &AlertCondition{source: condition, root: root}, nil
+}
+
+// String returns the condition as written
+func (c *AlertCondition) String() string {
+	return c.source
+}
+
+// Metrics returns the metric names the condition reads, sorted
+func (c *AlertCondition) Metrics() []string {
+	seen := make(map[string]bool)
+	c.root.walk(func(n alertNode) {
+		if m, ok := n.(*alertPath); ok && !m.object {
+			seen[strings.Join(m.path, ".")] = true
+		}
+	})
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Evaluate reports whether the condition holds for input
+func (c *AlertCondition) Evaluate(input *AlertInput) bool {
+	if input == nil {
+		input = &AlertInput{}
+	}
+	return truthy(c.root.eval(&alertScope{input: input}))
+}
+
+// Validate checks a rule's condition against ScanAlertMetrics, its severity and its
+// cooldown, so broken rules are rejected when a monitor is registered
+func (r *AlertRule) Validate() error {
+	_, err := compileAlertRule(r, ScanAlertMetrics)
+	return err
+}
+
+// AlertEvent is a rule that fired
+type AlertEvent struct {
+	Rule      string                 `json:"rule"`
+	Severity  string                 `json:"severity"`
+	Condition string                 `json:"condition"`
+	Actions   []string               `json:"actions,omitempty"`
+	FiredAt   time.Time              `json:"fired_at"`
+	Values    map[string]interface{} `json:"values,omitempty"` // metrics the condition read
+}
+
+// AlertEvaluator evaluates a monitor's rules on every scheduled run, holding back rules
+// that fired within their cooldown. It is safe for concurrent use.
+type AlertEvaluator struct {
+	rules []*compiledAlertRule
+
+	mu        sync.Mutex
+	lastFired map[string]time.Time
+}
+
+type compiledAlertRule struct {
+	rule      *AlertRule
+	condition *AlertCondition
+	cooldown  time.Duration
+}
+
+// NewAlertEvaluator validates and compiles rules. metrics lists the metric names
+// conditions may use; nil means ScanAlertMetrics.
+func NewAlertEvaluator(rules []*AlertRule, metrics []string) (*AlertEvaluator, error) {
+	if metrics == nil {
+		metrics = ScanAlertMetrics
+	}
+	e := &AlertEvaluator{lastFired: make(map[string]time.Time)}
+	names := make(map[string]bool, len(rules))
+	for i, rule := range rules {
+		if rule == nil {
+			continue
+		}
+		compiled, err := compileAlertRule(rule, metrics)
+		if err != nil {
+			return nil, fmt.Errorf("alert rule %d: %w", i, err)
+		}
+		if names[rule.Name] {
+			return nil, fmt.Errorf("alert rule %q is defined twice", rule.Name)
+		}
+		names[rule.Name] = true
+		e.rules = append(e.rules, compiled)
+	}
+	return e, nil
+}
+
+// AlertEvaluator validates the request's alert rules; Monitor implementations call it
+// when registering the monitor and run the evaluator after every scheduled scan
+func (r *MonitorRequest) AlertEvaluator() (*AlertEvaluator, error) {
+	return NewAlertEvaluator(r.Alerts, nil)
+}
+
+// Evaluate returns the events of the rules whose condition holds at now, in rule order.
+// A rule that fired is not reported again until its cooldown has passed.
+func (e *AlertEvaluator) Evaluate(input *AlertInput, now time.Time) []AlertEvent {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var events []AlertEvent
+	for _, r := range e.rules {
+		if !r.condition.Evaluate(input) {
+			continue
+		}
+		if last, ok := e.lastFired[r.rule.Name]; ok && now.Sub(last) < r.cooldown {
+			continue
+		}
+		e.lastFired[r.rule.Name] = now
+		event := AlertEvent{
+			Rule:      r.rule.Name,
+			Severity:  r.rule.Severity,
+			Condition: r.condition.String(),
+			Actions:   append([]string(nil), r.rule.Actions...),
+			FiredAt:   now,
+		}
+		for _, name := range r.condition.Metrics() {
+			if event.Values == nil {
+				event.Values = make(map[string]interface{})
+			}
+			event.Values[name] = lookupPath(input.Metrics, strings.Split(name, "."))
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+func compileAlertRule(r *AlertRule, metrics []string) (*compiledAlertRule, error) {
+	if r.Name == "" {
+		return nil, fmt.Errorf("alert rule needs a name")
+	}
+	switch r.Severity {
+	case AlertSeverityCritical, AlertSeverityWarning, AlertSeverityInfo:
+	default:
+		return nil, fmt.Errorf("alert rule %q: severity %q must be %s, %s or %s",
+			r.Name, r.Severity, AlertSeverityCritical, AlertSeverityWarning, AlertSeverityInfo)
+	}
+	compiled := &compiledAlertRule{rule: r}
+	if r.Cooldown != "" {
+		cooldown, err := time.ParseDuration(r.Cooldown)
+		if err != nil || cooldown < 0 {
+			return nil, fmt.Errorf("alert rule %q: invalid cooldown %q", r.Name, r.Cooldown)
+		}
+		compiled.cooldown = cooldown
+	}
+	condition, err := ParseAlertCondition(r.Condition, metrics)
+	if err != nil {
+		return nil, fmt.Errorf("alert rule %q: %w", r.Name, err)
+	}
+	compiled.condition = condition
+	return compiled, nil
+}
+
+// =============================================================================
+// LEXER
+// =============================================================================
+
+type alertTokenKind int
+
+const (
+	alertEOF alertTokenKind = iota
+	alertNumber
+	alertString
+	alertIdent
+	alertOp
+	alertLParen
+	alertRParen
+)
+
+type alertToken struct {
+	kind alertTokenKind
+	text string
+	pos  int
+	num  float64
+}
+
+func lexAlert(s string) ([]alertToken, error) {
+	var tokens []alertToken
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, alertToken{kind: alertLParen, text: "(", pos: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, alertToken{kind: alertRParen, text: ")", pos: i})
+			i++
+		case c == '"':
+			j := i + 1
+			for j < len(s) && s[j] != '"' {
+				if s[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(s) {
+				return nil, fmt.Errorf("unterminated string at offset %d", i)
+			}
+			text, err := strconv.Unquote(s[i : j+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid string at offset %d: %w", i, err)
+			}
+			tokens = append(tokens, alertToken{kind: alertString, text: text, pos: i})
+			i = j + 1
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(s) && s[i+1] >= '0' && s[i+1] <= '9':
+			j := i
+			for j < len(s) && (s[j] >= '0' && s[j] <= '9' || s[j] == '.' || s[j] == '_' || s[j] == 'e' || s[j] == 'E') {
+				j++
+			}
+			num, err := strconv.ParseFloat(strings.ReplaceAll(s[i:j], "_", ""), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q at offset %d", s[i:j], i)
+			}
+			tokens = append(tokens, alertToken{kind: alertNumber, text: s[i:j], pos: i, num: num})
+			i = j
+		case c == '_' || unicode.IsLetter(rune(c)):
+			j := i
+			for j < len(s) && (s[j] == '_' || s[j] == '.' || s[j] == '-' || unicode.IsLetter(rune(s[j])) || unicode.IsDigit(rune(s[j]))) {
+				j++
+			}
+			text := s[i:j]
+			switch text {
+			case "and":
+				tokens = append(tokens, alertToken{kind: alertOp, text: "&&", pos: i})
+			case "or":
+				tokens = append(tokens, alertToken{kind: alertOp, text: "||", pos: i})
+			case "not":
+				tokens = append(tokens, alertToken{kind: alertOp, text: "!", pos: i})
+			default:
+				tokens = append(tokens, alertToken{kind: alertIdent, text: text, pos: i})
+			}
+			i = j
+		default:
+			op := ""
+			for _, candidate := range []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "-"} {
+				if strings.HasPrefix(s[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character %q at offset %d", c, i)
+			}
+			tokens = append(tokens, alertToken{kind: alertOp, text: op, pos: i})
+			i += len(op)
+		}
+	}
+	return append(tokens, alertToken{kind: alertEOF, text: "end of condition", pos: len(s)}), nil
+}
+
+// =============================================================================
+// PARSER
+// =============================================================================
+
+type alertParser struct {
+	tokens  []alertToken
+	pos     int
+	metrics []string
+	inCount bool
+}
+
+func (p *alertParser) peek() alertToken { return p.tokens[p.pos] }
+
+func (p *alertParser) next() alertToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != alertEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *alertParser) acceptOp(op string) bool {
+	if tok := p.peek(); tok.kind == alertOp && tok.text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *alertParser) parseOr() (alertNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptOp("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		if left, err = newAlertLogic("||", left, right); err != nil {
+			return nil, err
+		}
+	}
+	return left, nil
+}
+
+func (p *alertParser) parseAnd() (alertNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptOp("&&") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		if left, err = newAlertLogic("&&", left, right); err != nil {
+			return nil, err
+		}
+	}
+	return left, nil
+}
+
+func (p *alertParser) parseNot() (alertNode, error) {
+	if p.acceptOp("!") {
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		if operand.kind() != kindBool && operand.kind() != kindAny {
+			return nil, fmt.Errorf("! needs a comparison")
+		}
+		return &alertNot{operand: operand}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *alertParser) parseComparison() (alertNode, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	tok := p.peek()
+	if tok.kind != alertOp {
+		return left, nil
+	}
+	switch tok.text {
+	case "==", "!=", "<", "<=", ">", ">=":
+	default:
+		return left, nil
+	}
+	p.next()
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	if tok.text != "==" && tok.text != "!=" {
+		for _, side := range []alertNode{left, right} {
+			if k := side.kind(); k == kindBool || k == kindNull {
+				return nil, fmt.Errorf("%s at offset %d needs numbers or strings", tok.text, tok.pos)
+			}
+		}
+		if l, r := left.kind(), right.kind(); l != kindAny && r != kindAny && l != r {
+			return nil, fmt.Errorf("%s at offset %d compares a %s with a %s", tok.text, tok.pos, l, r)
+		}
+	}
+	return &alertCompare{op: tok.text, left: left, right: right}, nil
+}
+
+func (p *alertParser) parseOperand() (alertNode, error) {
+	tok := p.next()
+	switch tok.kind {
+	case alertNumber:
+		return &alertLiteral{value: tok.num}, nil
+	case alertString:
+		return &alertLiteral{value: tok.text}, nil
+	case alertLParen:
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != alertRParen {
+			return nil, fmt.Errorf("expected ) at offset %d, got %q", closing.pos, closing.text)
+		}
+		return inner, nil
+	case alertOp:
+		if tok.text == "-" {
+			if num := p.next(); num.kind == alertNumber {
+				return &alertLiteral{value: -num.num}, nil
+			}
+		}
+	case alertIdent:
+		switch tok.text {
+		case "true":
+			return &alertLiteral{value: true}, nil
+		case "false":
+			return &alertLiteral{value: false}, nil
+		case "null":
+			return &alertLiteral{value: nil}, nil
+		case "count":
+			return p.parseCount(tok)
+		}
+		return p.path(tok)
+	}
+	return nil, fmt.Errorf("unexpected %q at offset %d", tok.text, tok.pos)
+}
+
+func (p *alertParser) parseCount(tok alertToken) (alertNode, error) {
+	if p.inCount {
+		return nil, fmt.Errorf("count at offset %d cannot be nested", tok.pos)
+	}
+	if open := p.next(); open.kind != alertLParen {
+		return nil, fmt.Errorf("expected ( after count at offset %d", open.pos)
+	}
+	p.inCount = true
+	filter, err := p.parseOr()
+	p.inCount = false
+	if err != nil {
+		return nil, err
+	}
+	if filter.kind() != kindBool && filter.kind() != kindAny {
+		return nil, fmt.Errorf("count at offset %d needs a condition", tok.pos)
+	}
+	if closing := p.next(); closing.kind != alertRParen {
+		return nil, fmt.Errorf("expected ) at offset %d, got %q", closing.pos, closing.text)
+	}
+	return &alertCount{filter: filter}, nil
+}
+
+func (p *alertParser) path(tok alertToken) (alertNode, error) {
+	segments := strings.Split(tok.text, ".")
+	for _, segment := range segments {
+		if segment == "" {
+			return nil, fmt.Errorf("invalid name %q at offset %d", tok.text, tok.pos)
+		}
+	}
+	known := p.metrics
+	what := "metric"
+	if p.inCount {
+		known, what = AlertObjectFields, "object field"
+	}
+	if known != nil && !containsAlertName(known, segments[0]) {
+		return nil, fmt.Errorf("unknown %s %q at offset %d", what, segments[0], tok.pos)
+	}
+	return &alertPath{path: segments, object: p.inCount}, nil
+}
+
+func containsAlertName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// =============================================================================
+// EVALUATION
+// =============================================================================
+
+type alertKind string
+
+const (
+	kindAny    alertKind = "value"
+	kindBool   alertKind = "boolean"
+	kindNumber alertKind = "number"
+	kindString alertKind = "string"
+	kindNull   alertKind = "null"
+)
+
+type alertScope struct {
+	input  *AlertInput
+	object map[string]interface{} // the object count() is evaluating
+}
+
+type alertNode interface {
+	eval(scope *alertScope) interface{}
+	kind() alertKind
+	walk(fn func(alertNode))
+}
+
+type alertLiteral struct{ value interface{} }
+
+func (n *alertLiteral) eval(*alertScope) interface{} { return n.value }
+func (n *alertLiteral) walk(fn func(alertNode))      { fn(n) }
+func (n *alertLiteral) kind() alertKind {
+	switch n.value.(type) {
+	case bool:
+		return kindBool
+	case float64:
+		return kindNumber
+	case string:
+		return kindString
+	}
+	return kindNull
+}
+
+type alertPath struct {
+	path   []string
+	object bool // an object field inside count()
+}
+
+func (n *alertPath) kind() alertKind         { return kindAny }
+func (n *alertPath) walk(fn func(alertNode)) { fn(n) }
+func (n *alertPath) eval(scope *alertScope) interface{} {
+	if n.object {
+		return lookupPath(scope.object, n.path)
+	}
+	return lookupPath(scope.input.Metrics, n.path)
+}
+
+type alertCount struct{ filter alertNode }
+
+func (n *alertCount) kind() alertKind { return kindNumber }
+func (n *alertCount) walk(fn func(alertNode)) {
+	fn(n)
+	n.filter.walk(fn)
+}
+func (n *alertCount) eval(scope *alertScope) interface{} {
+	count := 0
+	for _, obj := range scope.input.Objects {
+		if obj == nil {
+			continue
+		}
+		fields, _ := normalize(obj).(map[string]interface{})
+		if truthy(n.filter.eval(&alertScope{input: scope.input, object: fields})) {
+			count++
+		}
+	}
+	return float64(count)
+}
+
+type alertNot struct{ operand alertNode }
+
+func (n *alertNot) kind() alertKind { return kindBool }
+func (n *alertNot) walk(fn func(alertNode)) {
+	fn(n)
+	n.operand.walk(fn)
+}
+func (n *alertNot) eval(scope *alertScope) interface{} { return !truthy(n.operand.eval(scope)) }
+
+type alertLogic struct {
+	op          string
+	left, right alertNode
+}
+
+func newAlertLogic(op string, left, right alertNode) (alertNode, error) {
+	for _, side := range []alertNode{left, right} {
+		if k := side.kind(); k != kindBool && k != kindAny {
+			return nil, fmt.Errorf("%s needs comparisons on both sides", op)
+		}
+	}
+	return &alertLogic{op: op, left: left, right: right}, nil
+}
+
+func (n *alertLogic) kind() alertKind { return kindBool }
+func (n *alertLogic) walk(fn func(alertNode)) {
+	fn(n)
+	n.left.walk(fn)
+	n.right.walk(fn)
+}
+func (n *alertLogic) eval(scope *alertScope) interface{} {
+	if n.op == "&&" {
+		return truthy(n.left.eval(scope)) && truthy(n.right.eval(scope))
+	}
+	return truthy(n.left.eval(scope)) || truthy(n.right.eval(scope))
+}
+
+type alertCompare struct {
+	op          string
+	left, right alertNode
+}
+
+func (n *alertCompare) kind() alertKind { return kindBool }
+func (n *alertCompare) walk(fn func(alertNode)) {
+	fn(n)
+	n.left.walk(fn)
+	n.right.walk(fn)
+}
+func (n *alertCompare) eval(scope *alertScope) interface{} {
+	left, right := alertValue(n.left.eval(scope)), alertValue(n.right.eval(scope))
+	switch n.op {
+	case "==":
+		return left == right
+	case "!=":
+		return left != right
+	}
+	var cmp int
+	switch l := left.(type) {
+	case float64:
+		r, ok := right.(float64)
+		if !ok {
+			return false
+		}
+		cmp = compareOrdered(l, r)
+	case string:
+		r, ok := right.(string)
+		if !ok {
+			return false
+		}
+		cmp = compareOrdered(l, r)
+	default:
+		return false
+	}
+	switch n.op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	default:
+		return cmp >= 0
+	}
+}
+
+func compareOrdered[T float64 | string](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// alertValue reduces a value to a comparable scalar: numbers become float64, and
+// lists and maps, which conditions cannot compare, become null
+func alertValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case float64, string, bool, nil:
+		return v
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	}
+	return nil
+}
+
+func truthy(v interface{}) bool {
+	b, ok := v.(bool)
+	return ok && b
+}
+
+// lookupPath follows a dotted path through nested maps
+func lookupPath(values map[string]interface{}, path []string) interface{} {
+	var current interface{} = values
+	for _, segment := range path {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = m[segment]
+	}
+	return current
+}
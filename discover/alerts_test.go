@@ -0,0 +1,117 @@
+package discover
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func alertTestInput() *AlertInput {
+	resp := &ScanResponse{
+		Objects: []*DiscoveredObject{
+			{ID: "1", Name: "users", Type: "table", Properties: map[string]interface{}{"row_count": 2000000}, Tags: map[string]string{"pii": "true"}},
+			{ID: "2", Name: "orders", Type: "table", Properties: map[string]interface{}{"row_count": 10}},
+			{ID: "3", Name: "v_users", Type: "view"},
+		},
+		Summary: &ScanSummary{
+			TotalObjects: 3,
+			ObjectTypes:  map[string]int{"table": 2, "view": 1},
+			Systems:      map[string]int{"postgres": 3},
+			Errors:       1,
+		},
+		Warnings: []string{"slow catalog query"},
+	}
+	diff := &ScanDiff{Totals: DiffCounts{Added: 1, Removed: 2}}
+	return NewScanAlertInput(resp, diff)
+}
+
+// TestAlertConditionEvaluate validates comparisons, logic, metric paths and count()
+func TestAlertConditionEvaluate(t *testing.T) {
+	input := alertTestInput()
+	cases := map[string]bool{
+		`errors > 0`:                                      true,
+		`errors > 0 && warnings == 0`:                     false,
+		`errors > 0 and not (warnings == 0)`:              true,
+		`removed >= 2 || added > 10`:                      true,
+		`object_types.table >= 2`:                         true,
+		`object_types.view < 1`:                           false,
+		`systems.postgres == total_objects`:               true,
+		`object_types.index > 0`:                          false, // absent metrics are null
+		`object_types.index == null`:                      true,
+		`count(type == "table") == 2`:                     true,
+		`count(properties.row_count > 1_000_000) > 0`:     true,
+		`count(tags.pii == "true" && type == "view") > 0`: false,
+		`count(name >= "v") == 1`:                         true,
+		`changed != -1`:                                   true,
+	}
+	for source, want := range cases {
+		condition, err := ParseAlertCondition(source, ScanAlertMetrics)
+		if err != nil {
+			t.Fatalf("ParseAlertCondition(%q): %v", source, err)
+		}
+		if got := condition.Evaluate(input); got != want {
+			t.Errorf("%s = %v, want %v", source, got, want)
+		}
+	}
+}
+
+// TestParseAlertConditionErrors validates that malformed or ill-typed conditions are rejected
+func TestParseAlertConditionErrors(t *testing.T) {
+	for source, want := range map[string]string{
+		``:                         "unexpected",
+		`errors >`:                 "unexpected",
+		`errors > 0 &&`:            "unexpected",
+		`(errors > 0`:              "expected )",
+		`error_count > 0`:          `unknown metric "error_count"`,
+		`count(row_count > 1) > 0`: `unknown object field "row_count"`,
+		`count(count(true)) > 0`:   "cannot be nested",
+		`5 > "many"`:               "compares a number with a string",
+		`errors > true`:            "needs numbers or strings",
+		`total_objects`:            "not a comparison",
+		`errors > 0 && 5`:          "needs comparisons",
+		`errors > 0 # note`:        "unexpected character",
+		`name == "open`:            "unterminated string",
+	} {
+		_, err := ParseAlertCondition(source, ScanAlertMetrics)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseAlertCondition(%q) error = %v, want %q", source, err, want)
+		}
+	}
+}
+
+// TestAlertEvaluatorCooldown validates registration checks, fired events and cooldowns
+func TestAlertEvaluatorCooldown(t *testing.T) {
+	if _, err := NewAlertEvaluator([]*AlertRule{{Name: "bad", Condition: "errors > 0", Severity: "urgent"}}, nil); err == nil {
+		t.Fatal("expected invalid severity to be rejected")
+	}
+	if err := (&AlertRule{Name: "bad", Condition: "errors > 0", Severity: "info", Cooldown: "soon"}).Validate(); err == nil {
+		t.Fatal("expected invalid cooldown to be rejected")
+	}
+	req := &MonitorRequest{Alerts: []*AlertRule{
+		{Name: "scan-errors", Condition: "errors > 0", Severity: AlertSeverityCritical, Actions: []string{"page"}, Cooldown: "1h"},
+		{Name: "drops", Condition: "removed > 5", Severity: AlertSeverityWarning},
+		{Name: "big-tables", Condition: "object_types.table >= 2", Severity: AlertSeverityInfo},
+	}}
+	evaluator, err := req.AlertEvaluator()
+	if err != nil {
+		t.Fatalf("AlertEvaluator: %v", err)
+	}
+
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	events := evaluator.Evaluate(alertTestInput(), now)
+	if len(events) != 2 || events[0].Rule != "scan-errors" || events[1].Rule != "big-tables" {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+	if events[0].Values["errors"] != float64(1) || events[0].Actions[0] != "page" || !events[0].FiredAt.Equal(now) {
+		t.Fatalf("unexpected event: %+v", events[0])
+	}
+
+	events = evaluator.Evaluate(alertTestInput(), now.Add(30*time.Minute))
+	if len(events) != 1 || events[0].Rule != "big-tables" {
+		t.Fatalf("expected scan-errors held back by its cooldown: %+v", events)
+	}
+	events = evaluator.Evaluate(alertTestInput(), now.Add(time.Hour))
+	if len(events) != 2 {
+		t.Fatalf("expected scan-errors after its cooldown: %+v", events)
+	}
+}
@@ -0,0 +1,235 @@
+// Package discover provides depth-aware analysis with per-object time budgets
+package discover
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Analysis depths accepted in AnalyzeRequest.Depth
+const (
+	DepthShallow       = "shallow"       // metadata only, no data is read
+	DepthDeep          = "deep"          // metadata plus a bounded sample of rows
+	DepthComprehensive = "comprehensive" // full scan of the object's data
+)
+
+// Analysis defaults
+const (
+	DefaultSampleRows = 1000
+	DefaultDepth      = DepthShallow
+)
+
+// DefaultObjectBudgets is the time each object may take at each depth
+var DefaultObjectBudgets = map[string]time.Duration{
+	DepthShallow:       5 * time.Second,
+	DepthDeep:          30 * time.Second,
+	DepthComprehensive: 5 * time.Minute,
+}
+
+// AnalysisPlan is the resolved depth, row limit and time budgets for an analyze request
+type AnalysisPlan struct {
+	Depth        string        `json:"depth"`
+	SampleRows   int           `json:"sample_rows"`            // 0 = no data, -1 = unlimited
+	ObjectBudget time.Duration `json:"object_budget"`          // per object
+	TotalBudget  time.Duration `json:"total_budget,omitempty"` // whole request, 0 = none
+}
+
+// InspectsData reports whether analyzers may read object data
+func (p *AnalysisPlan) InspectsData() bool {
+	return p.SampleRows != 0
+}
+
+// FullScan reports whether analyzers should read all data instead of a sample
+func (p *AnalysisPlan) FullScan() bool {
+	return p.SampleRows < 0
+}
+
+// PlanAnalysis resolves an analyze request into a plan. Options may override the
+// defaults with sample_rows (deep only), object_timeout and timeout, given either as
+// Go duration strings ("45s") or as seconds.
+func PlanAnalysis(req *AnalyzeRequest) (*AnalysisPlan, error) {
+	depth := req.Depth
+	if depth == "" {
+		depth = DefaultDepth
+	}
+	budget, ok := DefaultObjectBudgets[depth]
+	if !ok {
+		return nil, fmt.Errorf("unsupported analysis depth %q (expected %s, %s or %s)", depth, DepthShallow, DepthDeep, DepthComprehensive)
+	}
+
+	plan := &AnalysisPlan{Depth: depth, ObjectBudget: budget}
+	switch depth {
+	case DepthDeep:
+		plan.SampleRows = DefaultSampleRows
+	case DepthComprehensive:
+		plan.SampleRows = -1
+	}
+
+	if v, ok := req.Options["sample_rows"]; ok && depth == DepthDeep {
+		rows, ok := v.(float64)
+		if !ok || rows < 1 {
+			return nil, fmt.Errorf("sample_rows must be a positive number")
+		}
+		plan.SampleRows = int(rows)
+	}
+	if v, ok := req.Options["object_timeout"]; ok {
+		d, err := optionDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid object_timeout: %w", err)
+		}
+		plan.ObjectBudget = d
+	}
+	if v, ok := req.Options["timeout"]; ok {
+		d, err := optionDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout: %w", err)
+		}
+		plan.TotalBudget = d
+	}
+	return plan, nil
+}
+
+func optionDuration(v interface{}) (time.Duration, error) {
+	var d time.Duration
+	switch value := v.(type) {
+	case string:
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, err
+		}
+		d = parsed
+	case float64:
+		d = time.Duration(value * float64(time.Second))
+	default:
+		return 0, fmt.Errorf("expected duration string or seconds, got %T", v)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("duration must be positive")
+	}
+	return d, nil
+}
+
+// ObjectAnalyzer performs one kind of analysis on a single object. Implementations
+// should honour the plan's depth and row limit and return when ctx is done; whatever
+// they return is stored under their name in AnalysisResult.Analysis.
+type ObjectAnalyzer interface {
+	AnalyzeObject(ctx context.Context, obj *ObjectIdentifier, plan *AnalysisPlan) (map[string]interface{}, error)
+	Name() string
+}
+
+// RunAnalysis runs analyzers over every object in req within the plan's budgets.
+// Analyzers that exceed a budget are abandoned and reported in Skipped, and results
+// gathered before the budget ran out are kept, so a slow object yields a partial
+// result instead of failing the whole request.
+func RunAnalysis(ctx context.Context, req *AnalyzeRequest, analyzers []ObjectAnalyzer) (*AnalyzeResponse, error) {
+	plan, err := PlanAnalysis(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if plan.TotalBudget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, plan.TotalBudget)
+		defer cancel()
+	}
+
+	selected := selectAnalyzers(analyzers, req.AnalysisType)
+	start := time.Now()
+	resp := &AnalyzeResponse{Results: make([]*AnalysisResult, 0, len(req.Objects))}
+	partial := 0
+
+	for _, obj := range req.Objects {
+		result := analyzeObject(ctx, obj, plan, selected)
+		if result.Partial {
+			partial++
+		}
+		resp.Results = append(resp.Results, result)
+	}
+
+	resp.Partial = partial > 0
+	resp.Metadata = map[string]interface{}{
+		"depth":           plan.Depth,
+		"sample_rows":     plan.SampleRows,
+		"object_budget":   plan.ObjectBudget.String(),
+		"duration":        time.Since(start).String(),
+		"partial_objects": partial,
+	}
+	return resp, nil
+}
+
+// selectAnalyzers keeps the analyzers named in analysisTypes, or all when none are named
+func selectAnalyzers(analyzers []ObjectAnalyzer, analysisTypes []string) []ObjectAnalyzer {
+	if len(analysisTypes) == 0 {
+		return analyzers
+	}
+	wanted := make(map[string]bool, len(analysisTypes))
+	for _, t := range analysisTypes {
+		wanted[t] = true
+	}
+	var selected []ObjectAnalyzer
+	for _, a := range analyzers {
+		if wanted[a.Name()] {
+			selected = append(selected, a)
+		}
+	}
+	return selected
+}
+
+type analyzerOutcome struct {
+	data map[string]interface{}
+	err  error
+}
+
+// analyzeObject runs each analyzer in turn under the object's budget
+func analyzeObject(ctx context.Context, obj *ObjectIdentifier, plan *AnalysisPlan, analyzers []ObjectAnalyzer) *AnalysisResult {
+	start := time.Now()
+	result := &AnalysisResult{
+		Object:   obj,
+		Analysis: make(map[string]interface{}),
+		Depth:    plan.Depth,
+	}
+
+	objCtx, cancel := context.WithTimeout(ctx, plan.ObjectBudget)
+	defer cancel()
+
+	for _, analyzer := range analyzers {
+		if objCtx.Err() != nil {
+			result.Skipped = append(result.Skipped, analyzer.Name())
+			continue
+		}
+
+		// Run in a goroutine so an analyzer that ignores ctx cannot hold the request
+		// past its budget; an abandoned analyzer's late result is discarded
+		done := make(chan analyzerOutcome, 1)
+		go func(a ObjectAnalyzer) {
+			data, err := a.AnalyzeObject(objCtx, obj, plan)
+			done <- analyzerOutcome{data: data, err: err}
+		}(analyzer)
+
+		select {
+		case outcome := <-done:
+			if outcome.err != nil {
+				if objCtx.Err() != nil {
+					result.Skipped = append(result.Skipped, analyzer.Name())
+					continue
+				}
+				if result.Errors == nil {
+					result.Errors = make(map[string]string)
+				}
+				result.Errors[analyzer.Name()] = outcome.err.Error()
+				continue
+			}
+			if outcome.data != nil {
+				result.Analysis[analyzer.Name()] = outcome.data
+			}
+		case <-objCtx.Done():
+			result.Skipped = append(result.Skipped, analyzer.Name())
+		}
+	}
+
+	result.Partial = len(result.Skipped) > 0
+	result.Duration = time.Since(start).String()
+	result.Generated = time.Now().UTC().Format(time.RFC3339)
+	return result
+}
@@ -0,0 +1,87 @@
+package discover
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type testAnalyzer struct {
+	name  string
+	delay time.Duration
+}
+
+func (a *testAnalyzer) Name() string { return a.name }
+
+func (a *testAnalyzer) AnalyzeObject(ctx context.Context, obj *ObjectIdentifier, plan *AnalysisPlan) (map[string]interface{}, error) {
+	select {
+	case <-time.After(a.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return map[string]interface{}{"rows_inspected": plan.SampleRows}, nil
+}
+
+// TestPlanAnalysis validates depth defaults and option overrides
+func TestPlanAnalysis(t *testing.T) {
+	plan, err := PlanAnalysis(&AnalyzeRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Depth != DepthShallow || plan.InspectsData() {
+		t.Errorf("expected shallow metadata-only default, got %+v", plan)
+	}
+
+	plan, err = PlanAnalysis(&AnalyzeRequest{Depth: DepthDeep, Options: map[string]interface{}{
+		"sample_rows":    float64(50),
+		"object_timeout": "2s",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.SampleRows != 50 || plan.ObjectBudget != 2*time.Second || plan.FullScan() {
+		t.Errorf("unexpected deep plan %+v", plan)
+	}
+
+	plan, err = PlanAnalysis(&AnalyzeRequest{Depth: DepthComprehensive})
+	if err != nil || !plan.FullScan() {
+		t.Errorf("expected comprehensive full scan, got %+v (%v)", plan, err)
+	}
+
+	if _, err := PlanAnalysis(&AnalyzeRequest{Depth: "exhaustive"}); err == nil {
+		t.Errorf("expected unsupported depth error")
+	}
+}
+
+// TestRunAnalysisBudget validates partial results when an object exceeds its budget
+func TestRunAnalysisBudget(t *testing.T) {
+	analyzers := []ObjectAnalyzer{
+		&testAnalyzer{name: "stats"},
+		&testAnalyzer{name: "profile", delay: time.Second},
+		&testAnalyzer{name: "pii"},
+	}
+	req := &AnalyzeRequest{
+		Depth:   DepthDeep,
+		Objects: []*ObjectIdentifier{{Name: "users", Type: "table"}},
+		Options: map[string]interface{}{"object_timeout": "50ms"},
+	}
+
+	resp, err := RunAnalysis(context.Background(), req, analyzers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Partial || len(resp.Results) != 1 {
+		t.Fatalf("expected one partial result, got %+v", resp)
+	}
+
+	result := resp.Results[0]
+	if _, ok := result.Analysis["stats"]; !ok {
+		t.Errorf("expected stats collected before the budget ran out")
+	}
+	if len(result.Skipped) != 2 || result.Skipped[0] != "profile" || result.Skipped[1] != "pii" {
+		t.Errorf("expected profile and pii skipped, got %v", result.Skipped)
+	}
+	if result.Depth != DepthDeep {
+		t.Errorf("expected depth on result, got %q", result.Depth)
+	}
+}
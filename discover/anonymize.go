@@ -0,0 +1,30 @@
+// Package discover provides anonymization of scan responses for sharing diagnostics
+package discover
+
+import (
+	"encoding/json"
+
+	"github.com/schemabounce/kolumn/sdk/helpers/anonymize"
+)
+
+// AnonymizeScanResponse returns a copy of a scan response whose names, IDs, locations,
+// properties and warnings are replaced by the anonymizer's stable pseudonyms. Object
+// types, categories, systems, counts and the relationships between objects are kept,
+// so the copy can be attached to a bug report.
+func AnonymizeScanResponse(resp *ScanResponse, a *anonymize.Anonymizer) (*ScanResponse, error) {
+	if resp == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return nil, err
+	}
+	if data, err = a.JSON(data); err != nil {
+		return nil, err
+	}
+	var out ScanResponse
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
@@ -0,0 +1,402 @@
+// Package discover provides generation of Kolumn configuration from discovered objects
+package discover
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+	"github.com/schemabounce/kolumn/sdk/helpers/hcl"
+	"github.com/schemabounce/kolumn/sdk/types"
+)
+
+// ConfigFileExtension is the extension of generated configuration files
+const ConfigFileExtension = ".kl"
+
+// DefaultExcludedProperties are discovered properties that describe the live object
+// rather than its configuration, so they are left out of generated blocks
+var DefaultExcludedProperties = []string{
+	"id", "oid", "*_id", "created_at", "updated_at", "last_*", "row_count", "size_bytes",
+	"stats", "stats.*", "owner",
+}
+
+// ClassificationRule suggests a classification for columns whose name contains any of
+// its patterns
+type ClassificationRule struct {
+	Classification string
+	Patterns       []string
+}
+
+// DefaultClassificationRules suggest classifications from common column names
+var DefaultClassificationRules = []ClassificationRule{
+	{Classification: "secret", Patterns: []string{"password", "secret", "token", "api_key", "private_key"}},
+	{Classification: "financial", Patterns: []string{"credit_card", "card_number", "iban", "account_number", "salary"}},
+	{Classification: "pii", Patterns: []string{"email", "phone", "ssn", "social_security", "first_name", "last_name",
+		"full_name", "birth", "dob", "address", "postal_code", "zip", "passport", "ip_addr"}},
+}
+
+// ClassificationSuggestion proposes a classification for one column of a generated resource
+type ClassificationSuggestion struct {
+	Column         string `json:"column"`
+	Classification string `json:"classification"`
+	Pattern        string `json:"pattern"` // the rule pattern the column name matched
+}
+
+// GeneratedResource is one block of a generated file
+type GeneratedResource struct {
+	Address      string                     `json:"address"`
+	DiscoveredID string                     `json:"discovered_id"`
+	DependsOn    []string                   `json:"depends_on,omitempty"`
+	Suggestions  []ClassificationSuggestion `json:"suggestions,omitempty"`
+}
+
+// GeneratedFile is one configuration file holding the blocks of a group
+type GeneratedFile struct {
+	Path      string              `json:"path"` // relative, e.g. public.kl
+	Group     string              `json:"group"`
+	Content   []byte              `json:"-"`
+	Resources []GeneratedResource `json:"resources"`
+}
+
+// CodegenOptions controls GenerateConfig
+type CodegenOptions struct {
+	// TypeMapping maps discovered object types to resource types; unmapped types are
+	// used as they are
+	TypeMapping map[string]string
+
+	// Schemas restricts the attributes of a resource type to its declared properties
+	Schemas map[string]*core.ObjectType
+
+	// ExcludeProperties are path.Match patterns of properties to leave out in addition
+	// to DefaultExcludedProperties
+	ExcludeProperties []string
+
+	// GroupBy names the file an object goes to; defaults to its schema, topic or
+	// database property, then its source location, then its type
+	GroupBy func(*DiscoveredObject) string
+
+	// ClassificationRules replace DefaultClassificationRules when set
+	ClassificationRules []ClassificationRule
+
+	// DependsOn returns the explicit depends_on entries of a block from its resource
+	// and configuration attributes, typically the addresses returned by
+	// state.DependencyManager.ExplicitDependencies. Entries are written as bare
+	// references, so they must be resource addresses.
+	DependsOn func(resource GeneratedResource, values map[string]interface{}) []string
+}
+
+// GenerateConfig converts discovered objects into ready-to-commit Kolumn configuration,
+// one file per group. Every object becomes a create block with its configuration
+// attributes, preceded by the command importing it and any classification suggestions
+// for its columns. Files and blocks are ordered by name so regenerating is stable.
+func GenerateConfig(objects []*DiscoveredObject, opts CodegenOptions) ([]*GeneratedFile, error) {
+	groupBy := opts.GroupBy
+	if groupBy == nil {
+		groupBy = DefaultGroup
+	}
+	rules := opts.ClassificationRules
+	if rules == nil {
+		rules = DefaultClassificationRules
+	}
+	excluded := append(append([]string(nil), DefaultExcludedProperties...), opts.ExcludeProperties...)
+
+	groups := make(map[string][]*DiscoveredObject)
+	for _, obj := range objects {
+		if obj == nil {
+			continue
+		}
+		if obj.Type == "" || (obj.Name == "" && obj.ID == "") {
+			return nil, fmt.Errorf("discovered object %q needs a type and a name or ID", obj.ID)
+		}
+		group := groupBy(obj)
+		groups[group] = append(groups[group], obj)
+	}
+
+	groupNames := make([]string, 0, len(groups))
+	for group := range groups {
+		groupNames = append(groupNames, group)
+	}
+	sort.Strings(groupNames)
+
+	files := make([]*GeneratedFile, 0, len(groups))
+	paths := make(map[string]bool, len(groups))
+	for _, group := range groupNames {
+		members := groups[group]
+		sort.SliceStable(members, func(i, j int) bool {
+			if members[i].Type != members[j].Type {
+				return members[i].Type < members[j].Type
+			}
+			return members[i].Name < members[j].Name
+		})
+
+		file := &GeneratedFile{Path: uniqueIdentifier(group, "", paths) + ConfigFileExtension, Group: group}
+		var src strings.Builder
+		fmt.Fprintf(&src, "# Generated by kolumn discover from %s. Review before applying.\n", commentText(group))
+		used := make(map[string]bool)
+		for _, obj := range members {
+			resourceType := obj.Type
+			if mapped, ok := opts.TypeMapping[obj.Type]; ok {
+				resourceType = mapped
+			}
+			name := uniqueIdentifier(objectName(obj), resourceType, used)
+			address := types.Address{Type: resourceType, Name: name}.String()
+			resource := GeneratedResource{
+				Address:      address,
+				DiscoveredID: obj.ID,
+				Suggestions:  SuggestClassifications(obj, rules),
+			}
+
+			src.WriteString("\n")
+			if obj.ID != "" {
+				fmt.Fprintf(&src, "# kolumn import %s %s\n", commentText(address), commentText(obj.ID))
+			}
+			for _, s := range resource.Suggestions {
+				fmt.Fprintf(&src, "# suggested classification for %s: %s (name contains %q)\n",
+					commentText(s.Column), commentText(s.Classification), s.Pattern)
+			}
+			values := configAttributes(obj.Properties, opts.Schemas[resourceType], excluded)
+			block := core.RenderExampleHCL("create", resourceType, name, values)
+			if opts.DependsOn != nil {
+				resource.DependsOn = opts.DependsOn(resource, values)
+				block = withDependsOn(block, resource.DependsOn, len(values) > 0)
+			}
+			src.WriteString(block)
+			src.WriteString("\n")
+			file.Resources = append(file.Resources, resource)
+		}
+
+		content, err := hcl.Format([]byte(src.String()))
+		if err != nil {
+			return nil, fmt.Errorf("generate %s: %w", file.Path, err)
+		}
+		file.Content = content
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// withDependsOn adds a depends_on meta-argument as the first line of a rendered block,
+// separated from the attributes that follow
+func withDependsOn(block string, targets []string, hasAttributes bool) string {
+	if len(targets) == 0 {
+		return block
+	}
+	header, body, _ := strings.Cut(block, "\n")
+	line := "  depends_on = [" + strings.Join(targets, ", ") + "]\n"
+	if hasAttributes {
+		line += "\n"
+	}
+	return header + "\n" + line + body
+}
+
+// WriteGeneratedFiles writes generated files into dir. Existing files are only replaced
+// when overwrite is set, so hand edits are not lost by accident. Two files with the same
+// path are rejected rather than one silently replacing the other.
+func WriteGeneratedFiles(dir string, files []*GeneratedFile, overwrite bool) error {
+	paths := make(map[string]string, len(files))
+	for _, file := range files {
+		if group, ok := paths[file.Path]; ok {
+			return fmt.Errorf("groups %q and %q both generate %s", group, file.Group, file.Path)
+		}
+		paths[file.Path] = file.Group
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for _, file := range files {
+		target := filepath.Join(dir, file.Path)
+		if !overwrite {
+			if _, err := os.Stat(target); err == nil {
+				return fmt.Errorf("%s already exists", target)
+			}
+		}
+		if err := os.WriteFile(target, file.Content, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DefaultGroup groups objects by schema, topic or database, then source location
+func DefaultGroup(obj *DiscoveredObject) string {
+	for _, key := range []string{"schema", "topic", "database"} {
+		if v, ok := obj.Properties[key].(string); ok && v != "" {
+			return v
+		}
+	}
+	if obj.Source != nil && obj.Source.Location != "" {
+		return obj.Source.Location
+	}
+	return obj.Type
+}
+
+// SuggestClassifications matches the object's column names against rules. Columns are
+// read from its columns or fields property; the first matching rule wins per column.
+func SuggestClassifications(obj *DiscoveredObject, rules []ClassificationRule) []ClassificationSuggestion {
+	var suggestions []ClassificationSuggestion
+	for _, column := range columnNames(obj.Properties) {
+		lower := strings.ToLower(column)
+	rules:
+		for _, rule := range rules {
+			for _, pattern := range rule.Patterns {
+				if strings.Contains(lower, pattern) {
+					suggestions = append(suggestions, ClassificationSuggestion{Column: column, Classification: rule.Classification, Pattern: pattern})
+					break rules
+				}
+			}
+		}
+	}
+	return suggestions
+}
+
+// columnNames returns the names of the columns or fields an object declares
+func columnNames(properties map[string]interface{}) []string {
+	var names []string
+	for _, key := range []string{"columns", "fields"} {
+		switch list := properties[key].(type) {
+		case []interface{}:
+			for _, item := range list {
+				if m, ok := item.(map[string]interface{}); ok {
+					if name, ok := m["name"].(string); ok {
+						names = append(names, name)
+					}
+				}
+			}
+		case []map[string]interface{}:
+			for _, m := range list {
+				if name, ok := m["name"].(string); ok {
+					names = append(names, name)
+				}
+			}
+		}
+	}
+	return names
+}
+
+// configAttributes keeps the properties that belong in configuration
+func configAttributes(properties map[string]interface{}, schema *core.ObjectType, excluded []string) map[string]interface{} {
+	values := make(map[string]interface{}, len(properties))
+	for key, value := range properties {
+		if value == nil || excludedProperty(key, excluded) {
+			continue
+		}
+		if schema != nil && len(schema.Properties) > 0 {
+			if _, declared := schema.Properties[key]; !declared {
+				continue
+			}
+		}
+		values[key] = hclValue(value)
+	}
+	return values
+}
+
+func excludedProperty(key string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// hclValue converts discovered values to the types RenderExampleHCL writes
+func hclValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case []map[string]interface{}:
+		items := make([]interface{}, len(v))
+		for i, m := range v {
+			items[i] = hclValue(m)
+		}
+		return items
+	case []string:
+		items := make([]interface{}, len(v))
+		for i, s := range v {
+			items[i] = s
+		}
+		return items
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = hclValue(item)
+		}
+		return items
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			if item != nil {
+				m[key] = hclValue(item)
+			}
+		}
+		return m
+	case map[string]string:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[key] = item
+		}
+		return m
+	default:
+		return v
+	}
+}
+
+func objectName(obj *DiscoveredObject) string {
+	if obj.Name != "" {
+		return obj.Name
+	}
+	return obj.ID
+}
+
+var nonIdentifier = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// identifier turns a discovered name into a block label or file name
+func identifier(name string) string {
+	id := strings.Trim(nonIdentifier.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if id == "" {
+		return "unnamed"
+	}
+	if id[0] >= '0' && id[0] <= '9' {
+		id = "_" + id
+	}
+	return id
+}
+
+// commentText escapes control characters so a discovered value cannot end the comment
+// line it is written into and inject configuration
+func commentText(s string) string {
+	if strings.IndexFunc(s, unicode.IsControl) < 0 {
+		return s
+	}
+	var b strings.Builder
+	for _, r := range s {
+		if unicode.IsControl(r) {
+			quoted := strconv.QuoteRune(r)
+			b.WriteString(quoted[1 : len(quoted)-1])
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// uniqueIdentifier suffixes names that collide within a file, or file names that
+// collide once groups differing only in case or punctuation are turned into identifiers
+func uniqueIdentifier(name, resourceType string, used map[string]bool) string {
+	base := identifier(name)
+	id := base
+	for i := 2; used[resourceType+"."+id]; i++ {
+		id = fmt.Sprintf("%s_%d", base, i)
+	}
+	used[resourceType+"."+id] = true
+	return id
+}
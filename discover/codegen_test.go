@@ -0,0 +1,192 @@
+package discover
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+	"github.com/schemabounce/kolumn/sdk/helpers/hcl"
+)
+
+func codegenObjects() []*DiscoveredObject {
+	return []*DiscoveredObject{
+		{ID: "db.public.users", Name: "users", Type: "table", Properties: map[string]interface{}{
+			"schema":    "public",
+			"comment":   "Registered accounts",
+			"row_count": 1200,
+			"columns": []interface{}{
+				map[string]interface{}{"name": "id", "type": "bigint"},
+				map[string]interface{}{"name": "email", "type": "text"},
+				map[string]interface{}{"name": "password_hash", "type": "text"},
+			},
+		}},
+		{ID: "db.public.orders", Name: "Orders 2024", Type: "table", Properties: map[string]interface{}{
+			"schema":     "public",
+			"created_at": "2024-01-01",
+			"columns":    []map[string]interface{}{{"name": "total", "type": "numeric"}},
+		}},
+		{ID: "events", Name: "events", Type: "topic", Properties: map[string]interface{}{"partitions": 12},
+			Source: &Source{System: "kafka", Location: "cluster-a"}},
+	}
+}
+
+// TestGenerateConfig validates discovered objects become grouped, parseable configuration
+func TestGenerateConfig(t *testing.T) {
+	files, err := GenerateConfig(codegenObjects(), CodegenOptions{TypeMapping: map[string]string{"table": "postgres_table", "topic": "kafka_topic"}})
+	if err != nil {
+		t.Fatalf("GenerateConfig: %v", err)
+	}
+	if len(files) != 2 || files[0].Path != "cluster_a.kl" || files[1].Path != "public.kl" {
+		t.Fatalf("unexpected files: %+v", files)
+	}
+
+	public := files[1]
+	parsed, err := hcl.Parse(public.Content, public.Path)
+	if err != nil {
+		t.Fatalf("generated config does not parse: %v\n%s", err, public.Content)
+	}
+	blocks := parsed.CreateBlocks()
+	if len(blocks) != 2 || blocks[0].Address() != "postgres_table.orders_2024" || blocks[1].Address() != "postgres_table.users" {
+		t.Fatalf("unexpected blocks in\n%s", public.Content)
+	}
+	users := blocks[1].Body()
+	if users["comment"] != "Registered accounts" || users["schema"] != "public" {
+		t.Errorf("configuration attributes missing: %v", users)
+	}
+	if _, ok := users["row_count"]; ok {
+		t.Error("live statistics should be excluded")
+	}
+	if _, ok := blocks[0].Body()["created_at"]; ok {
+		t.Error("timestamps should be excluded")
+	}
+
+	content := string(public.Content)
+	for _, want := range []string{
+		"# kolumn import postgres_table.users db.public.users",
+		`# suggested classification for email: pii (name contains "email")`,
+		`# suggested classification for password_hash: secret (name contains "password")`,
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("missing %q in\n%s", want, content)
+		}
+	}
+	if got := public.Resources[1].Suggestions; len(got) != 2 || got[0].Column != "email" {
+		t.Errorf("suggestions = %+v", got)
+	}
+	if formatted, err := hcl.IsFormatted(public.Content, hcl.FormatOptions{}); err != nil || !formatted {
+		t.Errorf("generated config is not formatted: %v", err)
+	}
+}
+
+// TestGenerateConfigSchemaAndWrite validates schema filtering and that existing files are kept
+func TestGenerateConfigSchemaAndWrite(t *testing.T) {
+	schemas := map[string]*core.ObjectType{"table": {Name: "table", Properties: map[string]*core.Property{"schema": {Type: "string"}}}}
+	files, err := GenerateConfig(codegenObjects()[:1], CodegenOptions{Schemas: schemas})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := hcl.Parse(files[0].Content, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := parsed.CreateBlocks()[0].AttributeNames(); len(names) != 1 || names[0] != "schema" {
+		t.Errorf("attributes should be limited to the schema, got %v", names)
+	}
+
+	dir := t.TempDir()
+	if err := WriteGeneratedFiles(dir, files, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "public.kl")); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteGeneratedFiles(dir, files, false); err == nil {
+		t.Error("existing files should not be overwritten without overwrite")
+	}
+	if err := WriteGeneratedFiles(dir, files, true); err != nil {
+		t.Errorf("overwrite: %v", err)
+	}
+
+	if _, err := GenerateConfig([]*DiscoveredObject{{Name: "x"}}, CodegenOptions{}); err == nil {
+		t.Error("objects without a type should be rejected")
+	}
+}
+
+// TestGenerateConfigDependsOn validates explicit dependencies are written as a depends_on meta-argument
+func TestGenerateConfigDependsOn(t *testing.T) {
+	opts := CodegenOptions{
+		TypeMapping: map[string]string{"table": "postgres_table"},
+		DependsOn: func(resource GeneratedResource, values map[string]interface{}) []string {
+			if resource.Address == "postgres_table.users" {
+				return []string{"postgres_table.orders_2024"}
+			}
+			return nil
+		},
+	}
+	files, err := GenerateConfig(codegenObjects(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	public := files[1]
+	parsed, err := hcl.Parse(public.Content, public.Path)
+	if err != nil {
+		t.Fatalf("generated config does not parse: %v\n%s", err, public.Content)
+	}
+	blocks := parsed.CreateBlocks()
+	refs := blocks[1].References()
+	if len(refs) != 1 || refs[0].String() != "postgres_table.orders_2024" {
+		t.Errorf("depends_on references = %v in\n%s", refs, public.Content)
+	}
+	if _, ok := blocks[0].Attributes["depends_on"]; ok {
+		t.Error("blocks without dependencies should not declare depends_on")
+	}
+	if got := public.Resources[1].DependsOn; len(got) != 1 {
+		t.Errorf("generated resource depends_on = %v", got)
+	}
+	if formatted, err := hcl.IsFormatted(public.Content, hcl.FormatOptions{}); err != nil || !formatted {
+		t.Errorf("generated config is not formatted: %v\n%s", err, public.Content)
+	}
+}
+
+// TestGenerateConfigEscapesComments validates discovered values cannot inject blocks
+// through comment lines and that groups differing in case get their own files
+func TestGenerateConfigEscapesComments(t *testing.T) {
+	objects := []*DiscoveredObject{
+		{ID: "t\nresource \"role\" \"pwn\" {\n}", Name: "t", Type: "table", Properties: map[string]interface{}{
+			"schema":  "Sales",
+			"columns": []interface{}{map[string]interface{}{"name": "email\ncreate \"role\" \"x\" {}"}},
+		}},
+		{ID: "t2", Name: "t2", Type: "table", Properties: map[string]interface{}{"schema": "sales"}},
+		{ID: "t3", Name: "t3", Type: "table", Properties: map[string]interface{}{"schema": "Sales\ncreate"}},
+	}
+	files, err := GenerateConfig(objects, CodegenOptions{})
+	if err != nil {
+		t.Fatalf("GenerateConfig: %v", err)
+	}
+	if len(files) != 3 || files[0].Path != "sales.kl" || files[1].Path != "sales_create.kl" || files[2].Path != "sales_2.kl" {
+		t.Fatalf("expected distinct paths, got %s, %s, %s", files[0].Path, files[1].Path, files[2].Path)
+	}
+
+	for _, file := range files {
+		parsed, err := hcl.Parse(file.Content, file.Path)
+		if err != nil {
+			t.Fatalf("generated config does not parse: %v\n%s", err, file.Content)
+		}
+		if blocks := parsed.CreateBlocks(); len(blocks) != 1 || blocks[0].Address() == "role.pwn" {
+			t.Errorf("expected one table block in %s, got\n%s", file.Path, file.Content)
+		}
+		if strings.Contains(string(file.Content), "\nresource") || strings.Contains(string(file.Content), "\ncreate \"role\"") {
+			t.Errorf("discovered values escaped their comment in\n%s", file.Content)
+		}
+	}
+	if !strings.Contains(string(files[0].Content), `# kolumn import table.t t\nresource "role" "pwn" {\n}`) {
+		t.Errorf("expected the escaped ID in\n%s", files[0].Content)
+	}
+
+	files[2].Path = files[0].Path
+	if err := WriteGeneratedFiles(t.TempDir(), files, true); err == nil {
+		t.Error("files sharing a path should be rejected")
+	}
+}
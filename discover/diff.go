@@ -0,0 +1,363 @@
+// Package discover provides differential reports between two discovery scans
+package discover
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// Property change kinds
+const (
+	PropertyAdded    = "added"
+	PropertyRemoved  = "removed"
+	PropertyModified = "modified"
+)
+
+// ScanSnapshot is a ScanResponse persisted with the time it was taken
+type ScanSnapshot struct {
+	CapturedAt time.Time     `json:"captured_at"`
+	Label      string        `json:"label,omitempty"`
+	Response   *ScanResponse `json:"response"`
+}
+
+// DiffOptions tunes how scans are compared
+type DiffOptions struct {
+	// IgnoreProperties lists property paths (path.Match patterns such as "stats.*"
+	// or "last_analyzed") whose changes are not reported
+	IgnoreProperties []string
+
+	// IgnoreTags skips tag changes
+	IgnoreTags bool
+
+	// KeyFunc identifies the same object across scans; defaults to type/ID, falling
+	// back to type/location/name when the ID is empty
+	KeyFunc func(*DiscoveredObject) string
+}
+
+// PropertyDiff is a single property-level change
+type PropertyDiff struct {
+	Path   string      `json:"path"` // e.g. "columns[2].type" or "tags.owner"
+	Kind   string      `json:"kind"` // added, removed, modified
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+// ObjectChange is an object present in both scans whose properties differ
+type ObjectChange struct {
+	Key     string            `json:"key"`
+	Before  *DiscoveredObject `json:"before"`
+	After   *DiscoveredObject `json:"after"`
+	Changes []PropertyDiff    `json:"changes"`
+}
+
+// DiffCounts counts added, removed, changed and unchanged objects
+type DiffCounts struct {
+	Added     int `json:"added"`
+	Removed   int `json:"removed"`
+	Changed   int `json:"changed"`
+	Unchanged int `json:"unchanged"`
+}
+
+// ScanDiff is the difference between a baseline scan and a current scan
+type ScanDiff struct {
+	BaselineAt time.Time              `json:"baseline_at,omitempty"`
+	CurrentAt  time.Time              `json:"current_at,omitempty"`
+	Added      []*DiscoveredObject    `json:"added"`
+	Removed    []*DiscoveredObject    `json:"removed"`
+	Changed    []*ObjectChange        `json:"changed"`
+	Totals     DiffCounts             `json:"totals"`
+	ByType     map[string]*DiffCounts `json:"by_type"`
+}
+
+// HasChanges reports whether anything was added, removed or changed
+func (d *ScanDiff) HasChanges() bool {
+	return len(d.Added) > 0 || len(d.Removed) > 0 || len(d.Changed) > 0
+}
+
+// DiffScans compares a baseline scan with a current scan
+func DiffScans(baseline, current *ScanResponse, opts *DiffOptions) *ScanDiff {
+	if opts == nil {
+		opts = &DiffOptions{}
+	}
+	keyFunc := opts.KeyFunc
+	if keyFunc == nil {
+		keyFunc = DefaultObjectKey
+	}
+
+	before := indexObjects(baseline, keyFunc)
+	after := indexObjects(current, keyFunc)
+
+	diff := &ScanDiff{
+		Added:   []*DiscoveredObject{},
+		Removed: []*DiscoveredObject{},
+		Changed: []*ObjectChange{},
+		ByType:  make(map[string]*DiffCounts),
+	}
+	counts := func(objectType string) *DiffCounts {
+		c, ok := diff.ByType[objectType]
+		if !ok {
+			c = &DiffCounts{}
+			diff.ByType[objectType] = c
+		}
+		return c
+	}
+
+	for _, key := range sortedKeys(after) {
+		obj := after[key]
+		old, existed := before[key]
+		if !existed {
+			diff.Added = append(diff.Added, obj)
+			counts(obj.Type).Added++
+			continue
+		}
+
+		changes := diffObject(old, obj, opts)
+		if len(changes) == 0 {
+			counts(obj.Type).Unchanged++
+			continue
+		}
+		diff.Changed = append(diff.Changed, &ObjectChange{Key: key, Before: old, After: obj, Changes: changes})
+		counts(obj.Type).Changed++
+	}
+	for _, key := range sortedKeys(before) {
+		if _, exists := after[key]; !exists {
+			obj := before[key]
+			diff.Removed = append(diff.Removed, obj)
+			counts(obj.Type).Removed++
+		}
+	}
+
+	for _, c := range diff.ByType {
+		diff.Totals.Added += c.Added
+		diff.Totals.Removed += c.Removed
+		diff.Totals.Changed += c.Changed
+		diff.Totals.Unchanged += c.Unchanged
+	}
+	return diff
+}
+
+// DiffSnapshots compares two persisted scans
+func DiffSnapshots(baseline, current *ScanSnapshot, opts *DiffOptions) *ScanDiff {
+	diff := DiffScans(baseline.Response, current.Response, opts)
+	diff.BaselineAt = baseline.CapturedAt
+	diff.CurrentAt = current.CapturedAt
+	return diff
+}
+
+// DefaultObjectKey identifies an object by type and ID, or by type, location and name
+func DefaultObjectKey(obj *DiscoveredObject) string {
+	if obj.ID != "" {
+		return obj.Type + "/" + obj.ID
+	}
+	location := ""
+	if obj.Source != nil {
+		location = obj.Source.Location
+	}
+	return obj.Type + "/" + location + "/" + obj.Name
+}
+
+func indexObjects(resp *ScanResponse, keyFunc func(*DiscoveredObject) string) map[string]*DiscoveredObject {
+	index := make(map[string]*DiscoveredObject)
+	if resp == nil {
+		return index
+	}
+	for _, obj := range resp.Objects {
+		if obj != nil {
+			index[keyFunc(obj)] = obj
+		}
+	}
+	return index
+}
+
+func sortedKeys(m map[string]*DiscoveredObject) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// diffObject compares the name, properties and tags of two versions of an object
+func diffObject(before, after *DiscoveredObject, opts *DiffOptions) []PropertyDiff {
+	var changes []PropertyDiff
+	if before.Name != after.Name {
+		changes = append(changes, PropertyDiff{Path: "name", Kind: PropertyModified, Before: before.Name, After: after.Name})
+	}
+
+	diffValues("", normalizeMap(before.Properties), normalizeMap(after.Properties), &changes)
+
+	if !opts.IgnoreTags {
+		diffValues("tags", normalizeMap(before.Tags), normalizeMap(after.Tags), &changes)
+	}
+
+	if len(opts.IgnoreProperties) == 0 {
+		return changes
+	}
+	filtered := changes[:0]
+	for _, c := range changes {
+		if !ignored(c.Path, opts.IgnoreProperties) {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered
+}
+
+// diffValues recursively compares JSON-shaped values
+func diffValues(prefix string, before, after interface{}, changes *[]PropertyDiff) {
+	beforeMap, beforeIsMap := before.(map[string]interface{})
+	afterMap, afterIsMap := after.(map[string]interface{})
+	if beforeIsMap && afterIsMap {
+		keys := make(map[string]bool)
+		for k := range beforeMap {
+			keys[k] = true
+		}
+		for k := range afterMap {
+			keys[k] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+
+		for _, k := range sorted {
+			p := k
+			if prefix != "" {
+				p = prefix + "." + k
+			}
+			b, inBefore := beforeMap[k]
+			a, inAfter := afterMap[k]
+			switch {
+			case !inBefore:
+				*changes = append(*changes, PropertyDiff{Path: p, Kind: PropertyAdded, After: a})
+			case !inAfter:
+				*changes = append(*changes, PropertyDiff{Path: p, Kind: PropertyRemoved, Before: b})
+			default:
+				diffValues(p, b, a, changes)
+			}
+		}
+		return
+	}
+
+	beforeList, beforeIsList := before.([]interface{})
+	afterList, afterIsList := after.([]interface{})
+	if beforeIsList && afterIsList {
+		for i := 0; i < len(beforeList) || i < len(afterList); i++ {
+			p := fmt.Sprintf("%s[%d]", prefix, i)
+			switch {
+			case i >= len(beforeList):
+				*changes = append(*changes, PropertyDiff{Path: p, Kind: PropertyAdded, After: afterList[i]})
+			case i >= len(afterList):
+				*changes = append(*changes, PropertyDiff{Path: p, Kind: PropertyRemoved, Before: beforeList[i]})
+			default:
+				diffValues(p, beforeList[i], afterList[i], changes)
+			}
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(before, after) {
+		*changes = append(*changes, PropertyDiff{Path: prefix, Kind: PropertyModified, Before: before, After: after})
+	}
+}
+
+// normalize round-trips a value through JSON so typed maps, slices and numbers compare
+// the same way regardless of how the handler built them
+func normalize(v interface{}) interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return v
+	}
+	return out
+}
+
+// normalizeMap normalizes a top-level map, treating nil as empty so that a first tag or
+// property is reported per key
+func normalizeMap(v interface{}) interface{} {
+	if out, ok := normalize(v).(map[string]interface{}); ok {
+		return out
+	}
+	return map[string]interface{}{}
+}
+
+func ignored(p string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if pattern == p {
+			return true
+		}
+		if matched, err := path.Match(pattern, p); err == nil && matched {
+			return true
+		}
+		// A pattern covers everything nested beneath it
+		if len(p) > len(pattern) && p[:len(pattern)] == pattern && (p[len(pattern)] == '.' || p[len(pattern)] == '[') {
+			return true
+		}
+	}
+	return false
+}
+
+// SaveSnapshot writes a scan to path so later scans can be compared against it
+func SaveSnapshot(filePath string, resp *ScanResponse, label string) error {
+	snapshot := &ScanSnapshot{CapturedAt: time.Now().UTC(), Label: label, Response: resp}
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	if err := os.WriteFile(filePath, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return nil
+}
+
+// LoadSnapshot reads a snapshot written by SaveSnapshot
+func LoadSnapshot(filePath string) (*ScanSnapshot, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	var snapshot ScanSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot %s: %w", filePath, err)
+	}
+	return &snapshot, nil
+}
+
+// WriteText renders a human-readable change report
+func (d *ScanDiff) WriteText(w io.Writer) {
+	if !d.BaselineAt.IsZero() {
+		fmt.Fprintf(w, "Changes since %s\n", d.BaselineAt.Format(time.RFC3339))
+	}
+	fmt.Fprintf(w, "%d added, %d removed, %d changed, %d unchanged\n",
+		d.Totals.Added, d.Totals.Removed, d.Totals.Changed, d.Totals.Unchanged)
+
+	for _, obj := range d.Added {
+		fmt.Fprintf(w, "  + %s %s\n", obj.Type, obj.Name)
+	}
+	for _, obj := range d.Removed {
+		fmt.Fprintf(w, "  - %s %s\n", obj.Type, obj.Name)
+	}
+	for _, change := range d.Changed {
+		fmt.Fprintf(w, "  ~ %s %s\n", change.After.Type, change.After.Name)
+		for _, c := range change.Changes {
+			switch c.Kind {
+			case PropertyAdded:
+				fmt.Fprintf(w, "      + %s = %v\n", c.Path, c.After)
+			case PropertyRemoved:
+				fmt.Fprintf(w, "      - %s (was %v)\n", c.Path, c.Before)
+			default:
+				fmt.Fprintf(w, "      ~ %s: %v -> %v\n", c.Path, c.Before, c.After)
+			}
+		}
+	}
+}
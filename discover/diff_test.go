@@ -0,0 +1,95 @@
+package discover
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func diffTestScan(objects ...*DiscoveredObject) *ScanResponse {
+	return &ScanResponse{Objects: objects}
+}
+
+// TestDiffScans validates added, removed and property-level changes
+func TestDiffScans(t *testing.T) {
+	baseline := diffTestScan(
+		&DiscoveredObject{ID: "1", Name: "users", Type: "table", Properties: map[string]interface{}{
+			"columns":       []interface{}{map[string]interface{}{"name": "id", "type": "int"}},
+			"row_count":     100,
+			"last_analyzed": "2026-10-01",
+		}},
+		&DiscoveredObject{ID: "2", Name: "orders", Type: "table"},
+		&DiscoveredObject{ID: "3", Name: "v_users", Type: "view"},
+	)
+	current := diffTestScan(
+		&DiscoveredObject{ID: "1", Name: "users", Type: "table", Properties: map[string]interface{}{
+			"columns": []map[string]interface{}{
+				{"name": "id", "type": "bigint"},
+				{"name": "email", "type": "text"},
+			},
+			"row_count":     100,
+			"last_analyzed": "2026-10-08",
+		}, Tags: map[string]string{"owner": "data"}},
+		&DiscoveredObject{ID: "3", Name: "v_users", Type: "view"},
+		&DiscoveredObject{ID: "4", Name: "payments", Type: "table"},
+	)
+
+	diff := DiffScans(baseline, current, &DiffOptions{IgnoreProperties: []string{"last_analyzed"}})
+
+	if len(diff.Added) != 1 || diff.Added[0].Name != "payments" {
+		t.Errorf("expected payments added, got %+v", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0].Name != "orders" {
+		t.Errorf("expected orders removed, got %+v", diff.Removed)
+	}
+	if len(diff.Changed) != 1 {
+		t.Fatalf("expected 1 changed object, got %d", len(diff.Changed))
+	}
+
+	paths := map[string]string{}
+	for _, c := range diff.Changed[0].Changes {
+		paths[c.Path] = c.Kind
+	}
+	expected := map[string]string{
+		"columns[0].type": PropertyModified,
+		"columns[1]":      PropertyAdded,
+		"tags.owner":      PropertyAdded,
+	}
+	for p, kind := range expected {
+		if paths[p] != kind {
+			t.Errorf("expected %s to be %s, got %q (all: %v)", p, kind, paths[p], paths)
+		}
+	}
+	if _, ok := paths["last_analyzed"]; ok {
+		t.Errorf("expected last_analyzed to be ignored")
+	}
+	if diff.ByType["table"].Added != 1 || diff.ByType["view"].Unchanged != 1 {
+		t.Errorf("unexpected per-type counts: table=%+v view=%+v", diff.ByType["table"], diff.ByType["view"])
+	}
+}
+
+// TestDiffSnapshots validates snapshot persistence and the text report
+func TestDiffSnapshots(t *testing.T) {
+	dir := t.TempDir()
+	before := filepath.Join(dir, "before.json")
+	if err := SaveSnapshot(before, diffTestScan(&DiscoveredObject{ID: "1", Name: "users", Type: "table"}), "weekly"); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	baseline, err := LoadSnapshot(before)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	current := &ScanSnapshot{Response: diffTestScan()}
+	diff := DiffSnapshots(baseline, current, nil)
+	if !diff.HasChanges() || diff.BaselineAt.IsZero() {
+		t.Fatalf("expected removal since baseline, got %+v", diff)
+	}
+
+	var buf bytes.Buffer
+	diff.WriteText(&buf)
+	if !strings.Contains(buf.String(), "- table users") {
+		t.Errorf("unexpected report:\n%s", buf.String())
+	}
+}
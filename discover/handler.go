@@ -10,6 +10,7 @@ import (
 	"fmt"
 
 	"github.com/schemabounce/kolumn/sdk/core"
+	"github.com/schemabounce/kolumn/sdk/helpers/ids"
 	"github.com/schemabounce/kolumn/sdk/helpers/security"
 )
 
@@ -61,10 +62,10 @@ type ScanResponse struct {
 // AnalyzeRequest specifies what to analyze in detail
 type AnalyzeRequest struct {
 	ObjectType   string                 `json:"object_type"`
-	Objects      []*ObjectIdentifier    `json:"objects"`         // specific objects to analyze
-	AnalysisType []string               `json:"analysis_type"`   // types of analysis to perform
-	Depth        string                 `json:"depth,omitempty"` // "shallow", "deep", "comprehensive"
-	Options      map[string]interface{} `json:"options,omitempty"`
+	Objects      []*ObjectIdentifier    `json:"objects"`           // specific objects to analyze
+	AnalysisType []string               `json:"analysis_type"`     // types of analysis to perform
+	Depth        string                 `json:"depth,omitempty"`   // "shallow", "deep", "comprehensive"
+	Options      map[string]interface{} `json:"options,omitempty"` // sample_rows, object_timeout, timeout
 }
 
 // AnalyzeResponse contains detailed analysis results
@@ -73,6 +74,7 @@ type AnalyzeResponse struct {
 	Insights        []*Insight             `json:"insights,omitempty"`
 	Issues          []*Issue               `json:"issues,omitempty"`
 	Recommendations []*Recommendation      `json:"recommendations,omitempty"`
+	Partial         bool                   `json:"partial,omitempty"` // a time budget cut analysis short
 	Metadata        map[string]interface{} `json:"metadata,omitempty"`
 }
 
@@ -114,6 +116,11 @@ type MonitorResponse struct {
 	Metadata  map[string]interface{} `json:"metadata,omitempty"`
 }
 
+// NewMonitorID returns a unique, time-ordered ID for a MonitorResponse
+func NewMonitorID() string {
+	return ids.Prefixed("mon")
+}
+
 // InsightsRequest asks for actionable insights
 type InsightsRequest struct {
 	ObjectType   string                 `json:"object_type"`
@@ -162,6 +169,10 @@ type DiscoveredObject struct {
 	Discovered string                 `json:"discovered"` // timestamp
 	Source     *Source                `json:"source"`
 	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+
+	// Relationships are typed edges to other discovered objects, populated by the
+	// handler's relation analyzers
+	Relationships []*ObjectRelationship `json:"relationships,omitempty"`
 }
 
 // Source describes where an object was discovered
@@ -222,6 +233,11 @@ type AnalysisResult struct {
 	Issues    []*Issue               `json:"issues,omitempty"`
 	Insights  []*Insight             `json:"insights,omitempty"`
 	Generated string                 `json:"generated"` // timestamp
+	Depth     string                 `json:"depth,omitempty"`
+	Duration  string                 `json:"duration,omitempty"`
+	Partial   bool                   `json:"partial,omitempty"` // the object's time budget was exceeded
+	Skipped   []string               `json:"skipped,omitempty"` // analyzers that did not finish
+	Errors    map[string]string      `json:"errors,omitempty"`  // analyzer name -> error
 }
 
 // Insight represents an actionable insight about discovered objects
@@ -324,8 +340,9 @@ type AlertRule struct {
 
 // Registry manages DISCOVER object handlers
 type Registry struct {
-	handlers map[string]ObjectHandler
-	schemas  map[string]*core.ObjectType
+	handlers   map[string]ObjectHandler
+	schemas    map[string]*core.ObjectType
+	generation uint64
 }
 
 // NewRegistry creates a new DISCOVER object registry
@@ -344,9 +361,16 @@ func (r *Registry) RegisterHandler(objectType string, handler ObjectHandler, sch
 
 	r.handlers[objectType] = handler
 	r.schemas[objectType] = schema
+	r.generation++
 	return nil
 }
 
+// Generation changes whenever a handler is registered, so cached schemas built from
+// the registry know when to rebuild (see core.SchemaGenerationer)
+func (r *Registry) Generation() uint64 {
+	return r.generation
+}
+
 // GetHandler returns the handler for an object type
 func (r *Registry) GetHandler(objectType string) (ObjectHandler, bool) {
 	handler, exists := r.handlers[objectType]
@@ -368,6 +392,15 @@ func (r *Registry) GetObjectTypes() map[string]*core.ObjectType {
 	return result
 }
 
+// Operations lists the operations the handler of an object type implements, for the
+// contract linter (see core.LintContract)
+func (r *Registry) Operations(objectType string) []string {
+	if _, exists := r.handlers[objectType]; !exists {
+		return nil
+	}
+	return []string{"discover", "scan", "analyze", "query"}
+}
+
 // CallHandler executes a handler method by name with comprehensive security validation
 func (r *Registry) CallHandler(ctx context.Context, objectType, method string, input []byte) ([]byte, error) {
 	// SECURITY: Validate object type to prevent injection
@@ -533,6 +566,7 @@ type AdvancedHandler struct {
 	introspectors     []Introspector
 	relationAnalyzers []RelationAnalyzer
 	metadataProviders []MetadataProvider
+	objectAnalyzers   []ObjectAnalyzer
 }
 
 // NewAdvancedHandler creates a new AdvancedHandler for the specified object type
@@ -590,6 +624,11 @@ func (h *AdvancedHandler) AddMetadataProvider(provider MetadataProvider) {
 	h.metadataProviders = append(h.metadataProviders, provider)
 }
 
+// AddObjectAnalyzer adds an analyzer used by Analyze
+func (h *AdvancedHandler) AddObjectAnalyzer(analyzer ObjectAnalyzer) {
+	h.objectAnalyzers = append(h.objectAnalyzers, analyzer)
+}
+
 // Default implementations for ObjectHandler interface
 func (h *AdvancedHandler) Scan(ctx context.Context, req *ScanRequest) (*ScanResponse, error) {
 	// Use registered scanners
@@ -613,8 +652,12 @@ func (h *AdvancedHandler) Scan(ctx context.Context, req *ScanRequest) (*ScanResp
 			}
 		}
 
+		// Attach relationship edges
+		warnings := AnalyzeRelationships(ctx, filteredObjects, h.relationAnalyzers...)
+
 		return &ScanResponse{
-			Objects: filteredObjects,
+			Objects:  filteredObjects,
+			Warnings: warnings,
 			Summary: &ScanSummary{
 				TotalObjects: len(filteredObjects),
 				ObjectTypes:  map[string]int{h.objectType: len(filteredObjects)},
@@ -636,6 +679,9 @@ func (h *AdvancedHandler) Scan(ctx context.Context, req *ScanRequest) (*ScanResp
 }
 
 func (h *AdvancedHandler) Analyze(ctx context.Context, req *AnalyzeRequest) (*AnalyzeResponse, error) {
+	if len(h.objectAnalyzers) > 0 {
+		return RunAnalysis(ctx, req, h.objectAnalyzers)
+	}
 	return &AnalyzeResponse{
 		Results: []*AnalysisResult{},
 	}, nil
@@ -0,0 +1,100 @@
+// Package discover provides typed relationship edges between discovered objects
+package discover
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+)
+
+// Relationship types. An edge points from an object to the object it depends on, so a
+// table's foreign key points at the referenced table and a view at the objects it
+// selects from.
+const (
+	RelationshipForeignKey     = "foreign_key"     // table -> referenced table
+	RelationshipViewDependency = "view_dependency" // view -> table or view it reads
+	RelationshipTopicSchema    = "topic_schema"    // topic -> registered schema it is bound to
+	RelationshipDependsOn      = "depends_on"
+	RelationshipReferences     = "references"
+	RelationshipContains       = "contains"
+)
+
+// ObjectRelationship is a typed edge from a discovered object to another
+type ObjectRelationship struct {
+	Type       string `json:"type"`
+	TargetID   string `json:"target_id"`
+	TargetType string `json:"target_type,omitempty"`
+
+	// Inbound reverses the edge: the target depends on this object, e.g. a table that
+	// is used_by a view
+	Inbound bool `json:"inbound,omitempty"`
+
+	// Properties describe the edge, e.g. the columns of a foreign key
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+// RelationshipFromReference converts a reference returned by a RelationAnalyzer.
+// "used_by", "referenced_by" and "contains" become inbound edges; any other relation
+// type, including the Relationship types, is an outbound edge of that type.
+func RelationshipFromReference(ref core.ResourceReference) *ObjectRelationship {
+	rel := &ObjectRelationship{
+		Type:       ref.RelationType,
+		TargetID:   ref.ResourceID,
+		TargetType: ref.ResourceType,
+		Properties: ref.Properties,
+	}
+	switch ref.RelationType {
+	case "":
+		rel.Type = RelationshipDependsOn
+	case "used_by":
+		rel.Type, rel.Inbound = RelationshipDependsOn, true
+	case "referenced_by":
+		rel.Type, rel.Inbound = RelationshipReferences, true
+	case RelationshipContains:
+		rel.Inbound = true
+	}
+	return rel
+}
+
+// AddRelationship adds an edge unless the object already has one of the same type,
+// target and direction
+func (o *DiscoveredObject) AddRelationship(rel *ObjectRelationship) {
+	for _, existing := range o.Relationships {
+		if existing.Type == rel.Type && existing.TargetID == rel.TargetID && existing.Inbound == rel.Inbound {
+			return
+		}
+	}
+	o.Relationships = append(o.Relationships, rel)
+}
+
+// AnalyzeRelationships asks every analyzer for the relations of every object and adds
+// them as relationship edges. The object's properties are passed as the request state.
+// Edges from an object to itself are dropped. Analyzer failures do not stop the scan;
+// they are returned as warnings.
+func AnalyzeRelationships(ctx context.Context, objects []*DiscoveredObject, analyzers ...RelationAnalyzer) []string {
+	var warnings []string
+	for _, obj := range objects {
+		if obj == nil {
+			continue
+		}
+		for _, analyzer := range analyzers {
+			refs, err := analyzer.AnalyzeRelations(ctx, &core.RelationsRequest{
+				ResourceID:   obj.ID,
+				ResourceType: obj.Type,
+				State:        obj.Properties,
+			})
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("relation analyzer %s failed for %s: %v", analyzer.Name(), obj.ID, err))
+				continue
+			}
+			for _, ref := range refs {
+				if ref.ResourceID == "" || ref.ResourceID == obj.ID {
+					continue
+				}
+				obj.AddRelationship(RelationshipFromReference(ref))
+			}
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,65 @@
+package discover
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+)
+
+type staticScanner struct{ objects []*DiscoveredObject }
+
+func (s staticScanner) Name() string { return "static" }
+
+func (s staticScanner) Scan(ctx context.Context, req *ScanRequest) ([]*DiscoveredObject, error) {
+	return s.objects, nil
+}
+
+type fkAnalyzer struct{}
+
+func (fkAnalyzer) Name() string { return "fk" }
+
+func (fkAnalyzer) AnalyzeRelations(ctx context.Context, req *core.RelationsRequest) ([]core.ResourceReference, error) {
+	if req.ResourceID == "broken" {
+		return nil, fmt.Errorf("catalog unavailable")
+	}
+	var refs []core.ResourceReference
+	if target, ok := req.State["references"].(string); ok {
+		refs = append(refs, core.ResourceReference{ResourceID: target, ResourceType: "table", RelationType: RelationshipForeignKey})
+	}
+	refs = append(refs,
+		core.ResourceReference{ResourceID: req.ResourceID, RelationType: RelationshipForeignKey},
+		core.ResourceReference{ResourceID: "v_" + req.ResourceID, RelationType: "used_by"},
+	)
+	return refs, nil
+}
+
+// TestAnalyzeRelationships validates analyzer references become typed edges
+func TestAnalyzeRelationships(t *testing.T) {
+	h := NewAdvancedHandler("table")
+	h.AddScanner(staticScanner{objects: []*DiscoveredObject{
+		{ID: "orders", Type: "table", Properties: map[string]interface{}{"references": "users"}},
+		{ID: "broken", Type: "table"},
+	}})
+	h.AddRelationAnalyzer(fkAnalyzer{})
+	h.AddRelationAnalyzer(fkAnalyzer{})
+
+	resp, err := h.Scan(context.Background(), &ScanRequest{})
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	orders := resp.Objects[0]
+	if len(orders.Relationships) != 2 {
+		t.Fatalf("expected deduplicated edges without self references, got %+v", orders.Relationships)
+	}
+	if fk := orders.Relationships[0]; fk.Type != RelationshipForeignKey || fk.TargetID != "users" || fk.Inbound {
+		t.Errorf("unexpected foreign key edge: %+v", fk)
+	}
+	if used := orders.Relationships[1]; used.Type != RelationshipDependsOn || used.TargetID != "v_orders" || !used.Inbound {
+		t.Errorf("unexpected inbound edge: %+v", used)
+	}
+	if len(resp.Warnings) != 2 {
+		t.Errorf("expected a warning per failed analyzer call, got %v", resp.Warnings)
+	}
+}
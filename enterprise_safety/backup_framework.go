@@ -12,6 +12,8 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/schemabounce/kolumn/sdk/helpers/ids"
 )
 
 // BackupIntegrityFramework provides cross-provider backup validation capabilities
@@ -144,13 +146,13 @@ func DefaultValidationRules() ValidationRules {
 // BackupObject backs up a database object with full integrity validation
 func (f *BackupIntegrityFramework) BackupObject(ctx context.Context, db *sql.DB, objRef ObjectReference) (*BackupObject, error) {
 	backup := &BackupObject{
-		ID:              generateBackupID(objRef),
+		ID:              ids.Prefixed("backup"),
 		ProviderType:    f.ProviderType,
 		ObjectType:      objRef.Type,
 		ObjectName:      objRef.Name,
 		DatabaseName:    objRef.DatabaseName,
 		SchemaName:      objRef.SchemaName,
-		BackupTimestamp: time.Now(),
+		BackupTimestamp: clock.Now(),
 		BackupVersion:   "1.0",
 		Metadata:        make(map[string]interface{}),
 	}
@@ -205,7 +207,7 @@ func (f *BackupIntegrityFramework) BackupObject(ctx context.Context, db *sql.DB,
 // ValidateBackupIntegrity validates a backup object's integrity
 func (f *BackupIntegrityFramework) ValidateBackupIntegrity(backup *BackupObject) ValidationStatus {
 	status := ValidationStatus{
-		LastValidated: time.Now(),
+		LastValidated: clock.Now(),
 	}
 
 	var score float64
@@ -251,7 +253,7 @@ func (f *BackupIntegrityFramework) ValidateBackupIntegrity(backup *BackupObject)
 	}
 
 	// Validate backup age
-	if time.Since(backup.BackupTimestamp) > f.ValidationRules.MaxBackupAge {
+	if clock.Since(backup.BackupTimestamp) > f.ValidationRules.MaxBackupAge {
 		errors = append(errors, "Backup is too old")
 		score -= 10
 	} else {
@@ -288,7 +290,7 @@ func (f *BackupIntegrityFramework) RestoreObject(ctx context.Context, db *sql.DB
 
 // TestCascadeDelete tests cascade delete behavior
 func (f *BackupIntegrityFramework) TestCascadeDelete(ctx context.Context, db *sql.DB, test CascadeDeleteTest) CascadeTestResult {
-	startTime := time.Now()
+	startTime := clock.Now()
 	result := CascadeTestResult{}
 
 	// Backup all objects before deletion
@@ -298,7 +300,7 @@ func (f *BackupIntegrityFramework) TestCascadeDelete(ctx context.Context, db *sq
 	primaryBackup, err := f.BackupObject(ctx, db, test.PrimaryObject)
 	if err != nil {
 		result.Error = fmt.Sprintf("Failed to backup primary object: %v", err)
-		result.Duration = time.Since(startTime)
+		result.Duration = clock.Since(startTime)
 		return result
 	}
 	backups[test.PrimaryObject.Identifier] = primaryBackup
@@ -321,7 +323,7 @@ func (f *BackupIntegrityFramework) TestCascadeDelete(ctx context.Context, db *sq
 	err = f.deleteObject(db, test.PrimaryObject)
 	if err != nil {
 		result.Error = fmt.Sprintf("Failed to delete primary object: %v", err)
-		result.Duration = time.Since(startTime)
+		result.Duration = clock.Since(startTime)
 		return result
 	}
 
@@ -357,7 +359,7 @@ func (f *BackupIntegrityFramework) TestCascadeDelete(ctx context.Context, db *sq
 
 	result.Success = len(result.IntegrityViolations) == 0 &&
 		(result.OrphanedResourceCount == 0 || !test.ExpectedBehavior.OrphanPrevention)
-	result.Duration = time.Since(startTime)
+	result.Duration = clock.Since(startTime)
 
 	return result
 }
@@ -366,7 +368,7 @@ func (f *BackupIntegrityFramework) TestCascadeDelete(ctx context.Context, db *sq
 func (f *BackupIntegrityFramework) GenerateIntegrityReport() IntegrityReport {
 	report := IntegrityReport{
 		ProviderType: f.ProviderType,
-		GeneratedAt:  time.Now(),
+		GeneratedAt:  clock.Now(),
 		Framework:    f,
 		Metrics:      f.Metrics,
 	}
@@ -713,9 +715,3 @@ func (f *BackupIntegrityFramework) generateRecommendations(report IntegrityRepor
 
 	return recommendations
 }
-
-func generateBackupID(objRef ObjectReference) string {
-	hasher := md5.New()
-	hasher.Write([]byte(fmt.Sprintf("%s:%s:%s:%s", objRef.Type, objRef.DatabaseName, objRef.SchemaName, objRef.Name)))
-	return hex.EncodeToString(hasher.Sum(nil))[:12]
-}
@@ -3,8 +3,9 @@ package enterprise_safety
 
 import (
 	"context"
-	"fmt"
 	"time"
+
+	"github.com/schemabounce/kolumn/sdk/helpers/ids"
 )
 
 // BaseSafetyProvider provides a base implementation of ProviderSafetyCapabilities
@@ -71,12 +72,12 @@ func (b *BaseSafetyProvider) CreateBackup(ctx context.Context, req *BackupReques
 	return &BackupResponse{
 		BackupID:   req.BackupID,
 		Provider:   b.providerType,
-		CreatedAt:  time.Now(),
+		CreatedAt:  clock.Now(),
 		Objects:    []*BackupObject{},
 		BackupSize: 0,
 		IntegrityCheck: &BackupIntegrityResult{
 			Valid:     true,
-			CheckedAt: time.Now(),
+			CheckedAt: clock.Now(),
 			Issues:    []string{},
 			Checksums: map[string]string{},
 		},
@@ -87,7 +88,7 @@ func (b *BaseSafetyProvider) CreateBackup(ctx context.Context, req *BackupReques
 func (b *BaseSafetyProvider) ValidateBackup(ctx context.Context, req *BackupValidationRequest) (*BackupValidationResponse, error) {
 	return &BackupValidationResponse{
 		Valid:       true,
-		ValidatedAt: time.Now(),
+		ValidatedAt: clock.Now(),
 		Issues:      []string{},
 		Details:     map[string]interface{}{},
 	}, nil
@@ -97,7 +98,7 @@ func (b *BaseSafetyProvider) ValidateBackup(ctx context.Context, req *BackupVali
 func (b *BaseSafetyProvider) RestoreFromBackup(ctx context.Context, req *RestoreRequest) (*RestoreResponse, error) {
 	return &RestoreResponse{
 		Success:    true,
-		RestoredAt: time.Now(),
+		RestoredAt: clock.Now(),
 		Objects:    []*DatabaseObject{},
 		Issues:     []string{},
 		Details:    map[string]interface{}{},
@@ -107,10 +108,10 @@ func (b *BaseSafetyProvider) RestoreFromBackup(ctx context.Context, req *Restore
 // GenerateRollbackPlan provides a default rollback plan generation (empty plan)
 func (b *BaseSafetyProvider) GenerateRollbackPlan(ctx context.Context, req *RollbackPlanRequest) (*RollbackPlanResponse, error) {
 	return &RollbackPlanResponse{
-		PlanID:      fmt.Sprintf("rollback-plan-%d", time.Now().Unix()),
+		PlanID:      ids.Prefixed("rollback_plan"),
 		Operations:  []*RollbackOperation{},
-		GeneratedAt: time.Now(),
-		ValidUntil:  time.Now().Add(24 * time.Hour), // Valid for 24 hours
+		GeneratedAt: clock.Now(),
+		ValidUntil:  clock.Now().Add(24 * time.Hour), // Valid for 24 hours
 		RiskLevel:   RiskLevelLow,
 		Notes:       []string{"Default rollback plan - no operations"},
 	}, nil
@@ -120,7 +121,7 @@ func (b *BaseSafetyProvider) GenerateRollbackPlan(ctx context.Context, req *Roll
 func (b *BaseSafetyProvider) ExecuteRollback(ctx context.Context, req *RollbackExecutionRequest) (*RollbackExecutionResponse, error) {
 	return &RollbackExecutionResponse{
 		Success:    true,
-		ExecutedAt: time.Now(),
+		ExecutedAt: clock.Now(),
 		Operations: []string{},
 		Issues:     []string{},
 		Details:    map[string]interface{}{},
@@ -229,7 +230,7 @@ func createDefaultCapabilityMatrix(providerType string) *ProviderCapabilityMatri
 		},
 		Metadata: map[string]interface{}{
 			"sdk_version":      "1.0.0",
-			"created_at":       time.Now().Format(time.RFC3339),
+			"created_at":       clock.Now().Format(time.RFC3339),
 			"default_provider": true,
 		},
 	}
@@ -15,6 +15,9 @@ type CascadeDeleteTestFramework struct {
 	ProviderType string
 	TestResults  []CascadeDeleteTestResult
 	Metrics      CascadeTestMetrics
+
+	// Deleter removes the primary object; nil drops it with SQL (see ProviderDeleter)
+	Deleter ObjectDeleter
 }
 
 // CascadeTestMetrics tracks cascade testing performance
@@ -74,6 +77,8 @@ type CascadeExpectation struct {
 	ConstraintViolations []string               `json:"constraint_violations"`
 	CleanupRequired      bool                   `json:"cleanup_required"`
 	Dependencies         map[string]interface{} `json:"dependencies"`
+	DeleteBlocked        bool                   `json:"delete_blocked"`  // the provider must refuse the delete
+	BackupExpected       bool                   `json:"backup_expected"` // the provider must report a backup
 }
 
 // CascadeActual represents what actually happened during cascade delete
@@ -86,6 +91,7 @@ type CascadeActual struct {
 	ErrorsEncountered     []string               `json:"errors_encountered"`
 	CleanupExecuted       bool                   `json:"cleanup_executed"`
 	ResultDetails         map[string]interface{} `json:"result_details"`
+	Delete                *DeleteOutcome         `json:"delete,omitempty"`
 }
 
 // OrphanedResource represents a resource left without its parent
@@ -116,15 +122,15 @@ type IntegrityViolation struct {
 
 // CascadeTestScenario defines a cascade delete test scenario
 type CascadeTestScenario struct {
-	Name              string
-	Description       string
-	PrimaryObject     ObjectInfo
-	DependentObjects  []ObjectInfo
-	TestData          map[string]interface{}
-	ExpectedBehavior  CascadeExpectation
-	SetupSQL          []string
-	CleanupSQL        []string
-	ValidationQueries []ValidationQuery
+	Name              string                 `json:"name"`
+	Description       string                 `json:"description,omitempty"`
+	PrimaryObject     ObjectInfo             `json:"primary_object"`
+	DependentObjects  []ObjectInfo           `json:"dependent_objects,omitempty"`
+	TestData          map[string]interface{} `json:"test_data,omitempty"`
+	ExpectedBehavior  CascadeExpectation     `json:"expected_behavior"`
+	SetupSQL          []string               `json:"setup_sql,omitempty"`
+	CleanupSQL        []string               `json:"cleanup_sql,omitempty"`
+	ValidationQueries []ValidationQuery      `json:"validation_queries,omitempty"`
 }
 
 // ValidationQuery represents a query to validate cascade behavior
@@ -151,7 +157,7 @@ func (f *CascadeDeleteTestFramework) RunCascadeDeleteTest(ctx context.Context, d
 		TestName:         scenario.Name,
 		TestType:         "cascade_delete",
 		ProviderType:     f.ProviderType,
-		StartTime:        time.Now(),
+		StartTime:        clock.Now(),
 		PrimaryObject:    scenario.PrimaryObject,
 		DependentObjects: scenario.DependentObjects,
 		ExpectedBehavior: scenario.ExpectedBehavior,
@@ -161,7 +167,7 @@ func (f *CascadeDeleteTestFramework) RunCascadeDeleteTest(ctx context.Context, d
 	}
 
 	defer func() {
-		result.Duration = time.Since(result.StartTime)
+		result.Duration = clock.Since(result.StartTime)
 		f.TestResults = append(f.TestResults, result)
 		f.updateMetrics(result)
 	}()
@@ -176,7 +182,11 @@ func (f *CascadeDeleteTestFramework) RunCascadeDeleteTest(ctx context.Context, d
 	result.PreDeleteCounts = f.countAllObjects(db, scenario.DependentObjects)
 
 	// Step 3: Execute cascade delete
-	err := f.executeCascadeDelete(ctx, db, scenario.PrimaryObject)
+	deleter := f.Deleter
+	if deleter == nil {
+		deleter = sqlDeleter{framework: f}
+	}
+	outcome, err := deleter.DeleteObject(ctx, db, scenario.PrimaryObject)
 	if err != nil {
 		result.Error = fmt.Sprintf("Delete failed: %v", err)
 		return result
@@ -187,6 +197,7 @@ func (f *CascadeDeleteTestFramework) RunCascadeDeleteTest(ctx context.Context, d
 
 	// Step 5: Analyze cascade behavior
 	result.ActualBehavior = f.analyzeCascadeBehavior(result.PreDeleteCounts, result.PostDeleteCounts, scenario)
+	result.ActualBehavior.Delete = outcome
 
 	// Step 6: Detect orphaned resources
 	result.OrphanedResources = f.detectOrphanedResources(ctx, db, scenario)
@@ -214,12 +225,12 @@ func (f *CascadeDeleteTestFramework) RunOrphanDetectionTest(ctx context.Context,
 		TestName:     "Orphaned Resource Detection",
 		TestType:     "orphan_detection",
 		ProviderType: f.ProviderType,
-		StartTime:    time.Now(),
+		StartTime:    clock.Now(),
 		Metadata:     make(map[string]interface{}),
 	}
 
 	defer func() {
-		result.Duration = time.Since(result.StartTime)
+		result.Duration = clock.Since(result.StartTime)
 		f.TestResults = append(f.TestResults, result)
 		f.updateMetrics(result)
 	}()
@@ -247,12 +258,12 @@ func (f *CascadeDeleteTestFramework) RunReferentialIntegrityTest(ctx context.Con
 		TestName:     "Referential Integrity Test",
 		TestType:     "referential_integrity",
 		ProviderType: f.ProviderType,
-		StartTime:    time.Now(),
+		StartTime:    clock.Now(),
 		Metadata:     make(map[string]interface{}),
 	}
 
 	defer func() {
-		result.Duration = time.Since(result.StartTime)
+		result.Duration = clock.Since(result.StartTime)
 		f.TestResults = append(f.TestResults, result)
 		f.updateMetrics(result)
 	}()
@@ -442,7 +453,7 @@ func (f *CascadeDeleteTestFramework) detectOrphanedRecords(db *sql.DB, obj Objec
 					ParentType:     "table", // Would parse from dependency
 					ParentName:     dependency,
 					OrphanedCount:  orphanCount,
-					OrphanedSince:  time.Now(),
+					OrphanedSince:  clock.Now(),
 					Severity:       f.calculateOrphanSeverity(orphanCount, obj.Type),
 					CleanupAction:  f.suggestCleanupAction(obj.Type, orphanCount),
 					CanAutoCleanup: f.canAutoCleanup(obj.Type),
@@ -508,7 +519,7 @@ func (f *CascadeDeleteTestFramework) runValidationQuery(db *sql.DB, validation V
 			Description:       fmt.Sprintf("Validation query failed: %v", err),
 			AffectedObjects:   []string{validation.Name},
 			Severity:          "HIGH",
-			DetectedAt:        time.Now(),
+			DetectedAt:        clock.Now(),
 			RecommendedAction: "Review and fix validation query",
 			CanAutoResolve:    false,
 		}
@@ -521,7 +532,7 @@ func (f *CascadeDeleteTestFramework) runValidationQuery(db *sql.DB, validation V
 			Description:       fmt.Sprintf("Validation '%s' failed: expected %v, got %v", validation.Name, validation.ExpectedResult, result),
 			AffectedObjects:   []string{validation.Name},
 			Severity:          "MEDIUM",
-			DetectedAt:        time.Now(),
+			DetectedAt:        clock.Now(),
 			RecommendedAction: "Investigate data integrity issue",
 			CanAutoResolve:    false,
 		}
@@ -541,6 +552,16 @@ func (f *CascadeDeleteTestFramework) validateTestResults(result CascadeDeleteTes
 		return false
 	}
 
+	// Check the provider refused or performed the delete as expected
+	if delete := result.ActualBehavior.Delete; delete != nil {
+		if delete.Blocked != scenario.ExpectedBehavior.DeleteBlocked {
+			return false
+		}
+		if scenario.ExpectedBehavior.BackupExpected && delete.BackupID == "" {
+			return false
+		}
+	}
+
 	// Check cascade behavior
 	expectedCascade := scenario.ExpectedBehavior.ShouldCascade
 	actualCascade := result.ActualBehavior.CascadeExecuted
@@ -579,6 +600,18 @@ func (f *CascadeDeleteTestFramework) generateRecommendations(result CascadeDelet
 		recommendations = append(recommendations, "Address data integrity violations before production deployment")
 	}
 
+	if delete := result.ActualBehavior.Delete; delete != nil {
+		if delete.Blocked && !scenario.ExpectedBehavior.DeleteBlocked {
+			recommendations = append(recommendations, fmt.Sprintf("Provider refused the delete: %s", delete.Message))
+		}
+		if !delete.Blocked && scenario.ExpectedBehavior.DeleteBlocked {
+			recommendations = append(recommendations, "Validate dependencies in the DeleteResource handler before dropping objects")
+		}
+		if scenario.ExpectedBehavior.BackupExpected && delete.BackupID == "" {
+			recommendations = append(recommendations, "Create a backup in the DeleteResource handler when create_backup is requested")
+		}
+	}
+
 	if !result.ActualBehavior.CascadeExecuted && scenario.ExpectedBehavior.ShouldCascade {
 		recommendations = append(recommendations, "Enable CASCADE DELETE in foreign key constraints")
 	}
@@ -701,7 +734,7 @@ func (f *CascadeDeleteTestFramework) canAutoCleanup(objectType string) bool {
 func (f *CascadeDeleteTestFramework) GenerateReport() CascadeTestReport {
 	report := CascadeTestReport{
 		ProviderType: f.ProviderType,
-		GeneratedAt:  time.Now(),
+		GeneratedAt:  clock.Now(),
 		TestResults:  f.TestResults,
 		Metrics:      f.Metrics,
 	}
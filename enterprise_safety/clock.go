@@ -0,0 +1,13 @@
+// Package enterprise_safety clock used for backup, validation and cascade timestamps
+package enterprise_safety
+
+import "github.com/schemabounce/kolumn/sdk/types"
+
+// clock supplies every timestamp and duration recorded by this package
+var clock types.ClockVar
+
+// SetClock replaces the clock used by enterprise_safety, or restores the system clock
+// when nil. It returns a function restoring the previous clock.
+func SetClock(c types.Clock) (restore func()) {
+	return clock.Set(c)
+}
@@ -0,0 +1,124 @@
+// Package enterprise_safety deletion of cascade scenario objects through provider handlers
+package enterprise_safety
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+)
+
+// ObjectDeleter removes a scenario's primary object. The framework's default deletes with
+// DROP ... CASCADE; ProviderDeleter goes through the provider's DeleteResource handler
+// instead, so its dependency validation and backup logic are exercised too.
+type ObjectDeleter interface {
+	DeleteObject(ctx context.Context, db *sql.DB, obj ObjectInfo) (*DeleteOutcome, error)
+}
+
+// DeleteOutcome is what happened when the primary object was deleted
+type DeleteOutcome struct {
+	Via      string   `json:"via"`     // "sql" or "handler"
+	Blocked  bool     `json:"blocked"` // the provider refused the delete
+	Message  string   `json:"message,omitempty"`
+	BackupID string   `json:"backup_id,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// sqlDeleter is the framework's default deleter
+type sqlDeleter struct {
+	framework *CascadeDeleteTestFramework
+}
+
+// DeleteObject implements ObjectDeleter
+func (d sqlDeleter) DeleteObject(ctx context.Context, db *sql.DB, obj ObjectInfo) (*DeleteOutcome, error) {
+	if err := d.framework.executeCascadeDelete(ctx, db, obj); err != nil {
+		return nil, err
+	}
+	return &DeleteOutcome{Via: "sql"}, nil
+}
+
+// ProviderDeleter deletes objects by calling DeleteResource on a provider
+type ProviderDeleter struct {
+	Provider core.Provider
+
+	// ResourceTypes maps scenario object types to provider resource types, e.g.
+	// "table" -> "postgres_table"; unmapped types are used as is
+	ResourceTypes map[string]string
+
+	// Options are sent with every delete, e.g. to request a backup
+	Options *core.DeleteOptions
+
+	// RequestFields are merged into every request, e.g. user_context or tenant_id
+	RequestFields map[string]interface{}
+}
+
+// NewProviderDeleter creates a deleter for provider
+func NewProviderDeleter(provider core.Provider) *ProviderDeleter {
+	return &ProviderDeleter{Provider: provider}
+}
+
+// DeleteObject implements ObjectDeleter. A handler error or an unsuccessful response is
+// reported as a blocked delete rather than an error, since refusing to delete an object
+// with dependents is often exactly what a scenario checks.
+func (d *ProviderDeleter) DeleteObject(ctx context.Context, db *sql.DB, obj ObjectInfo) (*DeleteOutcome, error) {
+	resourceType := obj.Type
+	if mapped, ok := d.ResourceTypes[obj.Type]; ok {
+		resourceType = mapped
+	}
+
+	state := map[string]interface{}{"name": obj.Name}
+	if obj.SchemaName != "" {
+		state["schema"] = obj.SchemaName
+	}
+	if obj.DatabaseName != "" {
+		state["database"] = obj.DatabaseName
+	}
+	request := map[string]interface{}{
+		"resource_type": resourceType,
+		"resource_id":   objectID(obj),
+		"name":          obj.Name,
+		"state":         state,
+	}
+	if d.Options != nil {
+		request["options"] = d.Options
+	}
+	for k, v := range d.RequestFields {
+		request[k] = v
+	}
+	input, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode delete request: %w", err)
+	}
+
+	outcome := &DeleteOutcome{Via: "handler"}
+	output, err := d.Provider.CallFunction(ctx, "DeleteResource", input)
+	if err != nil {
+		outcome.Blocked = true
+		outcome.Message = err.Error()
+		return outcome, nil
+	}
+	var resp core.DeleteResponse
+	if err := json.Unmarshal(output, &resp); err != nil {
+		return nil, fmt.Errorf("invalid DeleteResource response: %w", err)
+	}
+	outcome.Blocked = !resp.Success
+	outcome.Message = resp.Message
+	outcome.BackupID = resp.BackupID
+	for _, warning := range resp.Warnings {
+		outcome.Warnings = append(outcome.Warnings, warning.Message)
+	}
+	return outcome, nil
+}
+
+// objectID is the qualified name used as the resource ID of a scenario object
+func objectID(obj ObjectInfo) string {
+	switch {
+	case obj.SchemaName != "":
+		return obj.SchemaName + "." + obj.Name
+	case obj.DatabaseName != "":
+		return obj.DatabaseName + "." + obj.Name
+	}
+	return obj.Name
+}
@@ -0,0 +1,77 @@
+package enterprise_safety
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+)
+
+// deletingProvider refuses deletes without force and reports a backup otherwise
+type deletingProvider struct {
+	requests []map[string]interface{}
+}
+
+func (p *deletingProvider) Configure(ctx context.Context, config map[string]interface{}) error {
+	return nil
+}
+func (p *deletingProvider) Schema() (*core.Schema, error) { return &core.Schema{}, nil }
+func (p *deletingProvider) Close() error                  { return nil }
+
+func (p *deletingProvider) CallFunction(ctx context.Context, function string, input []byte) ([]byte, error) {
+	var req map[string]interface{}
+	if err := json.Unmarshal(input, &req); err != nil {
+		return nil, err
+	}
+	p.requests = append(p.requests, req)
+	options, _ := req["options"].(map[string]interface{})
+	if force, _ := options["force"].(bool); !force {
+		return nil, errors.New("table has dependent views")
+	}
+	return json.Marshal(core.DeleteResponse{Success: true, BackupID: "bk_1"})
+}
+
+// TestProviderDeleter validates cascade scenarios driven through DeleteResource
+func TestProviderDeleter(t *testing.T) {
+	db, err := sql.Open("kolumn_recording", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	scenario := CascadeTestScenario{
+		Name:             "handler_delete",
+		PrimaryObject:    ObjectInfo{Type: "table", Name: "orders", SchemaName: "app"},
+		DependentObjects: []ObjectInfo{{Type: "view", Name: "order_totals", SchemaName: "app"}},
+		ExpectedBehavior: CascadeExpectation{DeleteBlocked: true},
+	}
+
+	provider := &deletingProvider{}
+	deleter := NewProviderDeleter(provider)
+	deleter.ResourceTypes = map[string]string{"table": "postgres_table"}
+	framework := NewCascadeDeleteTestFramework("postgres")
+	framework.Deleter = deleter
+
+	blocked := framework.RunCascadeDeleteTest(context.Background(), db, scenario)
+	if !blocked.Success || blocked.ActualBehavior.Delete == nil || !blocked.ActualBehavior.Delete.Blocked {
+		t.Fatalf("expected the refused delete to satisfy the scenario: %+v", blocked.ActualBehavior.Delete)
+	}
+	req := provider.requests[0]
+	if req["resource_type"] != "postgres_table" || req["resource_id"] != "app.orders" {
+		t.Errorf("unexpected DeleteResource request %v", req)
+	}
+
+	deleter.Options = &core.DeleteOptions{Force: true, CreateBackup: true}
+	if result := framework.RunCascadeDeleteTest(context.Background(), db, scenario); result.Success {
+		t.Error("a delete the scenario expects to be refused must fail the test when it succeeds")
+	}
+
+	scenario.ExpectedBehavior = CascadeExpectation{BackupExpected: true}
+	result := framework.RunCascadeDeleteTest(context.Background(), db, scenario)
+	if !result.Success || result.ActualBehavior.Delete.BackupID != "bk_1" || result.ActualBehavior.Delete.Via != "handler" {
+		t.Fatalf("expected a successful handler delete with a backup: %+v", result.ActualBehavior.Delete)
+	}
+}
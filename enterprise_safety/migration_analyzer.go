@@ -0,0 +1,475 @@
+// Package enterprise_safety analysis of planned schema changes for destructive patterns
+package enterprise_safety
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Confirmation flags a caller passes to proceed with a destructive change
+const (
+	ConfirmDropColumnWithData = "allow_drop_column_with_data"
+	ConfirmDropTableWithData  = "allow_drop_table_with_data"
+	ConfirmTypeNarrowing      = "allow_type_narrowing"
+	ConfirmLossyCharset       = "allow_lossy_charset"
+)
+
+// ConfirmationsContextKey is the ValidationRequest.Context key holding confirmation
+// flags, as a list of strings or a comma-separated string
+const ConfirmationsContextKey = "confirmations"
+
+// DataProbe tells the analyzer whether a change would touch existing data. Column ""
+// counts the rows of the table; otherwise the non-null values of the column are counted.
+type DataProbe interface {
+	CountValues(ctx context.Context, schema, table, column string) (int64, error)
+}
+
+// SQLDataProbe counts values with SELECT COUNT against a database
+type SQLDataProbe struct {
+	DB *sql.DB
+}
+
+// identifierPattern limits identifiers SQLDataProbe splices into queries
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*$`)
+
+// CountValues implements DataProbe
+func (p SQLDataProbe) CountValues(ctx context.Context, schema, table, column string) (int64, error) {
+	target := "*"
+	if column != "" {
+		target = column
+	}
+	from := table
+	if schema != "" {
+		from = schema + "." + table
+	}
+	for _, ident := range []string{schema, table, column} {
+		if ident != "" && !identifierPattern.MatchString(ident) {
+			return 0, fmt.Errorf("unsafe identifier %q", ident)
+		}
+	}
+	var count int64
+	err := p.DB.QueryRowContext(ctx, fmt.Sprintf("SELECT COUNT(%s) FROM %s", target, from)).Scan(&count)
+	return count, err
+}
+
+// MigrationFinding is one dangerous pattern found in a planned change
+type MigrationFinding struct {
+	RuleID       string                 `json:"rule_id"`
+	Risk         RiskLevel              `json:"risk"`
+	Message      string                 `json:"message"`
+	Confirmation string                 `json:"confirmation,omitempty"` // flag needed to proceed; empty when none is
+	Operation    *DatabaseOperationSpec `json:"operation"`
+	Details      map[string]interface{} `json:"details,omitempty"`
+}
+
+// MigrationAnalysis is the outcome of analyzing a set of planned changes
+type MigrationAnalysis struct {
+	Findings []*MigrationFinding `json:"findings,omitempty"`
+	Risk     RiskLevel           `json:"risk"`
+}
+
+// RequiredConfirmations returns the flags needed to proceed, sorted
+func (a *MigrationAnalysis) RequiredConfirmations() []string {
+	var flags []string
+	for _, finding := range a.Findings {
+		if finding.Confirmation != "" {
+			flags = append(flags, finding.Confirmation)
+		}
+	}
+	return uniqueStrings(flags)
+}
+
+// Unconfirmed returns the findings whose confirmation flag was not given
+func (a *MigrationAnalysis) Unconfirmed(confirmations []string) []*MigrationFinding {
+	var unconfirmed []*MigrationFinding
+	for _, finding := range a.Findings {
+		if finding.Confirmation != "" && !containsFlag(confirmations, finding.Confirmation) {
+			unconfirmed = append(unconfirmed, finding)
+		}
+	}
+	return unconfirmed
+}
+
+// Check returns an *UnconfirmedMigrationError unless every required flag was given
+func (a *MigrationAnalysis) Check(confirmations ...string) error {
+	unconfirmed := a.Unconfirmed(confirmations)
+	if len(unconfirmed) == 0 {
+		return nil
+	}
+	return &UnconfirmedMigrationError{Findings: unconfirmed}
+}
+
+// UnconfirmedMigrationError blocks a migration with destructive changes nobody confirmed
+type UnconfirmedMigrationError struct {
+	Findings []*MigrationFinding
+}
+
+func (e *UnconfirmedMigrationError) Error() string {
+	var flags, messages []string
+	for _, finding := range e.Findings {
+		flags = append(flags, finding.Confirmation)
+		messages = append(messages, finding.Message)
+	}
+	return fmt.Sprintf("destructive changes need confirmation (%s): %s",
+		strings.Join(uniqueStrings(flags), ", "), strings.Join(messages, "; "))
+}
+
+// MigrationAnalyzer inspects planned changes against the live schema and data before
+// they run: dropping columns or tables that hold data, narrowing column types, and
+// charset changes that cannot represent every existing character. Each such change
+// needs an explicit confirmation flag to proceed.
+type MigrationAnalyzer struct {
+	ProviderType string
+	Introspector ProviderIntrospector
+	Probe        DataProbe // optional; without it columns and tables are assumed to hold data
+}
+
+// NewMigrationAnalyzer creates an analyzer reading the schema through introspector and
+// data presence through probe
+func NewMigrationAnalyzer(providerType string, introspector ProviderIntrospector, probe DataProbe) *MigrationAnalyzer {
+	return &MigrationAnalyzer{ProviderType: providerType, Introspector: introspector, Probe: probe}
+}
+
+// Analyze inspects operations and classifies their risk
+func (m *MigrationAnalyzer) Analyze(ctx context.Context, operations []*DatabaseOperationSpec) *MigrationAnalysis {
+	analysis := &MigrationAnalysis{Risk: RiskLevelLow}
+	for _, op := range operations {
+		if op == nil {
+			continue
+		}
+		switch op.Type {
+		case OperationDropColumn:
+			analysis.add(m.analyzeDrop(ctx, op, op.ColumnName))
+		case OperationDropTable:
+			analysis.add(m.analyzeDrop(ctx, op, ""))
+		case OperationAlterColumn:
+			analysis.add(m.analyzeAlterColumn(ctx, op)...)
+		}
+	}
+	return analysis
+}
+
+// Validate runs the analysis for a validation request, reporting unconfirmed findings
+// as critical violations and confirmed ones as warnings. Providers can call it from
+// ValidateOperations.
+func (m *MigrationAnalyzer) Validate(ctx context.Context, req *ValidationRequest) (*ValidationResponse, error) {
+	analysis := m.Analyze(ctx, req.Operations)
+	confirmations := ConfirmationsFromContext(req.Context)
+
+	resp := &ValidationResponse{Success: true}
+	var factors []string
+	for _, finding := range analysis.Findings {
+		factors = append(factors, finding.Message)
+		switch {
+		case finding.Confirmation != "" && !containsFlag(confirmations, finding.Confirmation):
+			resp.Success = false
+			resp.Violations = append(resp.Violations, &ValidationViolation{
+				RuleID:     finding.RuleID,
+				Severity:   SeverityCritical,
+				Message:    finding.Message,
+				Suggestion: fmt.Sprintf("Back up the data and pass the %s confirmation to proceed", finding.Confirmation),
+				Operation:  finding.Operation,
+				Details:    finding.Details,
+			})
+		default:
+			resp.Warnings = append(resp.Warnings, &ValidationWarning{
+				Code:      finding.RuleID,
+				Message:   finding.Message,
+				Operation: finding.Operation,
+				Details:   finding.Details,
+			})
+		}
+	}
+	resp.RiskAssessment = CreateRiskAssessment(analysis.Risk, factors)
+	resp.RiskAssessment.DataLossRisk = analysis.Risk
+	return resp, nil
+}
+
+// ConfirmationsFromContext reads confirmation flags from a request context
+func ConfirmationsFromContext(ctx map[string]interface{}) []string {
+	switch v := ctx[ConfirmationsContextKey].(type) {
+	case []string:
+		return v
+	case []interface{}:
+		flags := make([]string, 0, len(v))
+		for _, flag := range v {
+			if s, ok := flag.(string); ok {
+				flags = append(flags, s)
+			}
+		}
+		return flags
+	case string:
+		var flags []string
+		for _, flag := range strings.Split(v, ",") {
+			if flag = strings.TrimSpace(flag); flag != "" {
+				flags = append(flags, flag)
+			}
+		}
+		return flags
+	}
+	return nil
+}
+
+func (a *MigrationAnalysis) add(findings ...*MigrationFinding) {
+	for _, finding := range findings {
+		if finding == nil {
+			continue
+		}
+		a.Findings = append(a.Findings, finding)
+		if riskRank[finding.Risk] > riskRank[a.Risk] {
+			a.Risk = finding.Risk
+		}
+	}
+}
+
+var riskRank = map[RiskLevel]int{RiskLevelLow: 0, RiskLevelMedium: 1, RiskLevelHigh: 2, RiskLevelCritical: 3}
+
+// analyzeDrop checks a dropped column, or table when column is empty, for data
+func (m *MigrationAnalyzer) analyzeDrop(ctx context.Context, op *DatabaseOperationSpec, column string) *MigrationFinding {
+	subject, rule, flag := "table "+qualifiedTable(op), "drop_table_with_data", ConfirmDropTableWithData
+	if column != "" {
+		subject, rule, flag = fmt.Sprintf("column %s.%s", qualifiedTable(op), column), "drop_column_with_data", ConfirmDropColumnWithData
+	}
+
+	count, known, err := m.countValues(ctx, op, column)
+	details := map[string]interface{}{}
+	switch {
+	case err != nil:
+		details["probe_error"] = err.Error()
+		return &MigrationFinding{RuleID: rule, Risk: RiskLevelCritical, Confirmation: flag, Operation: op, Details: details,
+			Message: fmt.Sprintf("Dropping %s cannot be checked for data: %v", subject, err)}
+	case !known:
+		return &MigrationFinding{RuleID: rule, Risk: RiskLevelCritical, Confirmation: flag, Operation: op, Details: details,
+			Message: fmt.Sprintf("Dropping %s may destroy data", subject)}
+	case count == 0:
+		return &MigrationFinding{RuleID: strings.TrimSuffix(rule, "_with_data"), Risk: RiskLevelLow, Operation: op,
+			Message: fmt.Sprintf("Dropping %s, which holds no data", subject)}
+	}
+	details["values"] = count
+	return &MigrationFinding{RuleID: rule, Risk: RiskLevelCritical, Confirmation: flag, Operation: op, Details: details,
+		Message: fmt.Sprintf("Dropping %s destroys %d values", subject, count)}
+}
+
+// analyzeAlterColumn checks a column type or charset change against the current column
+func (m *MigrationAnalyzer) analyzeAlterColumn(ctx context.Context, op *DatabaseOperationSpec) []*MigrationFinding {
+	if m.Introspector == nil {
+		return nil
+	}
+	current, err := m.Introspector.GetColumnDefinition(op.TableName, op.SchemaName, op.ColumnName)
+	if err != nil || current == nil {
+		return nil // a column that does not exist yet has nothing to lose
+	}
+	subject := fmt.Sprintf("%s.%s", qualifiedTable(op), op.ColumnName)
+
+	var findings []*MigrationFinding
+	if op.DataType != "" {
+		if reason := typeNarrowing(current.DataType, op.DataType); reason != "" {
+			findings = append(findings, m.dataDependent(ctx, op, &MigrationFinding{
+				RuleID: "type_narrowing", Risk: RiskLevelHigh, Confirmation: ConfirmTypeNarrowing, Operation: op,
+				Message: fmt.Sprintf("Changing %s from %s to %s %s", subject, current.DataType, op.DataType, reason),
+				Details: map[string]interface{}{"from": current.DataType, "to": op.DataType},
+			}))
+		}
+	}
+	from, to := columnCharset(current.Metadata), columnCharset(op.Parameters)
+	if from != "" && to != "" && !m.charsetCovers(to, from) {
+		findings = append(findings, m.dataDependent(ctx, op, &MigrationFinding{
+			RuleID: "lossy_charset", Risk: RiskLevelHigh, Confirmation: ConfirmLossyCharset, Operation: op,
+			Message: fmt.Sprintf("Changing %s from %s to %s cannot represent every existing character", subject, from, to),
+			Details: map[string]interface{}{"from": from, "to": to},
+		}))
+	}
+	return findings
+}
+
+// dataDependent downgrades a finding to low risk when the column is known to be empty
+func (m *MigrationAnalyzer) dataDependent(ctx context.Context, op *DatabaseOperationSpec, finding *MigrationFinding) *MigrationFinding {
+	count, known, err := m.countValues(ctx, op, op.ColumnName)
+	if err == nil && known {
+		finding.Details["values"] = count
+		if count == 0 {
+			finding.Risk = RiskLevelLow
+			finding.Confirmation = ""
+		}
+	}
+	return finding
+}
+
+func (m *MigrationAnalyzer) countValues(ctx context.Context, op *DatabaseOperationSpec, column string) (int64, bool, error) {
+	if m.Probe == nil {
+		return 0, false, nil
+	}
+	count, err := m.Probe.CountValues(ctx, op.SchemaName, op.TableName, column)
+	if err != nil {
+		return 0, false, err
+	}
+	return count, true, nil
+}
+
+func qualifiedTable(op *DatabaseOperationSpec) string {
+	if op.SchemaName != "" {
+		return op.SchemaName + "." + op.TableName
+	}
+	return op.TableName
+}
+
+func containsFlag(flags []string, flag string) bool {
+	for _, f := range flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// columnTypePattern splits a type such as "varchar(255)" or "numeric(10, 2)"
+var columnTypePattern = regexp.MustCompile(`^\s*([a-z][a-z0-9 ]*?)\s*(?:\(\s*(\d+)\s*(?:,\s*(\d+)\s*)?\))?\s*$`)
+
+// Column type families and the width each type holds within its family; unbounded
+// string types are -1
+var (
+	integerWidths = map[string]int{"tinyint": 1, "int1": 1, "smallint": 2, "int2": 2, "mediumint": 3,
+		"int": 4, "integer": 4, "int4": 4, "bigint": 8, "int8": 8}
+	floatWidths  = map[string]int{"real": 4, "float4": 4, "float": 8, "double": 8, "double precision": 8, "float8": 8}
+	stringTypes  = map[string]bool{"char": true, "character": true, "varchar": true, "character varying": true, "nchar": true, "nvarchar": true, "text": true, "string": true, "clob": true}
+	decimalTypes = map[string]bool{"numeric": true, "decimal": true, "number": true}
+)
+
+// typeNarrowing explains why changing a column from one type to another can lose or
+// reject existing values, or returns "" when every value fits
+func typeNarrowing(from, to string) string {
+	fromBase, fromArgs := parseColumnType(from)
+	toBase, toArgs := parseColumnType(to)
+	if fromBase == "" || toBase == "" {
+		if strings.EqualFold(strings.TrimSpace(from), strings.TrimSpace(to)) {
+			return ""
+		}
+		return "converts between unrecognised types"
+	}
+
+	switch {
+	case integerWidths[fromBase] > 0 && integerWidths[toBase] > 0:
+		if integerWidths[toBase] < integerWidths[fromBase] {
+			return "reduces the integer range"
+		}
+		return ""
+	case floatWidths[fromBase] > 0 && floatWidths[toBase] > 0:
+		if floatWidths[toBase] < floatWidths[fromBase] {
+			return "reduces floating point precision"
+		}
+		return ""
+	case stringTypes[fromBase] && stringTypes[toBase]:
+		fromLen, toLen := stringLength(fromBase, fromArgs), stringLength(toBase, toArgs)
+		if toLen >= 0 && (fromLen < 0 || toLen < fromLen) {
+			return "truncates longer strings"
+		}
+		return ""
+	case decimalTypes[fromBase] && decimalTypes[toBase]:
+		if len(fromArgs) == 0 {
+			if len(toArgs) > 0 {
+				return "limits an unconstrained decimal"
+			}
+			return ""
+		}
+		if len(toArgs) == 0 {
+			return ""
+		}
+		fromScale, toScale := argAt(fromArgs, 1), argAt(toArgs, 1)
+		if toArgs[0]-toScale < fromArgs[0]-fromScale {
+			return "reduces the number of integer digits"
+		}
+		if toScale < fromScale {
+			return "rounds away decimal places"
+		}
+		return ""
+	case integerWidths[fromBase] > 0 && (decimalTypes[toBase] || floatWidths[toBase] > 0):
+		if decimalTypes[toBase] && len(toArgs) > 0 && toArgs[0]-argAt(toArgs, 1) < integerWidths[fromBase]*5/2 {
+			return "reduces the integer range"
+		}
+		return ""
+	case integerWidths[fromBase] > 0 && stringTypes[toBase]:
+		if length := stringLength(toBase, toArgs); length >= 0 && length < integerWidths[fromBase]*5/2+1 {
+			return "truncates longer numbers"
+		}
+		return ""
+	case fromBase == toBase:
+		return ""
+	}
+	return "may not convert every existing value"
+}
+
+func parseColumnType(t string) (string, []int) {
+	match := columnTypePattern.FindStringSubmatch(strings.ToLower(t))
+	if match == nil {
+		return "", nil
+	}
+	var args []int
+	for _, arg := range match[2:] {
+		if arg != "" {
+			n, _ := strconv.Atoi(arg)
+			args = append(args, n)
+		}
+	}
+	return match[1], args
+}
+
+func stringLength(base string, args []int) int {
+	if len(args) > 0 {
+		return args[0]
+	}
+	switch base {
+	case "char", "character", "nchar":
+		return 1
+	}
+	return -1
+}
+
+func argAt(args []int, i int) int {
+	if i < len(args) {
+		return args[i]
+	}
+	return 0
+}
+
+// columnCharset reads a charset from column metadata or operation parameters
+func columnCharset(values map[string]interface{}) string {
+	for _, key := range []string{"charset", "character_set", "encoding"} {
+		if s, ok := values[key].(string); ok && s != "" {
+			return strings.ToLower(strings.ReplaceAll(s, "-", ""))
+		}
+	}
+	return ""
+}
+
+// charsetRepertoire ranks charsets whose repertoires nest: each holds every character of
+// the ones ranked below it. Charsets outside this list only cover themselves.
+var charsetRepertoire = map[string]int{"ascii": 1, "latin1": 2, "utf8mb3": 3, "utf8mb4": 4, "utf16": 4, "utf32": 4}
+
+// charsetCovers reports whether every character of from can be stored in to. MySQL's
+// utf8 is the three-byte utf8mb3; elsewhere utf8 is full Unicode.
+func (m *MigrationAnalyzer) charsetCovers(to, from string) bool {
+	normalize := func(charset string) string {
+		switch charset {
+		case "utf8":
+			if m.ProviderType == "mysql" {
+				return "utf8mb3"
+			}
+			return "utf8mb4"
+		case "sqlascii", "usascii":
+			return "ascii"
+		case "iso88591":
+			return "latin1"
+		}
+		return charset
+	}
+	to, from = normalize(to), normalize(from)
+	if to == from {
+		return true
+	}
+	toRank, fromRank := charsetRepertoire[to], charsetRepertoire[from]
+	return toRank > 0 && fromRank > 0 && toRank >= fromRank
+}
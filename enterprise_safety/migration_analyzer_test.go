@@ -0,0 +1,164 @@
+package enterprise_safety
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// fakeIntrospector serves column definitions from a map keyed by table.column
+type fakeIntrospector struct {
+	columns map[string]*ColumnDefinition
+}
+
+func (f fakeIntrospector) GetTableDefinition(table, schema string) (*TableDefinition, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (f fakeIntrospector) GetColumnDefinition(table, schema, column string) (*ColumnDefinition, error) {
+	col, ok := f.columns[table+"."+column]
+	if !ok {
+		return nil, fmt.Errorf("column %s.%s does not exist", table, column)
+	}
+	return col, nil
+}
+
+func (f fakeIntrospector) TableExists(table, schema string) (bool, error) { return true, nil }
+
+func (f fakeIntrospector) ColumnExists(table, schema, column string) (bool, error) {
+	_, ok := f.columns[table+"."+column]
+	return ok, nil
+}
+
+func (f fakeIntrospector) GetConstraints(table, schema string) ([]*ConstraintDefinition, error) {
+	return nil, nil
+}
+
+func (f fakeIntrospector) GetIndexes(table, schema string) ([]*IndexDefinition, error) {
+	return nil, nil
+}
+
+// countProbe returns counts keyed by table.column, or by table for row counts
+type countProbe map[string]int64
+
+func (p countProbe) CountValues(ctx context.Context, schema, table, column string) (int64, error) {
+	key := table
+	if column != "" {
+		key += "." + column
+	}
+	return p[key], nil
+}
+
+// TestTypeNarrowing validates classification of column type changes
+func TestTypeNarrowing(t *testing.T) {
+	cases := []struct {
+		from, to string
+		narrows  bool
+	}{
+		{"bigint", "integer", true},
+		{"integer", "bigint", false},
+		{"varchar(255)", "varchar(50)", true},
+		{"varchar(50)", "character varying(255)", false},
+		{"text", "varchar(100)", true},
+		{"varchar(100)", "text", false},
+		{"numeric(10,2)", "numeric(12,2)", false},
+		{"numeric(10,2)", "numeric(10,0)", true},
+		{"numeric(10, 2)", "numeric(8,2)", true},
+		{"double precision", "real", true},
+		{"integer", "numeric(12)", false},
+		{"bigint", "numeric(10)", true},
+		{"integer", "varchar(20)", false},
+		{"varchar(20)", "integer", true},
+		{"timestamp", "timestamp", false},
+	}
+	for _, c := range cases {
+		if got := typeNarrowing(c.from, c.to) != ""; got != c.narrows {
+			t.Errorf("%s -> %s: narrowing %v, want %v", c.from, c.to, got, c.narrows)
+		}
+	}
+}
+
+// TestMigrationAnalyzer validates detection of destructive changes and the confirmation
+// flags needed to proceed with them
+func TestMigrationAnalyzer(t *testing.T) {
+	introspector := fakeIntrospector{columns: map[string]*ColumnDefinition{
+		"users.legacy_id": {Name: "legacy_id", DataType: "bigint"},
+		"users.email":     {Name: "email", DataType: "varchar(255)", Metadata: map[string]interface{}{"charset": "utf8mb4"}},
+		"users.nickname":  {Name: "nickname", DataType: "varchar(255)"},
+	}}
+	probe := countProbe{"users.legacy_id": 120, "users.email": 120, "users.nickname": 0, "users.notes": 0, "audit": 5000}
+	analyzer := NewMigrationAnalyzer("mysql", introspector, probe)
+
+	operations := []*DatabaseOperationSpec{
+		{Type: OperationDropColumn, TableName: "users", ColumnName: "legacy_id"},
+		{Type: OperationDropColumn, TableName: "users", ColumnName: "notes"},
+		{Type: OperationDropTable, TableName: "audit"},
+		{Type: OperationAlterColumn, TableName: "users", ColumnName: "email", DataType: "varchar(100)",
+			Parameters: map[string]interface{}{"charset": "latin1"}},
+		{Type: OperationAlterColumn, TableName: "users", ColumnName: "nickname", DataType: "varchar(20)"},
+		{Type: OperationCreateColumn, TableName: "users", ColumnName: "created_at"},
+	}
+	analysis := analyzer.Analyze(context.Background(), operations)
+	if analysis.Risk != RiskLevelCritical {
+		t.Errorf("expected critical risk, got %s", analysis.Risk)
+	}
+	rules := make(map[string]RiskLevel)
+	for _, finding := range analysis.Findings {
+		rules[finding.RuleID+":"+finding.Operation.ColumnName] = finding.Risk
+	}
+	want := map[string]RiskLevel{
+		"drop_column_with_data:legacy_id": RiskLevelCritical,
+		"drop_column:notes":               RiskLevelLow,
+		"drop_table_with_data:":           RiskLevelCritical,
+		"type_narrowing:email":            RiskLevelHigh,
+		"lossy_charset:email":             RiskLevelHigh,
+		"type_narrowing:nickname":         RiskLevelLow,
+	}
+	if len(rules) != len(want) {
+		t.Fatalf("unexpected findings %v", rules)
+	}
+	for rule, risk := range want {
+		if rules[rule] != risk {
+			t.Errorf("%s: risk %s, want %s", rule, rules[rule], risk)
+		}
+	}
+
+	required := analysis.RequiredConfirmations()
+	if len(required) != 4 {
+		t.Fatalf("unexpected confirmations %v", required)
+	}
+	var unconfirmed *UnconfirmedMigrationError
+	if err := analysis.Check(ConfirmDropTableWithData); !errors.As(err, &unconfirmed) || len(unconfirmed.Findings) != 3 {
+		t.Fatalf("expected three unconfirmed findings, got %v", err)
+	}
+	if err := analysis.Check(required...); err != nil {
+		t.Errorf("all confirmations given: %v", err)
+	}
+
+	resp, err := analyzer.Validate(context.Background(), &ValidationRequest{
+		Operations: operations,
+		Context:    map[string]interface{}{ConfirmationsContextKey: "allow_drop_column_with_data, allow_drop_table_with_data"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Success || len(resp.Violations) != 2 || len(resp.Warnings) != 4 {
+		t.Errorf("unexpected validation %d violations, %d warnings", len(resp.Violations), len(resp.Warnings))
+	}
+}
+
+// TestMigrationAnalyzerWithoutProbe validates that drops are treated as destructive
+// when data presence cannot be checked
+func TestMigrationAnalyzerWithoutProbe(t *testing.T) {
+	analyzer := NewMigrationAnalyzer("postgres", fakeIntrospector{}, nil)
+	analysis := analyzer.Analyze(context.Background(), []*DatabaseOperationSpec{
+		{Type: OperationDropColumn, SchemaName: "app", TableName: "users", ColumnName: "email"},
+	})
+	if len(analysis.Findings) != 1 || analysis.Findings[0].Confirmation != ConfirmDropColumnWithData {
+		t.Fatalf("unexpected findings %+v", analysis.Findings)
+	}
+	if !analyzer.charsetCovers("utf8", "latin1") || analyzer.charsetCovers("latin1", "utf8") {
+		t.Error("postgres utf8 is full unicode")
+	}
+}
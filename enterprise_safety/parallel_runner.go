@@ -0,0 +1,237 @@
+// Package enterprise_safety parallel execution of cascade scenarios in isolated namespaces
+package enterprise_safety
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/schemabounce/kolumn/sdk/helpers/ids"
+)
+
+// DefaultScenarioParallelism is the number of scenarios run at once when none is set
+const DefaultScenarioParallelism = 4
+
+// NamespaceProvisioner creates and removes the schema or database a scenario runs in
+type NamespaceProvisioner interface {
+	Provision(ctx context.Context, db *sql.DB, namespace string) error
+	Teardown(ctx context.Context, db *sql.DB, namespace string) error
+}
+
+// SchemaNamespace isolates scenarios in their own schema (PostgreSQL and similar)
+type SchemaNamespace struct{}
+
+// Provision implements NamespaceProvisioner
+func (SchemaNamespace) Provision(ctx context.Context, db *sql.DB, namespace string) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf("CREATE SCHEMA %s", namespace))
+	return err
+}
+
+// Teardown implements NamespaceProvisioner
+func (SchemaNamespace) Teardown(ctx context.Context, db *sql.DB, namespace string) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf("DROP SCHEMA IF EXISTS %s CASCADE", namespace))
+	return err
+}
+
+// DatabaseNamespace isolates scenarios in their own database (MySQL and similar)
+type DatabaseNamespace struct{}
+
+// Provision implements NamespaceProvisioner
+func (DatabaseNamespace) Provision(ctx context.Context, db *sql.DB, namespace string) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf("CREATE DATABASE %s", namespace))
+	return err
+}
+
+// Teardown implements NamespaceProvisioner
+func (DatabaseNamespace) Teardown(ctx context.Context, db *sql.DB, namespace string) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf("DROP DATABASE IF EXISTS %s", namespace))
+	return err
+}
+
+// namespaceVariables names the scenario variable holding the namespace per provider type
+var namespaceVariables = map[string]string{
+	"postgres": "schema",
+	"mysql":    "database",
+}
+
+// IsolatedScenario builds a scenario whose objects live in the given namespace
+type IsolatedScenario struct {
+	Name  string
+	Build func(namespace string) (CascadeTestScenario, error)
+}
+
+// IsolatedScenarios turns a scenario document into isolated scenarios; namespaceVar is
+// the variable the document uses for its schema or database, e.g. "schema"
+func IsolatedScenarios(data []byte, namespaceVar string, vars map[string]string) ([]IsolatedScenario, error) {
+	withNamespace := func(namespace string) map[string]string {
+		merged := map[string]string{namespaceVar: namespace}
+		for k, v := range vars {
+			if k != namespaceVar {
+				merged[k] = v
+			}
+		}
+		return merged
+	}
+
+	// Load once up front so broken documents fail before anything is provisioned
+	scenarios, err := LoadScenarios(data, withNamespace("validation"))
+	if err != nil {
+		return nil, err
+	}
+	isolated := make([]IsolatedScenario, len(scenarios))
+	for i, scenario := range scenarios {
+		index := i
+		isolated[i] = IsolatedScenario{
+			Name: scenario.Name,
+			Build: func(namespace string) (CascadeTestScenario, error) {
+				scenarios, err := LoadScenarios(data, withNamespace(namespace))
+				if err != nil {
+					return CascadeTestScenario{}, err
+				}
+				return scenarios[index], nil
+			},
+		}
+	}
+	return isolated, nil
+}
+
+// LibraryIsolatedScenarios returns the built-in scenarios of a provider type as
+// isolated scenarios (see LibraryScenarios)
+func LibraryIsolatedScenarios(providerType string, vars map[string]string, names ...string) ([]IsolatedScenario, error) {
+	data, err := scenarioLibrary.ReadFile("scenarios/" + providerType + ".yaml")
+	if err != nil {
+		return nil, fmt.Errorf("no scenario library for provider type %q", providerType)
+	}
+	isolated, err := IsolatedScenarios(data, namespaceVariables[providerType], vars)
+	if err != nil || len(names) == 0 {
+		return isolated, err
+	}
+	byName := make(map[string]IsolatedScenario, len(isolated))
+	for _, scenario := range isolated {
+		byName[scenario.Name] = scenario
+	}
+	selected := make([]IsolatedScenario, 0, len(names))
+	for _, name := range names {
+		scenario, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("scenario library for %s has no scenario %q", providerType, name)
+		}
+		selected = append(selected, scenario)
+	}
+	return selected, nil
+}
+
+// namespacePattern limits namespaces to identifiers safe to splice into DDL
+var namespacePattern = regexp.MustCompile(`^[a-z_][a-z0-9_]{0,62}$`)
+
+// ParallelScenarioRunner runs scenarios concurrently, each in a schema or database of its
+// own that is provisioned before and dropped after the scenario, so scenarios cannot
+// see each other's objects. Results are collected into Framework in input order.
+type ParallelScenarioRunner struct {
+	Framework       *CascadeDeleteTestFramework
+	Parallelism     int
+	Namespaces      NamespaceProvisioner
+	NamespacePrefix string
+}
+
+// NewParallelScenarioRunner creates a runner for a provider type. Postgres scenarios are
+// isolated per schema and MySQL scenarios per database; other provider types must set
+// Namespaces.
+func NewParallelScenarioRunner(providerType string, parallelism int) *ParallelScenarioRunner {
+	runner := &ParallelScenarioRunner{
+		Framework:       NewCascadeDeleteTestFramework(providerType),
+		Parallelism:     parallelism,
+		NamespacePrefix: "kolumn_t",
+	}
+	switch providerType {
+	case "postgres":
+		runner.Namespaces = SchemaNamespace{}
+	case "mysql":
+		runner.Namespaces = DatabaseNamespace{}
+	}
+	return runner
+}
+
+// newNamespace returns a unique namespace name
+func (r *ParallelScenarioRunner) newNamespace() (string, error) {
+	namespace := strings.ToLower(r.NamespacePrefix + "_" + ids.ULID())
+	if !namespacePattern.MatchString(namespace) {
+		return "", fmt.Errorf("invalid namespace %q", namespace)
+	}
+	return namespace, nil
+}
+
+// Run executes scenarios with up to Parallelism running at once and returns their
+// results in input order
+func (r *ParallelScenarioRunner) Run(ctx context.Context, db *sql.DB, scenarios []IsolatedScenario) []CascadeDeleteTestResult {
+	parallelism := r.Parallelism
+	if parallelism <= 0 {
+		parallelism = DefaultScenarioParallelism
+	}
+
+	results := make([]CascadeDeleteTestResult, len(scenarios))
+	sem := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for i, scenario := range scenarios {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, scenario IsolatedScenario) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = r.runIsolated(ctx, db, scenario)
+		}(i, scenario)
+	}
+	wg.Wait()
+
+	for _, result := range results {
+		r.Framework.TestResults = append(r.Framework.TestResults, result)
+		r.Framework.updateMetrics(result)
+	}
+	return results
+}
+
+// runIsolated provisions a namespace, runs one scenario in it and tears it down
+func (r *ParallelScenarioRunner) runIsolated(ctx context.Context, db *sql.DB, scenario IsolatedScenario) CascadeDeleteTestResult {
+	failed := func(format string, args ...interface{}) CascadeDeleteTestResult {
+		return CascadeDeleteTestResult{
+			TestName:     scenario.Name,
+			TestType:     "cascade_delete",
+			ProviderType: r.Framework.ProviderType,
+			StartTime:    clock.Now(),
+			Error:        fmt.Sprintf(format, args...),
+			Metadata:     make(map[string]interface{}),
+		}
+	}
+	if r.Namespaces == nil {
+		return failed("no namespace provisioner for provider type %s", r.Framework.ProviderType)
+	}
+
+	namespace, err := r.newNamespace()
+	if err != nil {
+		return failed("Namespace setup failed: %v", err)
+	}
+	built, err := scenario.Build(namespace)
+	if err != nil {
+		return failed("Scenario build failed: %v", err)
+	}
+	if err := r.Namespaces.Provision(ctx, db, namespace); err != nil {
+		return failed("Namespace provisioning failed: %v", err)
+	}
+
+	// Each scenario records into a framework of its own; results are merged by Run
+	framework := NewCascadeDeleteTestFramework(r.Framework.ProviderType)
+	framework.Deleter = r.Framework.Deleter
+	framework.RunCascadeDeleteTest(ctx, db, built)
+	result := framework.TestResults[0] // carries the duration recorded on return
+	if result.Metadata == nil {
+		result.Metadata = make(map[string]interface{})
+	}
+	result.Metadata["namespace"] = namespace
+	if err := r.Namespaces.Teardown(context.WithoutCancel(ctx), db, namespace); err != nil {
+		result.Metadata["teardown_error"] = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,146 @@
+package enterprise_safety
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingDriver accepts every statement and answers every query with a single 1
+type recordingDriver struct {
+	mu         sync.Mutex
+	statements []string
+}
+
+func (d *recordingDriver) Open(name string) (driver.Conn, error) { return &recordingConn{d}, nil }
+
+func (d *recordingDriver) record(query string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.statements = append(d.statements, query)
+}
+
+type recordingConn struct{ d *recordingDriver }
+
+func (c *recordingConn) Prepare(query string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c *recordingConn) Close() error                              { return nil }
+func (c *recordingConn) Begin() (driver.Tx, error)                 { return nil, driver.ErrSkip }
+
+func (c *recordingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.d.record(query)
+	return driver.RowsAffected(0), nil
+}
+
+func (c *recordingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.d.record(query)
+	return &oneRow{}, nil
+}
+
+type oneRow struct{ done bool }
+
+func (r *oneRow) Columns() []string { return []string{"count"} }
+func (r *oneRow) Close() error      { return nil }
+func (r *oneRow) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = int64(1)
+	return nil
+}
+
+var testDriver = &recordingDriver{}
+
+func init() {
+	sql.Register("kolumn_recording", testDriver)
+}
+
+// countingNamespaces wraps a provisioner and tracks how many namespaces exist at once
+type countingNamespaces struct {
+	SchemaNamespace
+	mu                sync.Mutex
+	active, maxActive int
+	provisioned       []string
+	tornDown          []string
+}
+
+func (n *countingNamespaces) Provision(ctx context.Context, db *sql.DB, namespace string) error {
+	n.mu.Lock()
+	n.active++
+	if n.active > n.maxActive {
+		n.maxActive = n.active
+	}
+	n.provisioned = append(n.provisioned, namespace)
+	n.mu.Unlock()
+	time.Sleep(5 * time.Millisecond)
+	return n.SchemaNamespace.Provision(ctx, db, namespace)
+}
+
+func (n *countingNamespaces) Teardown(ctx context.Context, db *sql.DB, namespace string) error {
+	n.mu.Lock()
+	n.active--
+	n.tornDown = append(n.tornDown, namespace)
+	n.mu.Unlock()
+	return n.SchemaNamespace.Teardown(ctx, db, namespace)
+}
+
+// TestParallelScenarioRunner validates namespace isolation, bounded parallelism and
+// result ordering
+func TestParallelScenarioRunner(t *testing.T) {
+	db, err := sql.Open("kolumn_recording", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	library, err := LibraryIsolatedScenarios("postgres", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scenarios := append(append([]IsolatedScenario{}, library...), library...)
+
+	runner := NewParallelScenarioRunner("postgres", 2)
+	namespaces := &countingNamespaces{}
+	runner.Namespaces = namespaces
+	results := runner.Run(context.Background(), db, scenarios)
+
+	if len(results) != len(scenarios) {
+		t.Fatalf("expected %d results, got %d", len(scenarios), len(results))
+	}
+	seen := make(map[string]bool)
+	for i, result := range results {
+		if result.TestName != scenarios[i].Name {
+			t.Errorf("result %d is %s, want %s", i, result.TestName, scenarios[i].Name)
+		}
+		namespace, _ := result.Metadata["namespace"].(string)
+		if !strings.HasPrefix(namespace, "kolumn_t_") || seen[namespace] {
+			t.Errorf("result %d ran in namespace %q, want a fresh one", i, namespace)
+		}
+		seen[namespace] = true
+		if result.PrimaryObject.SchemaName != namespace {
+			t.Errorf("scenario objects must live in the namespace, got %q", result.PrimaryObject.SchemaName)
+		}
+	}
+	if namespaces.maxActive > 2 {
+		t.Errorf("at most 2 scenarios may run at once, saw %d", namespaces.maxActive)
+	}
+	if len(namespaces.tornDown) != len(namespaces.provisioned) {
+		t.Errorf("every provisioned namespace must be torn down: %d of %d", len(namespaces.tornDown), len(namespaces.provisioned))
+	}
+	if runner.Framework.Metrics.TotalTests != len(scenarios) {
+		t.Errorf("framework recorded %d tests, want %d", runner.Framework.Metrics.TotalTests, len(scenarios))
+	}
+
+	testDriver.mu.Lock()
+	defer testDriver.mu.Unlock()
+	for _, stmt := range testDriver.statements {
+		if strings.Contains(stmt, "kolumn_cascade_test") {
+			t.Fatalf("statement escaped its namespace: %s", stmt)
+		}
+	}
+}
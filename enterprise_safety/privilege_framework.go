@@ -0,0 +1,335 @@
+// Package enterprise_safety permissions and privilege escalation testing framework
+package enterprise_safety
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+)
+
+// PublicGrantee is the pseudo-role every database user belongs to
+const PublicGrantee = "PUBLIC"
+
+// Grant is one privilege held on an object
+type Grant struct {
+	Grantee         string `json:"grantee"`
+	Privilege       string `json:"privilege"` // upper case, e.g. SELECT
+	WithGrantOption bool   `json:"with_grant_option,omitempty"`
+}
+
+// String formats the grant as GRANT-statement shorthand
+func (g Grant) String() string {
+	s := fmt.Sprintf("%s TO %s", g.Privilege, g.Grantee)
+	if g.WithGrantOption {
+		s += " WITH GRANT OPTION"
+	}
+	return s
+}
+
+// PrivilegeInspector enumerates the grants held on an object after it was created
+type PrivilegeInspector interface {
+	Grants(ctx context.Context, obj ObjectInfo) ([]Grant, error)
+}
+
+// PrivilegeInspectorFunc adapts a function to PrivilegeInspector
+type PrivilegeInspectorFunc func(ctx context.Context, obj ObjectInfo) ([]Grant, error)
+
+// Grants implements PrivilegeInspector
+func (f PrivilegeInspectorFunc) Grants(ctx context.Context, obj ObjectInfo) ([]Grant, error) {
+	return f(ctx, obj)
+}
+
+// SQLPrivilegeInspector reads table and view grants from information_schema
+type SQLPrivilegeInspector struct {
+	DB           *sql.DB
+	ProviderType string // postgres or mysql
+}
+
+// Grants implements PrivilegeInspector
+func (i SQLPrivilegeInspector) Grants(ctx context.Context, obj ObjectInfo) ([]Grant, error) {
+	var query string
+	var args []interface{}
+	switch i.ProviderType {
+	case "postgres":
+		query = `SELECT grantee, privilege_type, is_grantable FROM information_schema.table_privileges
+			WHERE table_schema = $1 AND table_name = $2`
+		args = []interface{}{obj.SchemaName, obj.Name}
+	case "mysql":
+		query = `SELECT GRANTEE, PRIVILEGE_TYPE, IS_GRANTABLE FROM information_schema.TABLE_PRIVILEGES
+			WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?`
+		args = []interface{}{obj.DatabaseName, obj.Name}
+	default:
+		return nil, fmt.Errorf("no privilege query for provider type %s", i.ProviderType)
+	}
+
+	rows, err := i.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var grants []Grant
+	for rows.Next() {
+		var grantee, privilege, grantable string
+		if err := rows.Scan(&grantee, &privilege, &grantable); err != nil {
+			return nil, err
+		}
+		grants = append(grants, Grant{
+			Grantee:         grantee,
+			Privilege:       strings.ToUpper(privilege),
+			WithGrantOption: strings.EqualFold(grantable, "YES"),
+		})
+	}
+	return grants, rows.Err()
+}
+
+// actionPrivileges maps governance access-control actions to SQL privileges
+var actionPrivileges = map[string][]string{
+	"read":   {"SELECT"},
+	"write":  {"INSERT", "UPDATE", "DELETE"},
+	"admin":  {"SELECT", "INSERT", "UPDATE", "DELETE", "TRUNCATE", "REFERENCES", "TRIGGER"},
+	"select": {"SELECT"},
+	"insert": {"INSERT"},
+	"update": {"UPDATE"},
+	"delete": {"DELETE"},
+}
+
+// ExpectedGrants derives the grants governance intends for a data object from its access
+// controls. Principals naming a governance role are translated through the role's
+// provider mapping for providerType.
+func ExpectedGrants(object *core.DataObjectContext, roles map[string]*core.RoleContext, providerType string) []Grant {
+	if object == nil {
+		return nil
+	}
+	seen := make(map[Grant]bool)
+	var grants []Grant
+	for _, control := range object.AccessControls {
+		grantee := control.Principal
+		if role, ok := roles[grantee]; ok && role.ProviderRoles[providerType] != "" {
+			grantee = role.ProviderRoles[providerType]
+		}
+		for _, action := range control.Actions {
+			privileges, ok := actionPrivileges[strings.ToLower(action)]
+			if !ok {
+				privileges = []string{strings.ToUpper(action)}
+			}
+			for _, privilege := range privileges {
+				grant := Grant{Grantee: grantee, Privilege: privilege}
+				if !seen[grant] {
+					seen[grant] = true
+					grants = append(grants, grant)
+				}
+			}
+		}
+	}
+	sortGrants(grants)
+	return grants
+}
+
+// PrivilegeExpectation is what an object's grants must look like after creation
+type PrivilegeExpectation struct {
+	Name     string                          `json:"name"`
+	Object   ObjectInfo                      `json:"object"`
+	Expected []Grant                         `json:"expected"`
+	Owner    string                          `json:"owner,omitempty"`  // the owner's implicit grants are not checked
+	Ignore   []string                        `json:"ignore,omitempty"` // grantees outside the test, e.g. superusers
+	Create   func(ctx context.Context) error `json:"-"`                // optionally creates the object first
+}
+
+// PrivilegeFinding is one deviation from the expected grants
+type PrivilegeFinding struct {
+	Kind     string `json:"kind"` // missing, excess, public, grant_option
+	Grant    Grant  `json:"grant"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// PrivilegeTestResult is the outcome of one privilege check
+type PrivilegeTestResult struct {
+	TestName     string             `json:"test_name"`
+	ProviderType string             `json:"provider_type"`
+	Object       ObjectInfo         `json:"object"`
+	StartTime    time.Time          `json:"start_time"`
+	Duration     time.Duration      `json:"duration"`
+	Success      bool               `json:"success"`
+	Actual       []Grant            `json:"actual"`
+	Findings     []PrivilegeFinding `json:"findings,omitempty"`
+	Error        string             `json:"error,omitempty"`
+}
+
+// PrivilegeTestMetrics tracks privilege testing results
+type PrivilegeTestMetrics struct {
+	TotalTests      int
+	PassedTests     int
+	FailedTests     int
+	MissingGrants   int
+	ExcessGrants    int
+	PublicGrants    int
+	GrantOptionLeak int
+}
+
+// PrivilegeTestFramework verifies that objects created by a provider carry exactly the
+// grants governance intends: nothing missing, nothing extra, nothing granted to PUBLIC
+// and no grant options that let grantees hand their privileges on
+type PrivilegeTestFramework struct {
+	ProviderType string
+	Inspector    PrivilegeInspector
+	TestResults  []PrivilegeTestResult
+	Metrics      PrivilegeTestMetrics
+}
+
+// NewPrivilegeTestFramework creates a framework reading grants with inspector
+func NewPrivilegeTestFramework(providerType string, inspector PrivilegeInspector) *PrivilegeTestFramework {
+	return &PrivilegeTestFramework{
+		ProviderType: providerType,
+		Inspector:    inspector,
+		TestResults:  make([]PrivilegeTestResult, 0),
+	}
+}
+
+// RunPrivilegeTest creates the object when the expectation says how, enumerates its
+// grants and compares them with the expected ones
+func (f *PrivilegeTestFramework) RunPrivilegeTest(ctx context.Context, expectation PrivilegeExpectation) (result PrivilegeTestResult) {
+	result = PrivilegeTestResult{
+		TestName:     expectation.Name,
+		ProviderType: f.ProviderType,
+		Object:       expectation.Object,
+		StartTime:    clock.Now(),
+	}
+	defer func() {
+		result.Duration = clock.Since(result.StartTime)
+		f.TestResults = append(f.TestResults, result)
+		f.updateMetrics(result)
+	}()
+
+	if expectation.Create != nil {
+		if err := expectation.Create(ctx); err != nil {
+			result.Error = fmt.Sprintf("Create failed: %v", err)
+			return result
+		}
+	}
+
+	actual, err := f.Inspector.Grants(ctx, expectation.Object)
+	if err != nil {
+		result.Error = fmt.Sprintf("Listing grants failed: %v", err)
+		return result
+	}
+	sortGrants(actual)
+	result.Actual = actual
+	result.Findings = ComparePrivileges(expectation, actual)
+	result.Success = len(result.Findings) == 0
+	return result
+}
+
+// ComparePrivileges reports how actual grants deviate from an expectation. Grantee names
+// are compared case-insensitively.
+func ComparePrivileges(expectation PrivilegeExpectation, actual []Grant) []PrivilegeFinding {
+	key := func(g Grant) string { return strings.ToLower(g.Grantee) + "\x00" + strings.ToUpper(g.Privilege) }
+	skip := func(grantee string) bool {
+		if expectation.Owner != "" && strings.EqualFold(grantee, expectation.Owner) {
+			return true
+		}
+		for _, ignored := range expectation.Ignore {
+			if strings.EqualFold(grantee, ignored) {
+				return true
+			}
+		}
+		return false
+	}
+
+	expected := make(map[string]Grant, len(expectation.Expected))
+	for _, grant := range expectation.Expected {
+		expected[key(grant)] = grant
+	}
+	held := make(map[string]bool, len(actual))
+
+	var findings []PrivilegeFinding
+	for _, grant := range actual {
+		if skip(grant.Grantee) {
+			continue
+		}
+		held[key(grant)] = true
+		want, ok := expected[key(grant)]
+		switch {
+		case strings.EqualFold(grant.Grantee, PublicGrantee) && !ok:
+			findings = append(findings, PrivilegeFinding{Kind: "public", Grant: grant, Severity: "CRITICAL",
+				Message: fmt.Sprintf("%s is granted to every user through PUBLIC", grant.Privilege)})
+		case !ok:
+			findings = append(findings, PrivilegeFinding{Kind: "excess", Grant: grant, Severity: "HIGH",
+				Message: fmt.Sprintf("%s holds %s, which governance does not grant", grant.Grantee, grant.Privilege)})
+		case grant.WithGrantOption && !want.WithGrantOption:
+			findings = append(findings, PrivilegeFinding{Kind: "grant_option", Grant: grant, Severity: "HIGH",
+				Message: fmt.Sprintf("%s can re-grant %s to other roles", grant.Grantee, grant.Privilege)})
+		}
+	}
+	for _, grant := range expectation.Expected {
+		if !held[key(grant)] && !skip(grant.Grantee) {
+			findings = append(findings, PrivilegeFinding{Kind: "missing", Grant: grant, Severity: "MEDIUM",
+				Message: fmt.Sprintf("%s is missing %s", grant.Grantee, grant.Privilege)})
+		}
+	}
+	return findings
+}
+
+func sortGrants(grants []Grant) {
+	sort.Slice(grants, func(i, j int) bool {
+		if grants[i].Grantee != grants[j].Grantee {
+			return grants[i].Grantee < grants[j].Grantee
+		}
+		return grants[i].Privilege < grants[j].Privilege
+	})
+}
+
+func (f *PrivilegeTestFramework) updateMetrics(result PrivilegeTestResult) {
+	f.Metrics.TotalTests++
+	if result.Success {
+		f.Metrics.PassedTests++
+	} else {
+		f.Metrics.FailedTests++
+	}
+	for _, finding := range result.Findings {
+		switch finding.Kind {
+		case "missing":
+			f.Metrics.MissingGrants++
+		case "excess":
+			f.Metrics.ExcessGrants++
+		case "public":
+			f.Metrics.PublicGrants++
+		case "grant_option":
+			f.Metrics.GrantOptionLeak++
+		}
+	}
+}
+
+// PrivilegeTestReport summarises privilege test results
+type PrivilegeTestReport struct {
+	ProviderType    string                `json:"provider_type"`
+	GeneratedAt     time.Time             `json:"generated_at"`
+	TestResults     []PrivilegeTestResult `json:"test_results"`
+	Metrics         PrivilegeTestMetrics  `json:"metrics"`
+	Recommendations []string              `json:"recommendations"`
+}
+
+// GenerateReport generates a privilege test report
+func (f *PrivilegeTestFramework) GenerateReport() PrivilegeTestReport {
+	report := PrivilegeTestReport{
+		ProviderType: f.ProviderType,
+		GeneratedAt:  clock.Now(),
+		TestResults:  f.TestResults,
+		Metrics:      f.Metrics,
+	}
+	if f.Metrics.PublicGrants > 0 {
+		report.Recommendations = append(report.Recommendations, "Revoke privileges granted to PUBLIC on created objects")
+	}
+	if f.Metrics.ExcessGrants > 0 || f.Metrics.GrantOptionLeak > 0 {
+		report.Recommendations = append(report.Recommendations, "Grant only the privileges governance access controls require, without GRANT OPTION")
+	}
+	if f.Metrics.MissingGrants > 0 {
+		report.Recommendations = append(report.Recommendations, "Apply every governance access control when creating objects")
+	}
+	return report
+}
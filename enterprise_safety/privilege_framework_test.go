@@ -0,0 +1,76 @@
+package enterprise_safety
+
+import (
+	"context"
+	"testing"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+)
+
+// TestPrivilegeFramework validates expected grants from governance and detection of
+// missing, excess, public and re-grantable privileges
+func TestPrivilegeFramework(t *testing.T) {
+	object := &core.DataObjectContext{
+		Name: "customers",
+		AccessControls: []core.AccessControl{
+			{Principal: "analyst", Actions: []string{"read"}},
+			{Principal: "etl_writer", Actions: []string{"read", "write"}},
+		},
+	}
+	roles := map[string]*core.RoleContext{
+		"analyst": {Name: "analyst", ProviderRoles: map[string]string{"postgres": "pg_analyst"}},
+	}
+	expected := ExpectedGrants(object, roles, "postgres")
+	if len(expected) != 5 || expected[0] != (Grant{Grantee: "etl_writer", Privilege: "DELETE"}) {
+		t.Fatalf("unexpected expected grants %v", expected)
+	}
+
+	actual := []Grant{
+		{Grantee: "app_owner", Privilege: "SELECT"},
+		{Grantee: "pg_analyst", Privilege: "SELECT", WithGrantOption: true},
+		{Grantee: "PUBLIC", Privilege: "SELECT"},
+		{Grantee: "etl_writer", Privilege: "SELECT"},
+		{Grantee: "etl_writer", Privilege: "INSERT"},
+		{Grantee: "etl_writer", Privilege: "UPDATE"},
+		{Grantee: "etl_writer", Privilege: "TRUNCATE"},
+	}
+	framework := NewPrivilegeTestFramework("postgres", PrivilegeInspectorFunc(func(ctx context.Context, obj ObjectInfo) ([]Grant, error) {
+		return actual, nil
+	}))
+	result := framework.RunPrivilegeTest(context.Background(), PrivilegeExpectation{
+		Name:     "customers_grants",
+		Object:   ObjectInfo{Type: "table", Name: "customers", SchemaName: "app"},
+		Expected: expected,
+		Owner:    "app_owner",
+	})
+
+	kinds := make(map[string]int)
+	for _, finding := range result.Findings {
+		kinds[finding.Kind]++
+	}
+	if result.Success || kinds["public"] != 1 || kinds["excess"] != 1 || kinds["grant_option"] != 1 || kinds["missing"] != 1 {
+		t.Fatalf("unexpected findings %+v", result.Findings)
+	}
+
+	actual = []Grant{
+		{Grantee: "pg_analyst", Privilege: "SELECT"},
+		{Grantee: "ETL_WRITER", Privilege: "select"},
+		{Grantee: "etl_writer", Privilege: "INSERT"},
+		{Grantee: "etl_writer", Privilege: "UPDATE"},
+		{Grantee: "etl_writer", Privilege: "DELETE"},
+		{Grantee: "postgres", Privilege: "SELECT"},
+	}
+	clean := framework.RunPrivilegeTest(context.Background(), PrivilegeExpectation{
+		Name:     "customers_exact",
+		Expected: expected,
+		Ignore:   []string{"postgres"},
+	})
+	if !clean.Success {
+		t.Fatalf("exact grants must pass: %+v", clean.Findings)
+	}
+
+	report := framework.GenerateReport()
+	if report.Metrics.FailedTests != 1 || report.Metrics.PublicGrants != 1 || len(report.Recommendations) != 3 {
+		t.Errorf("unexpected report %+v", report)
+	}
+}
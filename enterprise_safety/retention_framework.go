@@ -0,0 +1,324 @@
+// Package enterprise_safety data retention and TTL testing framework
+package enterprise_safety
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+	"github.com/schemabounce/kolumn/sdk/helpers/governance"
+)
+
+// DefaultRetentionAuditAction is the audit action expected for every purged record
+const DefaultRetentionAuditAction = "retention_purge"
+
+// RetentionRecord is a record seeded into a retention scenario
+type RetentionRecord struct {
+	Key string        `json:"key"`
+	Age time.Duration `json:"age"` // how old the record is when seeded
+}
+
+// RetentionScenario describes a resource under a retention policy and the records in it
+type RetentionScenario struct {
+	Name     string                       `json:"name"`
+	Resource governance.RetentionResource `json:"resource"`
+	Policy   string                       `json:"policy"` // e.g. "30d", "7 years", "permanent"
+	Records  []RetentionRecord            `json:"records"`
+
+	// AdvanceBy is how far time is moved forward before enforcement; zero moves to
+	// one day past the retention period
+	AdvanceBy time.Duration `json:"advance_by,omitempty"`
+
+	// AuditAction is the audit action each purge must raise; empty uses
+	// DefaultRetentionAuditAction
+	AuditAction string `json:"audit_action,omitempty"`
+}
+
+// RetentionTarget is the provider side of a retention test: it creates the resource with
+// its policy, runs the provider's enforcement and reports what is left
+type RetentionTarget interface {
+	// Seed creates scenario.Resource under policy and inserts the records, each aged by
+	// its Age relative to now
+	Seed(ctx context.Context, scenario RetentionScenario, policy *governance.RetentionPolicy, now time.Time) error
+
+	// Enforce runs retention enforcement (purge job, TTL sweep, lifecycle rule) as of now
+	Enforce(ctx context.Context, now time.Time) error
+
+	// Remaining returns the keys of records still present
+	Remaining(ctx context.Context) ([]string, error)
+
+	// AuditEvents returns the audit events raised since Seed
+	AuditEvents(ctx context.Context) ([]core.AuditEvent, error)
+
+	// Cleanup removes the resource
+	Cleanup(ctx context.Context) error
+}
+
+// TimeTraveler moves a retention test forward in time
+type TimeTraveler interface {
+	Advance(ctx context.Context, d time.Duration) error
+}
+
+// TimeTravelFunc adapts a function to TimeTraveler
+type TimeTravelFunc func(ctx context.Context, d time.Duration) error
+
+// Advance implements TimeTraveler
+func (f TimeTravelFunc) Advance(ctx context.Context, d time.Duration) error {
+	return f(ctx, d)
+}
+
+// ClockTravel advances an injected fake clock, e.g. the one returned by
+// testing.UseFakeClock, for providers that read time through the SDK clock
+func ClockTravel(clock interface{ Advance(time.Duration) }) TimeTraveler {
+	return TimeTravelFunc(func(ctx context.Context, d time.Duration) error {
+		clock.Advance(d)
+		return nil
+	})
+}
+
+// SQLTimeTravel backdates data in the backend instead of moving a clock, for
+// enforcement that runs inside the database. Each statement is formatted with the
+// number of seconds to move, e.g.
+//
+//	UPDATE app.events SET created_at = created_at - make_interval(secs => %d)
+func SQLTimeTravel(db *sql.DB, statements ...string) TimeTraveler {
+	return TimeTravelFunc(func(ctx context.Context, d time.Duration) error {
+		for _, stmt := range statements {
+			if _, err := db.ExecContext(ctx, fmt.Sprintf(stmt, int64(d/time.Second))); err != nil {
+				return fmt.Errorf("time travel statement failed: %v", err)
+			}
+		}
+		return nil
+	})
+}
+
+// RetentionTestResult is the outcome of one retention scenario
+type RetentionTestResult struct {
+	TestName     string        `json:"test_name"`
+	ProviderType string        `json:"provider_type"`
+	Policy       string        `json:"policy"`
+	StartTime    time.Time     `json:"start_time"`
+	Duration     time.Duration `json:"duration"`
+	AdvancedBy   time.Duration `json:"advanced_by"`
+	Success      bool          `json:"success"`
+
+	Expired  []string `json:"expired"`  // records past retention that must be gone
+	Retained []string `json:"retained"` // records within retention that must remain
+
+	NotPurged     []string `json:"not_purged,omitempty"`     // expired but still present
+	PurgedEarly   []string `json:"purged_early,omitempty"`   // removed while still retained
+	UnauditedKeys []string `json:"unaudited_keys,omitempty"` // purged without an audit event
+	Error         string   `json:"error,omitempty"`
+}
+
+// RetentionTestMetrics tracks retention testing results
+type RetentionTestMetrics struct {
+	TotalTests        int
+	PassedTests       int
+	FailedTests       int
+	NotPurged         int
+	PurgedEarly       int
+	UnauditedPurges   int
+	TotalTestDuration time.Duration
+}
+
+// RetentionTestFramework validates that providers remove data once its retention period
+// passes, keep data that is still retained, and audit every removal
+type RetentionTestFramework struct {
+	ProviderType string
+	TimeTravel   TimeTraveler
+	TestResults  []RetentionTestResult
+	Metrics      RetentionTestMetrics
+}
+
+// NewRetentionTestFramework creates a retention framework that moves time with travel
+func NewRetentionTestFramework(providerType string, travel TimeTraveler) *RetentionTestFramework {
+	return &RetentionTestFramework{
+		ProviderType: providerType,
+		TimeTravel:   travel,
+		TestResults:  make([]RetentionTestResult, 0),
+	}
+}
+
+// RunRetentionTest seeds the scenario, moves time forward, runs enforcement and checks
+// which records are left and which purges were audited
+func (f *RetentionTestFramework) RunRetentionTest(ctx context.Context, target RetentionTarget, scenario RetentionScenario) (result RetentionTestResult) {
+	result = RetentionTestResult{
+		TestName:     scenario.Name,
+		ProviderType: f.ProviderType,
+		Policy:       scenario.Policy,
+		StartTime:    clock.Now(),
+	}
+	defer func() {
+		result.Duration = clock.Since(result.StartTime)
+		f.TestResults = append(f.TestResults, result)
+		f.updateMetrics(result)
+	}()
+
+	// Step 1: Parse the policy
+	policy, err := governance.ParseRetentionPolicy(scenario.Policy)
+	if err != nil {
+		result.Error = fmt.Sprintf("Invalid policy: %v", err)
+		return result
+	}
+
+	// Step 2: Seed the resource and its records
+	now := clock.Now()
+	if err := target.Seed(ctx, scenario, policy, now); err != nil {
+		result.Error = fmt.Sprintf("Seed failed: %v", err)
+		return result
+	}
+	defer func() {
+		if err := target.Cleanup(ctx); err != nil && result.Error == "" {
+			result.Error = fmt.Sprintf("Cleanup failed: %v", err)
+			result.Success = false
+		}
+	}()
+
+	// Step 3: Move past the retention period
+	advance := scenario.AdvanceBy
+	if advance == 0 {
+		advance = retentionPeriod(policy) + 24*time.Hour
+	}
+	if f.TimeTravel != nil {
+		if err := f.TimeTravel.Advance(ctx, advance); err != nil {
+			result.Error = fmt.Sprintf("Time travel failed: %v", err)
+			return result
+		}
+	}
+	result.AdvancedBy = advance
+	now = now.Add(advance)
+
+	// Step 4: Enforce retention
+	if err := target.Enforce(ctx, now); err != nil {
+		result.Error = fmt.Sprintf("Enforcement failed: %v", err)
+		return result
+	}
+
+	// Step 5: Compare what is left with what the policy allows
+	for _, record := range scenario.Records {
+		if !policy.Permanent && record.Age+advance > retentionPeriod(policy) {
+			result.Expired = append(result.Expired, record.Key)
+		} else {
+			result.Retained = append(result.Retained, record.Key)
+		}
+	}
+	remaining, err := target.Remaining(ctx)
+	if err != nil {
+		result.Error = fmt.Sprintf("Listing remaining records failed: %v", err)
+		return result
+	}
+	present := make(map[string]bool, len(remaining))
+	for _, key := range remaining {
+		present[key] = true
+	}
+	var purged []string
+	for _, key := range result.Expired {
+		if present[key] {
+			result.NotPurged = append(result.NotPurged, key)
+		} else {
+			purged = append(purged, key)
+		}
+	}
+	for _, key := range result.Retained {
+		if !present[key] {
+			result.PurgedEarly = append(result.PurgedEarly, key)
+		}
+	}
+
+	// Step 6: Every purge must be audited
+	events, err := target.AuditEvents(ctx)
+	if err != nil {
+		result.Error = fmt.Sprintf("Reading audit events failed: %v", err)
+		return result
+	}
+	action := scenario.AuditAction
+	if action == "" {
+		action = DefaultRetentionAuditAction
+	}
+	audited := auditedKeys(events, action)
+	for _, key := range purged {
+		if !audited[key] {
+			result.UnauditedKeys = append(result.UnauditedKeys, key)
+		}
+	}
+
+	result.Success = len(result.NotPurged) == 0 && len(result.PurgedEarly) == 0 && len(result.UnauditedKeys) == 0
+	return result
+}
+
+// retentionPeriod converts a policy to a duration
+func retentionPeriod(policy *governance.RetentionPolicy) time.Duration {
+	return time.Duration(policy.Days) * 24 * time.Hour
+}
+
+// auditedKeys collects the record keys covered by audit events with the given action:
+// the event resource, or the keys listed in its "keys" detail for batch purges
+func auditedKeys(events []core.AuditEvent, action string) map[string]bool {
+	keys := make(map[string]bool)
+	for _, event := range events {
+		if event.Action != action {
+			continue
+		}
+		keys[event.Resource] = true
+		switch listed := event.Details["keys"].(type) {
+		case []string:
+			for _, key := range listed {
+				keys[key] = true
+			}
+		case []interface{}:
+			for _, key := range listed {
+				if s, ok := key.(string); ok {
+					keys[s] = true
+				}
+			}
+		}
+	}
+	return keys
+}
+
+func (f *RetentionTestFramework) updateMetrics(result RetentionTestResult) {
+	f.Metrics.TotalTests++
+	f.Metrics.TotalTestDuration += result.Duration
+	if result.Success {
+		f.Metrics.PassedTests++
+	} else {
+		f.Metrics.FailedTests++
+	}
+	f.Metrics.NotPurged += len(result.NotPurged)
+	f.Metrics.PurgedEarly += len(result.PurgedEarly)
+	f.Metrics.UnauditedPurges += len(result.UnauditedKeys)
+}
+
+// RetentionTestReport summarises retention test results
+type RetentionTestReport struct {
+	ProviderType    string                `json:"provider_type"`
+	GeneratedAt     time.Time             `json:"generated_at"`
+	TestResults     []RetentionTestResult `json:"test_results"`
+	Metrics         RetentionTestMetrics  `json:"metrics"`
+	Recommendations []string              `json:"recommendations"`
+}
+
+// GenerateReport generates a retention test report
+func (f *RetentionTestFramework) GenerateReport() RetentionTestReport {
+	report := RetentionTestReport{
+		ProviderType: f.ProviderType,
+		GeneratedAt:  clock.Now(),
+		TestResults:  f.TestResults,
+		Metrics:      f.Metrics,
+	}
+	if f.Metrics.NotPurged > 0 {
+		report.Recommendations = append(report.Recommendations, "Schedule retention enforcement so expired data is removed")
+	}
+	if f.Metrics.PurgedEarly > 0 {
+		report.Recommendations = append(report.Recommendations, "Fix retention enforcement removing data that is still within its retention period")
+	}
+	if f.Metrics.UnauditedPurges > 0 {
+		report.Recommendations = append(report.Recommendations, "Raise an audit event for every retention purge")
+	}
+	sort.Strings(report.Recommendations)
+	return report
+}
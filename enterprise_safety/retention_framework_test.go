@@ -0,0 +1,111 @@
+package enterprise_safety
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+	"github.com/schemabounce/kolumn/sdk/helpers/governance"
+	"github.com/schemabounce/kolumn/sdk/types"
+)
+
+// steppedClock is a clock that only moves when advanced
+type steppedClock struct{ now time.Time }
+
+func (c *steppedClock) Now() time.Time          { return c.now }
+func (c *steppedClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+// memoryRetention keeps records in memory and purges them like a TTL sweep
+type memoryRetention struct {
+	audit   bool
+	policy  *governance.RetentionPolicy
+	created map[string]time.Time
+	events  []core.AuditEvent
+}
+
+func (m *memoryRetention) Seed(ctx context.Context, scenario RetentionScenario, policy *governance.RetentionPolicy, now time.Time) error {
+	m.policy = policy
+	m.created = make(map[string]time.Time)
+	for _, record := range scenario.Records {
+		m.created[record.Key] = now.Add(-record.Age)
+	}
+	return nil
+}
+
+func (m *memoryRetention) Enforce(ctx context.Context, now time.Time) error {
+	if m.policy.Permanent {
+		return nil
+	}
+	var purged []string
+	for key, created := range m.created {
+		if now.Sub(created) > time.Duration(m.policy.Days)*24*time.Hour {
+			delete(m.created, key)
+			purged = append(purged, key)
+		}
+	}
+	if m.audit && len(purged) > 0 {
+		m.events = append(m.events, core.AuditEvent{Action: DefaultRetentionAuditAction, Details: map[string]interface{}{"keys": purged}})
+	}
+	return nil
+}
+
+func (m *memoryRetention) Remaining(ctx context.Context) ([]string, error) {
+	keys := make([]string, 0, len(m.created))
+	for key := range m.created {
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (m *memoryRetention) AuditEvents(ctx context.Context) ([]core.AuditEvent, error) {
+	return m.events, nil
+}
+
+func (m *memoryRetention) Cleanup(ctx context.Context) error { return nil }
+
+// TestRetentionFramework validates expiry, retention and audit checks with a fake clock
+func TestRetentionFramework(t *testing.T) {
+	fake := &steppedClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	defer SetClock(types.Clock(fake))()
+
+	scenario := RetentionScenario{
+		Name:   "events_30d",
+		Policy: "30d",
+		Records: []RetentionRecord{
+			{Key: "old", Age: 20 * 24 * time.Hour},
+			{Key: "new", Age: 0},
+		},
+		AdvanceBy: 15 * 24 * time.Hour,
+	}
+
+	framework := NewRetentionTestFramework("memory", ClockTravel(fake))
+	result := framework.RunRetentionTest(context.Background(), &memoryRetention{audit: true}, scenario)
+	if !result.Success || len(result.Expired) != 1 || result.Expired[0] != "old" || len(result.Retained) != 1 {
+		t.Fatalf("expected old to expire and new to be retained: %+v", result)
+	}
+	if got := fake.Now(); !got.Equal(time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected the injected clock to move forward 15 days, now %s", got)
+	}
+
+	unaudited := framework.RunRetentionTest(context.Background(), &memoryRetention{}, scenario)
+	if unaudited.Success || len(unaudited.UnauditedKeys) != 1 {
+		t.Errorf("purges without audit events must fail: %+v", unaudited)
+	}
+
+	scenario.Policy = "permanent"
+	scenario.AdvanceBy = 0
+	if kept := framework.RunRetentionTest(context.Background(), &memoryRetention{audit: true}, scenario); !kept.Success || len(kept.Expired) != 0 {
+		t.Errorf("permanent data must never expire: %+v", kept)
+	}
+
+	scenario.Policy = "whenever"
+	if invalid := framework.RunRetentionTest(context.Background(), &memoryRetention{}, scenario); invalid.Error == "" {
+		t.Error("expected an invalid policy to be reported")
+	}
+
+	report := framework.GenerateReport()
+	if report.Metrics.TotalTests != 4 || report.Metrics.FailedTests != 2 || len(report.Recommendations) != 1 {
+		t.Errorf("unexpected report %+v", report)
+	}
+}
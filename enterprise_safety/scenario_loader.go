@@ -0,0 +1,239 @@
+// Package enterprise_safety loader for declarative cascade test scenarios in YAML or JSON
+package enterprise_safety
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ScenarioFile is the document format read by LoadScenarios. Field names follow the
+// JSON tags of CascadeTestScenario, and since YAML is a superset of JSON the same
+// loader reads both:
+//
+//	vars:
+//	  schema: cascade_test
+//	scenarios:
+//	  - name: view_dependency
+//	    primary_object: {type: table, name: orders, schema_name: "${schema}"}
+//	    dependent_objects:
+//	      - {type: view, name: order_totals, schema_name: "${schema}"}
+//	    expected_behavior: {should_cascade: true}
+//	    setup_sql:
+//	      - CREATE TABLE ${schema}.orders (id integer)
+//
+// ${name} placeholders anywhere in the file are replaced by variables; vars in the file
+// are defaults that the caller's variables override.
+type ScenarioFile struct {
+	Vars      map[string]string     `json:"vars,omitempty"`
+	Scenarios []CascadeTestScenario `json:"scenarios"`
+}
+
+//go:embed scenarios/*.yaml
+var scenarioLibrary embed.FS
+
+// scenarioVarPattern matches ${name} placeholders; other uses of $ such as $1 or $$
+// function bodies are left alone
+var scenarioVarPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// LoadScenarios parses a YAML or JSON scenario document. Unknown fields are rejected so
+// typos in hand-written files do not silently weaken a test.
+func LoadScenarios(data []byte, vars map[string]string) ([]CascadeTestScenario, error) {
+	// Read the defaults first so placeholders can be expanded before decoding
+	var header struct {
+		Vars map[string]string `yaml:"vars"`
+	}
+	if err := yaml.Unmarshal(data, &header); err != nil {
+		return nil, fmt.Errorf("invalid scenario document: %w", err)
+	}
+	merged := make(map[string]string, len(header.Vars)+len(vars))
+	for k, v := range header.Vars {
+		merged[k] = v
+	}
+	for k, v := range vars {
+		merged[k] = v
+	}
+
+	var missing []string
+	expanded := scenarioVarPattern.ReplaceAllFunc(data, func(match []byte) []byte {
+		name := string(match[2 : len(match)-1])
+		value, ok := merged[name]
+		if !ok {
+			missing = append(missing, name)
+			return match
+		}
+		return []byte(value)
+	})
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("undefined scenario variables: %s", strings.Join(uniqueStrings(missing), ", "))
+	}
+
+	var doc interface{}
+	if err := yaml.Unmarshal(expanded, &doc); err != nil {
+		return nil, fmt.Errorf("invalid scenario document: %w", err)
+	}
+	// Decode through JSON so the json tags of the framework types apply
+	encoded, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid scenario document: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.DisallowUnknownFields()
+	var file ScenarioFile
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("invalid scenario document: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for i := range file.Scenarios {
+		scenario := &file.Scenarios[i]
+		if err := validateScenario(scenario); err != nil {
+			return nil, fmt.Errorf("scenario %d: %w", i+1, err)
+		}
+		if seen[scenario.Name] {
+			return nil, fmt.Errorf("duplicate scenario %q", scenario.Name)
+		}
+		seen[scenario.Name] = true
+		normalizeExpectedResults(scenario)
+	}
+	return file.Scenarios, nil
+}
+
+// LoadScenarioFile reads scenarios from a .yaml, .yml or .json file
+func LoadScenarioFile(path string, vars map[string]string) ([]CascadeTestScenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scenario file: %w", err)
+	}
+	scenarios, err := LoadScenarios(data, vars)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return scenarios, nil
+}
+
+// LoadScenarioDir reads every scenario file in dir in name order
+func LoadScenarioDir(dir string, vars map[string]string) ([]CascadeTestScenario, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scenario directory: %w", err)
+	}
+	var scenarios []CascadeTestScenario
+	for _, entry := range entries {
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".yaml", ".yml", ".json":
+		default:
+			continue
+		}
+		if entry.IsDir() {
+			continue
+		}
+		loaded, err := LoadScenarioFile(filepath.Join(dir, entry.Name()), vars)
+		if err != nil {
+			return nil, err
+		}
+		scenarios = append(scenarios, loaded...)
+	}
+	return scenarios, nil
+}
+
+// LibraryScenarios returns the built-in scenarios for a provider type: fk_cascade,
+// view_dependency and trigger_cleanup for postgres, view_dependency for mysql. Names
+// filters the result; none returns every scenario.
+func LibraryScenarios(providerType string, vars map[string]string, names ...string) ([]CascadeTestScenario, error) {
+	data, err := scenarioLibrary.ReadFile("scenarios/" + providerType + ".yaml")
+	if err != nil {
+		return nil, fmt.Errorf("no scenario library for provider type %q", providerType)
+	}
+	scenarios, err := LoadScenarios(data, vars)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return scenarios, nil
+	}
+	byName := make(map[string]CascadeTestScenario, len(scenarios))
+	for _, scenario := range scenarios {
+		byName[scenario.Name] = scenario
+	}
+	selected := make([]CascadeTestScenario, 0, len(names))
+	for _, name := range names {
+		scenario, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("scenario library for %s has no scenario %q", providerType, name)
+		}
+		selected = append(selected, scenario)
+	}
+	return selected, nil
+}
+
+// LibraryProviderTypes returns the provider types with a built-in scenario library
+func LibraryProviderTypes() []string {
+	entries, _ := scenarioLibrary.ReadDir("scenarios")
+	types := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		types = append(types, strings.TrimSuffix(entry.Name(), ".yaml"))
+	}
+	sort.Strings(types)
+	return types
+}
+
+// RunScenarios runs scenarios in order and returns their results
+func (f *CascadeDeleteTestFramework) RunScenarios(ctx context.Context, db *sql.DB, scenarios []CascadeTestScenario) []CascadeDeleteTestResult {
+	results := make([]CascadeDeleteTestResult, 0, len(scenarios))
+	for _, scenario := range scenarios {
+		results = append(results, f.RunCascadeDeleteTest(ctx, db, scenario))
+	}
+	return results
+}
+
+func validateScenario(scenario *CascadeTestScenario) error {
+	if scenario.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if scenario.PrimaryObject.Type == "" || scenario.PrimaryObject.Name == "" {
+		return fmt.Errorf("%s: primary_object needs a type and a name", scenario.Name)
+	}
+	for i, obj := range scenario.DependentObjects {
+		if obj.Type == "" || obj.Name == "" {
+			return fmt.Errorf("%s: dependent_objects[%d] needs a type and a name", scenario.Name, i)
+		}
+	}
+	for i, query := range scenario.ValidationQueries {
+		if query.Name == "" || query.Query == "" {
+			return fmt.Errorf("%s: validation_queries[%d] needs a name and a query", scenario.Name, i)
+		}
+	}
+	return nil
+}
+
+// normalizeExpectedResults turns whole numbers decoded as float64 into int64, the type
+// database drivers scan COUNT(*) into, so validation queries compare equal
+func normalizeExpectedResults(scenario *CascadeTestScenario) {
+	for i, query := range scenario.ValidationQueries {
+		if f, ok := query.ExpectedResult.(float64); ok && f == float64(int64(f)) {
+			scenario.ValidationQueries[i].ExpectedResult = int64(f)
+		}
+	}
+}
+
+func uniqueStrings(values []string) []string {
+	sort.Strings(values)
+	out := values[:0]
+	for i, v := range values {
+		if i == 0 || v != values[i-1] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
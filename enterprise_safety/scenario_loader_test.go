@@ -0,0 +1,100 @@
+package enterprise_safety
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestLoadScenarios validates YAML and JSON parsing, variable expansion and validation
+func TestLoadScenarios(t *testing.T) {
+	yamlDoc := `
+vars:
+  schema: qa
+scenarios:
+  - name: drop_orders
+    primary_object: {type: table, name: orders, schema_name: "${schema}"}
+    dependent_objects:
+      - {type: view, name: order_totals, schema_name: "${schema}"}
+    expected_behavior: {should_cascade: true}
+    setup_sql:
+      - CREATE FUNCTION ${schema}.f() RETURNS int AS $$ SELECT $1 $$
+    validation_queries:
+      - {name: no_views, query: "SELECT COUNT(*) FROM ${schema}.v", expected_result: 0}
+`
+	scenarios, err := LoadScenarios([]byte(yamlDoc), map[string]string{"schema": "ci_42"})
+	if err != nil {
+		t.Fatalf("LoadScenarios: %v", err)
+	}
+	if len(scenarios) != 1 {
+		t.Fatalf("expected 1 scenario, got %d", len(scenarios))
+	}
+	s := scenarios[0]
+	if s.PrimaryObject.SchemaName != "ci_42" || !s.ExpectedBehavior.ShouldCascade {
+		t.Errorf("caller variables must override file defaults, got %+v", s.PrimaryObject)
+	}
+	if s.SetupSQL[0] != "CREATE FUNCTION ci_42.f() RETURNS int AS $$ SELECT $1 $$" {
+		t.Errorf("only ${name} placeholders may be expanded, got %q", s.SetupSQL[0])
+	}
+	if got, ok := s.ValidationQueries[0].ExpectedResult.(int64); !ok || got != 0 {
+		t.Errorf("whole-number expectations must decode as int64, got %T", s.ValidationQueries[0].ExpectedResult)
+	}
+
+	jsonDoc := `{"scenarios": [{"name": "j", "primary_object": {"type": "view", "name": "v"}}]}`
+	if scenarios, err := LoadScenarios([]byte(jsonDoc), nil); err != nil || scenarios[0].PrimaryObject.Type != "view" {
+		t.Errorf("JSON documents must load: %v", err)
+	}
+
+	for doc, want := range map[string]string{
+		`{"scenarios": [{"name": "x", "primary_object": {"type": "table", "name": "t"}, "expect": {}}]}`:                                                    "unknown field",
+		`{"scenarios": [{"name": "x", "primary_object": {"type": "table", "name": "${table}"}}]}`:                                                           "undefined scenario variables: table",
+		`{"scenarios": [{"name": "x", "primary_object": {"type": "table"}}]}`:                                                                               "needs a type and a name",
+		`{"scenarios": [{"name": "x", "primary_object": {"type": "table", "name": "t"}}, {"name": "x", "primary_object": {"type": "table", "name": "t"}}]}`: "duplicate scenario",
+	} {
+		if _, err := LoadScenarios([]byte(doc), nil); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error containing %q, got %v", want, err)
+		}
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.json"), []byte(jsonDoc), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if scenarios, err := LoadScenarioDir(dir, nil); err != nil || len(scenarios) != 1 {
+		t.Errorf("LoadScenarioDir: %d scenarios, %v", len(scenarios), err)
+	}
+}
+
+// TestLibraryScenarios validates the built-in scenario library loads for every provider type
+func TestLibraryScenarios(t *testing.T) {
+	types := LibraryProviderTypes()
+	if strings.Join(types, ",") != "mysql,postgres" {
+		t.Fatalf("unexpected library provider types %v", types)
+	}
+	for _, providerType := range types {
+		if _, err := LibraryScenarios(providerType, nil); err != nil {
+			t.Errorf("%s library: %v", providerType, err)
+		}
+	}
+
+	scenarios, err := LibraryScenarios("postgres", map[string]string{"schema": "run_7"}, "trigger_cleanup", "fk_cascade")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scenarios) != 2 || scenarios[0].Name != "trigger_cleanup" || scenarios[1].PrimaryObject.SchemaName != "run_7" {
+		t.Fatalf("unexpected selection %+v", scenarios)
+	}
+	if !strings.Contains(scenarios[0].SetupSQL[2], "$$ BEGIN") {
+		t.Errorf("dollar-quoted bodies must survive expansion: %q", scenarios[0].SetupSQL[2])
+	}
+	if _, err := LibraryScenarios("postgres", nil, "missing"); err == nil {
+		t.Error("expected an error for an unknown library scenario")
+	}
+	if _, err := LibraryScenarios("oracle", nil); err == nil {
+		t.Error("expected an error for a provider type without a library")
+	}
+}
@@ -0,0 +1,302 @@
+// Package anonymize rewrites discovery and state dumps so they can be shared in bug
+// reports without leaking schema names, identifiers or personal data.
+//
+// Every word of a string value is replaced by a stable pseudonym derived from a keyed
+// hash, so the same name maps to the same pseudonym wherever it appears: an object ID
+// such as "db.public.users" still contains the pseudonym of its schema property
+// "public", and a reference such as ${postgres.table.users.id} still points at the
+// anonymized users table. Separators, JSON numbers, object keys, the values of
+// structural fields (types, categories, statuses, timestamps) and the shape of the
+// document are kept, so the dump still reproduces the problem. Numbers inside strings
+// are kept only when short and alone, such as a port or an index; number sequences
+// shaped like IP addresses, phone, card or social security numbers are pseudonymized.
+package anonymize
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// PseudonymPrefix starts every pseudonym, keeping pseudonyms valid identifiers.
+const PseudonymPrefix = "anon_"
+
+// DefaultKeepFields are fields whose string values describe structure rather than user
+// data and are kept verbatim.
+var DefaultKeepFields = []string{
+	"type", "object_type", "resource_type", "provider_type", "data_type", "kind",
+	"category", "classification", "severity", "status", "change_type", "relation_type",
+	"relationship", "system", "scan_type", "depth", "version", "checksum",
+	"discovered", "created_at", "updated_at", "last_updated", "timestamp", "duration",
+}
+
+// Options configures an Anonymizer.
+type Options struct {
+	// Key derives the pseudonyms. Dumps anonymized with the same key use the same
+	// pseudonyms, so several dumps from one system can be correlated. A random key is
+	// used when empty.
+	Key []byte
+
+	// KeepFields are kept in addition to DefaultKeepFields.
+	KeepFields []string
+}
+
+// Anonymizer issues pseudonyms. It is safe for concurrent use.
+type Anonymizer struct {
+	key  []byte
+	keep map[string]bool
+
+	mu         sync.Mutex
+	vocabulary map[string]bool   // words that are structure, kept verbatim
+	keys       map[string]bool   // object keys, kept within longer values such as references
+	pseudonyms map[string]string // word -> pseudonym
+	originals  map[string]string // pseudonym -> word
+}
+
+// New creates an Anonymizer.
+func New(opts Options) *Anonymizer {
+	key := opts.Key
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			panic("anonymize: no randomness available: " + err.Error())
+		}
+	}
+	keep := make(map[string]bool, len(DefaultKeepFields)+len(opts.KeepFields))
+	for _, field := range append(append([]string(nil), DefaultKeepFields...), opts.KeepFields...) {
+		keep[field] = true
+	}
+	return &Anonymizer{
+		key:        key,
+		keep:       keep,
+		vocabulary: make(map[string]bool),
+		keys:       make(map[string]bool),
+		pseudonyms: make(map[string]string),
+		originals:  make(map[string]string),
+	}
+}
+
+// Keep adds words that are kept verbatim wherever they appear, such as provider names.
+// The words of kept fields are added automatically. Object keys are kept inside longer
+// values, e.g. the attribute of a reference, but a value that is just a key is replaced.
+func (a *Anonymizer) Keep(words ...string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, w := range words {
+		a.vocabulary[w] = true
+	}
+}
+
+// Pseudonym returns the pseudonym of a single word.
+func (a *Anonymizer) Pseudonym(word string) string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.pseudonym(word)
+}
+
+func (a *Anonymizer) pseudonym(word string) string {
+	if p, ok := a.pseudonyms[word]; ok {
+		return p
+	}
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte(word))
+	sum := hex.EncodeToString(mac.Sum(nil))
+	// lengthen on the unlikely collision so pseudonyms stay reversible
+	for n := 8; ; n += 4 {
+		p := PseudonymPrefix + sum[:n]
+		if other, taken := a.originals[p]; !taken || other == word {
+			a.pseudonyms[word] = p
+			a.originals[p] = word
+			return p
+		}
+	}
+}
+
+// String anonymizes every word of s. Words are runs of letters, digits and
+// underscores; short lone runs of digits, kept words and pseudonyms are left as they
+// are. Sequences of digit groups and long digit runs are replaced as one pseudonym.
+func (a *Anonymizer) String(s string) string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.rewrite(s)
+}
+
+// numberSequence matches digit groups joined by separators, such as IP addresses and
+// phone, card or social security numbers, and digit runs long enough to identify someone
+var numberSequence = regexp.MustCompile(`[0-9]+(?:[ .\-/()]{1,2}[0-9]+)+|[0-9]{7,}`)
+
+func (a *Anonymizer) rewrite(s string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range numberSequence.FindAllStringIndex(s, -1) {
+		if !wordBoundary(s, m[0], m[1]) {
+			continue
+		}
+		a.rewriteWords(&b, s, last, m[0])
+		b.WriteString(a.pseudonym(s[m[0]:m[1]]))
+		last = m[1]
+	}
+	a.rewriteWords(&b, s, last, len(s))
+	return b.String()
+}
+
+// rewriteWords writes s[from:to] with every word anonymized
+func (a *Anonymizer) rewriteWords(b *strings.Builder, s string, from, to int) {
+	start := -1
+	flush := func(end int) {
+		if start >= 0 {
+			b.WriteString(a.word(s[start:end], end-start == len(s)))
+			start = -1
+		}
+	}
+	for i, r := range s[from:to] {
+		if isWordRune(r) {
+			if start < 0 {
+				start = from + i
+			}
+			continue
+		}
+		flush(from + i)
+		b.WriteRune(r)
+	}
+	flush(to)
+}
+
+// word anonymizes one word; whole is set when the word is the entire value
+func (a *Anonymizer) word(w string, whole bool) string {
+	if a.vocabulary[w] || (a.keys[w] && !whole) || isNumber(w) {
+		return w
+	}
+	if _, issued := a.originals[w]; issued {
+		return w
+	}
+	return a.pseudonym(w)
+}
+
+// Value anonymizes a decoded JSON value and returns the copy. Object keys are kept
+// except in the objects named by identifierMaps, whose keys are identifiers such as
+// resource IDs and are anonymized like values. Strings in kept fields, RFC 3339
+// timestamps, numbers, bools and nulls are unchanged. Anonymize a whole dump in one
+// call so that the words of kept fields are known before anything is rewritten.
+func (a *Anonymizer) Value(v interface{}, identifierMaps ...string) interface{} {
+	idMaps := make(map[string]bool, len(identifierMaps))
+	for _, field := range identifierMaps {
+		idMaps[field] = true
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.learn(v, "", idMaps)
+	return a.value(v, "", idMaps)
+}
+
+// learn records object keys and adds the words of kept fields to the vocabulary
+func (a *Anonymizer) learn(v interface{}, field string, idMaps map[string]bool) {
+	switch v := v.(type) {
+	case string:
+		if a.keep[field] {
+			for _, w := range strings.FieldsFunc(v, func(r rune) bool { return !isWordRune(r) }) {
+				a.vocabulary[w] = true
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			a.learn(item, field, idMaps)
+		}
+	case map[string]interface{}:
+		for key, item := range v {
+			if !idMaps[field] {
+				a.keys[key] = true
+				a.learn(item, key, idMaps)
+			} else {
+				a.learn(item, "", idMaps)
+			}
+		}
+	}
+}
+
+func (a *Anonymizer) value(v interface{}, field string, idMaps map[string]bool) interface{} {
+	switch v := v.(type) {
+	case string:
+		if a.keep[field] || isTimestamp(v) {
+			return v
+		}
+		return a.rewrite(v)
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = a.value(item, field, idMaps)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			if idMaps[field] {
+				out[a.rewrite(key)] = a.value(item, "", idMaps)
+			} else {
+				out[key] = a.value(item, key, idMaps)
+			}
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// JSON anonymizes a JSON document. See Value for identifierMaps.
+func (a *Anonymizer) JSON(data []byte, identifierMaps ...string) ([]byte, error) {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(a.Value(v, identifierMaps...))
+}
+
+// Mapping returns the words replaced so far keyed by pseudonym, so the reporter can
+// translate replies that mention pseudonyms. It is the key to the anonymized dump and
+// must not be shared with it.
+func (a *Anonymizer) Mapping() map[string]string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	out := make(map[string]string, len(a.originals))
+	for p, w := range a.originals {
+		out[p] = w
+	}
+	return out
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// wordBoundary reports whether s[start:end] is not part of a longer word
+func wordBoundary(s string, start, end int) bool {
+	if before, _ := utf8.DecodeLastRuneInString(s[:start]); start > 0 && isWordRune(before) {
+		return false
+	}
+	if after, _ := utf8.DecodeRuneInString(s[end:]); end < len(s) && isWordRune(after) {
+		return false
+	}
+	return true
+}
+
+func isNumber(w string) bool {
+	for _, r := range w {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isTimestamp(s string) bool {
+	_, err := time.Parse(time.RFC3339Nano, s)
+	return err == nil
+}
@@ -0,0 +1,113 @@
+package anonymize
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStringKeepsStructure(t *testing.T) {
+	a := New(Options{Key: []byte("k")})
+	a.Keep("postgres", "table", "id")
+
+	users := a.Pseudonym("users")
+	require.True(t, strings.HasPrefix(users, PseudonymPrefix))
+	require.Equal(t, users, a.Pseudonym("users"))
+	require.Equal(t, users, New(Options{Key: []byte("k")}).Pseudonym("users"), "same key, same pseudonyms")
+	require.NotEqual(t, users, New(Options{Key: []byte("other")}).Pseudonym("users"))
+
+	require.Equal(t, "${postgres.table."+users+".id}", a.String("${postgres.table.users.id}"))
+	require.Equal(t, a.Pseudonym("db")+"."+a.Pseudonym("public")+"."+users+"[3]", a.String("db.public.users[3]"))
+	// pseudonyms are not anonymized twice
+	require.Equal(t, users, a.String(users))
+	require.Equal(t, "users", a.Mapping()[users])
+	require.Len(t, a.Mapping(), 3)
+}
+
+func TestValue(t *testing.T) {
+	dump := `{
+		"objects": [{
+			"id": "db.public.users",
+			"name": "users",
+			"type": "table",
+			"discovered": "2026-10-16T09:00:00Z",
+			"properties": {
+				"schema": "public",
+				"comment": "Registered accounts",
+				"row_count": 1200,
+				"columns": [{"name": "email", "type": "varchar", "nullable": false}]
+			},
+			"source": {"system": "postgres", "location": "prod-db.internal:5432"}
+		}],
+		"resources": {"db.public.users": {"owner": "alice@example.com", "at": "2026-10-16T09:00:00Z"}}
+	}`
+	a := New(Options{})
+	out, err := a.JSON([]byte(dump), "resources")
+	require.NoError(t, err)
+	for _, secret := range []string{"users", "public", "Registered", "email", "alice", "example", "prod"} {
+		require.NotContains(t, string(out), secret)
+	}
+
+	var v struct {
+		Objects []struct {
+			ID         string                 `json:"id"`
+			Name       string                 `json:"name"`
+			Type       string                 `json:"type"`
+			Discovered string                 `json:"discovered"`
+			Properties map[string]interface{} `json:"properties"`
+			Source     map[string]string      `json:"source"`
+		} `json:"objects"`
+		Resources map[string]map[string]string `json:"resources"`
+	}
+	require.NoError(t, json.Unmarshal(out, &v))
+	obj := v.Objects[0]
+	require.Equal(t, "table", obj.Type)
+	require.Equal(t, "2026-10-16T09:00:00Z", obj.Discovered)
+	require.Equal(t, "postgres", obj.Source["system"])
+	require.True(t, strings.HasSuffix(obj.ID, "."+obj.Properties["schema"].(string)+"."+obj.Name), "ID keeps its relation to schema and name")
+	require.Equal(t, float64(1200), obj.Properties["row_count"])
+	column := obj.Properties["columns"].([]interface{})[0].(map[string]interface{})
+	require.Equal(t, "varchar", column["type"])
+	require.Equal(t, false, column["nullable"])
+	require.Contains(t, obj.Source["location"], ":5432")
+
+	resource, ok := v.Resources[obj.ID]
+	require.True(t, ok, "identifier map keys use the same pseudonyms as values")
+	require.Equal(t, "2026-10-16T09:00:00Z", resource["at"])
+}
+
+func TestPIIShapedValues(t *testing.T) {
+	a := New(Options{Key: []byte("k")})
+	for value, want := range map[string]string{
+		"10.20.30.40":         a.Pseudonym("10.20.30.40"),
+		"+1 415-555-0132":     "+" + a.Pseudonym("1 415-555-0132"),
+		"(415) 555-0132":      "(" + a.Pseudonym("415) 555-0132"),
+		"4111 1111 1111 1111": a.Pseudonym("4111 1111 1111 1111"),
+		"4111111111111111":    a.Pseudonym("4111111111111111"),
+		"078-05-1120":         a.Pseudonym("078-05-1120"),
+		"1985-03-14":          a.Pseudonym("1985-03-14"),
+	} {
+		require.Equal(t, want, a.String(value), "%q is replaced as one pseudonym", value)
+	}
+	require.Equal(t, a.Pseudonym("host")+":"+a.Pseudonym("10.20.30.40"), a.String("host:10.20.30.40"))
+
+	// short lone numbers and digits inside words keep their shape
+	require.Equal(t, a.Pseudonym("db")+":5432", a.String("db:5432"))
+	require.Equal(t, a.Pseudonym("users")+"[3]", a.String("users[3]"))
+	require.Equal(t, a.Pseudonym("v2024")+"."+a.Pseudonym("users"), a.String("v2024.users"))
+}
+
+func TestValueKeysInValues(t *testing.T) {
+	a := New(Options{Key: []byte("k")})
+	out := a.Value(map[string]interface{}{
+		"email":    "alice@example.com",
+		"password": "email",
+		"ref":      "${postgres.table.users.email}",
+	}).(map[string]interface{})
+
+	require.Equal(t, a.Pseudonym("email"), out["password"], "a value equal to a key is replaced")
+	require.Equal(t, "${"+a.Pseudonym("postgres")+"."+a.Pseudonym("table")+"."+a.Pseudonym("users")+".email}", out["ref"],
+		"keys are kept inside references")
+}
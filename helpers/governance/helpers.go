@@ -410,6 +410,12 @@ func (gh *GovernanceHelper) parseRetentionDays(retentionPolicy string) int {
 	if retentionPolicy == "" {
 		return 0
 	}
+	if parsed, err := ParseRetentionPolicy(retentionPolicy); err == nil {
+		if parsed.Permanent {
+			return -1
+		}
+		return parsed.Days
+	}
 
 	policy := strings.ToLower(retentionPolicy)
 	switch {
@@ -0,0 +1,332 @@
+package governance
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/schemabounce/kolumn/sdk/runtime"
+)
+
+// =============================================================================
+// RETENTION POLICIES
+// =============================================================================
+
+// RetentionPolicy is a parsed retention policy. Data covered by a policy is kept for
+// Days and may be removed afterwards.
+type RetentionPolicy struct {
+	Days      int    `json:"days"`      // 0 means data may be removed immediately
+	Permanent bool   `json:"permanent"` // data is never removed
+	Source    string `json:"source"`    // policy text as written
+}
+
+var retentionPattern = regexp.MustCompile(`^(\d+)\s*(d|day|days|w|week|weeks|m|mo|month|months|y|year|years)$`)
+
+// ParseRetentionPolicy parses policies such as "30d", "90 days", "18 months",
+// "7 years", "permanent" or "immediate". Months count as 30 days and years as 365.
+func ParseRetentionPolicy(policy string) (*RetentionPolicy, error) {
+	text := strings.ToLower(strings.TrimSpace(policy))
+	switch text {
+	case "":
+		return nil, fmt.Errorf("retention policy is empty")
+	case "permanent", "forever", "indefinite":
+		return &RetentionPolicy{Permanent: true, Source: policy}, nil
+	case "immediate", "none":
+		return &RetentionPolicy{Days: 0, Source: policy}, nil
+	}
+
+	match := retentionPattern.FindStringSubmatch(text)
+	if match == nil {
+		return nil, fmt.Errorf("unrecognized retention policy %q (expected e.g. \"90 days\", \"7 years\" or \"permanent\")", policy)
+	}
+	n, err := strconv.Atoi(match[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid retention period in %q: %w", policy, err)
+	}
+
+	days := n
+	switch match[2][0] {
+	case 'w':
+		days = n * 7
+	case 'm':
+		days = n * 30
+	case 'y':
+		days = n * 365
+	}
+	return &RetentionPolicy{Days: days, Source: policy}, nil
+}
+
+// Longest returns the policy that keeps data longest, since every policy covering the
+// same data has to be satisfied. Permanent beats any finite period.
+func Longest(policies ...*RetentionPolicy) *RetentionPolicy {
+	var longest *RetentionPolicy
+	for _, p := range policies {
+		switch {
+		case p == nil:
+		case longest == nil, p.Permanent && !longest.Permanent:
+			longest = p
+		case !longest.Permanent && p.Days > longest.Days:
+			longest = p
+		}
+	}
+	return longest
+}
+
+// =============================================================================
+// PROVIDER TRANSLATORS
+// =============================================================================
+
+// RetentionResource identifies the resource a retention policy is enforced on
+type RetentionResource struct {
+	Provider string                 `json:"provider"` // postgres, kafka, s3, ...
+	Type     string                 `json:"type"`
+	Name     string                 `json:"name"`
+	Config   map[string]interface{} `json:"config,omitempty"`
+}
+
+// RetentionTranslator turns a retention policy into provider-specific planned changes
+type RetentionTranslator interface {
+	Translate(resource RetentionResource, policy *RetentionPolicy) ([]runtime.Operation, error)
+}
+
+// RetentionTranslatorFunc adapts a function to RetentionTranslator
+type RetentionTranslatorFunc func(resource RetentionResource, policy *RetentionPolicy) ([]runtime.Operation, error)
+
+// Translate implements RetentionTranslator
+func (f RetentionTranslatorFunc) Translate(resource RetentionResource, policy *RetentionPolicy) ([]runtime.Operation, error) {
+	return f(resource, policy)
+}
+
+var (
+	retentionMu          sync.RWMutex
+	retentionTranslators = map[string]RetentionTranslator{
+		"postgres": RetentionTranslatorFunc(postgresRetention),
+		"kafka":    RetentionTranslatorFunc(kafkaRetention),
+		"s3":       RetentionTranslatorFunc(s3Retention),
+	}
+)
+
+// RegisterRetentionTranslator adds or replaces the translator for a provider
+func RegisterRetentionTranslator(provider string, translator RetentionTranslator) {
+	retentionMu.Lock()
+	defer retentionMu.Unlock()
+	retentionTranslators[provider] = translator
+}
+
+// RetentionOperations translates a policy for a resource. Permanent policies need no
+// artifacts and return nil.
+func RetentionOperations(resource RetentionResource, policy *RetentionPolicy) ([]runtime.Operation, error) {
+	if policy == nil || policy.Permanent {
+		return nil, nil
+	}
+
+	retentionMu.RLock()
+	translator, ok := retentionTranslators[resource.Provider]
+	retentionMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("no retention translator registered for provider %q", resource.Provider)
+	}
+
+	ops, err := translator.Translate(resource, policy)
+	if err != nil {
+		return nil, fmt.Errorf("retention for %s %s: %w", resource.Type, resource.Name, err)
+	}
+	for i := range ops {
+		if ops[i].Metadata == nil {
+			ops[i].Metadata = make(map[string]any)
+		}
+		ops[i].Metadata["retention_policy"] = policy.Source
+		ops[i].Metadata["retention_days"] = policy.Days
+	}
+	return ops, nil
+}
+
+var sqlIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// postgresRetention partitions the table by day on its retention column and schedules
+// a pg_cron job that drops partitions older than the policy
+func postgresRetention(resource RetentionResource, policy *RetentionPolicy) ([]runtime.Operation, error) {
+	column, _ := resource.Config["retention_column"].(string)
+	if column == "" {
+		column = "created_at"
+	}
+	if !sqlIdentifier.MatchString(resource.Name) || !sqlIdentifier.MatchString(column) {
+		return nil, fmt.Errorf("table and retention column must be plain identifiers")
+	}
+
+	table := resource.Name
+	job := table + "_retention"
+	dropPartitions := fmt.Sprintf(`DO $retention$
+DECLARE part record;
+BEGIN
+  FOR part IN
+    SELECT c.relname FROM pg_inherits i
+    JOIN pg_class c ON c.oid = i.inhrelid
+    JOIN pg_class p ON p.oid = i.inhparent
+    WHERE p.relname = '%[1]s'
+      AND c.relname ~ '^%[1]s_p[0-9]{8}$'
+      AND to_date(right(c.relname, 8), 'YYYYMMDD') < current_date - %[2]d
+  LOOP
+    EXECUTE format('DROP TABLE IF EXISTS %%I', part.relname);
+  END LOOP;
+END
+$retention$`, table, policy.Days)
+
+	return []runtime.Operation{
+		{
+			ID:       table + ":retention:partitioning",
+			Action:   "configure",
+			Resource: runtime.ResourceRef{Type: "postgres_partitioning", Name: table},
+			Risk:     "medium",
+			Metadata: map[string]any{
+				"partition_by":          fmt.Sprintf("RANGE (%s)", column),
+				"partition_interval":    "1 day",
+				"partition_name_format": table + "_pYYYYMMDD",
+			},
+		},
+		{
+			ID:         table + ":retention:drop_job",
+			Action:     "create",
+			Resource:   runtime.ResourceRef{Type: "postgres_cron_job", Name: job},
+			Risk:       "high",
+			Statements: []string{fmt.Sprintf("SELECT cron.schedule('%s', '0 3 * * *', $job$%s$job$)", job, dropPartitions)},
+			Rollback:   []string{fmt.Sprintf("SELECT cron.unschedule('%s')", job)},
+		},
+	}, nil
+}
+
+// kafkaRetention sets retention.ms on the topic
+func kafkaRetention(resource RetentionResource, policy *RetentionPolicy) ([]runtime.Operation, error) {
+	ms := int64(policy.Days) * 24 * 60 * 60 * 1000
+	return []runtime.Operation{{
+		ID:       resource.Name + ":retention:topic_config",
+		Action:   "update",
+		Resource: runtime.ResourceRef{Type: "kafka_topic_config", Name: resource.Name},
+		Risk:     "medium",
+		Metadata: map[string]any{
+			"config": map[string]string{"retention.ms": strconv.FormatInt(ms, 10)},
+		},
+	}}, nil
+}
+
+// s3Retention adds a lifecycle rule expiring objects under the resource's prefix
+func s3Retention(resource RetentionResource, policy *RetentionPolicy) ([]runtime.Operation, error) {
+	prefix, _ := resource.Config["prefix"].(string)
+	days := policy.Days
+	if days < 1 {
+		days = 1 // S3 expiration is at least one day
+	}
+	rule := map[string]any{
+		"ID":         resource.Name + "-retention",
+		"Status":     "Enabled",
+		"Filter":     map[string]any{"Prefix": prefix},
+		"Expiration": map[string]any{"Days": days},
+	}
+	document, err := json.Marshal(rule)
+	if err != nil {
+		return nil, err
+	}
+	return []runtime.Operation{{
+		ID:         resource.Name + ":retention:lifecycle",
+		Action:     "update",
+		Resource:   runtime.ResourceRef{Type: "s3_bucket_lifecycle_rule", Name: resource.Name},
+		Risk:       "high",
+		Statements: []string{string(document)},
+		Metadata:   map[string]any{"lifecycle_rule": rule},
+	}}, nil
+}
+
+// =============================================================================
+// PLAN INTEGRATION
+// =============================================================================
+
+// ResourceRetention returns the retention policy for a resource: an explicit
+// config["retention_policy"] when present, otherwise the longest policy among the
+// governed columns listed in config["columns"]. It returns nil when no policy applies.
+func (gh *GovernanceHelper) ResourceRetention(config map[string]interface{}) (*RetentionPolicy, error) {
+	if text, ok := config["retention_policy"].(string); ok && text != "" {
+		return ParseRetentionPolicy(text)
+	}
+	if !gh.HasGovernance() {
+		return nil, nil
+	}
+
+	var policies []*RetentionPolicy
+	for _, name := range columnNames(config["columns"]) {
+		columnGov, err := gh.middleware.GetColumnGovernance(name)
+		if err != nil || columnGov.RetentionPolicy == "" {
+			continue
+		}
+		policy, err := ParseRetentionPolicy(columnGov.RetentionPolicy)
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", name, err)
+		}
+		policies = append(policies, policy)
+	}
+	return Longest(policies...), nil
+}
+
+// AddRetentionOperations appends retention artifacts to a plan for every created
+// resource that carries a retention policy. The resource config is read from the
+// operation's "config" metadata.
+func (gh *GovernanceHelper) AddRetentionOperations(plan *runtime.PlanResponse) error {
+	var extra []runtime.Operation
+	for _, op := range plan.Operations {
+		if op.Action != "create" {
+			continue
+		}
+		config, _ := op.Metadata["config"].(map[string]any)
+		if config == nil {
+			continue
+		}
+		policy, err := gh.ResourceRetention(config)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op.ID, err)
+		}
+		if policy == nil {
+			continue
+		}
+
+		ops, err := RetentionOperations(RetentionResource{
+			Provider: plan.Provider,
+			Type:     op.Resource.Type,
+			Name:     op.Resource.Name,
+			Config:   config,
+		}, policy)
+		if err != nil {
+			return err
+		}
+		for i := range ops {
+			ops[i].Metadata["depends_on"] = op.ID
+		}
+		extra = append(extra, ops...)
+	}
+
+	sort.SliceStable(extra, func(i, j int) bool { return extra[i].ID < extra[j].ID })
+	plan.Operations = append(plan.Operations, extra...)
+	return nil
+}
+
+func columnNames(columns interface{}) []string {
+	var names []string
+	switch list := columns.(type) {
+	case []string:
+		names = append(names, list...)
+	case []interface{}:
+		for _, item := range list {
+			switch col := item.(type) {
+			case string:
+				names = append(names, col)
+			case map[string]interface{}:
+				if name, ok := col["name"].(string); ok {
+					names = append(names, name)
+				}
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,104 @@
+package governance
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/schemabounce/kolumn/sdk/runtime"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRetentionPolicy(t *testing.T) {
+	cases := map[string]int{
+		"30d":       30,
+		"90 days":   90,
+		"2 weeks":   14,
+		"18 months": 540,
+		"7 years":   2555,
+		"immediate": 0,
+	}
+	for text, days := range cases {
+		policy, err := ParseRetentionPolicy(text)
+		require.NoError(t, err, text)
+		require.Equal(t, days, policy.Days, text)
+	}
+
+	policy, err := ParseRetentionPolicy("Permanent")
+	require.NoError(t, err)
+	require.True(t, policy.Permanent)
+
+	_, err = ParseRetentionPolicy("until further notice")
+	require.Error(t, err)
+}
+
+func TestLongest(t *testing.T) {
+	short := &RetentionPolicy{Days: 30}
+	long := &RetentionPolicy{Days: 2555}
+	forever := &RetentionPolicy{Permanent: true}
+
+	require.Same(t, long, Longest(short, nil, long))
+	require.Same(t, forever, Longest(short, forever, long))
+	require.Nil(t, Longest())
+}
+
+func TestRetentionOperations(t *testing.T) {
+	policy := &RetentionPolicy{Days: 90, Source: "90 days"}
+
+	ops, err := RetentionOperations(RetentionResource{Provider: "postgres", Type: "table", Name: "events", Config: map[string]interface{}{"retention_column": "occurred_at"}}, policy)
+	require.NoError(t, err)
+	require.Len(t, ops, 2)
+	require.Equal(t, "RANGE (occurred_at)", ops[0].Metadata["partition_by"])
+	require.Contains(t, ops[1].Statements[0], "cron.schedule('events_retention'")
+	require.Contains(t, ops[1].Statements[0], "current_date - 90")
+	require.Equal(t, "90 days", ops[1].Metadata["retention_policy"])
+
+	ops, err = RetentionOperations(RetentionResource{Provider: "kafka", Name: "clicks"}, policy)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"retention.ms": "7776000000"}, ops[0].Metadata["config"])
+
+	ops, err = RetentionOperations(RetentionResource{Provider: "s3", Name: "raw", Config: map[string]interface{}{"prefix": "landing/"}}, policy)
+	require.NoError(t, err)
+	require.Contains(t, ops[0].Statements[0], `"Expiration":{"Days":90}`)
+
+	_, err = RetentionOperations(RetentionResource{Provider: "postgres", Name: "events; drop table x"}, policy)
+	require.Error(t, err)
+
+	_, err = RetentionOperations(RetentionResource{Provider: "mongodb", Name: "docs"}, policy)
+	require.Error(t, err)
+
+	ops, err = RetentionOperations(RetentionResource{Provider: "mongodb", Name: "docs"}, &RetentionPolicy{Permanent: true})
+	require.NoError(t, err)
+	require.Nil(t, ops)
+}
+
+func TestAddRetentionOperations(t *testing.T) {
+	gh := NewGovernanceHelper()
+	require.NoError(t, gh.ExtractFromRequest(map[string]interface{}{
+		"governance_context": map[string]interface{}{
+			"columns": []interface{}{
+				map[string]interface{}{"name": "email", "retention_policy": "1 year"},
+				map[string]interface{}{"name": "ledger_id", "retention_policy": "7 years"},
+			},
+		},
+	}))
+
+	plan := &runtime.PlanResponse{
+		Provider: "postgres",
+		Operations: []runtime.Operation{
+			{ID: "create-accounts", Action: "create", Resource: runtime.ResourceRef{Type: "table", Name: "accounts"}, Metadata: map[string]any{
+				"config": map[string]any{"columns": []interface{}{
+					map[string]interface{}{"name": "email"},
+					map[string]interface{}{"name": "ledger_id"},
+				}},
+			}},
+			{ID: "update-other", Action: "update", Resource: runtime.ResourceRef{Type: "table", Name: "other"}},
+		},
+	}
+	require.NoError(t, gh.AddRetentionOperations(plan))
+	require.Len(t, plan.Operations, 4)
+
+	drop := plan.Operations[2]
+	require.Equal(t, "accounts:retention:drop_job", drop.ID)
+	require.Equal(t, "create-accounts", drop.Metadata["depends_on"])
+	require.True(t, strings.Contains(drop.Statements[0], "current_date - 2555"), "expected the longest column policy to win")
+}
@@ -0,0 +1,313 @@
+// Package hcl parses Kolumn configuration files (.kl) into typed block structures.
+//
+// It understands the subset of HCL used by Kolumn: labelled blocks (create, discover,
+// provider, variable, output, ...), attributes, nested blocks, lists, objects, heredocs,
+// references such as postgres_table.users.id and function calls. Every node carries its
+// source position so callers can produce precise error messages.
+package hcl
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Pos identifies a location in a source file.
+type Pos struct {
+	Filename string `json:"filename,omitempty"`
+	Line     int    `json:"line"`   // 1-based
+	Column   int    `json:"column"` // 1-based, in runes
+	Offset   int    `json:"offset"` // 0-based, in bytes
+}
+
+// String formats the position as file:line:column.
+func (p Pos) String() string {
+	if p.Filename == "" {
+		return fmt.Sprintf("%d:%d", p.Line, p.Column)
+	}
+	return fmt.Sprintf("%s:%d:%d", p.Filename, p.Line, p.Column)
+}
+
+// ParseError describes a syntax error at a specific position.
+type ParseError struct {
+	Pos     Pos
+	Message string
+}
+
+// Error implements the error interface.
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pos, e.Message)
+}
+
+// File is a parsed .kl file.
+type File struct {
+	Filename string   `json:"filename,omitempty"`
+	Blocks   []*Block `json:"blocks"`
+}
+
+// BlocksOfType returns the top-level blocks with the given keyword.
+func (f *File) BlocksOfType(blockType string) []*Block {
+	var blocks []*Block
+	for _, b := range f.Blocks {
+		if b.Type == blockType {
+			blocks = append(blocks, b)
+		}
+	}
+	return blocks
+}
+
+// CreateBlocks returns all top-level create blocks.
+func (f *File) CreateBlocks() []*Block {
+	return f.BlocksOfType(BlockCreate)
+}
+
+// DiscoverBlocks returns all top-level discover blocks.
+func (f *File) DiscoverBlocks() []*Block {
+	return f.BlocksOfType(BlockDiscover)
+}
+
+// Block keywords with special meaning in Kolumn configurations.
+const (
+	BlockCreate   = "create"
+	BlockDiscover = "discover"
+	BlockProvider = "provider"
+	BlockVariable = "variable"
+	BlockOutput   = "output"
+)
+
+// Block is a keyword followed by zero or more labels and a body.
+type Block struct {
+	Type       string                `json:"type"`
+	Labels     []string              `json:"labels,omitempty"`
+	Attributes map[string]*Attribute `json:"attributes,omitempty"`
+	Blocks     []*Block              `json:"blocks,omitempty"`
+	Pos        Pos                   `json:"pos"`
+	EndPos     Pos                   `json:"end_pos"`
+}
+
+// ResourceType returns the first label of a create or discover block.
+func (b *Block) ResourceType() string {
+	if len(b.Labels) > 0 {
+		return b.Labels[0]
+	}
+	return ""
+}
+
+// Name returns the second label of a create or discover block.
+func (b *Block) Name() string {
+	if len(b.Labels) > 1 {
+		return b.Labels[1]
+	}
+	return ""
+}
+
+// Address returns the canonical block address, e.g. postgres_table.users.
+func (b *Block) Address() string {
+	return strings.Join(b.Labels, ".")
+}
+
+// AttributeNames returns the attribute names in sorted order.
+func (b *Block) AttributeNames() []string {
+	names := make([]string, 0, len(b.Attributes))
+	for name := range b.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NestedBlocks returns the nested blocks with the given keyword.
+func (b *Block) NestedBlocks(blockType string) []*Block {
+	var blocks []*Block
+	for _, nested := range b.Blocks {
+		if nested.Type == blockType {
+			blocks = append(blocks, nested)
+		}
+	}
+	return blocks
+}
+
+// References returns every reference found in the block's attributes and nested blocks.
+func (b *Block) References() []*Reference {
+	var refs []*Reference
+	for _, name := range b.AttributeNames() {
+		refs = append(refs, b.Attributes[name].Value.References()...)
+	}
+	for _, nested := range b.Blocks {
+		refs = append(refs, nested.References()...)
+	}
+	return refs
+}
+
+// Body converts attributes and nested blocks into plain Go values. Repeated nested
+// blocks with the same keyword become a list. See Value.Interface for value mapping.
+func (b *Block) Body() map[string]interface{} {
+	body := make(map[string]interface{}, len(b.Attributes)+len(b.Blocks))
+	for name, attr := range b.Attributes {
+		body[name] = attr.Value.Interface()
+	}
+	for _, nested := range b.Blocks {
+		value := nested.Body()
+		switch existing := body[nested.Type].(type) {
+		case nil:
+			body[nested.Type] = value
+		case []interface{}:
+			body[nested.Type] = append(existing, value)
+		default:
+			body[nested.Type] = []interface{}{existing, value}
+		}
+	}
+	return body
+}
+
+// Attribute is a name = value assignment inside a block body.
+type Attribute struct {
+	Name  string `json:"name"`
+	Value *Value `json:"value"`
+	Pos   Pos    `json:"pos"`
+}
+
+// ValueKind classifies a Value.
+type ValueKind string
+
+// Value kinds produced by the parser.
+const (
+	KindString    ValueKind = "string"
+	KindNumber    ValueKind = "number"
+	KindBool      ValueKind = "bool"
+	KindNull      ValueKind = "null"
+	KindList      ValueKind = "list"
+	KindObject    ValueKind = "object"
+	KindReference ValueKind = "reference"
+	KindFunction  ValueKind = "function"
+)
+
+// Value is an attribute value or expression.
+type Value struct {
+	Kind ValueKind `json:"kind"`
+	Pos  Pos       `json:"pos"`
+
+	String string  `json:"string,omitempty"` // KindString (heredocs included)
+	Number float64 `json:"number,omitempty"` // KindNumber
+	Bool   bool    `json:"bool,omitempty"`   // KindBool
+
+	Items  []*Value          `json:"items,omitempty"`  // KindList
+	Fields map[string]*Value `json:"fields,omitempty"` // KindObject
+
+	Reference *Reference `json:"reference,omitempty"` // KindReference
+	Function  string     `json:"function,omitempty"`  // KindFunction
+	Args      []*Value   `json:"args,omitempty"`      // KindFunction
+
+	// Source is the raw expression text for references and function calls
+	Source string `json:"source,omitempty"`
+}
+
+// IsExpression reports whether the value can only be known at apply time.
+func (v *Value) IsExpression() bool {
+	switch v.Kind {
+	case KindReference, KindFunction:
+		return true
+	case KindString:
+		return len(v.Interpolations()) > 0
+	}
+	return false
+}
+
+// Interpolations returns the ${...} expressions embedded in a string value.
+func (v *Value) Interpolations() []*Reference {
+	if v.Kind != KindString {
+		return nil
+	}
+	var refs []*Reference
+	for _, expr := range FindInterpolations(v.String) {
+		if ref, err := ParseReference(expr); err == nil {
+			ref.Pos = v.Pos
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+// References returns every reference in the value, including those nested in lists,
+// objects, function arguments and string interpolations.
+func (v *Value) References() []*Reference {
+	switch v.Kind {
+	case KindReference:
+		return []*Reference{v.Reference}
+	case KindString:
+		return v.Interpolations()
+	case KindList:
+		var refs []*Reference
+		for _, item := range v.Items {
+			refs = append(refs, item.References()...)
+		}
+		return refs
+	case KindObject:
+		keys := make([]string, 0, len(v.Fields))
+		for k := range v.Fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var refs []*Reference
+		for _, k := range keys {
+			refs = append(refs, v.Fields[k].References()...)
+		}
+		return refs
+	case KindFunction:
+		var refs []*Reference
+		for _, arg := range v.Args {
+			refs = append(refs, arg.References()...)
+		}
+		return refs
+	}
+	return nil
+}
+
+// Interface converts the value into plain Go values: string, float64, bool, nil,
+// []interface{} and map[string]interface{}. References and function calls are
+// rendered as "${expression}" interpolation strings, the form core uses for values
+// it has not resolved yet.
+func (v *Value) Interface() interface{} {
+	switch v.Kind {
+	case KindString:
+		return v.String
+	case KindNumber:
+		return v.Number
+	case KindBool:
+		return v.Bool
+	case KindList:
+		items := make([]interface{}, len(v.Items))
+		for i, item := range v.Items {
+			items[i] = item.Interface()
+		}
+		return items
+	case KindObject:
+		fields := make(map[string]interface{}, len(v.Fields))
+		for k, field := range v.Fields {
+			fields[k] = field.Interface()
+		}
+		return fields
+	case KindReference, KindFunction:
+		return "${" + v.Source + "}"
+	}
+	return nil
+}
+
+// Reference is a dotted traversal such as postgres_table.users.id or var.region.
+type Reference struct {
+	Parts []string `json:"parts"`
+	Pos   Pos      `json:"pos"`
+}
+
+// String returns the reference in dotted form.
+func (r *Reference) String() string {
+	return strings.Join(r.Parts, ".")
+}
+
+// Root returns the first segment of the reference (resource type, var, local, ...).
+func (r *Reference) Root() string {
+	if len(r.Parts) == 0 {
+		return ""
+	}
+	return r.Parts[0]
+}
@@ -0,0 +1,345 @@
+package hcl
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// FormatOptions controls Format beyond the canonical layout
+type FormatOptions struct {
+	// SortAttributes orders each group of consecutive single-line attributes by name.
+	// Groups are separated by blank lines, comments, nested blocks and multi-line
+	// values, so comments stay next to the attributes they describe.
+	SortAttributes bool
+}
+
+// Format rewrites Kolumn HCL source in canonical form: two-space indentation per
+// nesting level, one space around '=', aligned '=' within groups of consecutive
+// attributes, single spaces between block labels, at most one blank line in a row,
+// no blank lines just inside braces and a single trailing newline. Comments and
+// heredoc bodies are kept verbatim. The source must parse; the result is guaranteed
+// to parse to the same blocks.
+func Format(src []byte) ([]byte, error) {
+	return FormatWithOptions(src, FormatOptions{})
+}
+
+// FormatWithOptions is Format with attribute ordering and other options applied
+func FormatWithOptions(src []byte, opts FormatOptions) ([]byte, error) {
+	before, err := Parse(src, "")
+	if err != nil {
+		return nil, err
+	}
+
+	out := formatLines(splitLines(src), opts)
+
+	after, err := Parse(out, "")
+	if err != nil {
+		return nil, fmt.Errorf("formatting produced invalid source: %w", err)
+	}
+	if !sameBlocks(before.Blocks, after.Blocks) {
+		return nil, fmt.Errorf("formatting changed the meaning of the source")
+	}
+	return out, nil
+}
+
+// IsFormatted reports whether src is already in canonical form
+func IsFormatted(src []byte, opts FormatOptions) (bool, error) {
+	out, err := FormatWithOptions(src, opts)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(bytes.ReplaceAll(src, []byte("\r\n"), []byte("\n")), out), nil
+}
+
+// fmtLine is one output line before alignment
+type fmtLine struct {
+	text   string // trimmed content, or verbatim text for heredoc and comment bodies
+	depth  int
+	raw    bool   // emit text as is, without indentation
+	name   string // attribute name for single-line attributes
+	value  string // attribute value for single-line attributes
+	blank  bool
+	groups bool // single-line attribute eligible for alignment and sorting
+}
+
+func splitLines(src []byte) []string {
+	text := strings.ReplaceAll(string(src), "\r\n", "\n")
+	return strings.Split(text, "\n")
+}
+
+func formatLines(lines []string, opts FormatOptions) []byte {
+	var (
+		out          []fmtLine
+		depth        int
+		heredoc      string // closing marker while inside a heredoc body
+		blockComment bool
+	)
+
+	for _, line := range lines {
+		if heredoc != "" {
+			out = append(out, fmtLine{text: line, raw: true})
+			if strings.TrimSpace(line) == heredoc {
+				heredoc = ""
+			}
+			continue
+		}
+		if blockComment {
+			out = append(out, fmtLine{text: strings.TrimRight(line, " \t"), raw: true})
+			blockComment = !strings.Contains(line, "*/")
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			out = append(out, fmtLine{blank: true})
+			continue
+		}
+
+		scan := scanLine(trimmed)
+		lineDepth := depth - scan.leadingClosers
+		if lineDepth < 0 {
+			lineDepth = 0
+		}
+		depth += scan.opens - scan.closes
+		if depth < 0 {
+			depth = 0
+		}
+		heredoc = scan.heredoc
+		blockComment = scan.blockComment
+
+		fl := fmtLine{text: trimmed, depth: lineDepth}
+		if name, value, ok := splitAttribute(trimmed, scan.assign); ok {
+			fl.text = name + " = " + value
+			fl.name, fl.value = name, value
+			fl.groups = scan.opens == scan.closes && scan.heredoc == "" && !scan.blockComment
+		} else if header := strings.TrimSpace(strings.TrimSuffix(trimmed, "{")); header != trimmed && header != "" &&
+			scan.assign < 0 && scan.opens == 1 && scan.closes == 0 {
+			fl.text = collapseSpaces(header) + " {"
+		}
+		out = append(out, fl)
+	}
+
+	out = tidyBlankLines(out)
+	alignGroups(out, opts)
+
+	var b strings.Builder
+	for _, fl := range out {
+		switch {
+		case fl.blank:
+		case fl.raw:
+			b.WriteString(fl.text)
+		default:
+			b.WriteString(strings.Repeat("  ", fl.depth))
+			b.WriteString(fl.text)
+		}
+		b.WriteByte('\n')
+	}
+	return []byte(b.String())
+}
+
+// lineScan summarises the structure of one trimmed source line
+type lineScan struct {
+	opens, closes  int
+	leadingClosers int
+	assign         int    // byte offset of the top-level '=', or -1
+	heredoc        string // marker of a heredoc opened on this line
+	blockComment   bool   // an unterminated /* comment
+}
+
+// scanLine counts brackets outside strings and comments
+func scanLine(line string) lineScan {
+	s := lineScan{assign: -1}
+	leading := true
+	inString := false
+	interp := 0
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		if inString {
+			switch {
+			case interp > 0 && c == '{':
+				interp++
+			case interp > 0 && c == '}':
+				interp--
+			case interp > 0:
+			case c == '\\':
+				i++
+			case c == '$' && i+1 < len(line) && line[i+1] == '{':
+				interp = 1
+				i++
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch {
+		case c == '"':
+			inString = true
+		case c == '#', c == '/' && i+1 < len(line) && line[i+1] == '/':
+			return s
+		case c == '/' && i+1 < len(line) && line[i+1] == '*':
+			end := strings.Index(line[i+2:], "*/")
+			if end < 0 {
+				s.blockComment = true
+				return s
+			}
+			i += end + 3
+			continue
+		case c == '<' && strings.HasPrefix(line[i:], "<<"):
+			marker := strings.TrimPrefix(line[i+2:], "-")
+			n := 0
+			for n < len(marker) && isIdentPart(rune(marker[n])) {
+				n++
+			}
+			if n > 0 {
+				s.heredoc = marker[:n]
+				return s
+			}
+		case c == '{' || c == '[' || c == '(':
+			s.opens++
+		case c == '}' || c == ']' || c == ')':
+			s.closes++
+			if leading {
+				s.leadingClosers++
+			}
+			continue
+		case c == '=' && s.assign < 0 && s.opens == 0 && s.closes == 0:
+			if (i+1 >= len(line) || line[i+1] != '=') && (i == 0 || !strings.ContainsRune("=!<>", rune(line[i-1]))) {
+				s.assign = i
+			}
+		case c == ' ' || c == '\t' || c == ',':
+			continue
+		}
+		leading = false
+	}
+	return s
+}
+
+// splitAttribute splits `name = value` at the top-level '=' found by scanLine
+func splitAttribute(line string, assign int) (string, string, bool) {
+	if assign <= 0 {
+		return "", "", false
+	}
+	name := strings.TrimSpace(line[:assign])
+	value := strings.TrimSpace(line[assign+1:])
+	if name == "" || value == "" {
+		return "", "", false
+	}
+	if strings.HasPrefix(name, `"`) {
+		if !strings.HasSuffix(name, `"`) || len(name) < 2 {
+			return "", "", false
+		}
+	} else {
+		for i, r := range name {
+			if (i == 0 && !isIdentStart(r)) || !isIdentPart(r) {
+				return "", "", false
+			}
+		}
+	}
+	return name, value, true
+}
+
+// collapseSpaces replaces runs of spaces and tabs outside quoted strings with one space
+func collapseSpaces(s string) string {
+	var b strings.Builder
+	inString, space := false, false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !inString && (c == ' ' || c == '\t') {
+			space = true
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		if c == '"' && (i == 0 || s[i-1] != '\\') {
+			inString = !inString
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// tidyBlankLines drops leading, trailing and repeated blank lines and blank lines
+// directly inside braces
+func tidyBlankLines(lines []fmtLine) []fmtLine {
+	var out []fmtLine
+	for i, fl := range lines {
+		if fl.blank {
+			if len(out) == 0 || out[len(out)-1].blank {
+				continue
+			}
+			if prev := out[len(out)-1]; !prev.raw && strings.HasSuffix(prev.text, "{") {
+				continue
+			}
+			if next := nextNonBlank(lines, i); next == nil || (!next.raw && strings.HasPrefix(next.text, "}")) {
+				continue
+			}
+		}
+		out = append(out, fl)
+	}
+	return out
+}
+
+func nextNonBlank(lines []fmtLine, i int) *fmtLine {
+	for j := i + 1; j < len(lines); j++ {
+		if !lines[j].blank {
+			return &lines[j]
+		}
+	}
+	return nil
+}
+
+// alignGroups aligns '=' across runs of consecutive single-line attributes at the same
+// depth, sorting each run first when requested
+func alignGroups(lines []fmtLine, opts FormatOptions) {
+	for start := 0; start < len(lines); {
+		if !lines[start].groups {
+			start++
+			continue
+		}
+		end := start + 1
+		for end < len(lines) && lines[end].groups && lines[end].depth == lines[start].depth {
+			end++
+		}
+
+		group := lines[start:end]
+		if opts.SortAttributes {
+			sort.SliceStable(group, func(i, j int) bool {
+				return strings.Trim(group[i].name, `"`) < strings.Trim(group[j].name, `"`)
+			})
+		}
+		width := 0
+		for _, fl := range group {
+			if n := len([]rune(fl.name)); n > width {
+				width = n
+			}
+		}
+		for i := range group {
+			pad := width - len([]rune(group[i].name))
+			group[i].text = group[i].name + strings.Repeat(" ", pad) + " = " + group[i].value
+		}
+		start = end
+	}
+}
+
+// sameBlocks compares parsed blocks ignoring source positions
+func sameBlocks(a, b []*Block) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Type != b[i].Type || !reflect.DeepEqual(a[i].Labels, b[i].Labels) {
+			return false
+		}
+		if !reflect.DeepEqual(a[i].Body(), b[i].Body()) || !sameBlocks(a[i].Blocks, b[i].Blocks) {
+			return false
+		}
+	}
+	return true
+}
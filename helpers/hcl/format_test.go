@@ -0,0 +1,139 @@
+package hcl
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatCanonicalSourceIsUnchanged(t *testing.T) {
+	out, err := Format([]byte(sampleConfig))
+	require.NoError(t, err)
+	require.Equal(t, sampleConfig, string(out))
+
+	formatted, err := IsFormatted([]byte(sampleConfig), FormatOptions{})
+	require.NoError(t, err)
+	require.True(t, formatted)
+}
+
+func TestFormatIndentsAndAligns(t *testing.T) {
+	src := "\n\ncreate   \"postgres_table\"   \"users\"{\n\n" +
+		"\tschema=\"app\"\n" +
+		"        comment    = \"a = b\" # keep me\n" +
+		"    tags = [\n\"core\",\n    \"pii\",\n ]\n\n\n\n" +
+		"column \"id\" {\ntype=\"bigint\"\n  primary_key = true\n}\n" +
+		"check = <<-SQL\n      email LIKE '%@%'\n    SQL\n\n}\n\n"
+
+	want := `create "postgres_table" "users" {
+  schema  = "app"
+  comment = "a = b" # keep me
+  tags = [
+    "core",
+    "pii",
+  ]
+
+  column "id" {
+    type        = "bigint"
+    primary_key = true
+  }
+  check = <<-SQL
+      email LIKE '%@%'
+    SQL
+}
+`
+	out, err := Format([]byte(src))
+	require.NoError(t, err)
+	require.Equal(t, want, string(out))
+
+	again, err := Format(out)
+	require.NoError(t, err)
+	require.Equal(t, want, string(again), "formatting must be idempotent")
+}
+
+func TestFormatSortAttributesKeepsGroups(t *testing.T) {
+	src := `create "postgres_table" "users" {
+  schema = "app"
+  comment = "users"
+
+  # ownership
+  owner = "team"
+  name = "users"
+}
+`
+	want := `create "postgres_table" "users" {
+  comment = "users"
+  schema  = "app"
+
+  # ownership
+  name  = "users"
+  owner = "team"
+}
+`
+	out, err := FormatWithOptions([]byte(src), FormatOptions{SortAttributes: true})
+	require.NoError(t, err)
+	require.Equal(t, want, string(out))
+}
+
+func TestFormatRejectsInvalidSource(t *testing.T) {
+	_, err := Format([]byte(`create "postgres_table" "users" {`))
+	require.Error(t, err)
+}
+
+func TestLintReportsSchemaProblems(t *testing.T) {
+	schemas := LintSchemas{
+		"postgres_table": json.RawMessage(`{
+			"properties": {
+				"name": {"type": "string"},
+				"schema": {"type": "string"},
+				"column": {"type": "array", "items": {"properties": {"type": {}}, "required": ["type"]}}
+			},
+			"required": ["name"]
+		}`),
+		"postgres_view": nil,
+	}
+	src := `create "postgres_table" "users" {
+  schema     = "app"
+  depends_on = [postgres_view.active]
+  colour     = "blue"
+
+  column "id" {
+    nullable = false
+  }
+}
+
+create "postgres_view" "active" {
+  anything = true
+}
+
+discover "postgres_table" "legacy" {
+  schema = "legacy"
+}
+
+create "mysql_table" "orders" {
+}
+`
+	issues, err := LintSource([]byte(src), "main.kl", schemas, LintOptions{})
+	require.NoError(t, err)
+
+	var codes []string
+	for _, issue := range issues {
+		codes = append(codes, issue.Code+" "+issue.Attribute)
+	}
+	require.Equal(t, []string{
+		"missing_required name",
+		"unknown_attribute colour",
+		"missing_required type",
+		"unknown_attribute nullable",
+		"unknown_resource_type ",
+	}, codes)
+	require.Equal(t, 4, issues[1].Pos.Line)
+	require.Equal(t, "main.kl", issues[1].Pos.Filename)
+}
+
+func TestLintSourceReportsFormatting(t *testing.T) {
+	issues, err := LintSource([]byte("create \"postgres_view\" \"v\" {\nname=\"v\"\n}\n"), "v.kl", LintSchemas{"postgres_view": nil}, LintOptions{})
+	require.NoError(t, err)
+	require.Len(t, issues, 1)
+	require.Equal(t, LintNotFormatted, issues[0].Code)
+}
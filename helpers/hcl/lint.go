@@ -0,0 +1,185 @@
+package hcl
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Lint issue codes
+const (
+	LintNotFormatted        = "not_formatted"
+	LintUnknownResourceType = "unknown_resource_type"
+	LintUnknownAttribute    = "unknown_attribute"
+	LintMissingRequired     = "missing_required"
+)
+
+// MetaArguments are attributes and nested blocks Kolumn accepts on every create and
+// discover block, whatever the resource schema says
+var MetaArguments = []string{"count", "depends_on", "for_each", "lifecycle", "provider"}
+
+// LintIssue is a problem found in a configuration snippet
+type LintIssue struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	Pos       Pos    `json:"pos"`
+	Address   string `json:"address,omitempty"`
+	Attribute string `json:"attribute,omitempty"`
+}
+
+// String formats the issue as pos: message
+func (i LintIssue) String() string {
+	return fmt.Sprintf("%s: %s", i.Pos, i.Message)
+}
+
+// LintSchemas maps resource type names to the JSON Schema of their configuration, as
+// found in a provider's ResourceTypeDefinition.ConfigSchema
+type LintSchemas map[string]json.RawMessage
+
+// LintOptions controls LintSource
+type LintOptions struct {
+	// Format reports sources that Format would change
+	Format FormatOptions
+
+	// SkipFormat disables the not_formatted check
+	SkipFormat bool
+}
+
+// LintSource parses src and lints it, also checking that it is canonically formatted.
+// Syntax errors are returned as an error rather than an issue.
+func LintSource(src []byte, filename string, schemas LintSchemas, opts LintOptions) ([]LintIssue, error) {
+	file, err := Parse(src, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []LintIssue
+	if !opts.SkipFormat {
+		formatted, err := IsFormatted(src, opts.Format)
+		if err != nil {
+			return nil, err
+		}
+		if !formatted {
+			issues = append(issues, LintIssue{
+				Code:    LintNotFormatted,
+				Message: "file is not canonically formatted",
+				Pos:     Pos{Filename: filename, Line: 1, Column: 1},
+			})
+		}
+	}
+	return append(issues, Lint(file, schemas)...), nil
+}
+
+// Lint checks create and discover blocks against the resource schemas: the resource
+// type must exist and every attribute and nested block must be a schema property or a
+// meta-argument. Create blocks must also set every required property; discover blocks
+// carry filters, so missing properties are not reported for them. Types without a
+// config schema or without declared properties are only checked for existence.
+func Lint(file *File, schemas LintSchemas) []LintIssue {
+	var issues []LintIssue
+	for _, block := range file.Blocks {
+		if block.Type != BlockCreate && block.Type != BlockDiscover {
+			continue
+		}
+
+		raw, ok := schemas[block.ResourceType()]
+		if !ok {
+			issues = append(issues, LintIssue{
+				Code:    LintUnknownResourceType,
+				Message: fmt.Sprintf("resource type %q is not defined in the provider schema", block.ResourceType()),
+				Pos:     block.Pos,
+				Address: block.Address(),
+			})
+			continue
+		}
+
+		var schema lintSchema
+		if len(raw) > 0 {
+			if err := json.Unmarshal(raw, &schema); err != nil {
+				continue
+			}
+		}
+		issues = append(issues, lintBody(block, block, &schema, block.Type == BlockCreate, true)...)
+	}
+	return issues
+}
+
+// lintSchema is the subset of JSON Schema used for linting
+type lintSchema struct {
+	Properties map[string]*lintSchema `json:"properties"`
+	Required   []string               `json:"required"`
+	Items      *lintSchema            `json:"items"`
+}
+
+// lintBody checks one block body against schema, recursing into nested blocks
+func lintBody(root, block *Block, schema *lintSchema, required, top bool) []LintIssue {
+	var issues []LintIssue
+	known := func(name string) (*lintSchema, bool) {
+		if top && containsName(MetaArguments, name) {
+			return nil, true
+		}
+		if len(schema.Properties) == 0 {
+			return nil, true
+		}
+		property, ok := schema.Properties[name]
+		return property, ok
+	}
+
+	if required {
+		for _, name := range schema.Required {
+			if _, ok := block.Attributes[name]; ok || len(block.NestedBlocks(name)) > 0 {
+				continue
+			}
+			issues = append(issues, LintIssue{
+				Code:      LintMissingRequired,
+				Message:   fmt.Sprintf("%s: required attribute %q is missing", root.Address(), name),
+				Pos:       block.Pos,
+				Address:   root.Address(),
+				Attribute: name,
+			})
+		}
+	}
+
+	for _, name := range block.AttributeNames() {
+		if _, ok := known(name); !ok {
+			issues = append(issues, unknownAttribute(root, name, block.Attributes[name].Pos))
+		}
+	}
+
+	for _, nested := range block.Blocks {
+		property, ok := known(nested.Type)
+		if !ok {
+			issues = append(issues, unknownAttribute(root, nested.Type, nested.Pos))
+			continue
+		}
+		if property == nil {
+			continue
+		}
+		if property.Items != nil {
+			property = property.Items
+		}
+		issues = append(issues, lintBody(root, nested, property, required, false)...)
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Pos.Offset < issues[j].Pos.Offset })
+	return issues
+}
+
+func unknownAttribute(root *Block, name string, pos Pos) LintIssue {
+	return LintIssue{
+		Code:      LintUnknownAttribute,
+		Message:   fmt.Sprintf("%s: attribute %q is not defined in the %s schema", root.Address(), name, root.ResourceType()),
+		Pos:       pos,
+		Address:   root.Address(),
+		Attribute: name,
+	}
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,657 @@
+package hcl
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ParseFile reads and parses a .kl file from disk.
+func ParseFile(path string) (*File, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return Parse(src, path)
+}
+
+// Parse parses Kolumn HCL source. The filename is only used in positions.
+func Parse(src []byte, filename string) (*File, error) {
+	p := &parser{src: src, filename: filename, line: 1, col: 1}
+	file := &File{Filename: filename}
+
+	for {
+		p.skipSpace(true)
+		if p.eof() {
+			return file, nil
+		}
+
+		block, err := p.block()
+		if err != nil {
+			return nil, err
+		}
+		file.Blocks = append(file.Blocks, block)
+	}
+}
+
+// ParseString is a convenience wrapper around Parse for inline sources.
+func ParseString(src string) (*File, error) {
+	return Parse([]byte(src), "")
+}
+
+// ParseReference parses a dotted traversal such as postgres_table.users.id or
+// module.db.tables[0].name. Index segments become their own parts.
+func ParseReference(expr string) (*Reference, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, fmt.Errorf("empty reference")
+	}
+
+	p := &parser{src: []byte(expr), line: 1, col: 1}
+	ref, err := p.traversal()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace(false)
+	if !p.eof() {
+		return nil, fmt.Errorf("%q is not a plain reference", expr)
+	}
+	return ref, nil
+}
+
+// FindInterpolations returns the expressions inside every ${...} sequence in s.
+// Escaped sequences ($${...}) are ignored.
+func FindInterpolations(s string) []string {
+	var exprs []string
+	for i := 0; i < len(s)-1; i++ {
+		if s[i] != '$' || s[i+1] != '{' {
+			continue
+		}
+		if i > 0 && s[i-1] == '$' {
+			continue
+		}
+
+		depth := 0
+		for j := i + 1; j < len(s); j++ {
+			switch s[j] {
+			case '{':
+				depth++
+			case '}':
+				depth--
+			}
+			if depth == 0 {
+				exprs = append(exprs, strings.TrimSpace(s[i+2:j]))
+				i = j
+				break
+			}
+		}
+	}
+	return exprs
+}
+
+// parser is a recursive-descent parser over the raw source bytes
+type parser struct {
+	src      []byte
+	filename string
+	off      int
+	line     int
+	col      int
+}
+
+func (p *parser) eof() bool { return p.off >= len(p.src) }
+
+func (p *parser) peek() rune {
+	if p.eof() {
+		return 0
+	}
+	r, _ := utf8.DecodeRune(p.src[p.off:])
+	return r
+}
+
+func (p *parser) peekAt(n int) byte {
+	if p.off+n >= len(p.src) {
+		return 0
+	}
+	return p.src[p.off+n]
+}
+
+func (p *parser) next() rune {
+	r, size := utf8.DecodeRune(p.src[p.off:])
+	p.off += size
+	if r == '\n' {
+		p.line++
+		p.col = 1
+	} else {
+		p.col++
+	}
+	return r
+}
+
+func (p *parser) pos() Pos {
+	return Pos{Filename: p.filename, Line: p.line, Column: p.col, Offset: p.off}
+}
+
+func (p *parser) errorf(pos Pos, format string, args ...interface{}) error {
+	return &ParseError{Pos: pos, Message: fmt.Sprintf(format, args...)}
+}
+
+// skipSpace skips whitespace and comments; newlines are only skipped when allowed
+func (p *parser) skipSpace(newlines bool) {
+	for !p.eof() {
+		c := p.peek()
+		switch {
+		case c == '\n' && !newlines:
+			return
+		case unicode.IsSpace(c):
+			p.next()
+		case c == '#' || (c == '/' && p.peekAt(1) == '/'):
+			for !p.eof() && p.peek() != '\n' {
+				p.next()
+			}
+		case c == '/' && p.peekAt(1) == '*':
+			p.next()
+			p.next()
+			for !p.eof() && !(p.peek() == '*' && p.peekAt(1) == '/') {
+				p.next()
+			}
+			if !p.eof() {
+				p.next()
+				p.next()
+			}
+		default:
+			return
+		}
+	}
+}
+
+func isIdentStart(c rune) bool { return unicode.IsLetter(c) || c == '_' }
+
+func isIdentPart(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsDigit(c) || c == '_' || c == '-'
+}
+
+func (p *parser) ident() string {
+	start := p.off
+	if !isIdentStart(p.peek()) {
+		return ""
+	}
+	for !p.eof() && isIdentPart(p.peek()) {
+		p.next()
+	}
+	return string(p.src[start:p.off])
+}
+
+// block parses `keyword "label"... { body }`
+func (p *parser) block() (*Block, error) {
+	start := p.pos()
+	keyword := p.ident()
+	if keyword == "" {
+		return nil, p.errorf(start, "expected block keyword, found %q", p.peek())
+	}
+
+	block := &Block{Type: keyword, Pos: start, Attributes: make(map[string]*Attribute)}
+	for {
+		p.skipSpace(false)
+		c := p.peek()
+		if c == '"' {
+			label, err := p.quoted()
+			if err != nil {
+				return nil, err
+			}
+			block.Labels = append(block.Labels, label)
+			continue
+		}
+		if isIdentStart(c) {
+			block.Labels = append(block.Labels, p.ident())
+			continue
+		}
+		break
+	}
+
+	if err := p.body(block); err != nil {
+		return nil, err
+	}
+
+	if (keyword == BlockCreate || keyword == BlockDiscover) && len(block.Labels) != 2 {
+		return nil, p.errorf(start, "%s block requires a type and a name label, got %d", keyword, len(block.Labels))
+	}
+	return block, nil
+}
+
+// body parses `{ attributes and nested blocks }` into block
+func (p *parser) body(block *Block) error {
+	p.skipSpace(false)
+	if p.peek() != '{' {
+		return p.errorf(p.pos(), "expected '{' to open %s block body", block.Type)
+	}
+	p.next()
+
+	for {
+		p.skipSpace(true)
+		if p.eof() {
+			return p.errorf(p.pos(), "unexpected end of file, expected '}' to close %s block opened at %s", block.Type, block.Pos)
+		}
+		if p.peek() == '}' {
+			p.next()
+			block.EndPos = p.pos()
+			return nil
+		}
+
+		namePos := p.pos()
+		var name string
+		if p.peek() == '"' {
+			quoted, err := p.quoted()
+			if err != nil {
+				return err
+			}
+			name = quoted
+		} else {
+			name = p.ident()
+		}
+		if name == "" {
+			return p.errorf(namePos, "expected attribute or block name, found %q", p.peek())
+		}
+
+		p.skipSpace(false)
+		switch c := p.peek(); {
+		case c == '=' || c == ':':
+			p.next()
+			value, err := p.value()
+			if err != nil {
+				return err
+			}
+			if _, dup := block.Attributes[name]; dup {
+				return p.errorf(namePos, "duplicate attribute %q", name)
+			}
+			block.Attributes[name] = &Attribute{Name: name, Value: value, Pos: namePos}
+		case c == '{' || c == '"' || isIdentStart(c):
+			nested := &Block{Type: name, Pos: namePos, Attributes: make(map[string]*Attribute)}
+			for p.peek() == '"' || isIdentStart(p.peek()) {
+				var label string
+				if p.peek() == '"' {
+					quoted, err := p.quoted()
+					if err != nil {
+						return err
+					}
+					label = quoted
+				} else {
+					label = p.ident()
+				}
+				nested.Labels = append(nested.Labels, label)
+				p.skipSpace(false)
+			}
+			if err := p.body(nested); err != nil {
+				return err
+			}
+			block.Blocks = append(block.Blocks, nested)
+		default:
+			return p.errorf(p.pos(), "expected '=' or '{' after %q", name)
+		}
+	}
+}
+
+// value parses an expression
+func (p *parser) value() (*Value, error) {
+	p.skipSpace(false)
+	start := p.pos()
+
+	switch c := p.peek(); {
+	case c == '"':
+		s, err := p.quoted()
+		if err != nil {
+			return nil, err
+		}
+		return &Value{Kind: KindString, String: s, Pos: start}, nil
+	case c == '<' && p.peekAt(1) == '<':
+		s, err := p.heredoc()
+		if err != nil {
+			return nil, err
+		}
+		return &Value{Kind: KindString, String: s, Pos: start}, nil
+	case c == '[':
+		return p.list()
+	case c == '{':
+		return p.object()
+	case c == '-' || unicode.IsDigit(c):
+		return p.number()
+	case isIdentStart(c):
+		return p.identValue()
+	case c == 0:
+		return nil, p.errorf(start, "unexpected end of file, expected a value")
+	default:
+		return nil, p.errorf(start, "unexpected character %q, expected a value", c)
+	}
+}
+
+func (p *parser) number() (*Value, error) {
+	start := p.pos()
+	if p.peek() == '-' {
+		p.next()
+	}
+	for !p.eof() {
+		c := p.peek()
+		if unicode.IsDigit(c) || c == '.' || c == 'e' || c == 'E' ||
+			((c == '+' || c == '-') && (p.src[p.off-1] == 'e' || p.src[p.off-1] == 'E')) {
+			p.next()
+			continue
+		}
+		break
+	}
+	text := string(p.src[start.Offset:p.off])
+	n, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return nil, p.errorf(start, "invalid number %q", text)
+	}
+	return &Value{Kind: KindNumber, Number: n, Pos: start}, nil
+}
+
+// identValue parses keywords, references and function calls
+func (p *parser) identValue() (*Value, error) {
+	start := p.pos()
+	save := *p
+	word := p.ident()
+
+	switch word {
+	case "true", "false":
+		return &Value{Kind: KindBool, Bool: word == "true", Pos: start}, nil
+	case "null":
+		return &Value{Kind: KindNull, Pos: start}, nil
+	}
+
+	if p.peek() == '(' {
+		p.next()
+		fn := &Value{Kind: KindFunction, Function: word, Pos: start}
+		for {
+			p.skipSpace(true)
+			if p.peek() == ')' {
+				p.next()
+				break
+			}
+			arg, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			fn.Args = append(fn.Args, arg)
+			p.skipSpace(true)
+			switch p.peek() {
+			case ',':
+				p.next()
+			case ')':
+			default:
+				return nil, p.errorf(p.pos(), "expected ',' or ')' in call to %s", word)
+			}
+		}
+		fn.Source = string(p.src[start.Offset:p.off])
+		return fn, nil
+	}
+
+	*p = save
+	ref, err := p.traversal()
+	if err != nil {
+		return nil, err
+	}
+	return &Value{Kind: KindReference, Reference: ref, Source: ref.String(), Pos: start}, nil
+}
+
+// traversal parses name(.name|[index])*
+func (p *parser) traversal() (*Reference, error) {
+	start := p.pos()
+	root := p.ident()
+	if root == "" {
+		return nil, p.errorf(start, "expected reference")
+	}
+
+	ref := &Reference{Parts: []string{root}, Pos: start}
+	for {
+		switch p.peek() {
+		case '.':
+			p.next()
+			if p.peek() == '*' {
+				p.next()
+				ref.Parts = append(ref.Parts, "*")
+				continue
+			}
+			part := p.ident()
+			if part == "" {
+				return nil, p.errorf(p.pos(), "expected attribute name after '.' in reference")
+			}
+			ref.Parts = append(ref.Parts, part)
+		case '[':
+			p.next()
+			indexStart := p.off
+			for !p.eof() && p.peek() != ']' && p.peek() != '\n' {
+				p.next()
+			}
+			if p.peek() != ']' {
+				return nil, p.errorf(p.pos(), "expected ']' to close index")
+			}
+			index := strings.Trim(strings.TrimSpace(string(p.src[indexStart:p.off])), `"`)
+			p.next()
+			ref.Parts = append(ref.Parts, index)
+		default:
+			return ref, nil
+		}
+	}
+}
+
+func (p *parser) list() (*Value, error) {
+	start := p.pos()
+	p.next() // [
+	list := &Value{Kind: KindList, Pos: start, Items: []*Value{}}
+	for {
+		p.skipSpace(true)
+		if p.eof() {
+			return nil, p.errorf(start, "unterminated list")
+		}
+		if p.peek() == ']' {
+			p.next()
+			return list, nil
+		}
+		item, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		list.Items = append(list.Items, item)
+		p.skipSpace(true)
+		if p.peek() == ',' {
+			p.next()
+		}
+	}
+}
+
+func (p *parser) object() (*Value, error) {
+	start := p.pos()
+	p.next() // {
+	obj := &Value{Kind: KindObject, Pos: start, Fields: make(map[string]*Value)}
+	for {
+		p.skipSpace(true)
+		if p.eof() {
+			return nil, p.errorf(start, "unterminated object")
+		}
+		if p.peek() == '}' {
+			p.next()
+			return obj, nil
+		}
+
+		keyPos := p.pos()
+		var key string
+		if p.peek() == '"' {
+			quoted, err := p.quoted()
+			if err != nil {
+				return nil, err
+			}
+			key = quoted
+		} else {
+			key = p.ident()
+		}
+		if key == "" {
+			return nil, p.errorf(keyPos, "expected object key, found %q", p.peek())
+		}
+
+		p.skipSpace(false)
+		if c := p.peek(); c != '=' && c != ':' {
+			return nil, p.errorf(p.pos(), "expected '=' or ':' after object key %q", key)
+		}
+		p.next()
+
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		obj.Fields[key] = value
+
+		p.skipSpace(true)
+		if p.peek() == ',' {
+			p.next()
+		}
+	}
+}
+
+// quoted parses a double-quoted string, keeping ${...} sequences verbatim
+func (p *parser) quoted() (string, error) {
+	start := p.pos()
+	p.next() // opening quote
+
+	var b strings.Builder
+	for {
+		if p.eof() || p.peek() == '\n' {
+			return "", p.errorf(start, "unterminated string")
+		}
+		c := p.next()
+		switch c {
+		case '"':
+			return b.String(), nil
+		case '$':
+			b.WriteRune(c)
+			if p.peek() != '{' {
+				continue
+			}
+			// Copy interpolations verbatim so quoted arguments don't end the string
+			depth := 0
+			for !p.eof() && p.peek() != '\n' {
+				r := p.next()
+				b.WriteRune(r)
+				if r == '{' {
+					depth++
+				} else if r == '}' {
+					depth--
+					if depth == 0 {
+						break
+					}
+				}
+			}
+		case '\\':
+			if p.eof() {
+				return "", p.errorf(start, "unterminated string")
+			}
+			escPos := p.pos()
+			switch esc := p.next(); esc {
+			case 'n':
+				b.WriteRune('\n')
+			case 't':
+				b.WriteRune('\t')
+			case 'r':
+				b.WriteRune('\r')
+			case '"', '\\':
+				b.WriteRune(esc)
+			default:
+				return "", p.errorf(escPos, "invalid escape sequence \\%c", esc)
+			}
+		default:
+			b.WriteRune(c)
+		}
+	}
+}
+
+// heredoc parses <<MARKER or <<-MARKER strings; <<- strips common leading indentation
+func (p *parser) heredoc() (string, error) {
+	start := p.pos()
+	p.next()
+	p.next()
+	indent := false
+	if p.peek() == '-' {
+		p.next()
+		indent = true
+	}
+	marker := p.ident()
+	if marker == "" {
+		return "", p.errorf(start, "expected heredoc marker")
+	}
+	for !p.eof() && p.peek() != '\n' {
+		p.next()
+	}
+	if !p.eof() {
+		p.next()
+	}
+
+	var lines []string
+	for {
+		if p.eof() {
+			return "", p.errorf(start, "unterminated heredoc, expected %s", marker)
+		}
+		lineStart := p.off
+		for !p.eof() && p.peek() != '\n' {
+			p.next()
+		}
+		line := string(p.src[lineStart:p.off])
+		if !p.eof() {
+			p.next()
+		}
+		if strings.TrimSpace(line) == marker {
+			break
+		}
+		lines = append(lines, line)
+	}
+
+	if indent {
+		lines = dedent(lines)
+	}
+	if len(lines) == 0 {
+		return "", nil
+	}
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+// dedent removes the longest common whitespace prefix from non-blank lines
+func dedent(lines []string) []string {
+	common := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		n := len(line) - len(strings.TrimLeft(line, " \t"))
+		if common < 0 || n < common {
+			common = n
+		}
+	}
+	if common <= 0 {
+		return lines
+	}
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		if len(line) >= common {
+			out[i] = line[common:]
+		} else {
+			out[i] = strings.TrimLeft(line, " \t")
+		}
+	}
+	return out
+}
+
+// ParseExpression parses a standalone expression such as the contents of a
+// ${...} interpolation: a literal, reference, list, object or function call.
+func ParseExpression(expr string) (*Value, error) {
+	p := &parser{src: []byte(expr), line: 1, col: 1}
+	value, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace(true)
+	if !p.eof() {
+		return nil, p.errorf(p.pos(), "unexpected %q after expression", p.peek())
+	}
+	return value, nil
+}
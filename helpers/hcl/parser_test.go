@@ -0,0 +1,139 @@
+package hcl
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const sampleConfig = `# Example Kolumn configuration
+provider "postgres" {
+  host = "localhost"
+  port = 5432
+}
+
+create "postgres_table" "users" {
+  schema  = postgres_schema.app.name
+  comment = "Users of ${var.app_name}"
+  tags    = ["core", "pii"]
+  owner   = lower(var.owner)
+  options = {
+    fillfactor = 90
+    logged     = true
+  }
+
+  column "id" {
+    type        = "bigint"
+    primary_key = true
+  }
+
+  column "email" {
+    type = "text"
+  }
+
+  check = <<-SQL
+    email LIKE '%@%'
+  SQL
+}
+
+discover "postgres_table" "legacy" {
+  schema = "legacy"
+}
+`
+
+func TestParse_Blocks(t *testing.T) {
+	file, err := Parse([]byte(sampleConfig), "main.kl")
+	require.NoError(t, err)
+	require.Len(t, file.Blocks, 3)
+
+	creates := file.CreateBlocks()
+	require.Len(t, creates, 1)
+	users := creates[0]
+	require.Equal(t, "postgres_table", users.ResourceType())
+	require.Equal(t, "users", users.Name())
+	require.Equal(t, "postgres_table.users", users.Address())
+	require.Equal(t, "main.kl:7:1", users.Pos.String())
+
+	require.Len(t, users.NestedBlocks("column"), 2)
+	require.Equal(t, []string{"id"}, users.NestedBlocks("column")[0].Labels)
+
+	discovers := file.DiscoverBlocks()
+	require.Len(t, discovers, 1)
+	require.Equal(t, "legacy", discovers[0].Name())
+}
+
+func TestParse_Values(t *testing.T) {
+	file, err := ParseString(sampleConfig)
+	require.NoError(t, err)
+	users := file.CreateBlocks()[0]
+
+	schema := users.Attributes["schema"].Value
+	require.Equal(t, KindReference, schema.Kind)
+	require.Equal(t, []string{"postgres_schema", "app", "name"}, schema.Reference.Parts)
+	require.Equal(t, 8, schema.Pos.Line)
+
+	owner := users.Attributes["owner"].Value
+	require.Equal(t, KindFunction, owner.Kind)
+	require.Equal(t, "lower", owner.Function)
+	require.Equal(t, "${lower(var.owner)}", owner.Interface())
+
+	require.Equal(t, "email LIKE '%@%'\n", users.Attributes["check"].Value.String)
+
+	body := users.Body()
+	require.Equal(t, []interface{}{"core", "pii"}, body["tags"])
+	require.Equal(t, map[string]interface{}{"fillfactor": float64(90), "logged": true}, body["options"])
+	require.Len(t, body["column"], 2)
+
+	var refs []string
+	for _, ref := range users.References() {
+		refs = append(refs, ref.String())
+	}
+	require.ElementsMatch(t, []string{"postgres_schema.app.name", "var.app_name", "var.owner"}, refs)
+}
+
+func TestParse_ErrorPositions(t *testing.T) {
+	_, err := Parse([]byte("create \"t\" \"a\" {\n  name = \n}\n"), "bad.kl")
+	require.Error(t, err)
+
+	var parseErr *ParseError
+	require.True(t, errors.As(err, &parseErr))
+	require.Equal(t, "bad.kl", parseErr.Pos.Filename)
+	require.Equal(t, 2, parseErr.Pos.Line)
+	require.Contains(t, err.Error(), "bad.kl:2:")
+
+	_, err = ParseString(`create "only_type" { }`)
+	require.ErrorContains(t, err, "requires a type and a name label")
+}
+
+func TestParseReference(t *testing.T) {
+	ref, err := ParseReference(`module.db.tables[0].name`)
+	require.NoError(t, err)
+	require.Equal(t, []string{"module", "db", "tables", "0", "name"}, ref.Parts)
+	require.Equal(t, "module", ref.Root())
+
+	_, err = ParseReference("a.b + 1")
+	require.Error(t, err)
+}
+
+func TestFindInterpolations(t *testing.T) {
+	require.Equal(t, []string{"var.a", "b.c.d"}, FindInterpolations("x-${var.a}-${ b.c.d }"))
+	require.Empty(t, FindInterpolations("escaped $${var.a}"))
+}
+
+func TestParseExpression(t *testing.T) {
+	value, err := ParseExpression(`join("-", [var.env, "db"])`)
+	require.NoError(t, err)
+	require.Equal(t, KindFunction, value.Kind)
+	require.Len(t, value.Args, 2)
+	require.Equal(t, KindList, value.Args[1].Kind)
+
+	_, err = ParseExpression("var.a var.b")
+	require.Error(t, err)
+}
+
+func TestParse_QuotedInterpolation(t *testing.T) {
+	file, err := ParseString(`create "t" "a" { name = "${lower("APP")}-db" }`)
+	require.NoError(t, err)
+	require.Equal(t, `${lower("APP")}-db`, file.Blocks[0].Attributes["name"].Value.String)
+}
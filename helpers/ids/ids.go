@@ -0,0 +1,242 @@
+// Package ids provides ID generation for resources and events.
+//
+// Resource IDs are produced by a Strategy chosen per resource type. Random strategies
+// (UUIDv7, ULID) yield a fresh ID on every call; deterministic strategies (address
+// hash, backend-native) yield the same ID for the same resource, so an ID assigned at
+// create time can be reproduced on import and used for idempotency checks.
+package ids
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/types"
+)
+
+// Strategy names
+const (
+	StrategyUUIDv7      = "uuidv7"
+	StrategyULID        = "ulid"
+	StrategyNative      = "native"
+	StrategyAddressHash = "address_hash"
+)
+
+// ErrNoNativeID is returned by the native strategy when the backend supplied no ID
+var ErrNoNativeID = errors.New("backend did not supply a native ID")
+
+// Request describes the resource an ID is generated for
+type Request struct {
+	ResourceType string
+	Address      types.Address
+	NativeID     string // ID assigned by the backend, if any
+}
+
+// Strategy generates resource IDs
+type Strategy interface {
+	Name() string
+	Generate(req Request) (string, error)
+
+	// Deterministic reports whether the same request always yields the same ID
+	Deterministic() bool
+}
+
+// IDMismatchError is returned when an existing ID differs from the one a deterministic
+// strategy produces for the same resource
+type IDMismatchError struct {
+	Address  types.Address
+	Existing string
+	Expected string
+}
+
+func (e *IDMismatchError) Error() string {
+	return fmt.Sprintf("resource %s has ID %q but its ID strategy yields %q", e.Address, e.Existing, e.Expected)
+}
+
+// UUIDv7 returns a new time-ordered RFC 9562 version 7 UUID
+func UUIDv7() string {
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[:8], uint64(time.Now().UnixMilli())<<16)
+	if _, err := rand.Read(b[6:]); err != nil {
+		panic(fmt.Sprintf("ids: reading random bytes: %v", err))
+	}
+	b[6] = (b[6] & 0x0f) | 0x70 // version 7
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 9562 variant
+	h := hex.EncodeToString(b[:])
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+}
+
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ULID returns a new lexicographically sortable ULID
+func ULID() string {
+	var b [16]byte
+	ms := uint64(time.Now().UnixMilli())
+	for i := 5; i >= 0; i-- {
+		b[i] = byte(ms)
+		ms >>= 8
+	}
+	if _, err := rand.Read(b[6:]); err != nil {
+		panic(fmt.Sprintf("ids: reading random bytes: %v", err))
+	}
+
+	// 128 bits as 26 base32 characters, the first carrying the top 3 bits
+	hi, lo := binary.BigEndian.Uint64(b[:8]), binary.BigEndian.Uint64(b[8:])
+	out := make([]byte, 26)
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out)
+}
+
+// Prefixed returns a new ULID-based event ID such as gov_evt_01HV3K8Q2M4N6P8R0T2V4X6Z8B.
+// IDs are unique across goroutines and processes and sort by creation time.
+func Prefixed(prefix string) string {
+	if prefix == "" {
+		return ULID()
+	}
+	return prefix + "_" + ULID()
+}
+
+// AddressHash returns a deterministic ID derived from a resource address
+func AddressHash(prefix string, addr types.Address) string {
+	sum := sha256.Sum256([]byte(addr.String()))
+	id := hex.EncodeToString(sum[:12])
+	if prefix != "" {
+		return prefix + "_" + id
+	}
+	return id
+}
+
+type uuidv7Strategy struct{}
+
+func (uuidv7Strategy) Name() string                         { return StrategyUUIDv7 }
+func (uuidv7Strategy) Deterministic() bool                  { return false }
+func (uuidv7Strategy) Generate(req Request) (string, error) { return UUIDv7(), nil }
+
+type ulidStrategy struct{}
+
+func (ulidStrategy) Name() string                         { return StrategyULID }
+func (ulidStrategy) Deterministic() bool                  { return false }
+func (ulidStrategy) Generate(req Request) (string, error) { return ULID(), nil }
+
+type nativeStrategy struct{}
+
+func (nativeStrategy) Name() string        { return StrategyNative }
+func (nativeStrategy) Deterministic() bool { return true }
+func (nativeStrategy) Generate(req Request) (string, error) {
+	if req.NativeID == "" {
+		return "", ErrNoNativeID
+	}
+	return req.NativeID, nil
+}
+
+type addressHashStrategy struct {
+	prefix string
+}
+
+func (s addressHashStrategy) Name() string        { return StrategyAddressHash }
+func (s addressHashStrategy) Deterministic() bool { return true }
+func (s addressHashStrategy) Generate(req Request) (string, error) {
+	if req.Address.Type == "" || req.Address.Name == "" {
+		return "", fmt.Errorf("address hash IDs require a resource address")
+	}
+	return AddressHash(s.prefix, req.Address), nil
+}
+
+// UUIDv7Strategy generates random time-ordered UUIDs
+func UUIDv7Strategy() Strategy { return uuidv7Strategy{} }
+
+// ULIDStrategy generates random time-ordered ULIDs
+func ULIDStrategy() Strategy { return ulidStrategy{} }
+
+// NativeStrategy uses the ID assigned by the backend
+func NativeStrategy() Strategy { return nativeStrategy{} }
+
+// AddressHashStrategy derives the ID from the resource address, optionally prefixed
+func AddressHashStrategy(prefix string) Strategy { return addressHashStrategy{prefix: prefix} }
+
+// Generator selects an ID strategy per resource type
+type Generator struct {
+	mu       sync.RWMutex
+	fallback Strategy
+	byType   map[string]Strategy
+}
+
+// NewGenerator creates a generator using fallback for resource types without their own
+// strategy; a nil fallback means UUIDv7
+func NewGenerator(fallback Strategy) *Generator {
+	if fallback == nil {
+		fallback = UUIDv7Strategy()
+	}
+	return &Generator{fallback: fallback, byType: make(map[string]Strategy)}
+}
+
+// SetStrategy configures the strategy of a resource type
+func (g *Generator) SetStrategy(resourceType string, s Strategy) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.byType[resourceType] = s
+}
+
+// StrategyFor returns the strategy used for a resource type
+func (g *Generator) StrategyFor(resourceType string) Strategy {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if s, ok := g.byType[resourceType]; ok {
+		return s
+	}
+	return g.fallback
+}
+
+// Generate returns an ID for the resource using its type's strategy
+func (g *Generator) Generate(req Request) (string, error) {
+	if req.ResourceType == "" {
+		req.ResourceType = req.Address.Type
+	}
+	return g.StrategyFor(req.ResourceType).Generate(req)
+}
+
+// CheckIdempotent verifies that an existing ID is the one the resource's strategy
+// yields, so a repeated create or an import maps onto the same resource. Random
+// strategies cannot be checked and always pass.
+func (g *Generator) CheckIdempotent(req Request, existingID string) error {
+	if req.ResourceType == "" {
+		req.ResourceType = req.Address.Type
+	}
+	s := g.StrategyFor(req.ResourceType)
+	if !s.Deterministic() {
+		return nil
+	}
+	expected, err := s.Generate(req)
+	if err != nil {
+		return err
+	}
+	if expected != existingID {
+		return &IDMismatchError{Address: req.Address, Existing: existingID, Expected: expected}
+	}
+	return nil
+}
+
+// StrategyByName returns a built-in strategy; prefix only applies to address hashes
+func StrategyByName(name, prefix string) (Strategy, error) {
+	switch name {
+	case StrategyUUIDv7:
+		return UUIDv7Strategy(), nil
+	case StrategyULID:
+		return ULIDStrategy(), nil
+	case StrategyNative:
+		return NativeStrategy(), nil
+	case StrategyAddressHash:
+		return AddressHashStrategy(prefix), nil
+	default:
+		return nil, fmt.Errorf("unknown ID strategy %q", name)
+	}
+}
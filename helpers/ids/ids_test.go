@@ -0,0 +1,88 @@
+package ids
+
+import (
+	"errors"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRandomFormats(t *testing.T) {
+	require.Regexp(t, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`), UUIDv7())
+	require.Regexp(t, regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`), ULID())
+	require.NotEqual(t, ULID(), ULID())
+}
+
+func TestGeneratorStrategies(t *testing.T) {
+	g := NewGenerator(nil)
+	g.SetStrategy("postgres_table", AddressHashStrategy("table"))
+	g.SetStrategy("s3_bucket", NativeStrategy())
+
+	addr := types.NewAddress("postgres", "postgres_table", "users")
+	req := Request{ResourceType: "postgres_table", Address: addr}
+	created, err := g.Generate(req)
+	require.NoError(t, err)
+	imported, err := g.Generate(req)
+	require.NoError(t, err)
+	require.Equal(t, created, imported)
+	require.NoError(t, g.CheckIdempotent(req, created))
+
+	var mismatch *IDMismatchError
+	require.True(t, errors.As(g.CheckIdempotent(req, "table_users"), &mismatch))
+
+	_, err = g.Generate(Request{ResourceType: "s3_bucket"})
+	require.ErrorIs(t, err, ErrNoNativeID)
+	id, err := g.Generate(Request{ResourceType: "s3_bucket", NativeID: "arn:aws:s3:::logs"})
+	require.NoError(t, err)
+	require.Equal(t, "arn:aws:s3:::logs", id)
+
+	require.Equal(t, StrategyUUIDv7, g.StrategyFor("view").Name())
+	require.NoError(t, g.CheckIdempotent(Request{ResourceType: "view"}, "anything"))
+}
+
+func TestConcurrentIDsDoNotCollide(t *testing.T) {
+	generators := map[string]func() string{
+		"uuidv7":   UUIDv7,
+		"ulid":     ULID,
+		"prefixed": func() string { return Prefixed("gov_evt") },
+	}
+	for name, generate := range generators {
+		t.Run(name, func(t *testing.T) {
+			const workers, perWorker = 8, 5000
+			results := make(chan string, workers*perWorker)
+			var wg sync.WaitGroup
+			for w := 0; w < workers; w++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := 0; i < perWorker; i++ {
+						results <- generate()
+					}
+				}()
+			}
+			wg.Wait()
+			close(results)
+
+			seen := make(map[string]bool, workers*perWorker)
+			for id := range results {
+				require.False(t, seen[id], "duplicate ID %s", id)
+				seen[id] = true
+			}
+			require.Len(t, seen, workers*perWorker)
+		})
+	}
+}
+
+func TestIDsSortByCreationTime(t *testing.T) {
+	first := ULID()
+	time.Sleep(2 * time.Millisecond)
+	second := ULID()
+	require.Less(t, first, second)
+
+	require.Regexp(t, `^backup_[0-9A-HJKMNP-TV-Z]{26}$`, Prefixed("backup"))
+	require.Len(t, Prefixed(""), 26)
+}
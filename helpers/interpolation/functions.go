@@ -0,0 +1,123 @@
+package interpolation
+
+import (
+	"fmt"
+	"strings"
+)
+
+// builtinFunctions is the function set every Resolver starts with
+var builtinFunctions = map[string]Function{
+	"lower":    stringFunc(strings.ToLower),
+	"upper":    stringFunc(strings.ToUpper),
+	"trim":     stringFunc(strings.TrimSpace),
+	"join":     joinFunc,
+	"concat":   concatFunc,
+	"coalesce": coalesceFunc,
+	"format":   formatFunc,
+	"length":   lengthFunc,
+	"replace":  replaceFunc,
+	"tostring": tostringFunc,
+}
+
+// stringFunc adapts a single-argument string transform into a Function
+func stringFunc(fn func(string) string) Function {
+	return func(args []interface{}) (interface{}, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("expected 1 argument, got %d", len(args))
+		}
+		s, ok := args[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("expected string argument, got %T", args[0])
+		}
+		return fn(s), nil
+	}
+}
+
+func joinFunc(args []interface{}) (interface{}, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("expected 2 arguments, got %d", len(args))
+	}
+	sep, ok := args[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("separator must be a string, got %T", args[0])
+	}
+	list, ok := args[1].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("second argument must be a list, got %T", args[1])
+	}
+	parts := make([]string, len(list))
+	for i, item := range list {
+		parts[i] = formatValue(item)
+	}
+	return strings.Join(parts, sep), nil
+}
+
+func concatFunc(args []interface{}) (interface{}, error) {
+	out := []interface{}{}
+	for i, arg := range args {
+		list, ok := arg.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("argument %d must be a list, got %T", i+1, arg)
+		}
+		out = append(out, list...)
+	}
+	return out, nil
+}
+
+func coalesceFunc(args []interface{}) (interface{}, error) {
+	for _, arg := range args {
+		if arg != nil && arg != "" {
+			return arg, nil
+		}
+	}
+	return nil, fmt.Errorf("no non-empty arguments")
+}
+
+func formatFunc(args []interface{}) (interface{}, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("expected at least 1 argument")
+	}
+	spec, ok := args[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("format spec must be a string, got %T", args[0])
+	}
+	return fmt.Sprintf(spec, args[1:]...), nil
+}
+
+func lengthFunc(args []interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("expected 1 argument, got %d", len(args))
+	}
+	switch v := args[0].(type) {
+	case string:
+		return float64(len([]rune(v))), nil
+	case []interface{}:
+		return float64(len(v)), nil
+	case map[string]interface{}:
+		return float64(len(v)), nil
+	default:
+		return nil, fmt.Errorf("cannot take length of %T", args[0])
+	}
+}
+
+func replaceFunc(args []interface{}) (interface{}, error) {
+	if len(args) != 3 {
+		return nil, fmt.Errorf("expected 3 arguments, got %d", len(args))
+	}
+	strs := make([]string, 3)
+	for i, arg := range args {
+		s, ok := arg.(string)
+		if !ok {
+			return nil, fmt.Errorf("argument %d must be a string, got %T", i+1, arg)
+		}
+		strs[i] = s
+	}
+	return strings.ReplaceAll(strs[0], strs[1], strs[2]), nil
+}
+
+func tostringFunc(args []interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("expected 1 argument, got %d", len(args))
+	}
+	return formatValue(args[0]), nil
+}
@@ -0,0 +1,345 @@
+// Package interpolation resolves Kolumn "${...}" expressions that remain in resource
+// configurations after core has done its own evaluation.
+//
+// Core resolves most references before calling a provider, but values that depend on
+// resources created in the same apply can arrive as placeholders. Providers register the
+// values they know about (outputs of resources they created, variables, ...) and call
+// Resolve, then RequireResolved to fail fast with the exact config path of anything left.
+package interpolation
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/schemabounce/kolumn/sdk/helpers/hcl"
+)
+
+// Function implements a callable used inside expressions, e.g. lower(var.name).
+type Function func(args []interface{}) (interface{}, error)
+
+// Resolver evaluates interpolation expressions against registered values and functions.
+type Resolver struct {
+	values    map[string]interface{}
+	functions map[string]Function
+}
+
+// NewResolver creates a resolver with the built-in function set.
+func NewResolver() *Resolver {
+	r := &Resolver{
+		values:    make(map[string]interface{}),
+		functions: make(map[string]Function),
+	}
+	for name, fn := range builtinFunctions {
+		r.functions[name] = fn
+	}
+	return r
+}
+
+// SetValue registers the value behind an address such as "postgres_table.users" or
+// "var.region". References are resolved by descending from the longest registered
+// address prefix into nested maps and lists.
+func (r *Resolver) SetValue(address string, value interface{}) *Resolver {
+	r.values[address] = value
+	return r
+}
+
+// SetValues registers several addresses at once.
+func (r *Resolver) SetValues(values map[string]interface{}) *Resolver {
+	for address, value := range values {
+		r.values[address] = value
+	}
+	return r
+}
+
+// RegisterFunction adds or replaces a function available to expressions.
+func (r *Resolver) RegisterFunction(name string, fn Function) *Resolver {
+	r.functions[name] = fn
+	return r
+}
+
+// Resolve returns a copy of config with every resolvable expression replaced. A string
+// consisting of a single "${...}" becomes the referenced value with its original type;
+// expressions embedded in longer strings are formatted into the string. Expressions
+// that reference unknown values are left untouched so RequireResolved can report them.
+// Errors are returned only for malformed expressions or failing function calls.
+func (r *Resolver) Resolve(config map[string]interface{}) (map[string]interface{}, error) {
+	resolved, err := r.resolveValue(config, "")
+	if err != nil {
+		return nil, err
+	}
+	out, _ := resolved.(map[string]interface{})
+	return out, nil
+}
+
+// resolveValue walks value, resolving strings and recursing into containers
+func (r *Resolver) resolveValue(value interface{}, path string) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return r.resolveString(v, path)
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			resolved, err := r.resolveValue(item, joinPath(path, k))
+			if err != nil {
+				return nil, err
+			}
+			out[k] = resolved
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			resolved, err := r.resolveValue(item, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			out[i] = resolved
+		}
+		return out, nil
+	default:
+		return value, nil
+	}
+}
+
+// resolveString resolves the interpolations inside a single string value
+func (r *Resolver) resolveString(s, path string) (interface{}, error) {
+	exprs := hcl.FindInterpolations(s)
+	if len(exprs) == 0 {
+		return s, nil
+	}
+
+	// A lone placeholder keeps the type of the value it resolves to
+	if len(exprs) == 1 && strings.TrimSpace(s) == "${"+exprs[0]+"}" {
+		value, ok, err := r.Evaluate(exprs[0])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", displayPath(path), err)
+		}
+		if !ok {
+			return s, nil
+		}
+		return value, nil
+	}
+
+	out := s
+	for _, expr := range exprs {
+		value, ok, err := r.Evaluate(expr)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", displayPath(path), err)
+		}
+		if !ok {
+			continue
+		}
+		out = replacePlaceholder(out, expr, formatValue(value))
+	}
+	return out, nil
+}
+
+// replacePlaceholder substitutes the first ${expr} occurrence, tolerating inner spacing
+func replacePlaceholder(s, expr, replacement string) string {
+	for _, candidate := range []string{"${" + expr + "}", "${ " + expr + " }"} {
+		if strings.Contains(s, candidate) {
+			return strings.Replace(s, candidate, replacement, 1)
+		}
+	}
+	return s
+}
+
+// Evaluate evaluates a single expression (without the surrounding ${}). The boolean
+// result is false when the expression depends on a value that is not registered.
+func (r *Resolver) Evaluate(expr string) (interface{}, bool, error) {
+	node, err := hcl.ParseExpression(expr)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid expression %q: %w", expr, err)
+	}
+	return r.evaluate(node)
+}
+
+func (r *Resolver) evaluate(node *hcl.Value) (interface{}, bool, error) {
+	switch node.Kind {
+	case hcl.KindReference:
+		value, ok := r.lookup(node.Reference.Parts)
+		return value, ok, nil
+	case hcl.KindFunction:
+		fn, exists := r.functions[node.Function]
+		if !exists {
+			return nil, false, fmt.Errorf("unknown function %q", node.Function)
+		}
+		args := make([]interface{}, len(node.Args))
+		for i, arg := range node.Args {
+			value, ok, err := r.evaluate(arg)
+			if err != nil || !ok {
+				return nil, ok, err
+			}
+			args[i] = value
+		}
+		value, err := fn(args)
+		if err != nil {
+			return nil, false, fmt.Errorf("%s(): %w", node.Function, err)
+		}
+		return value, true, nil
+	case hcl.KindList:
+		items := make([]interface{}, len(node.Items))
+		for i, item := range node.Items {
+			value, ok, err := r.evaluate(item)
+			if err != nil || !ok {
+				return nil, ok, err
+			}
+			items[i] = value
+		}
+		return items, true, nil
+	case hcl.KindObject:
+		fields := make(map[string]interface{}, len(node.Fields))
+		for k, field := range node.Fields {
+			value, ok, err := r.evaluate(field)
+			if err != nil || !ok {
+				return nil, ok, err
+			}
+			fields[k] = value
+		}
+		return fields, true, nil
+	case hcl.KindString:
+		resolved, err := r.resolveString(node.String, "")
+		if err != nil {
+			return nil, false, err
+		}
+		str, isString := resolved.(string)
+		return resolved, !isString || len(hcl.FindInterpolations(str)) == 0, nil
+	default:
+		return node.Interface(), true, nil
+	}
+}
+
+// lookup finds the longest registered address prefix and descends into the remainder
+func (r *Resolver) lookup(parts []string) (interface{}, bool) {
+	for n := len(parts); n > 0; n-- {
+		root, ok := r.values[strings.Join(parts[:n], ".")]
+		if !ok {
+			continue
+		}
+		return descend(root, parts[n:])
+	}
+	return nil, false
+}
+
+// descend follows map keys and list indexes into value
+func descend(value interface{}, parts []string) (interface{}, bool) {
+	for _, part := range parts {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			next, ok := v[part]
+			if !ok {
+				return nil, false
+			}
+			value = next
+		case []interface{}:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			value = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// =============================================================================
+// UNRESOLVED PLACEHOLDER DETECTION
+// =============================================================================
+
+// Placeholder is an interpolation expression that is still present in a config.
+type Placeholder struct {
+	Path       string `json:"path"`       // e.g. columns[0].default
+	Expression string `json:"expression"` // e.g. postgres_sequence.ids.name
+}
+
+// UnresolvedError reports every placeholder that survived resolution.
+type UnresolvedError struct {
+	Placeholders []Placeholder
+}
+
+// Error lists each unresolved placeholder with its config path.
+func (e *UnresolvedError) Error() string {
+	parts := make([]string, len(e.Placeholders))
+	for i, p := range e.Placeholders {
+		parts[i] = fmt.Sprintf("%s (${%s})", displayPath(p.Path), p.Expression)
+	}
+	return fmt.Sprintf("unresolved interpolation in config: %s", strings.Join(parts, ", "))
+}
+
+// FindUnresolved returns every "${...}" placeholder in config, sorted by path.
+func FindUnresolved(config map[string]interface{}) []Placeholder {
+	var found []Placeholder
+	collectPlaceholders(config, "", &found)
+	sort.SliceStable(found, func(i, j int) bool { return found[i].Path < found[j].Path })
+	return found
+}
+
+func collectPlaceholders(value interface{}, path string, found *[]Placeholder) {
+	switch v := value.(type) {
+	case string:
+		for _, expr := range hcl.FindInterpolations(v) {
+			*found = append(*found, Placeholder{Path: path, Expression: expr})
+		}
+	case map[string]interface{}:
+		for k, item := range v {
+			collectPlaceholders(item, joinPath(path, k), found)
+		}
+	case []interface{}:
+		for i, item := range v {
+			collectPlaceholders(item, fmt.Sprintf("%s[%d]", path, i), found)
+		}
+	}
+}
+
+// RequireResolved returns an *UnresolvedError when config still contains placeholders.
+// Call it before touching the backing system so apply fails before any side effects.
+func RequireResolved(config map[string]interface{}) error {
+	if placeholders := FindUnresolved(config); len(placeholders) > 0 {
+		return &UnresolvedError{Placeholders: placeholders}
+	}
+	return nil
+}
+
+// ResolveAll resolves config and requires that nothing is left unresolved.
+func (r *Resolver) ResolveAll(config map[string]interface{}) (map[string]interface{}, error) {
+	resolved, err := r.Resolve(config)
+	if err != nil {
+		return nil, err
+	}
+	if err := RequireResolved(resolved); err != nil {
+		return nil, err
+	}
+	return resolved, nil
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func displayPath(path string) string {
+	if path == "" {
+		return "<root>"
+	}
+	return path
+}
+
+// formatValue renders a resolved value for embedding into a string
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,72 @@
+package interpolation
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestResolver() *Resolver {
+	return NewResolver().
+		SetValue("postgres_table.users", map[string]interface{}{
+			"name":    "users",
+			"id":      float64(42),
+			"columns": []interface{}{map[string]interface{}{"name": "id"}},
+		}).
+		SetValue("var.env", "prod")
+}
+
+func TestResolve_TypedAndEmbedded(t *testing.T) {
+	config := map[string]interface{}{
+		"table_id": "${postgres_table.users.id}",
+		"label":    "${var.env}-${postgres_table.users.name}",
+		"first":    "${postgres_table.users.columns[0].name}",
+		"upper":    `${upper(var.env)}`,
+		"nested":   map[string]interface{}{"list": []interface{}{"${join(\"/\", [var.env, \"x\"])}"}},
+		"plain":    "unchanged",
+		"count":    float64(3),
+	}
+
+	resolved, err := newTestResolver().Resolve(config)
+	require.NoError(t, err)
+
+	require.Equal(t, float64(42), resolved["table_id"])
+	require.Equal(t, "prod-users", resolved["label"])
+	require.Equal(t, "id", resolved["first"])
+	require.Equal(t, "PROD", resolved["upper"])
+	require.Equal(t, []interface{}{"prod/x"}, resolved["nested"].(map[string]interface{})["list"])
+	require.Equal(t, "unchanged", resolved["plain"])
+	require.Equal(t, "${postgres_table.users.id}", config["table_id"], "input must not be mutated")
+}
+
+func TestResolve_LeavesUnknownReferences(t *testing.T) {
+	config := map[string]interface{}{
+		"owner": "${postgres_role.app.name}",
+		"columns": []interface{}{
+			map[string]interface{}{"default": "nextval('${postgres_sequence.ids.name}')"},
+		},
+		"env": "${var.env}",
+	}
+
+	resolved, err := newTestResolver().Resolve(config)
+	require.NoError(t, err)
+	require.Equal(t, "prod", resolved["env"])
+
+	err = RequireResolved(resolved)
+	var unresolved *UnresolvedError
+	require.True(t, errors.As(err, &unresolved))
+	require.Equal(t, []Placeholder{
+		{Path: "columns[0].default", Expression: "postgres_sequence.ids.name"},
+		{Path: "owner", Expression: "postgres_role.app.name"},
+	}, unresolved.Placeholders)
+	require.Contains(t, err.Error(), "columns[0].default")
+}
+
+func TestResolve_Errors(t *testing.T) {
+	_, err := NewResolver().Resolve(map[string]interface{}{"x": "${nope(1)}"})
+	require.ErrorContains(t, err, `x: unknown function "nope"`)
+
+	_, err = NewResolver().ResolveAll(map[string]interface{}{"x": "${var.missing}"})
+	require.ErrorContains(t, err, "unresolved interpolation")
+}
@@ -3,7 +3,9 @@
 package logging
 
 import (
+	"fmt"
 	"strings"
+	"time"
 
 	"github.com/schemabounce/kolumn/sdk/core"
 )
@@ -164,13 +166,53 @@ func LogPlanComplete(logger *Logger, ctx *PlanLoggingContext, summaries []core.P
 	} else {
 		logger.Info("Plan complete: no changes required (%d resources unchanged)", noopCount)
 	}
+
+	LogTimingSummary(logger, core.SummarizeTimings(ResourceTimings(summaries)))
 }
 
 // LogResourceSummary logs a single resource summary in concise format.
 func LogResourceSummary(logger *Logger, summary core.PlanResourceSummary) {
+	line := fmt.Sprintf("%s.%s: %s", summary.ResourceType, summary.Name, summary.Action)
 	if summary.Reason != "" {
-		logger.Debug("%s.%s: %s (%s)", summary.ResourceType, summary.Name, summary.Action, summary.Reason)
-	} else {
-		logger.Debug("%s.%s: %s", summary.ResourceType, summary.Name, summary.Action)
+		line += fmt.Sprintf(" (%s)", summary.Reason)
+	}
+	if summary.ResponseMetadata != nil {
+		line += fmt.Sprintf(" [%s]", summary.ResponseMetadata)
+	}
+	logger.Debug("%s", line)
+}
+
+// ResourceTimings collects the response metadata of summaries that carry it.
+func ResourceTimings(summaries []core.PlanResourceSummary) []core.ResourceTiming {
+	var timings []core.ResourceTiming
+	for _, s := range summaries {
+		if s.ResponseMetadata == nil {
+			continue
+		}
+		timings = append(timings, core.ResourceTiming{
+			ResourceType: s.ResourceType,
+			Name:         s.Name,
+			Operation:    s.Action,
+			Metadata:     *s.ResponseMetadata,
+		})
+	}
+	return timings
+}
+
+// slowestResourcesLogged is how many of the slowest resources LogTimingSummary lists.
+const slowestResourcesLogged = 5
+
+// LogTimingSummary logs the total backend time and the slowest resources, so slow
+// applies can be traced to individual resources and backend requests.
+func LogTimingSummary(logger *Logger, summary *core.TimingSummary) {
+	if summary == nil || len(summary.Resources) == 0 {
+		return
+	}
+	logger.Info("Backend time: %s across %d resources", summary.Total.Round(time.Millisecond), len(summary.Resources))
+	for _, timing := range summary.Slowest(slowestResourcesLogged) {
+		logger.Info("  %s.%s (%s): %s", timing.ResourceType, timing.Name, timing.Operation, &timing.Metadata)
+	}
+	if summary.RateLimitRemaining != nil {
+		logger.Info("Lowest remaining rate limit: %d requests", *summary.RateLimitRemaining)
 	}
 }
@@ -0,0 +1,61 @@
+package par
+
+import (
+	"context"
+	"sync"
+)
+
+// Group runs functions concurrently, like errgroup.Group, with at most limit running at
+// once. The first error cancels the group's context and is returned by Wait.
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	sem    chan struct{}
+	wg     sync.WaitGroup
+
+	errOnce sync.Once
+	err     error
+}
+
+// NewGroup returns a group and the context passed to its functions, which is cancelled
+// after the first error or once Wait returns. A limit of zero uses DefaultLimit.
+func NewGroup(ctx context.Context, limit int) (*Group, context.Context) {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	return &Group{ctx: ctx, cancel: cancel, sem: make(chan struct{}, limit)}, ctx
+}
+
+// Go runs fn in a new goroutine, blocking while the group is at its limit. Once the
+// group's context is done fn is not started and the context error is recorded instead.
+func (g *Group) Go(fn func(ctx context.Context) error) {
+	if err := acquire(g.ctx, g.sem); err != nil {
+		g.fail(err)
+		return
+	}
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		defer func() { <-g.sem }()
+		if _, err := call(g.ctx, struct{}{}, func(ctx context.Context, _ struct{}) (struct{}, error) {
+			return struct{}{}, fn(ctx)
+		}); err != nil {
+			g.fail(err)
+		}
+	}()
+}
+
+// Wait blocks until every started function has returned and returns the first error.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel()
+	return g.err
+}
+
+func (g *Group) fail(err error) {
+	g.errOnce.Do(func() {
+		g.err = err
+		g.cancel()
+	})
+}
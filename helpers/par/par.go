@@ -0,0 +1,233 @@
+// Package par provides structured concurrency for handlers that fan out over many
+// items, such as creating the grants of a role or scanning every schema of a database.
+//
+// Map and ForEach run a function per item with bounded concurrency and always return
+// one Result per item, in input order, so handlers can report partial success:
+//
+//	results := par.Map(ctx, schemas, par.Options[string]{Limit: 4}, func(ctx context.Context, schema string) ([]Table, error) {
+//		return scanSchema(ctx, db, schema)
+//	})
+//	for _, r := range results.Succeeded() {
+//		tables = append(tables, r.Value...)
+//	}
+//	diagnostics = append(diagnostics, results.Diagnostics("SCAN_FAILED")...)
+//
+// Group is an errgroup-style alternative for heterogeneous work that only needs the
+// first error.
+package par
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+)
+
+// DefaultLimit is the number of items processed at once when Options.Limit is unset.
+const DefaultLimit = 8
+
+// Options controls how Map and ForEach process items.
+type Options[I any] struct {
+	// Limit bounds how many items are processed at once; zero uses DefaultLimit.
+	Limit int
+
+	// FailFast cancels the context passed to running items after the first failure
+	// and skips items that have not started yet.
+	FailFast bool
+
+	// Key names an item in errors and diagnostics, e.g. its resource address.
+	// Without it items are named by their index.
+	Key func(I) string
+}
+
+// Result is the outcome of processing one item.
+type Result[T any] struct {
+	Index    int
+	Key      string
+	Value    T
+	Err      error
+	Skipped  bool // not started because the context was cancelled
+	Duration time.Duration
+}
+
+// Results holds one Result per item, in input order.
+type Results[T any] []Result[T]
+
+// ItemError attributes an error to the item that produced it.
+type ItemError struct {
+	Index int
+	Key   string
+	Err   error
+}
+
+func (e *ItemError) Error() string {
+	if e.Key != "" {
+		return fmt.Sprintf("%s: %v", e.Key, e.Err)
+	}
+	return fmt.Sprintf("item %d: %v", e.Index, e.Err)
+}
+
+func (e *ItemError) Unwrap() error {
+	return e.Err
+}
+
+// PanicError is returned for an item whose function panicked, so one bad item cannot
+// take down the provider process.
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Map calls fn for every item with at most opts.Limit calls running at once and
+// collects the results. Map returns when every started call has returned.
+func Map[I, T any](ctx context.Context, items []I, opts Options[I], fn func(context.Context, I) (T, error)) Results[T] {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(Results[T], len(items))
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i, item := range items {
+		results[i].Index = i
+		if opts.Key != nil {
+			results[i].Key = opts.Key(item)
+		}
+
+		if err := acquire(ctx, sem); err != nil {
+			results[i].Err, results[i].Skipped = err, true
+			continue
+		}
+
+		wg.Add(1)
+		go func(result *Result[T], item I) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			start := time.Now()
+			result.Value, result.Err = call(ctx, item, fn)
+			result.Duration = time.Since(start)
+			if result.Err != nil && opts.FailFast {
+				cancel()
+			}
+		}(&results[i], item)
+	}
+	wg.Wait()
+	return results
+}
+
+// ForEach calls fn for every item like Map, for work that produces no value.
+func ForEach[I any](ctx context.Context, items []I, opts Options[I], fn func(context.Context, I) error) Results[struct{}] {
+	return Map(ctx, items, opts, func(ctx context.Context, item I) (struct{}, error) {
+		return struct{}{}, fn(ctx, item)
+	})
+}
+
+// acquire takes a slot in sem, failing once ctx is done.
+func acquire(ctx context.Context, sem chan struct{}) error {
+	select {
+	case sem <- struct{}{}:
+		if err := ctx.Err(); err != nil {
+			<-sem
+			return err
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// call runs fn, converting a panic into a PanicError.
+func call[I, T any](ctx context.Context, item I, fn func(context.Context, I) (T, error)) (value T, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return fn(ctx, item)
+}
+
+// Succeeded returns the results without an error.
+func (rs Results[T]) Succeeded() Results[T] {
+	var out Results[T]
+	for _, r := range rs {
+		if r.Err == nil {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// Failed returns the results with an error, including skipped items.
+func (rs Results[T]) Failed() Results[T] {
+	var out Results[T]
+	for _, r := range rs {
+		if r.Err != nil {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// Values returns the values of the successful results, in input order.
+func (rs Results[T]) Values() []T {
+	var values []T
+	for _, r := range rs {
+		if r.Err == nil {
+			values = append(values, r.Value)
+		}
+	}
+	return values
+}
+
+// Err joins the errors of the failed items as ItemErrors, or returns nil when every
+// item succeeded.
+func (rs Results[T]) Err() error {
+	var errs []error
+	for _, r := range rs {
+		if r.Err != nil {
+			errs = append(errs, &ItemError{Index: r.Index, Key: r.Key, Err: r.Err})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Diagnostics returns an error diagnostic with the given code for every failed item.
+// Items are named by their Key, which should be the resource address when the
+// diagnostics are returned to Kolumn. Skipped items are reported as warnings since they
+// were never attempted.
+func (rs Results[T]) Diagnostics(code string) []core.Diagnostic {
+	var diagnostics []core.Diagnostic
+	for _, r := range rs {
+		if r.Err == nil {
+			continue
+		}
+		name := r.Key
+		if name == "" {
+			name = fmt.Sprintf("item %d", r.Index)
+		}
+		diagnostic := core.Diagnostic{
+			Severity: "error",
+			Code:     code,
+			Summary:  name + " failed",
+			Detail:   r.Err.Error(),
+			Resource: r.Key,
+		}
+		if r.Skipped {
+			diagnostic.Severity = "warning"
+			diagnostic.Summary = name + " skipped"
+		}
+		diagnostics = append(diagnostics, diagnostic)
+	}
+	return diagnostics
+}
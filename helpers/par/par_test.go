@@ -0,0 +1,117 @@
+package par
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMap_BoundedConcurrencyAndOrder(t *testing.T) {
+	var running, peak int32
+	items := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+
+	results := Map(context.Background(), items, Options[int]{Limit: 3}, func(ctx context.Context, n int) (int, error) {
+		now := atomic.AddInt32(&running, 1)
+		for {
+			old := atomic.LoadInt32(&peak)
+			if now <= old || atomic.CompareAndSwapInt32(&peak, old, now) {
+				break
+			}
+		}
+		time.Sleep(2 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return n * n, nil
+	})
+
+	require.LessOrEqual(t, peak, int32(3))
+	require.NoError(t, results.Err())
+	require.Equal(t, []int{1, 4, 9, 16, 25, 36, 49, 64, 81, 100}, results.Values())
+}
+
+func TestMap_PartialResultsAndDiagnostics(t *testing.T) {
+	grants := []string{"SELECT", "INSERT", "DROP", "UPDATE"}
+	opts := Options[string]{Key: func(g string) string { return "postgres.grant." + g }}
+
+	results := Map(context.Background(), grants, opts, func(ctx context.Context, grant string) (string, error) {
+		switch grant {
+		case "DROP":
+			return "", errors.New("permission denied")
+		case "UPDATE":
+			panic("driver bug")
+		}
+		return grant, nil
+	})
+
+	require.Equal(t, []string{"SELECT", "INSERT"}, results.Values())
+	require.Len(t, results.Failed(), 2)
+
+	var panicErr *PanicError
+	require.ErrorAs(t, results[3].Err, &panicErr)
+	require.NotEmpty(t, panicErr.Stack)
+
+	var itemErr *ItemError
+	require.ErrorAs(t, results.Err(), &itemErr)
+	require.Equal(t, "postgres.grant.DROP: permission denied", itemErr.Error())
+
+	diagnostics := results.Diagnostics("GRANT_FAILED")
+	require.Len(t, diagnostics, 2)
+	require.Equal(t, "error", diagnostics[0].Severity)
+	require.Equal(t, "GRANT_FAILED", diagnostics[0].Code)
+	require.Equal(t, "postgres.grant.DROP", diagnostics[0].Resource)
+	require.Equal(t, "permission denied", diagnostics[0].Detail)
+}
+
+func TestForEach_FailFastSkipsRemaining(t *testing.T) {
+	items := make([]int, 20)
+	for i := range items {
+		items[i] = i
+	}
+	var started int32
+
+	results := ForEach(context.Background(), items, Options[int]{Limit: 1, FailFast: true}, func(ctx context.Context, n int) error {
+		atomic.AddInt32(&started, 1)
+		if n == 2 {
+			return fmt.Errorf("schema %d unreachable", n)
+		}
+		return nil
+	})
+
+	require.Equal(t, int32(3), started)
+	require.True(t, results[19].Skipped)
+	require.ErrorIs(t, results[19].Err, context.Canceled)
+
+	diagnostics := results.Diagnostics("SCAN_FAILED")
+	require.Equal(t, "error", diagnostics[0].Severity)
+	require.Equal(t, "warning", diagnostics[1].Severity)
+	require.Equal(t, "item 3 skipped", diagnostics[1].Summary)
+}
+
+func TestGroup_FirstErrorCancels(t *testing.T) {
+	g, ctx := NewGroup(context.Background(), 2)
+	boom := errors.New("boom")
+
+	g.Go(func(ctx context.Context) error { return boom })
+	g.Go(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	require.ErrorIs(t, g.Wait(), boom)
+	require.Error(t, ctx.Err())
+
+	g, _ = NewGroup(context.Background(), 0)
+	var count int32
+	for i := 0; i < 10; i++ {
+		g.Go(func(ctx context.Context) error {
+			atomic.AddInt32(&count, 1)
+			return nil
+		})
+	}
+	require.NoError(t, g.Wait())
+	require.Equal(t, int32(10), count)
+}
@@ -0,0 +1,192 @@
+// Package sandbox launches auxiliary processes and third-party provider binaries with
+// least privilege. By default a sandboxed process gets:
+//
+//   - a restricted environment holding only DefaultEnv and the variables the caller
+//     passes through, so credentials in the parent environment do not leak
+//   - a private, empty working directory, also used as HOME and TMPDIR, that Cleanup
+//     removes
+//   - no network access (Linux only: the process runs in its own user and network
+//     namespaces, where only an unconfigured loopback interface exists)
+//
+// Each default is lifted only by granting the matching Capability:
+//
+//	cmd, err := sandbox.Command(ctx, sandbox.Options{}, binary, "--schema")
+//	if err != nil {
+//		return err
+//	}
+//	defer cmd.Cleanup()
+//	out, err := cmd.Output()
+//
+// The sandbox does not filter system calls or restrict which files the process can
+// read; run binaries that are not trusted at all in a container.
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// Capability is a privilege a sandboxed process only gets when granted explicitly.
+type Capability string
+
+// Capabilities a sandboxed process can be granted.
+const (
+	// CapNetwork keeps network access.
+	CapNetwork Capability = "network"
+
+	// CapEnv passes the full parent environment instead of the restricted one.
+	CapEnv Capability = "env"
+
+	// CapWorkDir runs the process in the caller's working directory instead of a
+	// private one.
+	CapWorkDir Capability = "workdir"
+
+	// CapHome keeps the caller's HOME, e.g. so ssh finds ~/.ssh.
+	CapHome Capability = "home"
+)
+
+// DefaultEnv names the parent environment variables every sandboxed process keeps.
+var DefaultEnv = []string{"PATH", "LANG", "LC_ALL", "TZ", "TERM", "SYSTEMROOT"}
+
+// ErrIsolationUnsupported is returned when network isolation is required on a platform
+// that cannot provide it.
+var ErrIsolationUnsupported = errors.New("sandbox: network isolation is not supported on this platform")
+
+// Options configures a sandboxed process. The zero value applies every restriction.
+type Options struct {
+	// Capabilities lifts individual restrictions.
+	Capabilities []Capability
+
+	// Env names further parent environment variables to pass through, and SetEnv sets
+	// variables explicitly, overriding inherited ones.
+	Env    []string
+	SetEnv map[string]string
+
+	// Dir is the working directory. Empty means a private temporary directory, or the
+	// caller's working directory with CapWorkDir.
+	Dir string
+
+	// BestEffort runs the process without network isolation where the platform cannot
+	// provide it, instead of failing with ErrIsolationUnsupported.
+	BestEffort bool
+}
+
+// Allows reports whether a capability was granted.
+func (o Options) Allows(c Capability) bool {
+	for _, granted := range o.Capabilities {
+		if granted == c {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseCapabilities parses a comma-separated capability list such as "network,home",
+// as taken by command-line flags.
+func ParseCapabilities(list string) ([]Capability, error) {
+	var caps []Capability
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(strings.ToLower(name))
+		switch Capability(name) {
+		case "":
+			continue
+		case CapNetwork, CapEnv, CapWorkDir, CapHome:
+			caps = append(caps, Capability(name))
+		default:
+			return nil, fmt.Errorf("sandbox: unknown capability %q", name)
+		}
+	}
+	return caps, nil
+}
+
+// Cmd is a sandboxed command. Call Cleanup once it has exited to remove its private
+// working directory.
+type Cmd struct {
+	*exec.Cmd
+	privateDir string
+}
+
+// Cleanup removes the private working directory, if the sandbox created one.
+func (c *Cmd) Cleanup() error {
+	if c.privateDir == "" {
+		return nil
+	}
+	dir := c.privateDir
+	c.privateDir = ""
+	return os.RemoveAll(dir)
+}
+
+// Command returns a sandboxed command running name with args. The context kills the
+// process when done, like exec.CommandContext.
+func Command(ctx context.Context, opts Options, name string, args ...string) (*Cmd, error) {
+	cmd := &Cmd{Cmd: exec.CommandContext(ctx, name, args...)}
+
+	switch {
+	case opts.Dir != "":
+		cmd.Dir = opts.Dir
+	case !opts.Allows(CapWorkDir):
+		dir, err := os.MkdirTemp("", "kolumn-sandbox-")
+		if err != nil {
+			return nil, fmt.Errorf("sandbox: %w", err)
+		}
+		cmd.Dir, cmd.privateDir = dir, dir
+	}
+
+	cmd.Env = sandboxEnv(opts, cmd.privateDir)
+
+	if !opts.Allows(CapNetwork) {
+		if err := isolateNetwork(cmd.Cmd); err != nil {
+			if !errors.Is(err, ErrIsolationUnsupported) || !opts.BestEffort {
+				cmd.Cleanup()
+				return nil, err
+			}
+		}
+	}
+	return cmd, nil
+}
+
+// sandboxEnv builds the environment of a sandboxed process
+func sandboxEnv(opts Options, privateDir string) []string {
+	env := make(map[string]string)
+	if opts.Allows(CapEnv) {
+		for _, kv := range os.Environ() {
+			if k, v, ok := strings.Cut(kv, "="); ok {
+				env[k] = v
+			}
+		}
+	} else {
+		for _, names := range [][]string{DefaultEnv, opts.Env} {
+			for _, name := range names {
+				if v, ok := os.LookupEnv(name); ok {
+					env[name] = v
+				}
+			}
+		}
+		if opts.Allows(CapHome) {
+			if v, ok := os.LookupEnv("HOME"); ok {
+				env["HOME"] = v
+			}
+		}
+	}
+	if privateDir != "" {
+		if !opts.Allows(CapHome) && !opts.Allows(CapEnv) {
+			env["HOME"] = privateDir
+		}
+		env["TMPDIR"] = privateDir
+	}
+	for k, v := range opts.SetEnv {
+		env[k] = v
+	}
+
+	list := make([]string, 0, len(env))
+	for k, v := range env {
+		list = append(list, k+"="+v)
+	}
+	sort.Strings(list)
+	return list
+}
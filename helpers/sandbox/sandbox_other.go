@@ -0,0 +1,10 @@
+//go:build !linux
+
+package sandbox
+
+import "os/exec"
+
+// isolateNetwork is not available outside Linux
+func isolateNetwork(cmd *exec.Cmd) error {
+	return ErrIsolationUnsupported
+}
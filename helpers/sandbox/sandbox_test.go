@@ -0,0 +1,103 @@
+package sandbox
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func shell(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+	return "/bin/sh"
+}
+
+func TestCommandRestrictsEnvironmentAndWorkDir(t *testing.T) {
+	sh := shell(t)
+	t.Setenv("KOLUMN_TEST_SECRET", "hunter2")
+	t.Setenv("KOLUMN_TEST_PASSED", "yes")
+
+	cmd, err := Command(context.Background(), Options{
+		Capabilities: []Capability{CapNetwork},
+		Env:          []string{"KOLUMN_TEST_PASSED"},
+		SetEnv:       map[string]string{"KOLUMN_TEST_SET": "set"},
+	}, sh, "-c", `echo "$KOLUMN_TEST_SECRET|$KOLUMN_TEST_PASSED|$KOLUMN_TEST_SET|$HOME"; pwd -P`)
+	require.NoError(t, err)
+
+	out, err := cmd.Output()
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	require.Len(t, lines, 2)
+
+	dir := cmd.Dir
+	require.Equal(t, "|yes|set|"+dir, lines[0], "secrets must not leak and HOME must be the private directory")
+	resolved, err := os.Stat(lines[1])
+	require.NoError(t, err)
+	expected, err := os.Stat(dir)
+	require.NoError(t, err)
+	require.True(t, os.SameFile(resolved, expected), "process must run in the private directory")
+
+	require.NoError(t, cmd.Cleanup())
+	_, err = os.Stat(dir)
+	require.True(t, os.IsNotExist(err), "Cleanup must remove the private directory")
+}
+
+func TestCommandCapabilities(t *testing.T) {
+	sh := shell(t)
+	t.Setenv("KOLUMN_TEST_SECRET", "hunter2")
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+
+	cmd, err := Command(context.Background(), Options{
+		Capabilities: []Capability{CapNetwork, CapEnv, CapWorkDir},
+	}, sh, "-c", `echo "$KOLUMN_TEST_SECRET"; pwd -P`)
+	require.NoError(t, err)
+	defer cmd.Cleanup()
+
+	out, err := cmd.Output()
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	require.Equal(t, "hunter2", lines[0])
+	resolved, err := os.Stat(lines[1])
+	require.NoError(t, err)
+	expected, err := os.Stat(wd)
+	require.NoError(t, err)
+	require.True(t, os.SameFile(resolved, expected))
+}
+
+func TestCommandWithoutNetwork(t *testing.T) {
+	sh := shell(t)
+	cmd, err := Command(context.Background(), Options{}, sh, "-c", "cat /proc/net/dev")
+	if runtime.GOOS != "linux" {
+		require.ErrorIs(t, err, ErrIsolationUnsupported)
+		return
+	}
+	require.NoError(t, err)
+	defer cmd.Cleanup()
+
+	out, err := cmd.Output()
+	if err != nil {
+		t.Skipf("user namespaces are not available: %v", err)
+	}
+	for _, line := range strings.Split(string(out), "\n")[2:] {
+		name, _, _ := strings.Cut(strings.TrimSpace(line), ":")
+		if name != "" && name != "lo" {
+			t.Fatalf("network interface %q is visible inside the sandbox", name)
+		}
+	}
+}
+
+func TestParseCapabilities(t *testing.T) {
+	caps, err := ParseCapabilities(" network, Home ,")
+	require.NoError(t, err)
+	require.Equal(t, []Capability{CapNetwork, CapHome}, caps)
+
+	_, err = ParseCapabilities("root")
+	require.Error(t, err)
+}
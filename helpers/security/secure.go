@@ -49,6 +49,9 @@ var AllowedMethods = map[string]bool{
 	"delete": true,
 	"plan":   true,
 
+	// Polling of asynchronous operations started by create, update or delete
+	"poll_operation": true,
+
 	// DISCOVER object methods
 	"scan":    true,
 	"analyze": true,
@@ -348,6 +351,23 @@ func (v *InputSizeValidator) ValidateConfigSize(config map[string]interface{}) e
 	return nil
 }
 
+// ValidateRawConfigSize validates a configuration map whose values are still raw JSON.
+// Only the key count and key lengths are checked; the values must come from input that
+// passed SafeUnmarshal, which already limits their size and depth.
+func (v *InputSizeValidator) ValidateRawConfigSize(config map[string]json.RawMessage) error {
+	if len(config) > MaxArrayItems {
+		return ErrTooManyItems
+	}
+
+	for key := range config {
+		if len(key) > MaxStringLength {
+			return ErrStringTooLong
+		}
+	}
+
+	return nil
+}
+
 // RateLimiter provides basic rate limiting functionality
 type RateLimiter struct {
 	requests map[string]int
@@ -0,0 +1,313 @@
+// Package tlsconfig builds *tls.Config values from provider configuration maps.
+//
+// Database and streaming providers accept the same TLS settings, either as top-level
+// tls_* keys (tls_ca_cert_file, ...) or nested under a "tls" block:
+//
+//	tls {
+//	  enabled              = true
+//	  ca_cert_file         = "/etc/ssl/ca.pem"
+//	  client_cert_file     = "/etc/ssl/client.pem"
+//	  client_key_file      = "/etc/ssl/client-key.pem"
+//	  server_name          = "db.internal"
+//	  min_version          = "1.2"
+//	  insecure_skip_verify = false
+//	}
+//
+// PEM content can be passed inline with ca_cert, client_cert and client_key instead of
+// the *_file variants.
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+	"github.com/schemabounce/kolumn/sdk/helpers/logging"
+)
+
+// Configuration keys understood by FromConfig.
+const (
+	KeyBlock              = "tls"
+	KeyEnabled            = "enabled"
+	KeyCACert             = "ca_cert"
+	KeyCACertFile         = "ca_cert_file"
+	KeyClientCert         = "client_cert"
+	KeyClientCertFile     = "client_cert_file"
+	KeyClientKey          = "client_key"
+	KeyClientKeyFile      = "client_key_file"
+	KeyServerName         = "server_name"
+	KeyMinVersion         = "min_version"
+	KeyInsecureSkipVerify = "insecure_skip_verify"
+)
+
+// Options are the TLS settings extracted from a provider configuration.
+type Options struct {
+	Enabled            bool
+	CACert             string // inline PEM
+	CACertFile         string
+	ClientCert         string // inline PEM
+	ClientCertFile     string
+	ClientKey          string // inline PEM
+	ClientKeyFile      string
+	ServerName         string
+	MinVersion         string
+	InsecureSkipVerify bool
+}
+
+var minVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// FromConfig reads TLS options from a provider configuration map. Settings under a
+// nested "tls" block take precedence over top-level tls_* keys. TLS is considered enabled
+// when "enabled" is true or any certificate setting is present.
+func FromConfig(config map[string]interface{}) (*Options, error) {
+	merged := make(map[string]interface{})
+	for _, key := range []string{KeyEnabled, KeyCACert, KeyCACertFile, KeyClientCert, KeyClientCertFile,
+		KeyClientKey, KeyClientKeyFile, KeyServerName, KeyMinVersion, KeyInsecureSkipVerify} {
+		if v, ok := config["tls_"+key]; ok {
+			merged[key] = v
+		}
+	}
+	switch block := config[KeyBlock].(type) {
+	case map[string]interface{}:
+		for k, v := range block {
+			merged[k] = v
+		}
+	case bool:
+		merged[KeyEnabled] = block
+	}
+
+	opts := &Options{}
+	var err error
+	strField := func(key string, dst *string) {
+		if err != nil {
+			return
+		}
+		if v, ok := merged[key]; ok && v != nil {
+			s, isString := v.(string)
+			if !isString {
+				err = fmt.Errorf("tls %s must be a string", key)
+				return
+			}
+			*dst = s
+		}
+	}
+	boolField := func(key string, dst *bool) {
+		if err != nil {
+			return
+		}
+		if v, ok := merged[key]; ok && v != nil {
+			b, isBool := v.(bool)
+			if !isBool {
+				err = fmt.Errorf("tls %s must be a boolean", key)
+				return
+			}
+			*dst = b
+		}
+	}
+
+	strField(KeyCACert, &opts.CACert)
+	strField(KeyCACertFile, &opts.CACertFile)
+	strField(KeyClientCert, &opts.ClientCert)
+	strField(KeyClientCertFile, &opts.ClientCertFile)
+	strField(KeyClientKey, &opts.ClientKey)
+	strField(KeyClientKeyFile, &opts.ClientKeyFile)
+	strField(KeyServerName, &opts.ServerName)
+	strField(KeyMinVersion, &opts.MinVersion)
+	boolField(KeyInsecureSkipVerify, &opts.InsecureSkipVerify)
+	boolField(KeyEnabled, &opts.Enabled)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, explicit := merged[KeyEnabled]; !explicit {
+		opts.Enabled = opts.CACert != "" || opts.CACertFile != "" || opts.ClientCert != "" ||
+			opts.ClientCertFile != "" || opts.ServerName != "" || opts.InsecureSkipVerify
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
+
+// Validate checks that the options are internally consistent without touching files.
+func (o *Options) Validate() error {
+	if o.CACert != "" && o.CACertFile != "" {
+		return fmt.Errorf("tls ca_cert and ca_cert_file are mutually exclusive")
+	}
+	if o.ClientCert != "" && o.ClientCertFile != "" {
+		return fmt.Errorf("tls client_cert and client_cert_file are mutually exclusive")
+	}
+	if o.ClientKey != "" && o.ClientKeyFile != "" {
+		return fmt.Errorf("tls client_key and client_key_file are mutually exclusive")
+	}
+
+	hasCert := o.ClientCert != "" || o.ClientCertFile != ""
+	hasKey := o.ClientKey != "" || o.ClientKeyFile != ""
+	if hasCert != hasKey {
+		return fmt.Errorf("tls client certificate and client key must be provided together")
+	}
+
+	if o.MinVersion != "" {
+		if _, ok := minVersions[strings.TrimPrefix(o.MinVersion, "TLS")]; !ok {
+			return fmt.Errorf("tls min_version %q is not supported (use 1.2 or 1.3)", o.MinVersion)
+		}
+	}
+	return nil
+}
+
+// Build constructs the *tls.Config. It returns nil when TLS is disabled.
+func (o *Options) Build() (*tls.Config, error) {
+	if !o.Enabled {
+		return nil, nil
+	}
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: o.ServerName,
+	}
+	if o.MinVersion != "" {
+		cfg.MinVersion = minVersions[strings.TrimPrefix(o.MinVersion, "TLS")]
+	}
+	if cfg.MinVersion < tls.VersionTLS12 {
+		logging.SecurityLogger.Warnf("TLS %s is deprecated and insecure; use TLS 1.2 or newer", o.MinVersion)
+	}
+
+	caPEM, err := readPEM(o.CACert, o.CACertFile, "CA bundle")
+	if err != nil {
+		return nil, err
+	}
+	if caPEM != nil {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("tls CA bundle contains no valid certificates")
+		}
+		cfg.RootCAs = pool
+	}
+
+	certPEM, err := readPEM(o.ClientCert, o.ClientCertFile, "client certificate")
+	if err != nil {
+		return nil, err
+	}
+	if certPEM != nil {
+		keyPEM, err := readPEM(o.ClientKey, o.ClientKeyFile, "client key")
+		if err != nil {
+			return nil, err
+		}
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("tls client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	if o.InsecureSkipVerify {
+		logging.SecurityLogger.Warn("!!! TLS certificate verification is DISABLED (insecure_skip_verify = true). " +
+			"Connections are vulnerable to man-in-the-middle attacks. Never use this in production. !!!")
+		cfg.InsecureSkipVerify = true
+	}
+	return cfg, nil
+}
+
+// Build reads TLS options from a provider configuration map and constructs the
+// *tls.Config. It returns nil when TLS is not enabled.
+func Build(config map[string]interface{}) (*tls.Config, error) {
+	opts, err := FromConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return opts.Build()
+}
+
+func readPEM(inline, file, what string) ([]byte, error) {
+	if inline != "" {
+		return []byte(inline), nil
+	}
+	if file == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tls %s: %w", what, err)
+	}
+	return data, nil
+}
+
+// ValidationRules returns config framework rules for the top-level tls_* keys, so
+// providers can register them with BaseProvider.AddValidationRules.
+func ValidationRules() []core.ConfigValidationRule {
+	versions := make([]string, 0, len(minVersions))
+	for v := range minVersions {
+		versions = append(versions, v)
+	}
+	sort.Strings(versions)
+
+	return []core.ConfigValidationRule{
+		{
+			Field:       "tls_ca_cert_file",
+			Type:        "string",
+			Description: "Path to a PEM encoded CA bundle used to verify the server",
+			Custom:      fileExists,
+			Suggestion:  "Provide a readable PEM file",
+			Example:     `tls_ca_cert_file = "/etc/ssl/certs/ca.pem"`,
+		},
+		{
+			Field:       "tls_client_cert_file",
+			Type:        "string",
+			Description: "Path to a PEM encoded client certificate for mutual TLS",
+			Custom:      fileExists,
+			Suggestion:  "Provide a readable PEM file together with tls_client_key_file",
+			Example:     `tls_client_cert_file = "/etc/ssl/client.pem"`,
+		},
+		{
+			Field:       "tls_client_key_file",
+			Type:        "string",
+			Description: "Path to the PEM encoded private key for the client certificate",
+			Custom:      fileExists,
+			Suggestion:  "Provide a readable PEM file together with tls_client_cert_file",
+			Example:     `tls_client_key_file = "/etc/ssl/client-key.pem"`,
+		},
+		{
+			Field:       "tls_server_name",
+			Type:        "string",
+			Description: "Server name used for SNI and certificate verification",
+			Example:     `tls_server_name = "db.internal"`,
+		},
+		{
+			Field:       "tls_min_version",
+			Type:        "string",
+			Enum:        versions,
+			Description: "Minimum TLS version",
+			Suggestion:  "Use 1.2 or 1.3",
+			Example:     `tls_min_version = "1.2"`,
+		},
+		{
+			Field:       "tls_insecure_skip_verify",
+			Type:        "bool",
+			Description: "Disable server certificate verification (insecure, testing only)",
+			Example:     "tls_insecure_skip_verify = false",
+		},
+	}
+}
+
+func fileExists(value interface{}) error {
+	path, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("must be a file path")
+	}
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("file %s is not readable: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package tlsconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func selfSignedPEM(t *testing.T) (certPEM, keyPEM []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "kolumn-test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM
+}
+
+func TestBuildDisabled(t *testing.T) {
+	cfg, err := Build(map[string]interface{}{"host": "localhost"})
+	require.NoError(t, err)
+	require.Nil(t, cfg)
+}
+
+func TestBuildMutualTLSFromFiles(t *testing.T) {
+	certPEM, keyPEM := selfSignedPEM(t)
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "client.pem")
+	keyFile := filepath.Join(dir, "client-key.pem")
+	require.NoError(t, os.WriteFile(certFile, certPEM, 0o600))
+	require.NoError(t, os.WriteFile(keyFile, keyPEM, 0o600))
+
+	cfg, err := Build(map[string]interface{}{
+		"tls": map[string]interface{}{
+			"ca_cert_file":     certFile,
+			"client_cert_file": certFile,
+			"client_key_file":  keyFile,
+			"server_name":      "db.internal",
+			"min_version":      "1.3",
+		},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, cfg)
+	require.NotNil(t, cfg.RootCAs)
+	require.Len(t, cfg.Certificates, 1)
+	require.Equal(t, "db.internal", cfg.ServerName)
+	require.Equal(t, uint16(tls.VersionTLS13), cfg.MinVersion)
+	require.False(t, cfg.InsecureSkipVerify)
+}
+
+func TestBuildInlinePEMAndTopLevelKeys(t *testing.T) {
+	certPEM, _ := selfSignedPEM(t)
+
+	cfg, err := Build(map[string]interface{}{
+		"tls_ca_cert":              string(certPEM),
+		"tls_insecure_skip_verify": true,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, cfg)
+	require.True(t, cfg.InsecureSkipVerify)
+	require.Equal(t, uint16(tls.VersionTLS12), cfg.MinVersion)
+}
+
+func TestFromConfigValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		config map[string]interface{}
+	}{
+		{"cert without key", map[string]interface{}{"tls_client_cert_file": "/tmp/cert.pem"}},
+		{"inline and file CA", map[string]interface{}{"tls_ca_cert": "x", "tls_ca_cert_file": "/tmp/ca.pem"}},
+		{"unsupported version", map[string]interface{}{"tls": map[string]interface{}{"enabled": true, "min_version": "2.0"}}},
+		{"wrong type", map[string]interface{}{"tls_insecure_skip_verify": "yes"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := FromConfig(tt.config)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestBuildRejectsInvalidCABundle(t *testing.T) {
+	_, err := Build(map[string]interface{}{"tls_ca_cert": "not a certificate"})
+	require.Error(t, err)
+}
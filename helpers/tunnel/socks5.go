@@ -0,0 +1,182 @@
+package tunnel
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+)
+
+// SOCKS5 protocol constants (RFC 1928, RFC 1929).
+const (
+	socksVersion5        = 0x05
+	socksAuthNone        = 0x00
+	socksAuthPassword    = 0x02
+	socksAuthNoAccept    = 0xff
+	socksCmdConnect      = 0x01
+	socksAddrIPv4        = 0x01
+	socksAddrDomain      = 0x03
+	socksAddrIPv6        = 0x04
+	socksPasswordVersion = 0x01
+)
+
+var socksReplies = map[byte]string{
+	0x01: "general SOCKS server failure",
+	0x02: "connection not allowed by ruleset",
+	0x03: "network unreachable",
+	0x04: "host unreachable",
+	0x05: "connection refused",
+	0x06: "TTL expired",
+	0x07: "command not supported",
+	0x08: "address type not supported",
+}
+
+// socks5Dialer dials targets through a SOCKS5 proxy.
+type socks5Dialer struct {
+	proxyAddr string
+	username  string
+	password  string
+	dialer    net.Dialer
+}
+
+// DialContext connects to addr through the proxy.
+func (d *socks5Dialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	if network != "tcp" && network != "tcp4" && network != "tcp6" {
+		return nil, fmt.Errorf("socks5: unsupported network %q", network)
+	}
+
+	conn, err := d.dialer.DialContext(ctx, "tcp", d.proxyAddr)
+	if err != nil {
+		return nil, fmt.Errorf("socks5: failed to reach proxy %s: %w", d.proxyAddr, err)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+		defer conn.SetDeadline(noDeadline)
+	}
+
+	if err := d.handshake(conn, addr); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (d *socks5Dialer) handshake(conn net.Conn, addr string) error {
+	method := byte(socksAuthNone)
+	if d.username != "" {
+		method = socksAuthPassword
+	}
+	if _, err := conn.Write([]byte{socksVersion5, 1, method}); err != nil {
+		return fmt.Errorf("socks5: greeting failed: %w", err)
+	}
+
+	reply := make([]byte, 2)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return fmt.Errorf("socks5: greeting failed: %w", err)
+	}
+	if reply[0] != socksVersion5 {
+		return fmt.Errorf("socks5: unexpected protocol version %d", reply[0])
+	}
+	switch reply[1] {
+	case socksAuthNone:
+	case socksAuthPassword:
+		if err := d.authenticate(conn); err != nil {
+			return err
+		}
+	case socksAuthNoAccept:
+		return errors.New("socks5: proxy rejected all authentication methods")
+	default:
+		return fmt.Errorf("socks5: proxy selected unsupported authentication method %d", reply[1])
+	}
+
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("socks5: invalid target address %q: %w", addr, err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port < 1 || port > 65535 {
+		return fmt.Errorf("socks5: invalid target port %q", portStr)
+	}
+
+	req := []byte{socksVersion5, socksCmdConnect, 0}
+	if ip := net.ParseIP(host); ip != nil {
+		if ip4 := ip.To4(); ip4 != nil {
+			req = append(req, socksAddrIPv4)
+			req = append(req, ip4...)
+		} else {
+			req = append(req, socksAddrIPv6)
+			req = append(req, ip.To16()...)
+		}
+	} else {
+		if len(host) > 255 {
+			return fmt.Errorf("socks5: target host name too long")
+		}
+		req = append(req, socksAddrDomain, byte(len(host)))
+		req = append(req, host...)
+	}
+	req = binary.BigEndian.AppendUint16(req, uint16(port))
+
+	if _, err := conn.Write(req); err != nil {
+		return fmt.Errorf("socks5: connect request failed: %w", err)
+	}
+
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return fmt.Errorf("socks5: connect reply failed: %w", err)
+	}
+	if header[1] != 0 {
+		msg, ok := socksReplies[header[1]]
+		if !ok {
+			msg = fmt.Sprintf("reply code %d", header[1])
+		}
+		return fmt.Errorf("socks5: proxy could not connect to %s: %s", addr, msg)
+	}
+
+	// Discard the bound address
+	var skip int
+	switch header[3] {
+	case socksAddrIPv4:
+		skip = net.IPv4len
+	case socksAddrIPv6:
+		skip = net.IPv6len
+	case socksAddrDomain:
+		l := make([]byte, 1)
+		if _, err := io.ReadFull(conn, l); err != nil {
+			return fmt.Errorf("socks5: connect reply failed: %w", err)
+		}
+		skip = int(l[0])
+	default:
+		return fmt.Errorf("socks5: unknown bound address type %d", header[3])
+	}
+	if _, err := io.CopyN(io.Discard, conn, int64(skip+2)); err != nil {
+		return fmt.Errorf("socks5: connect reply failed: %w", err)
+	}
+	return nil
+}
+
+func (d *socks5Dialer) authenticate(conn net.Conn) error {
+	if len(d.username) > 255 || len(d.password) > 255 {
+		return errors.New("socks5: username and password must be at most 255 bytes")
+	}
+
+	req := []byte{socksPasswordVersion, byte(len(d.username))}
+	req = append(req, d.username...)
+	req = append(req, byte(len(d.password)))
+	req = append(req, d.password...)
+	if _, err := conn.Write(req); err != nil {
+		return fmt.Errorf("socks5: authentication failed: %w", err)
+	}
+
+	reply := make([]byte, 2)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return fmt.Errorf("socks5: authentication failed: %w", err)
+	}
+	if reply[1] != 0 {
+		return errors.New("socks5: proxy rejected credentials")
+	}
+	return nil
+}
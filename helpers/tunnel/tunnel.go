@@ -0,0 +1,448 @@
+// Package tunnel establishes SSH bastion and SOCKS5 tunnels for backend connections.
+//
+// Providers embedding core.BaseProvider open the tunnel in Configure; the base provider
+// dials through it, reports it from CheckTunnel and closes it in Close:
+//
+//	func (p *Provider) Configure(ctx context.Context, config map[string]interface{}) error {
+//		if _, err := tunnel.Configure(ctx, p.BaseProvider, config); err != nil {
+//			return err
+//		}
+//		conn, err := p.DialBackend(ctx, "tcp", "db.internal:5432")
+//		...
+//	}
+//
+//	func (p *Provider) HealthCheck(ctx context.Context) (*core.HealthStatus, error) {
+//		status := &core.HealthStatus{Healthy: true, Status: "healthy"}
+//		p.CheckTunnel(ctx, status)
+//		return status, nil
+//	}
+//
+// SSH tunnels run the system ssh client with dynamic port forwarding (-D), so both
+// tunnel types are dialed as SOCKS5 proxies and the SDK keeps to the standard library.
+package tunnel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+	"github.com/schemabounce/kolumn/sdk/helpers/logging"
+	"github.com/schemabounce/kolumn/sdk/helpers/sandbox"
+)
+
+// Tunnel types.
+const (
+	TypeSSH    = "ssh"
+	TypeSOCKS5 = "socks5"
+)
+
+// DefaultStartTimeout bounds how long Open waits for an SSH tunnel to accept connections.
+const DefaultStartTimeout = 15 * time.Second
+
+var noDeadline time.Time
+
+// Config describes a tunnel. It is usually read from the "tunnel" block of a provider
+// configuration with FromConfig.
+type Config struct {
+	Type string
+
+	// SSH bastion settings
+	Host                  string
+	Port                  int
+	User                  string
+	PrivateKeyFile        string
+	KnownHostsFile        string
+	StrictHostKeyChecking bool
+	SSHBinary             string // defaults to "ssh"
+
+	// SOCKS5 proxy settings
+	Address  string // host:port of the proxy
+	Username string
+	Password string
+
+	StartTimeout time.Duration
+
+	// Sandbox runs the ssh client with a restricted environment and a private working
+	// directory. Network access, HOME (for ~/.ssh) and SSH_AUTH_SOCK are always granted.
+	Sandbox *sandbox.Options
+}
+
+// FromConfig reads the "tunnel" block of a provider configuration. It returns nil when
+// no tunnel is configured.
+func FromConfig(config map[string]interface{}) (*Config, error) {
+	block, ok := config["tunnel"].(map[string]interface{})
+	if !ok || len(block) == 0 {
+		return nil, nil
+	}
+
+	cfg := &Config{
+		Port:                  22,
+		StrictHostKeyChecking: true,
+	}
+	var err error
+	str := func(key string) string {
+		v, exists := block[key]
+		if !exists || v == nil || err != nil {
+			return ""
+		}
+		s, isString := v.(string)
+		if !isString {
+			err = fmt.Errorf("tunnel %s must be a string", key)
+		}
+		return s
+	}
+
+	cfg.Type = str("type")
+	cfg.Host = str("host")
+	cfg.User = str("user")
+	cfg.PrivateKeyFile = str("private_key_file")
+	cfg.KnownHostsFile = str("known_hosts_file")
+	cfg.SSHBinary = str("ssh_binary")
+	cfg.Address = str("address")
+	cfg.Username = str("username")
+	cfg.Password = str("password")
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := block["port"].(type) {
+	case nil:
+	case int:
+		cfg.Port = v
+	case float64:
+		cfg.Port = int(v)
+	case string:
+		if cfg.Port, err = strconv.Atoi(v); err != nil {
+			return nil, fmt.Errorf("tunnel port must be a number")
+		}
+	default:
+		return nil, fmt.Errorf("tunnel port must be a number")
+	}
+	if v, exists := block["strict_host_key_checking"]; exists {
+		b, isBool := v.(bool)
+		if !isBool {
+			return nil, fmt.Errorf("tunnel strict_host_key_checking must be a boolean")
+		}
+		cfg.StrictHostKeyChecking = b
+	}
+	if v, exists := block["start_timeout"]; exists && v != nil {
+		s, isString := v.(string)
+		if !isString {
+			return nil, fmt.Errorf("tunnel start_timeout must be a duration string such as \"30s\"")
+		}
+		if cfg.StartTimeout, err = time.ParseDuration(s); err != nil {
+			return nil, fmt.Errorf("tunnel start_timeout: %w", err)
+		}
+	}
+
+	if cfg.Type == "" {
+		cfg.Type = TypeSSH
+		if cfg.Address != "" {
+			cfg.Type = TypeSOCKS5
+		}
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Validate checks that the required settings for the tunnel type are present.
+func (c *Config) Validate() error {
+	switch c.Type {
+	case TypeSSH:
+		if c.Host == "" {
+			return errors.New("ssh tunnel requires host")
+		}
+		if c.User == "" {
+			return errors.New("ssh tunnel requires user")
+		}
+		if c.Port < 1 || c.Port > 65535 {
+			return fmt.Errorf("ssh tunnel port %d is out of range", c.Port)
+		}
+	case TypeSOCKS5:
+		if _, _, err := net.SplitHostPort(c.Address); err != nil {
+			return fmt.Errorf("socks5 tunnel address must be host:port: %w", err)
+		}
+	default:
+		return fmt.Errorf("unsupported tunnel type %q (use %s or %s)", c.Type, TypeSSH, TypeSOCKS5)
+	}
+	return nil
+}
+
+// Tunnel is an established tunnel. A nil *Tunnel dials directly, so providers can use
+// it unconditionally.
+type Tunnel struct {
+	config *Config
+	dialer *socks5Dialer
+
+	mu      sync.Mutex
+	cmd     *exec.Cmd
+	exited  chan struct{}
+	exitErr error
+	closed  bool
+}
+
+// OpenFromConfig opens the tunnel described by the provider configuration, or returns
+// nil when none is configured.
+func OpenFromConfig(ctx context.Context, config map[string]interface{}) (*Tunnel, error) {
+	cfg, err := FromConfig(config)
+	if err != nil || cfg == nil {
+		return nil, err
+	}
+	return Open(ctx, cfg)
+}
+
+// Configure opens the tunnel described by the provider configuration and hands it to
+// bp, closing any tunnel an earlier Configure opened. It returns nil and clears the
+// provider's tunnel when none is configured.
+func Configure(ctx context.Context, bp *core.BaseProvider, config map[string]interface{}) (*Tunnel, error) {
+	t, err := OpenFromConfig(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, bp.SetTunnel(nil)
+	}
+	if err := bp.SetTunnel(t); err != nil {
+		return t, fmt.Errorf("failed to close previous tunnel: %w", err)
+	}
+	return t, nil
+}
+
+// Open establishes a tunnel. SSH tunnels are started and waited on until the local
+// forwarding port accepts connections.
+func Open(ctx context.Context, cfg *Config) (*Tunnel, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	t := &Tunnel{config: cfg}
+	switch cfg.Type {
+	case TypeSOCKS5:
+		t.dialer = &socks5Dialer{proxyAddr: cfg.Address, username: cfg.Username, password: cfg.Password}
+		return t, nil
+	case TypeSSH:
+		if err := t.startSSH(ctx); err != nil {
+			return nil, err
+		}
+		return t, nil
+	}
+	return nil, fmt.Errorf("unsupported tunnel type %q", cfg.Type)
+}
+
+// startSSH runs ssh with dynamic forwarding on a free loopback port
+func (t *Tunnel) startSSH(ctx context.Context) error {
+	localAddr, err := freeLoopbackAddr()
+	if err != nil {
+		return fmt.Errorf("ssh tunnel: %w", err)
+	}
+
+	binary := t.config.SSHBinary
+	if binary == "" {
+		binary = "ssh"
+	}
+	// Not tied to ctx: the tunnel must outlive Configure
+	cmd := exec.Command(binary, sshArgs(t.config, localAddr)...)
+	cleanup := func() error { return nil }
+	if t.config.Sandbox != nil {
+		opts := *t.config.Sandbox
+		opts.Capabilities = append(append([]sandbox.Capability(nil), opts.Capabilities...), sandbox.CapNetwork, sandbox.CapHome)
+		opts.Env = append(append([]string(nil), opts.Env...), "SSH_AUTH_SOCK")
+		sandboxed, err := sandbox.Command(context.Background(), opts, binary, sshArgs(t.config, localAddr)...)
+		if err != nil {
+			return fmt.Errorf("ssh tunnel: %w", err)
+		}
+		cmd, cleanup = sandboxed.Cmd, sandboxed.Cleanup
+	}
+	if err := cmd.Start(); err != nil {
+		cleanup()
+		return fmt.Errorf("ssh tunnel: failed to start %s: %w", binary, err)
+	}
+
+	t.cmd = cmd
+	t.exited = make(chan struct{})
+	go func() {
+		err := cmd.Wait()
+		cleanup()
+		t.mu.Lock()
+		t.exitErr = err
+		t.mu.Unlock()
+		close(t.exited)
+	}()
+
+	timeout := t.config.StartTimeout
+	if timeout == 0 {
+		timeout = DefaultStartTimeout
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		conn, dialErr := net.DialTimeout("tcp", localAddr, 200*time.Millisecond)
+		if dialErr == nil {
+			conn.Close()
+			break
+		}
+		select {
+		case <-t.exited:
+			return fmt.Errorf("ssh tunnel to %s@%s exited: %v", t.config.User, t.config.Host, t.exitError())
+		case <-waitCtx.Done():
+			t.Close()
+			return fmt.Errorf("ssh tunnel to %s@%s did not become ready: %w", t.config.User, t.config.Host, waitCtx.Err())
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	t.dialer = &socks5Dialer{proxyAddr: localAddr}
+	logging.ConnectionLogger.Infof("SSH tunnel established via %s@%s:%d", t.config.User, t.config.Host, t.config.Port)
+	return nil
+}
+
+// sshArgs builds the ssh command line for dynamic forwarding on localAddr
+func sshArgs(cfg *Config, localAddr string) []string {
+	strict := "yes"
+	if !cfg.StrictHostKeyChecking {
+		strict = "accept-new"
+	}
+
+	args := []string{
+		"-N",
+		"-D", localAddr,
+		"-p", strconv.Itoa(cfg.Port),
+		"-o", "ExitOnForwardFailure=yes",
+		"-o", "BatchMode=yes",
+		"-o", "ServerAliveInterval=30",
+		"-o", "StrictHostKeyChecking=" + strict,
+	}
+	if cfg.PrivateKeyFile != "" {
+		args = append(args, "-i", cfg.PrivateKeyFile, "-o", "IdentitiesOnly=yes")
+	}
+	if cfg.KnownHostsFile != "" {
+		args = append(args, "-o", "UserKnownHostsFile="+cfg.KnownHostsFile)
+	}
+	return append(args, cfg.User+"@"+cfg.Host)
+}
+
+func freeLoopbackAddr() (string, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", fmt.Errorf("failed to allocate local port: %w", err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	return addr, nil
+}
+
+// DialContext connects to addr through the tunnel, or directly when t is nil.
+func (t *Tunnel) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	if t == nil {
+		var d net.Dialer
+		return d.DialContext(ctx, network, addr)
+	}
+	if t.isClosed() {
+		return nil, errors.New("tunnel is closed")
+	}
+	if t.exited != nil {
+		select {
+		case <-t.exited:
+			return nil, fmt.Errorf("ssh tunnel exited: %v", t.exitError())
+		default:
+		}
+	}
+	return t.dialer.DialContext(ctx, network, addr)
+}
+
+// Check reports tunnel health as a HealthStatus check named "tunnel".
+func (t *Tunnel) Check(ctx context.Context) core.CheckResult {
+	start := time.Now()
+	result := core.CheckResult{Name: "tunnel", CheckedAt: start}
+
+	if t == nil {
+		result.Passed = true
+		result.Message = "no tunnel configured"
+		return result
+	}
+
+	err := t.ping(ctx)
+	result.Duration = time.Since(start)
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+	result.Passed = true
+	result.Message = fmt.Sprintf("%s tunnel is up", t.config.Type)
+	return result
+}
+
+// ping checks that the proxy endpoint still accepts connections
+func (t *Tunnel) ping(ctx context.Context) error {
+	if t.isClosed() {
+		return errors.New("tunnel is closed")
+	}
+	if t.exited != nil {
+		select {
+		case <-t.exited:
+			return fmt.Errorf("ssh tunnel exited: %v", t.exitError())
+		default:
+		}
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", t.dialer.proxyAddr)
+	if err != nil {
+		return fmt.Errorf("tunnel endpoint %s is unreachable: %w", t.dialer.proxyAddr, err)
+	}
+	return conn.Close()
+}
+
+// Close stops the tunnel. It is safe to call on a nil tunnel and more than once.
+func (t *Tunnel) Close() error {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return nil
+	}
+	t.closed = true
+	cmd := t.cmd
+	t.mu.Unlock()
+
+	if cmd == nil || cmd.Process == nil {
+		return nil
+	}
+	select {
+	case <-t.exited:
+		return nil
+	default:
+	}
+	if err := cmd.Process.Kill(); err != nil {
+		return fmt.Errorf("failed to stop ssh tunnel: %w", err)
+	}
+	<-t.exited
+	return nil
+}
+
+func (t *Tunnel) isClosed() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.closed
+}
+
+func (t *Tunnel) exitError() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.exitErr == nil {
+		return errors.New("process exited")
+	}
+	return t.exitErr
+}
@@ -0,0 +1,198 @@
+package tunnel
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+	"github.com/stretchr/testify/require"
+)
+
+// startSOCKS5Server runs a minimal SOCKS5 proxy that requires username/password auth
+// and records the requested target.
+func startSOCKS5Server(t *testing.T, user, pass string, targets chan<- string) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func(c net.Conn) {
+				defer c.Close()
+				buf := make([]byte, 512)
+
+				// greeting
+				if _, err := io.ReadFull(c, buf[:2]); err != nil {
+					return
+				}
+				io.ReadFull(c, buf[:buf[1]])
+				c.Write([]byte{5, 2})
+
+				// username/password
+				io.ReadFull(c, buf[:2])
+				u := make([]byte, buf[1])
+				io.ReadFull(c, u)
+				io.ReadFull(c, buf[:1])
+				p := make([]byte, buf[0])
+				io.ReadFull(c, p)
+				if string(u) != user || string(p) != pass {
+					c.Write([]byte{1, 1})
+					return
+				}
+				c.Write([]byte{1, 0})
+
+				// connect request with a domain target
+				io.ReadFull(c, buf[:4])
+				io.ReadFull(c, buf[:1])
+				host := make([]byte, buf[0])
+				io.ReadFull(c, host)
+				io.ReadFull(c, buf[:2])
+				if targets != nil {
+					targets <- net.JoinHostPort(string(host), strconv.Itoa(int(binary.BigEndian.Uint16(buf[:2]))))
+				}
+
+				c.Write([]byte{5, 0, 0, 1, 127, 0, 0, 1, 0, 0})
+				c.Write([]byte("hello"))
+			}(conn)
+		}
+	}()
+	return l.Addr().String()
+}
+
+func TestFromConfig(t *testing.T) {
+	cfg, err := FromConfig(map[string]interface{}{"host": "db"})
+	require.NoError(t, err)
+	require.Nil(t, cfg)
+
+	cfg, err = FromConfig(map[string]interface{}{
+		"tunnel": map[string]interface{}{
+			"host":             "bastion.example.com",
+			"user":             "ops",
+			"port":             float64(2222),
+			"private_key_file": "/keys/id_ed25519",
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, TypeSSH, cfg.Type)
+	require.Equal(t, 2222, cfg.Port)
+	require.True(t, cfg.StrictHostKeyChecking)
+
+	args := sshArgs(cfg, "127.0.0.1:10800")
+	require.Contains(t, args, "127.0.0.1:10800")
+	require.Contains(t, args, "StrictHostKeyChecking=yes")
+	require.Equal(t, "ops@bastion.example.com", args[len(args)-1])
+
+	cfg, err = FromConfig(map[string]interface{}{
+		"tunnel": map[string]interface{}{"address": "proxy:1080"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, TypeSOCKS5, cfg.Type)
+
+	_, err = FromConfig(map[string]interface{}{
+		"tunnel": map[string]interface{}{"type": "ssh", "host": "bastion"},
+	})
+	require.Error(t, err)
+
+	_, err = FromConfig(map[string]interface{}{
+		"tunnel": map[string]interface{}{"address": "proxy:1080", "start_timeout": float64(30)},
+	})
+	require.ErrorContains(t, err, "start_timeout")
+}
+
+func TestSOCKS5Tunnel(t *testing.T) {
+	targets := make(chan string, 1)
+	addr := startSOCKS5Server(t, "kolumn", "secret", targets)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tun, err := OpenFromConfig(ctx, map[string]interface{}{
+		"tunnel": map[string]interface{}{
+			"type":     "socks5",
+			"address":  addr,
+			"username": "kolumn",
+			"password": "secret",
+		},
+	})
+	require.NoError(t, err)
+
+	conn, err := tun.DialContext(ctx, "tcp", "db.internal:5432")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.Equal(t, "db.internal:5432", <-targets)
+	greeting := make([]byte, 5)
+	_, err = io.ReadFull(conn, greeting)
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(greeting))
+
+	check := tun.Check(ctx)
+	require.True(t, check.Passed, check.Message)
+
+	require.NoError(t, tun.Close())
+	require.False(t, tun.Check(ctx).Passed)
+	_, err = tun.DialContext(ctx, "tcp", "db.internal:5432")
+	require.Error(t, err)
+}
+
+func TestSOCKS5RejectedCredentials(t *testing.T) {
+	addr := startSOCKS5Server(t, "kolumn", "secret", nil)
+
+	tun, err := Open(context.Background(), &Config{Type: TypeSOCKS5, Address: addr, Username: "kolumn", Password: "wrong"})
+	require.NoError(t, err)
+
+	_, err = tun.DialContext(context.Background(), "tcp", "db.internal:5432")
+	require.ErrorContains(t, err, "rejected credentials")
+}
+
+func TestNilTunnel(t *testing.T) {
+	var tun *Tunnel
+	require.True(t, tun.Check(context.Background()).Passed)
+	require.NoError(t, tun.Close())
+}
+
+func TestConfigureBaseProvider(t *testing.T) {
+	targets := make(chan string, 1)
+	addr := startSOCKS5Server(t, "kolumn", "secret", targets)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	bp := core.NewBaseProvider("test")
+	tun, err := Configure(ctx, bp, map[string]interface{}{
+		"tunnel": map[string]interface{}{"address": addr, "username": "kolumn", "password": "secret"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, core.BackendTunnel(tun), bp.Tunnel())
+
+	conn, err := bp.DialBackend(ctx, "tcp", "db.internal:5432")
+	require.NoError(t, err)
+	conn.Close()
+	require.Equal(t, "db.internal:5432", <-targets)
+
+	status := &core.HealthStatus{Healthy: true}
+	bp.CheckTunnel(ctx, status)
+	require.True(t, status.Healthy)
+	require.True(t, status.Checks["tunnel"].Passed)
+
+	require.NoError(t, bp.Close())
+	require.Nil(t, bp.Tunnel())
+	_, err = tun.DialContext(ctx, "tcp", "db.internal:5432")
+	require.Error(t, err, "Close must stop the tunnel")
+
+	// reconfiguring without a tunnel clears it
+	_, err = Configure(ctx, bp, map[string]interface{}{"host": "db"})
+	require.NoError(t, err)
+	require.Nil(t, bp.Tunnel())
+}
@@ -1,6 +1,10 @@
 package ui
 
-import "strings"
+import (
+	"strings"
+
+	"github.com/schemabounce/kolumn/sdk/types"
+)
 
 // HumanStatusColor returns the color to use for a human-facing status tag.
 func HumanStatusColor(status string) string {
@@ -231,12 +235,7 @@ func FormatTerraformStyle(resourceType, resourceName, action string, options Sty
 	action = strings.TrimSpace(action)
 
 	// Build resource identifier
-	var resource string
-	if resourceName != "" {
-		resource = resourceType + "." + resourceName
-	} else {
-		resource = resourceType
-	}
+	resource := types.Address{Type: resourceType, Name: resourceName}.String()
 
 	// Colorize based on action
 	if options.UseColors {
@@ -5,6 +5,8 @@ import (
 	"os"
 	"runtime"
 	"strings"
+
+	"github.com/schemabounce/kolumn/sdk/types"
 )
 
 // Reset / ANSI color codes
@@ -453,7 +455,7 @@ func FormatResourceMessage(resourceType, name, action string, options StyleOptio
 		}
 		parts = append(parts, prefix)
 	}
-	resource := resourceType + "." + name
+	resource := types.Address{Type: resourceType, Name: name}.String()
 	if options.UseColors {
 		resource = Bold + resource + Reset
 	}
@@ -394,6 +394,11 @@ func ValidateSchema(schema *core.Schema) error {
 		}
 	}
 
+	// Every referenced schema mixin must resolve without conflicts
+	if _, err := schema.ComposedResourceTypes(); err != nil {
+		return err
+	}
+
 	// Validate config schema if present
 	if schema.ConfigSchema != nil {
 		// ConfigSchema is now json.RawMessage, so we can't directly access Properties/Required
@@ -0,0 +1,567 @@
+package pdk
+
+import (
+	"bufio"
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+	"github.com/schemabounce/kolumn/sdk/helpers/security"
+)
+
+// MuxProtocolVersion is the version of the wire protocol spoken by Mux and DialMux.
+const MuxProtocolVersion = "1"
+
+// MaxMuxFrameSize bounds one protocol frame: a request payload at the dispatcher's
+// JSON size limit plus its envelope.
+const MaxMuxFrameSize = security.MaxJSONSize + 64*1024
+
+// Mux methods.
+const (
+	MuxMethodConfigure = "configure"
+	MuxMethodSchema    = "schema"
+	MuxMethodCall      = "call"
+)
+
+// MuxOutputEncodingBase64 marks a response whose output is not JSON, e.g. a compressed
+// payload, and is carried as a base64 JSON string.
+const MuxOutputEncodingBase64 = "base64"
+
+// MuxOtherFunction labels the metrics of calls to functions the dispatcher does not
+// route, so client-supplied names cannot grow the metric series without bound.
+const MuxOtherFunction = "other"
+
+// MuxHandshake is the first frame a client sends, naming the provider the connection
+// is routed to. A CoreVersion outside the provider schema's supported core versions
+// is rejected, as is a Secret that does not match Mux.Secret.
+type MuxHandshake struct {
+	Provider    string `json:"provider"`
+	Protocol    string `json:"protocol"`
+	CoreVersion string `json:"core_version,omitempty"`
+	Secret      string `json:"secret,omitempty"`
+}
+
+// MuxHandshakeResponse answers the handshake. Error is set, and the connection closed,
+// when the provider is unknown or the protocol is not supported.
+type MuxHandshakeResponse struct {
+	Provider  string   `json:"provider,omitempty"`
+	Protocol  string   `json:"protocol"`
+	Providers []string `json:"providers"` // every provider the process serves
+	Error     string   `json:"error,omitempty"`
+}
+
+// MuxRequest is a request frame. Requests on one connection may be answered out of
+// order; responses carry the request ID.
+type MuxRequest struct {
+	ID       uint64          `json:"id"`
+	Method   string          `json:"method"`
+	Function string          `json:"function,omitempty"` // call
+	Input    json.RawMessage `json:"input,omitempty"`    // call input or configure config
+}
+
+// MuxResponse is a response frame. Output is raw JSON unless OutputEncoding says
+// otherwise.
+type MuxResponse struct {
+	ID             uint64          `json:"id"`
+	Output         json.RawMessage `json:"output,omitempty"`
+	OutputEncoding string          `json:"output_encoding,omitempty"`
+	Error          string          `json:"error,omitempty"`
+	ErrorCode      string          `json:"error_code,omitempty"`
+}
+
+// Mux serves several providers from one process on a single listener, for monorepos
+// that build every provider into one binary. Each connection names its provider in
+// the handshake and is routed to it for its lifetime; frames are newline-delimited
+// JSON. The providers share one health and metrics endpoint (see Handler).
+type Mux struct {
+	mu        sync.RWMutex
+	providers map[string]core.Provider
+
+	metrics *muxMetrics
+	slo     map[string]*core.SLOTracker
+
+	drainMu  sync.Mutex
+	draining bool
+	active   int           // requests in flight
+	idle     chan struct{} // closed when draining and no request is in flight
+
+	// HealthTimeout bounds the ping of each provider by the health endpoint; the
+	// default is 10 seconds.
+	HealthTimeout time.Duration
+
+	// Secret, when set, must be presented in every handshake. ServeRPC sets a new one
+	// for each launch and hands it to core in the ServeHandshake.
+	Secret string
+}
+
+// NewMux creates an empty multiplexer.
+func NewMux() *Mux {
+	return &Mux{providers: make(map[string]core.Provider), metrics: newMuxMetrics(), slo: make(map[string]*core.SLOTracker)}
+}
+
+// TrackSLO records the function calls of a registered provider in tracker, reports its
+// error budgets on /metrics and its warnings on /healthz. Providers whose dispatcher
+// already records calls with core.UnifiedDispatcher.SetSLOTracker should not be tracked
+// here as well, or every call counts twice.
+func (m *Mux) TrackSLO(name string, tracker *core.SLOTracker) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.providers[name]; !ok {
+		return fmt.Errorf("mux: provider %q is not registered", name)
+	}
+	if tracker == nil {
+		delete(m.slo, name)
+		return nil
+	}
+	m.slo[name] = tracker
+	return nil
+}
+
+func (m *Mux) sloTrackers() map[string]*core.SLOTracker {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	trackers := make(map[string]*core.SLOTracker, len(m.slo))
+	for name, tracker := range m.slo {
+		trackers[name] = tracker
+	}
+	return trackers
+}
+
+// Register adds a provider under the name clients route to.
+func (m *Mux) Register(name string, provider core.Provider) error {
+	if name == "" || provider == nil {
+		return errors.New("mux: provider name and implementation are required")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, exists := m.providers[name]; exists {
+		return fmt.Errorf("mux: provider %q already registered", name)
+	}
+	m.providers[name] = provider
+	return nil
+}
+
+// Providers returns the registered provider names, sorted.
+func (m *Mux) Providers() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	names := make([]string, 0, len(m.providers))
+	for name := range m.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (m *Mux) provider(name string) (core.Provider, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	provider, ok := m.providers[name]
+	return provider, ok
+}
+
+// Serve accepts connections on ln until ctx is cancelled or the listener fails.
+// Connections still open when ctx is cancelled are closed.
+func (m *Mux) Serve(ctx context.Context, ln net.Listener) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.serveConn(ctx, conn)
+		}()
+	}
+}
+
+// Close closes every registered provider.
+func (m *Mux) Close() error {
+	var errs []error
+	for _, name := range m.Providers() {
+		provider, _ := m.provider(name)
+		if err := provider.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Shutdown drains the mux: requests arriving from now on are rejected with
+// PROVIDER_SHUTTING_DOWN, and Shutdown waits until the requests in flight finished or
+// ctx is done. Cancel the context passed to Serve afterwards to close the connections.
+func (m *Mux) Shutdown(ctx context.Context) error {
+	m.drainMu.Lock()
+	m.draining = true
+	if m.active == 0 {
+		m.drainMu.Unlock()
+		return nil
+	}
+	if m.idle == nil {
+		m.idle = make(chan struct{})
+	}
+	idle := m.idle
+	m.drainMu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// begin counts a request in flight, unless the mux is draining
+func (m *Mux) begin() bool {
+	m.drainMu.Lock()
+	defer m.drainMu.Unlock()
+	if m.draining {
+		return false
+	}
+	m.active++
+	return true
+}
+
+func (m *Mux) end() {
+	m.drainMu.Lock()
+	defer m.drainMu.Unlock()
+	m.active--
+	if m.active == 0 && m.idle != nil {
+		close(m.idle)
+		m.idle = nil
+	}
+}
+
+// serveConn runs the handshake, then answers requests until the client disconnects
+func (m *Mux) serveConn(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 64*1024), MaxMuxFrameSize)
+	var writeMu sync.Mutex
+	write := func(v interface{}) error {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		_, err = conn.Write(append(data, '\n'))
+		return err
+	}
+
+	if !scanner.Scan() {
+		return
+	}
+	var hello MuxHandshake
+	response := MuxHandshakeResponse{Protocol: MuxProtocolVersion, Providers: m.Providers()}
+	provider, ok := core.Provider(nil), false
+	switch {
+	case json.Unmarshal(scanner.Bytes(), &hello) != nil:
+		response.Error = "invalid handshake"
+	case hello.Protocol != MuxProtocolVersion:
+		response.Error = fmt.Sprintf("unsupported mux protocol %q, expected %q", hello.Protocol, MuxProtocolVersion)
+	case subtle.ConstantTimeCompare([]byte(hello.Secret), []byte(m.Secret)) != 1:
+		// the provider list is not disclosed to unauthenticated clients
+		response.Error, response.Providers = "invalid secret", nil
+	default:
+		if provider, ok = m.provider(hello.Provider); !ok {
+			response.Error = fmt.Sprintf("unknown provider %q", hello.Provider)
+		} else if err := checkMuxCoreVersion(provider, hello.CoreVersion); err != nil {
+			response.Error, ok = err.Error(), false
+		}
+	}
+	if !ok {
+		_ = write(response)
+		return
+	}
+	response.Provider = hello.Provider
+	if write(response) != nil {
+		return
+	}
+
+	m.metrics.connected(hello.Provider, 1)
+	defer m.metrics.connected(hello.Provider, -1)
+
+	// each connection negotiates its own settings, such as compression
+	ctx, cancel := context.WithCancel(core.WithSession(ctx, core.NewSession()))
+	defer cancel()
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for scanner.Scan() {
+		var req MuxRequest
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			_ = write(MuxResponse{Error: "invalid request", ErrorCode: "INVALID_REQUEST"})
+			continue
+		}
+		if !m.begin() {
+			_ = write(MuxResponse{ID: req.ID, Error: "provider is shutting down", ErrorCode: "PROVIDER_SHUTTING_DOWN"})
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer m.end()
+			_ = write(m.handle(ctx, hello.Provider, provider, &req))
+		}()
+	}
+}
+
+// checkMuxCoreVersion rejects a handshake from a core outside the provider's supported
+// core versions
+func checkMuxCoreVersion(provider core.Provider, version string) error {
+	if version == "" {
+		return nil
+	}
+	schema, err := provider.Schema()
+	if err != nil {
+		return fmt.Errorf("cannot check core version: %w", err)
+	}
+	return schema.CheckCoreVersion(version)
+}
+
+// handle runs one request against a provider and records its metrics
+func (m *Mux) handle(ctx context.Context, name string, provider core.Provider, req *MuxRequest) *MuxResponse {
+	start := time.Now()
+	resp := &MuxResponse{ID: req.ID}
+	var (
+		output []byte
+		err    error
+	)
+	label := req.Method
+	switch req.Method {
+	case MuxMethodConfigure:
+		var config map[string]interface{}
+		if err = security.SafeUnmarshal(req.Input, &config); err == nil {
+			err = provider.Configure(ctx, config)
+		}
+	case MuxMethodSchema:
+		var schema *core.Schema
+		if schema, err = provider.Schema(); err == nil {
+			output, err = json.Marshal(schema)
+		}
+	case MuxMethodCall:
+		label = MuxOtherFunction
+		if core.IsDispatchFunction(req.Function) {
+			label = req.Function
+		}
+		output, err = provider.CallFunction(ctx, req.Function, req.Input)
+	default:
+		err = security.NewSecureError("unknown method", "mux method "+req.Method, "INVALID_METHOD")
+	}
+	duration := time.Since(start)
+	m.metrics.observe(name, label, duration, err)
+	if req.Method == MuxMethodCall {
+		m.mu.RLock()
+		tracker := m.slo[name]
+		m.mu.RUnlock()
+		if tracker != nil {
+			tracker.Observe(label, duration, err)
+		}
+	}
+
+	if err != nil {
+		resp.Error = err.Error()
+		var secErr *security.SecureError
+		if errors.As(err, &secErr) {
+			resp.ErrorCode = secErr.Code
+		}
+		return resp
+	}
+	if len(output) > 0 && !json.Valid(output) {
+		// Outputs are carried as raw JSON; anything else, such as a compressed
+		// payload, is carried byte for byte as base64
+		output, _ = json.Marshal(base64.StdEncoding.EncodeToString(output))
+		resp.OutputEncoding = MuxOutputEncodingBase64
+	}
+	resp.Output = output
+	return resp
+}
+
+// Handler serves the infrastructure shared by every provider of the process:
+//
+//	/healthz   pings every provider; 200 when all are healthy, 503 otherwise
+//	/metrics   call counts, errors, durations and connections in Prometheus text format,
+//	           and the error budgets of providers tracked with TrackSLO
+func (m *Mux) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", m.serveHealth)
+	mux.HandleFunc("/metrics", m.serveMetrics)
+	return mux
+}
+
+// MuxHealth is the /healthz response. Warnings name the functions exhausting their
+// error budget; they do not make the process unhealthy.
+type MuxHealth struct {
+	Healthy   bool                          `json:"healthy"`
+	Providers map[string]*HealthcheckResult `json:"providers"`
+	Warnings  []string                      `json:"warnings,omitempty"`
+}
+
+func (m *Mux) serveHealth(w http.ResponseWriter, r *http.Request) {
+	timeout := m.HealthTimeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	health := &MuxHealth{Healthy: true, Providers: make(map[string]*HealthcheckResult)}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, name := range m.Providers() {
+		provider, _ := m.provider(name)
+		wg.Add(1)
+		go func(name string, provider core.Provider) {
+			defer wg.Done()
+			result := ping(ctx, provider)
+			mu.Lock()
+			defer mu.Unlock()
+			health.Providers[name] = result
+			health.Healthy = health.Healthy && result.Healthy
+		}(name, provider)
+	}
+	wg.Wait()
+
+	trackers := m.sloTrackers()
+	for _, name := range m.Providers() {
+		if tracker, ok := trackers[name]; ok {
+			for _, status := range tracker.Statuses() {
+				if status.Warning != "" {
+					health.Warnings = append(health.Warnings, name+": "+status.Warning)
+				}
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !health.Healthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(health)
+}
+
+// muxMetrics counts calls per provider and function
+type muxMetrics struct {
+	mu          sync.Mutex
+	calls       map[[2]string]*callMetrics
+	connections map[string]int
+}
+
+type callMetrics struct {
+	count    uint64
+	errors   uint64
+	duration time.Duration
+}
+
+func newMuxMetrics() *muxMetrics {
+	return &muxMetrics{calls: make(map[[2]string]*callMetrics), connections: make(map[string]int)}
+}
+
+func (m *muxMetrics) observe(provider, function string, duration time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := [2]string{provider, function}
+	c, ok := m.calls[key]
+	if !ok {
+		c = &callMetrics{}
+		m.calls[key] = c
+	}
+	c.count++
+	c.duration += duration
+	if err != nil {
+		c.errors++
+	}
+}
+
+func (m *muxMetrics) connected(provider string, delta int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.connections[provider] += delta
+}
+
+func (m *Mux) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	var b strings.Builder
+	m.metrics.write(&b)
+	if trackers := m.sloTrackers(); len(trackers) > 0 {
+		_ = core.WriteSLOMetrics(&b, trackers)
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	_, _ = io.WriteString(w, b.String())
+}
+
+func (m *muxMetrics) write(b *strings.Builder) {
+	m.mu.Lock()
+	keys := make([][2]string, 0, len(m.calls))
+	for key := range m.calls {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+	calls := make([]callMetrics, len(keys))
+	for i, key := range keys {
+		calls[i] = *m.calls[key]
+	}
+	providers := make([]string, 0, len(m.connections))
+	for provider := range m.connections {
+		providers = append(providers, provider)
+	}
+	sort.Strings(providers)
+	connections := make([]int, len(providers))
+	for i, provider := range providers {
+		connections[i] = m.connections[provider]
+	}
+	m.mu.Unlock()
+
+	series := func(name, help, kind string, value func(i int) string) {
+		fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+		for i, key := range keys {
+			fmt.Fprintf(b, "%s{provider=%q,function=%q} %s\n", name, key[0], key[1], value(i))
+		}
+	}
+	series("kolumn_provider_calls_total", "Provider requests served.", "counter", func(i int) string {
+		return fmt.Sprint(calls[i].count)
+	})
+	series("kolumn_provider_call_errors_total", "Provider requests that failed.", "counter", func(i int) string {
+		return fmt.Sprint(calls[i].errors)
+	})
+	series("kolumn_provider_call_duration_seconds_total", "Time spent serving provider requests.", "counter", func(i int) string {
+		return fmt.Sprintf("%g", calls[i].duration.Seconds())
+	})
+	fmt.Fprintf(b, "# HELP kolumn_provider_connections Open client connections.\n# TYPE kolumn_provider_connections gauge\n")
+	for i, provider := range providers {
+		fmt.Fprintf(b, "kolumn_provider_connections{provider=%q} %d\n", provider, connections[i])
+	}
+}
@@ -0,0 +1,213 @@
+package pdk
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+	"github.com/schemabounce/kolumn/sdk/helpers/security"
+)
+
+// MuxClient is a connection to one provider served by a Mux. It implements
+// core.Provider, so callers use a remote provider like a local one. Requests may be
+// issued concurrently.
+type MuxClient struct {
+	conn      net.Conn
+	provider  string
+	providers []string
+
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	nextID  uint64
+	pending map[uint64]chan *MuxResponse
+	err     error // set once the connection fails
+	done    chan struct{}
+}
+
+// DialMux connects to a Mux and routes the connection to the named provider.
+func DialMux(ctx context.Context, network, address, provider string) (*MuxClient, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+	client, err := NewMuxClient(conn, MuxHandshake{Provider: provider, Protocol: MuxProtocolVersion})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+// NewMuxClient runs the handshake on an established connection.
+func NewMuxClient(conn net.Conn, hello MuxHandshake) (*MuxClient, error) {
+	if hello.Protocol == "" {
+		hello.Protocol = MuxProtocolVersion
+	}
+	data, err := json.Marshal(hello)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write(append(data, '\n')); err != nil {
+		return nil, fmt.Errorf("mux handshake: %w", err)
+	}
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 64*1024), MaxMuxFrameSize)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("mux handshake: %w", err)
+		}
+		return nil, errors.New("mux handshake: connection closed")
+	}
+	var resp MuxHandshakeResponse
+	if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+		return nil, fmt.Errorf("mux handshake: %w", err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("mux handshake: %s (serving %v)", resp.Error, resp.Providers)
+	}
+
+	c := &MuxClient{
+		conn:      conn,
+		provider:  resp.Provider,
+		providers: resp.Providers,
+		pending:   make(map[uint64]chan *MuxResponse),
+		done:      make(chan struct{}),
+	}
+	go c.readLoop(scanner)
+	return c, nil
+}
+
+// Provider returns the name of the provider the connection is routed to.
+func (c *MuxClient) Provider() string { return c.provider }
+
+// Providers returns every provider the remote process serves.
+func (c *MuxClient) Providers() []string { return append([]string(nil), c.providers...) }
+
+// Configure implements core.Provider.
+func (c *MuxClient) Configure(ctx context.Context, config map[string]interface{}) error {
+	input, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	_, err = c.roundTrip(ctx, &MuxRequest{Method: MuxMethodConfigure, Input: input})
+	return err
+}
+
+// Schema implements core.Provider.
+func (c *MuxClient) Schema() (*core.Schema, error) {
+	output, err := c.roundTrip(context.Background(), &MuxRequest{Method: MuxMethodSchema})
+	if err != nil {
+		return nil, err
+	}
+	var schema core.Schema
+	if err := json.Unmarshal(output, &schema); err != nil {
+		return nil, fmt.Errorf("invalid schema from %s: %w", c.provider, err)
+	}
+	return &schema, nil
+}
+
+// CallFunction implements core.Provider.
+func (c *MuxClient) CallFunction(ctx context.Context, function string, input []byte) ([]byte, error) {
+	return c.roundTrip(ctx, &MuxRequest{Method: MuxMethodCall, Function: function, Input: input})
+}
+
+// Close closes the connection; the remote provider keeps serving other clients.
+func (c *MuxClient) Close() error {
+	return c.conn.Close()
+}
+
+// roundTrip sends a request and waits for its response
+func (c *MuxClient) roundTrip(ctx context.Context, req *MuxRequest) ([]byte, error) {
+	if len(req.Input) > 0 && !json.Valid(req.Input) {
+		return nil, errors.New("mux: input must be JSON")
+	}
+	ch := make(chan *MuxResponse, 1)
+	c.mu.Lock()
+	if c.err != nil {
+		err := c.err
+		c.mu.Unlock()
+		return nil, err
+	}
+	c.nextID++
+	req.ID = c.nextID
+	c.pending[req.ID] = ch
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, req.ID)
+		c.mu.Unlock()
+	}()
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	c.writeMu.Lock()
+	_, err = c.conn.Write(append(data, '\n'))
+	c.writeMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case resp := <-ch:
+		if resp.Error != "" {
+			return nil, security.NewSecureError(resp.Error, fmt.Sprintf("%s: %s", c.provider, resp.Error), resp.ErrorCode)
+		}
+		return decodeMuxOutput(resp)
+	case <-c.done:
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return nil, c.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// readLoop delivers responses to their waiting requests until the connection closes
+func (c *MuxClient) readLoop(scanner *bufio.Scanner) {
+	for scanner.Scan() {
+		var resp MuxResponse
+		if json.Unmarshal(scanner.Bytes(), &resp) != nil {
+			continue
+		}
+		c.mu.Lock()
+		ch, ok := c.pending[resp.ID]
+		c.mu.Unlock()
+		if ok {
+			ch <- &resp
+		}
+	}
+	err := scanner.Err()
+	if err == nil {
+		err = errors.New("mux: connection closed")
+	}
+	c.mu.Lock()
+	c.err = err
+	c.mu.Unlock()
+	close(c.done)
+}
+
+// decodeMuxOutput returns the bytes a provider produced for a response
+func decodeMuxOutput(resp *MuxResponse) ([]byte, error) {
+	switch resp.OutputEncoding {
+	case "":
+		return resp.Output, nil
+	case MuxOutputEncodingBase64:
+		var encoded string
+		if err := json.Unmarshal(resp.Output, &encoded); err != nil {
+			return nil, fmt.Errorf("mux: invalid %s output: %w", resp.OutputEncoding, err)
+		}
+		return base64.StdEncoding.DecodeString(encoded)
+	default:
+		return nil, fmt.Errorf("mux: unsupported output encoding %q", resp.OutputEncoding)
+	}
+}
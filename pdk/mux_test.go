@@ -0,0 +1,268 @@
+package pdk
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+	"github.com/schemabounce/kolumn/sdk/helpers/security"
+)
+
+// downProvider fails every call
+type downProvider struct{ contractProvider }
+
+func (p *downProvider) CallFunction(ctx context.Context, function string, input []byte) ([]byte, error) {
+	return nil, errors.New("connection refused")
+}
+
+func startMux(t *testing.T, providers map[string]core.Provider) (*Mux, string) {
+	t.Helper()
+	mux := NewMux()
+	for name, provider := range providers {
+		if err := mux.Register(name, provider); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- mux.Serve(ctx, ln) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Serve: %v", err)
+		}
+	})
+	return mux, ln.Addr().String()
+}
+
+// TestMuxRoutesByProviderName validates connections reach the provider named in the handshake
+func TestMuxRoutesByProviderName(t *testing.T) {
+	acme, beta := newContractProvider(), newContractProvider()
+	mux, addr := startMux(t, map[string]core.Provider{"acme": acme, "beta": beta})
+	if err := mux.Register("acme", acme); err == nil {
+		t.Error("duplicate provider names should be rejected")
+	}
+
+	ctx := context.Background()
+	client, err := DialMux(ctx, "tcp", addr, "beta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	if client.Provider() != "beta" || strings.Join(client.Providers(), ",") != "acme,beta" {
+		t.Errorf("handshake = %s %v", client.Provider(), client.Providers())
+	}
+
+	if err := client.Configure(ctx, map[string]interface{}{"host": "db"}); err != nil {
+		t.Fatal(err)
+	}
+	if beta.configured["host"] != "db" || acme.configured != nil {
+		t.Errorf("configure was not routed to beta: acme %v, beta %v", acme.configured, beta.configured)
+	}
+	if schema, err := client.Schema(); err != nil || schema.Name != "acme" {
+		t.Errorf("Schema = %+v, %v", schema, err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			output, err := client.CallFunction(ctx, "Ping", []byte(`{}`))
+			var pong map[string]interface{}
+			if err != nil || json.Unmarshal(output, &pong) != nil || pong["status"] != "healthy" {
+				t.Errorf("Ping = %s, %v", output, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	_, err = client.CallFunction(ctx, "DropEverything", []byte(`{}`))
+	var secErr *security.SecureError
+	if !errors.As(err, &secErr) || secErr.Code != "INVALID_FUNCTION" {
+		t.Errorf("unknown function error = %v", err)
+	}
+
+	if _, err := DialMux(ctx, "tcp", addr, "gamma"); err == nil || !strings.Contains(err.Error(), `unknown provider "gamma"`) {
+		t.Errorf("unknown provider handshake = %v", err)
+	}
+}
+
+// TestMuxHandler validates the shared health and metrics endpoints
+func TestMuxHandler(t *testing.T) {
+	mux, addr := startMux(t, map[string]core.Provider{"acme": newContractProvider()})
+	client, err := DialMux(context.Background(), "tcp", addr, "acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	tracker, err := core.NewSLOTracker(core.SLObjective{Function: "*", SuccessRate: 0.99})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mux.TrackSLO("acme", tracker); err != nil {
+		t.Fatal(err)
+	}
+	if err := mux.TrackSLO("unknown", tracker); err == nil {
+		t.Error("expected tracking an unregistered provider to fail")
+	}
+	_, _ = client.CallFunction(context.Background(), "Ping", []byte(`{}`))
+
+	server := httptest.NewServer(mux.Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/healthz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var health MuxHealth
+	_ = json.NewDecoder(resp.Body).Decode(&health)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !health.Healthy || !health.Providers["acme"].Healthy {
+		t.Errorf("/healthz = %d %+v", resp.StatusCode, health)
+	}
+
+	resp, err = http.Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	for _, want := range []string{
+		`kolumn_provider_calls_total{provider="acme",function="Ping"} 1`,
+		`kolumn_provider_connections{provider="acme"} 1`,
+		`kolumn_provider_slo_success_ratio{provider="acme",function="Ping"} 1`,
+		`kolumn_provider_slo_burn_rate{provider="acme",function="Ping",window="short"} 0`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("/metrics lacks %s:\n%s", want, body)
+		}
+	}
+
+	if err := mux.Register("down", &downProvider{}); err != nil {
+		t.Fatal(err)
+	}
+	resp, err = http.Get(server.URL + "/healthz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("/healthz with a failing provider = %d", resp.StatusCode)
+	}
+}
+
+// rawProvider returns non-JSON output, as a provider returning a compressed payload does
+type rawProvider struct {
+	contractProvider
+	output []byte
+}
+
+func (p *rawProvider) CallFunction(ctx context.Context, function string, input []byte) ([]byte, error) {
+	return p.output, nil
+}
+
+// versionedProvider supports only a range of core versions
+type versionedProvider struct{ contractProvider }
+
+func (p *versionedProvider) Schema() (*core.Schema, error) {
+	schema, err := p.contractProvider.Schema()
+	if err == nil {
+		schema.MinCoreVersion, schema.MaxCoreVersion = "1.2.0", "1.4"
+	}
+	return schema, err
+}
+
+// TestMuxNonJSONOutput validates non-JSON output reaches the client byte for byte
+func TestMuxNonJSONOutput(t *testing.T) {
+	var gzipped bytes.Buffer
+	zw := gzip.NewWriter(&gzipped)
+	_, _ = zw.Write([]byte(`{"status":"healthy"}`))
+	_ = zw.Close()
+
+	for name, output := range map[string][]byte{
+		"gzip":    gzipped.Bytes(),
+		"invalid": {0xff, 0xfe, 'o', 'k'},
+		"text":    []byte("pong"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, addr := startMux(t, map[string]core.Provider{"acme": &rawProvider{output: output}})
+			client, err := DialMux(context.Background(), "tcp", addr, "acme")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer client.Close()
+			got, err := client.CallFunction(context.Background(), "Ping", []byte(`{}`))
+			if err != nil || !bytes.Equal(got, output) {
+				t.Errorf("CallFunction = %q, %v, want %q", got, err, output)
+			}
+		})
+	}
+
+	if _, err := decodeMuxOutput(&MuxResponse{Output: json.RawMessage(`"x"`), OutputEncoding: "zip"}); err == nil {
+		t.Error("expected an unknown output encoding to fail")
+	}
+}
+
+// TestMuxMetricsFunctionLabels validates only dispatchable function names become labels
+func TestMuxMetricsFunctionLabels(t *testing.T) {
+	mux, addr := startMux(t, map[string]core.Provider{"acme": newContractProvider()})
+	client, err := DialMux(context.Background(), "tcp", addr, "acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	for _, function := range []string{"Ping", "DropEverything", "Drop_1", "Drop_2"} {
+		_, _ = client.CallFunction(context.Background(), function, []byte(`{}`))
+	}
+
+	server := httptest.NewServer(mux.Handler())
+	defer server.Close()
+	resp, err := http.Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), `kolumn_provider_calls_total{provider="acme",function="Ping"} 1`) ||
+		!strings.Contains(string(body), `kolumn_provider_calls_total{provider="acme",function="other"} 3`) {
+		t.Errorf("/metrics lacks bounded function labels:\n%s", body)
+	}
+	if strings.Contains(string(body), "Drop") {
+		t.Errorf("/metrics labels a client-supplied function name:\n%s", body)
+	}
+}
+
+// TestMuxHandshakeCoreVersion validates handshakes from unsupported cores are rejected
+func TestMuxHandshakeCoreVersion(t *testing.T) {
+	_, addr := startMux(t, map[string]core.Provider{"acme": &versionedProvider{}})
+	for version, supported := range map[string]bool{"": true, "1.2.0": true, "1.4.7": true, "1.1.9": false, "1.5.0": false} {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		client, err := NewMuxClient(conn, MuxHandshake{Provider: "acme", CoreVersion: version})
+		if supported != (err == nil) {
+			t.Errorf("core %q handshake error = %v, supported %v", version, err, supported)
+		}
+		if client != nil {
+			client.Close()
+		} else {
+			conn.Close()
+		}
+	}
+}
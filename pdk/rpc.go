@@ -0,0 +1,223 @@
+package pdk
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+)
+
+// Environment variables Kolumn core sets when it launches a provider binary to serve it.
+const (
+	// EnvMagicCookie must hold MagicCookieValue. It tells a binary started without a
+	// contract flag that core launched it, rather than a user running it by hand.
+	EnvMagicCookie = "KOLUMN_PROVIDER_MAGIC_COOKIE"
+
+	// EnvServeAddress optionally chooses where the provider listens, as "unix:PATH" or
+	// "tcp:HOST:PORT". The default is a unix socket in a private temporary directory.
+	// TCP is not encrypted, so HOST must be a loopback address.
+	EnvServeAddress = "KOLUMN_PROVIDER_ADDRESS"
+)
+
+// MagicCookieValue is the value of EnvMagicCookie. It is not a secret; it only keeps
+// the binary from waiting for connections nobody will make.
+const MagicCookieValue = "d7c1a3f06b9e4e5c8f2a7b1d4c6e9f03"
+
+// DefaultShutdownTimeout bounds how long requests in flight may finish after the
+// provider is asked to stop.
+const DefaultShutdownTimeout = 30 * time.Second
+
+// ServeHandshake is the line a served provider writes to stdout once it listens. Core
+// reads it to learn where to connect; see ReadServeHandshake. Secret is generated for
+// each launch and must be presented when connecting, so only the process reading
+// stdout can use the provider.
+type ServeHandshake struct {
+	Protocol    string `json:"protocol"` // MuxProtocolVersion
+	CLIContract string `json:"cli_contract"`
+	Provider    string `json:"provider"`
+	Network     string `json:"network"`
+	Address     string `json:"address"`
+	Secret      string `json:"secret"`
+}
+
+// ServeRPC serves one provider to Kolumn core until ctx is cancelled or the process
+// receives SIGINT or SIGTERM. It handles the whole lifecycle:
+//
+//   - listens on EnvServeAddress, or a private unix socket
+//   - writes the ServeHandshake, with a secret for this launch, to stdout
+//   - serves the provider over the Mux protocol, named after its schema
+//   - on shutdown, stops taking requests, lets those in flight finish within
+//     opts.ShutdownTimeout, closes the provider and removes the socket
+//
+// Serve calls it when core launches the binary, so a provider's main only needs
+// pdk.Serve.
+func ServeRPC(ctx context.Context, provider core.Provider, opts ServeOptions, stdout io.Writer) (err error) {
+	name := "provider"
+	if schema, err := provider.Schema(); err == nil && schema != nil && schema.Name != "" {
+		name = schema.Name
+	}
+	secret, err := newServeSecret()
+	if err != nil {
+		return err
+	}
+	mux := NewMux()
+	mux.Secret = secret
+	if err := mux.Register(name, provider); err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := mux.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("failed to close provider: %w", closeErr)
+		}
+	}()
+
+	ln, cleanup, err := listen(os.Getenv(EnvServeAddress))
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	handshake, err := json.Marshal(ServeHandshake{
+		Protocol:    MuxProtocolVersion,
+		CLIContract: CLIContractVersion,
+		Provider:    name,
+		Network:     ln.Addr().Network(),
+		Address:     ln.Addr().String(),
+		Secret:      secret,
+	})
+	if err != nil {
+		ln.Close()
+		return err
+	}
+	if _, err := fmt.Fprintln(stdout, string(handshake)); err != nil {
+		ln.Close()
+		return fmt.Errorf("failed to write handshake: %w", err)
+	}
+
+	stopCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	serveCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- mux.Serve(serveCtx, ln) }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-stopCtx.Done():
+	}
+
+	timeout := opts.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = DefaultShutdownTimeout
+	}
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), timeout)
+	defer drainCancel()
+	drainErr := mux.Shutdown(drainCtx)
+	cancel()
+	if err := <-done; err != nil {
+		return err
+	}
+	if drainErr != nil {
+		return fmt.Errorf("requests still running after %s: %w", timeout, drainErr)
+	}
+	return nil
+}
+
+// listen opens the listener for address; cleanup removes a socket directory it created
+func listen(address string) (net.Listener, func(), error) {
+	if address != "" {
+		network, addr, ok := strings.Cut(address, ":")
+		if !ok || (network != "unix" && network != "tcp") {
+			return nil, nil, fmt.Errorf("invalid %s %q, expected unix:PATH or tcp:HOST:PORT", EnvServeAddress, address)
+		}
+		if network == "tcp" && !isLoopback(addr) {
+			return nil, nil, fmt.Errorf("invalid %s %q, tcp providers must listen on a loopback address", EnvServeAddress, address)
+		}
+		ln, err := net.Listen(network, addr)
+		if err != nil {
+			return nil, nil, err
+		}
+		return ln, func() {}, nil
+	}
+
+	dir, err := os.MkdirTemp("", "kolumn-provider-")
+	if err != nil {
+		return nil, nil, err
+	}
+	ln, err := net.Listen("unix", filepath.Join(dir, "provider.sock"))
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, nil, err
+	}
+	return ln, func() { os.RemoveAll(dir) }, nil
+}
+
+// isLoopback reports whether a HOST:PORT address only accepts local connections
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// newServeSecret returns a random secret for one launch
+func newServeSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate handshake secret: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// ReadServeHandshake reads the handshake a provider started by core writes to stdout.
+// Lines before it, such as log output, are skipped.
+func ReadServeHandshake(r io.Reader) (*ServeHandshake, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		var handshake ServeHandshake
+		if json.Unmarshal(scanner.Bytes(), &handshake) == nil && handshake.Address != "" {
+			if handshake.Protocol != MuxProtocolVersion {
+				return nil, fmt.Errorf("provider speaks mux protocol %q, expected %q", handshake.Protocol, MuxProtocolVersion)
+			}
+			return &handshake, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, errors.New("provider exited without a handshake")
+}
+
+// Dial connects to the provider that wrote the handshake, presenting its secret.
+func (h *ServeHandshake) Dial(ctx context.Context) (*MuxClient, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, h.Network, h.Address)
+	if err != nil {
+		return nil, err
+	}
+	client, err := NewMuxClient(conn, MuxHandshake{Provider: h.Provider, Protocol: MuxProtocolVersion, Secret: h.Secret})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return client, nil
+}
@@ -0,0 +1,176 @@
+package pdk
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+	"github.com/schemabounce/kolumn/sdk/helpers/security"
+)
+
+// lifecycleProvider records Close and can hold calls until released
+type lifecycleProvider struct {
+	contractProvider
+	started chan struct{}
+	release chan struct{}
+	closed  bool
+}
+
+func (p *lifecycleProvider) CallFunction(ctx context.Context, function string, input []byte) ([]byte, error) {
+	if function == "DiscoverResources" {
+		p.started <- struct{}{}
+		<-p.release
+		return []byte(`{"objects":[]}`), nil
+	}
+	return p.contractProvider.CallFunction(ctx, function, input)
+}
+
+func (p *lifecycleProvider) Close() error {
+	p.closed = true
+	return nil
+}
+
+func newLifecycleProvider() *lifecycleProvider {
+	return &lifecycleProvider{
+		contractProvider: *newContractProvider(),
+		started:          make(chan struct{}, 1),
+		release:          make(chan struct{}),
+	}
+}
+
+// TestServeRPCLifecycle validates the handshake, serving calls and closing the provider on shutdown
+func TestServeRPCLifecycle(t *testing.T) {
+	t.Setenv(EnvServeAddress, "tcp:127.0.0.1:0")
+	provider := newLifecycleProvider()
+
+	// without the cookie, a binary started by hand prints usage
+	if code, _ := run(t, ServeOptions{}); code != 2 {
+		t.Fatalf("without %s = %d, want 2", EnvMagicCookie, code)
+	}
+	t.Setenv(EnvMagicCookie, MagicCookieValue)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stdout, stdoutWriter := io.Pipe()
+	var stderr bytes.Buffer
+	exit := make(chan int, 1)
+	go func() {
+		exit <- Main(ctx, provider, ServeOptions{}, nil, stdoutWriter, &stderr)
+		stdoutWriter.Close()
+	}()
+
+	handshake, err := ReadServeHandshake(stdout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if handshake.Provider != "acme" || handshake.Network != "tcp" || handshake.CLIContract != CLIContractVersion {
+		t.Fatalf("handshake = %+v", handshake)
+	}
+	if len(handshake.Secret) != 64 {
+		t.Fatalf("handshake secret = %q", handshake.Secret)
+	}
+	if _, err := DialMux(context.Background(), handshake.Network, handshake.Address, "acme"); err == nil || !strings.Contains(err.Error(), "invalid secret") {
+		t.Fatalf("dial without the secret = %v", err)
+	}
+	client, err := handshake.Dial(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	if err := client.Configure(context.Background(), map[string]interface{}{"host": "db"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CallFunction(context.Background(), "Ping", []byte(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	cancel()
+	select {
+	case code := <-exit:
+		if code != 0 {
+			t.Fatalf("exit = %d: %s", code, stderr.String())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ServeRPC did not stop")
+	}
+	if !provider.closed || provider.configured["host"] != "db" {
+		t.Errorf("provider closed = %v, configured = %v", provider.closed, provider.configured)
+	}
+}
+
+// TestMuxShutdownDrains validates requests in flight finish while new ones are rejected
+func TestMuxShutdownDrains(t *testing.T) {
+	provider := newLifecycleProvider()
+	mux, addr := startMux(t, map[string]core.Provider{"acme": provider})
+	client, err := DialMux(context.Background(), "tcp", addr, "acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := client.CallFunction(context.Background(), "DiscoverResources", []byte(`{}`))
+		result <- err
+	}()
+	<-provider.started
+
+	drained := make(chan error, 1)
+	go func() { drained <- mux.Shutdown(context.Background()) }()
+	for {
+		_, err := client.CallFunction(context.Background(), "Ping", []byte(`{}`))
+		var secErr *security.SecureError
+		if errors.As(err, &secErr) && secErr.Code == "PROVIDER_SHUTTING_DOWN" {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case err := <-drained:
+		t.Fatalf("Shutdown returned with a request in flight: %v", err)
+	default:
+	}
+
+	close(provider.release)
+	if err := <-result; err != nil {
+		t.Errorf("request in flight: %v", err)
+	}
+	if err := <-drained; err != nil {
+		t.Errorf("Shutdown: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := mux.Shutdown(ctx); err != nil {
+		t.Errorf("Shutdown of an idle mux: %v", err)
+	}
+}
+
+// TestListenAddress validates tcp listeners are restricted to loopback addresses
+func TestListenAddress(t *testing.T) {
+	for address, valid := range map[string]bool{
+		"tcp:127.0.0.1:0":  true,
+		"tcp:[::1]:0":      true,
+		"tcp:localhost:0":  true,
+		"tcp::0":           false,
+		"tcp:0.0.0.0:0":    false,
+		"tcp:10.1.2.3:0":   false,
+		"tcp:db.example:0": false,
+		"udp:127.0.0.1:0":  false,
+	} {
+		ln, cleanup, err := listen(address)
+		rejected := err != nil && strings.Contains(err.Error(), "invalid "+EnvServeAddress)
+		if rejected == valid {
+			t.Errorf("listen(%q) = %v, valid %v", address, err, valid)
+		}
+		if err == nil {
+			ln.Close()
+			cleanup()
+		}
+	}
+}
@@ -0,0 +1,313 @@
+// Package pdk is the provider development kit entry point for provider binaries.
+//
+// Serve implements the versioned CLI contract every provider binary answers, so tools
+// such as kolumn-docs-gen and kolumn-upgrade can inspect a provider without loading it
+// (see the probe package):
+//
+//	--version       VersionInfo, including the contract version
+//	--schema        core.Schema
+//	--docs          core.ProviderDocumentation
+//	--capabilities  core.CapabilityDocs
+//	--build-info    core.BuildInfo of the binary, including its dependency SBOM
+//	--healthcheck   HealthcheckResult; takes --config FILE to configure the provider first
+//	--vet           core.ContractReport; exits 1 when the provider violates the contract
+//
+// --vet checks registered handlers only when the provider implements
+// core.ContractInspector. Every mode writes one JSON document to stdout and exits 0 on success, 1 on failure
+// and 2 on usage errors. The validate-config command (see core.RunValidateConfigCommand)
+// is available as well. When Kolumn core launches the binary without a contract flag,
+// Serve serves the provider over RPC with the whole lifecycle handled (see ServeRPC),
+// so a provider's main only needs:
+//
+//	func main() {
+//		pdk.Serve(NewProvider(), pdk.ServeOptions{})
+//	}
+//
+// Monorepos building several providers into one binary serve them together with Mux,
+// which routes each connection by the provider named in its handshake and shares one
+// health and metrics endpoint; DialMux connects to it.
+package pdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+)
+
+// CLIContractVersion is the version of the CLI contract implemented by Serve. It changes
+// only when a mode is removed or its output changes incompatibly.
+const CLIContractVersion = "1"
+
+// Contract flags.
+const (
+	FlagVersion      = "--version"
+	FlagSchema       = "--schema"
+	FlagDocs         = "--docs"
+	FlagCapabilities = "--capabilities"
+	FlagBuildInfo    = "--build-info"
+	FlagHealthcheck  = "--healthcheck"
+	FlagVet          = "--vet"
+)
+
+// ContractFlags lists the modes of the CLI contract in the order tools usually call them.
+var ContractFlags = []string{FlagVersion, FlagSchema, FlagDocs, FlagCapabilities, FlagBuildInfo, FlagHealthcheck, FlagVet}
+
+// VersionInfo is the output of --version.
+type VersionInfo struct {
+	Provider    string `json:"provider"`
+	Version     string `json:"version"`
+	SDKVersion  string `json:"sdk_version"`
+	APIVersion  string `json:"api_version"`
+	CLIContract string `json:"cli_contract"`
+	Protocol    string `json:"protocol,omitempty"`
+}
+
+// HealthcheckResult is the output of --healthcheck.
+type HealthcheckResult struct {
+	Healthy bool                    `json:"healthy"`
+	Error   string                  `json:"error,omitempty"`
+	Ping    map[string]interface{}  `json:"ping,omitempty"`   // Ping response without --config
+	Report  *core.ConfigCheckReport `json:"report,omitempty"` // full check with --config
+}
+
+// ServeOptions configures Serve.
+type ServeOptions struct {
+	// Run serves the provider to Kolumn core when the binary is started without a
+	// contract flag, replacing ServeRPC. Without it, such invocations are served with
+	// ServeRPC when core launched the binary (see EnvMagicCookie), and print usage and
+	// exit 2 otherwise.
+	Run func(ctx context.Context, provider core.Provider) error
+
+	// HealthcheckTimeout bounds --healthcheck; the default is 30 seconds.
+	HealthcheckTimeout time.Duration
+
+	// ShutdownTimeout bounds how long ServeRPC lets requests in flight finish once it
+	// is asked to stop; the default is DefaultShutdownTimeout.
+	ShutdownTimeout time.Duration
+}
+
+// Serve runs the provider binary with os.Args and exits with the resulting status.
+func Serve(provider core.Provider, opts ServeOptions) {
+	os.Exit(Main(context.Background(), provider, opts, os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// Main implements Serve for the given arguments and returns the exit code, so it can be
+// tested or embedded in a custom main.
+func Main(ctx context.Context, provider core.Provider, opts ServeOptions, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		run := opts.Run
+		if run == nil && os.Getenv(EnvMagicCookie) == MagicCookieValue {
+			run = func(ctx context.Context, provider core.Provider) error {
+				return ServeRPC(ctx, provider, opts, stdout)
+			}
+		}
+		if run == nil {
+			usage(stderr)
+			return 2
+		}
+		if err := run(ctx, provider); err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	var (
+		output interface{}
+		err    error
+	)
+	switch args[0] {
+	case core.ValidateConfigCommand:
+		return core.RunValidateConfigCommand(provider, args[1:], stdout)
+	case FlagVersion:
+		output, err = versionInfo(provider)
+	case FlagSchema:
+		output, err = provider.Schema()
+	case FlagDocs:
+		output, err = documentation(provider)
+	case FlagCapabilities:
+		output, err = capabilities(provider)
+	case FlagBuildInfo:
+		output = core.GetBuildInfo()
+	case FlagHealthcheck:
+		return healthcheck(ctx, provider, opts, args[1:], stdout, stderr)
+	case FlagVet:
+		return vet(provider, stdout, stderr)
+	default:
+		fmt.Fprintf(stderr, "Error: unknown argument %q\n\n", args[0])
+		usage(stderr)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	return writeJSON(output, stdout, stderr)
+}
+
+func versionInfo(provider core.Provider) (*VersionInfo, error) {
+	schema, err := provider.Schema()
+	if err != nil {
+		return nil, err
+	}
+	return &VersionInfo{
+		Provider:    schema.Name,
+		Version:     schema.Version,
+		SDKVersion:  core.SDKVersion,
+		APIVersion:  core.APIVersion,
+		CLIContract: CLIContractVersion,
+		Protocol:    schema.Protocol,
+	}, nil
+}
+
+// documentation returns the provider's documentation, or a minimal one built from its
+// schema when it does not implement core.DocumentedProvider
+func documentation(provider core.Provider) (*core.ProviderDocumentation, error) {
+	var docs *core.ProviderDocumentation
+	if documented, ok := provider.(core.DocumentedProvider); ok {
+		docs = documented.Documentation()
+	}
+	if docs == nil {
+		schema, err := provider.Schema()
+		if err != nil {
+			return nil, err
+		}
+		docs = &core.ProviderDocumentation{Name: schema.Name, Version: schema.Version, Description: schema.Description}
+	}
+	if governed, ok := provider.(core.GovernanceAwareProvider); ok && docs.Governance == nil {
+		docs.Governance = governed.GetGovernanceCapabilities()
+	}
+	return docs, nil
+}
+
+func capabilities(provider core.Provider) (*core.CapabilityDocs, error) {
+	schema, err := provider.Schema()
+	if err != nil {
+		return nil, err
+	}
+	var governance *core.GovernanceCapabilities
+	if governed, ok := provider.(core.GovernanceAwareProvider); ok {
+		governance = governed.GetGovernanceCapabilities()
+	}
+	return core.BuildCapabilityDocs(schema, governance), nil
+}
+
+// healthcheck pings the provider, or runs the full configuration check when --config is
+// given, and exits 1 when it is unhealthy
+func healthcheck(ctx context.Context, provider core.Provider, opts ServeOptions, args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet(FlagHealthcheck, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configFile := fs.String("config", "", "Path to provider configuration (.json, .kl or .hcl)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	timeout := opts.HealthcheckTimeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	result := &HealthcheckResult{}
+	if *configFile != "" {
+		config, err := core.LoadProviderConfigFile(*configFile)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 2
+		}
+		result.Report = core.CheckProviderConfig(ctx, provider, config)
+		result.Report.ConfigFile = *configFile
+		result.Healthy = result.Report.Success
+	} else {
+		result = ping(ctx, provider)
+	}
+
+	if code := writeJSON(result, stdout, stderr); code != 0 {
+		return code
+	}
+	if !result.Healthy {
+		return 1
+	}
+	return 0
+}
+
+// ping calls the provider's Ping function
+func ping(ctx context.Context, provider core.Provider) *HealthcheckResult {
+	result := &HealthcheckResult{}
+	output, err := provider.CallFunction(ctx, "Ping", []byte("{}"))
+	if err == nil {
+		err = json.Unmarshal(output, &result.Ping)
+	}
+	if err == nil {
+		if ok, isBool := result.Ping["success"].(bool); isBool && !ok {
+			err = errors.New("ping reported failure")
+		}
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	result.Healthy = err == nil
+	return result
+}
+
+// vet lints the provider's schema, and its handlers when it exposes them, and exits 1
+// when the contract is violated
+func vet(provider core.Provider, stdout, stderr io.Writer) int {
+	schema, err := provider.Schema()
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	var handlers map[string][]string
+	if inspector, ok := provider.(core.ContractInspector); ok {
+		handlers = inspector.HandlerOperations()
+	}
+	report := core.LintContract(schema, handlers)
+	if code := writeJSON(report, stdout, stderr); code != 0 {
+		return code
+	}
+	if report.HasErrors() {
+		return 1
+	}
+	return 0
+}
+
+func writeJSON(v interface{}, stdout, stderr io.Writer) int {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Fprintln(stdout, string(data))
+	return 0
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintf(w, `Kolumn provider (CLI contract %s)
+
+USAGE:
+    %s <mode>
+
+MODES:
+    --version                    Provider, SDK and contract versions
+    --schema                     Provider schema
+    --docs                       Provider documentation
+    --capabilities               Capability matrix
+    --build-info                 Build metadata, dependencies and provenance
+    --healthcheck [--config F]   Ping the provider, or configure it from F and run
+                                 the full configuration check
+    --vet                        Check the schema and handlers against the contract
+    %s --config F     Validate a provider configuration
+
+Every mode writes JSON to stdout. Without a mode, the provider is served to Kolumn
+core when core launched it.
+`, CLIContractVersion, os.Args[0], core.ValidateConfigCommand)
+}
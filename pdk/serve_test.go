@@ -0,0 +1,144 @@
+package pdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+)
+
+// contractProvider is a minimal provider answering Ping through the dispatcher
+type contractProvider struct {
+	dispatcher *core.UnifiedDispatcher
+	configured map[string]interface{}
+}
+
+func newContractProvider() *contractProvider {
+	return &contractProvider{dispatcher: core.NewUnifiedDispatcher(nil, nil)}
+}
+
+func (p *contractProvider) Configure(ctx context.Context, config map[string]interface{}) error {
+	p.configured = config
+	return nil
+}
+
+func (p *contractProvider) Schema() (*core.Schema, error) {
+	return &core.Schema{
+		Name:               "acme",
+		Version:            "1.2.0",
+		Protocol:           "1.0",
+		SupportedFunctions: []string{"CreateResource", "ReadResource", "Ping"},
+		ResourceTypes:      []core.ResourceTypeDefinition{{Name: "table", Operations: []string{"create", "read"}}},
+		ConfigSchema:       json.RawMessage(`{"type": "object"}`),
+	}, nil
+}
+
+func (p *contractProvider) CallFunction(ctx context.Context, function string, input []byte) ([]byte, error) {
+	return p.dispatcher.Dispatch(ctx, function, input)
+}
+
+func (p *contractProvider) Close() error { return nil }
+
+func (p *contractProvider) HandlerOperations() map[string][]string {
+	return p.dispatcher.HandlerOperations()
+}
+
+func run(t *testing.T, opts ServeOptions, args ...string) (int, []byte) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := Main(context.Background(), newContractProvider(), opts, args, &stdout, &stderr)
+	if code != 0 {
+		t.Logf("stderr: %s", stderr.String())
+	}
+	return code, stdout.Bytes()
+}
+
+// TestContractModes validates every contract mode emits its JSON document
+func TestContractModes(t *testing.T) {
+	code, out := run(t, ServeOptions{}, FlagVersion)
+	var info VersionInfo
+	if code != 0 || json.Unmarshal(out, &info) != nil {
+		t.Fatalf("--version = %d, %s", code, out)
+	}
+	if info.Provider != "acme" || info.Version != "1.2.0" || info.CLIContract != CLIContractVersion || info.SDKVersion != core.SDKVersion {
+		t.Errorf("unexpected version info %+v", info)
+	}
+
+	code, out = run(t, ServeOptions{}, FlagSchema)
+	var schema core.Schema
+	if code != 0 || json.Unmarshal(out, &schema) != nil || schema.ResourceTypes[0].Name != "table" {
+		t.Errorf("--schema = %d, %s", code, out)
+	}
+
+	code, out = run(t, ServeOptions{}, FlagDocs)
+	var docs core.ProviderDocumentation
+	if code != 0 || json.Unmarshal(out, &docs) != nil || docs.Name != "acme" {
+		t.Errorf("--docs = %d, %s", code, out)
+	}
+
+	code, out = run(t, ServeOptions{}, FlagCapabilities)
+	var caps core.CapabilityDocs
+	if code != 0 || json.Unmarshal(out, &caps) != nil || !caps.Features[core.CapabilityCreate] {
+		t.Errorf("--capabilities = %d, %s", code, out)
+	}
+
+	code, out = run(t, ServeOptions{}, FlagBuildInfo)
+	var build core.BuildInfo
+	if code != 0 || json.Unmarshal(out, &build) != nil || build.GoVersion == "" {
+		t.Errorf("--build-info = %d, %s", code, out)
+	}
+}
+
+// TestHealthcheck validates the ping and configured health checks
+func TestHealthcheck(t *testing.T) {
+	code, out := run(t, ServeOptions{}, FlagHealthcheck)
+	var result HealthcheckResult
+	if code != 0 || json.Unmarshal(out, &result) != nil || !result.Healthy || result.Ping["status"] != "healthy" {
+		t.Fatalf("--healthcheck = %d, %s", code, out)
+	}
+
+	config := filepath.Join(t.TempDir(), "provider.json")
+	if err := os.WriteFile(config, []byte(`{"resource_type": "table"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	code, out = run(t, ServeOptions{}, FlagHealthcheck, "--config", config)
+	result = HealthcheckResult{}
+	if code != 0 || json.Unmarshal(out, &result) != nil || result.Report == nil || !result.Healthy {
+		t.Errorf("--healthcheck --config = %d, %s", code, out)
+	}
+}
+
+// TestVet validates --vet reports contract violations and exits 1
+func TestVet(t *testing.T) {
+	code, out := run(t, ServeOptions{}, FlagVet)
+	var report core.ContractReport
+	if code != 1 || json.Unmarshal(out, &report) != nil {
+		t.Fatalf("--vet = %d, %s", code, out)
+	}
+	if !report.HandlersInspected || report.Findings[0].Rule != core.LintRuleMissingHandler {
+		t.Errorf("table has no registered handler: %+v", report)
+	}
+}
+
+// TestServeWithoutContractFlag validates usage errors and the serve callback
+func TestServeWithoutContractFlag(t *testing.T) {
+	if code, _ := run(t, ServeOptions{}); code != 2 {
+		t.Errorf("no arguments without Run = %d, want 2", code)
+	}
+	if code, _ := run(t, ServeOptions{}, "--bogus"); code != 2 {
+		t.Errorf("unknown argument = %d, want 2", code)
+	}
+
+	served := false
+	opts := ServeOptions{Run: func(ctx context.Context, provider core.Provider) error {
+		served = true
+		return nil
+	}}
+	if code, _ := run(t, opts); code != 0 || !served {
+		t.Errorf("Run was not called: %d", code)
+	}
+}
@@ -0,0 +1,67 @@
+package probe
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+)
+
+// PluginPackage is the import path of the Go plugin package, whose use to load
+// providers is deprecated.
+const PluginPackage = "plugin"
+
+// FindPluginLoading reports every Go file under root, tests included, that imports the
+// Go plugin package. Loading providers with plugin.Open ties the host to the exact
+// toolchain and dependency versions the provider was built with and is deprecated;
+// providers are served with pdk.Serve and inspected with a Prober instead. Findings
+// use core.LintRulePluginLoading with warning severity, so kolumn-sdk vet -source
+// reports them next to the contract findings.
+func FindPluginLoading(root string) ([]core.ContractFinding, error) {
+	var findings []core.ContractFinding
+	fset := token.NewFileSet()
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != root && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+		file, err := parser.ParseFile(fset, path, nil, parser.ImportsOnly)
+		if err != nil {
+			return err
+		}
+		for _, spec := range file.Imports {
+			if importPath, _ := strconv.Unquote(spec.Path.Value); importPath == PluginPackage {
+				pos := fset.Position(spec.Pos())
+				rel, relErr := filepath.Rel(root, pos.Filename)
+				if relErr != nil {
+					rel = pos.Filename
+				}
+				findings = append(findings, core.ContractFinding{
+					Rule:     core.LintRulePluginLoading,
+					Severity: core.LintSeverityWarning,
+					Message: fmt.Sprintf("%s:%d imports the Go plugin package; loading providers with plugin.Open is deprecated, "+
+						"serve them with pdk.Serve and query them with the probe package", filepath.ToSlash(rel), pos.Line),
+				})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", root, err)
+	}
+	return findings, nil
+}
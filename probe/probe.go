@@ -0,0 +1,277 @@
+// Package probe queries provider binaries over the pdk CLI contract (see pdk.Serve):
+// version, schema, documentation, capabilities, health and contract checks. It is what
+// kolumn-docs-gen, kolumn-upgrade and kolumn-sdk use to inspect a provider, and the
+// supported replacement for loading providers in-process with the Go plugin package,
+// which is deprecated (see FindPluginLoading).
+//
+//	p := probe.New("./kolumn-provider-postgres", probe.Options{Sandbox: &sandbox.Options{}})
+//	schema, err := p.Schema(ctx)
+//
+// Every query runs the binary once in one contract mode and decodes its JSON output.
+// Running providers are reached over RPC with pdk.DialMux instead.
+package probe
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+	"github.com/schemabounce/kolumn/sdk/helpers/sandbox"
+	"github.com/schemabounce/kolumn/sdk/pdk"
+)
+
+// DefaultTimeout bounds each run of the provider binary.
+const DefaultTimeout = time.Minute
+
+// ErrNoContract is returned when a binary does not answer --version with the output of
+// pdk.Serve, usually because it was built without the pdk.
+var ErrNoContract = errors.New("provider does not implement the CLI contract (serve it with pdk.Serve)")
+
+// Options configures how provider binaries are run.
+type Options struct {
+	// Sandbox runs the binary with least privilege; nil runs it directly.
+	Sandbox *sandbox.Options
+
+	// Env sets environment variables for the binary, such as core.FeatureFlagsEnvVar.
+	Env map[string]string
+
+	// Timeout bounds each run; the default is DefaultTimeout.
+	Timeout time.Duration
+}
+
+// Prober queries one provider binary.
+type Prober struct {
+	binary string
+	opts   Options
+}
+
+// New creates a prober for the provider binary at path.
+func New(binary string, opts Options) *Prober {
+	return &Prober{binary: binary, opts: opts}
+}
+
+// Binary returns the path of the provider binary.
+func (p *Prober) Binary() string {
+	return p.binary
+}
+
+// ExitError is returned when the binary exits with a non-zero status. Output holds
+// what it wrote to stdout; the contract writes reports there even on failure.
+type ExitError struct {
+	Mode     string
+	ExitCode int
+	Output   []byte
+	Stderr   string
+}
+
+func (e *ExitError) Error() string {
+	msg := fmt.Sprintf("provider %s exited with status %d", e.Mode, e.ExitCode)
+	if e.Stderr != "" {
+		msg += ": " + e.Stderr
+	}
+	return msg
+}
+
+// Run executes the binary with args and returns its stdout.
+func (p *Prober) Run(ctx context.Context, args ...string) ([]byte, error) {
+	timeout := p.opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if p.opts.Sandbox != nil {
+		opts := *p.opts.Sandbox
+		opts.SetEnv = mergeEnv(opts.SetEnv, p.opts.Env)
+		sandboxed, err := sandbox.Command(ctx, opts, p.binary, args...)
+		if err != nil {
+			return nil, err
+		}
+		defer sandboxed.Cleanup()
+		cmd = sandboxed.Cmd
+	} else {
+		cmd = exec.CommandContext(ctx, p.binary, args...)
+		if len(p.opts.Env) > 0 {
+			cmd.Env = os.Environ()
+			for _, name := range sortedKeys(p.opts.Env) {
+				cmd.Env = append(cmd.Env, name+"="+p.opts.Env[name])
+			}
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		mode := ""
+		if len(args) > 0 {
+			mode = args[0]
+		}
+		return nil, &ExitError{Mode: mode, ExitCode: exitErr.ExitCode(), Output: stdout.Bytes(), Stderr: strings.TrimSpace(stderr.String())}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to run %s: %w", p.binary, err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// Query runs one contract mode and decodes its JSON output into v.
+func (p *Prober) Query(ctx context.Context, v interface{}, mode string, args ...string) error {
+	output, err := p.Run(ctx, append([]string{mode}, args...)...)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(output, v); err != nil {
+		return fmt.Errorf("invalid %s output: %w", mode, err)
+	}
+	return nil
+}
+
+// Version returns the provider's version information and checks it implements the
+// CLI contract this SDK speaks.
+func (p *Prober) Version(ctx context.Context) (*pdk.VersionInfo, error) {
+	var info pdk.VersionInfo
+	if err := p.Query(ctx, &info, pdk.FlagVersion); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoContract, err)
+	}
+	if info.CLIContract == "" {
+		return nil, ErrNoContract
+	}
+	if info.CLIContract != pdk.CLIContractVersion {
+		return nil, fmt.Errorf("provider implements CLI contract %q, expected %q", info.CLIContract, pdk.CLIContractVersion)
+	}
+	return &info, nil
+}
+
+// Schema returns the provider's schema.
+func (p *Prober) Schema(ctx context.Context) (*core.Schema, error) {
+	var schema core.Schema
+	if err := p.Query(ctx, &schema, pdk.FlagSchema); err != nil {
+		return nil, fmt.Errorf("failed to get schema: %w", err)
+	}
+	return &schema, nil
+}
+
+// Docs returns the provider's documentation.
+func (p *Prober) Docs(ctx context.Context) (*core.ProviderDocumentation, error) {
+	var docs core.ProviderDocumentation
+	if err := p.Query(ctx, &docs, pdk.FlagDocs); err != nil {
+		return nil, fmt.Errorf("failed to get documentation: %w", err)
+	}
+	return &docs, nil
+}
+
+// Capabilities returns the provider's capability matrix.
+func (p *Prober) Capabilities(ctx context.Context) (*core.CapabilityDocs, error) {
+	var capabilities core.CapabilityDocs
+	if err := p.Query(ctx, &capabilities, pdk.FlagCapabilities); err != nil {
+		return nil, fmt.Errorf("failed to get capabilities: %w", err)
+	}
+	return &capabilities, nil
+}
+
+// Documentation checks the contract version and returns the schema and documentation,
+// with the governance capabilities filled in from --capabilities when the documentation
+// lacks them. This is everything kolumn-docs-gen needs from a provider.
+func (p *Prober) Documentation(ctx context.Context) (*core.Schema, *core.ProviderDocumentation, error) {
+	if _, err := p.Version(ctx); err != nil {
+		return nil, nil, err
+	}
+	schema, err := p.Schema(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	docs, err := p.Docs(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if docs.Governance == nil {
+		capabilities, err := p.Capabilities(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		docs.Governance = capabilities.Governance
+	}
+	return schema, docs, nil
+}
+
+// Healthcheck pings the provider, or checks configFile when it is not empty. An
+// unhealthy provider is a result, not an error.
+func (p *Prober) Healthcheck(ctx context.Context, configFile string) (*pdk.HealthcheckResult, error) {
+	var args []string
+	if configFile != "" {
+		args = []string{"--config", configFile}
+	}
+	var result pdk.HealthcheckResult
+	err := p.Query(ctx, &result, pdk.FlagHealthcheck, args...)
+	if err := reportOnExit1(err, &result, pdk.FlagHealthcheck); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Vet lints the provider against the contract. Binaries built before --vet existed are
+// linted from their schema, without the handler checks. A provider violating the
+// contract is a report with errors, not an error.
+func (p *Prober) Vet(ctx context.Context) (*core.ContractReport, error) {
+	var report core.ContractReport
+	err := p.Query(ctx, &report, pdk.FlagVet)
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode == 2 {
+		schema, err := p.Schema(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return core.LintContract(schema, nil), nil
+	}
+	if err := reportOnExit1(err, &report, pdk.FlagVet); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// reportOnExit1 decodes the report a contract mode writes before exiting with status 1
+func reportOnExit1(err error, v interface{}, mode string) error {
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode != 1 || len(exitErr.Output) == 0 {
+		return err
+	}
+	if err := json.Unmarshal(exitErr.Output, v); err != nil {
+		return fmt.Errorf("invalid %s output: %w", mode, err)
+	}
+	return nil
+}
+
+func mergeEnv(base, extra map[string]string) map[string]string {
+	if len(extra) == 0 {
+		return base
+	}
+	merged := make(map[string]string, len(base)+len(extra))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range extra {
+		merged[k] = v
+	}
+	return merged
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
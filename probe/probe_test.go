@@ -0,0 +1,133 @@
+package probe
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+	"github.com/schemabounce/kolumn/sdk/pdk"
+)
+
+// providerEnv makes the test binary serve testProvider instead of running the tests,
+// so the prober has a real binary implementing the CLI contract to run
+const providerEnv = "PROBE_TEST_PROVIDER"
+
+func TestMain(m *testing.M) {
+	if mode := os.Getenv(providerEnv); mode != "" {
+		provider := &testProvider{unhealthy: mode == "unhealthy"}
+		os.Exit(pdk.Main(context.Background(), provider, pdk.ServeOptions{}, os.Args[1:], os.Stdout, os.Stderr))
+	}
+	os.Exit(m.Run())
+}
+
+type testProvider struct {
+	unhealthy bool
+}
+
+func (p *testProvider) Configure(ctx context.Context, config map[string]interface{}) error {
+	return nil
+}
+
+func (p *testProvider) Schema() (*core.Schema, error) {
+	return &core.Schema{
+		Name:               "acme",
+		Version:            "1.2.0",
+		SupportedFunctions: []string{"CreateResource", "Ping"},
+		ResourceTypes:      []core.ResourceTypeDefinition{{Name: "table", Operations: []string{"create"}}},
+		ConfigSchema:       json.RawMessage(`{"type":"object"}`),
+	}, nil
+}
+
+func (p *testProvider) CallFunction(ctx context.Context, function string, input []byte) ([]byte, error) {
+	if p.unhealthy {
+		return nil, errors.New("database unreachable")
+	}
+	return []byte(`{"success":true,"flags":"` + os.Getenv(core.FeatureFlagsEnvVar) + `"}`), nil
+}
+
+func (p *testProvider) Close() error { return nil }
+
+func testProber(t *testing.T, mode string) *Prober {
+	binary, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(binary, Options{Env: map[string]string{providerEnv: mode, core.FeatureFlagsEnvVar: "*"}})
+}
+
+// TestProberContract validates the version check, schema, documentation and vet queries
+func TestProberContract(t *testing.T) {
+	ctx := context.Background()
+	p := testProber(t, "healthy")
+
+	info, err := p.Version(ctx)
+	if err != nil || info.Provider != "acme" || info.CLIContract != pdk.CLIContractVersion {
+		t.Fatalf("Version = %+v, %v", info, err)
+	}
+	schema, docs, err := p.Documentation(ctx)
+	if err != nil || schema.Name != "acme" || docs == nil {
+		t.Fatalf("Documentation = %+v, %+v, %v", schema, docs, err)
+	}
+	report, err := p.Vet(ctx)
+	if err != nil || report.Provider != "acme" {
+		t.Fatalf("Vet = %+v, %v", report, err)
+	}
+
+	health, err := p.Healthcheck(ctx, "")
+	if err != nil || !health.Healthy || health.Ping["flags"] != "*" {
+		t.Fatalf("Healthcheck = %+v, %v", health, err)
+	}
+	health, err = testProber(t, "unhealthy").Healthcheck(ctx, "")
+	if err != nil || health.Healthy || !strings.Contains(health.Error, "database unreachable") {
+		t.Fatalf("unhealthy Healthcheck = %+v, %v", health, err)
+	}
+
+	var exitErr *ExitError
+	if _, err := p.Run(ctx, "--no-such-mode"); !errors.As(err, &exitErr) || exitErr.ExitCode != 2 {
+		t.Fatalf("unknown mode error = %v", err)
+	}
+}
+
+// TestProberNoContract validates binaries without the CLI contract are reported as such
+func TestProberNoContract(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "legacy-provider")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho not json\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(script, Options{}).Version(context.Background()); !errors.Is(err, ErrNoContract) {
+		t.Fatalf("Version error = %v, want ErrNoContract", err)
+	}
+}
+
+// TestFindPluginLoading validates imports of the plugin package are reported with their position
+func TestFindPluginLoading(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"loader/load.go":   "package loader\n\nimport (\n\t\"fmt\"\n\t\"plugin\"\n)\n\nvar _ = plugin.Open\nvar _ = fmt.Sprint\n",
+		"main.go":          "package main\n\nimport \"github.com/example/plugin\"\n\nvar _ = plugin.X\n",
+		"vendor/x/x.go":    "package x\n\nimport \"plugin\"\n\nvar _ = plugin.Open\n",
+		"loader/README.md": "import \"plugin\"",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	findings, err := FindPluginLoading(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 1 || findings[0].Rule != core.LintRulePluginLoading || !strings.HasPrefix(findings[0].Message, "loader/load.go:5 ") {
+		t.Fatalf("findings = %+v", findings)
+	}
+}
@@ -0,0 +1,252 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+	"github.com/schemabounce/kolumn/sdk/runtimehelpers/telemetry"
+)
+
+type recordingLogger struct {
+	mu      sync.Mutex
+	entries []telemetry.Fields
+}
+
+func (r *recordingLogger) record(fields telemetry.Fields) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, fields)
+}
+
+func (r *recordingLogger) Debug(ctx context.Context, msg string, fields telemetry.Fields) {
+	r.record(fields)
+}
+func (r *recordingLogger) Info(ctx context.Context, msg string, fields telemetry.Fields) {
+	r.record(fields)
+}
+func (r *recordingLogger) Warn(ctx context.Context, msg string, fields telemetry.Fields) {
+	r.record(fields)
+}
+func (r *recordingLogger) Error(ctx context.Context, msg string, err error, fields telemetry.Fields) {
+	r.record(fields)
+}
+func (r *recordingLogger) WithComponent(string) telemetry.Logger { return r }
+
+type closingClient struct{ closed bool }
+
+func (c *closingClient) Close() error {
+	c.closed = true
+	return nil
+}
+
+func fastRetry(attempts int) RetryPolicy {
+	return RetryPolicy{Attempts: attempts, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+}
+
+func TestFactoryRegisterAndGet(t *testing.T) {
+	factory := NewFactory(Instrumentation{Retry: fastRetry(2)})
+	first := &closingClient{}
+	if err := Register(factory, "kafka", Instrumentation{}, func(inst *Instrumentation) (*closingClient, error) {
+		if inst.Name != "kafka" || inst.Retry.Attempts != 2 {
+			t.Errorf("instrumentation not merged with defaults: %+v", inst)
+		}
+		return first, nil
+	}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	if _, err := Get[*closingClient](context.Background(), "kafka"); err == nil {
+		t.Error("Get without an attached factory should fail")
+	}
+	ctx := factory.Attach(context.Background())
+	got, err := Get[*closingClient](ctx, "kafka")
+	if err != nil || got != first {
+		t.Fatalf("Get = %v, %v", got, err)
+	}
+	if _, err := Get[*http.Client](ctx, "kafka"); err == nil {
+		t.Error("Get with the wrong type should fail")
+	}
+	if _, err := Get[*closingClient](ctx, "missing"); err == nil {
+		t.Error("Get of an unregistered client should fail")
+	}
+
+	// reconfiguring replaces and closes the previous client
+	second := &closingClient{}
+	_ = Register(factory, "kafka", Instrumentation{}, func(*Instrumentation) (*closingClient, error) { return second, nil })
+	if !first.closed {
+		t.Error("replaced client was not closed")
+	}
+	if err := factory.Close(); err != nil || !second.closed {
+		t.Errorf("Close = %v, closed = %v", err, second.closed)
+	}
+	if len(factory.Names()) != 0 {
+		t.Error("Close should forget clients")
+	}
+}
+
+func TestCallRetriesAndObserves(t *testing.T) {
+	var calls []CallInfo
+	inst := NewInstrumentation(Instrumentation{
+		Name:    "warehouse",
+		Retry:   fastRetry(3),
+		Metrics: MetricsFunc(func(ctx context.Context, call CallInfo) { calls = append(calls, call) }),
+	})
+
+	attempts := 0
+	value, err := Call(context.Background(), inst, "query", func(ctx context.Context) (string, error) {
+		if attempts++; attempts < 3 {
+			return "", &RetryableError{Err: errors.New("warehouse busy")}
+		}
+		return "ok", nil
+	})
+	if err != nil || value != "ok" {
+		t.Fatalf("Call = %q, %v", value, err)
+	}
+	if len(calls) != 3 || calls[2].Attempt != 3 || calls[0].Err == nil || calls[2].Err != nil {
+		t.Errorf("unexpected observations: %+v", calls)
+	}
+
+	permanent := errors.New("syntax error")
+	attempts = 0
+	err = inst.Do(context.Background(), "query", func(ctx context.Context) error {
+		attempts++
+		return permanent
+	})
+	if !errors.Is(err, permanent) || attempts != 1 {
+		t.Errorf("permanent errors should not be retried: %v after %d attempts", err, attempts)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	inst := NewInstrumentation(Instrumentation{RateLimit: 50, Burst: 1})
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := inst.Do(context.Background(), "produce", func(context.Context) error { return nil }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("3 calls at 50/s with burst 1 took %v", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := inst.Do(ctx, "produce", func(context.Context) error { return nil }); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected cancellation while waiting for the limiter, got %v", err)
+	}
+}
+
+func TestWrapHTTPRetriesAndRedacts(t *testing.T) {
+	var hits int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits++; hits == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Set-Cookie", "session=abc")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	logger := &recordingLogger{}
+	inst := NewInstrumentation(Instrumentation{Name: "api", Retry: fastRetry(3), Logger: logger, SensitiveHeaders: []string{"X-Tenant"}})
+	client := inst.WrapHTTP(server.Client())
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/v1/warehouses?api_key=secret&page=2", nil)
+	req.Header.Set("Authorization", "Bearer abc")
+	req.Header.Set("X-Tenant", "acme")
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || hits != 2 {
+		t.Errorf("status %d after %d hits, want 200 after 2", resp.StatusCode, hits)
+	}
+
+	for _, fields := range logger.entries {
+		for _, key := range []string{"request_headers", "response_headers"} {
+			headers, ok := fields[key].(http.Header)
+			if !ok {
+				continue
+			}
+			for _, name := range []string{"Authorization", "X-Tenant", "Set-Cookie"} {
+				if v := headers.Get(name); v != "" && v != redacted {
+					t.Errorf("%s %s logged unredacted: %q", key, name, v)
+				}
+			}
+			if key == "request_headers" && headers.Get("Accept") != "application/json" {
+				t.Error("non-sensitive headers should be logged")
+			}
+		}
+		if url, ok := fields["url"].(string); ok && strings.Contains(url, "secret") {
+			t.Errorf("url logged with credentials: %s", url)
+		}
+	}
+
+	// non-idempotent requests are not retried
+	hits = 0
+	resp, err = client.Post(server.URL, "application/json", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || hits != 1 {
+		t.Errorf("POST got %d after %d hits, want 503 after 1", resp.StatusCode, hits)
+	}
+}
+
+// httpRegistry makes one request through client for every handler call.
+type httpRegistry struct {
+	client *http.Client
+	url    string
+}
+
+func (r *httpRegistry) GetObjectTypes() map[string]*core.ObjectType { return nil }
+
+func (r *httpRegistry) CallHandler(ctx context.Context, objectType, method string, input []byte) ([]byte, error) {
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, r.url, nil)
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return []byte(`{"success":true}`), nil
+}
+
+func TestWrapHTTPRecordsResponseMetadata(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req-1")
+		w.Header().Set("X-Warehouse-Query", "q-9")
+		w.Header().Set("X-RateLimit-Remaining", "17")
+		w.Header().Set("X-RateLimit-Reset", "30")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	inst := NewInstrumentation(Instrumentation{Name: "api", RequestIDHeaders: []string{"X-Warehouse-Query"}})
+	d := core.NewUnifiedDispatcher(&httpRegistry{client: inst.WrapHTTP(server.Client()), url: server.URL}, nil)
+	output, err := d.Dispatch(context.Background(), "ReadResource", []byte(`{"resource_type":"table","name":"users"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	metadata, ok := core.ResponseMetadataFromOutput(output)
+	if !ok {
+		t.Fatalf("no response metadata in %s", output)
+	}
+	if got := metadata.BackendRequestIDs; len(got) != 2 || got[0] != "req-1" || got[1] != "q-9" {
+		t.Errorf("backend request IDs = %v", got)
+	}
+	if metadata.RateLimitRemaining == nil || *metadata.RateLimitRemaining != 17 || metadata.RateLimitReset == nil {
+		t.Errorf("rate limit = %v, reset %v", metadata.RateLimitRemaining, metadata.RateLimitReset)
+	}
+}
@@ -0,0 +1,164 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// Factory holds the backend clients a provider builds in Configure. Handlers retrieve
+// them from the context with Get once the provider attaches the factory.
+type Factory struct {
+	defaults *Instrumentation
+
+	mu      sync.RWMutex
+	clients map[string]registered
+}
+
+type registered struct {
+	client any
+	inst   *Instrumentation
+}
+
+// NewFactory creates a factory whose clients inherit unset instrumentation from
+// defaults.
+func NewFactory(defaults Instrumentation) *Factory {
+	return &Factory{defaults: defaults.merge(nil), clients: make(map[string]registered)}
+}
+
+// Register builds a client with instrumentation merged from inst and the factory
+// defaults and stores it under name, replacing and closing any previous client with that
+// name, e.g. when Configure runs again.
+func Register[C any](f *Factory, name string, inst Instrumentation, build func(*Instrumentation) (C, error)) error {
+	if name == "" {
+		return errors.New("clients: client name is required")
+	}
+	inst.Name = name
+	merged := inst.merge(f.defaults)
+	client, err := build(merged)
+	if err != nil {
+		return fmt.Errorf("clients: build %s: %w", name, err)
+	}
+
+	f.mu.Lock()
+	previous, replaced := f.clients[name]
+	f.clients[name] = registered{client: client, inst: merged}
+	f.mu.Unlock()
+
+	if replaced {
+		closeClient(previous.client)
+	}
+	return nil
+}
+
+// Instrumentation returns the instrumentation of a registered client.
+func (f *Factory) Instrumentation(name string) (*Instrumentation, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	r, ok := f.clients[name]
+	return r.inst, ok
+}
+
+// Names returns the registered client names, sorted.
+func (f *Factory) Names() []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	names := make([]string, 0, len(f.clients))
+	for name := range f.clients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Close closes every registered client implementing io.Closer and forgets all clients.
+// Providers call it from Close.
+func (f *Factory) Close() error {
+	f.mu.Lock()
+	clients := f.clients
+	f.clients = make(map[string]registered)
+	f.mu.Unlock()
+
+	var errs []error
+	for name, r := range clients {
+		if err := closeClient(r.client); err != nil {
+			errs = append(errs, fmt.Errorf("clients: close %s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func closeClient(client any) error {
+	if closer, ok := client.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// factoryKey is the context key holding the Factory
+type factoryKey struct{}
+
+// Attach returns a context carrying the factory. Providers call it in CallFunction
+// before dispatching so handlers can use Get.
+func (f *Factory) Attach(ctx context.Context) context.Context {
+	return context.WithValue(ctx, factoryKey{}, f)
+}
+
+// FromContext returns the factory attached to ctx.
+func FromContext(ctx context.Context) (*Factory, bool) {
+	f, ok := ctx.Value(factoryKey{}).(*Factory)
+	return f, ok
+}
+
+// Get returns the client registered under name in the factory attached to ctx.
+func Get[C any](ctx context.Context, name string) (C, error) {
+	var zero C
+	f, ok := FromContext(ctx)
+	if !ok {
+		return zero, errors.New("clients: no client factory in context; attach it in CallFunction")
+	}
+	f.mu.RLock()
+	r, ok := f.clients[name]
+	f.mu.RUnlock()
+	if !ok {
+		return zero, fmt.Errorf("clients: no client registered as %q", name)
+	}
+	client, ok := r.client.(C)
+	if !ok {
+		return zero, fmt.Errorf("clients: client %q is %T, not %T", name, r.client, zero)
+	}
+	return client, nil
+}
+
+// Client pairs a backend client without built-in instrumentation, such as a *sql.DB or
+// a Kafka producer, with the instrumentation its calls go through.
+type Client[C any] struct {
+	raw  C
+	inst *Instrumentation
+}
+
+// Wrap pairs client with inst. Register build functions use it for clients WrapHTTP does
+// not cover.
+func Wrap[C any](client C, inst *Instrumentation) *Client[C] {
+	return &Client[C]{raw: client, inst: inst}
+}
+
+// Raw returns the wrapped client for calls that need no instrumentation.
+func (c *Client[C]) Raw() C {
+	return c.raw
+}
+
+// Do runs fn with the wrapped client as an instrumented operation.
+func (c *Client[C]) Do(ctx context.Context, operation string, fn func(context.Context, C) error) error {
+	return c.inst.Do(ctx, operation, func(ctx context.Context) error {
+		return fn(ctx, c.raw)
+	})
+}
+
+// Close closes the wrapped client when it implements io.Closer.
+func (c *Client[C]) Close() error {
+	return closeClient(c.raw)
+}
@@ -0,0 +1,233 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+	"github.com/schemabounce/kolumn/sdk/runtimehelpers/telemetry"
+)
+
+// DefaultSensitiveHeaders are always redacted from logged HTTP headers. Headers whose
+// names contain "token", "secret", "password" or "api-key" are redacted as well.
+var DefaultSensitiveHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Api-Key",
+	"X-Auth-Token",
+}
+
+// DefaultRequestIDHeaders are the response headers recorded as backend request IDs.
+var DefaultRequestIDHeaders = []string{
+	"X-Request-Id",
+	"Request-Id",
+	"X-Amz-Request-Id",
+	"X-Ms-Request-Id",
+	"X-Goog-Request-Id",
+	"X-Correlation-Id",
+}
+
+// rateLimitRemainingHeaders and rateLimitResetHeaders report a backend's remaining
+// request budget and when it refills, in the common spellings.
+var (
+	rateLimitRemainingHeaders = []string{"X-RateLimit-Remaining", "RateLimit-Remaining"}
+	rateLimitResetHeaders     = []string{"X-RateLimit-Reset", "RateLimit-Reset"}
+)
+
+var sensitiveHeaderTokens = []string{"token", "secret", "password", "api-key", "apikey"}
+
+const redacted = "<redacted>"
+
+// StatusError is the error of an attempt that got a retryable HTTP status.
+type StatusError struct {
+	Method     string
+	URL        string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s %s: %d %s", e.Method, e.URL, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// RetryableStatus reports whether an HTTP status asks the caller to try again later.
+func RetryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// RedactHeaders returns a copy of h with sensitive values replaced. extra names more
+// headers to redact, matched case-insensitively.
+func RedactHeaders(h http.Header, extra ...string) http.Header {
+	out := make(http.Header, len(h))
+	for name, values := range h {
+		if sensitiveHeader(name, extra) {
+			out[name] = []string{redacted}
+			continue
+		}
+		out[name] = append([]string(nil), values...)
+	}
+	return out
+}
+
+func sensitiveHeader(name string, extra []string) bool {
+	for _, lists := range [][]string{DefaultSensitiveHeaders, extra} {
+		for _, sensitive := range lists {
+			if strings.EqualFold(name, sensitive) {
+				return true
+			}
+		}
+	}
+	lower := strings.ToLower(name)
+	for _, token := range sensitiveHeaderTokens {
+		if strings.Contains(lower, token) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactURL drops credentials and sensitive query values from a URL for logging.
+func redactURL(u *url.URL) string {
+	c := *u
+	if c.User != nil {
+		c.User = url.User(redacted)
+	}
+	if c.RawQuery != "" {
+		query := c.Query()
+		for key := range query {
+			if sensitiveHeader(key, nil) || strings.Contains(strings.ToLower(key), "key") {
+				query[key] = []string{redacted}
+			}
+		}
+		c.RawQuery = query.Encode()
+	}
+	return c.String()
+}
+
+// WrapHTTP returns a copy of client whose transport runs every request through the
+// instrumentation. Only idempotent requests (GET, HEAD, OPTIONS, PUT, DELETE, or any
+// request with an Idempotency-Key header) with a replayable body are retried; a
+// retryable status on the last attempt is returned as the response.
+func (i *Instrumentation) WrapHTTP(client *http.Client) *http.Client {
+	if client == nil {
+		client = &http.Client{}
+	}
+	wrapped := *client
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	wrapped.Transport = &transport{base: base, inst: i}
+	return &wrapped
+}
+
+type transport struct {
+	base http.RoundTripper
+	inst *Instrumentation
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	inst := t.inst
+	if !retryableRequest(req) {
+		single := *inst
+		single.Retry.Attempts = 1
+		inst = &single
+	}
+
+	attempt := 0
+	return Call(req.Context(), inst, req.Method, func(ctx context.Context) (*http.Response, error) {
+		attempt++
+		attemptReq := req.WithContext(ctx)
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptReq.Body = body
+		}
+
+		resp, err := t.base.RoundTrip(attemptReq)
+		fields := telemetry.Fields{
+			"client":          inst.Name,
+			"method":          req.Method,
+			"url":             redactURL(req.URL),
+			"attempt":         attempt,
+			"request_headers": RedactHeaders(req.Header, inst.SensitiveHeaders...),
+		}
+		if err != nil {
+			inst.Logger.Debug(ctx, "clients.http", telemetry.MergeFields(fields, telemetry.Fields{"error": err.Error()}))
+			return nil, err
+		}
+		inst.Logger.Debug(ctx, "clients.http", telemetry.MergeFields(fields, telemetry.Fields{
+			"status":           resp.StatusCode,
+			"response_headers": RedactHeaders(resp.Header, inst.SensitiveHeaders...),
+		}))
+		recordResponseMetadata(ctx, resp.Header, inst.RequestIDHeaders)
+
+		if RetryableStatus(resp.StatusCode) && attempt < inst.Retry.Attempts {
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+			return nil, &RetryableError{Err: &StatusError{Method: req.Method, URL: redactURL(req.URL), StatusCode: resp.StatusCode}}
+		}
+		return resp, nil
+	})
+}
+
+func retryableRequest(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != ""
+}
+
+// recordResponseMetadata reports backend request IDs and rate-limit headers of a
+// response as metadata of the operation the request was made for.
+func recordResponseMetadata(ctx context.Context, h http.Header, extra []string) {
+	for _, names := range [][]string{DefaultRequestIDHeaders, extra} {
+		for _, name := range names {
+			if id := h.Get(name); id != "" {
+				core.RecordBackendRequestID(ctx, id)
+			}
+		}
+	}
+
+	remaining, ok := headerInt(h, rateLimitRemainingHeaders)
+	if !ok {
+		return
+	}
+	var reset time.Time
+	if value, ok := headerInt(h, rateLimitResetHeaders); ok {
+		// Large values are Unix timestamps, small ones seconds until the reset
+		if value > 1_000_000_000 {
+			reset = time.Unix(int64(value), 0)
+		} else {
+			reset = time.Now().Add(time.Duration(value) * time.Second)
+		}
+	}
+	core.RecordRateLimit(ctx, remaining, reset)
+}
+
+func headerInt(h http.Header, names []string) (int, bool) {
+	for _, name := range names {
+		if value, err := strconv.Atoi(strings.TrimSpace(h.Get(name))); err == nil {
+			return value, true
+		}
+	}
+	return 0, false
+}
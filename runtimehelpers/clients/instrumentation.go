@@ -0,0 +1,305 @@
+// Package clients builds backend clients (HTTP, SQL, Kafka, cloud SDKs) once in
+// Configure and hands them to handlers through the context, with shared
+// instrumentation: retries, rate limiting, metrics, tracing and redaction of sensitive
+// headers.
+//
+//	func (p *Provider) Configure(ctx context.Context, config map[string]interface{}) error {
+//		p.clients = clients.NewFactory(clients.Instrumentation{Logger: telemetry.NewLogger("snowflake")})
+//		return clients.Register(p.clients, "api", clients.Instrumentation{RateLimit: 10},
+//			func(inst *clients.Instrumentation) (*http.Client, error) {
+//				return inst.WrapHTTP(&http.Client{Timeout: 30 * time.Second}), nil
+//			})
+//	}
+//
+//	func (p *Provider) CallFunction(ctx context.Context, function string, input []byte) ([]byte, error) {
+//		return p.dispatcher.Dispatch(p.clients.Attach(ctx), function, input)
+//	}
+//
+//	// in a handler
+//	api, err := clients.Get[*http.Client](ctx, "api")
+package clients
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+	"github.com/schemabounce/kolumn/sdk/runtimehelpers/telemetry"
+)
+
+// RetryPolicy captures retry behavior for transient errors.
+type RetryPolicy struct {
+	Attempts    int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	ShouldRetry func(error) bool
+}
+
+// CallInfo describes one attempt of a backend call, as reported to Metrics.
+type CallInfo struct {
+	Client    string
+	Operation string
+	Attempt   int
+	Duration  time.Duration
+	Err       error
+}
+
+// Metrics receives an observation for every attempt of every call.
+type Metrics interface {
+	ObserveCall(ctx context.Context, call CallInfo)
+}
+
+// MetricsFunc adapts a function into Metrics.
+type MetricsFunc func(ctx context.Context, call CallInfo)
+
+// ObserveCall implements Metrics.
+func (f MetricsFunc) ObserveCall(ctx context.Context, call CallInfo) {
+	f(ctx, call)
+}
+
+// Tracer starts a span around each call. The returned function ends the span with the
+// call's final error.
+type Tracer interface {
+	StartSpan(ctx context.Context, name string, attributes map[string]string) (context.Context, func(error))
+}
+
+// Instrumentation is the behavior shared by every call made through a client.
+type Instrumentation struct {
+	// Name identifies the client in logs, metrics and spans; set by Register.
+	Name string
+
+	Retry RetryPolicy
+
+	// RateLimit caps calls per second across all handlers using the client; zero means
+	// unlimited. Burst defaults to 1.
+	RateLimit float64
+	Burst     int
+
+	Logger  telemetry.Logger
+	Metrics Metrics
+	Tracer  Tracer
+
+	// SensitiveHeaders are redacted from logged HTTP requests and responses in addition
+	// to DefaultSensitiveHeaders.
+	SensitiveHeaders []string
+
+	// RequestIDHeaders name response headers holding backend request IDs in addition to
+	// DefaultRequestIDHeaders; wrapped HTTP clients record them as response metadata.
+	RequestIDHeaders []string
+
+	limiter *rateLimiter
+}
+
+// NewInstrumentation fills in defaults for unset fields. Instrumentation used with Call
+// must come from NewInstrumentation or a Factory.
+func NewInstrumentation(i Instrumentation) *Instrumentation {
+	return i.merge(nil)
+}
+
+// merge returns i with unset fields taken from defaults.
+func (i Instrumentation) merge(defaults *Instrumentation) *Instrumentation {
+	if defaults != nil {
+		if i.Retry.Attempts == 0 && i.Retry.BaseDelay == 0 && i.Retry.MaxDelay == 0 && i.Retry.ShouldRetry == nil {
+			i.Retry = defaults.Retry
+		}
+		if i.RateLimit == 0 {
+			i.RateLimit, i.Burst = defaults.RateLimit, defaults.Burst
+		}
+		if i.Logger == nil {
+			i.Logger = defaults.Logger
+		}
+		if i.Metrics == nil {
+			i.Metrics = defaults.Metrics
+		}
+		if i.Tracer == nil {
+			i.Tracer = defaults.Tracer
+		}
+		i.SensitiveHeaders = append(append([]string(nil), defaults.SensitiveHeaders...), i.SensitiveHeaders...)
+		i.RequestIDHeaders = append(append([]string(nil), defaults.RequestIDHeaders...), i.RequestIDHeaders...)
+	}
+	if i.Retry.Attempts <= 0 {
+		i.Retry.Attempts = 3
+	}
+	if i.Retry.BaseDelay <= 0 {
+		i.Retry.BaseDelay = 100 * time.Millisecond
+	}
+	if i.Retry.MaxDelay <= 0 {
+		i.Retry.MaxDelay = 2 * time.Second
+	}
+	if i.Retry.ShouldRetry == nil {
+		i.Retry.ShouldRetry = DefaultShouldRetry
+	}
+	if i.Logger == nil {
+		i.Logger = telemetry.NoopLogger{}
+	}
+	i.limiter = nil
+	if i.RateLimit > 0 {
+		i.limiter = newRateLimiter(i.RateLimit, i.Burst)
+	}
+	return &i
+}
+
+// Call runs fn as an instrumented operation: it waits for the rate limiter, starts a
+// span, retries transient failures with exponential backoff, and logs and observes every
+// attempt.
+func Call[T any](ctx context.Context, inst *Instrumentation, operation string, fn func(context.Context) (T, error)) (T, error) {
+	var zero T
+	if inst == nil {
+		inst = NewInstrumentation(Instrumentation{})
+	}
+	if err := inst.limiter.wait(ctx); err != nil {
+		return zero, err
+	}
+
+	end := func(error) {}
+	if inst.Tracer != nil {
+		ctx, end = inst.Tracer.StartSpan(ctx, inst.Name+"."+operation, map[string]string{
+			"client":    inst.Name,
+			"operation": operation,
+		})
+	}
+
+	result, err := runWithRetry(ctx, inst, operation, fn)
+	end(err)
+	return result, err
+}
+
+// Do runs fn like Call, for operations that return no value.
+func (i *Instrumentation) Do(ctx context.Context, operation string, fn func(context.Context) error) error {
+	_, err := Call(ctx, i, operation, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, fn(ctx)
+	})
+	return err
+}
+
+func runWithRetry[T any](ctx context.Context, inst *Instrumentation, operation string, fn func(context.Context) (T, error)) (T, error) {
+	var zero T
+	delay := inst.Retry.BaseDelay
+
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		result, err := fn(core.WithAttempt(ctx, attempt))
+		call := CallInfo{Client: inst.Name, Operation: operation, Attempt: attempt, Duration: time.Since(start), Err: err}
+		if inst.Metrics != nil {
+			inst.Metrics.ObserveCall(ctx, call)
+		}
+
+		fields := telemetry.Fields{
+			"client":      inst.Name,
+			"operation":   operation,
+			"attempt":     attempt,
+			"duration_ms": call.Duration.Seconds() * 1000,
+		}
+		if err == nil {
+			inst.Logger.Debug(ctx, "clients.success", fields)
+			return result, nil
+		}
+		if ctx.Err() != nil {
+			return zero, ctx.Err()
+		}
+		if attempt >= inst.Retry.Attempts || !inst.Retry.ShouldRetry(err) {
+			inst.Logger.Error(ctx, "clients.error", err, fields)
+			return zero, err
+		}
+
+		inst.Logger.Warn(ctx, "clients.retry", telemetry.MergeFields(fields, telemetry.Fields{
+			"error":         err.Error(),
+			"next_delay_ms": delay.Seconds() * 1000,
+		}))
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return zero, ctx.Err()
+		case <-timer.C:
+		}
+		if delay *= 2; delay > inst.Retry.MaxDelay {
+			delay = inst.Retry.MaxDelay
+		}
+	}
+}
+
+// RetryableError marks an error as transient regardless of its type, e.g. a backend
+// response that asks the caller to back off.
+type RetryableError struct {
+	Err error
+}
+
+func (e *RetryableError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryableError) Unwrap() error {
+	return e.Err
+}
+
+// DefaultShouldRetry retries RetryableErrors, broken connections and network timeouts.
+// Context cancellation is never retried.
+func DefaultShouldRetry(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var retryable *RetryableError
+	if errors.As(err, &retryable) {
+		return true
+	}
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// rateLimiter is a token bucket shared by every call through one client.
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// wait blocks until a token is available or ctx is done. A nil limiter never blocks.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+		l.last = now
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		l.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("rate limit wait: %w", ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package sqlrunner
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultBackfillBatchSize is the number of rows a backfill updates per batch when none is set.
+const DefaultBackfillBatchSize = 1000
+
+// Backfill updates an existing table in key-ordered batches, each in its own statement,
+// so a table-altering change can populate new columns without long locks.
+type Backfill struct {
+	// Dialect selects placeholder syntax: "postgres" uses $n, anything else uses ?.
+	Dialect string
+
+	Table     string // optionally schema-qualified
+	KeyColumn string // unique, ordered column batches are cut on, usually the primary key
+
+	// Set is the assignment list applied to each batch, e.g. "email_lower = lower(email)".
+	Set string
+
+	// Where optionally limits the rows touched, e.g. "email_lower IS NULL".
+	Where string
+
+	BatchSize int
+
+	// Pause is slept between batches.
+	Pause time.Duration
+
+	// MaxRowsPerSecond caps throughput by sleeping after batches that run ahead of it.
+	MaxRowsPerSecond int
+
+	// Throttle is consulted before every batch, e.g. to back off while replicas lag; a
+	// positive duration is slept before the batch runs.
+	Throttle func(ctx context.Context) (time.Duration, error)
+
+	// StartAfter resumes a backfill after this key, typically BackfillProgress.LastKey.
+	StartAfter any
+
+	// OnProgress is called after every batch.
+	OnProgress func(BackfillProgress)
+}
+
+// BackfillProgress reports how far a backfill got.
+type BackfillProgress struct {
+	Batches      int           `json:"batches"`
+	RowsAffected int64         `json:"rows_affected"`
+	LastKey      any           `json:"last_key,omitempty"` // resume point for StartAfter
+	Elapsed      time.Duration `json:"elapsed"`
+	Done         bool          `json:"done"`
+}
+
+// RowsPerSecond is the average throughput so far.
+func (p BackfillProgress) RowsPerSecond() float64 {
+	if p.Elapsed <= 0 {
+		return 0
+	}
+	return float64(p.RowsAffected) / p.Elapsed.Seconds()
+}
+
+// identifierPattern accepts plain and schema-qualified identifiers.
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*(\.[A-Za-z_][A-Za-z0-9_$]*)?$`)
+
+// BackfillStatements is the SQL a backfill runs.
+type BackfillStatements struct {
+	FirstBound  string // upper key of the first batch; args: batch size
+	NextBound   string // upper key of a later batch; args: previous key, batch size
+	FirstUpdate string // args: upper key
+	NextUpdate  string // args: previous key, upper key
+}
+
+// Statements generates the backfill's SQL.
+func (b *Backfill) Statements() (BackfillStatements, error) {
+	if !identifierPattern.MatchString(b.Table) || !identifierPattern.MatchString(b.KeyColumn) {
+		return BackfillStatements{}, fmt.Errorf("sqlrunner: backfill needs a valid table and key column, got %q and %q", b.Table, b.KeyColumn)
+	}
+	if strings.TrimSpace(b.Set) == "" {
+		return BackfillStatements{}, fmt.Errorf("sqlrunner: backfill of %s has no assignments", b.Table)
+	}
+	p, key := b.placeholder, b.KeyColumn
+	filter := ""
+	if b.Where != "" {
+		filter = " AND (" + b.Where + ")"
+	}
+	bound := "SELECT MAX(%[1]s) FROM (SELECT %[1]s FROM %[2]s WHERE %[3]s%[4]s ORDER BY %[1]s LIMIT %[5]s) batch"
+	return BackfillStatements{
+		FirstBound:  fmt.Sprintf(bound, key, b.Table, "1 = 1", filter, p(1)),
+		NextBound:   fmt.Sprintf(bound, key, b.Table, key+" > "+p(1), filter, p(2)),
+		FirstUpdate: fmt.Sprintf("UPDATE %s SET %s WHERE %s <= %s%s", b.Table, b.Set, key, p(1), filter),
+		NextUpdate:  fmt.Sprintf("UPDATE %s SET %s WHERE %s > %s AND %s <= %s%s", b.Table, b.Set, key, p(1), key, p(2), filter),
+	}, nil
+}
+
+func (b *Backfill) placeholder(n int) string {
+	if b.Dialect == "postgres" {
+		return fmt.Sprintf("$%d", n)
+	}
+	return "?"
+}
+
+// Run executes the backfill batch by batch until no rows are left. Each batch update
+// goes through the runner's retry policy. On error or cancellation the returned progress
+// holds the last completed key, so the backfill can resume with StartAfter.
+func (b *Backfill) Run(ctx context.Context, r *Runner) (BackfillProgress, error) {
+	stmts, err := b.Statements()
+	if err != nil {
+		return BackfillProgress{}, err
+	}
+
+	batchSize := b.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBackfillBatchSize
+	}
+
+	start := time.Now()
+	progress := BackfillProgress{LastKey: b.StartAfter}
+	for {
+		if err := ctx.Err(); err != nil {
+			return progress, err
+		}
+		if err := b.throttle(ctx, progress, start); err != nil {
+			return progress, err
+		}
+
+		var upper any
+		var boundErr error
+		if progress.LastKey == nil {
+			upper, boundErr = queryBound(ctx, r, stmts.FirstBound, batchSize)
+		} else {
+			upper, boundErr = queryBound(ctx, r, stmts.NextBound, progress.LastKey, batchSize)
+		}
+		if boundErr != nil {
+			return progress, fmt.Errorf("sqlrunner: backfill of %s: find batch: %w", b.Table, boundErr)
+		}
+		if upper == nil {
+			progress.Done = true
+			progress.Elapsed = time.Since(start)
+			b.report(progress)
+			return progress, nil
+		}
+
+		var result sql.Result
+		if progress.LastKey == nil {
+			result, err = r.Exec(ctx, stmts.FirstUpdate, upper)
+		} else {
+			result, err = r.Exec(ctx, stmts.NextUpdate, progress.LastKey, upper)
+		}
+		if err != nil {
+			return progress, fmt.Errorf("sqlrunner: backfill of %s: batch %d: %w", b.Table, progress.Batches+1, err)
+		}
+		affected, _ := result.RowsAffected()
+
+		progress.Batches++
+		progress.RowsAffected += affected
+		progress.LastKey = upper
+		progress.Elapsed = time.Since(start)
+		b.report(progress)
+	}
+}
+
+func (b *Backfill) report(progress BackfillProgress) {
+	if b.OnProgress != nil {
+		b.OnProgress(progress)
+	}
+}
+
+// throttle waits out the pause, rate limit and throttle hook before a batch.
+func (b *Backfill) throttle(ctx context.Context, progress BackfillProgress, start time.Time) error {
+	var wait time.Duration
+	if progress.Batches > 0 {
+		wait = b.Pause
+		if b.MaxRowsPerSecond > 0 {
+			due := time.Duration(float64(progress.RowsAffected) / float64(b.MaxRowsPerSecond) * float64(time.Second))
+			if ahead := due - time.Since(start); ahead > wait {
+				wait = ahead
+			}
+		}
+	}
+	if b.Throttle != nil {
+		extra, err := b.Throttle(ctx)
+		if err != nil {
+			return fmt.Errorf("sqlrunner: backfill of %s: throttle: %w", b.Table, err)
+		}
+		if extra > wait {
+			wait = extra
+		}
+	}
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// queryBound returns the single value of a bound query, nil when no rows are left.
+func queryBound(ctx context.Context, r *Runner, query string, args ...any) (any, error) {
+	rows, err := r.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var bound any
+	if rows.Next() {
+		if err := rows.Scan(&bound); err != nil {
+			return nil, err
+		}
+	}
+	if b, ok := bound.([]byte); ok {
+		bound = string(b)
+	}
+	return bound, rows.Err()
+}
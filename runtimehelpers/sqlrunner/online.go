@@ -0,0 +1,224 @@
+package sqlrunner
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DualWrite keeps new columns in step with writes to the old ones while an online
+// migration is in progress. It installs BEFORE INSERT/UPDATE triggers that compute each
+// target column from the row being written, so application code can keep writing the
+// old shape until reads have moved over.
+type DualWrite struct {
+	// Dialect is "postgres" or "mysql".
+	Dialect string
+
+	Table string
+
+	// Name prefixes the triggers and, for postgres, the trigger function.
+	Name string
+
+	// Assignments maps each target column to an expression over the written row,
+	// e.g. "email_lower": "lower(NEW.email)".
+	Assignments map[string]string
+}
+
+// InstallSQL returns the statements creating the dual-write triggers.
+func (d DualWrite) InstallSQL() ([]string, error) {
+	if err := d.validate(); err != nil {
+		return nil, err
+	}
+	columns := make([]string, 0, len(d.Assignments))
+	for column := range d.Assignments {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	switch d.Dialect {
+	case "postgres":
+		var body strings.Builder
+		for _, column := range columns {
+			fmt.Fprintf(&body, "NEW.%s := %s; ", column, d.Assignments[column])
+		}
+		return []string{
+			fmt.Sprintf("CREATE OR REPLACE FUNCTION %s_fn() RETURNS trigger AS $$ BEGIN %sRETURN NEW; END $$ LANGUAGE plpgsql", d.Name, body.String()),
+			fmt.Sprintf("DROP TRIGGER IF EXISTS %s ON %s", d.Name, d.Table),
+			fmt.Sprintf("CREATE TRIGGER %s BEFORE INSERT OR UPDATE ON %s FOR EACH ROW EXECUTE FUNCTION %s_fn()", d.Name, d.Table, d.Name),
+		}, nil
+	case "mysql":
+		sets := make([]string, len(columns))
+		for i, column := range columns {
+			sets[i] = fmt.Sprintf("NEW.%s = %s", column, d.Assignments[column])
+		}
+		assignments := strings.Join(sets, ", ")
+		return []string{
+			fmt.Sprintf("DROP TRIGGER IF EXISTS %s_ins", d.Name),
+			fmt.Sprintf("CREATE TRIGGER %s_ins BEFORE INSERT ON %s FOR EACH ROW SET %s", d.Name, d.Table, assignments),
+			fmt.Sprintf("DROP TRIGGER IF EXISTS %s_upd", d.Name),
+			fmt.Sprintf("CREATE TRIGGER %s_upd BEFORE UPDATE ON %s FOR EACH ROW SET %s", d.Name, d.Table, assignments),
+		}, nil
+	}
+	return nil, fmt.Errorf("sqlrunner: dual write does not support dialect %q", d.Dialect)
+}
+
+// RemoveSQL returns the statements dropping the dual-write triggers.
+func (d DualWrite) RemoveSQL() ([]string, error) {
+	if err := d.validate(); err != nil {
+		return nil, err
+	}
+	switch d.Dialect {
+	case "postgres":
+		return []string{
+			fmt.Sprintf("DROP TRIGGER IF EXISTS %s ON %s", d.Name, d.Table),
+			fmt.Sprintf("DROP FUNCTION IF EXISTS %s_fn()", d.Name),
+		}, nil
+	case "mysql":
+		return []string{
+			fmt.Sprintf("DROP TRIGGER IF EXISTS %s_ins", d.Name),
+			fmt.Sprintf("DROP TRIGGER IF EXISTS %s_upd", d.Name),
+		}, nil
+	}
+	return nil, fmt.Errorf("sqlrunner: dual write does not support dialect %q", d.Dialect)
+}
+
+func (d DualWrite) validate() error {
+	if !identifierPattern.MatchString(d.Table) || !identifierPattern.MatchString(d.Name) || strings.Contains(d.Name, ".") {
+		return fmt.Errorf("sqlrunner: dual write needs a valid table and trigger name, got %q and %q", d.Table, d.Name)
+	}
+	if len(d.Assignments) == 0 {
+		return fmt.Errorf("sqlrunner: dual write on %s has no assignments", d.Table)
+	}
+	for column := range d.Assignments {
+		if !identifierPattern.MatchString(column) || strings.Contains(column, ".") {
+			return fmt.Errorf("sqlrunner: dual write on %s: invalid column %q", d.Table, column)
+		}
+	}
+	return nil
+}
+
+// Online migration phases, in the order they run.
+const (
+	PhaseDualWrite = "dual_write"
+	PhaseBackfill  = "backfill"
+	PhaseVerify    = "verify"
+	PhaseCutover   = "cutover"
+	PhaseContract  = "contract"
+)
+
+// OnlineMigration sequences a zero-downtime column change: start dual writes so new
+// rows get the new shape, backfill existing rows, verify nothing was missed, switch
+// reads over and finally remove the dual-write triggers. Providers supply the pieces;
+// the sequence and its bookkeeping are shared.
+type OnlineMigration struct {
+	DualWrite DualWrite
+	Backfill  Backfill
+
+	// VerifyQuery returns the number of rows still in the old shape; the migration
+	// stops unless it is zero, e.g. "SELECT COUNT(*) FROM users WHERE email_lower IS NULL".
+	VerifyQuery string
+
+	// Cutover switches reads to the new shape, e.g. by swapping a view or flipping a
+	// feature flag. Optional.
+	Cutover func(ctx context.Context, r *Runner) error
+
+	// KeepDualWrite leaves the triggers in place after cutover so old readers and
+	// writers stay compatible; call Contract once the compatibility window closes.
+	KeepDualWrite bool
+
+	// OnPhase is called as each phase starts.
+	OnPhase func(phase string)
+}
+
+// OnlineMigrationResult records a migration run.
+type OnlineMigrationResult struct {
+	Completed []string         `json:"completed"` // phases finished, in order
+	Backfill  BackfillProgress `json:"backfill"`
+	Remaining int64            `json:"remaining"` // rows the verify query still found
+	Duration  time.Duration    `json:"duration"`
+}
+
+// Run executes the migration phases in order. A failure stops the migration with the
+// dual-write triggers left installed, so the backfill can be resumed safely.
+func (m *OnlineMigration) Run(ctx context.Context, r *Runner) (*OnlineMigrationResult, error) {
+	start := time.Now()
+	result := &OnlineMigrationResult{}
+	finish := func(err error) (*OnlineMigrationResult, error) {
+		result.Duration = time.Since(start)
+		return result, err
+	}
+	phase := func(name string, fn func() error) error {
+		if m.OnPhase != nil {
+			m.OnPhase(name)
+		}
+		if err := fn(); err != nil {
+			return fmt.Errorf("sqlrunner: online migration %s: %w", name, err)
+		}
+		result.Completed = append(result.Completed, name)
+		return nil
+	}
+
+	if err := phase(PhaseDualWrite, func() error {
+		statements, err := m.DualWrite.InstallSQL()
+		if err != nil {
+			return err
+		}
+		return execAll(ctx, r, statements)
+	}); err != nil {
+		return finish(err)
+	}
+
+	if err := phase(PhaseBackfill, func() (err error) {
+		result.Backfill, err = m.Backfill.Run(ctx, r)
+		return err
+	}); err != nil {
+		return finish(err)
+	}
+
+	if m.VerifyQuery != "" {
+		if err := phase(PhaseVerify, func() error {
+			if err := r.QueryRow(ctx, m.VerifyQuery).Scan(&result.Remaining); err != nil {
+				return err
+			}
+			if result.Remaining != 0 {
+				return fmt.Errorf("%d rows were not backfilled", result.Remaining)
+			}
+			return nil
+		}); err != nil {
+			return finish(err)
+		}
+	}
+
+	if m.Cutover != nil {
+		if err := phase(PhaseCutover, func() error { return m.Cutover(ctx, r) }); err != nil {
+			return finish(err)
+		}
+	}
+
+	if !m.KeepDualWrite {
+		if err := phase(PhaseContract, func() error { return m.Contract(ctx, r) }); err != nil {
+			return finish(err)
+		}
+	}
+	return finish(nil)
+}
+
+// Contract removes the dual-write triggers, ending the compatibility window.
+func (m *OnlineMigration) Contract(ctx context.Context, r *Runner) error {
+	statements, err := m.DualWrite.RemoveSQL()
+	if err != nil {
+		return err
+	}
+	return execAll(ctx, r, statements)
+}
+
+func execAll(ctx context.Context, r *Runner, statements []string) error {
+	for _, statement := range statements {
+		if _, err := r.Exec(ctx, statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
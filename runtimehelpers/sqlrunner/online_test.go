@@ -0,0 +1,129 @@
+package sqlrunner
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/runtimehelpers/telemetry"
+)
+
+// keyedTable answers backfill bound queries over keys 1..rows
+func keyedTable(rows int64) func(query string, args []driver.NamedValue) [][]driver.Value {
+	return func(query string, args []driver.NamedValue) [][]driver.Value {
+		if strings.HasPrefix(query, "SELECT COUNT") {
+			return [][]driver.Value{{int64(0)}}
+		}
+		after, limit := int64(0), args[len(args)-1].Value.(int64)
+		if len(args) == 2 {
+			after = args[0].Value.(int64)
+		}
+		if after >= rows {
+			return [][]driver.Value{{nil}}
+		}
+		upper := after + limit
+		if upper > rows {
+			upper = rows
+		}
+		return [][]driver.Value{{upper}}
+	}
+}
+
+func TestBackfillRunsInBatches(t *testing.T) {
+	state := &stubState{queryColumns: []string{"max"}, queryFunc: keyedTable(25)}
+	runner, err := NewRunner(Config{ExistingDB: sql.OpenDB(&stubConnector{state: state}), Logger: telemetry.NoopLogger{}})
+	if err != nil {
+		t.Fatalf("new runner: %v", err)
+	}
+
+	var reports []BackfillProgress
+	backfill := &Backfill{
+		Dialect:    "postgres",
+		Table:      "app.users",
+		KeyColumn:  "id",
+		Set:        "email_lower = lower(email)",
+		Where:      "email_lower IS NULL",
+		BatchSize:  10,
+		OnProgress: func(p BackfillProgress) { reports = append(reports, p) },
+	}
+	progress, err := backfill.Run(context.Background(), runner)
+	if err != nil {
+		t.Fatalf("backfill: %v", err)
+	}
+	if !progress.Done || progress.Batches != 3 || progress.LastKey != int64(25) || len(reports) != 4 {
+		t.Fatalf("unexpected progress %+v after %d reports", progress, len(reports))
+	}
+	if len(state.execQueries) != 3 {
+		t.Fatalf("expected 3 batch updates, got %d", len(state.execQueries))
+	}
+	if state.execQueries[0] != "UPDATE app.users SET email_lower = lower(email) WHERE id <= $1 AND (email_lower IS NULL)" {
+		t.Fatalf("unexpected first batch: %s", state.execQueries[0])
+	}
+	if got := state.execArgs[2]; len(got) != 2 || got[0].Value != int64(20) || got[1].Value != int64(25) {
+		t.Fatalf("unexpected last batch bounds %v", got)
+	}
+
+	// Resuming after the last key finds nothing left
+	state.execQueries = nil
+	backfill.StartAfter = progress.LastKey
+	if resumed, err := backfill.Run(context.Background(), runner); err != nil || resumed.Batches != 0 || len(state.execQueries) != 0 {
+		t.Fatalf("resume should find no rows: %+v %v", resumed, err)
+	}
+
+	if _, err := (&Backfill{Table: "users; DROP TABLE x", KeyColumn: "id", Set: "a = b"}).Statements(); err == nil {
+		t.Fatal("expected invalid table to be rejected")
+	}
+}
+
+func TestBackfillThrottleError(t *testing.T) {
+	state := &stubState{queryColumns: []string{"max"}, queryFunc: keyedTable(5)}
+	runner, _ := NewRunner(Config{ExistingDB: sql.OpenDB(&stubConnector{state: state}), Logger: telemetry.NoopLogger{}})
+
+	lagging := errors.New("replica lag too high")
+	_, err := (&Backfill{
+		Table: "users", KeyColumn: "id", Set: "a = b",
+		Throttle: func(ctx context.Context) (time.Duration, error) { return 0, lagging },
+	}).Run(context.Background(), runner)
+	if !errors.Is(err, lagging) {
+		t.Fatalf("expected throttle error, got %v", err)
+	}
+}
+
+func TestOnlineMigrationPhases(t *testing.T) {
+	state := &stubState{queryColumns: []string{"max"}, queryFunc: keyedTable(5)}
+	runner, _ := NewRunner(Config{ExistingDB: sql.OpenDB(&stubConnector{state: state}), Logger: telemetry.NoopLogger{}})
+
+	var phases []string
+	migration := &OnlineMigration{
+		DualWrite: DualWrite{Dialect: "mysql", Table: "users", Name: "users_email_lower",
+			Assignments: map[string]string{"email_lower": "lower(NEW.email)"}},
+		Backfill:    Backfill{Table: "users", KeyColumn: "id", Set: "email_lower = lower(email)"},
+		VerifyQuery: "SELECT COUNT(*) FROM users WHERE email_lower IS NULL",
+		Cutover:     func(ctx context.Context, r *Runner) error { return nil },
+		OnPhase:     func(phase string) { phases = append(phases, phase) },
+	}
+	result, err := migration.Run(context.Background(), runner)
+	if err != nil {
+		t.Fatalf("migration: %v", err)
+	}
+	want := "dual_write,backfill,verify,cutover,contract"
+	if strings.Join(result.Completed, ",") != want || strings.Join(phases, ",") != want {
+		t.Fatalf("unexpected phases %v", result.Completed)
+	}
+	if state.execQueries[1] != "CREATE TRIGGER users_email_lower_ins BEFORE INSERT ON users FOR EACH ROW SET NEW.email_lower = lower(NEW.email)" {
+		t.Fatalf("unexpected trigger: %s", state.execQueries[1])
+	}
+	if last := state.execQueries[len(state.execQueries)-1]; last != "DROP TRIGGER IF EXISTS users_email_lower_upd" {
+		t.Fatalf("expected triggers to be removed last, got %s", last)
+	}
+
+	install, _ := DualWrite{Dialect: "postgres", Table: "app.users", Name: "users_email_lower",
+		Assignments: map[string]string{"email_lower": "lower(NEW.email)"}}.InstallSQL()
+	if len(install) != 3 || !strings.Contains(install[0], "NEW.email_lower := lower(NEW.email); RETURN NEW;") {
+		t.Fatalf("unexpected postgres dual write %v", install)
+	}
+}
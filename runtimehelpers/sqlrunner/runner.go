@@ -11,6 +11,7 @@ import (
 	"text/template"
 	"time"
 
+	"github.com/schemabounce/kolumn/sdk/core"
 	"github.com/schemabounce/kolumn/sdk/runtimehelpers/telemetry"
 )
 
@@ -225,8 +226,9 @@ func runWithRetry[T any](ctx context.Context, r *Runner, operation, statement st
 	delay := r.policy.BaseDelay
 
 	for attempt := 1; attempt <= r.policy.Attempts; attempt++ {
+		attemptCtx := core.WithAttempt(ctx, attempt)
 		start := time.Now()
-		result, err := fn(ctx)
+		result, err := fn(attemptCtx)
 		duration := time.Since(start)
 
 		if err == nil {
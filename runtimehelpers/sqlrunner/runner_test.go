@@ -135,6 +135,8 @@ type stubState struct {
 	lastQuery     string
 	queryColumns  []string
 	queryRows     [][]driver.Value
+	queryFunc     func(query string, args []driver.NamedValue) [][]driver.Value
+	execArgs      [][]driver.NamedValue
 	beginErr      error
 	commitErr     error
 	rollbackErr   error
@@ -165,6 +167,7 @@ func (c *stubConn) ExecContext(ctx context.Context, query string, args []driver.
 	c.state.mu.Lock()
 	defer c.state.mu.Unlock()
 	c.state.execQueries = append(c.state.execQueries, query)
+	c.state.execArgs = append(c.state.execArgs, args)
 	if len(c.state.execErrors) > 0 {
 		err := c.state.execErrors[0]
 		c.state.execErrors = c.state.execErrors[1:]
@@ -179,10 +182,14 @@ func (c *stubConn) QueryContext(ctx context.Context, query string, args []driver
 	c.state.mu.Lock()
 	defer c.state.mu.Unlock()
 	c.state.lastQuery = query
-	rows := make([][]driver.Value, len(c.state.queryRows))
-	for i := range c.state.queryRows {
-		row := make([]driver.Value, len(c.state.queryRows[i]))
-		copy(row, c.state.queryRows[i])
+	source := c.state.queryRows
+	if c.state.queryFunc != nil {
+		source = c.state.queryFunc(query, args)
+	}
+	rows := make([][]driver.Value, len(source))
+	for i := range source {
+		row := make([]driver.Value, len(source[i]))
+		copy(row, source[i])
 		rows[i] = row
 	}
 	return &stubRows{columns: append([]string(nil), c.state.queryColumns...), rows: rows}, nil
@@ -0,0 +1,12 @@
+package telemetry
+
+import "github.com/schemabounce/kolumn/sdk/types"
+
+// clock supplies usage report periods and logged durations
+var clock types.ClockVar
+
+// SetClock replaces the clock used by telemetry, or restores the system clock when
+// nil. It returns a function restoring the previous clock.
+func SetClock(c types.Clock) (restore func()) {
+	return clock.Set(c)
+}
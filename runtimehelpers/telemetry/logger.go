@@ -6,6 +6,7 @@ import (
 	"sort"
 	"time"
 
+	"github.com/schemabounce/kolumn/sdk/core"
 	"github.com/schemabounce/kolumn/sdk/helpers/logging"
 )
 
@@ -63,29 +64,41 @@ func (l *StructuredLogger) WithComponent(component string) Logger {
 }
 
 // Debug emits a debug event with fields.
-func (l *StructuredLogger) Debug(_ context.Context, msg string, fields Fields) {
-	l.base.DebugWithFields(msg, flatten(fields)...)
+func (l *StructuredLogger) Debug(ctx context.Context, msg string, fields Fields) {
+	l.base.DebugWithFields(msg, flatten(withOperation(ctx, fields))...)
 }
 
 // Info emits an info event with fields.
-func (l *StructuredLogger) Info(_ context.Context, msg string, fields Fields) {
-	l.base.InfoWithFields(msg, flatten(fields)...)
+func (l *StructuredLogger) Info(ctx context.Context, msg string, fields Fields) {
+	l.base.InfoWithFields(msg, flatten(withOperation(ctx, fields))...)
 }
 
 // Warn emits a warning event with fields.
-func (l *StructuredLogger) Warn(_ context.Context, msg string, fields Fields) {
-	l.base.WarnWithFields(msg, flatten(fields)...)
+func (l *StructuredLogger) Warn(ctx context.Context, msg string, fields Fields) {
+	l.base.WarnWithFields(msg, flatten(withOperation(ctx, fields))...)
 }
 
 // Error emits an error event with fields and the error message attached.
-func (l *StructuredLogger) Error(_ context.Context, msg string, err error, fields Fields) {
-	merged := cloneFields(fields)
+func (l *StructuredLogger) Error(ctx context.Context, msg string, err error, fields Fields) {
+	merged := withOperation(ctx, fields)
 	if err != nil {
 		merged["error"] = err.Error()
 	}
 	l.base.ErrorWithFields(msg, flatten(merged)...)
 }
 
+// withOperation adds the core.OperationContext fields from ctx; explicit fields win.
+func withOperation(ctx context.Context, fields Fields) Fields {
+	if ctx == nil {
+		return cloneFields(fields)
+	}
+	op, ok := core.OperationFromContext(ctx)
+	if !ok {
+		return cloneFields(fields)
+	}
+	return MergeFields(Fields(op.Fields()), fields)
+}
+
 // NoopLogger drops all telemetry and is safe for tests.
 type NoopLogger struct{}
 
@@ -100,12 +113,12 @@ func TrackOperation(ctx context.Context, logger Logger, name string, fn func(con
 	if logger == nil {
 		logger = NoopLogger{}
 	}
-	start := time.Now()
+	start := clock.Now()
 	logger.Info(ctx, fmt.Sprintf("%s.start", name), Fields{"ts": start.Format(time.RFC3339Nano)})
 
 	err := fn(ctx)
 
-	dur := time.Since(start)
+	dur := clock.Since(start)
 	fields := Fields{"duration_ms": dur.Seconds() * 1000}
 	if err != nil {
 		logger.Error(ctx, fmt.Sprintf("%s.fail", name), err, fields)
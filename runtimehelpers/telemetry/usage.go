@@ -0,0 +1,386 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+	"github.com/schemabounce/kolumn/sdk/helpers/ids"
+)
+
+// Opt-out environment variables. Either one disables usage reporting regardless of
+// provider configuration.
+const (
+	OptOutEnvVar     = "KOLUMN_TELEMETRY_DISABLED"
+	DoNotTrackEnvVar = "DO_NOT_TRACK"
+)
+
+// Usage reporting defaults.
+const (
+	DefaultFlushInterval = time.Hour
+	DefaultMaxSpoolFiles = 100
+)
+
+// UsageReport is the anonymous payload sent to the telemetry endpoint. It contains
+// counts only: no configuration, resource names, identities or error messages.
+type UsageReport struct {
+	SessionID       string                      `json:"session_id"` // random per process
+	SDKVersion      string                      `json:"sdk_version"`
+	GoVersion       string                      `json:"go_version"`
+	Platform        string                      `json:"platform"`
+	Provider        string                      `json:"provider,omitempty"`
+	ProviderVersion string                      `json:"provider_version,omitempty"`
+	PeriodStart     time.Time                   `json:"period_start"`
+	PeriodEnd       time.Time                   `json:"period_end"`
+	Calls           map[string]int64            `json:"calls"`
+	Errors          map[string]map[string]int64 `json:"errors,omitempty"` // function -> error class -> count
+}
+
+// UsageSink delivers usage reports.
+type UsageSink interface {
+	Send(ctx context.Context, report *UsageReport) error
+}
+
+// HTTPSink posts usage reports as JSON to an endpoint.
+type HTTPSink struct {
+	Endpoint string
+	Client   *http.Client
+}
+
+// Send implements UsageSink.
+func (s *HTTPSink) Send(ctx context.Context, report *UsageReport) error {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := s.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("telemetry endpoint returned %s", resp.Status)
+	}
+	return nil
+}
+
+// UsageConfig configures usage reporting. Reporting is off unless Enabled is set and
+// a Sink or Endpoint is provided.
+type UsageConfig struct {
+	Enabled         bool
+	Endpoint        string
+	Sink            UsageSink // overrides Endpoint
+	Provider        string
+	ProviderVersion string
+	SpoolDir        string // defaults to <user cache dir>/kolumn/telemetry
+	MaxSpoolFiles   int
+	FlushInterval   time.Duration
+}
+
+// OptedOut reports whether the user disabled telemetry through the environment.
+func OptedOut() bool {
+	for _, key := range []string{OptOutEnvVar, DoNotTrackEnvVar} {
+		switch strings.ToLower(strings.TrimSpace(os.Getenv(key))) {
+		case "", "0", "false", "no", "off":
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// UsageReporter aggregates function call counts and spools reports it cannot deliver.
+// A disabled reporter accepts calls and does nothing, so providers can record
+// unconditionally.
+type UsageReporter struct {
+	enabled  bool
+	sink     UsageSink
+	spoolDir string
+	maxSpool int
+	interval time.Duration
+	base     UsageReport
+
+	mu     sync.Mutex
+	start  time.Time
+	calls  map[string]int64
+	errors map[string]map[string]int64
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewUsageReporter creates a reporter. It is disabled when the user opted out or the
+// configuration does not enable reporting.
+func NewUsageReporter(cfg UsageConfig) *UsageReporter {
+	r := &UsageReporter{
+		maxSpool: cfg.MaxSpoolFiles,
+		interval: cfg.FlushInterval,
+		spoolDir: cfg.SpoolDir,
+		start:    clock.Now().UTC(),
+		calls:    make(map[string]int64),
+		errors:   make(map[string]map[string]int64),
+		base: UsageReport{
+			SessionID:       newSessionID(),
+			SDKVersion:      core.SDKVersion,
+			GoVersion:       runtime.Version(),
+			Platform:        runtime.GOOS + "/" + runtime.GOARCH,
+			Provider:        cfg.Provider,
+			ProviderVersion: cfg.ProviderVersion,
+		},
+	}
+
+	r.sink = cfg.Sink
+	if r.sink == nil && cfg.Endpoint != "" {
+		r.sink = &HTTPSink{Endpoint: cfg.Endpoint}
+	}
+	r.enabled = cfg.Enabled && r.sink != nil && !OptedOut()
+
+	if r.maxSpool <= 0 {
+		r.maxSpool = DefaultMaxSpoolFiles
+	}
+	if r.interval <= 0 {
+		r.interval = DefaultFlushInterval
+	}
+	if r.spoolDir == "" {
+		if dir, err := os.UserCacheDir(); err == nil {
+			r.spoolDir = filepath.Join(dir, "kolumn", "telemetry")
+		}
+	}
+	return r
+}
+
+// Enabled reports whether usage is being collected.
+func (r *UsageReporter) Enabled() bool {
+	return r != nil && r.enabled
+}
+
+// RecordCall counts one function call and, when err is non-nil, its error class.
+func (r *UsageReporter) RecordCall(function string, err error) {
+	if !r.Enabled() {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls[function]++
+	if err != nil {
+		class := ErrorClass(err)
+		if r.errors[function] == nil {
+			r.errors[function] = make(map[string]int64)
+		}
+		r.errors[function][class]++
+	}
+}
+
+// ErrorClass reduces an error to an anonymous class: context errors by name, other
+// errors by their Go type. Messages are never reported.
+func ErrorClass(err error) string {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return "deadline_exceeded"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	}
+	var coded interface{ ErrorCode() string }
+	if errors.As(err, &coded) {
+		return coded.ErrorCode()
+	}
+	return fmt.Sprintf("%T", err)
+}
+
+// Snapshot returns the counts collected since the last flush without resetting them.
+func (r *UsageReporter) Snapshot() *UsageReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.snapshotLocked()
+}
+
+func (r *UsageReporter) snapshotLocked() *UsageReport {
+	report := r.base
+	report.PeriodStart = r.start
+	report.PeriodEnd = clock.Now().UTC()
+	report.Calls = make(map[string]int64, len(r.calls))
+	for k, v := range r.calls {
+		report.Calls[k] = v
+	}
+	if len(r.errors) > 0 {
+		report.Errors = make(map[string]map[string]int64, len(r.errors))
+		for fn, classes := range r.errors {
+			report.Errors[fn] = make(map[string]int64, len(classes))
+			for class, n := range classes {
+				report.Errors[fn][class] = n
+			}
+		}
+	}
+	return &report
+}
+
+// Flush sends previously spooled reports and the current counts. Reports that cannot
+// be delivered are written to the spool directory and retried on the next flush.
+func (r *UsageReporter) Flush(ctx context.Context) error {
+	if !r.Enabled() {
+		return nil
+	}
+
+	r.mu.Lock()
+	report := r.snapshotLocked()
+	r.start = report.PeriodEnd
+	r.calls = make(map[string]int64)
+	r.errors = make(map[string]map[string]int64)
+	r.mu.Unlock()
+
+	if err := r.drainSpool(ctx); err != nil {
+		if len(report.Calls) > 0 {
+			return r.spool(report)
+		}
+		return nil
+	}
+	if len(report.Calls) == 0 {
+		return nil
+	}
+	if err := r.sink.Send(ctx, report); err != nil {
+		return r.spool(report)
+	}
+	return nil
+}
+
+// Start flushes periodically until Close is called.
+func (r *UsageReporter) Start() {
+	if !r.Enabled() || r.stop != nil {
+		return
+	}
+	r.stop = make(chan struct{})
+	r.done = make(chan struct{})
+
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+				_ = r.Flush(ctx)
+				cancel()
+			case <-r.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Close stops periodic flushing and performs a final flush.
+func (r *UsageReporter) Close(ctx context.Context) error {
+	if !r.Enabled() {
+		return nil
+	}
+	if r.stop != nil {
+		close(r.stop)
+		<-r.done
+		r.stop = nil
+	}
+	return r.Flush(ctx)
+}
+
+// spool persists a report for later delivery, keeping at most maxSpool files.
+func (r *UsageReporter) spool(report *UsageReport) error {
+	if r.spoolDir == "" {
+		return errors.New("telemetry spool directory is unavailable")
+	}
+	if err := os.MkdirAll(r.spoolDir, 0o700); err != nil {
+		return fmt.Errorf("failed to create telemetry spool: %w", err)
+	}
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("usage-%d-%s.json", report.PeriodEnd.UnixNano(), report.SessionID[:8])
+	if err := os.WriteFile(filepath.Join(r.spoolDir, name), data, 0o600); err != nil {
+		return fmt.Errorf("failed to spool telemetry: %w", err)
+	}
+
+	files, err := r.spooledFiles()
+	if err != nil {
+		return nil
+	}
+	for len(files) > r.maxSpool {
+		os.Remove(files[0])
+		files = files[1:]
+	}
+	return nil
+}
+
+// drainSpool sends spooled reports oldest first and stops at the first failure.
+func (r *UsageReporter) drainSpool(ctx context.Context) error {
+	files, err := r.spooledFiles()
+	if err != nil {
+		return nil
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		var report UsageReport
+		if err := json.Unmarshal(data, &report); err != nil {
+			os.Remove(file)
+			continue
+		}
+		if err := r.sink.Send(ctx, &report); err != nil {
+			return err
+		}
+		os.Remove(file)
+	}
+	return nil
+}
+
+// SpooledReports returns the number of reports waiting for delivery.
+func (r *UsageReporter) SpooledReports() int {
+	files, _ := r.spooledFiles()
+	return len(files)
+}
+
+func (r *UsageReporter) spooledFiles() ([]string, error) {
+	if r.spoolDir == "" {
+		return nil, nil
+	}
+	files, err := filepath.Glob(filepath.Join(r.spoolDir, "usage-*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func newSessionID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return strings.ToLower(ids.ULID())
+	}
+	return hex.EncodeToString(b)
+}
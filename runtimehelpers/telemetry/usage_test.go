@@ -0,0 +1,102 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+type fakeSink struct {
+	mu      sync.Mutex
+	fail    bool
+	reports []*UsageReport
+}
+
+func (s *fakeSink) Send(ctx context.Context, report *UsageReport) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fail {
+		return errors.New("offline")
+	}
+	s.reports = append(s.reports, report)
+	return nil
+}
+
+func TestUsageReporterOptOut(t *testing.T) {
+	t.Setenv(OptOutEnvVar, "1")
+
+	sink := &fakeSink{}
+	r := NewUsageReporter(UsageConfig{Enabled: true, Sink: sink, SpoolDir: t.TempDir()})
+	if r.Enabled() {
+		t.Fatalf("expected opt-out env var to disable reporting")
+	}
+	r.RecordCall("Ping", nil)
+	if err := r.Flush(context.Background()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(sink.reports) != 0 {
+		t.Fatalf("expected nothing to be sent, got %d reports", len(sink.reports))
+	}
+}
+
+func TestUsageReporterCountsAndErrorClasses(t *testing.T) {
+	t.Setenv(OptOutEnvVar, "")
+	t.Setenv(DoNotTrackEnvVar, "")
+
+	sink := &fakeSink{}
+	r := NewUsageReporter(UsageConfig{Enabled: true, Sink: sink, Provider: "postgres", SpoolDir: t.TempDir()})
+
+	r.RecordCall("CreateResource", nil)
+	r.RecordCall("CreateResource", context.DeadlineExceeded)
+	r.RecordCall("Ping", nil)
+
+	if err := r.Flush(context.Background()); err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
+	if len(sink.reports) != 1 {
+		t.Fatalf("expected 1 report, got %d", len(sink.reports))
+	}
+	report := sink.reports[0]
+	if report.Calls["CreateResource"] != 2 || report.Calls["Ping"] != 1 {
+		t.Fatalf("unexpected call counts %v", report.Calls)
+	}
+	if report.Errors["CreateResource"]["deadline_exceeded"] != 1 {
+		t.Fatalf("unexpected error classes %v", report.Errors)
+	}
+	if report.Provider != "postgres" || report.SDKVersion == "" {
+		t.Fatalf("unexpected report metadata %+v", report)
+	}
+	if len(r.Snapshot().Calls) != 0 {
+		t.Fatalf("expected counters to reset after flush")
+	}
+}
+
+func TestUsageReporterSpoolsWhenOffline(t *testing.T) {
+	t.Setenv(OptOutEnvVar, "")
+	t.Setenv(DoNotTrackEnvVar, "")
+
+	sink := &fakeSink{fail: true}
+	r := NewUsageReporter(UsageConfig{Enabled: true, Sink: sink, SpoolDir: t.TempDir(), MaxSpoolFiles: 2})
+
+	for i := 0; i < 3; i++ {
+		r.RecordCall("ReadResource", nil)
+		if err := r.Flush(context.Background()); err != nil {
+			t.Fatalf("flush failed: %v", err)
+		}
+	}
+	if got := r.SpooledReports(); got != 2 {
+		t.Fatalf("expected spool capped at 2 reports, got %d", got)
+	}
+
+	sink.fail = false
+	if err := r.Close(context.Background()); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+	if got := r.SpooledReports(); got != 0 {
+		t.Fatalf("expected spool to drain, %d left", got)
+	}
+	if len(sink.reports) != 2 {
+		t.Fatalf("expected 2 spooled reports delivered, got %d", len(sink.reports))
+	}
+}
@@ -0,0 +1,326 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/create"
+	"github.com/schemabounce/kolumn/sdk/discover"
+	"github.com/schemabounce/kolumn/sdk/types"
+)
+
+// AdoptionMetadataKey is the resource metadata key holding adoption provenance
+const AdoptionMetadataKey = "adoption"
+
+// Adoption outcomes
+const (
+	AdoptStatusAdopted        = "adopted"
+	AdoptStatusWouldAdopt     = "would_adopt"
+	AdoptStatusAlreadyManaged = "already_managed"
+	AdoptStatusUnsupported    = "unsupported"
+	AdoptStatusFailed         = "failed"
+)
+
+// Importer is the part of a CREATE handler used to adopt existing objects;
+// create.EnhancedObjectHandler implements it
+type Importer interface {
+	Import(ctx context.Context, req *create.ImportRequest) (*create.ImportResponse, error)
+}
+
+// AdoptOptions controls how discovered objects become managed resources
+type AdoptOptions struct {
+	// TypeMapping maps discovered object types to resource types; unmapped types are
+	// adopted under their discovered type
+	TypeMapping map[string]string
+
+	// DryRun reports what would be adopted without importing or changing state
+	DryRun bool
+
+	// AdoptedBy is recorded in the provenance metadata
+	AdoptedBy string
+
+	// Dependencies, when set, receives the adopted resources and the dependencies
+	// derived from their relationship edges
+	Dependencies *DependencyManager
+}
+
+// AdoptionProvenance records where an adopted resource came from
+type AdoptionProvenance struct {
+	DiscoveredID   string    `json:"discovered_id"`
+	DiscoveredType string    `json:"discovered_type"`
+	DiscoveredAt   string    `json:"discovered_at,omitempty"`
+	SourceSystem   string    `json:"source_system,omitempty"`
+	SourceLocation string    `json:"source_location,omitempty"`
+	AdoptedAt      time.Time `json:"adopted_at"`
+	AdoptedBy      string    `json:"adopted_by,omitempty"`
+}
+
+// AdoptOutcome is the result of adopting one discovered object
+type AdoptOutcome struct {
+	DiscoveredID string   `json:"discovered_id"`
+	ResourceType string   `json:"resource_type"`
+	ResourceID   string   `json:"resource_id"`
+	Name         string   `json:"name"`
+	Status       string   `json:"status"` // one of the AdoptStatus values
+	Error        string   `json:"error,omitempty"`
+	Warnings     []string `json:"warnings,omitempty"`
+	Dependencies []string `json:"dependencies,omitempty"` // resource IDs derived from relationships
+}
+
+// AdoptResult summarizes an adoption run
+type AdoptResult struct {
+	Outcomes []AdoptOutcome `json:"outcomes"`
+	Adopted  int            `json:"adopted"`
+	Skipped  int            `json:"skipped"`
+	Failed   int            `json:"failed"`
+}
+
+// Adopt imports discovered objects through their CREATE handlers and records them in
+// state as managed resources with provenance metadata. Objects already in state are
+// skipped, as are objects whose resource type has no handler implementing Import. A
+// failed import is reported in its outcome and does not stop the run. The relationship
+// edges of adopted objects become dependencies of their resources (see
+// LinkRelationships).
+func Adopt(ctx context.Context, registry *create.Registry, st *UniversalState, objects []*discover.DiscoveredObject, opts AdoptOptions) (*AdoptResult, error) {
+	if registry == nil || st == nil {
+		return nil, fmt.Errorf("adopt requires a create registry and a state")
+	}
+
+	sorted := append([]*discover.DiscoveredObject(nil), objects...)
+	sort.SliceStable(sorted, func(i, j int) bool { return adoptionID(sorted[i], "") < adoptionID(sorted[j], "") })
+
+	result := &AdoptResult{Outcomes: make([]AdoptOutcome, 0, len(sorted))}
+	for _, obj := range sorted {
+		if obj == nil {
+			continue
+		}
+		outcome := adoptOne(ctx, registry, st, obj, opts)
+		switch outcome.Status {
+		case AdoptStatusAdopted, AdoptStatusWouldAdopt:
+			result.Adopted++
+		case AdoptStatusFailed:
+			result.Failed++
+		default:
+			result.Skipped++
+		}
+		result.Outcomes = append(result.Outcomes, outcome)
+	}
+	if !opts.DryRun {
+		if err := LinkRelationships(st, sorted, result.Outcomes, opts.Dependencies); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// LinkRelationships turns the relationship edges of the objects adopted in outcomes
+// into dependencies between their state resources, so that imported estates are
+// ordered correctly without hand-written depends_on. A target is resolved to the
+// resource adopted from it in the same run, or to the state resource with its ID.
+// Only resources adopted in this run gain dependencies; an inbound edge whose dependent
+// is not among them is ignored, and an unresolved target is reported as a warning of
+// the outcome. When deps is set, the adopted resources and their new dependencies are
+// also added to it.
+func LinkRelationships(st *UniversalState, objects []*discover.DiscoveredObject, outcomes []AdoptOutcome, deps *DependencyManager) error {
+	resourceIDs := make(map[string]string, len(outcomes))
+	adopted := make(map[string]int, len(outcomes))
+	for i, outcome := range outcomes {
+		switch outcome.Status {
+		case AdoptStatusAdopted:
+			adopted[outcome.ResourceID] = i
+			fallthrough
+		case AdoptStatusAlreadyManaged:
+			if outcome.DiscoveredID != "" {
+				resourceIDs[outcome.DiscoveredID] = outcome.ResourceID
+			}
+		}
+	}
+	resolve := func(discoveredID string) (string, bool) {
+		if id, ok := resourceIDs[discoveredID]; ok {
+			return id, true
+		}
+		_, ok := st.GetResource(discoveredID)
+		return discoveredID, ok
+	}
+
+	if deps != nil {
+		for _, id := range sortedKeys(adopted) {
+			if r, ok := st.GetResource(id); ok {
+				deps.AddResource(r.Address())
+			}
+		}
+	}
+	for _, obj := range objects {
+		if obj == nil {
+			continue
+		}
+		self, ok := resourceIDs[obj.ID]
+		if !ok {
+			continue
+		}
+		for _, rel := range obj.Relationships {
+			target, ok := resolve(rel.TargetID)
+			if !ok {
+				if i, adoptedNow := adopted[self]; adoptedNow {
+					outcomes[i].Warnings = append(outcomes[i].Warnings,
+						fmt.Sprintf("%s relationship target %s is not managed in this state", rel.Type, rel.TargetID))
+				}
+				continue
+			}
+			from, to := self, target
+			if rel.Inbound {
+				from, to = target, self
+			}
+			i, adoptedNow := adopted[from]
+			if !adoptedNow || from == to {
+				continue
+			}
+			if err := linkDependency(st, from, to, deps); err != nil {
+				return err
+			}
+			if !containsString(outcomes[i].Dependencies, to) {
+				outcomes[i].Dependencies = append(outcomes[i].Dependencies, to)
+			}
+		}
+	}
+	return nil
+}
+
+// linkDependency records that resource from depends on resource to
+func linkDependency(st *UniversalState, from, to string, deps *DependencyManager) error {
+	dependent, ok := st.GetResource(from)
+	if !ok {
+		return nil
+	}
+	dependency, ok := st.GetResource(to)
+	if !ok {
+		return nil
+	}
+	dependent.AddDependency(to)
+	if deps == nil {
+		return nil
+	}
+	deps.AddResource(dependency.Address())
+	return deps.AddDependency(types.Dependency{From: dependent.Address(), To: dependency.Address()})
+}
+
+func adoptOne(ctx context.Context, registry *create.Registry, st *UniversalState, obj *discover.DiscoveredObject, opts AdoptOptions) AdoptOutcome {
+	outcome, importer := prepareAdoption(registry, st, obj, opts)
+	if importer == nil {
+		return outcome
+	}
+	resp, outcome := importAdoption(ctx, importer, obj, outcome)
+	if resp != nil {
+		recordAdoption(st, obj, resp, &outcome, opts)
+	}
+	return outcome
+}
+
+// prepareAdoption resolves the resource type and importer of a discovered object. A nil
+// importer means the returned outcome is final.
+func prepareAdoption(registry *create.Registry, st *UniversalState, obj *discover.DiscoveredObject, opts AdoptOptions) (AdoptOutcome, Importer) {
+	resourceType := obj.Type
+	if mapped, ok := opts.TypeMapping[obj.Type]; ok {
+		resourceType = mapped
+	}
+	outcome := AdoptOutcome{
+		DiscoveredID: obj.ID,
+		ResourceType: resourceType,
+		ResourceID:   adoptionID(obj, resourceType),
+		Name:         obj.Name,
+	}
+
+	if _, exists := st.GetResource(outcome.ResourceID); exists {
+		outcome.Status = AdoptStatusAlreadyManaged
+		return outcome, nil
+	}
+	handler, ok := registry.GetHandler(resourceType)
+	if !ok {
+		outcome.Status = AdoptStatusUnsupported
+		outcome.Error = fmt.Sprintf("no handler registered for resource type %s", resourceType)
+		return outcome, nil
+	}
+	importer, ok := handler.(Importer)
+	if !ok {
+		outcome.Status = AdoptStatusUnsupported
+		outcome.Error = fmt.Sprintf("handler for %s does not support import", resourceType)
+		return outcome, nil
+	}
+	if opts.DryRun {
+		outcome.Status = AdoptStatusWouldAdopt
+		return outcome, nil
+	}
+	return outcome, importer
+}
+
+// importAdoption imports the object through its handler without touching state, so
+// imports can run concurrently
+func importAdoption(ctx context.Context, importer Importer, obj *discover.DiscoveredObject, outcome AdoptOutcome) (*create.ImportResponse, AdoptOutcome) {
+	resp, err := importer.Import(ctx, &create.ImportRequest{
+		ObjectType:   outcome.ResourceType,
+		ID:           obj.ID,
+		Name:         obj.Name,
+		ImportConfig: obj.Properties,
+	})
+	if err != nil {
+		outcome.Status = AdoptStatusFailed
+		outcome.Error = err.Error()
+		return nil, outcome
+	}
+	for _, w := range resp.Warnings {
+		outcome.Warnings = append(outcome.Warnings, w.String())
+	}
+	return resp, outcome
+}
+
+// recordAdoption writes an imported object into state with its provenance
+func recordAdoption(st *UniversalState, obj *discover.DiscoveredObject, resp *create.ImportResponse, outcome *AdoptOutcome, opts AdoptOptions) {
+	provenance := AdoptionProvenance{
+		DiscoveredID:   obj.ID,
+		DiscoveredType: obj.Type,
+		DiscoveredAt:   obj.Discovered,
+		AdoptedAt:      clock.Now().UTC(),
+		AdoptedBy:      opts.AdoptedBy,
+	}
+	if obj.Source != nil {
+		provenance.SourceSystem = obj.Source.System
+		provenance.SourceLocation = obj.Source.Location
+	}
+
+	resource := NewUniversalResource(outcome.ResourceID, outcome.ResourceType, obj.Name, st.ProviderType, st.ProviderID)
+	resource.Status = ResourceStatusActive
+	if resp.State != nil {
+		resource.Data = resp.State
+	}
+	for _, dep := range resp.Dependencies {
+		resource.AddDependency(dep)
+	}
+	resource.Metadata[AdoptionMetadataKey] = provenance
+	if resp.Config != nil {
+		resource.Metadata["config"] = resp.Config
+	}
+	resource.ChangeInfo = &ResourceChangeInfo{
+		ChangeType:   ChangeTypeCreate,
+		After:        resource.Data,
+		ChangeReason: "adopted from discovery",
+		ChangedBy:    opts.AdoptedBy,
+		ChangedAt:    provenance.AdoptedAt,
+	}
+	st.AddResource(resource)
+
+	outcome.Status = AdoptStatusAdopted
+}
+
+// adoptionID is the state ID of an adopted object: its discovered ID, or its relative
+// resource address when discovery did not assign one
+func adoptionID(obj *discover.DiscoveredObject, resourceType string) string {
+	if obj.ID != "" {
+		return obj.ID
+	}
+	if resourceType == "" {
+		resourceType = obj.Type
+	}
+	return types.Address{Type: resourceType, Name: obj.Name}.String()
+}
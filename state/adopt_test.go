@@ -0,0 +1,138 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+	"github.com/schemabounce/kolumn/sdk/create"
+	"github.com/schemabounce/kolumn/sdk/discover"
+)
+
+type importingHandler struct {
+	*create.AdvancedHandler
+	fail map[string]bool
+}
+
+func (h *importingHandler) Import(ctx context.Context, req *create.ImportRequest) (*create.ImportResponse, error) {
+	if h.fail[req.ID] {
+		return nil, fmt.Errorf("permission denied")
+	}
+	return &create.ImportResponse{
+		State:  map[string]interface{}{"name": req.Name, "columns": req.ImportConfig["columns"]},
+		Config: map[string]interface{}{"name": req.Name},
+	}, nil
+}
+
+// TestAdopt validates discovered objects are imported into state with provenance
+func TestAdopt(t *testing.T) {
+	registry := create.NewRegistry()
+	handler := &importingHandler{AdvancedHandler: create.NewAdvancedHandler("postgres_table"), fail: map[string]bool{"db.bad": true}}
+	if err := registry.RegisterHandler("postgres_table", handler, &core.ObjectType{Name: "postgres_table", Type: core.CREATE}); err != nil {
+		t.Fatal(err)
+	}
+
+	st := NewUniversalState("pg-main", "postgres")
+	st.AddResource(NewUniversalResource("db.users", "postgres_table", "users", "postgres", "pg-main"))
+
+	objects := []*discover.DiscoveredObject{
+		{ID: "db.orders", Name: "orders", Type: "table", Discovered: "2026-10-01T00:00:00Z",
+			Properties: map[string]interface{}{"columns": []interface{}{"id"}},
+			Source:     &discover.Source{System: "postgres", Location: "db.public"}},
+		{ID: "db.users", Name: "users", Type: "table"},
+		{ID: "db.bad", Name: "bad", Type: "table"},
+		{ID: "db.v", Name: "v", Type: "view"},
+	}
+
+	result, err := Adopt(context.Background(), registry, st, objects, AdoptOptions{
+		TypeMapping: map[string]string{"table": "postgres_table"},
+		AdoptedBy:   "alice",
+	})
+	if err != nil {
+		t.Fatalf("Adopt: %v", err)
+	}
+	if result.Adopted != 1 || result.Skipped != 2 || result.Failed != 1 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	adopted, ok := st.GetResource("db.orders")
+	if !ok {
+		t.Fatal("db.orders not recorded in state")
+	}
+	if adopted.Status != ResourceStatusActive || adopted.Data["name"] != "orders" {
+		t.Errorf("unexpected resource: %+v", adopted)
+	}
+	provenance, ok := adopted.Metadata[AdoptionMetadataKey].(AdoptionProvenance)
+	if !ok || provenance.SourceLocation != "db.public" || provenance.DiscoveredType != "table" || provenance.AdoptedBy != "alice" {
+		t.Errorf("unexpected provenance: %+v", adopted.Metadata[AdoptionMetadataKey])
+	}
+
+	dry, _ := Adopt(context.Background(), registry, NewUniversalState("pg-main", "postgres"), objects[:1], AdoptOptions{
+		TypeMapping: map[string]string{"table": "postgres_table"},
+		DryRun:      true,
+	})
+	if dry.Outcomes[0].Status != AdoptStatusWouldAdopt {
+		t.Errorf("dry run should not import: %+v", dry.Outcomes[0])
+	}
+}
+
+// TestAdoptLinksRelationships validates relationship edges become state dependencies
+func TestAdoptLinksRelationships(t *testing.T) {
+	registry := create.NewRegistry()
+	for _, resourceType := range []string{"postgres_table", "postgres_view"} {
+		handler := &importingHandler{AdvancedHandler: create.NewAdvancedHandler(resourceType)}
+		if err := registry.RegisterHandler(resourceType, handler, &core.ObjectType{Name: resourceType, Type: core.CREATE}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	st := NewUniversalState("pg-main", "postgres")
+	st.AddResource(NewUniversalResource("db.customers", "postgres_table", "customers", "postgres", "pg-main"))
+
+	objects := []*discover.DiscoveredObject{
+		{ID: "db.orders", Name: "orders", Type: "table", Relationships: []*discover.ObjectRelationship{
+			{Type: discover.RelationshipForeignKey, TargetID: "db.users"},
+			{Type: discover.RelationshipForeignKey, TargetID: "db.customers"},
+			{Type: discover.RelationshipForeignKey, TargetID: "db.missing"},
+		}},
+		{ID: "db.users", Name: "users", Type: "table", Relationships: []*discover.ObjectRelationship{
+			{Type: discover.RelationshipDependsOn, TargetID: "db.v_users", Inbound: true},
+		}},
+		{ID: "db.v_users", Name: "v_users", Type: "view"},
+	}
+
+	deps := NewDependencyManager("pg-main")
+	result, err := Adopt(context.Background(), registry, st, objects, AdoptOptions{
+		TypeMapping:  map[string]string{"table": "postgres_table", "view": "postgres_view"},
+		Dependencies: deps,
+	})
+	if err != nil {
+		t.Fatalf("Adopt: %v", err)
+	}
+	if result.Adopted != 3 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	orders, _ := st.GetResource("db.orders")
+	if fmt.Sprint(orders.Dependencies) != "[db.users db.customers]" {
+		t.Errorf("unexpected orders dependencies: %v", orders.Dependencies)
+	}
+	view, _ := st.GetResource("db.v_users")
+	if fmt.Sprint(view.Dependencies) != "[db.users]" {
+		t.Errorf("unexpected view dependencies: %v", view.Dependencies)
+	}
+	for _, outcome := range result.Outcomes {
+		if outcome.DiscoveredID == "db.orders" && (len(outcome.Warnings) != 1 || len(outcome.Dependencies) != 2) {
+			t.Errorf("unexpected orders outcome: %+v", outcome)
+		}
+	}
+
+	order, err := deps.FindExecutionOrder()
+	if err != nil {
+		t.Fatalf("FindExecutionOrder: %v", err)
+	}
+	if fmt.Sprint(order) != "[[postgres.postgres_table.customers postgres.postgres_table.users] [postgres.postgres_table.orders postgres.postgres_view.v_users]]" {
+		t.Errorf("unexpected execution order: %v", order)
+	}
+}
@@ -0,0 +1,35 @@
+// Package state provides anonymization of state exports for sharing diagnostics
+package state
+
+import (
+	"encoding/json"
+
+	"github.com/schemabounce/kolumn/sdk/helpers/anonymize"
+)
+
+// stateIdentifierMaps are the fields of a state export keyed by resource IDs, stack
+// names and operation keys rather than field names
+var stateIdentifierMaps = []string{"resources", "dependencies", "stacks", "operations"}
+
+// AnonymizeState returns a copy of a state whose resource IDs, names, data, outputs and
+// metadata are replaced by the anonymizer's stable pseudonyms. Resource types, provider
+// types, statuses, timestamps and versions are kept, and a resource ID is replaced by
+// the same pseudonym wherever it appears, so dependencies and references still resolve
+// in the copy. Lock holders and backups are pseudonymized like any other value.
+func AnonymizeState(st *UniversalState, a *anonymize.Anonymizer) (*UniversalState, error) {
+	if st == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(st)
+	if err != nil {
+		return nil, err
+	}
+	if data, err = a.JSON(data, stateIdentifierMaps...); err != nil {
+		return nil, err
+	}
+	var out UniversalState
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
@@ -0,0 +1,59 @@
+package state
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/schemabounce/kolumn/sdk/helpers/anonymize"
+)
+
+// TestAnonymizeState validates identifiers are replaced consistently and the state still resolves
+func TestAnonymizeState(t *testing.T) {
+	st := NewUniversalState("customers", "postgres")
+	schema := NewUniversalResource("s1", "schema", "billing", "postgres", "customers")
+	table := NewUniversalResource("t1", "table", "invoices", "postgres", "customers")
+	table.Data = map[string]interface{}{"schema": "${postgres.schema.billing.name}", "comment": "Invoices for ACME Corp"}
+	table.AddDependency("s1")
+	st.AddResource(schema)
+	st.AddResource(table)
+	st.Dependencies = map[string][]string{"t1": {"s1"}}
+
+	out, err := AnonymizeState(st, anonymize.New(anonymize.Options{}))
+	if err != nil {
+		t.Fatalf("AnonymizeState: %v", err)
+	}
+	data, _ := json.Marshal(out)
+	for _, secret := range []string{"billing", "invoices", "ACME", "customers"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("%q leaked: %s", secret, data)
+		}
+	}
+	if len(out.Resources) != 2 || !out.CreatedAt.Equal(st.CreatedAt) {
+		t.Fatalf("structure not kept: %s", data)
+	}
+
+	var anonTable *UniversalResource
+	for _, r := range out.Resources {
+		if r.Type == "table" {
+			anonTable = r
+		}
+	}
+	if anonTable == nil || anonTable.ProviderType != "postgres" || anonTable.Status != table.Status {
+		t.Fatalf("resource types and statuses should be kept: %s", data)
+	}
+	if _, ok := out.Resources[anonTable.ID]; !ok {
+		t.Error("resources are not keyed by their anonymized ID")
+	}
+	if deps := out.Dependencies[anonTable.ID]; len(deps) != 1 || deps[0] != anonTable.Dependencies[0] {
+		t.Errorf("dependencies do not match: %v vs %v", out.Dependencies, anonTable.Dependencies)
+	}
+	m, err := NewDependencyManagerFromState("anon", out)
+	if err != nil {
+		t.Fatalf("anonymized dependencies do not resolve: %v", err)
+	}
+	refs := ConfigReferences(anonTable.Data)
+	if len(refs) != 1 || len(m.ExplicitDependencies(anonTable.Address(), anonTable.Data)) != 0 {
+		t.Errorf("reference to the schema should still be implied: %v", refs)
+	}
+}
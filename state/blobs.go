@@ -0,0 +1,240 @@
+// Package state provides offloading of large state values to external blob storage
+package state
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// DefaultBlobThreshold is the encoded size above which a resource field is offloaded
+const DefaultBlobThreshold = 256 << 10
+
+// BlobRefKey marks an offloaded value in resource data. The value is replaced by
+//
+//	{"$kolumn_blob": "sha256:<hex>", "size": <bytes>}
+const BlobRefKey = "$kolumn_blob"
+
+// ErrBlobNotFound is returned by BlobStore.GetBlob for unknown digests
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore keeps large state values outside the state document. Blobs are content
+// addressed: the digest is "sha256:" followed by the hex SHA-256 of the data, so
+// storing the same value twice is harmless.
+type BlobStore interface {
+	PutBlob(ctx context.Context, digest string, data []byte) error
+	GetBlob(ctx context.Context, digest string) ([]byte, error)
+}
+
+// MemoryBlobStore is an in-process BlobStore, mainly for tests
+type MemoryBlobStore struct {
+	mu    sync.RWMutex
+	blobs map[string][]byte
+}
+
+// NewMemoryBlobStore creates an empty in-memory blob store
+func NewMemoryBlobStore() *MemoryBlobStore {
+	return &MemoryBlobStore{blobs: make(map[string][]byte)}
+}
+
+// PutBlob implements BlobStore
+func (s *MemoryBlobStore) PutBlob(ctx context.Context, digest string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blobs[digest] = append([]byte(nil), data...)
+	return nil
+}
+
+// GetBlob implements BlobStore
+func (s *MemoryBlobStore) GetBlob(ctx context.Context, digest string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	data, ok := s.blobs[digest]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrBlobNotFound, digest)
+	}
+	return append([]byte(nil), data...), nil
+}
+
+// Len returns the number of stored blobs
+func (s *MemoryBlobStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.blobs)
+}
+
+// DirBlobStore stores blobs as files named by digest under a directory
+type DirBlobStore struct {
+	dir string
+}
+
+// NewDirBlobStore creates a blob store rooted at dir, creating it if needed
+func NewDirBlobStore(dir string) (*DirBlobStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create blob directory: %w", err)
+	}
+	return &DirBlobStore{dir: dir}, nil
+}
+
+func (s *DirBlobStore) path(digest string) (string, error) {
+	hexDigest, ok := strings.CutPrefix(digest, "sha256:")
+	if !ok || len(hexDigest) != sha256.Size*2 {
+		return "", fmt.Errorf("invalid blob digest %q", digest)
+	}
+	if _, err := hex.DecodeString(hexDigest); err != nil {
+		return "", fmt.Errorf("invalid blob digest %q", digest)
+	}
+	return filepath.Join(s.dir, hexDigest), nil
+}
+
+// PutBlob implements BlobStore; the file is written atomically
+func (s *DirBlobStore) PutBlob(ctx context.Context, digest string, data []byte) error {
+	path, err := s.path(digest)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.dir, ".blob-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// GetBlob implements BlobStore
+func (s *DirBlobStore) GetBlob(ctx context.Context, digest string) ([]byte, error) {
+	path, err := s.path(digest)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrBlobNotFound, digest)
+	}
+	return data, err
+}
+
+// blobDigest returns the content address of data
+func blobDigest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// blobRef returns the digest of an offloaded value, or false for ordinary values
+func blobRef(v interface{}) (string, bool) {
+	m, ok := v.(map[string]interface{})
+	if !ok || len(m) != 2 {
+		return "", false
+	}
+	digest, ok := m[BlobRefKey].(string)
+	return digest, ok
+}
+
+// OffloadBlobs returns a copy of st in which every top-level resource data field whose
+// JSON encoding exceeds threshold is stored in store and replaced by a reference.
+// threshold <= 0 uses DefaultBlobThreshold. st itself is not modified.
+func OffloadBlobs(ctx context.Context, store BlobStore, st *UniversalState, threshold int) (*UniversalState, error) {
+	if threshold <= 0 {
+		threshold = DefaultBlobThreshold
+	}
+	out := st.Clone()
+	for _, id := range sortedKeys(out.Resources) {
+		resource := out.Resources[id]
+		for _, field := range sortedKeys(resource.Data) {
+			value := resource.Data[field]
+			if _, ok := blobRef(value); ok {
+				continue
+			}
+			data, err := json.Marshal(value)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode %s.%s: %w", id, field, err)
+			}
+			if len(data) <= threshold {
+				continue
+			}
+			digest := blobDigest(data)
+			if err := store.PutBlob(ctx, digest, data); err != nil {
+				return nil, fmt.Errorf("failed to offload %s.%s: %w", id, field, err)
+			}
+			resource.Data[field] = map[string]interface{}{BlobRefKey: digest, "size": len(data)}
+		}
+	}
+	return out, nil
+}
+
+// RehydrateBlobs replaces every blob reference in st's resource data with the stored
+// value, verifying its digest. st is modified in place.
+func RehydrateBlobs(ctx context.Context, store BlobStore, st *UniversalState) error {
+	for _, id := range sortedKeys(st.Resources) {
+		resource := st.Resources[id]
+		for _, field := range sortedKeys(resource.Data) {
+			digest, ok := blobRef(resource.Data[field])
+			if !ok {
+				continue
+			}
+			data, err := store.GetBlob(ctx, digest)
+			if err != nil {
+				return fmt.Errorf("failed to rehydrate %s.%s: %w", id, field, err)
+			}
+			if blobDigest(data) != digest {
+				return fmt.Errorf("failed to rehydrate %s.%s: blob %s is corrupt", id, field, digest)
+			}
+			var value interface{}
+			if err := json.Unmarshal(data, &value); err != nil {
+				return fmt.Errorf("failed to decode %s.%s: %w", id, field, err)
+			}
+			resource.Data[field] = value
+		}
+	}
+	return nil
+}
+
+// BlobOffloadingBackend wraps a StateBackendProvider so large resource fields are
+// offloaded to a BlobStore on save and transparently rehydrated on load
+type BlobOffloadingBackend struct {
+	StateBackendProvider
+	store     BlobStore
+	threshold int
+}
+
+// NewBlobOffloadingBackend wraps backend; threshold <= 0 uses DefaultBlobThreshold
+func NewBlobOffloadingBackend(backend StateBackendProvider, store BlobStore, threshold int) *BlobOffloadingBackend {
+	return &BlobOffloadingBackend{StateBackendProvider: backend, store: store, threshold: threshold}
+}
+
+// LoadState loads state from the wrapped backend and rehydrates offloaded fields
+func (b *BlobOffloadingBackend) LoadState(ctx context.Context) (*UniversalState, error) {
+	st, err := b.StateBackendProvider.LoadState(ctx)
+	if err != nil || st == nil {
+		return st, err
+	}
+	// Rehydrate a copy so backends that cache the loaded state keep the slim form
+	st = st.Clone()
+	if err := RehydrateBlobs(ctx, b.store, st); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// SaveState offloads large fields and saves the slimmed state to the wrapped backend
+func (b *BlobOffloadingBackend) SaveState(ctx context.Context, st *UniversalState) error {
+	offloaded, err := OffloadBlobs(ctx, b.store, st, b.threshold)
+	if err != nil {
+		return err
+	}
+	return b.StateBackendProvider.SaveState(ctx, offloaded)
+}
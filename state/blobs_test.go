@@ -0,0 +1,74 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// savingBackend keeps whatever state was last saved
+type savingBackend struct {
+	memoryBackend
+}
+
+func (b *savingBackend) SaveState(ctx context.Context, state *UniversalState) error {
+	b.state = state
+	return nil
+}
+
+// TestBlobOffloadingBackend validates large fields are offloaded on save and restored on load
+func TestBlobOffloadingBackend(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewDirBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ddl := strings.Repeat("CREATE TABLE t (id bigint);\n", 100)
+	st := NewUniversalState("p1", "postgres")
+	resource := NewUniversalResource("r1", "schema", "app", "postgres", "p1")
+	resource.Data = map[string]interface{}{"name": "app", "ddl": ddl}
+	st.AddResource(resource)
+
+	inner := &savingBackend{}
+	backend := NewBlobOffloadingBackend(inner, store, 1024)
+	if err := backend.SaveState(ctx, st); err != nil {
+		t.Fatalf("SaveState: %v", err)
+	}
+
+	saved := inner.state.Resources["r1"].Data
+	ref := saved["ddl"]
+	digest, ok := blobRef(ref)
+	if !ok || saved["name"] != "app" {
+		t.Fatalf("expected ddl to be offloaded and name kept inline, got %v", saved)
+	}
+	if st.Resources["r1"].Data["ddl"] != ddl {
+		t.Fatal("SaveState must not modify the caller's state")
+	}
+
+	loaded, err := backend.LoadState(ctx)
+	if err != nil {
+		t.Fatalf("LoadState: %v", err)
+	}
+	if loaded.Resources["r1"].Data["ddl"] != ddl {
+		t.Fatal("offloaded field was not rehydrated")
+	}
+	if _, ok := blobRef(saved["ddl"]); !ok {
+		t.Fatal("LoadState must not rehydrate the backend's copy")
+	}
+
+	// Corrupt blobs and missing blobs are reported rather than silently loaded
+	path := filepath.Join(store.dir, strings.TrimPrefix(digest, "sha256:"))
+	if err := os.WriteFile(path, []byte(`"tampered"`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := backend.LoadState(ctx); err == nil || !strings.Contains(err.Error(), "corrupt") {
+		t.Fatalf("expected corrupt blob error, got %v", err)
+	}
+	if err := RehydrateBlobs(ctx, NewMemoryBlobStore(), inner.state); !errors.Is(err, ErrBlobNotFound) {
+		t.Fatalf("expected ErrBlobNotFound, got %v", err)
+	}
+}
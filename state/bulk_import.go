@@ -0,0 +1,306 @@
+// Package state provides bulk import of existing resources listed in inventory files
+package state
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/create"
+	"github.com/schemabounce/kolumn/sdk/discover"
+	"github.com/schemabounce/kolumn/sdk/helpers/par"
+)
+
+// Inventory file formats
+const (
+	InventoryFormatCSV  = "csv"
+	InventoryFormatJSON = "json"
+)
+
+// InventorySourceSystem is the provenance source system of resources imported in bulk
+const InventorySourceSystem = "inventory"
+
+// InventoryEntry is one resource listed in an inventory file. CSV files need a header
+// with resource_type, id and name columns; any other column is passed to the handler
+// as import config. JSON files hold an array of entries.
+type InventoryEntry struct {
+	Row          int                    `json:"-"` // line in a CSV file, position in a JSON array
+	ResourceType string                 `json:"resource_type"`
+	ID           string                 `json:"id"`
+	Name         string                 `json:"name"`
+	Config       map[string]interface{} `json:"config,omitempty"`
+}
+
+// inventoryColumns maps accepted CSV header names to entry fields
+var inventoryColumns = map[string]string{
+	"resource_type": "resource_type",
+	"type":          "resource_type",
+	"id":            "id",
+	"resource_id":   "id",
+	"name":          "name",
+}
+
+// LoadInventory reads an inventory file, choosing the format from its extension
+func LoadInventory(path string) ([]InventoryEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	entries, err := ReadInventory(f, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// ReadInventory parses an inventory in the given format
+func ReadInventory(r io.Reader, format string) ([]InventoryEntry, error) {
+	switch format {
+	case InventoryFormatCSV:
+		return readCSVInventory(r)
+	case InventoryFormatJSON:
+		var entries []InventoryEntry
+		if err := json.NewDecoder(r).Decode(&entries); err != nil {
+			return nil, fmt.Errorf("invalid JSON inventory: %w", err)
+		}
+		for i := range entries {
+			entries[i].Row = i + 1
+		}
+		return entries, nil
+	default:
+		return nil, fmt.Errorf("unsupported inventory format %q, use csv or json", format)
+	}
+}
+
+func readCSVInventory(r io.Reader) ([]InventoryEntry, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV inventory: %w", err)
+	}
+
+	fields := make([]string, len(header))
+	found := make(map[string]bool)
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(column))
+		if field, ok := inventoryColumns[column]; ok {
+			fields[i] = field
+			found[field] = true
+		} else {
+			fields[i] = column
+		}
+	}
+	if !found["resource_type"] || !found["id"] {
+		return nil, errors.New("CSV inventory header needs resource_type and id columns")
+	}
+
+	var entries []InventoryEntry
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV inventory: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+		entry := InventoryEntry{Row: line}
+		for i, value := range record {
+			switch fields[i] {
+			case "resource_type":
+				entry.ResourceType = value
+			case "id":
+				entry.ID = value
+			case "name":
+				entry.Name = value
+			default:
+				if value == "" {
+					continue
+				}
+				if entry.Config == nil {
+					entry.Config = make(map[string]interface{})
+				}
+				entry.Config[fields[i]] = value
+			}
+		}
+		entries = append(entries, entry)
+	}
+}
+
+// BulkImportOptions controls BulkImport
+type BulkImportOptions struct {
+	// Concurrency bounds how many handler imports run at once; zero uses par.DefaultLimit
+	Concurrency int
+
+	// DryRun reports what would be imported without importing or changing state
+	DryRun bool
+
+	// ImportedBy is recorded in provenance and operation history
+	ImportedBy string
+
+	// Source names the inventory, e.g. its file path, in provenance
+	Source string
+}
+
+// BulkImportRow is the result of importing one inventory entry
+type BulkImportRow struct {
+	Row int `json:"row"`
+	AdoptOutcome
+	Duration time.Duration `json:"duration,omitempty"`
+}
+
+// BulkImportResult reports every row of a bulk import in inventory order
+type BulkImportResult struct {
+	Rows     []BulkImportRow `json:"rows"`
+	Imported int             `json:"imported"`
+	Skipped  int             `json:"skipped"`
+	Failed   int             `json:"failed"`
+}
+
+// pendingImport is an inventory entry waiting for its handler import
+type pendingImport struct {
+	index    int
+	obj      *discover.DiscoveredObject
+	importer Importer
+}
+
+// BulkImport imports every inventory entry through its CREATE handler and writes the
+// imported resources into state. Handler imports run concurrently; state is only
+// changed once they finish, in inventory order. Entries already in state, listed
+// twice, or lacking a resource type or ID are skipped or failed in their row without
+// stopping the run.
+func BulkImport(ctx context.Context, registry *create.Registry, st *UniversalState, entries []InventoryEntry, opts BulkImportOptions) (*BulkImportResult, error) {
+	if registry == nil || st == nil {
+		return nil, fmt.Errorf("bulk import requires a create registry and a state")
+	}
+	adoptOpts := AdoptOptions{DryRun: opts.DryRun, AdoptedBy: opts.ImportedBy}
+
+	result := &BulkImportResult{Rows: make([]BulkImportRow, len(entries))}
+	seen := make(map[string]int)
+	var pending []pendingImport
+	for i, entry := range entries {
+		row := &result.Rows[i]
+		row.Row = entry.Row
+		obj := &discover.DiscoveredObject{
+			ID:         entry.ID,
+			Name:       entry.Name,
+			Type:       entry.ResourceType,
+			Properties: entry.Config,
+			Source:     &discover.Source{System: InventorySourceSystem, Location: inventoryLocation(opts.Source, entry.Row)},
+		}
+		row.AdoptOutcome = AdoptOutcome{DiscoveredID: entry.ID, ResourceType: entry.ResourceType, ResourceID: entry.ID, Name: entry.Name}
+
+		if entry.ResourceType == "" || entry.ID == "" {
+			row.Status = AdoptStatusFailed
+			row.Error = "resource_type and id are required"
+			continue
+		}
+		if first, ok := seen[entry.ResourceType+"/"+entry.ID]; ok {
+			row.Status = AdoptStatusAlreadyManaged
+			row.Error = fmt.Sprintf("duplicate of row %d", first)
+			continue
+		}
+		seen[entry.ResourceType+"/"+entry.ID] = entry.Row
+
+		outcome, importer := prepareAdoption(registry, st, obj, adoptOpts)
+		row.AdoptOutcome = outcome
+		if importer != nil {
+			pending = append(pending, pendingImport{index: i, obj: obj, importer: importer})
+		}
+	}
+
+	type importResult struct {
+		resp    *create.ImportResponse
+		outcome AdoptOutcome
+	}
+	imports := par.Map(ctx, pending, par.Options[pendingImport]{Limit: opts.Concurrency}, func(ctx context.Context, p pendingImport) (importResult, error) {
+		resp, outcome := importAdoption(ctx, p.importer, p.obj, result.Rows[p.index].AdoptOutcome)
+		return importResult{resp: resp, outcome: outcome}, nil
+	})
+	for _, imported := range imports {
+		p := pending[imported.Index]
+		row := &result.Rows[p.index]
+		row.Duration = imported.Duration
+		if imported.Err != nil {
+			// cancelled before the import started, or the handler panicked
+			row.Status = AdoptStatusFailed
+			row.Error = imported.Err.Error()
+			continue
+		}
+		row.AdoptOutcome = imported.Value.outcome
+		if imported.Value.resp == nil {
+			continue
+		}
+		recordAdoption(st, p.obj, imported.Value.resp, &row.AdoptOutcome, adoptOpts)
+		if err := st.RecordOperation(row.ResourceID, OperationRecord{
+			Operation: "import",
+			Actor:     opts.ImportedBy,
+			Duration:  imported.Duration,
+		}, 0); err != nil {
+			// the resource is imported; only its history entry is missing
+			row.Warnings = append(row.Warnings, fmt.Sprintf("failed to record import history: %v", err))
+		}
+	}
+
+	for _, row := range result.Rows {
+		switch row.Status {
+		case AdoptStatusAdopted, AdoptStatusWouldAdopt:
+			result.Imported++
+		case AdoptStatusFailed:
+			result.Failed++
+		default:
+			result.Skipped++
+		}
+	}
+	return result, nil
+}
+
+// inventoryLocation names an inventory row in provenance
+func inventoryLocation(source string, row int) string {
+	if source == "" {
+		return "row " + strconv.Itoa(row)
+	}
+	return source + ":" + strconv.Itoa(row)
+}
+
+// WriteCSV writes the per-row results as CSV, one line per inventory entry
+func (r *BulkImportResult) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"row", "resource_type", "id", "name", "status", "error", "warnings", "duration_ms"}); err != nil {
+		return fmt.Errorf("failed to write import report: %w", err)
+	}
+	for _, row := range r.Rows {
+		if err := writer.Write([]string{
+			strconv.Itoa(row.Row),
+			row.ResourceType,
+			row.ResourceID,
+			row.Name,
+			row.Status,
+			row.Error,
+			strings.Join(row.Warnings, "; "),
+			strconv.FormatInt(row.Duration.Milliseconds(), 10),
+		}); err != nil {
+			return fmt.Errorf("failed to write import report row %d: %w", row.Row, err)
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write import report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package state
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+	"github.com/schemabounce/kolumn/sdk/create"
+)
+
+// TestReadInventory validates CSV and JSON inventories and their row numbers
+func TestReadInventory(t *testing.T) {
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "estate.csv")
+	csvData := "Type,ID,Name,columns\npostgres_table,db.orders,orders,id\npostgres_table,db.items,items,\n"
+	if err := os.WriteFile(csvPath, []byte(csvData), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := LoadInventory(csvPath)
+	if err != nil {
+		t.Fatalf("LoadInventory: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Row != 2 || entries[0].ResourceType != "postgres_table" || entries[0].Config["columns"] != "id" {
+		t.Errorf("csv entries = %+v", entries)
+	}
+	if entries[1].Config != nil {
+		t.Errorf("empty columns should not become import config: %+v", entries[1].Config)
+	}
+
+	entries, err = ReadInventory(strings.NewReader(`[{"resource_type":"postgres_table","id":"db.a","name":"a"},{"resource_type":"view","id":"db.v"}]`), InventoryFormatJSON)
+	if err != nil || len(entries) != 2 || entries[1].Row != 2 || entries[1].ResourceType != "view" {
+		t.Errorf("json entries = %+v, %v", entries, err)
+	}
+
+	if _, err := ReadInventory(strings.NewReader("name\nx\n"), InventoryFormatCSV); err == nil {
+		t.Error("CSV without resource_type and id columns should be rejected")
+	}
+	if _, err := ReadInventory(strings.NewReader(""), "yaml"); err == nil {
+		t.Error("unsupported formats should be rejected")
+	}
+}
+
+// TestBulkImport validates per-row results and that imported resources land in state
+func TestBulkImport(t *testing.T) {
+	registry := create.NewRegistry()
+	handler := &importingHandler{AdvancedHandler: create.NewAdvancedHandler("postgres_table"), fail: map[string]bool{"db.bad": true}}
+	if err := registry.RegisterHandler("postgres_table", handler, &core.ObjectType{Name: "postgres_table", Type: core.CREATE}); err != nil {
+		t.Fatal(err)
+	}
+	st := NewUniversalState("pg-main", "postgres")
+	st.AddResource(NewUniversalResource("db.users", "postgres_table", "users", "postgres", "pg-main"))
+
+	entries := []InventoryEntry{
+		{Row: 2, ResourceType: "postgres_table", ID: "db.orders", Name: "orders", Config: map[string]interface{}{"columns": "id"}},
+		{Row: 3, ResourceType: "postgres_table", ID: "db.users", Name: "users"},
+		{Row: 4, ResourceType: "postgres_table", ID: "db.bad", Name: "bad"},
+		{Row: 5, ResourceType: "postgres_table", ID: "db.orders", Name: "orders"},
+		{Row: 6, ResourceType: "view", ID: "db.v", Name: "v"},
+		{Row: 7, ResourceType: "postgres_table", Name: "no_id"},
+		{Row: 8, ResourceType: "postgres_table", ID: "db.items", Name: "items"},
+	}
+	result, err := BulkImport(context.Background(), registry, st, entries, BulkImportOptions{Concurrency: 2, ImportedBy: "alice", Source: "estate.csv"})
+	if err != nil {
+		t.Fatalf("BulkImport: %v", err)
+	}
+	if result.Imported != 2 || result.Skipped != 3 || result.Failed != 2 {
+		t.Errorf("unexpected totals: %+v", result)
+	}
+	want := []string{AdoptStatusAdopted, AdoptStatusAlreadyManaged, AdoptStatusFailed, AdoptStatusAlreadyManaged, AdoptStatusUnsupported, AdoptStatusFailed, AdoptStatusAdopted}
+	for i, row := range result.Rows {
+		if row.Row != entries[i].Row || row.Status != want[i] {
+			t.Errorf("row %d = %s (%s), want %s", row.Row, row.Status, row.Error, want[i])
+		}
+	}
+	if result.Rows[3].Error != "duplicate of row 2" {
+		t.Errorf("duplicate row error = %q", result.Rows[3].Error)
+	}
+
+	orders, ok := st.GetResource("db.orders")
+	if !ok || orders.Data["columns"] != "id" {
+		t.Fatalf("db.orders not imported: %+v", orders)
+	}
+	provenance := orders.Metadata[AdoptionMetadataKey].(AdoptionProvenance)
+	if provenance.SourceSystem != InventorySourceSystem || provenance.SourceLocation != "estate.csv:2" {
+		t.Errorf("unexpected provenance %+v", provenance)
+	}
+	if last, ok := orders.LastModified(); !ok || last.Operation != "import" || last.Actor != "alice" {
+		t.Errorf("import not recorded in history: %+v", last)
+	}
+	if _, ok := st.GetResource("db.bad"); ok {
+		t.Error("failed imports should not be written to state")
+	}
+
+	var report bytes.Buffer
+	if err := result.WriteCSV(&report); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(report.String()), "\n")
+	if len(lines) != len(entries)+1 || !strings.HasPrefix(lines[3], "4,postgres_table,db.bad,bad,failed,permission denied") {
+		t.Errorf("unexpected report:\n%s", report.String())
+	}
+	if err := result.WriteCSV(failingWriter{}); err == nil {
+		t.Error("expected report write failures to be returned")
+	}
+}
+
+// failingWriter rejects every write
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) { return 0, errors.New("disk full") }
@@ -0,0 +1,13 @@
+// Package state provides the clock used for state timestamps
+package state
+
+import "github.com/schemabounce/kolumn/sdk/types"
+
+// clock supplies every timestamp recorded in state by this package
+var clock types.ClockVar
+
+// SetClock replaces the clock used by state, or restores the system clock when nil. It
+// returns a function restoring the previous clock; tests typically defer it.
+func SetClock(c types.Clock) (restore func()) {
+	return clock.Set(c)
+}
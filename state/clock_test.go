@@ -0,0 +1,25 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/types"
+)
+
+// TestSetClock validates state timestamps come from the installed clock
+func TestSetClock(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	defer SetClock(types.ClockFunc(func() time.Time { return now }))()
+
+	st := NewUniversalState("p1", "postgres")
+	if !st.CreatedAt.Equal(now) {
+		t.Fatalf("CreatedAt = %s, want %s", st.CreatedAt, now)
+	}
+
+	now = now.Add(time.Minute)
+	st.AddResource(NewUniversalResource("r1", "table", "users", "postgres", "p1"))
+	if !st.LastUpdated.Equal(now) || !st.Resources["r1"].CreatedAt.Equal(now) {
+		t.Fatalf("LastUpdated = %s, want %s", st.LastUpdated, now)
+	}
+}
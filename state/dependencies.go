@@ -0,0 +1,452 @@
+// Package state provides dependency ordering for resources within and across states
+package state
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/types"
+)
+
+// DependencyCycleError reports a dependency cycle; Cycle lists the nodes in order,
+// with the first node repeated at the end. Nodes in other states carry their state prefix.
+type DependencyCycleError struct {
+	Cycle []string
+}
+
+func (e *DependencyCycleError) Error() string {
+	return "dependency cycle detected: " + strings.Join(e.Cycle, " -> ")
+}
+
+// UnresolvedDependencyError lists external dependencies whose target could not be found
+type UnresolvedDependencyError struct {
+	Dependencies []types.Dependency
+	Reasons      []string
+}
+
+func (e *UnresolvedDependencyError) Error() string {
+	parts := make([]string, len(e.Dependencies))
+	for i, dep := range e.Dependencies {
+		parts[i] = dep.Target() + " (" + e.Reasons[i] + ")"
+	}
+	return "unresolved external dependencies: " + strings.Join(parts, ", ")
+}
+
+// DependencyManager tracks the resources of one state and the dependencies between them,
+// including dependencies on resources managed in other states
+type DependencyManager struct {
+	mu        sync.RWMutex
+	stateName string
+	nodes     map[string]types.Address
+	deps      map[string][]types.Dependency
+	protected map[string]bool
+	estimates map[string]time.Duration
+}
+
+// NewDependencyManager creates an empty dependency manager for the named state
+func NewDependencyManager(stateName string) *DependencyManager {
+	return &DependencyManager{
+		stateName: stateName,
+		nodes:     make(map[string]types.Address),
+		deps:      make(map[string][]types.Dependency),
+		protected: make(map[string]bool),
+	}
+}
+
+// NewDependencyManagerFromState builds a dependency manager from the resources of a
+// state. Each entry of a resource's Dependencies may be the ID of another resource in
+// the state, its address, or an external "state::address" target. Resources whose
+// metadata sets PreventDestroyMetadataKey are protected from destruction.
+func NewDependencyManagerFromState(stateName string, st *UniversalState) (*DependencyManager, error) {
+	m := NewDependencyManager(stateName)
+	if st == nil {
+		return m, nil
+	}
+	resources := st.ListResources()
+	sort.Slice(resources, func(i, j int) bool { return resources[i].ID < resources[j].ID })
+	for _, r := range resources {
+		m.AddResource(r.Address())
+		if prevent, _ := r.Metadata[PreventDestroyMetadataKey].(bool); prevent {
+			m.SetPreventDestroy(r.Address(), true)
+		}
+	}
+	for _, r := range resources {
+		for _, target := range r.Dependencies {
+			dep := types.Dependency{From: r.Address()}
+			if other, ok := st.GetResource(target); ok {
+				dep.To = other.Address()
+			} else {
+				stateRef, addr, err := types.ParseDependencyTarget(target)
+				if err != nil {
+					return nil, fmt.Errorf("resource %s: %w", r.ID, err)
+				}
+				dep.To, dep.State = addr, stateRef
+			}
+			if err := m.AddDependency(dep); err != nil {
+				return nil, fmt.Errorf("resource %s: %w", r.ID, err)
+			}
+		}
+	}
+	return m, nil
+}
+
+// StateName returns the name of the state the manager describes
+func (m *DependencyManager) StateName() string {
+	return m.stateName
+}
+
+// AddResource registers a resource of this state
+func (m *DependencyManager) AddResource(addr types.Address) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nodes[addr.String()] = addr
+}
+
+// AddDependency records a dependency. Its From resource is registered if needed; a
+// same-state target must already be registered, while an external target is only
+// checked when resolved. A dependency naming this manager's own state is treated as
+// a same-state dependency.
+func (m *DependencyManager) AddDependency(dep types.Dependency) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if dep.State == m.stateName {
+		dep.State = ""
+	}
+	from := dep.From.String()
+	if _, ok := m.nodes[from]; !ok {
+		m.nodes[from] = dep.From
+	}
+	if !dep.IsExternal() {
+		to, ok := m.lookup(dep.To)
+		if !ok {
+			return fmt.Errorf("dependency target %s is not a resource of state %q", dep.To, m.stateName)
+		}
+		dep.To = to
+	}
+	for _, existing := range m.deps[from] {
+		if existing.Target() == dep.Target() {
+			return nil
+		}
+	}
+	m.deps[from] = append(m.deps[from], dep)
+	return nil
+}
+
+// Resources returns the registered resource addresses in sorted order
+func (m *DependencyManager) Resources() []types.Address {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	keys := make([]string, 0, len(m.nodes))
+	for k := range m.nodes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	out := make([]types.Address, len(keys))
+	for i, k := range keys {
+		out[i] = m.nodes[k]
+	}
+	return out
+}
+
+// Dependencies returns the dependencies declared by a resource
+func (m *DependencyManager) Dependencies(addr types.Address) []types.Dependency {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]types.Dependency(nil), m.deps[addr.String()]...)
+}
+
+// ExternalDependencies returns every dependency on a resource in another state
+func (m *DependencyManager) ExternalDependencies() []types.Dependency {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var out []types.Dependency
+	for _, deps := range m.deps {
+		for _, dep := range deps {
+			if dep.IsExternal() {
+				out = append(out, dep)
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].String() < out[j].String() })
+	return out
+}
+
+// FindExecutionOrder returns the resources in creation order, grouped into batches
+// whose members have no dependencies on each other. External dependencies do not
+// affect ordering; they are expected to exist before this state is applied.
+func (m *DependencyManager) FindExecutionOrder() ([][]types.Address, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	nodes := make([]string, 0, len(m.nodes))
+	for key := range m.nodes {
+		nodes = append(nodes, key)
+	}
+	batches, err := topoBatches(nodes, m.localEdges())
+	if err != nil {
+		return nil, err
+	}
+	return m.addressBatches(batches), nil
+}
+
+// ResolveExternal loads every state referenced by an external dependency through its
+// backend and returns the target resources keyed by dependency target. Each backend is
+// loaded once. All missing states and resources are reported together.
+func (m *DependencyManager) ResolveExternal(ctx context.Context, backends map[string]StateBackendProvider) (map[string]*UniversalResource, error) {
+	external := m.ExternalDependencies()
+	loaded := make(map[string]*UniversalState)
+	resolved := make(map[string]*UniversalResource, len(external))
+	unresolved := &UnresolvedDependencyError{}
+
+	for _, dep := range external {
+		if _, done := resolved[dep.Target()]; done {
+			continue
+		}
+		st, ok := loaded[dep.State]
+		if !ok {
+			backend, found := backends[dep.State]
+			if !found {
+				unresolved.Dependencies = append(unresolved.Dependencies, dep)
+				unresolved.Reasons = append(unresolved.Reasons, "no backend for state")
+				continue
+			}
+			var err error
+			if st, err = backend.LoadState(ctx); err != nil {
+				return nil, NewStateBackendError("load state "+dep.State, backend.GetProviderID(), err, true)
+			}
+			loaded[dep.State] = st
+		}
+		resource := findResourceByAddress(st, dep.To)
+		if resource == nil {
+			unresolved.Dependencies = append(unresolved.Dependencies, dep)
+			unresolved.Reasons = append(unresolved.Reasons, "resource not found")
+			continue
+		}
+		resolved[dep.Target()] = resource
+	}
+
+	if len(unresolved.Dependencies) > 0 {
+		return resolved, unresolved
+	}
+	return resolved, nil
+}
+
+// DetectCrossStateCycles checks the combined dependency graph of several states for
+// cycles, including cycles that only appear when external dependencies are followed
+// into the states that own their targets
+func DetectCrossStateCycles(managers ...*DependencyManager) error {
+	edges := make(map[string][]string)
+	byState := make(map[string]*DependencyManager, len(managers))
+	for _, m := range managers {
+		byState[m.stateName] = m
+	}
+	for _, m := range managers {
+		m.mu.RLock()
+		for key := range m.nodes {
+			node := qualifiedNode(m.stateName, key)
+			if _, ok := edges[node]; !ok {
+				edges[node] = nil
+			}
+		}
+		for from, deps := range m.deps {
+			node := qualifiedNode(m.stateName, from)
+			for _, dep := range deps {
+				stateName, target := m.stateName, dep.To.String()
+				if dep.IsExternal() {
+					stateName = dep.State
+					if owner, ok := byState[stateName]; ok {
+						owner.mu.RLock()
+						if addr, found := owner.lookup(dep.To); found {
+							target = addr.String()
+						}
+						owner.mu.RUnlock()
+					}
+				}
+				edges[node] = append(edges[node], qualifiedNode(stateName, target))
+			}
+		}
+		m.mu.RUnlock()
+	}
+	if cycle := findCycle(edges); cycle != nil {
+		return &DependencyCycleError{Cycle: cycle}
+	}
+	return nil
+}
+
+// lookup finds a registered node by exact address, falling back to a unique match on
+// the relative address when addr has no provider. The caller must hold the lock.
+func (m *DependencyManager) lookup(addr types.Address) (types.Address, bool) {
+	if found, ok := m.nodes[addr.String()]; ok {
+		return found, true
+	}
+	if addr.Provider != "" {
+		return types.Address{}, false
+	}
+	var match types.Address
+	matches := 0
+	for _, node := range m.nodes {
+		if node.Relative().Equal(addr) {
+			match = node
+			matches++
+		}
+	}
+	return match, matches == 1
+}
+
+// localEdges maps each resource to the same-state resources it depends on. The caller
+// must hold the lock.
+func (m *DependencyManager) localEdges() map[string][]string {
+	edges := make(map[string][]string, len(m.deps))
+	for from, deps := range m.deps {
+		for _, dep := range deps {
+			if !dep.IsExternal() {
+				edges[from] = append(edges[from], dep.To.String())
+			}
+		}
+	}
+	return edges
+}
+
+// addressBatches converts batches of node keys to addresses. The caller must hold the
+// lock.
+func (m *DependencyManager) addressBatches(batches [][]string) [][]types.Address {
+	out := make([][]types.Address, len(batches))
+	for i, batch := range batches {
+		out[i] = make([]types.Address, len(batch))
+		for j, key := range batch {
+			out[i][j] = m.nodes[key]
+		}
+	}
+	return out
+}
+
+// topoBatches orders nodes so each comes after the nodes listed for it in after, grouping
+// nodes that become ready together into sorted batches. Entries of after that are not
+// in nodes are ignored. When nodes cannot all be ordered the cycle is reported.
+func topoBatches(nodes []string, after map[string][]string) ([][]string, error) {
+	pending := make(map[string]int, len(nodes))
+	for _, n := range nodes {
+		pending[n] = 0
+	}
+	dependents := make(map[string][]string)
+	for _, n := range nodes {
+		for _, before := range after[n] {
+			if _, ok := pending[before]; ok {
+				pending[n]++
+				dependents[before] = append(dependents[before], n)
+			}
+		}
+	}
+
+	var batches [][]string
+	for len(pending) > 0 {
+		var ready []string
+		for n, count := range pending {
+			if count == 0 {
+				ready = append(ready, n)
+			}
+		}
+		if len(ready) == 0 {
+			// every remaining node waits on another remaining node, so a cycle exists
+			stuck := make(map[string][]string, len(pending))
+			for n := range pending {
+				stuck[n] = nil
+				for _, before := range after[n] {
+					if _, ok := pending[before]; ok {
+						stuck[n] = append(stuck[n], before)
+					}
+				}
+			}
+			return nil, &DependencyCycleError{Cycle: findCycle(stuck)}
+		}
+		sort.Strings(ready)
+		for _, n := range ready {
+			delete(pending, n)
+			for _, dependent := range dependents[n] {
+				pending[dependent]--
+			}
+		}
+		batches = append(batches, ready)
+	}
+	return batches, nil
+}
+
+// findCycle returns the first cycle found by a depth-first walk in sorted node order
+func findCycle(edges map[string][]string) []string {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int, len(edges))
+	var stack []string
+	var cycle []string
+
+	var visit func(node string) bool
+	visit = func(node string) bool {
+		state[node] = visiting
+		stack = append(stack, node)
+		next := append([]string(nil), edges[node]...)
+		sort.Strings(next)
+		for _, n := range next {
+			switch state[n] {
+			case visiting:
+				for i, s := range stack {
+					if s == n {
+						cycle = append(append([]string(nil), stack[i:]...), n)
+						return true
+					}
+				}
+			case unvisited:
+				if visit(n) {
+					return true
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[node] = done
+		return false
+	}
+
+	nodes := make([]string, 0, len(edges))
+	for node := range edges {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	for _, node := range nodes {
+		if state[node] == unvisited && visit(node) {
+			return cycle
+		}
+	}
+	return nil
+}
+
+// findResourceByAddress returns the resource of a state matching addr, matching on the
+// relative address when addr has no provider
+func findResourceByAddress(st *UniversalState, addr types.Address) *UniversalResource {
+	if st == nil {
+		return nil
+	}
+	for _, r := range st.Resources {
+		if r == nil {
+			continue
+		}
+		have := r.Address()
+		if addr.Provider == "" {
+			have = have.Relative()
+		}
+		if have.Equal(addr.Resource()) {
+			return r
+		}
+	}
+	return nil
+}
+
+func qualifiedNode(stateName, addr string) string {
+	return stateName + types.StateSeparator + addr
+}
@@ -0,0 +1,254 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+	"github.com/schemabounce/kolumn/sdk/types"
+)
+
+// memoryBackend serves a fixed state through the backend interface
+type memoryBackend struct {
+	*BackendProviderHelper
+	state *UniversalState
+	loads int
+}
+
+func (b *memoryBackend) LoadState(ctx context.Context) (*UniversalState, error) {
+	b.loads++
+	return b.state, nil
+}
+func (b *memoryBackend) SaveState(ctx context.Context, state *UniversalState) error { return nil }
+func (b *memoryBackend) DeleteState(ctx context.Context) error                      { return nil }
+func (b *memoryBackend) StateExists(ctx context.Context) (bool, error)              { return true, nil }
+func (b *memoryBackend) ValidateConfig(ctx context.Context) error                   { return nil }
+func (b *memoryBackend) ValidateState(ctx context.Context, state *UniversalState) error {
+	return nil
+}
+func (b *memoryBackend) GetHealth(ctx context.Context) (*ProviderHealth, error) { return nil, nil }
+func (b *memoryBackend) CreateBackup(ctx context.Context, version string) error { return nil }
+func (b *memoryBackend) ListBackups(ctx context.Context) ([]string, error)      { return nil, nil }
+func (b *memoryBackend) RestoreFromBackup(ctx context.Context, version string) error {
+	return nil
+}
+
+// TestDependencyManagerExternal validates ordering and resolution of cross-state dependencies
+func TestDependencyManagerExternal(t *testing.T) {
+	app := NewUniversalState("app", "postgres")
+	schema := NewUniversalResource("s1", "schema", "app", "postgres", "app")
+	table := NewUniversalResource("t1", "table", "users", "postgres", "app")
+	table.AddDependency("s1")
+	table.AddDependency("network::aws.vpc.main")
+	table.AddDependency("network::aws.subnet.missing")
+	app.AddResource(schema)
+	app.AddResource(table)
+
+	m, err := NewDependencyManagerFromState("app", app)
+	if err != nil {
+		t.Fatalf("NewDependencyManagerFromState: %v", err)
+	}
+	order, err := m.FindExecutionOrder()
+	if err != nil {
+		t.Fatalf("FindExecutionOrder: %v", err)
+	}
+	if len(order) != 2 || order[0][0].String() != "postgres.schema.app" || order[1][0].String() != "postgres.table.users" {
+		t.Errorf("unexpected order: %v", order)
+	}
+	if ext := m.ExternalDependencies(); len(ext) != 2 {
+		t.Fatalf("expected 2 external dependencies, got %v", ext)
+	}
+
+	network := NewUniversalState("network", "aws")
+	network.AddResource(NewUniversalResource("vpc-1", "vpc", "main", "aws", "network"))
+	backend := &memoryBackend{BackendProviderHelper: NewBackendProviderHelper("network", "aws", false, true), state: network}
+
+	resolved, err := m.ResolveExternal(context.Background(), map[string]StateBackendProvider{"network": backend})
+	var unresolved *UnresolvedDependencyError
+	if !errors.As(err, &unresolved) || len(unresolved.Dependencies) != 1 || unresolved.Dependencies[0].To.Name != "missing" {
+		t.Errorf("expected missing subnet to be unresolved, got %v", err)
+	}
+	if r := resolved["network::aws.vpc.main"]; r == nil || r.ID != "vpc-1" {
+		t.Errorf("vpc not resolved: %v", resolved)
+	}
+	if backend.loads != 1 {
+		t.Errorf("backend loaded %d times, want 1", backend.loads)
+	}
+}
+
+// TestDetectCrossStateCycles validates cycles spanning states are reported
+func TestDetectCrossStateCycles(t *testing.T) {
+	vpc := types.NewAddress("aws", "vpc", "main")
+	db := types.NewAddress("postgres", "database", "app")
+
+	network := NewDependencyManager("network")
+	network.AddResource(vpc)
+	app := NewDependencyManager("app")
+	app.AddResource(db)
+	if err := app.AddDependency(types.Dependency{From: db, To: vpc.Relative(), State: "network"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := DetectCrossStateCycles(network, app); err != nil {
+		t.Fatalf("unexpected cycle: %v", err)
+	}
+
+	if err := network.AddDependency(types.Dependency{From: vpc, To: db, State: "app"}); err != nil {
+		t.Fatal(err)
+	}
+	var cycle *DependencyCycleError
+	if err := DetectCrossStateCycles(network, app); !errors.As(err, &cycle) || len(cycle.Cycle) != 3 {
+		t.Errorf("expected cross-state cycle, got %v", err)
+	}
+
+	local := NewDependencyManager("local")
+	a, b := types.NewAddress("", "t", "a"), types.NewAddress("", "t", "b")
+	local.AddResource(a)
+	local.AddResource(b)
+	_ = local.AddDependency(types.Dependency{From: a, To: b})
+	_ = local.AddDependency(types.Dependency{From: b, To: a})
+	if _, err := local.FindExecutionOrder(); !errors.As(err, &cycle) {
+		t.Errorf("expected cycle error, got %v", err)
+	}
+}
+
+// TestDestroyOrder validates reverse ordering, prevent_destroy and interleaved replacement
+func TestDestroyOrder(t *testing.T) {
+	schema := types.NewAddress("pg", "schema", "app")
+	table := types.NewAddress("pg", "table", "users")
+	view := types.NewAddress("pg", "view", "active_users")
+	other := types.NewAddress("pg", "table", "audit")
+
+	m := NewDependencyManager("app")
+	for _, addr := range []types.Address{schema, table, view, other} {
+		m.AddResource(addr)
+	}
+	_ = m.AddDependency(types.Dependency{From: table, To: schema})
+	_ = m.AddDependency(types.Dependency{From: view, To: table})
+	_ = m.AddDependency(types.Dependency{From: other, To: schema})
+
+	order, err := m.FindDestroyOrder(table)
+	if err != nil {
+		t.Fatalf("FindDestroyOrder: %v", err)
+	}
+	if len(order) != 2 || !order[0][0].Equal(view) || !order[1][0].Equal(table) {
+		t.Errorf("unexpected destroy order: %v", order)
+	}
+
+	full, err := m.FindDestroyOrder()
+	if err != nil {
+		t.Fatalf("FindDestroyOrder: %v", err)
+	}
+	if last := full[len(full)-1]; len(last) != 1 || !last[0].Equal(schema) {
+		t.Errorf("schema should be destroyed last: %v", full)
+	}
+
+	replace, err := m.FindReplacementOrder(schema, table)
+	if err != nil {
+		t.Fatalf("FindReplacementOrder: %v", err)
+	}
+	var steps []string
+	for _, b := range replace {
+		for _, r := range b.Resources {
+			steps = append(steps, b.Action+" "+r.String())
+		}
+	}
+	want := []string{"destroy pg.table.users", "destroy pg.schema.app", "create pg.schema.app", "create pg.table.users"}
+	if len(steps) != len(want) {
+		t.Fatalf("unexpected replacement steps: %v", steps)
+	}
+	for i := range want {
+		if steps[i] != want[i] {
+			t.Errorf("step %d = %q, want %q", i, steps[i], want[i])
+		}
+	}
+
+	m.SetPreventDestroy(schema, true)
+	var prevented *PreventDestroyError
+	if _, err := m.FindDestroyOrder(); !errors.As(err, &prevented) || !prevented.Resources[0].Equal(schema) {
+		t.Errorf("expected prevent_destroy error, got %v", err)
+	}
+	if _, err := m.FindDestroyOrder(view); err != nil {
+		t.Errorf("destroying an unprotected leaf should succeed: %v", err)
+	}
+}
+
+// TestBuildExecutionPlan validates the critical path and parallel duration estimate
+func TestBuildExecutionPlan(t *testing.T) {
+	schema := types.NewAddress("pg", "schema", "app")
+	users := types.NewAddress("pg", "table", "users")
+	orders := types.NewAddress("pg", "table", "orders")
+	report := types.NewAddress("pg", "view", "report")
+
+	m := NewDependencyManager("app")
+	for _, addr := range []types.Address{schema, users, orders, report} {
+		m.AddResource(addr)
+	}
+	_ = m.AddDependency(types.Dependency{From: users, To: schema})
+	_ = m.AddDependency(types.Dependency{From: orders, To: schema})
+	_ = m.AddDependency(types.Dependency{From: report, To: users})
+
+	m.SetEstimatedDuration(schema, time.Second)
+	m.SetEstimatedDuration(users, 2*time.Second)
+	m.SetEstimateFromPlan(orders, &core.PlanResponse{Changes: []core.PlannedChange{
+		{Action: "create", EstimatedTime: 2 * time.Second},
+		{Action: "create", EstimatedTime: 3 * time.Second},
+	}})
+	m.SetEstimatedDuration(report, time.Second)
+
+	plan, err := m.BuildExecutionPlan(0)
+	if err != nil {
+		t.Fatalf("BuildExecutionPlan: %v", err)
+	}
+	if plan.CriticalPathDuration != 6*time.Second || len(plan.CriticalPath) != 2 || !plan.CriticalPath[1].Equal(orders) {
+		t.Errorf("unexpected critical path %v (%s)", plan.CriticalPath, plan.CriticalPathDuration)
+	}
+	if plan.EstimatedDuration != 6*time.Second || plan.TotalWork != 9*time.Second {
+		t.Errorf("unexpected estimates: %+v", plan)
+	}
+
+	serial, _ := m.BuildExecutionPlan(1)
+	if serial.EstimatedDuration != 9*time.Second {
+		t.Errorf("serial estimate = %s, want 9s", serial.EstimatedDuration)
+	}
+}
+
+// TestWhatIf validates hypothetical changes are analyzed without modifying the graph
+func TestWhatIf(t *testing.T) {
+	schema := types.NewAddress("pg", "schema", "app")
+	table := types.NewAddress("pg", "table", "users")
+	view := types.NewAddress("pg", "view", "active_users")
+
+	m := NewDependencyManager("app")
+	for _, addr := range []types.Address{schema, table, view} {
+		m.AddResource(addr)
+	}
+	_ = m.AddDependency(types.Dependency{From: table, To: schema})
+	_ = m.AddDependency(types.Dependency{From: view, To: table})
+
+	impact, err := m.GetImpactAnalysis(schema)
+	if err != nil || len(impact.DirectDependents) != 1 || len(impact.TransitiveDependents) != 2 {
+		t.Fatalf("unexpected impact: %+v, %v", impact, err)
+	}
+
+	removal := m.WhatIf(HypotheticalChange{RemoveResources: []types.Address{table}})
+	if removal.Safe() || len(removal.BrokenDependencies) != 1 || !removal.BrokenDependencies[0].From.Equal(view) {
+		t.Errorf("expected the view's dependency to break: %+v", removal)
+	}
+
+	cyclic := m.WhatIf(HypotheticalChange{
+		AddDependencies: []types.Dependency{{From: schema, To: view}},
+		ModifyResources: []types.Address{schema},
+	})
+	if len(cyclic.NewCycles) != 1 || len(cyclic.NewCycles[0]) != 4 || cyclic.ExecutionOrder != nil {
+		t.Errorf("expected a new cycle: %+v", cyclic)
+	}
+
+	if _, err := m.FindExecutionOrder(); err != nil {
+		t.Errorf("what-if modified the manager: %v", err)
+	}
+	if deps := m.Dependencies(schema); len(deps) != 0 {
+		t.Errorf("what-if added dependencies: %v", deps)
+	}
+}
@@ -0,0 +1,136 @@
+// Package state provides explicit depends_on clauses for generated configuration
+package state
+
+import (
+	"github.com/schemabounce/kolumn/sdk/helpers/hcl"
+	"github.com/schemabounce/kolumn/sdk/types"
+)
+
+// ConfigReferences returns the references interpolated in the string values of a
+// resource configuration, e.g. "${postgres.table.users.id}", in sorted order.
+// Interpolations that are not canonical references are ignored.
+func ConfigReferences(config map[string]interface{}) []types.Reference {
+	seen := make(map[string]types.Reference)
+	var walk func(value interface{})
+	walk = func(value interface{}) {
+		switch v := value.(type) {
+		case string:
+			for _, expr := range hcl.FindInterpolations(v) {
+				if ref, err := types.ParseReference(expr); err == nil {
+					seen[ref.String()] = ref
+				}
+			}
+		case []interface{}:
+			for _, item := range v {
+				walk(item)
+			}
+		case []string:
+			for _, item := range v {
+				walk(item)
+			}
+		case map[string]interface{}:
+			for _, item := range v {
+				walk(item)
+			}
+		}
+	}
+	walk(config)
+
+	refs := make([]types.Reference, 0, len(seen))
+	for _, key := range sortedKeys(seen) {
+		refs = append(refs, seen[key])
+	}
+	return refs
+}
+
+// ExplicitDependencies returns the same-state dependencies of a resource that its
+// generated configuration must declare in depends_on. Dependencies already implied by
+// the references in config are left out: a referenced resource, and every resource
+// reachable from a referenced resource or from another dependency, is ordered before
+// addr without being listed. External dependencies are never listed; they do not affect
+// ordering within the state (see FindExecutionOrder).
+func (m *DependencyManager) ExplicitDependencies(addr types.Address, config map[string]interface{}) []types.Address {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	found, ok := m.lookup(addr)
+	if !ok {
+		return nil
+	}
+	return m.explicitDependencies(found.String(), config, m.localEdges())
+}
+
+// DependsOnClauses returns the depends_on entries of every resource of the state, keyed
+// by canonical address, for the configurations generated for them. configs holds the
+// configuration of each resource by canonical address; resources without one are
+// treated as having no references. Resources needing no depends_on are omitted.
+func (m *DependencyManager) DependsOnClauses(configs map[string]map[string]interface{}) map[string][]string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	edges := m.localEdges()
+	clauses := make(map[string][]string)
+	for key := range m.nodes {
+		deps := m.explicitDependencies(key, configs[key], edges)
+		if len(deps) == 0 {
+			continue
+		}
+		targets := make([]string, len(deps))
+		for i, dep := range deps {
+			targets[i] = dep.String()
+		}
+		clauses[key] = targets
+	}
+	return clauses
+}
+
+// explicitDependencies computes the transitive reduction of a node's dependencies,
+// treating references as edges. The caller must hold the lock.
+func (m *DependencyManager) explicitDependencies(key string, config map[string]interface{}, edges map[string][]string) []types.Address {
+	direct := stringSet(edges[key])
+	if len(direct) == 0 {
+		return nil
+	}
+
+	referenced := make(map[string]bool)
+	for _, ref := range ConfigReferences(config) {
+		if ref.State != "" && ref.State != m.stateName {
+			continue
+		}
+		if target, ok := m.lookup(ref.Address); ok {
+			referenced[target.String()] = true
+		} else if target, ok := m.lookup(ref.Address.Resource()); ok {
+			referenced[target.String()] = true
+		}
+	}
+
+	// implied holds everything ordered before key through a reference or through
+	// another dependency
+	implied := make(map[string]bool)
+	var reach func(node string)
+	reach = func(node string) {
+		for _, next := range edges[node] {
+			if !implied[next] {
+				implied[next] = true
+				reach(next)
+			}
+		}
+	}
+	for target := range referenced {
+		implied[target] = true
+		reach(target)
+	}
+	for target := range direct {
+		if !referenced[target] {
+			reach(target)
+		}
+	}
+
+	var out []types.Address
+	for _, target := range sortedKeys(direct) {
+		if !implied[target] {
+			out = append(out, m.nodes[target])
+		}
+	}
+	return out
+}
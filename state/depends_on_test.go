@@ -0,0 +1,55 @@
+package state
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/schemabounce/kolumn/sdk/types"
+)
+
+// TestDependsOnClauses validates depends_on omits dependencies implied by references or other dependencies
+func TestDependsOnClauses(t *testing.T) {
+	app := NewUniversalState("app", "postgres")
+	schema := NewUniversalResource("s1", "schema", "app", "postgres", "app")
+	table := NewUniversalResource("t1", "table", "users", "postgres", "app")
+	table.AddDependency("s1")
+	view := NewUniversalResource("v1", "view", "active", "postgres", "app")
+	view.AddDependency("t1")
+	view.AddDependency("s1")
+	grant := NewUniversalResource("g1", "grant", "reader", "postgres", "app")
+	grant.AddDependency("v1")
+	grant.AddDependency("t1")
+	grant.AddDependency("network::aws.vpc.main")
+	for _, r := range []*UniversalResource{schema, table, view, grant} {
+		app.AddResource(r)
+	}
+	m, err := NewDependencyManagerFromState("app", app)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	configs := map[string]map[string]interface{}{
+		"postgres.table.users": {"schema": "${postgres.schema.app.name}"},
+		"postgres.view.active": {"query": "SELECT * FROM users", "columns": []interface{}{"id"}},
+	}
+	want := map[string][]string{
+		"postgres.view.active":  {"postgres.table.users"},
+		"postgres.grant.reader": {"postgres.view.active"},
+	}
+	if got := m.DependsOnClauses(configs); !reflect.DeepEqual(got, want) {
+		t.Errorf("DependsOnClauses = %v, want %v", got, want)
+	}
+
+	// referencing the view leaves nothing to declare for the grant
+	addr := types.NewAddress("postgres", "grant", "reader")
+	config := map[string]interface{}{"on": map[string]interface{}{"object": "${postgres.view.active.name}"}}
+	if got := m.ExplicitDependencies(addr, config); len(got) != 0 {
+		t.Errorf("referenced dependencies should be implied, got %v", got)
+	}
+	if refs := ConfigReferences(config); len(refs) != 1 || refs[0].Address.Name != "active" {
+		t.Errorf("ConfigReferences = %v", refs)
+	}
+	if got := m.ExplicitDependencies(types.Address{Type: "view", Name: "active"}, nil); len(got) != 1 || got[0].Name != "users" {
+		t.Errorf("relative address lookup = %v", got)
+	}
+}
@@ -0,0 +1,192 @@
+// Package state provides destroy and replacement ordering for dependency managers
+package state
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/schemabounce/kolumn/sdk/types"
+)
+
+// PreventDestroyMetadataKey is the resource metadata key that, when true, protects a
+// resource from being destroyed or replaced
+const PreventDestroyMetadataKey = "prevent_destroy"
+
+// Batch actions
+const (
+	BatchActionCreate  = "create"
+	BatchActionDestroy = "destroy"
+)
+
+// ExecutionBatch is a set of resources that can be created or destroyed in parallel
+type ExecutionBatch struct {
+	Action    string          `json:"action"` // one of the BatchAction values
+	Resources []types.Address `json:"resources"`
+}
+
+// PreventDestroyError is returned when an ordering would destroy protected resources
+type PreventDestroyError struct {
+	Resources []types.Address
+}
+
+func (e *PreventDestroyError) Error() string {
+	names := make([]string, len(e.Resources))
+	for i, r := range e.Resources {
+		names[i] = r.String()
+	}
+	return "refusing to destroy resources protected by prevent_destroy: " + strings.Join(names, ", ")
+}
+
+// SetPreventDestroy sets or clears the prevent-destroy policy of a resource
+func (m *DependencyManager) SetPreventDestroy(addr types.Address, prevent bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if prevent {
+		m.protected[addr.String()] = true
+	} else {
+		delete(m.protected, addr.String())
+	}
+}
+
+// PreventsDestroy reports whether a resource is protected from destruction
+func (m *DependencyManager) PreventsDestroy(addr types.Address) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.protected[addr.String()]
+}
+
+// FindDestroyOrder returns the batches in which resources must be destroyed: every
+// resource is destroyed only after all resources depending on it. With no targets the
+// whole state is destroyed; otherwise the targets and everything that depends on them
+// are. Protected resources in that set fail the ordering with a PreventDestroyError.
+func (m *DependencyManager) FindDestroyOrder(targets ...types.Address) ([][]types.Address, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	set, err := m.destroySet(targets)
+	if err != nil {
+		return nil, err
+	}
+	batches, err := topoBatches(set, m.reverseEdges())
+	if err != nil {
+		return nil, err
+	}
+	return m.addressBatches(batches), nil
+}
+
+// FindReplacementOrder orders the destroy-then-create replacement of resources.
+// Destroy and create batches are interleaved: a resource is recreated as soon as it
+// has been destroyed and the replaced resources it depends on exist again, instead of
+// waiting for every destroy to finish. Within a round destroys run before creates.
+func (m *DependencyManager) FindReplacementOrder(replace ...types.Address) ([]ExecutionBatch, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	keys := make([]string, 0, len(replace))
+	for _, addr := range replace {
+		found, ok := m.lookup(addr)
+		if !ok {
+			continue
+		}
+		keys = append(keys, found.String())
+	}
+	if err := m.checkProtected(keys); err != nil {
+		return nil, err
+	}
+
+	forward, reverse := m.localEdges(), m.reverseEdges()
+	nodes := make([]string, 0, 2*len(keys))
+	after := make(map[string][]string, 2*len(keys))
+	for _, key := range keys {
+		destroy, create := BatchActionDestroy+":"+key, BatchActionCreate+":"+key
+		nodes = append(nodes, destroy, create)
+		for _, dependent := range reverse[key] {
+			after[destroy] = append(after[destroy], BatchActionDestroy+":"+dependent)
+		}
+		after[create] = append(after[create], destroy)
+		for _, dependency := range forward[key] {
+			after[create] = append(after[create], BatchActionCreate+":"+dependency)
+		}
+	}
+
+	rounds, err := topoBatches(nodes, after)
+	if err != nil {
+		return nil, err
+	}
+	var batches []ExecutionBatch
+	for _, round := range rounds {
+		for _, action := range []string{BatchActionDestroy, BatchActionCreate} {
+			batch := ExecutionBatch{Action: action}
+			for _, node := range round {
+				if key, ok := strings.CutPrefix(node, action+":"); ok {
+					batch.Resources = append(batch.Resources, m.nodes[key])
+				}
+			}
+			if len(batch.Resources) > 0 {
+				batches = append(batches, batch)
+			}
+		}
+	}
+	return batches, nil
+}
+
+// destroySet returns the node keys destroyed for the targets: all nodes when there are
+// none, otherwise the targets and their transitive dependents. The caller must hold
+// the lock.
+func (m *DependencyManager) destroySet(targets []types.Address) ([]string, error) {
+	var keys []string
+	if len(targets) == 0 {
+		for key := range m.nodes {
+			keys = append(keys, key)
+		}
+	} else {
+		reverse := m.reverseEdges()
+		seen := make(map[string]bool)
+		queue := make([]string, 0, len(targets))
+		for _, addr := range targets {
+			if found, ok := m.lookup(addr); ok {
+				queue = append(queue, found.String())
+			}
+		}
+		for len(queue) > 0 {
+			key := queue[0]
+			queue = queue[1:]
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			keys = append(keys, key)
+			queue = append(queue, reverse[key]...)
+		}
+	}
+	sort.Strings(keys)
+	return keys, m.checkProtected(keys)
+}
+
+// checkProtected fails when any of the node keys is protected. The caller must hold the
+// lock.
+func (m *DependencyManager) checkProtected(keys []string) error {
+	var protected []types.Address
+	for _, key := range keys {
+		if m.protected[key] {
+			protected = append(protected, m.nodes[key])
+		}
+	}
+	if len(protected) > 0 {
+		sort.Slice(protected, func(i, j int) bool { return protected[i].String() < protected[j].String() })
+		return &PreventDestroyError{Resources: protected}
+	}
+	return nil
+}
+
+// reverseEdges maps each resource to the same-state resources that depend on it. The
+// caller must hold the lock.
+func (m *DependencyManager) reverseEdges() map[string][]string {
+	reverse := make(map[string][]string)
+	for from, tos := range m.localEdges() {
+		for _, to := range tos {
+			reverse[to] = append(reverse[to], from)
+		}
+	}
+	return reverse
+}
@@ -0,0 +1,157 @@
+// Package state provides execution plans with duration estimates for dependency managers
+package state
+
+import (
+	"sort"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+	"github.com/schemabounce/kolumn/sdk/types"
+)
+
+// ExecutionPlan is the creation order of a state together with its expected duration
+type ExecutionPlan struct {
+	Batches [][]types.Address `json:"batches"`
+
+	// CriticalPath is the chain of dependent resources with the longest total estimated
+	// duration, in execution order; no amount of parallelism finishes sooner
+	CriticalPath         []types.Address `json:"critical_path"`
+	CriticalPathDuration time.Duration   `json:"critical_path_duration"`
+
+	// EstimatedDuration is the expected wall-clock time with Parallelism concurrent
+	// operations; TotalWork is the sum of every resource's estimate
+	EstimatedDuration time.Duration `json:"estimated_duration"`
+	TotalWork         time.Duration `json:"total_work"`
+	Parallelism       int           `json:"parallelism"` // 0 means unbounded
+}
+
+// SetEstimatedDuration records how long applying a resource is expected to take
+func (m *DependencyManager) SetEstimatedDuration(addr types.Address, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.estimates == nil {
+		m.estimates = make(map[string]time.Duration)
+	}
+	m.estimates[addr.String()] = d
+}
+
+// SetEstimateFromPlan records a resource's estimate from its handler's plan: the plan
+// summary's estimate when present, otherwise the sum of its planned changes' estimates
+func (m *DependencyManager) SetEstimateFromPlan(addr types.Address, plan *core.PlanResponse) {
+	if plan == nil {
+		return
+	}
+	var d time.Duration
+	if plan.Summary != nil && plan.Summary.EstimatedTime > 0 {
+		d = plan.Summary.EstimatedTime
+	} else {
+		for _, change := range plan.Changes {
+			d += change.EstimatedTime
+		}
+	}
+	m.SetEstimatedDuration(addr, d)
+}
+
+// BuildExecutionPlan orders the state and estimates how long applying it takes with
+// at most parallelism concurrent operations (0 for unbounded). Resources without an
+// estimate count as instantaneous. The estimate simulates a scheduler that always
+// starts the ready resource with the longest remaining path first.
+func (m *DependencyManager) BuildExecutionPlan(parallelism int) (*ExecutionPlan, error) {
+	batches, err := m.FindExecutionOrder()
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if parallelism < 0 {
+		parallelism = 0
+	}
+	plan := &ExecutionPlan{Batches: batches, Parallelism: parallelism}
+	forward, reverse := m.localEdges(), m.reverseEdges()
+
+	// tail is the longest duration from the start of a node to the end of its chain of
+	// dependents; walking batches backwards visits dependents first
+	tail := make(map[string]time.Duration, len(m.nodes))
+	next := make(map[string]string, len(m.nodes))
+	for i := len(batches) - 1; i >= 0; i-- {
+		for _, addr := range batches[i] {
+			key := addr.String()
+			var best time.Duration
+			for _, dependent := range sortedCopy(reverse[key]) {
+				if next[key] == "" || tail[dependent] > best {
+					best, next[key] = tail[dependent], dependent
+				}
+			}
+			tail[key] = m.estimates[key] + best
+			plan.TotalWork += m.estimates[key]
+		}
+	}
+
+	var start string
+	for _, batch := range batches {
+		for _, addr := range batch {
+			if key := addr.String(); start == "" || tail[key] > tail[start] {
+				start = key
+			}
+		}
+	}
+	for key := start; key != ""; key = next[key] {
+		plan.CriticalPath = append(plan.CriticalPath, m.nodes[key])
+	}
+	plan.CriticalPathDuration = tail[start]
+	plan.EstimatedDuration = m.simulate(parallelism, forward, reverse, tail)
+	return plan, nil
+}
+
+// simulate returns the makespan of a list schedule with the given parallelism. The
+// caller must hold the lock.
+func (m *DependencyManager) simulate(parallelism int, forward, reverse map[string][]string, tail map[string]time.Duration) time.Duration {
+	waiting := make(map[string]int, len(m.nodes))
+	var ready []string
+	for key := range m.nodes {
+		waiting[key] = len(forward[key])
+		if waiting[key] == 0 {
+			ready = append(ready, key)
+		}
+	}
+
+	type running struct {
+		key    string
+		finish time.Duration
+	}
+	var active []running
+	var now time.Duration
+	for len(ready) > 0 || len(active) > 0 {
+		sort.Slice(ready, func(i, j int) bool {
+			if tail[ready[i]] != tail[ready[j]] {
+				return tail[ready[i]] > tail[ready[j]]
+			}
+			return ready[i] < ready[j]
+		})
+		for len(ready) > 0 && (parallelism == 0 || len(active) < parallelism) {
+			active = append(active, running{key: ready[0], finish: now + m.estimates[ready[0]]})
+			ready = ready[1:]
+		}
+
+		sort.Slice(active, func(i, j int) bool { return active[i].finish < active[j].finish })
+		now = active[0].finish
+		for len(active) > 0 && active[0].finish == now {
+			done := active[0].key
+			active = active[1:]
+			for _, dependent := range reverse[done] {
+				if waiting[dependent]--; waiting[dependent] == 0 {
+					ready = append(ready, dependent)
+				}
+			}
+		}
+	}
+	return now
+}
+
+func sortedCopy(values []string) []string {
+	out := append([]string(nil), values...)
+	sort.Strings(out)
+	return out
+}
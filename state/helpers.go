@@ -9,7 +9,11 @@ import (
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"time"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+	"github.com/schemabounce/kolumn/sdk/helpers/ids"
 )
 
 // CalculateChecksum calculates a checksum for a UniversalState
@@ -130,7 +134,7 @@ func MergeUniversalStates(primary *UniversalState, secondary ...*UniversalState)
 	}
 
 	// Update merged state metadata
-	merged.LastUpdated = time.Now()
+	merged.LastUpdated = clock.Now()
 	merged.UpdatedAt = merged.LastUpdated
 	merged.Version++
 
@@ -245,8 +249,8 @@ func CreateStateSnapshot(state *UniversalState) (*StateSnapshot, error) {
 	}
 
 	snapshot := &StateSnapshot{
-		ID:          fmt.Sprintf("snapshot-%d", time.Now().Unix()),
-		Timestamp:   time.Now(),
+		ID:          ids.Prefixed("snapshot"),
+		Timestamp:   clock.Now(),
 		State:       state.Clone(),
 		Checksum:    checksum,
 		Description: fmt.Sprintf("Snapshot of state version %d", state.Version),
@@ -328,3 +332,126 @@ func (h *BackendProviderHelper) GetConfig() *StateBackendConfig {
 func (h *BackendProviderHelper) ValidateBasicState(ctx context.Context, state *UniversalState) error {
 	return ValidateUniversalState(state)
 }
+
+// StateSchemaVersionKey is the resource metadata key recording the state schema version
+// a resource was written with. UniversalResource.Version counts revisions instead.
+const StateSchemaVersionKey = "state_schema_version"
+
+// ResourceUsage summarizes resource types in use for upgrade analysis. When
+// providerType is non-empty only that provider's resources are included.
+func ResourceUsage(state *UniversalState, providerType string) []core.ResourceUsage {
+	byType := make(map[string]*core.ResourceUsage)
+	keys := make(map[string]map[string]bool)
+
+	for _, resource := range state.Resources {
+		if providerType != "" && resource.ProviderType != providerType {
+			continue
+		}
+
+		usage, exists := byType[resource.Type]
+		if !exists {
+			usage = &core.ResourceUsage{ResourceType: resource.Type, Provider: resource.ProviderType}
+			byType[resource.Type] = usage
+			keys[resource.Type] = make(map[string]bool)
+		}
+
+		name := resource.Name
+		if name == "" {
+			name = resource.ID
+		}
+		usage.Instances = append(usage.Instances, name)
+
+		for key := range resource.Data {
+			keys[resource.Type][key] = true
+		}
+
+		// Report the oldest schema version so every pending migration is listed
+		version := 0
+		switch v := resource.Metadata[StateSchemaVersionKey].(type) {
+		case int:
+			version = v
+		case float64:
+			version = int(v)
+		}
+		if !exists || version < usage.StateVersion {
+			usage.StateVersion = version
+		}
+	}
+
+	result := make([]core.ResourceUsage, 0, len(byType))
+	for resourceType, usage := range byType {
+		for key := range keys[resourceType] {
+			usage.ConfigKeys = append(usage.ConfigKeys, key)
+		}
+		sort.Strings(usage.ConfigKeys)
+		sort.Strings(usage.Instances)
+		result = append(result, *usage)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ResourceType < result[j].ResourceType })
+	return result
+}
+
+// RefreshTargets lists the resources of the given types (all types when none are
+// given) as RefreshAll targets, sorted by resource ID
+func RefreshTargets(state *UniversalState, resourceTypes ...string) []core.RefreshTarget {
+	wanted := make(map[string]bool, len(resourceTypes))
+	for _, t := range resourceTypes {
+		wanted[t] = true
+	}
+
+	targets := make([]core.RefreshTarget, 0, len(state.Resources))
+	for _, resource := range state.Resources {
+		if len(wanted) > 0 && !wanted[resource.Type] {
+			continue
+		}
+		if resource.Status == ResourceStatusDeleted {
+			continue
+		}
+		targets = append(targets, core.RefreshTarget{
+			ResourceType: resource.Type,
+			ResourceID:   resource.ID,
+			Name:         resource.Name,
+			State:        resource.Data,
+		})
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i].ResourceID < targets[j].ResourceID })
+	return targets
+}
+
+// ApplyRefresh records RefreshAll results in state. Changed resources take the state
+// that was read and are marked drifted; missing resources are marked deleted.
+// Unchanged and failed resources are left as they are.
+func ApplyRefresh(state *UniversalState, resp *core.RefreshAllResponse) {
+	now := clock.Now()
+	for _, result := range resp.Results {
+		resource, ok := state.GetResource(result.ResourceID)
+		if !ok {
+			continue
+		}
+		switch result.Status {
+		case core.RefreshChanged:
+			resource.ChangeInfo = &ResourceChangeInfo{
+				ChangeType:    ChangeTypeDrift,
+				Before:        resource.Data,
+				After:         result.State,
+				ChangedFields: result.ChangedFields,
+				ChangeReason:  "refresh",
+				ChangedAt:     now,
+			}
+			resource.Update(result.State)
+			resource.SetStatus(ResourceStatusDrifted)
+		case core.RefreshMissing:
+			resource.ChangeInfo = &ResourceChangeInfo{
+				ChangeType:   ChangeTypeDelete,
+				Before:       resource.Data,
+				ChangeReason: "refresh: resource no longer exists",
+				ChangedAt:    now,
+			}
+			resource.SetStatus(ResourceStatusDeleted)
+		default:
+			continue
+		}
+		state.LastUpdated = now
+		state.UpdatedAt = now
+	}
+}
@@ -0,0 +1,220 @@
+// Package state provides a bounded per-resource history of the operations Kolumn ran
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+)
+
+// OperationHistoryMetadataKey is the resource metadata key holding its operation history
+const OperationHistoryMetadataKey = "operation_history"
+
+// DefaultOperationHistoryLimit is how many operations a resource keeps when no limit is given
+const DefaultOperationHistoryLimit = 20
+
+// Operation outcomes recorded in history
+const (
+	OperationOutcomeSuccess = "success"
+	OperationOutcomeFailure = "failure"
+)
+
+// OperationRecord is one operation Kolumn ran against a resource
+type OperationRecord struct {
+	Operation   string        `json:"operation"` // e.g. create, update, delete, import
+	Timestamp   time.Time     `json:"timestamp"`
+	Actor       string        `json:"actor,omitempty"` // user or automation that ran it
+	Duration    time.Duration `json:"duration,omitempty"`
+	Outcome     string        `json:"outcome"`
+	Diagnostics string        `json:"diagnostics,omitempty"` // summary, see DiagnosticsSummary
+	ApplyID     string        `json:"apply_id,omitempty"`
+}
+
+// modifies reports whether the record changed the resource
+func (r OperationRecord) modifies() bool {
+	if r.Outcome != OperationOutcomeSuccess {
+		return false
+	}
+	switch ChangeType(r.Operation) {
+	case ChangeTypeCreate, ChangeTypeUpdate, ChangeTypeDelete:
+		return true
+	}
+	return r.Operation == "import"
+}
+
+// RecordOperation appends a record to the resource's history, dropping the oldest ones
+// beyond limit; a limit of zero or less uses DefaultOperationHistoryLimit. A zero
+// timestamp is set to now and an empty outcome to success.
+func (ur *UniversalResource) RecordOperation(record OperationRecord, limit int) {
+	if limit <= 0 {
+		limit = DefaultOperationHistoryLimit
+	}
+	if record.Timestamp.IsZero() {
+		record.Timestamp = clock.Now()
+	}
+	if record.Outcome == "" {
+		record.Outcome = OperationOutcomeSuccess
+	}
+
+	// A new slice is stored every time, so clones sharing the metadata value are unaffected
+	history := append(ur.OperationHistory(), record)
+	if len(history) > limit {
+		history = history[len(history)-limit:]
+	}
+	if ur.Metadata == nil {
+		ur.Metadata = make(map[string]interface{})
+	}
+	ur.Metadata[OperationHistoryMetadataKey] = history
+}
+
+// OperationHistory returns the resource's recorded operations, oldest first. History
+// loaded from a state file is decoded from its generic JSON form.
+func (ur *UniversalResource) OperationHistory() []OperationRecord {
+	switch v := ur.Metadata[OperationHistoryMetadataKey].(type) {
+	case nil:
+		return nil
+	case []OperationRecord:
+		return append([]OperationRecord(nil), v...)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil
+		}
+		var history []OperationRecord
+		if json.Unmarshal(data, &history) != nil {
+			return nil
+		}
+		return history
+	}
+}
+
+// LastOperation returns the most recent record, optionally limited to the given operations
+func (ur *UniversalResource) LastOperation(operations ...string) (OperationRecord, bool) {
+	history := ur.OperationHistory()
+	for i := len(history) - 1; i >= 0; i-- {
+		if len(operations) == 0 || containsString(operations, history[i].Operation) {
+			return history[i], true
+		}
+	}
+	return OperationRecord{}, false
+}
+
+// LastModified returns the most recent successful operation that changed the resource,
+// answering when Kolumn last modified it and who ran it
+func (ur *UniversalResource) LastModified() (OperationRecord, bool) {
+	history := ur.OperationHistory()
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].modifies() {
+			return history[i], true
+		}
+	}
+	return OperationRecord{}, false
+}
+
+// RecordOperation records an operation on a resource in state. Records made during an
+// apply are tagged with its ID.
+func (us *UniversalState) RecordOperation(resourceID string, record OperationRecord, limit int) error {
+	resource, ok := us.GetResource(resourceID)
+	if !ok {
+		return fmt.Errorf("record operation: resource %s not found", resourceID)
+	}
+	if record.ApplyID == "" && us.Apply != nil {
+		record.ApplyID = us.Apply.ID
+	}
+	resource.RecordOperation(record, limit)
+	us.LastUpdated = clock.Now()
+	return nil
+}
+
+// HistoryQuery filters QueryOperationHistory; empty fields match everything
+type HistoryQuery struct {
+	ResourceType string
+	Operation    string
+	Actor        string
+	Outcome      string
+	Since        time.Time // inclusive
+	Until        time.Time // exclusive
+	Limit        int       // maximum results; zero means all
+}
+
+// matches reports whether a record of a resource of resourceType passes the query
+func (q HistoryQuery) matches(resourceType string, record OperationRecord) bool {
+	switch {
+	case q.ResourceType != "" && q.ResourceType != resourceType,
+		q.Operation != "" && q.Operation != record.Operation,
+		q.Actor != "" && q.Actor != record.Actor,
+		q.Outcome != "" && q.Outcome != record.Outcome,
+		!q.Since.IsZero() && record.Timestamp.Before(q.Since),
+		!q.Until.IsZero() && !record.Timestamp.Before(q.Until):
+		return false
+	}
+	return true
+}
+
+// ResourceOperationRecord is an operation record together with the resource it ran on
+type ResourceOperationRecord struct {
+	ResourceID   string `json:"resource_id"`
+	ResourceType string `json:"resource_type"`
+	Name         string `json:"name"`
+	OperationRecord
+}
+
+// QueryOperationHistory returns the operations recorded across every resource in
+// state that match the query, newest first
+func (us *UniversalState) QueryOperationHistory(q HistoryQuery) []ResourceOperationRecord {
+	var results []ResourceOperationRecord
+	for _, id := range sortedKeys(us.Resources) {
+		resource := us.Resources[id]
+		for _, record := range resource.OperationHistory() {
+			if q.matches(resource.Type, record) {
+				results = append(results, ResourceOperationRecord{
+					ResourceID:      id,
+					ResourceType:    resource.Type,
+					Name:            resource.Name,
+					OperationRecord: record,
+				})
+			}
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Timestamp.After(results[j].Timestamp)
+	})
+	if q.Limit > 0 && len(results) > q.Limit {
+		results = results[:q.Limit]
+	}
+	return results
+}
+
+// DiagnosticsSummary condenses an operation's diagnostics into one line for history,
+// e.g. "1 error, 2 warnings: permission denied on schema public"
+func DiagnosticsSummary(diagnostics []core.Diagnostic) string {
+	if len(diagnostics) == 0 {
+		return ""
+	}
+	errors, warnings := 0, 0
+	first := ""
+	for _, d := range diagnostics {
+		if d.Severity == "error" {
+			if errors == 0 {
+				first = d.Summary
+			}
+			errors++
+		} else {
+			warnings++
+		}
+	}
+	if first == "" {
+		first = diagnostics[0].Summary
+	}
+	return fmt.Sprintf("%s, %s: %s", plural(errors, "error"), plural(warnings, "warning"), first)
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
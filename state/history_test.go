@@ -0,0 +1,98 @@
+package state
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+	"github.com/schemabounce/kolumn/sdk/types"
+)
+
+// TestOperationHistoryBounded validates history keeps the newest records and survives reload
+func TestOperationHistoryBounded(t *testing.T) {
+	now := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	defer SetClock(types.ClockFunc(func() time.Time { return now }))()
+
+	st := NewUniversalState("pg-1", "postgres")
+	st.AddResource(NewUniversalResource("users", "table", "users", "postgres", "pg-1"))
+	for i, op := range []string{"create", "update", "update", "refresh"} {
+		now = now.Add(time.Hour)
+		record := OperationRecord{Operation: op, Actor: "alice", Duration: time.Duration(i+1) * time.Second}
+		if err := st.RecordOperation("users", record, 3); err != nil {
+			t.Fatalf("RecordOperation: %v", err)
+		}
+	}
+	if err := st.RecordOperation("missing", OperationRecord{Operation: "create"}, 0); err == nil {
+		t.Error("recording on an unknown resource should fail")
+	}
+
+	data, _ := json.Marshal(st)
+	var restored UniversalState
+	if err := json.Unmarshal(data, &restored); err != nil {
+		t.Fatal(err)
+	}
+	history := restored.Resources["users"].OperationHistory()
+	if len(history) != 3 || history[0].Operation != "update" || history[2].Operation != "refresh" {
+		t.Fatalf("history after reload = %+v", history)
+	}
+	if history[2].Outcome != OperationOutcomeSuccess || !history[2].Timestamp.Equal(now) || history[2].Duration != 4*time.Second {
+		t.Errorf("unexpected last record %+v", history[2])
+	}
+
+	modified, ok := restored.Resources["users"].LastModified()
+	if !ok || modified.Operation != "update" || modified.Actor != "alice" || !modified.Timestamp.Equal(now.Add(-time.Hour)) {
+		t.Errorf("LastModified = %+v, %v", modified, ok)
+	}
+
+	// clones keep their own history
+	clone := restored.Resources["users"].Clone()
+	clone.RecordOperation(OperationRecord{Operation: "delete"}, 0)
+	if len(restored.Resources["users"].OperationHistory()) != 3 {
+		t.Error("recording on a clone changed the original")
+	}
+}
+
+// TestQueryOperationHistory validates filtering across resources, newest first
+func TestQueryOperationHistory(t *testing.T) {
+	base := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	st := NewUniversalState("pg-1", "postgres")
+	st.AddResource(NewUniversalResource("users", "table", "users", "postgres", "pg-1"))
+	st.AddResource(NewUniversalResource("active", "view", "active", "postgres", "pg-1"))
+	if _, err := st.BeginApply([]string{"active"}); err != nil {
+		t.Fatal(err)
+	}
+
+	_ = st.RecordOperation("users", OperationRecord{Operation: "create", Actor: "alice", Timestamp: base}, 0)
+	_ = st.RecordOperation("active", OperationRecord{Operation: "create", Actor: "bob", Timestamp: base.Add(time.Hour)}, 0)
+	_ = st.RecordOperation("users", OperationRecord{
+		Operation:   "update",
+		Actor:       "bob",
+		Timestamp:   base.Add(2 * time.Hour),
+		Outcome:     OperationOutcomeFailure,
+		Diagnostics: DiagnosticsSummary([]core.Diagnostic{{Severity: "warning", Summary: "slow"}, {Severity: "error", Summary: "permission denied"}}),
+	}, 0)
+
+	all := st.QueryOperationHistory(HistoryQuery{})
+	if len(all) != 3 || all[0].Operation != "update" || all[2].ResourceID != "users" {
+		t.Fatalf("all history = %+v", all)
+	}
+	if all[0].Diagnostics != "1 error, 1 warning: permission denied" {
+		t.Errorf("diagnostics = %q", all[0].Diagnostics)
+	}
+	if all[1].ApplyID == "" || all[1].ApplyID != st.Apply.ID {
+		t.Errorf("records during an apply should carry its ID, got %q", all[1].ApplyID)
+	}
+
+	bob := st.QueryOperationHistory(HistoryQuery{Actor: "bob", Outcome: OperationOutcomeSuccess})
+	if len(bob) != 1 || bob[0].ResourceType != "view" {
+		t.Errorf("bob's successful operations = %+v", bob)
+	}
+	window := st.QueryOperationHistory(HistoryQuery{ResourceType: "table", Since: base.Add(time.Minute), Until: base.Add(3 * time.Hour)})
+	if len(window) != 1 || window[0].Operation != "update" {
+		t.Errorf("windowed table history = %+v", window)
+	}
+	if limited := st.QueryOperationHistory(HistoryQuery{Limit: 2}); len(limited) != 2 {
+		t.Errorf("limit ignored: %d results", len(limited))
+	}
+}
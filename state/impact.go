@@ -0,0 +1,290 @@
+// Package state provides impact analysis and what-if simulation for dependency managers
+package state
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/types"
+)
+
+// ImpactAnalysis lists the resources affected by a change to one resource
+type ImpactAnalysis struct {
+	Resource             types.Address   `json:"resource"`
+	DirectDependents     []types.Address `json:"direct_dependents,omitempty"`
+	TransitiveDependents []types.Address `json:"transitive_dependents,omitempty"` // includes direct dependents
+}
+
+// HypotheticalChange describes edits to evaluate without applying them
+type HypotheticalChange struct {
+	AddResources       []types.Address    `json:"add_resources,omitempty"`
+	RemoveResources    []types.Address    `json:"remove_resources,omitempty"`
+	ModifyResources    []types.Address    `json:"modify_resources,omitempty"`
+	AddDependencies    []types.Dependency `json:"add_dependencies,omitempty"`
+	RemoveDependencies []types.Dependency `json:"remove_dependencies,omitempty"`
+}
+
+// WhatIfReport is the predicted outcome of a hypothetical change
+type WhatIfReport struct {
+	// Impacts covers every removed or modified resource; removals are analyzed against
+	// the current graph, modifications against the hypothetical one
+	Impacts []ImpactAnalysis `json:"impacts,omitempty"`
+
+	// BrokenDependencies are dependencies left pointing at removed resources
+	BrokenDependencies []types.Dependency `json:"broken_dependencies,omitempty"`
+
+	// NewCycles are dependency cycles that exist only after the change
+	NewCycles [][]string `json:"new_cycles,omitempty"`
+
+	// ExecutionOrder is the creation order after the change, when it has no cycles
+	ExecutionOrder [][]types.Address `json:"execution_order,omitempty"`
+
+	Errors []string `json:"errors,omitempty"`
+}
+
+// Safe reports whether the change breaks no dependencies and introduces no cycles
+func (r *WhatIfReport) Safe() bool {
+	return len(r.BrokenDependencies) == 0 && len(r.NewCycles) == 0 && len(r.Errors) == 0
+}
+
+// GetImpactAnalysis returns the resources that depend on addr, directly or transitively
+func (m *DependencyManager) GetImpactAnalysis(addr types.Address) (*ImpactAnalysis, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	found, ok := m.lookup(addr)
+	if !ok {
+		return nil, fmt.Errorf("resource %s is not part of state %q", addr, m.stateName)
+	}
+	return m.impactOf(found), nil
+}
+
+// WhatIf evaluates a hypothetical change against a copy of the graph and reports its
+// impact, broken dependencies and newly introduced cycles. The manager is not modified.
+func (m *DependencyManager) WhatIf(change HypotheticalChange) *WhatIfReport {
+	m.mu.RLock()
+	before := m.clone()
+	m.mu.RUnlock()
+
+	after := before.clone()
+	report := &WhatIfReport{}
+
+	for _, addr := range change.AddResources {
+		after.nodes[addr.String()] = addr
+	}
+	for _, dep := range change.RemoveDependencies {
+		after.removeDependency(dep)
+	}
+	for _, addr := range change.RemoveResources {
+		found, ok := before.lookup(addr)
+		if !ok {
+			report.Errors = append(report.Errors, fmt.Sprintf("cannot remove %s: not part of state %q", addr, m.stateName))
+			continue
+		}
+		report.Impacts = append(report.Impacts, *before.impactOf(found))
+		after.removeResource(found)
+	}
+	for _, dep := range change.AddDependencies {
+		if err := after.AddDependency(dep); err != nil {
+			report.Errors = append(report.Errors, err.Error())
+		}
+	}
+	for _, addr := range change.ModifyResources {
+		found, ok := after.lookup(addr)
+		if !ok {
+			report.Errors = append(report.Errors, fmt.Sprintf("cannot modify %s: not part of state %q", addr, m.stateName))
+			continue
+		}
+		report.Impacts = append(report.Impacts, *after.impactOf(found))
+	}
+
+	for _, deps := range after.deps {
+		for _, dep := range deps {
+			if _, ok := after.nodes[dep.To.String()]; !ok && !dep.IsExternal() {
+				report.BrokenDependencies = append(report.BrokenDependencies, dep)
+			}
+		}
+	}
+	sort.Slice(report.BrokenDependencies, func(i, j int) bool {
+		return report.BrokenDependencies[i].String() < report.BrokenDependencies[j].String()
+	})
+
+	existing := make(map[string]bool)
+	for _, cycle := range before.cycles() {
+		existing[cycleKey(cycle)] = true
+	}
+	for _, cycle := range after.cycles() {
+		if !existing[cycleKey(cycle)] {
+			report.NewCycles = append(report.NewCycles, cycle)
+		}
+	}
+	if order, err := after.FindExecutionOrder(); err == nil {
+		report.ExecutionOrder = order
+	}
+	return report
+}
+
+// impactOf walks the dependents of a registered node. The caller must hold the lock.
+func (m *DependencyManager) impactOf(addr types.Address) *ImpactAnalysis {
+	reverse := m.reverseEdges()
+	key := addr.String()
+	impact := &ImpactAnalysis{Resource: addr}
+
+	seen := map[string]bool{key: true}
+	queue := sortedCopy(reverse[key])
+	for _, direct := range queue {
+		impact.DirectDependents = append(impact.DirectDependents, m.nodes[direct])
+	}
+	var transitive []string
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		if seen[next] {
+			continue
+		}
+		seen[next] = true
+		transitive = append(transitive, next)
+		queue = append(queue, reverse[next]...)
+	}
+	sort.Strings(transitive)
+	for _, t := range transitive {
+		impact.TransitiveDependents = append(impact.TransitiveDependents, m.nodes[t])
+	}
+	return impact
+}
+
+// cycles returns one cycle for every strongly connected component of the same-state
+// graph that contains a cycle. The caller must hold the lock or own the manager.
+func (m *DependencyManager) cycles() [][]string {
+	edges := m.localEdges()
+	index, low := make(map[string]int), make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var components [][]string
+	counter := 0
+
+	var connect func(node string)
+	connect = func(node string) {
+		index[node], low[node] = counter, counter
+		counter++
+		stack = append(stack, node)
+		onStack[node] = true
+		for _, next := range sortedCopy(edges[node]) {
+			if _, visited := index[next]; !visited {
+				connect(next)
+				low[node] = min(low[node], low[next])
+			} else if onStack[next] {
+				low[node] = min(low[node], index[next])
+			}
+		}
+		if low[node] == index[node] {
+			var component []string
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[top] = false
+				component = append(component, top)
+				if top == node {
+					break
+				}
+			}
+			components = append(components, component)
+		}
+	}
+
+	keys := make([]string, 0, len(m.nodes))
+	for key := range m.nodes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if _, visited := index[key]; !visited {
+			connect(key)
+		}
+	}
+
+	var out [][]string
+	for _, component := range components {
+		members := make(map[string]bool, len(component))
+		for _, n := range component {
+			members[n] = true
+		}
+		sub := make(map[string][]string, len(component))
+		for _, n := range component {
+			sub[n] = nil
+			for _, next := range edges[n] {
+				if members[next] {
+					sub[n] = append(sub[n], next)
+				}
+			}
+		}
+		if cycle := findCycle(sub); cycle != nil {
+			out = append(out, cycle)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return cycleKey(out[i]) < cycleKey(out[j]) })
+	return out
+}
+
+// clone copies the graph. The caller must hold the lock.
+func (m *DependencyManager) clone() *DependencyManager {
+	c := NewDependencyManager(m.stateName)
+	for k, v := range m.nodes {
+		c.nodes[k] = v
+	}
+	for k, v := range m.deps {
+		c.deps[k] = append([]types.Dependency(nil), v...)
+	}
+	for k, v := range m.protected {
+		c.protected[k] = v
+	}
+	if m.estimates != nil {
+		c.estimates = make(map[string]time.Duration, len(m.estimates))
+		for k, v := range m.estimates {
+			c.estimates[k] = v
+		}
+	}
+	return c
+}
+
+// removeResource drops a node and the dependencies it declares; dependencies on it are
+// kept so callers can report them as broken
+func (m *DependencyManager) removeResource(addr types.Address) {
+	key := addr.String()
+	delete(m.nodes, key)
+	delete(m.deps, key)
+	delete(m.protected, key)
+}
+
+// removeDependency drops a declared dependency, matching relative targets
+func (m *DependencyManager) removeDependency(dep types.Dependency) {
+	from, ok := m.lookup(dep.From)
+	if !ok {
+		return
+	}
+	if dep.State == m.stateName {
+		dep.State = ""
+	}
+	target := dep.Target()
+	if !dep.IsExternal() {
+		if to, ok := m.lookup(dep.To); ok {
+			target = to.String()
+		}
+	}
+	key := from.String()
+	kept := m.deps[key][:0]
+	for _, existing := range m.deps[key] {
+		if existing.Target() != target {
+			kept = append(kept, existing)
+		}
+	}
+	m.deps[key] = kept
+}
+
+// cycleKey identifies a cycle by its members, independent of the starting node
+func cycleKey(cycle []string) string {
+	members := sortedCopy(cycle[:len(cycle)-1])
+	return strings.Join(members, ",")
+}
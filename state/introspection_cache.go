@@ -0,0 +1,274 @@
+// Package state provides a cache of backend introspection results keyed by backend version
+package state
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// IntrospectionCacheMetadataKey is the state metadata key the cache is persisted under
+const IntrospectionCacheMetadataKey = "introspection_cache"
+
+// DefaultIntrospectionTTL is how long introspected metadata is reused
+const DefaultIntrospectionTTL = 24 * time.Hour
+
+// BackendIdentity identifies the backend introspected metadata was read from. A
+// different version, e.g. after a server upgrade, never reuses cached results.
+type BackendIdentity struct {
+	Provider string `json:"provider"`           // provider type, e.g. postgres
+	Endpoint string `json:"endpoint,omitempty"` // host, account or cluster
+	Version  string `json:"version"`            // version the backend reports
+}
+
+// Key renders the identity as a cache key
+func (b BackendIdentity) Key() string {
+	return b.Provider + "|" + b.Endpoint + "|" + b.Version
+}
+
+// IntrospectionEntry is one cached introspection result
+type IntrospectionEntry struct {
+	Backend   BackendIdentity `json:"backend"`
+	Name      string          `json:"name"` // e.g. types, collations, features
+	Value     json.RawMessage `json:"value"`
+	FetchedAt time.Time       `json:"fetched_at"`
+}
+
+// IntrospectionCacheStats reports cache effectiveness
+type IntrospectionCacheStats struct {
+	Hits          int64 `json:"hits"`
+	Misses        int64 `json:"misses"`
+	Entries       int   `json:"entries"`
+	Invalidations int64 `json:"invalidations"`
+}
+
+// introspectionSnapshot is the persisted form of the cache
+type introspectionSnapshot struct {
+	ConfigHash string                `json:"config_hash,omitempty"`
+	Entries    []*IntrospectionEntry `json:"entries"`
+}
+
+// IntrospectionCache caches what providers read about their backend, such as data
+// types, collations and feature flags, so it is not introspected on every operation.
+// Entries are keyed by backend identity and name, expire after the TTL and are all
+// dropped when the connection configuration changes. The cache can be persisted in
+// state so a new provider process starts warm.
+type IntrospectionCache struct {
+	ttl time.Duration
+
+	mu            sync.Mutex
+	configHash    string
+	entries       map[string]*IntrospectionEntry
+	hits          int64
+	misses        int64
+	invalidations int64
+}
+
+// NewIntrospectionCache creates a cache; ttl <= 0 uses DefaultIntrospectionTTL
+func NewIntrospectionCache(ttl time.Duration) *IntrospectionCache {
+	if ttl <= 0 {
+		ttl = DefaultIntrospectionTTL
+	}
+	return &IntrospectionCache{ttl: ttl, entries: make(map[string]*IntrospectionEntry)}
+}
+
+// Configure records the connection configuration the cached results belong to. When it
+// differs from the previous configuration every entry is dropped and true is returned.
+// Providers call it from Configure.
+func (c *IntrospectionCache) Configure(config map[string]interface{}) (bool, error) {
+	hash, err := configHash(config)
+	if err != nil {
+		return false, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if hash == c.configHash {
+		return false, nil
+	}
+	invalidated := c.configHash != "" || len(c.entries) > 0
+	if invalidated {
+		c.invalidateLocked()
+	}
+	c.configHash = hash
+	return invalidated, nil
+}
+
+// Get decodes the cached result called name for backend into v and reports whether a
+// fresh entry was found
+func (c *IntrospectionCache) Get(backend BackendIdentity, name string, v interface{}) (bool, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[introspectionKey(backend, name)]
+	if ok && clock.Since(entry.FetchedAt) >= c.ttl {
+		delete(c.entries, introspectionKey(backend, name))
+		ok = false
+	}
+	if !ok {
+		c.misses++
+		c.mu.Unlock()
+		return false, nil
+	}
+	c.hits++
+	value := entry.Value
+	c.mu.Unlock()
+
+	if err := json.Unmarshal(value, v); err != nil {
+		return false, fmt.Errorf("introspection cache %s: %w", name, err)
+	}
+	return true, nil
+}
+
+// Put caches v as the result called name for backend
+func (c *IntrospectionCache) Put(backend BackendIdentity, name string, v interface{}) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("introspection cache %s: %w", name, err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[introspectionKey(backend, name)] = &IntrospectionEntry{
+		Backend:   backend,
+		Name:      name,
+		Value:     value,
+		FetchedAt: clock.Now(),
+	}
+	return nil
+}
+
+// Introspect returns the cached result called name for backend, calling fetch and
+// caching its result on a miss. Fetch errors are not cached.
+func Introspect[T any](c *IntrospectionCache, backend BackendIdentity, name string, fetch func() (T, error)) (T, error) {
+	var value T
+	if ok, err := c.Get(backend, name, &value); err == nil && ok {
+		return value, nil
+	}
+	value, err := fetch()
+	if err != nil {
+		return value, err
+	}
+	return value, c.Put(backend, name, value)
+}
+
+// InvalidateBackend drops every entry of one backend
+func (c *IntrospectionCache) InvalidateBackend(backend BackendIdentity) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, entry := range c.entries {
+		if entry.Backend == backend {
+			delete(c.entries, key)
+		}
+	}
+	c.invalidations++
+}
+
+// Invalidate drops every entry
+func (c *IntrospectionCache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.invalidateLocked()
+}
+
+func (c *IntrospectionCache) invalidateLocked() {
+	c.entries = make(map[string]*IntrospectionEntry)
+	c.invalidations++
+}
+
+// Stats reports hits, misses and the number of cached entries
+func (c *IntrospectionCache) Stats() IntrospectionCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return IntrospectionCacheStats{Hits: c.hits, Misses: c.misses, Entries: len(c.entries), Invalidations: c.invalidations}
+}
+
+// SaveTo stores the unexpired entries in the state's metadata
+func (c *IntrospectionCache) SaveTo(st *UniversalState) {
+	c.mu.Lock()
+	snapshot := introspectionSnapshot{ConfigHash: c.configHash}
+	for _, key := range sortedKeys(c.entries) {
+		if entry := c.entries[key]; clock.Since(entry.FetchedAt) < c.ttl {
+			snapshot.Entries = append(snapshot.Entries, entry)
+		}
+	}
+	c.mu.Unlock()
+
+	if st.Metadata == nil {
+		st.Metadata = make(map[string]interface{})
+	}
+	st.Metadata[IntrospectionCacheMetadataKey] = snapshot
+}
+
+// LoadFrom replaces the cache contents with the entries persisted in state. Entries
+// saved under a different connection configuration than the one already configured
+// are discarded.
+func (c *IntrospectionCache) LoadFrom(st *UniversalState) error {
+	raw, ok := st.Metadata[IntrospectionCacheMetadataKey]
+	if !ok {
+		return nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return fmt.Errorf("load introspection cache: %w", err)
+	}
+	var snapshot introspectionSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return fmt.Errorf("load introspection cache: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.configHash != "" && snapshot.ConfigHash != c.configHash {
+		return nil
+	}
+	c.configHash = snapshot.ConfigHash
+	c.entries = make(map[string]*IntrospectionEntry, len(snapshot.Entries))
+	for _, entry := range snapshot.Entries {
+		if clock.Since(entry.FetchedAt) < c.ttl {
+			c.entries[introspectionKey(entry.Backend, entry.Name)] = entry
+		}
+	}
+	return nil
+}
+
+// Persist saves the cache into the state held by a state backend
+func (c *IntrospectionCache) Persist(ctx context.Context, backend StateBackendProvider) error {
+	st, err := backend.LoadState(ctx)
+	if err != nil {
+		return fmt.Errorf("persist introspection cache: %w", err)
+	}
+	c.SaveTo(st)
+	if err := backend.SaveState(ctx, st); err != nil {
+		return fmt.Errorf("persist introspection cache: %w", err)
+	}
+	return nil
+}
+
+// Restore loads the cache from the state held by a state backend. A backend without
+// state leaves the cache empty.
+func (c *IntrospectionCache) Restore(ctx context.Context, backend StateBackendProvider) error {
+	exists, err := backend.StateExists(ctx)
+	if err != nil || !exists {
+		return err
+	}
+	st, err := backend.LoadState(ctx)
+	if err != nil {
+		return fmt.Errorf("restore introspection cache: %w", err)
+	}
+	return c.LoadFrom(st)
+}
+
+func introspectionKey(backend BackendIdentity, name string) string {
+	return backend.Key() + "|" + name
+}
+
+// configHash fingerprints a connection configuration; map keys are marshalled sorted
+func configHash(config map[string]interface{}) (string, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return "", fmt.Errorf("introspection cache config: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,103 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/types"
+)
+
+// TestIntrospectionCache validates hits, version keying, expiry and config invalidation
+func TestIntrospectionCache(t *testing.T) {
+	now := time.Date(2026, 6, 1, 8, 0, 0, 0, time.UTC)
+	defer SetClock(types.ClockFunc(func() time.Time { return now }))()
+
+	cache := NewIntrospectionCache(time.Hour)
+	if _, err := cache.Configure(map[string]interface{}{"host": "db1"}); err != nil {
+		t.Fatal(err)
+	}
+	pg15 := BackendIdentity{Provider: "postgres", Endpoint: "db1:5432", Version: "15.4"}
+	fetches := 0
+	fetchTypes := func() ([]string, error) {
+		fetches++
+		return []string{"int4", "text"}, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		got, err := Introspect(cache, pg15, "types", fetchTypes)
+		if err != nil || len(got) != 2 {
+			t.Fatalf("Introspect = %v, %v", got, err)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("fetched %d times, want 1", fetches)
+	}
+
+	pg16 := pg15
+	pg16.Version = "16.1"
+	if _, err := Introspect(cache, pg16, "types", fetchTypes); err != nil || fetches != 2 {
+		t.Errorf("a new backend version should be introspected again (%d fetches, %v)", fetches, err)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if _, _ = Introspect(cache, pg15, "types", fetchTypes); fetches != 3 {
+		t.Errorf("expired entries should be refetched, %d fetches", fetches)
+	}
+
+	if invalidated, _ := cache.Configure(map[string]interface{}{"host": "db1"}); invalidated {
+		t.Error("unchanged config should keep the cache")
+	}
+	if invalidated, _ := cache.Configure(map[string]interface{}{"host": "db2"}); !invalidated || cache.Stats().Entries != 0 {
+		t.Errorf("changed config should drop every entry: %+v", cache.Stats())
+	}
+
+	failing := func() ([]string, error) { return nil, errors.New("connection refused") }
+	if _, err := Introspect(cache, pg15, "collations", failing); err == nil || cache.Stats().Entries != 0 {
+		t.Error("fetch errors should be returned and not cached")
+	}
+}
+
+// TestIntrospectionCachePersistence validates the cache survives a restart through the state backend
+func TestIntrospectionCachePersistence(t *testing.T) {
+	now := time.Date(2026, 6, 1, 8, 0, 0, 0, time.UTC)
+	defer SetClock(types.ClockFunc(func() time.Time { return now }))()
+
+	backend := &memoryBackend{state: NewUniversalState("pg", "postgres")}
+	config := map[string]interface{}{"host": "db1", "port": 5432}
+	identity := BackendIdentity{Provider: "postgres", Endpoint: "db1:5432", Version: "15.4"}
+
+	cache := NewIntrospectionCache(0)
+	_, _ = cache.Configure(config)
+	_ = cache.Put(identity, "features", map[string]bool{"merge": true})
+	if err := cache.Persist(context.Background(), backend); err != nil {
+		t.Fatalf("Persist: %v", err)
+	}
+
+	// a new process reads the state file back
+	data, _ := json.Marshal(backend.state)
+	var reloaded UniversalState
+	if err := json.Unmarshal(data, &reloaded); err != nil {
+		t.Fatal(err)
+	}
+	backend.state = &reloaded
+
+	warm := NewIntrospectionCache(0)
+	if err := warm.Restore(context.Background(), backend); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	_, _ = warm.Configure(config)
+	var features map[string]bool
+	if ok, err := warm.Get(identity, "features", &features); !ok || err != nil || !features["merge"] {
+		t.Errorf("restored cache missed: %v, %v, %v", ok, err, features)
+	}
+
+	other := NewIntrospectionCache(0)
+	_, _ = other.Configure(map[string]interface{}{"host": "db2"})
+	_ = other.Restore(context.Background(), backend)
+	if ok, _ := other.Get(identity, "features", &features); ok {
+		t.Error("entries saved for another connection config should not be restored")
+	}
+}
@@ -0,0 +1,214 @@
+// Package state provides named locks for serializing operations on shared objects
+package state
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultLockRetryInterval is how often a backend lock is retried while held elsewhere
+const DefaultLockRetryInterval = 250 * time.Millisecond
+
+// ErrLockLost is reported when a held backend lock could not be renewed because it
+// expired and another owner acquired it
+var ErrLockLost = errors.New("lock lost")
+
+// NamedLocker serializes work on shared objects identified by name, e.g.
+// "postgres:schema:public"
+type NamedLocker interface {
+	// Lock blocks until the named lock is held or ctx is done, and returns the
+	// function that releases it. Release reports a lock that could not be released
+	// and so stays held until it expires.
+	Lock(ctx context.Context, name string) (func() error, error)
+}
+
+// NamedLockBackend is implemented by state backends that can hold named locks shared
+// between processes. Acquire reports false when another owner holds the lock; ttl
+// bounds how long a lock survives an owner that never releases it. Acquiring a lock
+// the owner already holds succeeds and renews its ttl.
+type NamedLockBackend interface {
+	AcquireNamedLock(ctx context.Context, name, owner string, ttl time.Duration) (bool, error)
+	ReleaseNamedLock(ctx context.Context, name, owner string) error
+}
+
+// MemoryLocker is an in-process NamedLocker
+type MemoryLocker struct {
+	mu    sync.Mutex
+	locks map[string]chan struct{}
+}
+
+// NewMemoryLocker creates an in-process named locker
+func NewMemoryLocker() *MemoryLocker {
+	return &MemoryLocker{locks: make(map[string]chan struct{})}
+}
+
+// Lock implements NamedLocker
+func (l *MemoryLocker) Lock(ctx context.Context, name string) (func() error, error) {
+	l.mu.Lock()
+	ch, ok := l.locks[name]
+	if !ok {
+		ch = make(chan struct{}, 1)
+		l.locks[name] = ch
+	}
+	l.mu.Unlock()
+
+	select {
+	case ch <- struct{}{}:
+		var once sync.Once
+		return func() error {
+			once.Do(func() { <-ch })
+			return nil
+		}, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("waiting for lock %q: %w", name, ctx.Err())
+	}
+}
+
+// BackendLocker holds named locks in a state backend so they are shared by every
+// process using it. Callers in the same process queue on an in-process lock first so
+// only one of them polls the backend. A held lock is renewed every third of its ttl
+// until released, so work outlasting the ttl keeps it.
+type BackendLocker struct {
+	backend       NamedLockBackend
+	owner         string
+	ttl           time.Duration
+	retryInterval time.Duration
+	local         *MemoryLocker
+	onRenewError  func(name string, err error)
+}
+
+// NewBackendLocker creates a locker backed by a state backend; owner identifies this
+// process in the backend and ttl is passed to every acquisition
+func NewBackendLocker(backend NamedLockBackend, owner string, ttl time.Duration) *BackendLocker {
+	return &BackendLocker{
+		backend:       backend,
+		owner:         owner,
+		ttl:           ttl,
+		retryInterval: DefaultLockRetryInterval,
+		local:         NewMemoryLocker(),
+	}
+}
+
+// SetRetryInterval changes how often a held lock is retried
+func (l *BackendLocker) SetRetryInterval(d time.Duration) {
+	l.retryInterval = d
+}
+
+// SetRenewErrorHandler is called as soon as renewing a held lock fails, e.g. to abort
+// the work it protects. The first failure is also returned by the lock's release.
+func (l *BackendLocker) SetRenewErrorHandler(handler func(name string, err error)) {
+	l.onRenewError = handler
+}
+
+// Lock implements NamedLocker
+func (l *BackendLocker) Lock(ctx context.Context, name string) (func() error, error) {
+	releaseLocal, err := l.local.Lock(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		acquired, err := l.backend.AcquireNamedLock(ctx, name, l.owner, l.ttl)
+		if err != nil {
+			releaseLocal()
+			return nil, NewStateBackendError("acquire lock "+name, l.owner, err, true)
+		}
+		if acquired {
+			break
+		}
+		select {
+		case <-time.After(l.retryInterval):
+		case <-ctx.Done():
+			releaseLocal()
+			return nil, fmt.Errorf("waiting for lock %q: %w", name, ctx.Err())
+		}
+	}
+
+	heartbeat := l.renew(name)
+	var once sync.Once
+	var releaseErr error
+	return func() error {
+		once.Do(func() {
+			renewErr := heartbeat.stop()
+			// release even if the caller's context is already cancelled; the local
+			// lock is released regardless since the backend lock expires after ttl
+			if err := l.backend.ReleaseNamedLock(context.Background(), name, l.owner); err != nil {
+				releaseErr = NewStateBackendError("release lock "+name, l.owner, err, true)
+			}
+			releaseErr = errors.Join(renewErr, releaseErr)
+			releaseLocal()
+		})
+		return releaseErr
+	}, nil
+}
+
+// lockHeartbeat renews a held backend lock until stopped
+type lockHeartbeat struct {
+	done chan struct{}
+	quit chan struct{}
+	err  error // first renewal failure
+}
+
+// stop ends the renewals and returns the first renewal failure
+func (h *lockHeartbeat) stop() error {
+	close(h.quit)
+	<-h.done
+	return h.err
+}
+
+// renew starts renewing the named lock every third of the ttl. Failed renewals are
+// retried on the next beat; a lock taken by another owner is not renewed further.
+func (l *BackendLocker) renew(name string) *lockHeartbeat {
+	h := &lockHeartbeat{done: make(chan struct{}), quit: make(chan struct{})}
+	interval := l.ttl / 3
+	if interval <= 0 {
+		close(h.done)
+		return h
+	}
+	go func() {
+		defer close(h.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-h.quit:
+				return
+			}
+			renewed, err := l.backend.AcquireNamedLock(context.Background(), name, l.owner, l.ttl)
+			if err != nil {
+				err = NewStateBackendError("renew lock "+name, l.owner, err, true)
+			} else if !renewed {
+				err = fmt.Errorf("renew lock %q: %w to another owner", name, ErrLockLost)
+			}
+			if err == nil {
+				continue
+			}
+			if h.err == nil {
+				h.err = err
+			}
+			if l.onRenewError != nil {
+				l.onRenewError(name, err)
+			}
+			if errors.Is(err, ErrLockLost) {
+				return
+			}
+		}
+	}()
+	return h
+}
+
+// WithLock runs fn while holding the named lock. A failure to release the lock is
+// returned together with fn's error.
+func WithLock(ctx context.Context, locker NamedLocker, name string, fn func() error) (err error) {
+	release, err := locker.Lock(ctx, name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, release())
+	}()
+	return fn()
+}
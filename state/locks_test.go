@@ -0,0 +1,214 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// sharedLockBackend is a NamedLockBackend shared by several lockers, as separate
+// processes would share a real backend
+type sharedLockBackend struct {
+	mu     sync.Mutex
+	owners map[string]string
+}
+
+func (b *sharedLockBackend) AcquireNamedLock(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if held, ok := b.owners[name]; ok && held != owner {
+		return false, nil
+	}
+	b.owners[name] = owner
+	return true, nil
+}
+
+func (b *sharedLockBackend) ReleaseNamedLock(ctx context.Context, name, owner string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.owners[name] == owner {
+		delete(b.owners, name)
+	}
+	return nil
+}
+
+// TestNamedLocks validates named locks serialize work in-process and across lockers
+func TestNamedLocks(t *testing.T) {
+	backend := &sharedLockBackend{owners: map[string]string{}}
+	lockers := []NamedLocker{NewMemoryLocker()}
+	for _, owner := range []string{"a", "b"} {
+		l := NewBackendLocker(backend, owner, time.Minute)
+		l.SetRetryInterval(time.Millisecond)
+		lockers = append(lockers, l)
+	}
+
+	for i, locker := range lockers {
+		var mu sync.Mutex
+		active, peak := 0, 0
+		var wg sync.WaitGroup
+		for j := 0; j < 8; j++ {
+			l := locker
+			if i > 0 {
+				l = lockers[1+j%2] // alternate between the two backend lockers
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := WithLock(context.Background(), l, "schema:public", func() error {
+					mu.Lock()
+					active++
+					if active > peak {
+						peak = active
+					}
+					mu.Unlock()
+					time.Sleep(time.Millisecond)
+					mu.Lock()
+					active--
+					mu.Unlock()
+					return nil
+				})
+				if err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+		if peak != 1 {
+			t.Errorf("locker %d allowed %d concurrent holders", i, peak)
+		}
+	}
+
+	release, _ := lockers[0].Lock(context.Background(), "busy")
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if _, err := lockers[0].Lock(ctx, "busy"); err == nil {
+		t.Error("expected timeout waiting for a held lock")
+	}
+}
+
+// failingReleaseBackend grants every lock but cannot release them
+type failingReleaseBackend struct{}
+
+func (failingReleaseBackend) AcquireNamedLock(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	return true, nil
+}
+
+func (failingReleaseBackend) ReleaseNamedLock(ctx context.Context, name, owner string) error {
+	return errors.New("backend unavailable")
+}
+
+// TestNamedLockReleaseError validates a failed backend release is reported to the caller
+func TestNamedLockReleaseError(t *testing.T) {
+	locker := NewBackendLocker(failingReleaseBackend{}, "a", time.Minute)
+
+	fnErr := errors.New("fn failed")
+	err := WithLock(context.Background(), locker, "schema:public", func() error { return fnErr })
+	var backendErr *StateBackendError
+	if !errors.Is(err, fnErr) || !errors.As(err, &backendErr) {
+		t.Fatalf("expected fn and release errors, got %v", err)
+	}
+
+	// the in-process lock is released even though the backend release failed
+	release, err := locker.Lock(context.Background(), "schema:public")
+	if err != nil {
+		t.Fatalf("Lock: %v", err)
+	}
+	if err := release(); err == nil {
+		t.Error("expected release error")
+	}
+}
+
+// renewingLockBackend counts acquisitions and can fail or lose held locks
+type renewingLockBackend struct {
+	mu       sync.Mutex
+	acquired int
+	fail     bool   // acquisitions after the first fail
+	stolenBy string // acquisitions after the first report the lock held by another owner
+}
+
+func (b *renewingLockBackend) AcquireNamedLock(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.acquired++
+	switch {
+	case b.acquired == 1:
+		return true, nil
+	case b.fail:
+		return false, errors.New("backend unavailable")
+	case b.stolenBy != "":
+		return false, nil
+	}
+	return true, nil
+}
+
+func (b *renewingLockBackend) ReleaseNamedLock(ctx context.Context, name, owner string) error {
+	return nil
+}
+
+func (b *renewingLockBackend) acquisitions() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.acquired
+}
+
+// TestBackendLockRenewal validates held locks are renewed and renewal failures surface
+func TestBackendLockRenewal(t *testing.T) {
+	tests := []struct {
+		name    string
+		backend *renewingLockBackend
+		want    error
+	}{
+		{name: "renewed", backend: &renewingLockBackend{}},
+		{name: "backend failure", backend: &renewingLockBackend{fail: true}},
+		{name: "lost", backend: &renewingLockBackend{stolenBy: "b"}, want: ErrLockLost},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			locker := NewBackendLocker(tt.backend, "a", 15*time.Millisecond)
+			renewErrors := make(chan error, 100)
+			locker.SetRenewErrorHandler(func(name string, err error) { renewErrors <- err })
+
+			release, err := locker.Lock(context.Background(), "schema:public")
+			if err != nil {
+				t.Fatal(err)
+			}
+			// the lock, then two renewals, or one for a lock lost on the first
+			want := 3
+			if tt.want != nil {
+				want = 2
+			}
+			deadline := time.Now().Add(5 * time.Second)
+			for tt.backend.acquisitions() < want && time.Now().Before(deadline) {
+				time.Sleep(time.Millisecond)
+			}
+			if tt.want != nil {
+				// a lost lock is not renewed again
+				time.Sleep(50 * time.Millisecond)
+				if n := tt.backend.acquisitions(); n != 2 {
+					t.Errorf("lost lock renewed: %d acquisitions", n)
+				}
+			} else if n := tt.backend.acquisitions(); n < 3 {
+				t.Fatalf("held lock renewed %d times", n-1)
+			}
+			err = release()
+
+			failing := tt.backend.fail || tt.backend.stolenBy != ""
+			if !failing {
+				if err != nil || len(renewErrors) != 0 {
+					t.Errorf("release = %v, %d renewal errors", err, len(renewErrors))
+				}
+				return
+			}
+			var backendErr *StateBackendError
+			if tt.want != nil && !errors.Is(err, tt.want) || tt.want == nil && !errors.As(err, &backendErr) {
+				t.Errorf("release = %v, want the renewal failure", err)
+			}
+			if len(renewErrors) == 0 {
+				t.Error("renewal failure was not reported to the handler")
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+// Package state provides asynchronous backend operations recorded in state
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+)
+
+// DefaultOperationPollInterval is the delay between polls when an operation suggests none
+const DefaultOperationPollInterval = 2 * time.Second
+
+// PendingOperation is an asynchronous operation recorded in state until it reaches a
+// final state, so it is polled again after a provider restart
+type PendingOperation struct {
+	Operation  core.OperationRef     `json:"operation"`
+	LastStatus *core.OperationStatus `json:"last_status,omitempty"`
+	Polls      int                   `json:"polls,omitempty"`
+	RecordedAt time.Time             `json:"recorded_at"`
+}
+
+// OperationStatusFunc returns the current status of an operation
+type OperationStatusFunc func(ctx context.Context, ref core.OperationRef) (*core.OperationStatus, error)
+
+// ProviderOperationStatus polls operations through the provider's GetOperationStatus function
+func ProviderOperationStatus(provider core.Provider) OperationStatusFunc {
+	return func(ctx context.Context, ref core.OperationRef) (*core.OperationStatus, error) {
+		input, err := json.Marshal(&core.GetOperationStatusRequest{ResourceType: ref.ResourceType, Operation: ref})
+		if err != nil {
+			return nil, err
+		}
+		output, err := provider.CallFunction(ctx, "GetOperationStatus", input)
+		if err != nil {
+			return nil, err
+		}
+		var status core.OperationStatus
+		if err := json.Unmarshal(output, &status); err != nil {
+			return nil, fmt.Errorf("invalid operation status: %w", err)
+		}
+		return &status, nil
+	}
+}
+
+// operationStatuses maps operation actions to the status of the resource while they run
+var operationStatuses = map[string]ResourceStatus{
+	"create": ResourceStatusCreating,
+	"update": ResourceStatusUpdating,
+	"delete": ResourceStatusDeleting,
+}
+
+// TrackOperation records an operation a handler returned, marking its resource as
+// creating, updating or deleting until the operation completes
+func (us *UniversalState) TrackOperation(ref core.OperationRef) error {
+	if err := ref.Validate(); err != nil {
+		return err
+	}
+	if ref.StartedAt.IsZero() {
+		ref.StartedAt = clock.Now()
+	}
+	if us.Operations == nil {
+		us.Operations = make(map[string]*PendingOperation)
+	}
+	us.Operations[ref.Key()] = &PendingOperation{Operation: ref, RecordedAt: clock.Now()}
+	if resource, ok := us.GetResource(ref.ResourceID); ok {
+		if status, ok := operationStatuses[ref.Action]; ok {
+			resource.Status = status
+		}
+	}
+	us.LastUpdated = clock.Now()
+	return nil
+}
+
+// GetOperation returns a pending operation by its key
+func (us *UniversalState) GetOperation(key string) (*PendingOperation, bool) {
+	op, ok := us.Operations[key]
+	return op, ok
+}
+
+// PendingOperations returns the operations still in progress, oldest first
+func (us *UniversalState) PendingOperations() []*PendingOperation {
+	ops := make([]*PendingOperation, 0, len(us.Operations))
+	for _, key := range sortedKeys(us.Operations) {
+		ops = append(ops, us.Operations[key])
+	}
+	sort.SliceStable(ops, func(i, j int) bool {
+		return ops[i].Operation.StartedAt.Before(ops[j].Operation.StartedAt)
+	})
+	return ops
+}
+
+// RecordOperationStatus records a polled status. A final status removes the operation:
+// on success the resource takes the reported state and outputs, or is removed when the
+// operation deleted it; on failure the resource is marked as errored.
+func (us *UniversalState) RecordOperationStatus(status *core.OperationStatus) error {
+	key := status.Operation.Key()
+	op, ok := us.Operations[key]
+	if !ok {
+		return fmt.Errorf("operation %s is not tracked", key)
+	}
+	op.LastStatus = status
+	op.Polls++
+	us.LastUpdated = clock.Now()
+	if !status.Done() {
+		return nil
+	}
+
+	delete(us.Operations, key)
+	resource, exists := us.GetResource(op.Operation.ResourceID)
+	if !exists {
+		return nil
+	}
+	switch {
+	case status.State != core.OperationSucceeded:
+		resource.Status = ResourceStatusError
+	case op.Operation.Action == "delete":
+		us.RemoveResource(resource.ID)
+	default:
+		resource.Status = ResourceStatusActive
+		if status.ResourceState != nil {
+			resource.Data = status.ResourceState
+		}
+		if status.Outputs != nil {
+			return us.RecordOutputs(resource.ID, status.Outputs)
+		}
+	}
+	return nil
+}
+
+// PollOperations polls every pending operation once and records the results. Operations
+// that cannot be polled stay pending and their errors are returned together.
+func (us *UniversalState) PollOperations(ctx context.Context, status OperationStatusFunc) ([]*core.OperationStatus, error) {
+	var statuses []*core.OperationStatus
+	var errs []error
+	for _, op := range us.PendingOperations() {
+		current, err := status(ctx, op.Operation)
+		if err == nil {
+			current.Operation = op.Operation
+			err = us.RecordOperationStatus(current)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("operation %s: %w", op.Operation.Key(), err))
+			continue
+		}
+		statuses = append(statuses, current)
+	}
+	return statuses, errors.Join(errs...)
+}
+
+// WaitForOperation polls a pending operation until it reaches a final state or ctx is done
+func (us *UniversalState) WaitForOperation(ctx context.Context, key string, status OperationStatusFunc) (*core.OperationStatus, error) {
+	for {
+		op, ok := us.Operations[key]
+		if !ok {
+			return nil, fmt.Errorf("operation %s is not tracked", key)
+		}
+		current, err := status(ctx, op.Operation)
+		if err != nil {
+			return nil, fmt.Errorf("operation %s: %w", key, err)
+		}
+		current.Operation = op.Operation
+		if err := us.RecordOperationStatus(current); err != nil {
+			return nil, err
+		}
+		if current.Done() {
+			return current, nil
+		}
+
+		interval := op.Operation.PollInterval
+		if interval <= 0 {
+			interval = DefaultOperationPollInterval
+		}
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return current, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
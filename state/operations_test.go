@@ -0,0 +1,97 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+)
+
+// scriptedStatuses returns the given states in order for every operation it polls
+func scriptedStatuses(states ...string) OperationStatusFunc {
+	polls := 0
+	return func(ctx context.Context, ref core.OperationRef) (*core.OperationStatus, error) {
+		state := states[polls]
+		polls++
+		status := &core.OperationStatus{Operation: ref, State: state}
+		if state == core.OperationSucceeded {
+			status.ResourceState = map[string]interface{}{"partitions": 12}
+			status.Outputs = map[string]core.Output{"job_url": {Value: "https://console/jobs/" + ref.ID}}
+		}
+		return status, nil
+	}
+}
+
+// TestOperationsSurviveRestart validates pending operations persist in state and complete
+// into the resource once polled to success
+func TestOperationsSurviveRestart(t *testing.T) {
+	st := NewUniversalState("bq-1", "bigquery")
+	st.AddResource(NewUniversalResource("ds-1", "dataset", "events", "bigquery", "bq-1"))
+	ref := core.OperationRef{ID: "job-1", ResourceType: "dataset", ResourceID: "ds-1", Action: "create", PollInterval: time.Millisecond}
+	if err := st.TrackOperation(ref); err != nil {
+		t.Fatalf("TrackOperation: %v", err)
+	}
+	if st.Resources["ds-1"].Status != ResourceStatusCreating {
+		t.Errorf("resource status = %s, want creating", st.Resources["ds-1"].Status)
+	}
+
+	// a restarted provider reloads state and finds the operation still pending
+	data, _ := json.Marshal(st)
+	var restored UniversalState
+	if err := json.Unmarshal(data, &restored); err != nil {
+		t.Fatal(err)
+	}
+	pending := restored.PendingOperations()
+	if len(pending) != 1 || pending[0].Operation.Key() != "dataset/job-1" {
+		t.Fatalf("pending operations after reload = %+v", pending)
+	}
+
+	status, err := restored.WaitForOperation(context.Background(), "dataset/job-1", scriptedStatuses(core.OperationRunning, core.OperationSucceeded))
+	if err != nil || status.State != core.OperationSucceeded {
+		t.Fatalf("WaitForOperation = %+v, %v", status, err)
+	}
+	resource := restored.Resources["ds-1"]
+	if resource.Status != ResourceStatusActive || resource.Data["partitions"] != 12 || resource.Outputs["job_url"].Value == nil {
+		t.Errorf("resource not updated from final status: %+v", resource)
+	}
+	if len(restored.PendingOperations()) != 0 {
+		t.Error("completed operation should no longer be pending")
+	}
+}
+
+// TestPollOperations validates final states of failed and delete operations
+func TestPollOperations(t *testing.T) {
+	st := NewUniversalState("bq-1", "bigquery")
+	st.AddResource(NewUniversalResource("ds-1", "dataset", "events", "bigquery", "bq-1"))
+	st.AddResource(NewUniversalResource("ds-2", "dataset", "old", "bigquery", "bq-1"))
+	_ = st.TrackOperation(core.OperationRef{ID: "a", ResourceType: "dataset", ResourceID: "ds-1", Action: "update", StartedAt: time.Unix(1, 0)})
+	_ = st.TrackOperation(core.OperationRef{ID: "b", ResourceType: "dataset", ResourceID: "ds-2", Action: "delete", StartedAt: time.Unix(2, 0)})
+
+	statuses, err := st.PollOperations(context.Background(), scriptedStatuses(core.OperationFailed, core.OperationSucceeded))
+	if err != nil || len(statuses) != 2 {
+		t.Fatalf("PollOperations = %v, %v", statuses, err)
+	}
+	if st.Resources["ds-1"].Status != ResourceStatusError {
+		t.Errorf("failed update should mark the resource errored, got %s", st.Resources["ds-1"].Status)
+	}
+	if _, ok := st.Resources["ds-2"]; ok {
+		t.Error("successful delete should remove the resource")
+	}
+
+	_ = st.TrackOperation(core.OperationRef{ID: "c", ResourceType: "dataset"})
+	unreachable := func(ctx context.Context, ref core.OperationRef) (*core.OperationStatus, error) {
+		return nil, errors.New("backend unreachable")
+	}
+	if _, err := st.PollOperations(context.Background(), unreachable); err == nil {
+		t.Error("expected poll errors to be returned")
+	}
+	if _, ok := st.GetOperation("dataset/c"); !ok {
+		t.Error("operations that cannot be polled should stay pending")
+	}
+	if err := st.TrackOperation(core.OperationRef{ResourceType: "dataset"}); err == nil {
+		t.Error("operations without an ID should be rejected")
+	}
+}
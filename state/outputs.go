@@ -0,0 +1,162 @@
+// Package state provides resource outputs recorded in state and references to them
+package state
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+	"github.com/schemabounce/kolumn/sdk/types"
+)
+
+// OutputsSegment separates a resource address from the output name in an output
+// reference, e.g. "postgres.database.main.outputs.connection_string"
+const OutputsSegment = "." + types.OutputsAttribute + "."
+
+// OutputReference points at an output of a resource, optionally in another state
+type OutputReference struct {
+	State   string // empty for the referencing state
+	Address types.Address
+	Output  string
+}
+
+// String renders the reference as [state::]address.outputs.name
+func (r OutputReference) String() string {
+	s := r.Address.String() + OutputsSegment + r.Output
+	if r.State != "" {
+		s = r.State + types.StateSeparator + s
+	}
+	return s
+}
+
+// ParseOutputReference parses a [state::]address.outputs.name reference
+func ParseOutputReference(s string) (OutputReference, error) {
+	i := strings.LastIndex(s, OutputsSegment)
+	if i < 0 {
+		return OutputReference{}, fmt.Errorf("invalid output reference %q: expected address%sname", s, OutputsSegment)
+	}
+	name := s[i+len(OutputsSegment):]
+	if name == "" || strings.Contains(name, ".") {
+		return OutputReference{}, fmt.Errorf("invalid output reference %q: bad output name", s)
+	}
+	stateName, addr, err := types.ParseDependencyTarget(s[:i])
+	if err != nil {
+		return OutputReference{}, fmt.Errorf("invalid output reference %q: %w", s, err)
+	}
+	return OutputReference{State: stateName, Address: addr, Output: name}, nil
+}
+
+// Dependency returns the dependency of from on the referenced resource, so ordering
+// creates the publisher before the resources consuming its outputs
+func (r OutputReference) Dependency(from types.Address) types.Dependency {
+	return types.Dependency{From: from, To: r.Address, State: r.State}
+}
+
+// RecordOutputs replaces the outputs recorded for a resource. A redacted sensitive value
+// keeps the value already recorded, so redacted GetOutputs responses never overwrite it.
+func (us *UniversalState) RecordOutputs(resourceID string, outputs map[string]core.Output) error {
+	resource, ok := us.GetResource(resourceID)
+	if !ok {
+		return fmt.Errorf("resource %s not found", resourceID)
+	}
+	recorded := make(map[string]core.Output, len(outputs))
+	for name, output := range outputs {
+		if previous, ok := resource.Outputs[name]; ok && output.Sensitive && output.Value == core.RedactedOutputValue {
+			output.Value = previous.Value
+		}
+		recorded[name] = output
+	}
+	resource.Outputs = recorded
+	us.LastUpdated = clock.Now()
+	return nil
+}
+
+// ApplyOutputs records the outputs of a GetOutputs response in state; failed and
+// missing results are skipped
+func ApplyOutputs(state *UniversalState, resp *core.GetOutputsResponse) {
+	for _, result := range resp.Results {
+		if result.Error != "" || result.NotFound {
+			continue
+		}
+		_ = state.RecordOutputs(result.ResourceID, result.Outputs)
+	}
+}
+
+// ResolveOutput returns the output a reference points at. References to other states
+// must be resolved against those states.
+func (us *UniversalState) ResolveOutput(ref OutputReference) (core.Output, error) {
+	resource := findResourceByAddress(us, ref.Address)
+	if resource == nil {
+		return core.Output{}, fmt.Errorf("output reference %s: resource not found", ref)
+	}
+	output, ok := resource.Outputs[ref.Output]
+	if !ok {
+		return core.Output{}, fmt.Errorf("output reference %s: resource publishes no output %q", ref, ref.Output)
+	}
+	return output, nil
+}
+
+// OutputsByAddress returns the outputs of every resource keyed by address, redacting sensitive
+// values unless includeSensitive is set
+func (us *UniversalState) OutputsByAddress(includeSensitive bool) map[string]map[string]core.Output {
+	all := make(map[string]map[string]core.Output)
+	for _, resource := range us.Resources {
+		if resource == nil || len(resource.Outputs) == 0 {
+			continue
+		}
+		outputs := resource.Outputs
+		if !includeSensitive {
+			outputs = core.RedactOutputs(outputs)
+		}
+		all[resource.Address().String()] = outputs
+	}
+	return all
+}
+
+// ResolveReference returns the value a reference points at: a recorded output for
+// output references, otherwise the attribute path in the resource's data. References to
+// other states must be resolved against those states.
+func (us *UniversalState) ResolveReference(ref types.Reference) (interface{}, error) {
+	if ref.IsOutput() {
+		output, err := us.ResolveOutput(OutputReference{State: ref.State, Address: ref.Address, Output: ref.Path[1]})
+		return output.Value, err
+	}
+	resource := findResourceByAddress(us, ref.Address)
+	if resource == nil {
+		return nil, fmt.Errorf("reference %s: resource not found", ref)
+	}
+	value, ok := ref.Lookup(resource.Data)
+	if !ok {
+		return nil, fmt.Errorf("reference %s: resource has no such attribute", ref)
+	}
+	return value, nil
+}
+
+// ReferenceValues returns every resource's data keyed by address, with its outputs
+// under "outputs", ready to register with an interpolation resolver. Sensitive outputs
+// are redacted unless includeSensitive is set.
+func (us *UniversalState) ReferenceValues(includeSensitive bool) map[string]interface{} {
+	values := make(map[string]interface{}, len(us.Resources))
+	for _, resource := range us.Resources {
+		if resource == nil {
+			continue
+		}
+		value := make(map[string]interface{}, len(resource.Data)+1)
+		for k, v := range resource.Data {
+			value[k] = v
+		}
+		if len(resource.Outputs) > 0 {
+			outputs := resource.Outputs
+			if !includeSensitive {
+				outputs = core.RedactOutputs(outputs)
+			}
+			published := make(map[string]interface{}, len(outputs))
+			for name, output := range outputs {
+				published[name] = output.Value
+			}
+			value[types.OutputsAttribute] = published
+		}
+		values[resource.Address().String()] = value
+	}
+	return values
+}
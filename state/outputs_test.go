@@ -0,0 +1,86 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+	"github.com/schemabounce/kolumn/sdk/types"
+)
+
+// TestOutputReferences validates outputs are recorded, resolved and redacted
+func TestOutputReferences(t *testing.T) {
+	st := NewUniversalState("pg-1", "postgres")
+	st.AddResource(NewUniversalResource("db-1", "database", "main", "postgres", "pg-1"))
+
+	err := st.RecordOutputs("db-1", map[string]core.Output{
+		"endpoint": {Value: "main.internal:5432"},
+		"password": {Value: "hunter2", Sensitive: true},
+	})
+	if err != nil {
+		t.Fatalf("RecordOutputs: %v", err)
+	}
+
+	ref, err := ParseOutputReference("postgres.database.main.outputs.endpoint")
+	if err != nil {
+		t.Fatalf("ParseOutputReference: %v", err)
+	}
+	if ref.String() != "postgres.database.main.outputs.endpoint" {
+		t.Errorf("String = %s", ref)
+	}
+	if output, err := st.ResolveOutput(ref); err != nil || output.Value != "main.internal:5432" {
+		t.Errorf("ResolveOutput = %+v, %v", output, err)
+	}
+	ref.Output = "missing"
+	if _, err := st.ResolveOutput(ref); err == nil {
+		t.Error("expected error for unknown output")
+	}
+
+	remote, err := ParseOutputReference("shared::postgres.database.main.outputs.password")
+	if err != nil || remote.State != "shared" || remote.Output != "password" {
+		t.Errorf("cross-state reference = %+v, %v", remote, err)
+	}
+	for _, bad := range []string{"postgres.database.main", "postgres.database.main.outputs.", "postgres.database.main.outputs.a.b"} {
+		if _, err := ParseOutputReference(bad); err == nil {
+			t.Errorf("ParseOutputReference(%q) should fail", bad)
+		}
+	}
+
+	// a redacted response keeps the recorded sensitive value
+	ApplyOutputs(st, &core.GetOutputsResponse{Results: []core.ResourceOutputs{{
+		ResourceID: "db-1",
+		Outputs: map[string]core.Output{
+			"endpoint": {Value: "main.internal:6432"},
+			"password": {Value: core.RedactedOutputValue, Sensitive: true},
+		},
+	}}})
+	outputs := st.Resources["db-1"].Outputs
+	if outputs["password"].Value != "hunter2" || outputs["endpoint"].Value != "main.internal:6432" {
+		t.Errorf("unexpected outputs after apply: %+v", outputs)
+	}
+	if redacted := st.OutputsByAddress(false)["postgres.database.main"]; redacted["password"].Value != core.RedactedOutputValue {
+		t.Errorf("OutputsByAddress should redact: %+v", redacted)
+	}
+}
+
+// TestResolveReference validates attribute and output references resolve from state
+func TestResolveReference(t *testing.T) {
+	st := NewUniversalState("pg-1", "postgres")
+	r := NewUniversalResource("db-1", "database", "main", "postgres", "pg-1")
+	r.Data["hosts"] = []interface{}{"a.internal", "b.internal"}
+	st.AddResource(r)
+	_ = st.RecordOutputs("db-1", map[string]core.Output{"password": {Value: "hunter2", Sensitive: true}})
+
+	ref, _ := types.ParseReference("postgres.database.main.hosts[1]")
+	if value, err := st.ResolveReference(ref); err != nil || value != "b.internal" {
+		t.Errorf("ResolveReference = %v, %v", value, err)
+	}
+	ref, _ = types.ParseReference("postgres.database.main.outputs.password")
+	if value, err := st.ResolveReference(ref); err != nil || value != "hunter2" {
+		t.Errorf("ResolveReference(output) = %v, %v", value, err)
+	}
+
+	values := st.ReferenceValues(false)["postgres.database.main"].(map[string]interface{})
+	if values[types.OutputsAttribute].(map[string]interface{})["password"] != core.RedactedOutputValue {
+		t.Errorf("ReferenceValues should redact sensitive outputs: %v", values)
+	}
+}
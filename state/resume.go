@@ -0,0 +1,220 @@
+// Package state provides resumption of applies interrupted by a provider crash
+package state
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+	"github.com/schemabounce/kolumn/sdk/helpers/ids"
+)
+
+// ResumePolicy decides what happens to an interrupted apply once its pending operations
+// have been reconciled with the backend
+type ResumePolicy string
+
+const (
+	// ResumeContinue keeps every change that finished and leaves the remaining targets
+	// for the apply to continue with
+	ResumeContinue ResumePolicy = "continue"
+
+	// ResumeRollbackOnFailure continues like ResumeContinue unless an operation failed,
+	// in which case every target is rolled back
+	ResumeRollbackOnFailure ResumePolicy = "rollback_on_failure"
+
+	// ResumeRollback rolls every target back to its state before the apply
+	ResumeRollback ResumePolicy = "rollback"
+)
+
+// ApplyCheckpoint records an apply in progress with a snapshot of the resources it
+// targets, so a restarted provider can resume or roll it back
+type ApplyCheckpoint struct {
+	ID        string    `json:"id"`
+	StartedAt time.Time `json:"started_at"`
+	Targets   []string  `json:"targets"`
+	Completed []string  `json:"completed,omitempty"`
+
+	// Before holds each target as it was when the apply began; targets the apply
+	// creates are absent
+	Before map[string]*UniversalResource `json:"before,omitempty"`
+}
+
+// Clone returns a deep copy of the checkpoint
+func (c *ApplyCheckpoint) Clone() *ApplyCheckpoint {
+	clone := *c
+	clone.Targets = append([]string(nil), c.Targets...)
+	clone.Completed = append([]string(nil), c.Completed...)
+	clone.Before = make(map[string]*UniversalResource, len(c.Before))
+	for id, resource := range c.Before {
+		clone.Before[id] = resource.Clone()
+	}
+	return &clone
+}
+
+// ApplyInProgressError is returned when an apply begins while another one is recorded
+type ApplyInProgressError struct {
+	ApplyID string
+}
+
+func (e *ApplyInProgressError) Error() string {
+	return fmt.Sprintf("apply %s is still in progress; resume it first", e.ApplyID)
+}
+
+// RollbackFunc reverts one target in the backend. before is nil when the apply created
+// the resource and after is nil when the apply already deleted it.
+type RollbackFunc func(ctx context.Context, before, after *UniversalResource) error
+
+// ResumeOptions controls ResumeApply
+type ResumeOptions struct {
+	Policy ResumePolicy
+
+	// Status polls pending operations, e.g. ProviderOperationStatus(provider)
+	Status OperationStatusFunc
+
+	// Rollback reverts targets in the backend before their state is restored; without
+	// it only state is restored and the next plan reconciles the backend
+	Rollback RollbackFunc
+}
+
+// ResumeReport describes how an interrupted apply was resumed
+type ResumeReport struct {
+	ApplyID    string                  `json:"apply_id"`
+	Succeeded  []*core.OperationStatus `json:"succeeded,omitempty"`
+	Failed     []*core.OperationStatus `json:"failed,omitempty"`
+	RolledBack []string                `json:"rolled_back,omitempty"`
+	Remaining  []string                `json:"remaining,omitempty"` // targets the continued apply still has to change
+}
+
+// BeginApply records the start of an apply changing targets, snapshotting them first
+func (us *UniversalState) BeginApply(targets []string) (*ApplyCheckpoint, error) {
+	if us.Apply != nil {
+		return nil, &ApplyInProgressError{ApplyID: us.Apply.ID}
+	}
+	checkpoint := &ApplyCheckpoint{
+		ID:        ids.Prefixed("apply"),
+		StartedAt: clock.Now(),
+		Targets:   sortedCopy(targets),
+		Before:    make(map[string]*UniversalResource),
+	}
+	for _, id := range checkpoint.Targets {
+		if resource, ok := us.GetResource(id); ok {
+			checkpoint.Before[id] = resource.Clone()
+		}
+	}
+	us.Apply = checkpoint
+	us.LastUpdated = clock.Now()
+	return checkpoint, nil
+}
+
+// MarkApplied records that the apply finished changing a target
+func (us *UniversalState) MarkApplied(resourceID string) {
+	if us.Apply == nil || containsString(us.Apply.Completed, resourceID) {
+		return
+	}
+	us.Apply.Completed = append(us.Apply.Completed, resourceID)
+	us.LastUpdated = clock.Now()
+}
+
+// FinishApply clears the checkpoint once every target has been applied
+func (us *UniversalState) FinishApply() {
+	us.Apply = nil
+	us.LastUpdated = clock.Now()
+}
+
+// InterruptedApply returns the checkpoint of an apply that did not finish, if any
+func (us *UniversalState) InterruptedApply() (*ApplyCheckpoint, bool) {
+	return us.Apply, us.Apply != nil
+}
+
+// ResumeApply reconciles an interrupted apply after a restart. Pending operations are
+// polled until they finish, then the policy decides whether the apply continues with
+// its remaining targets or every target is rolled back. It returns nil when no apply was
+// interrupted. Operations that cannot be polled leave the apply in place so resuming
+// can be retried.
+func (us *UniversalState) ResumeApply(ctx context.Context, opts ResumeOptions) (*ResumeReport, error) {
+	checkpoint, ok := us.InterruptedApply()
+	if !ok {
+		return nil, nil
+	}
+	if opts.Status == nil && len(us.Operations) > 0 {
+		return nil, errors.New("resume apply: a status function is required to reconcile pending operations")
+	}
+
+	report := &ResumeReport{ApplyID: checkpoint.ID}
+	targets := stringSet(checkpoint.Targets)
+	for _, op := range us.PendingOperations() {
+		if !targets[op.Operation.ResourceID] {
+			continue
+		}
+		status, err := us.WaitForOperation(ctx, op.Operation.Key(), opts.Status)
+		if err != nil {
+			return report, fmt.Errorf("resume apply %s: %w", checkpoint.ID, err)
+		}
+		if status.State == core.OperationSucceeded {
+			report.Succeeded = append(report.Succeeded, status)
+			us.MarkApplied(op.Operation.ResourceID)
+		} else {
+			report.Failed = append(report.Failed, status)
+		}
+	}
+
+	policy := opts.Policy
+	if policy == "" {
+		policy = ResumeContinue
+	}
+	if policy == ResumeRollback || (policy == ResumeRollbackOnFailure && len(report.Failed) > 0) {
+		rolledBack, err := us.rollbackApply(ctx, checkpoint, opts.Rollback)
+		report.RolledBack = rolledBack
+		if err != nil {
+			return report, fmt.Errorf("resume apply %s: %w", checkpoint.ID, err)
+		}
+		us.FinishApply()
+		return report, nil
+	}
+
+	completed := stringSet(checkpoint.Completed)
+	for _, id := range checkpoint.Targets {
+		if !completed[id] {
+			report.Remaining = append(report.Remaining, id)
+		}
+	}
+	if len(report.Remaining) == 0 {
+		us.FinishApply()
+	}
+	return report, nil
+}
+
+// rollbackApply restores every target to its snapshot, reverting it in the backend first
+// when rollback is set. Targets already restored stay restored if a later one fails.
+func (us *UniversalState) rollbackApply(ctx context.Context, checkpoint *ApplyCheckpoint, rollback RollbackFunc) ([]string, error) {
+	var rolledBack []string
+	for _, id := range checkpoint.Targets {
+		before := checkpoint.Before[id]
+		after, _ := us.GetResource(id)
+		if before == nil && after == nil {
+			continue
+		}
+		if rollback != nil {
+			if err := rollback(ctx, before, after); err != nil {
+				return rolledBack, fmt.Errorf("rollback %s: %w", id, err)
+			}
+		}
+		if before == nil {
+			us.RemoveResource(id)
+		} else {
+			us.AddResource(before.Clone())
+		}
+		rolledBack = append(rolledBack, id)
+	}
+	return rolledBack, nil
+}
+
+func stringSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
@@ -0,0 +1,134 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+)
+
+// interruptedApply returns state as a crashed provider left it: ds-1 was updated, ds-2
+// was being created by a pending operation and ds-3 was not reached
+func interruptedApply(t *testing.T) (*UniversalState, *ApplyCheckpoint) {
+	t.Helper()
+	st := NewUniversalState("bq-1", "bigquery")
+	original := NewUniversalResource("ds-1", "dataset", "events", "bigquery", "bq-1")
+	original.Data = map[string]interface{}{"location": "US"}
+	st.AddResource(original)
+	st.AddResource(NewUniversalResource("ds-3", "dataset", "audit", "bigquery", "bq-1"))
+
+	checkpoint, err := st.BeginApply([]string{"ds-3", "ds-1", "ds-2"})
+	if err != nil {
+		t.Fatalf("BeginApply: %v", err)
+	}
+	st.Resources["ds-1"].Data["location"] = "EU"
+	st.MarkApplied("ds-1")
+	st.AddResource(NewUniversalResource("ds-2", "dataset", "staging", "bigquery", "bq-1"))
+	ref := core.OperationRef{ID: "job-2", ResourceType: "dataset", ResourceID: "ds-2", Action: "create", PollInterval: time.Millisecond}
+	if err := st.TrackOperation(ref); err != nil {
+		t.Fatalf("TrackOperation: %v", err)
+	}
+
+	// the restarted provider reloads state from its backend
+	data, _ := json.Marshal(st)
+	var restored UniversalState
+	if err := json.Unmarshal(data, &restored); err != nil {
+		t.Fatal(err)
+	}
+	return &restored, checkpoint
+}
+
+// TestResumeApplyContinue validates a resumed apply reconciles pending operations and
+// reports the targets it still has to change
+func TestResumeApplyContinue(t *testing.T) {
+	st, checkpoint := interruptedApply(t)
+	if got, ok := st.InterruptedApply(); !ok || got.ID != checkpoint.ID {
+		t.Fatalf("InterruptedApply = %+v, %v", got, ok)
+	}
+	if _, err := st.BeginApply([]string{"ds-1"}); !errors.As(err, new(*ApplyInProgressError)) {
+		t.Fatalf("BeginApply during an interrupted apply = %v", err)
+	}
+
+	report, err := st.ResumeApply(context.Background(), ResumeOptions{Status: scriptedStatuses(core.OperationRunning, core.OperationSucceeded)})
+	if err != nil {
+		t.Fatalf("ResumeApply: %v", err)
+	}
+	if len(report.Succeeded) != 1 || len(report.Failed) != 0 {
+		t.Errorf("report = %+v", report)
+	}
+	if len(report.Remaining) != 1 || report.Remaining[0] != "ds-3" {
+		t.Errorf("remaining = %v, want [ds-3]", report.Remaining)
+	}
+	if st.Resources["ds-2"].Status != ResourceStatusActive {
+		t.Errorf("ds-2 status = %s, want active", st.Resources["ds-2"].Status)
+	}
+
+	st.MarkApplied("ds-3")
+	report, err = st.ResumeApply(context.Background(), ResumeOptions{})
+	if err != nil || len(report.Remaining) != 0 {
+		t.Fatalf("ResumeApply after finishing = %+v, %v", report, err)
+	}
+	if _, ok := st.InterruptedApply(); ok {
+		t.Error("apply should be finished once nothing remains")
+	}
+}
+
+// TestResumeApplyRollbackOnFailure validates a failed operation rolls every target back
+// to its snapshot
+func TestResumeApplyRollbackOnFailure(t *testing.T) {
+	st, _ := interruptedApply(t)
+
+	var reverted []string
+	rollback := func(ctx context.Context, before, after *UniversalResource) error {
+		if before == nil {
+			reverted = append(reverted, "drop "+after.ID)
+		} else {
+			reverted = append(reverted, "restore "+before.ID)
+		}
+		return nil
+	}
+	report, err := st.ResumeApply(context.Background(), ResumeOptions{
+		Policy:   ResumeRollbackOnFailure,
+		Status:   scriptedStatuses(core.OperationFailed),
+		Rollback: rollback,
+	})
+	if err != nil {
+		t.Fatalf("ResumeApply: %v", err)
+	}
+	if len(report.Failed) != 1 || len(report.RolledBack) != 3 {
+		t.Errorf("report = %+v", report)
+	}
+	if len(reverted) != 3 || reverted[1] != "drop ds-2" {
+		t.Errorf("reverted = %v", reverted)
+	}
+	if st.Resources["ds-1"].Data["location"] != "US" {
+		t.Errorf("ds-1 not restored: %+v", st.Resources["ds-1"].Data)
+	}
+	if _, ok := st.Resources["ds-2"]; ok {
+		t.Error("resource created by the apply should be removed")
+	}
+	if _, ok := st.InterruptedApply(); ok {
+		t.Error("rolled back apply should be finished")
+	}
+}
+
+// TestResumeApplyRollbackError validates a failed backend rollback keeps the apply for a retry
+func TestResumeApplyRollbackError(t *testing.T) {
+	st, _ := interruptedApply(t)
+	_, err := st.ResumeApply(context.Background(), ResumeOptions{
+		Policy: ResumeRollback,
+		Status: scriptedStatuses(core.OperationSucceeded),
+		Rollback: func(ctx context.Context, before, after *UniversalResource) error {
+			return errors.New("permission denied")
+		},
+	})
+	if err == nil {
+		t.Fatal("expected rollback error")
+	}
+	if _, ok := st.InterruptedApply(); !ok {
+		t.Error("apply should stay recorded when rollback fails")
+	}
+}
@@ -0,0 +1,133 @@
+// Package state provides persistence of discovery scan jobs in the state backend
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+)
+
+// ScanJobsMetadataKey is the state metadata key holding scan jobs
+const ScanJobsMetadataKey = "scan_jobs"
+
+// DefaultScanJobRetention is the number of finished scan jobs kept in state
+const DefaultScanJobRetention = 20
+
+// ScanJobStore keeps scan jobs in the state metadata of a state backend, so tooling
+// can poll a multi-hour scan without holding a connection open. Running jobs are always
+// kept; of the finished jobs, only the most recent Retention are.
+type ScanJobStore struct {
+	backend   StateBackendProvider
+	Retention int
+
+	mu sync.Mutex // serializes the load-modify-save cycles of this store
+}
+
+var _ core.ScanJobStore = (*ScanJobStore)(nil)
+
+// NewScanJobStore creates a store persisting to backend
+func NewScanJobStore(backend StateBackendProvider) *ScanJobStore {
+	return &ScanJobStore{backend: backend, Retention: DefaultScanJobRetention}
+}
+
+// SaveScanJob implements core.ScanJobStore
+func (s *ScanJobStore) SaveScanJob(ctx context.Context, job *core.ScanJob) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, err := s.load(ctx)
+	if err != nil {
+		return fmt.Errorf("save scan job: %w", err)
+	}
+	jobs, err := scanJobsFromState(st)
+	if err != nil {
+		return fmt.Errorf("save scan job: %w", err)
+	}
+	jobs[job.ID] = job
+	pruneScanJobs(jobs, s.Retention)
+
+	if st.Metadata == nil {
+		st.Metadata = make(map[string]interface{})
+	}
+	st.Metadata[ScanJobsMetadataKey] = jobs
+	if err := s.backend.SaveState(ctx, st); err != nil {
+		return fmt.Errorf("save scan job: %w", err)
+	}
+	return nil
+}
+
+// LoadScanJob implements core.ScanJobStore
+func (s *ScanJobStore) LoadScanJob(ctx context.Context, id string) (*core.ScanJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, err := s.load(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("load scan job: %w", err)
+	}
+	jobs, err := scanJobsFromState(st)
+	if err != nil {
+		return nil, fmt.Errorf("load scan job: %w", err)
+	}
+	job, ok := jobs[id]
+	if !ok {
+		return nil, core.ErrScanJobNotFound
+	}
+	return job, nil
+}
+
+// load returns the backend's state, or a new one when the backend has none yet
+func (s *ScanJobStore) load(ctx context.Context) (*UniversalState, error) {
+	exists, err := s.backend.StateExists(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return NewUniversalState(s.backend.GetProviderID(), s.backend.GetProviderType()), nil
+	}
+	return s.backend.LoadState(ctx)
+}
+
+// scanJobsFromState decodes the scan jobs of a state, which are generic JSON values
+// once the state was loaded from a backend
+func scanJobsFromState(st *UniversalState) (map[string]*core.ScanJob, error) {
+	jobs := make(map[string]*core.ScanJob)
+	raw, ok := st.Metadata[ScanJobsMetadataKey]
+	if !ok {
+		return jobs, nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &jobs); err != nil {
+		return nil, fmt.Errorf("invalid %s metadata: %w", ScanJobsMetadataKey, err)
+	}
+	return jobs, nil
+}
+
+// pruneScanJobs drops the oldest finished jobs beyond retention
+func pruneScanJobs(jobs map[string]*core.ScanJob, retention int) {
+	var finished []*core.ScanJob
+	for _, job := range jobs {
+		if job.Done() {
+			finished = append(finished, job)
+		}
+	}
+	if len(finished) <= retention {
+		return
+	}
+	sort.Slice(finished, func(i, j int) bool {
+		if !finished[i].StartedAt.Equal(finished[j].StartedAt) {
+			return finished[i].StartedAt.After(finished[j].StartedAt)
+		}
+		return finished[i].ID > finished[j].ID
+	})
+	for _, job := range finished[retention:] {
+		delete(jobs, job.ID)
+	}
+}
@@ -0,0 +1,42 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+)
+
+// TestScanJobStore validates scan jobs round-trip through state metadata and are pruned
+func TestScanJobStore(t *testing.T) {
+	backend := &memoryBackend{BackendProviderHelper: NewBackendProviderHelper("pg", "postgres", true, true), state: NewUniversalState("pg", "postgres")}
+	store := NewScanJobStore(backend)
+	store.Retention = 2
+	ctx := context.Background()
+
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 4; i++ {
+		job := &core.ScanJob{ID: fmt.Sprintf("scan-%d", i), State: core.OperationSucceeded, StartedAt: start.Add(time.Duration(i) * time.Hour)}
+		if i == 0 {
+			job.State = core.OperationRunning
+		}
+		if err := store.SaveScanJob(ctx, job); err != nil {
+			t.Fatalf("SaveScanJob: %v", err)
+		}
+	}
+
+	if job, err := store.LoadScanJob(ctx, "scan-0"); err != nil || job.State != core.OperationRunning {
+		t.Errorf("running job should be kept: %+v, %v", job, err)
+	}
+	if _, err := store.LoadScanJob(ctx, "scan-1"); !errors.Is(err, core.ErrScanJobNotFound) {
+		t.Errorf("oldest finished job should be pruned, got %v", err)
+	}
+	for _, id := range []string{"scan-2", "scan-3"} {
+		if _, err := store.LoadScanJob(ctx, id); err != nil {
+			t.Errorf("LoadScanJob(%s): %v", id, err)
+		}
+	}
+}
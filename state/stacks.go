@@ -0,0 +1,314 @@
+// Package state provides stacks: named groups of resources applied and destroyed together
+package state
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Stack is a named set of resources that is applied and destroyed as a unit. Stacks
+// depend on other stacks, so a data platform can be composed from modules, e.g. an
+// "ingest" stack on top of a "network" stack.
+type Stack struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+
+	// Resources are the IDs of the member resources; a resource belongs to at most one stack
+	Resources []string `json:"resources"`
+
+	// DependsOn names the stacks that must be applied before this one
+	DependsOn []string `json:"depends_on,omitempty"`
+
+	// Outputs are the stack's published values, e.g. an endpoint other stacks consume
+	Outputs map[string]StackOutput `json:"outputs,omitempty"`
+
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// StackOutput is a stack-level output: a literal Value, or the Attribute of a member
+// Resource read from its state data when resolved
+type StackOutput struct {
+	Value       interface{} `json:"value,omitempty"`
+	Resource    string      `json:"resource,omitempty"`
+	Attribute   string      `json:"attribute,omitempty"` // dot-separated path into the resource data
+	Sensitive   bool        `json:"sensitive,omitempty"`
+	Description string      `json:"description,omitempty"`
+}
+
+// Clone returns a deep copy of the stack
+func (s *Stack) Clone() *Stack {
+	clone := *s
+	clone.Resources = append([]string(nil), s.Resources...)
+	clone.DependsOn = append([]string(nil), s.DependsOn...)
+	if s.Outputs != nil {
+		clone.Outputs = make(map[string]StackOutput, len(s.Outputs))
+		for k, v := range s.Outputs {
+			clone.Outputs[k] = v
+		}
+	}
+	if s.Metadata != nil {
+		clone.Metadata = make(map[string]interface{}, len(s.Metadata))
+		for k, v := range s.Metadata {
+			clone.Metadata[k] = v
+		}
+	}
+	return &clone
+}
+
+// StackNotFoundError is returned for operations on a stack the state does not define
+type StackNotFoundError struct {
+	Name string
+}
+
+func (e *StackNotFoundError) Error() string {
+	return fmt.Sprintf("stack %q not found", e.Name)
+}
+
+// AddStack defines or replaces a stack. Its member resources and the stacks it depends
+// on must already exist, and no member may belong to another stack.
+func (us *UniversalState) AddStack(stack *Stack) error {
+	if stack == nil || stack.Name == "" {
+		return fmt.Errorf("stack must have a name")
+	}
+	for _, id := range stack.Resources {
+		if _, ok := us.GetResource(id); !ok {
+			return fmt.Errorf("stack %s: resource %s not found", stack.Name, id)
+		}
+		if owner, ok := us.StackOf(id); ok && owner != stack.Name {
+			return fmt.Errorf("stack %s: resource %s already belongs to stack %s", stack.Name, id, owner)
+		}
+	}
+	for _, dep := range stack.DependsOn {
+		if dep == stack.Name {
+			return fmt.Errorf("stack %s depends on itself", stack.Name)
+		}
+		if _, ok := us.Stacks[dep]; !ok {
+			return fmt.Errorf("stack %s: %w", stack.Name, &StackNotFoundError{Name: dep})
+		}
+	}
+	for name, output := range stack.Outputs {
+		if output.Resource != "" && !containsString(stack.Resources, output.Resource) {
+			return fmt.Errorf("stack %s: output %s reads resource %s outside the stack", stack.Name, name, output.Resource)
+		}
+	}
+
+	if us.Stacks == nil {
+		us.Stacks = make(map[string]*Stack)
+	}
+	previous := us.Stacks[stack.Name]
+	us.Stacks[stack.Name] = stack
+	if _, err := us.StackApplyOrder(); err != nil {
+		if previous != nil {
+			us.Stacks[stack.Name] = previous
+		} else {
+			delete(us.Stacks, stack.Name)
+		}
+		return err
+	}
+	us.LastUpdated = clock.Now()
+	us.Version++
+	return nil
+}
+
+// RemoveStack deletes a stack definition; its resources stay in the state. A stack
+// other stacks depend on cannot be removed.
+func (us *UniversalState) RemoveStack(name string) error {
+	if _, ok := us.Stacks[name]; !ok {
+		return &StackNotFoundError{Name: name}
+	}
+	if dependents := us.stackDependents(name); len(dependents) > 0 {
+		return fmt.Errorf("stack %s is depended on by %s", name, strings.Join(dependents, ", "))
+	}
+	delete(us.Stacks, name)
+	us.LastUpdated = clock.Now()
+	us.Version++
+	return nil
+}
+
+// GetStack returns a stack by name
+func (us *UniversalState) GetStack(name string) (*Stack, bool) {
+	stack, ok := us.Stacks[name]
+	return stack, ok
+}
+
+// StackOf returns the stack a resource belongs to
+func (us *UniversalState) StackOf(resourceID string) (string, bool) {
+	for name, stack := range us.Stacks {
+		if containsString(stack.Resources, resourceID) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// StackResources returns the member resources of a stack sorted by ID, skipping
+// members no longer in the state
+func (us *UniversalState) StackResources(name string) ([]*UniversalResource, error) {
+	stack, ok := us.Stacks[name]
+	if !ok {
+		return nil, &StackNotFoundError{Name: name}
+	}
+	ids := append([]string(nil), stack.Resources...)
+	sort.Strings(ids)
+	resources := make([]*UniversalResource, 0, len(ids))
+	for _, id := range ids {
+		if r, ok := us.GetResource(id); ok {
+			resources = append(resources, r)
+		}
+	}
+	return resources, nil
+}
+
+// StackOutputs resolves a stack's outputs. Sensitive values are included; callers
+// rendering them must redact.
+func (us *UniversalState) StackOutputs(name string) (map[string]interface{}, error) {
+	stack, ok := us.Stacks[name]
+	if !ok {
+		return nil, &StackNotFoundError{Name: name}
+	}
+	values := make(map[string]interface{}, len(stack.Outputs))
+	for key, output := range stack.Outputs {
+		if output.Resource == "" {
+			values[key] = output.Value
+			continue
+		}
+		r, ok := us.GetResource(output.Resource)
+		if !ok {
+			return nil, fmt.Errorf("stack %s: output %s: resource %s not found", name, key, output.Resource)
+		}
+		value, ok := lookupPath(r.Data, output.Attribute)
+		if !ok {
+			return nil, fmt.Errorf("stack %s: output %s: resource %s has no attribute %s", name, key, output.Resource, output.Attribute)
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+// StackApplyOrder returns the batches in which stacks are applied: every stack after
+// the stacks it depends on. With no targets every stack is ordered; otherwise the
+// targets and the stacks they transitively depend on are.
+func (us *UniversalState) StackApplyOrder(targets ...string) ([][]string, error) {
+	after := make(map[string][]string, len(us.Stacks))
+	for name, stack := range us.Stacks {
+		after[name] = stack.DependsOn
+	}
+	nodes, err := us.stackClosure(targets, after)
+	if err != nil {
+		return nil, err
+	}
+	return topoBatches(nodes, after)
+}
+
+// StackDestroyOrder returns the batches in which stacks are destroyed: every stack
+// after the stacks depending on it. With no targets every stack is ordered; otherwise
+// the targets and the stacks transitively depending on them are.
+func (us *UniversalState) StackDestroyOrder(targets ...string) ([][]string, error) {
+	after := make(map[string][]string, len(us.Stacks))
+	for name, stack := range us.Stacks {
+		for _, dep := range stack.DependsOn {
+			after[dep] = append(after[dep], name)
+		}
+	}
+	nodes, err := us.stackClosure(targets, after)
+	if err != nil {
+		return nil, err
+	}
+	return topoBatches(nodes, after)
+}
+
+// StackLockName is the NamedLocker name serializing operations on a stack
+func StackLockName(name string) string {
+	return "stack:" + name
+}
+
+// LockStacks acquires the locks of the named stacks in sorted order, so concurrent
+// callers locking overlapping stacks cannot deadlock, and returns the function
+// releasing them all
+func LockStacks(ctx context.Context, locker NamedLocker, names ...string) (func() error, error) {
+	sorted := append([]string(nil), names...)
+	sort.Strings(sorted)
+	releases := make([]func() error, 0, len(sorted))
+	release := func() error {
+		var errs []error
+		for i := len(releases) - 1; i >= 0; i-- {
+			if err := releases[i](); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	}
+	for i, name := range sorted {
+		if i > 0 && name == sorted[i-1] {
+			continue
+		}
+		r, err := locker.Lock(ctx, StackLockName(name))
+		if err != nil {
+			return nil, errors.Join(err, release())
+		}
+		releases = append(releases, r)
+	}
+	return release, nil
+}
+
+// stackClosure returns the targets and every stack reachable from them through edges,
+// or all stacks when there are no targets
+func (us *UniversalState) stackClosure(targets []string, edges map[string][]string) ([]string, error) {
+	if len(targets) == 0 {
+		return sortedKeys(us.Stacks), nil
+	}
+	seen := make(map[string]bool)
+	queue := append([]string(nil), targets...)
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if seen[name] {
+			continue
+		}
+		if _, ok := us.Stacks[name]; !ok {
+			return nil, &StackNotFoundError{Name: name}
+		}
+		seen[name] = true
+		queue = append(queue, edges[name]...)
+	}
+	return sortedKeys(seen), nil
+}
+
+// stackDependents returns the stacks directly depending on name
+func (us *UniversalState) stackDependents(name string) []string {
+	var dependents []string
+	for other, stack := range us.Stacks {
+		if containsString(stack.DependsOn, name) {
+			dependents = append(dependents, other)
+		}
+	}
+	sort.Strings(dependents)
+	return dependents
+}
+
+// lookupPath reads a dot-separated path from nested maps
+func lookupPath(data map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = data
+	for _, part := range strings.Split(path, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func containsString(values []string, want string) bool {
+	for _, v := range values {
+		if v == want {
+			return true
+		}
+	}
+	return false
+}
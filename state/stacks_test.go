@@ -0,0 +1,127 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newStackTestState(t *testing.T) *UniversalState {
+	t.Helper()
+	st := NewUniversalState("pg-1", "postgres")
+	for _, id := range []string{"vpc", "db", "topic", "sink"} {
+		r := NewUniversalResource(id, "table", id, "postgres", "pg-1")
+		st.AddResource(r)
+	}
+	st.Resources["db"].Data["endpoint"] = map[string]interface{}{"host": "db.internal"}
+
+	for _, stack := range []*Stack{
+		{Name: "network", Resources: []string{"vpc"}},
+		{Name: "storage", Resources: []string{"db"}, DependsOn: []string{"network"},
+			Outputs: map[string]StackOutput{"host": {Resource: "db", Attribute: "endpoint.host"}}},
+		{Name: "ingest", Resources: []string{"topic", "sink"}, DependsOn: []string{"storage", "network"}},
+	} {
+		if err := st.AddStack(stack); err != nil {
+			t.Fatalf("AddStack(%s): %v", stack.Name, err)
+		}
+	}
+	return st
+}
+
+// TestStackOrdering validates stacks apply after and destroy before their dependencies
+func TestStackOrdering(t *testing.T) {
+	st := newStackTestState(t)
+
+	apply, err := st.StackApplyOrder("ingest")
+	if err != nil {
+		t.Fatalf("StackApplyOrder: %v", err)
+	}
+	if want := [][]string{{"network"}, {"storage"}, {"ingest"}}; !reflect.DeepEqual(apply, want) {
+		t.Errorf("apply order = %v, want %v", apply, want)
+	}
+
+	destroy, err := st.StackDestroyOrder("storage")
+	if err != nil {
+		t.Fatalf("StackDestroyOrder: %v", err)
+	}
+	if want := [][]string{{"ingest"}, {"storage"}}; !reflect.DeepEqual(destroy, want) {
+		t.Errorf("destroy order = %v, want %v", destroy, want)
+	}
+
+	var notFound *StackNotFoundError
+	if _, err := st.StackApplyOrder("missing"); !errors.As(err, &notFound) {
+		t.Errorf("expected StackNotFoundError, got %v", err)
+	}
+}
+
+// TestAddStackValidation validates membership, dependency and cycle checks
+func TestAddStackValidation(t *testing.T) {
+	st := newStackTestState(t)
+
+	if err := st.AddStack(&Stack{Name: "other", Resources: []string{"db"}}); err == nil {
+		t.Error("a resource should belong to one stack only")
+	}
+	if err := st.AddStack(&Stack{Name: "other", Resources: []string{"nope"}}); err == nil {
+		t.Error("expected error for unknown resource")
+	}
+	var cycle *DependencyCycleError
+	if err := st.AddStack(&Stack{Name: "network", Resources: []string{"vpc"}, DependsOn: []string{"ingest"}}); !errors.As(err, &cycle) {
+		t.Errorf("expected DependencyCycleError, got %v", err)
+	}
+	if stack, _ := st.GetStack("network"); len(stack.DependsOn) != 0 {
+		t.Error("rejected stack should leave the previous definition in place")
+	}
+	if err := st.RemoveStack("storage"); err == nil {
+		t.Error("a stack others depend on should not be removable")
+	}
+	if err := st.RemoveStack("ingest"); err != nil {
+		t.Errorf("RemoveStack: %v", err)
+	}
+}
+
+// TestStackOutputsAndMembers validates output resolution, membership and cloning
+func TestStackOutputsAndMembers(t *testing.T) {
+	st := newStackTestState(t)
+
+	outputs, err := st.StackOutputs("storage")
+	if err != nil || outputs["host"] != "db.internal" {
+		t.Errorf("StackOutputs = %v, %v", outputs, err)
+	}
+	if name, ok := st.StackOf("sink"); !ok || name != "ingest" {
+		t.Errorf("StackOf(sink) = %q, %v", name, ok)
+	}
+	members, err := st.StackResources("ingest")
+	if err != nil || len(members) != 2 || members[0].ID != "sink" {
+		t.Errorf("StackResources = %v, %v", members, err)
+	}
+
+	clone := st.Clone()
+	clone.Stacks["ingest"].Resources[0] = "changed"
+	if st.Stacks["ingest"].Resources[0] != "topic" {
+		t.Error("Clone shares stack members with the original")
+	}
+}
+
+// TestLockStacks validates stack locks are exclusive and released together
+func TestLockStacks(t *testing.T) {
+	locker := NewMemoryLocker()
+	release, err := LockStacks(context.Background(), locker, "storage", "network", "storage")
+	if err != nil {
+		t.Fatalf("LockStacks: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := LockStacks(ctx, locker, "network"); err == nil {
+		t.Fatal("held stack lock should block")
+	}
+
+	release()
+	again, err := LockStacks(context.Background(), locker, "network", "storage")
+	if err != nil {
+		t.Fatalf("locks should be free after release: %v", err)
+	}
+	again()
+}
@@ -0,0 +1,209 @@
+// Package state provides time-travel queries over versioned state backends
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ErrStateVersionNotFound is returned when no saved state version matches a query
+var ErrStateVersionNotFound = errors.New("no state version matches the query")
+
+// ErrVersioningUnsupported is returned when a query needs an earlier state version
+// but the backend does not keep them
+var ErrVersioningUnsupported = errors.New("state backend does not keep state versions")
+
+// StateVersionInfo describes one saved version of a state
+type StateVersionInfo struct {
+	Version  int       `json:"version"`
+	SavedAt  time.Time `json:"saved_at"`
+	Checksum string    `json:"checksum,omitempty"`
+	User     string    `json:"user,omitempty"`
+}
+
+// VersionedStateBackend is implemented by state backends that keep every saved state
+// version, e.g. object stores with versioning enabled. Backends implementing it should
+// report SupportsVersioning in their capabilities.
+type VersionedStateBackend interface {
+	// ListStateVersions returns the saved versions in any order
+	ListStateVersions(ctx context.Context) ([]StateVersionInfo, error)
+
+	// LoadStateVersion loads one saved version
+	LoadStateVersion(ctx context.Context, version int) (*UniversalState, error)
+}
+
+// StateQuery selects a past state: the exact Version when set, otherwise the last
+// version saved at or before AsOf. ResourceTypes and ProviderTypes, when set, limit
+// the returned state to matching resources, e.g. the Kafka topics of a topology.
+type StateQuery struct {
+	AsOf          time.Time `json:"as_of,omitempty"`
+	Version       int       `json:"version,omitempty"`
+	ResourceTypes []string  `json:"resource_types,omitempty"`
+	ProviderTypes []string  `json:"provider_types,omitempty"`
+}
+
+// Validate checks that the query selects a version
+func (q StateQuery) Validate() error {
+	if q.Version < 0 {
+		return fmt.Errorf("state version must be positive, got %d", q.Version)
+	}
+	if q.Version == 0 && q.AsOf.IsZero() {
+		return errors.New("state query needs a version or an as-of timestamp")
+	}
+	return nil
+}
+
+// ResolveStateVersion picks the version a query selects
+func ResolveStateVersion(versions []StateVersionInfo, q StateQuery) (StateVersionInfo, error) {
+	if err := q.Validate(); err != nil {
+		return StateVersionInfo{}, err
+	}
+	var match *StateVersionInfo
+	for i := range versions {
+		v := &versions[i]
+		if q.Version > 0 {
+			if v.Version == q.Version {
+				return *v, nil
+			}
+			continue
+		}
+		if v.SavedAt.After(q.AsOf) {
+			continue
+		}
+		if match == nil || v.SavedAt.After(match.SavedAt) || (v.SavedAt.Equal(match.SavedAt) && v.Version > match.Version) {
+			match = v
+		}
+	}
+	if match == nil {
+		return StateVersionInfo{}, ErrStateVersionNotFound
+	}
+	return *match, nil
+}
+
+// LoadStateAsOf loads the state a query selects, limited to the query's resource and
+// provider types. Backends that do not implement VersionedStateBackend can only answer
+// with their current state, so queries selecting an earlier version return
+// ErrVersioningUnsupported.
+func LoadStateAsOf(ctx context.Context, backend StateBackendProvider, q StateQuery) (*UniversalState, StateVersionInfo, error) {
+	if err := q.Validate(); err != nil {
+		return nil, StateVersionInfo{}, err
+	}
+	if versioned, ok := backend.(VersionedStateBackend); ok {
+		versions, err := versioned.ListStateVersions(ctx)
+		if err != nil {
+			return nil, StateVersionInfo{}, fmt.Errorf("failed to list state versions: %w", err)
+		}
+		info, err := ResolveStateVersion(versions, q)
+		if err != nil {
+			return nil, StateVersionInfo{}, err
+		}
+		st, err := versioned.LoadStateVersion(ctx, info.Version)
+		if err != nil {
+			return nil, StateVersionInfo{}, fmt.Errorf("failed to load state version %d: %w", info.Version, err)
+		}
+		return q.filter(st), info, nil
+	}
+
+	current, err := backend.LoadState(ctx)
+	if err != nil {
+		return nil, StateVersionInfo{}, err
+	}
+	info := currentVersionInfo(current)
+	if _, err := ResolveStateVersion([]StateVersionInfo{info}, q); err != nil {
+		return nil, StateVersionInfo{}, ErrVersioningUnsupported
+	}
+	return q.filter(current), info, nil
+}
+
+// StateTimeDiff compares a past state with the current one
+type StateTimeDiff struct {
+	From StateVersionInfo `json:"from"`
+	To   StateVersionInfo `json:"to"`
+	*StateDiff
+}
+
+// DiffStateAsOf compares the state a query selects with the backend's current state,
+// both limited to the query's resource and provider types
+func DiffStateAsOf(ctx context.Context, backend StateBackendProvider, q StateQuery) (*StateTimeDiff, error) {
+	past, from, err := LoadStateAsOf(ctx, backend, q)
+	if err != nil {
+		return nil, err
+	}
+	current, err := backend.LoadState(ctx)
+	if err != nil {
+		return nil, err
+	}
+	diff, err := CompareUniversalStates(past, q.filter(current))
+	if err != nil {
+		return nil, err
+	}
+	return &StateTimeDiff{From: from, To: currentVersionInfo(current), StateDiff: diff}, nil
+}
+
+// Summary lists the changes one per line, sorted by resource ID
+func (d *StateTimeDiff) Summary() []string {
+	var lines []string
+	for _, id := range sortedKeys(d.Added) {
+		lines = append(lines, fmt.Sprintf("+ %s (%s)", id, d.Added[id].Address()))
+	}
+	for _, id := range sortedKeys(d.Modified) {
+		lines = append(lines, fmt.Sprintf("~ %s (%s): %v", id, d.Modified[id].New.Address(), d.Modified[id].ChangedFields()))
+	}
+	for _, id := range sortedKeys(d.Removed) {
+		lines = append(lines, fmt.Sprintf("- %s (%s)", id, d.Removed[id].Address()))
+	}
+	return lines
+}
+
+// ChangedFields returns the top-level data fields that differ between the old and new
+// resource, sorted; "status" is included when the status changed
+func (d *ResourceDiff) ChangedFields() []string {
+	if d.Old == nil || d.New == nil {
+		return nil
+	}
+	var fields []string
+	for key, value := range d.New.Data {
+		if old, ok := d.Old.Data[key]; !ok || !jsonEqual(old, value) {
+			fields = append(fields, key)
+		}
+	}
+	for key := range d.Old.Data {
+		if _, ok := d.New.Data[key]; !ok {
+			fields = append(fields, key)
+		}
+	}
+	if d.Old.Status != d.New.Status {
+		fields = append(fields, "status")
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// filter returns a copy of st holding only the resources the query selects
+func (q StateQuery) filter(st *UniversalState) *UniversalState {
+	if st == nil || (len(q.ResourceTypes) == 0 && len(q.ProviderTypes) == 0) {
+		return st
+	}
+	filtered := st.Clone()
+	for id, r := range filtered.Resources {
+		if (len(q.ResourceTypes) > 0 && !containsString(q.ResourceTypes, r.Type)) ||
+			(len(q.ProviderTypes) > 0 && !containsString(q.ProviderTypes, r.ProviderType)) {
+			delete(filtered.Resources, id)
+		}
+	}
+	return filtered
+}
+
+func currentVersionInfo(st *UniversalState) StateVersionInfo {
+	return StateVersionInfo{Version: st.Version, SavedAt: st.LastUpdated, Checksum: st.Checksum}
+}
+
+func jsonEqual(a, b interface{}) bool {
+	aData, errA := json.Marshal(a)
+	bData, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(aData) == string(bData)
+}
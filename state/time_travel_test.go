@@ -0,0 +1,95 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// versionedBackend keeps every saved version of a state
+type versionedBackend struct {
+	*memoryBackend
+	versions map[int]*UniversalState
+}
+
+func (b *versionedBackend) ListStateVersions(ctx context.Context) ([]StateVersionInfo, error) {
+	var out []StateVersionInfo
+	for _, st := range b.versions {
+		out = append(out, StateVersionInfo{Version: st.Version, SavedAt: st.LastUpdated})
+	}
+	return out, nil
+}
+
+func (b *versionedBackend) LoadStateVersion(ctx context.Context, version int) (*UniversalState, error) {
+	st, ok := b.versions[version]
+	if !ok {
+		return nil, errors.New("missing version")
+	}
+	return st, nil
+}
+
+func topologyVersion(version int, at time.Time, partitions float64, topics ...string) *UniversalState {
+	st := NewUniversalState("kafka", "kafka")
+	for _, name := range topics {
+		topic := NewUniversalResource(name, "topic", name, "kafka", "kafka")
+		topic.Data["partitions"] = partitions
+		st.AddResource(topic)
+	}
+	st.AddResource(NewUniversalResource("acl", "acl", "readers", "kafka", "kafka"))
+	st.Version, st.LastUpdated = version, at
+	return st
+}
+
+// TestStateAsOf validates version selection by timestamp and the diff against current
+func TestStateAsOf(t *testing.T) {
+	base := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	v1 := topologyVersion(1, base, 6, "orders", "payments")
+	v2 := topologyVersion(2, base.Add(time.Hour), 12, "orders", "refunds")
+	backend := &versionedBackend{
+		memoryBackend: &memoryBackend{BackendProviderHelper: NewBackendProviderHelper("kafka", "kafka", true, true), state: v2},
+		versions:      map[int]*UniversalState{1: v1, 2: v2},
+	}
+	ctx := context.Background()
+
+	incident := StateQuery{AsOf: base.Add(30 * time.Minute), ResourceTypes: []string{"topic"}}
+	past, info, err := LoadStateAsOf(ctx, backend, incident)
+	if err != nil {
+		t.Fatalf("LoadStateAsOf: %v", err)
+	}
+	if info.Version != 1 || len(past.Resources) != 2 {
+		t.Errorf("expected the two topics of version 1, got version %d with %d resources", info.Version, len(past.Resources))
+	}
+	if _, _, err := LoadStateAsOf(ctx, backend, StateQuery{AsOf: base.Add(-time.Minute)}); !errors.Is(err, ErrStateVersionNotFound) {
+		t.Errorf("query before the first version: %v", err)
+	}
+	if _, info, _ := LoadStateAsOf(ctx, backend, StateQuery{Version: 2}); info.Version != 2 {
+		t.Errorf("exact version query returned %d", info.Version)
+	}
+
+	diff, err := DiffStateAsOf(ctx, backend, incident)
+	if err != nil {
+		t.Fatalf("DiffStateAsOf: %v", err)
+	}
+	if diff.From.Version != 1 || diff.To.Version != 2 {
+		t.Errorf("diff spans %d..%d", diff.From.Version, diff.To.Version)
+	}
+	if len(diff.Added) != 1 || diff.Added["refunds"] == nil || len(diff.Removed) != 1 || diff.Removed["payments"] == nil {
+		t.Errorf("unexpected diff %v", diff.Summary())
+	}
+	if fields := diff.Modified["orders"].ChangedFields(); len(fields) != 1 || fields[0] != "partitions" {
+		t.Errorf("changed fields = %v", fields)
+	}
+	if lines := diff.Summary(); len(lines) != 3 || lines[0] != "+ refunds (kafka.topic.refunds)" {
+		t.Errorf("summary = %v", lines)
+	}
+
+	// without versioning only the current state can be queried
+	plain := backend.memoryBackend
+	if _, _, err := LoadStateAsOf(ctx, plain, incident); !errors.Is(err, ErrVersioningUnsupported) {
+		t.Errorf("expected ErrVersioningUnsupported, got %v", err)
+	}
+	if _, info, err := LoadStateAsOf(ctx, plain, StateQuery{AsOf: base.Add(2 * time.Hour)}); err != nil || info.Version != 2 {
+		t.Errorf("current state should answer later queries: %v", err)
+	}
+}
@@ -6,6 +6,9 @@ package state
 
 import (
 	"time"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+	"github.com/schemabounce/kolumn/sdk/types"
 )
 
 // UniversalState represents the complete state of a Kolumn deployment
@@ -35,6 +38,17 @@ type UniversalState struct {
 	Dependencies map[string][]string    `json:"dependencies,omitempty"`
 	Outputs      map[string]interface{} `json:"outputs,omitempty"`
 
+	// Stacks groups resources applied and destroyed together
+	Stacks map[string]*Stack `json:"stacks,omitempty"`
+
+	// Operations are asynchronous backend operations still in progress, keyed by
+	// OperationRef.Key
+	Operations map[string]*PendingOperation `json:"operations,omitempty"`
+
+	// Apply is the checkpoint of an apply in progress, kept until it finishes so an
+	// interrupted apply can be resumed
+	Apply *ApplyCheckpoint `json:"apply,omitempty"`
+
 	// State management
 	LockInfo *StateLock           `json:"lock_info,omitempty"`
 	Backups  []string             `json:"backups,omitempty"`
@@ -55,6 +69,9 @@ type UniversalResource struct {
 	Status  ResourceStatus         `json:"status"`
 	Data    map[string]interface{} `json:"data"`
 
+	// Outputs are the named values the resource's handler published
+	Outputs map[string]core.Output `json:"outputs,omitempty"`
+
 	// Lineage and relationships
 	Dependencies []string `json:"dependencies,omitempty"`
 	DependsOn    []string `json:"depends_on,omitempty"`
@@ -153,7 +170,7 @@ type ResourceState = UniversalResource
 
 // NewUniversalState creates a new UniversalState with default values
 func NewUniversalState(providerID, providerType string) *UniversalState {
-	now := time.Now()
+	now := clock.Now()
 
 	return &UniversalState{
 		Version:      1,
@@ -174,7 +191,7 @@ func NewUniversalState(providerID, providerType string) *UniversalState {
 
 // NewUniversalResource creates a new UniversalResource with default values
 func NewUniversalResource(id, resourceType, name, providerType, providerID string) *UniversalResource {
-	now := time.Now()
+	now := clock.Now()
 
 	return &UniversalResource{
 		ID:           id,
@@ -200,7 +217,7 @@ func (us *UniversalState) AddResource(resource *UniversalResource) {
 	}
 
 	us.Resources[resource.ID] = resource
-	us.LastUpdated = time.Now()
+	us.LastUpdated = clock.Now()
 	us.Version++
 }
 
@@ -208,7 +225,7 @@ func (us *UniversalState) AddResource(resource *UniversalResource) {
 func (us *UniversalState) RemoveResource(resourceID string) {
 	if us.Resources != nil {
 		delete(us.Resources, resourceID)
-		us.LastUpdated = time.Now()
+		us.LastUpdated = clock.Now()
 		us.Version++
 	}
 }
@@ -300,6 +317,28 @@ func (us *UniversalState) Clone() *UniversalState {
 		clone.Outputs[k] = v
 	}
 
+	// Deep copy stacks
+	if us.Stacks != nil {
+		clone.Stacks = make(map[string]*Stack, len(us.Stacks))
+		for name, stack := range us.Stacks {
+			clone.Stacks[name] = stack.Clone()
+		}
+	}
+
+	// Copy pending operations
+	if us.Operations != nil {
+		clone.Operations = make(map[string]*PendingOperation, len(us.Operations))
+		for key, op := range us.Operations {
+			opClone := *op
+			clone.Operations[key] = &opClone
+		}
+	}
+
+	// Copy apply checkpoint
+	if us.Apply != nil {
+		clone.Apply = us.Apply.Clone()
+	}
+
 	// Copy backups
 	copy(clone.Backups, us.Backups)
 
@@ -321,6 +360,11 @@ func (us *UniversalState) Clone() *UniversalState {
 	return clone
 }
 
+// Address returns the canonical provider.type.name address of the resource
+func (ur *UniversalResource) Address() types.Address {
+	return types.NewAddress(ur.ProviderType, ur.Type, ur.Name)
+}
+
 // Clone creates a deep copy of the UniversalResource
 func (ur *UniversalResource) Clone() *UniversalResource {
 	clone := &UniversalResource{
@@ -344,6 +388,14 @@ func (ur *UniversalResource) Clone() *UniversalResource {
 		clone.Data[k] = v
 	}
 
+	// Copy outputs
+	if ur.Outputs != nil {
+		clone.Outputs = make(map[string]core.Output, len(ur.Outputs))
+		for k, v := range ur.Outputs {
+			clone.Outputs[k] = v
+		}
+	}
+
 	// Copy dependencies
 	copy(clone.Dependencies, ur.Dependencies)
 	copy(clone.DependsOn, ur.DependsOn)
@@ -381,14 +433,14 @@ func (ur *UniversalResource) Clone() *UniversalResource {
 // Update updates the resource with new data
 func (ur *UniversalResource) Update(data map[string]interface{}) {
 	ur.Data = data
-	ur.UpdatedAt = time.Now()
+	ur.UpdatedAt = clock.Now()
 	ur.Version++
 }
 
 // SetStatus sets the resource status
 func (ur *UniversalResource) SetStatus(status ResourceStatus) {
 	ur.Status = status
-	ur.UpdatedAt = time.Now()
+	ur.UpdatedAt = clock.Now()
 }
 
 // AddDependency adds a dependency to the resource
@@ -0,0 +1,272 @@
+// Package state provides integrity verification and repair for universal state
+package state
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/schemabounce/kolumn/sdk/types"
+)
+
+// Integrity issue codes
+const (
+	IssueChecksumMismatch    = "checksum_mismatch"
+	IssueIDMismatch          = "id_mismatch"
+	IssueDanglingDependency  = "dangling_dependency"
+	IssueDuplicateAddress    = "duplicate_address"
+	IssueInconsistentStatus  = "inconsistent_status"
+	IssueDeletedButDependent = "deleted_but_depended_on"
+)
+
+// Repair actions
+const (
+	RepairRekeyResource    = "rekey_resource"
+	RepairRemoveDependency = "remove_dependency"
+	RepairResetStatus      = "reset_status"
+	RepairManual           = "manual"
+)
+
+// RepairAction is one step of a repair plan. Safe actions only discard information
+// that is already invalid and may be applied automatically.
+type RepairAction struct {
+	Action      string `json:"action"`
+	ResourceID  string `json:"resource_id,omitempty"`
+	Target      string `json:"target,omitempty"` // dependency or new key, depending on Action
+	Description string `json:"description"`
+	Safe        bool   `json:"safe"`
+}
+
+// IntegrityIssue is a problem found by VerifyState
+type IntegrityIssue struct {
+	Code       string        `json:"code"`
+	ResourceID string        `json:"resource_id,omitempty"`
+	Message    string        `json:"message"`
+	Repair     *RepairAction `json:"repair,omitempty"`
+}
+
+// VerifyOptions controls VerifyState
+type VerifyOptions struct {
+	// ApplySafeFixes applies the safe repair actions to the state in place
+	ApplySafeFixes bool
+}
+
+// VerifyReport is the result of VerifyState
+type VerifyReport struct {
+	Issues     []IntegrityIssue `json:"issues,omitempty"`
+	RepairPlan []RepairAction   `json:"repair_plan,omitempty"`
+	Applied    []RepairAction   `json:"applied,omitempty"`
+}
+
+// Valid reports whether no issues were found
+func (r *VerifyReport) Valid() bool {
+	return len(r.Issues) == 0
+}
+
+// VerifyState checks a state's checksum, resource keys, dependency targets, address
+// uniqueness and resource status consistency, and returns a repair plan. Dependencies
+// may name a resource ID, an address or an external "state::address" target; external
+// targets are not checked here. When the checksum was valid before safe fixes are
+// applied it is recomputed afterwards; a mismatching checksum is never blessed.
+func VerifyState(st *UniversalState, opts VerifyOptions) (*VerifyReport, error) {
+	if st == nil {
+		return nil, fmt.Errorf("state cannot be nil")
+	}
+	report := &VerifyReport{}
+	add := func(issue IntegrityIssue) {
+		report.Issues = append(report.Issues, issue)
+		if issue.Repair != nil {
+			report.RepairPlan = append(report.RepairPlan, *issue.Repair)
+		}
+	}
+
+	checksumValid := st.Checksum == ""
+	if st.Checksum != "" {
+		sum, err := CalculateChecksum(st)
+		if err != nil {
+			return nil, err
+		}
+		checksumValid = sum == st.Checksum
+		if !checksumValid {
+			add(IntegrityIssue{
+				Code:    IssueChecksumMismatch,
+				Message: fmt.Sprintf("recorded checksum %s does not match computed %s", st.Checksum, sum),
+				Repair:  &RepairAction{Action: RepairManual, Description: "investigate the modification, then restore from backup or recompute the checksum"},
+			})
+		}
+	}
+
+	keys := make([]string, 0, len(st.Resources))
+	for key := range st.Resources {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	addresses := make(map[string][]string)
+	dependedOn := make(map[string][]string)
+	for _, key := range keys {
+		r := st.Resources[key]
+		if r == nil {
+			continue
+		}
+		if r.ID != key {
+			add(IntegrityIssue{
+				Code: IssueIDMismatch, ResourceID: key,
+				Message: fmt.Sprintf("resource stored under %q has ID %q", key, r.ID),
+				Repair: &RepairAction{Action: RepairRekeyResource, ResourceID: key, Target: r.ID,
+					Description: fmt.Sprintf("store the resource under its ID %q", r.ID),
+					Safe:        r.ID != "" && st.Resources[r.ID] == nil},
+			})
+		}
+		addresses[r.Address().String()] = append(addresses[r.Address().String()], key)
+
+		for _, list := range [][]string{r.Dependencies, r.DependsOn} {
+			for _, target := range list {
+				resolved, external := resolveStateTarget(st, target)
+				if external {
+					continue
+				}
+				if resolved == nil {
+					add(IntegrityIssue{
+						Code: IssueDanglingDependency, ResourceID: key,
+						Message: fmt.Sprintf("dependency %q does not exist in state", target),
+						Repair: &RepairAction{Action: RepairRemoveDependency, ResourceID: key, Target: target,
+							Description: fmt.Sprintf("remove dependency %q", target), Safe: true},
+					})
+					continue
+				}
+				if r.Status != ResourceStatusDeleted {
+					dependedOn[resolved.ID] = append(dependedOn[resolved.ID], key)
+				}
+			}
+		}
+
+		if issue := statusIssue(st, key, r); issue != nil {
+			add(*issue)
+		}
+	}
+
+	for _, addr := range sortedKeys(addresses) {
+		if ids := addresses[addr]; len(ids) > 1 {
+			add(IntegrityIssue{
+				Code:    IssueDuplicateAddress,
+				Message: fmt.Sprintf("address %s is used by resources %v", addr, ids),
+				Repair:  &RepairAction{Action: RepairManual, Target: addr, Description: "remove or rename all but one of the resources"},
+			})
+		}
+	}
+	for _, key := range keys {
+		r := st.Resources[key]
+		if r != nil && r.Status == ResourceStatusDeleted && len(dependedOn[r.ID]) > 0 {
+			add(IntegrityIssue{
+				Code: IssueDeletedButDependent, ResourceID: key,
+				Message: fmt.Sprintf("deleted resource is still depended on by %v", dependedOn[r.ID]),
+				Repair:  &RepairAction{Action: RepairManual, ResourceID: key, Description: "recreate the resource or remove the dependencies on it"},
+			})
+		}
+	}
+
+	if opts.ApplySafeFixes {
+		// rekeying last keeps the resource IDs of the other actions valid
+		for _, rekey := range []bool{false, true} {
+			for _, action := range report.RepairPlan {
+				if action.Safe && (action.Action == RepairRekeyResource) == rekey && applyRepair(st, action) {
+					report.Applied = append(report.Applied, action)
+				}
+			}
+		}
+		if len(report.Applied) > 0 && checksumValid && st.Checksum != "" {
+			sum, err := CalculateChecksum(st)
+			if err != nil {
+				return nil, err
+			}
+			st.Checksum = sum
+		}
+	}
+	return report, nil
+}
+
+// statusIssue reports a resource left in a transitional status while the state is not
+// locked, or with a status that is not a known ResourceStatus
+func statusIssue(st *UniversalState, key string, r *UniversalResource) *IntegrityIssue {
+	switch r.Status {
+	case ResourceStatusActive, ResourceStatusDeleted, ResourceStatusError, ResourceStatusDrifted, ResourceStatusUnknown:
+		return nil
+	case ResourceStatusCreating, ResourceStatusUpdating, ResourceStatusDeleting:
+		if st.LockInfo != nil {
+			return nil
+		}
+		return &IntegrityIssue{
+			Code: IssueInconsistentStatus, ResourceID: key,
+			Message: fmt.Sprintf("resource is %s but no operation holds the state lock", r.Status),
+			Repair: &RepairAction{Action: RepairResetStatus, ResourceID: key, Target: string(ResourceStatusUnknown),
+				Description: "reset the status to unknown so the next refresh reconciles it", Safe: true},
+		}
+	default:
+		return &IntegrityIssue{
+			Code: IssueInconsistentStatus, ResourceID: key,
+			Message: fmt.Sprintf("resource has unrecognized status %q", r.Status),
+			Repair: &RepairAction{Action: RepairResetStatus, ResourceID: key, Target: string(ResourceStatusUnknown),
+				Description: "reset the status to unknown so the next refresh reconciles it", Safe: true},
+		}
+	}
+}
+
+// applyRepair applies one repair action, reporting whether it changed the state
+func applyRepair(st *UniversalState, action RepairAction) bool {
+	r := st.Resources[action.ResourceID]
+	if r == nil {
+		return false
+	}
+	switch action.Action {
+	case RepairRekeyResource:
+		if _, taken := st.Resources[action.Target]; taken {
+			return false
+		}
+		delete(st.Resources, action.ResourceID)
+		st.Resources[action.Target] = r
+	case RepairRemoveDependency:
+		r.RemoveDependency(action.Target)
+		kept := r.DependsOn[:0]
+		for _, dep := range r.DependsOn {
+			if dep != action.Target {
+				kept = append(kept, dep)
+			}
+		}
+		r.DependsOn = kept
+	case RepairResetStatus:
+		r.SetStatus(ResourceStatus(action.Target))
+	default:
+		return false
+	}
+	return true
+}
+
+// resolveStateTarget finds the resource a dependency entry refers to, reporting
+// external targets separately
+func resolveStateTarget(st *UniversalState, target string) (*UniversalResource, bool) {
+	if r, ok := st.Resources[target]; ok && r != nil {
+		return r, false
+	}
+	for _, r := range st.Resources {
+		if r != nil && r.ID == target {
+			return r, false
+		}
+	}
+	stateName, addr, err := types.ParseDependencyTarget(target)
+	if err != nil {
+		return nil, false
+	}
+	if stateName != "" {
+		return nil, true
+	}
+	return findResourceByAddress(st, addr), false
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,55 @@
+package state
+
+import (
+	"testing"
+)
+
+// TestVerifyState validates integrity issues are found and safe fixes applied
+func TestVerifyState(t *testing.T) {
+	st := NewUniversalState("pg-main", "postgres")
+	schema := NewUniversalResource("s1", "schema", "app", "postgres", "pg-main")
+	schema.Status = ResourceStatusActive
+	table := NewUniversalResource("t1", "table", "users", "postgres", "pg-main")
+	table.Status = ResourceStatusActive
+	table.AddDependency("s1")
+	table.AddDependency("gone")
+	table.AddDependency("network::aws.vpc.main")
+	dup := NewUniversalResource("t2", "table", "users", "postgres", "pg-main")
+	dup.Status = ResourceStatusCreating
+	st.AddResource(schema)
+	st.AddResource(table)
+	st.AddResource(dup)
+
+	sum, err := CalculateChecksum(st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st.Checksum = sum
+
+	report, err := VerifyState(st, VerifyOptions{ApplySafeFixes: true})
+	if err != nil {
+		t.Fatalf("VerifyState: %v", err)
+	}
+	codes := map[string]int{}
+	for _, issue := range report.Issues {
+		codes[issue.Code]++
+	}
+	if codes[IssueDanglingDependency] != 1 || codes[IssueDuplicateAddress] != 1 || codes[IssueInconsistentStatus] != 1 || codes[IssueChecksumMismatch] != 0 {
+		t.Errorf("unexpected issues: %+v", report.Issues)
+	}
+	if len(report.Applied) != 2 {
+		t.Errorf("expected dependency removal and status reset, got %+v", report.Applied)
+	}
+	if len(table.Dependencies) != 2 || dup.Status != ResourceStatusUnknown {
+		t.Errorf("safe fixes not applied: deps=%v status=%s", table.Dependencies, dup.Status)
+	}
+	if sum, _ := CalculateChecksum(st); sum != st.Checksum {
+		t.Error("checksum should be recomputed after safe fixes")
+	}
+
+	st.Resources["t1"].Data["rows"] = 5
+	again, _ := VerifyState(st, VerifyOptions{})
+	if again.Valid() || again.Issues[0].Code != IssueChecksumMismatch {
+		t.Errorf("expected checksum mismatch, got %+v", again.Issues)
+	}
+}
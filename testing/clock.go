@@ -0,0 +1,76 @@
+package testing
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+	"github.com/schemabounce/kolumn/sdk/create"
+	"github.com/schemabounce/kolumn/sdk/enterprise_safety"
+	"github.com/schemabounce/kolumn/sdk/runtimehelpers/telemetry"
+	"github.com/schemabounce/kolumn/sdk/state"
+	"github.com/schemabounce/kolumn/sdk/types"
+)
+
+// FakeClock is a types.Clock that only moves when told to. It is safe for concurrent use.
+type FakeClock struct {
+	mu   sync.Mutex
+	now  time.Time
+	step time.Duration
+}
+
+// NewFakeClock returns a clock frozen at start
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start}
+}
+
+// Now returns the current fake time, then advances it by the auto-step if one is set
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now
+	c.now = c.now.Add(c.step)
+	return now
+}
+
+// Advance moves the clock forward by d
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Set moves the clock to t
+func (c *FakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
+
+// SetStep makes every Now call advance the clock by d afterwards, so successive
+// timestamps are distinct but still deterministic. Zero disables the auto-step.
+func (c *FakeClock) SetStep(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.step = d
+}
+
+// UseFakeClock installs a FakeClock starting at start in core, create, state,
+// enterprise_safety and telemetry, and restores the previous clocks when t finishes.
+// The clocks are package-wide, so tests using it must not run in parallel with tests
+// that depend on the real time.
+func UseFakeClock(t *testing.T, start time.Time) *FakeClock {
+	t.Helper()
+	clock := NewFakeClock(start)
+	for _, set := range []func(types.Clock) func(){
+		core.SetClock,
+		create.SetClock,
+		state.SetClock,
+		enterprise_safety.SetClock,
+		telemetry.SetClock,
+	} {
+		t.Cleanup(set(clock))
+	}
+	return clock
+}
@@ -0,0 +1,313 @@
+package testing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"text/tabwriter"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Resource operations tracked by CoverageTracker
+const (
+	OperationCreate = "create"
+	OperationRead   = "read"
+	OperationUpdate = "update"
+	OperationDelete = "delete"
+	OperationImport = "import"
+	OperationDrift  = "drift"
+)
+
+// CoverageOperations lists the tracked operations in report column order
+var CoverageOperations = []string{OperationCreate, OperationRead, OperationUpdate, OperationDelete, OperationImport, OperationDrift}
+
+// Environment variables that override CoverageConfig in CI
+const (
+	CoverageThresholdEnv = "KOLUMN_OPERATION_COVERAGE_MIN"
+	CoverageReportEnv    = "KOLUMN_OPERATION_COVERAGE_REPORT"
+)
+
+// functionOperations maps provider functions to the operation they exercise
+var functionOperations = map[string]string{
+	"CreateResource": OperationCreate,
+	"ReadResource":   OperationRead,
+	"UpdateResource": OperationUpdate,
+	"DeleteResource": OperationDelete,
+}
+
+// CoverageTracker records which resource types and operations a test suite exercised.
+// The expected matrix comes from the operations each resource type declares in the
+// provider schema; Expect adds operations the schema does not list.
+//
+// Usage in provider tests:
+//
+//	var coverage *testing.CoverageTracker
+//
+//	func TestMain(m *gotesting.M) {
+//		coverage = testing.NewCoverageTracker(schema)
+//		code := m.Run()
+//		if err := coverage.Report().Check(testing.CoverageConfig{Threshold: 80}); err != nil {
+//			fmt.Fprintln(os.Stderr, err)
+//			code = 1
+//		}
+//		os.Exit(code)
+//	}
+//
+//	func TestTable(t *gotesting.T) {
+//		provider := coverage.Wrap(NewMyProvider())
+//		// CreateResource, ReadResource, ... calls are recorded automatically
+//		coverage.Record("table", testing.OperationImport)
+//	}
+type CoverageTracker struct {
+	mu        sync.Mutex
+	expected  map[string]map[string]bool
+	exercised map[string]map[string]int
+}
+
+// NewCoverageTracker creates a tracker expecting every operation the schema declares.
+// Declared operations outside CoverageOperations are ignored; a nil schema starts with
+// an empty expectation.
+func NewCoverageTracker(schema *ProviderSchema) *CoverageTracker {
+	c := &CoverageTracker{
+		expected:  make(map[string]map[string]bool),
+		exercised: make(map[string]map[string]int),
+	}
+	if schema != nil {
+		for _, rt := range schema.ResourceTypes {
+			c.Expect(rt.Name, rt.Operations...)
+		}
+	}
+	return c
+}
+
+// Expect adds operations that resourceType must exercise. Unknown operations are ignored.
+func (c *CoverageTracker) Expect(resourceType string, operations ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ops := c.expected[resourceType]
+	if ops == nil {
+		ops = make(map[string]bool)
+		c.expected[resourceType] = ops
+	}
+	for _, op := range operations {
+		if op = strings.ToLower(op); isCoverageOperation(op) {
+			ops[op] = true
+		}
+	}
+}
+
+// Record marks an operation as exercised for a resource type
+func (c *CoverageTracker) Record(resourceType, operation string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ops := c.exercised[resourceType]
+	if ops == nil {
+		ops = make(map[string]int)
+		c.exercised[resourceType] = ops
+	}
+	ops[strings.ToLower(operation)]++
+}
+
+// Wrap returns a provider that records CreateResource, ReadResource, UpdateResource
+// and DeleteResource calls, keyed by the resource_type in their input, before
+// forwarding them. Calls are recorded whether or not they succeed, so error-path tests
+// count as exercising the operation.
+func (c *CoverageTracker) Wrap(provider SchemaProvider) SchemaProvider {
+	return &coverageProvider{SchemaProvider: provider, tracker: c}
+}
+
+type coverageProvider struct {
+	SchemaProvider
+	tracker *CoverageTracker
+}
+
+// CallFunction records the operation and forwards the call
+func (p *coverageProvider) CallFunction(ctx context.Context, function string, input json.RawMessage) (json.RawMessage, error) {
+	if op, ok := functionOperations[function]; ok {
+		var req struct {
+			ResourceType string `json:"resource_type"`
+			ObjectType   string `json:"object_type"`
+		}
+		if json.Unmarshal(input, &req) == nil {
+			resourceType := req.ResourceType
+			if resourceType == "" {
+				resourceType = req.ObjectType
+			}
+			if resourceType != "" {
+				p.tracker.Record(resourceType, op)
+			}
+		}
+	}
+	return p.SchemaProvider.CallFunction(ctx, function, input)
+}
+
+// CoverageRow is one resource type in the coverage matrix
+type CoverageRow struct {
+	ResourceType string         `json:"resource_type"`
+	Expected     []string       `json:"expected"`
+	Exercised    map[string]int `json:"exercised"`
+	Missing      []string       `json:"missing,omitempty"`
+}
+
+// CoverageReport is the resource type by operation coverage matrix
+type CoverageReport struct {
+	Rows    []CoverageRow `json:"rows"`
+	Covered int           `json:"covered"`
+	Total   int           `json:"total"`
+	Percent float64       `json:"percent"`
+}
+
+// Report builds the coverage matrix. Resource types that were exercised but never
+// expected are listed with an empty expectation and do not affect the percentage.
+func (c *CoverageTracker) Report() *CoverageReport {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	types := make(map[string]bool, len(c.expected))
+	for rt := range c.expected {
+		types[rt] = true
+	}
+	for rt := range c.exercised {
+		types[rt] = true
+	}
+	names := make([]string, 0, len(types))
+	for rt := range types {
+		names = append(names, rt)
+	}
+	sort.Strings(names)
+
+	report := &CoverageReport{Rows: make([]CoverageRow, 0, len(names))}
+	for _, rt := range names {
+		row := CoverageRow{ResourceType: rt, Expected: []string{}, Exercised: make(map[string]int)}
+		for op, n := range c.exercised[rt] {
+			row.Exercised[op] = n
+		}
+		for _, op := range CoverageOperations {
+			if !c.expected[rt][op] {
+				continue
+			}
+			row.Expected = append(row.Expected, op)
+			report.Total++
+			if row.Exercised[op] > 0 {
+				report.Covered++
+			} else {
+				row.Missing = append(row.Missing, op)
+			}
+		}
+		report.Rows = append(report.Rows, row)
+	}
+
+	report.Percent = 100
+	if report.Total > 0 {
+		report.Percent = 100 * float64(report.Covered) / float64(report.Total)
+	}
+	return report
+}
+
+// WriteText renders the matrix as a table: the call count for exercised operations,
+// MISSING for expected operations never exercised and - for operations not expected
+func (r *CoverageReport) WriteText(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "RESOURCE TYPE\t%s\n", strings.ToUpper(strings.Join(CoverageOperations, "\t")))
+	for _, row := range r.Rows {
+		cells := make([]string, len(CoverageOperations))
+		for i, op := range CoverageOperations {
+			switch n := row.Exercised[op]; {
+			case n > 0:
+				cells[i] = strconv.Itoa(n)
+			case containsOperation(row.Missing, op):
+				cells[i] = "MISSING"
+			default:
+				cells[i] = "-"
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", row.ResourceType, strings.Join(cells, "\t"))
+	}
+	tw.Flush()
+	fmt.Fprintf(w, "Operation coverage: %d/%d (%.1f%%)\n", r.Covered, r.Total, r.Percent)
+}
+
+// CoverageConfig controls Check
+type CoverageConfig struct {
+	// Threshold is the minimum coverage percentage (0-100). KOLUMN_OPERATION_COVERAGE_MIN
+	// overrides it when set.
+	Threshold float64
+
+	// ReportFile receives the JSON report when set. KOLUMN_OPERATION_COVERAGE_REPORT
+	// overrides it when set.
+	ReportFile string
+}
+
+// Check writes the JSON report when configured and returns an error naming the missing
+// operations if coverage is below the threshold
+func (r *CoverageReport) Check(config CoverageConfig) error {
+	if env := os.Getenv(CoverageThresholdEnv); env != "" {
+		threshold, err := strconv.ParseFloat(env, 64)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q: %w", CoverageThresholdEnv, env, err)
+		}
+		config.Threshold = threshold
+	}
+	if env := os.Getenv(CoverageReportEnv); env != "" {
+		config.ReportFile = env
+	}
+
+	if config.ReportFile != "" {
+		data, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(config.ReportFile, data, 0644); err != nil {
+			return fmt.Errorf("failed to write coverage report: %w", err)
+		}
+	}
+
+	if r.Percent >= config.Threshold {
+		return nil
+	}
+	var missing []string
+	for _, row := range r.Rows {
+		for _, op := range row.Missing {
+			missing = append(missing, row.ResourceType+"."+op)
+		}
+	}
+	return fmt.Errorf("operation coverage %.1f%% is below the %.1f%% threshold; not exercised: %s",
+		r.Percent, config.Threshold, strings.Join(missing, ", "))
+}
+
+// RequireCoverage logs the coverage matrix and fails t when coverage is below the
+// configured threshold. Call it after the tests that exercise the provider, e.g. from
+// t.Cleanup in a top-level test or at the end of a suite.
+func (c *CoverageTracker) RequireCoverage(t *testing.T, config CoverageConfig) {
+	t.Helper()
+	report := c.Report()
+
+	var b strings.Builder
+	report.WriteText(&b)
+	t.Log("\n" + b.String())
+
+	require.NoError(t, report.Check(config))
+}
+
+func isCoverageOperation(op string) bool {
+	return containsOperation(CoverageOperations, op)
+}
+
+func containsOperation(ops []string, op string) bool {
+	for _, o := range ops {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
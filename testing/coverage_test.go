@@ -0,0 +1,130 @@
+package testing
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCoverageReport validates the report, table and threshold check for uncovered,
+// partially covered and fully covered resource types
+func TestCoverageReport(t *testing.T) {
+	t.Setenv(CoverageThresholdEnv, "")
+	t.Setenv(CoverageReportEnv, "")
+
+	schema, err := (&exampleProvider{}).Schema()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		calls     []string // provider functions called on postgres_table
+		recorded  []string // operations recorded directly
+		covered   int
+		missing   []string
+		text      []string
+		threshold float64
+		checkErr  string
+	}{
+		{
+			name:      "uncovered",
+			covered:   0,
+			missing:   []string{OperationCreate, OperationRead, OperationUpdate, OperationDelete},
+			text:      []string{"postgres_table  MISSING  MISSING  MISSING  MISSING  -", "Operation coverage: 0/4 (0.0%)"},
+			threshold: 1,
+			checkErr:  "operation coverage 0.0% is below the 1.0% threshold; not exercised: postgres_table.create, postgres_table.read, postgres_table.update, postgres_table.delete",
+		},
+		{
+			name:      "partially covered",
+			calls:     []string{"CreateResource", "CreateResource", "ReadResource"},
+			recorded:  []string{OperationImport},
+			covered:   2,
+			missing:   []string{OperationUpdate, OperationDelete},
+			text:      []string{"postgres_table  2       1     MISSING  MISSING  1", "Operation coverage: 2/4 (50.0%)"},
+			threshold: 80,
+			checkErr:  "not exercised: postgres_table.update, postgres_table.delete",
+		},
+		{
+			name:      "fully covered",
+			calls:     []string{"CreateResource", "ReadResource", "UpdateResource", "DeleteResource"},
+			covered:   4,
+			text:      []string{"postgres_table  1       1     1       1       -", "Operation coverage: 4/4 (100.0%)"},
+			threshold: 100,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := NewCoverageTracker(schema)
+			provider := tracker.Wrap(&exampleProvider{MockBackend: NewMockBackend()})
+			for _, function := range tt.calls {
+				_, _ = provider.CallFunction(context.Background(), function, json.RawMessage(`{"resource_type":"postgres_table","name":"users"}`))
+			}
+			for _, op := range tt.recorded {
+				tracker.Record("postgres_table", op)
+			}
+
+			report := tracker.Report()
+			require.Len(t, report.Rows, 1)
+			assert.Equal(t, tt.covered, report.Covered)
+			assert.Equal(t, 4, report.Total)
+			assert.Equal(t, tt.missing, report.Rows[0].Missing)
+
+			var b strings.Builder
+			report.WriteText(&b)
+			for _, line := range tt.text {
+				assert.Contains(t, b.String(), line)
+			}
+
+			err := report.Check(CoverageConfig{Threshold: tt.threshold})
+			if tt.checkErr == "" {
+				assert.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.checkErr)
+			}
+		})
+	}
+}
+
+// TestCoverageReportUnexpectedTypes validates exercised types outside the schema are
+// listed without affecting the percentage
+func TestCoverageReportUnexpectedTypes(t *testing.T) {
+	tracker := NewCoverageTracker(nil)
+	tracker.Record("postgres_view", "READ")
+
+	report := tracker.Report()
+	require.Len(t, report.Rows, 1)
+	assert.Equal(t, []string{}, report.Rows[0].Expected)
+	assert.Equal(t, map[string]int{OperationRead: 1}, report.Rows[0].Exercised)
+	assert.Equal(t, 0, report.Total)
+	assert.Equal(t, float64(100), report.Percent)
+}
+
+// TestCoverageCheckEnvironment validates the CI overrides for threshold and report file
+func TestCoverageCheckEnvironment(t *testing.T) {
+	tracker := NewCoverageTracker(nil)
+	tracker.Expect("postgres_table", OperationCreate, OperationRead, "vacuum")
+	tracker.Record("postgres_table", OperationCreate)
+	report := tracker.Report()
+	assert.Equal(t, 2, report.Total, "unknown operations are not expected")
+
+	path := filepath.Join(t.TempDir(), "coverage.json")
+	t.Setenv(CoverageThresholdEnv, "50")
+	t.Setenv(CoverageReportEnv, path)
+	require.NoError(t, report.Check(CoverageConfig{Threshold: 100}))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var written CoverageReport
+	require.NoError(t, json.Unmarshal(data, &written))
+	assert.Equal(t, report.Covered, written.Covered)
+	assert.Equal(t, []string{OperationRead}, written.Rows[0].Missing)
+
+	t.Setenv(CoverageThresholdEnv, "most")
+	assert.ErrorContains(t, report.Check(CoverageConfig{}), "invalid "+CoverageThresholdEnv)
+}
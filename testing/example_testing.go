@@ -0,0 +1,391 @@
+package testing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+	"github.com/schemabounce/kolumn/sdk/helpers/hcl"
+	"github.com/schemabounce/kolumn/sdk/helpers/ids"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ExampleTestConfig defines the configuration for documentation example testing
+type ExampleTestConfig struct {
+	// Provider supplies the schema examples are validated against
+	Provider SchemaProvider
+
+	// Documentation holds the provider and resource examples under test
+	Documentation *core.UniversalProviderDocumentation
+
+	// Execute runs each parsed block against Backend after validation
+	Execute bool
+
+	// Backend receives executed examples (optional, defaults to a fresh NewMockBackend
+	// per example). A backend with a Reset() method is reset before each example.
+	Backend SchemaProvider
+
+	// SkipExamples lists example names that should not be tested
+	SkipExamples []string
+}
+
+// ExampleBlock represents a create or discover block parsed from example HCL
+type ExampleBlock struct {
+	Kind       string                 `json:"kind"` // create, discover
+	Type       string                 `json:"type"`
+	Name       string                 `json:"name"`
+	Attributes map[string]interface{} `json:"attributes"`
+	Pos        hcl.Pos                `json:"pos"`
+}
+
+// RunExampleTests parses, validates and optionally executes every HCL example in the
+// provider documentation so that published examples never drift from the schema.
+//
+// Usage in provider tests:
+//
+//	func TestDocumentationExamples(t *testing.T) {
+//		docs := buildProviderDocs()
+//		testing.RunExampleTests(t, &testing.ExampleTestConfig{
+//			Provider:      NewMyProvider(),
+//			Documentation: docs,
+//			Execute:       true,
+//		})
+//	}
+func RunExampleTests(t *testing.T, config *ExampleTestConfig) {
+	require.NotNil(t, config, "ExampleTestConfig cannot be nil")
+	require.NotNil(t, config.Provider, "Provider cannot be nil")
+	require.NotNil(t, config.Documentation, "Documentation cannot be nil")
+
+	schema, err := config.Provider.Schema()
+	require.NoError(t, err, "Provider.Schema() must not return error")
+	require.NotNil(t, schema, "Provider.Schema() must not return nil")
+
+	// Examples are independent, so each one starts from an empty backend
+	backendFor := func() SchemaProvider {
+		if !config.Execute {
+			return nil
+		}
+		if config.Backend == nil {
+			return NewMockBackend()
+		}
+		if resetter, ok := config.Backend.(interface{ Reset() }); ok {
+			resetter.Reset()
+		}
+		return config.Backend
+	}
+
+	skip := make(map[string]bool, len(config.SkipExamples))
+	for _, name := range config.SkipExamples {
+		skip[name] = true
+	}
+
+	for _, example := range config.Documentation.Examples {
+		if example == nil || example.HCL == "" || skip[example.Name] {
+			continue
+		}
+		t.Run(fmt.Sprintf("ProviderExample_%s", example.Name), func(t *testing.T) {
+			runExample(t, schema, backendFor(), example.HCL)
+		})
+	}
+
+	resourceNames := make([]string, 0, len(config.Documentation.Resources))
+	for name := range config.Documentation.Resources {
+		resourceNames = append(resourceNames, name)
+	}
+	sort.Strings(resourceNames)
+
+	for _, resourceName := range resourceNames {
+		resource := config.Documentation.Resources[resourceName]
+		if resource == nil {
+			continue
+		}
+		for _, example := range resource.Examples {
+			if example == nil || example.HCL == "" || skip[example.Name] {
+				continue
+			}
+			t.Run(fmt.Sprintf("ResourceExample_%s_%s", resourceName, example.Name), func(t *testing.T) {
+				runExample(t, schema, backendFor(), example.HCL)
+			})
+		}
+	}
+}
+
+// runExample parses, validates and optionally executes a single HCL example
+func runExample(t *testing.T, schema *ProviderSchema, backend SchemaProvider, src string) {
+	blocks, err := ParseExampleHCL(src)
+	require.NoError(t, err, "Example HCL must parse")
+	require.NotEmpty(t, blocks, "Example HCL must contain at least one create or discover block")
+
+	for _, block := range blocks {
+		for _, problem := range ValidateExampleBlock(schema, block) {
+			assert.Fail(t, "Example does not match schema", "%s: %s %q %q: %s", block.Pos, block.Kind, block.Type, block.Name, problem)
+		}
+	}
+
+	if backend == nil || t.Failed() {
+		return
+	}
+
+	ctx := context.Background()
+	for _, block := range blocks {
+		function := "CreateResource"
+		input := map[string]interface{}{
+			"resource_type": block.Type,
+			"name":          block.Name,
+			"config":        block.Attributes,
+		}
+		if block.Kind == "discover" {
+			function = "DiscoverResources"
+			input = map[string]interface{}{
+				"resource_type": block.Type,
+				"filters":       block.Attributes,
+			}
+		}
+
+		inputJSON, err := json.Marshal(input)
+		require.NoError(t, err)
+
+		_, err = backend.CallFunction(ctx, function, inputJSON)
+		assert.NoError(t, err, "Executing %s %q %q must succeed", block.Kind, block.Type, block.Name)
+	}
+}
+
+// ValidateExampleBlock checks a parsed block against the provider schema and returns
+// a description of every mismatch found. An empty result means the block is valid.
+func ValidateExampleBlock(schema *ProviderSchema, block ExampleBlock) []string {
+	var resourceType *ResourceTypeDefinition
+	for i := range schema.ResourceTypes {
+		if schema.ResourceTypes[i].Name == block.Type {
+			resourceType = &schema.ResourceTypes[i]
+			break
+		}
+	}
+	if resourceType == nil {
+		return []string{fmt.Sprintf("resource type %q is not defined in the provider schema", block.Type)}
+	}
+
+	// Discover blocks carry filters rather than resource configuration
+	if block.Kind != "create" || len(resourceType.ConfigSchema) == 0 {
+		return nil
+	}
+
+	var configSchema struct {
+		Properties map[string]struct {
+			Type string        `json:"type"`
+			Enum []interface{} `json:"enum"`
+		} `json:"properties"`
+		Required []string `json:"required"`
+	}
+	if err := json.Unmarshal(resourceType.ConfigSchema, &configSchema); err != nil {
+		return []string{fmt.Sprintf("config schema for %q is not valid JSON: %v", block.Type, err)}
+	}
+
+	var problems []string
+	for _, field := range configSchema.Required {
+		if _, ok := block.Attributes[field]; !ok {
+			problems = append(problems, fmt.Sprintf("required attribute %q is missing", field))
+		}
+	}
+
+	if len(configSchema.Properties) == 0 {
+		return problems
+	}
+
+	attributes := make([]string, 0, len(block.Attributes))
+	for name := range block.Attributes {
+		attributes = append(attributes, name)
+	}
+	sort.Strings(attributes)
+
+	for _, name := range attributes {
+		property, ok := configSchema.Properties[name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("attribute %q is not defined in the config schema", name))
+			continue
+		}
+
+		value := block.Attributes[name]
+		if expr, ok := value.(string); ok && len(hcl.FindInterpolations(expr)) > 0 {
+			continue // references are resolved at apply time
+		}
+		if property.Type != "" && !exampleValueMatchesType(value, property.Type) {
+			problems = append(problems, fmt.Sprintf("attribute %q must be of type %s", name, property.Type))
+		}
+		if len(property.Enum) > 0 && !exampleValueInEnum(value, property.Enum) {
+			problems = append(problems, fmt.Sprintf("attribute %q has value %v which is not one of %v", name, value, property.Enum))
+		}
+	}
+
+	return problems
+}
+
+// exampleValueMatchesType reports whether a parsed HCL value matches a JSON schema type
+func exampleValueMatchesType(value interface{}, expectedType string) bool {
+	switch expectedType {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == float64(int64(f))
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "array", "list":
+		_, ok := value.([]interface{})
+		return ok
+	case "object", "map":
+		_, ok := value.(map[string]interface{})
+		return ok
+	default:
+		return true
+	}
+}
+
+// exampleValueInEnum reports whether value is one of the allowed enum values
+func exampleValueInEnum(value interface{}, enum []interface{}) bool {
+	for _, allowed := range enum {
+		if fmt.Sprint(allowed) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseExampleHCL extracts the create and discover blocks from a documentation example.
+// Other top-level blocks (provider, variable, output, ...) are parsed but skipped.
+// References and function calls are kept as "${...}" interpolation strings.
+func ParseExampleHCL(src string) ([]ExampleBlock, error) {
+	file, err := hcl.ParseString(src)
+	if err != nil {
+		return nil, err
+	}
+
+	var blocks []ExampleBlock
+	for _, block := range file.Blocks {
+		if block.Type != hcl.BlockCreate && block.Type != hcl.BlockDiscover {
+			continue
+		}
+		blocks = append(blocks, ExampleBlock{
+			Kind:       block.Type,
+			Type:       block.ResourceType(),
+			Name:       block.Name(),
+			Attributes: block.Body(),
+			Pos:        block.Pos,
+		})
+	}
+	return blocks, nil
+}
+
+// =============================================================================
+// MOCK BACKEND
+// =============================================================================
+
+// MockBackend is an in-memory SchemaProvider that records executed example calls
+type MockBackend struct {
+	mu        sync.Mutex
+	resources map[string]map[string]interface{}
+	calls     []MockCall
+}
+
+// MockCall records a single CallFunction invocation
+type MockCall struct {
+	Function string                 `json:"function"`
+	Input    map[string]interface{} `json:"input"`
+}
+
+// NewMockBackend creates an empty mock backend
+func NewMockBackend() *MockBackend {
+	return &MockBackend{
+		resources: make(map[string]map[string]interface{}),
+	}
+}
+
+// Schema returns an empty schema; MockBackend is only used as an execution target
+func (m *MockBackend) Schema() (*ProviderSchema, error) {
+	return &ProviderSchema{Name: "mock"}, nil
+}
+
+// Configure accepts any configuration
+func (m *MockBackend) Configure(ctx context.Context, config map[string]interface{}) error {
+	return nil
+}
+
+// CallFunction stores created resources and answers reads and discovery from memory
+func (m *MockBackend) CallFunction(ctx context.Context, function string, input json.RawMessage) (json.RawMessage, error) {
+	var req map[string]interface{}
+	if err := json.Unmarshal(input, &req); err != nil {
+		return nil, fmt.Errorf("mock backend: invalid input: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls = append(m.calls, MockCall{Function: function, Input: req})
+
+	resourceType, _ := req["resource_type"].(string)
+	name, _ := req["name"].(string)
+	key := resourceType + "." + name
+
+	switch function {
+	case "CreateResource":
+		if _, exists := m.resources[key]; exists {
+			return nil, fmt.Errorf("mock backend: resource %s already exists", key)
+		}
+		config, _ := req["config"].(map[string]interface{})
+		m.resources[key] = config
+		return json.Marshal(map[string]interface{}{"success": true, "resource_id": key, "state": config})
+	case "ReadResource":
+		state, exists := m.resources[key]
+		return json.Marshal(map[string]interface{}{"state": state, "not_found": !exists})
+	case "DeleteResource":
+		delete(m.resources, key)
+		return json.Marshal(map[string]interface{}{"success": true})
+	case "DiscoverResources":
+		var objects []interface{}
+		for id, state := range m.resources {
+			if strings.HasPrefix(id, resourceType+".") {
+				objects = append(objects, map[string]interface{}{"id": id, "properties": state})
+			}
+		}
+		return json.Marshal(map[string]interface{}{"objects": objects})
+	case "Ping":
+		return json.Marshal(map[string]interface{}{"success": true, "status": "healthy"})
+	default:
+		return json.Marshal(map[string]interface{}{"success": true})
+	}
+}
+
+// Reset forgets every stored resource and recorded call
+func (m *MockBackend) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.resources = make(map[string]map[string]interface{})
+	m.calls = nil
+}
+
+// Calls returns a copy of every recorded call in invocation order
+func (m *MockBackend) Calls() []MockCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	calls := make([]MockCall, len(m.calls))
+	copy(calls, m.calls)
+	return calls
+}
+
+// UniqueName returns prefix followed by a unique, time-ordered suffix, for naming
+// resources created by tests that may run in parallel or against shared backends
+func UniqueName(prefix string) string {
+	return strings.ToLower(ids.Prefixed(prefix))
+}
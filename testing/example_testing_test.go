@@ -0,0 +1,149 @@
+package testing
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/schemabounce/kolumn/sdk/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exampleProvider serves a schema with a single postgres_table resource type
+type exampleProvider struct {
+	*MockBackend
+}
+
+func (p *exampleProvider) Schema() (*ProviderSchema, error) {
+	return &ProviderSchema{
+		Name: "postgres",
+		ResourceTypes: []ResourceTypeDefinition{{
+			Name:       "postgres_table",
+			Operations: []string{"create", "read", "update", "delete"},
+			ConfigSchema: json.RawMessage(`{
+				"properties": {
+					"schema":   {"type": "string"},
+					"unlogged": {"type": "boolean"},
+					"fill":     {"type": "integer"},
+					"tier":     {"type": "string", "enum": ["hot", "cold"]}
+				},
+				"required": ["schema"]
+			}`),
+		}},
+	}, nil
+}
+
+const usersExample = `create "postgres_table" "users" {
+  schema = "public"
+}
+`
+
+// TestRunExampleTestsIsolatesExamples validates examples reusing a resource name run
+// against their own backend state
+func TestRunExampleTestsIsolatesExamples(t *testing.T) {
+	docs := &core.UniversalProviderDocumentation{
+		Examples: []*core.ProviderExample{
+			{Name: "basic", HCL: usersExample},
+			{Name: "again", HCL: usersExample},
+			{Name: "skipped", HCL: `create "missing_type" "x" {}`},
+			nil,
+		},
+		Resources: map[string]*core.ResourceDoc{
+			"postgres_table": {Examples: []*core.ResourceExample{{Name: "table", HCL: usersExample}}},
+		},
+	}
+	provider := &exampleProvider{MockBackend: NewMockBackend()}
+
+	RunExampleTests(t, &ExampleTestConfig{
+		Provider:      provider,
+		Documentation: docs,
+		Execute:       true,
+		SkipExamples:  []string{"skipped"},
+	})
+
+	// a shared backend is reset before every example
+	shared := NewMockBackend()
+	RunExampleTests(t, &ExampleTestConfig{
+		Provider:      provider,
+		Documentation: docs,
+		Execute:       true,
+		Backend:       shared,
+		SkipExamples:  []string{"skipped"},
+	})
+	calls := shared.Calls()
+	require.Len(t, calls, 1, "only the last example's calls remain after resets")
+	assert.Equal(t, "CreateResource", calls[0].Function)
+}
+
+// TestValidateExampleBlock validates schema mismatches reported for parsed blocks
+func TestValidateExampleBlock(t *testing.T) {
+	schema, err := (&exampleProvider{}).Schema()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		hcl      string
+		problems []string
+	}{
+		{"valid", usersExample, nil},
+		{"reference", "create \"postgres_table\" \"users\" {\n  schema = postgres_schema.app.name\n}\n", nil},
+		{"discover", "discover \"postgres_table\" \"all\" {\n  anything = true\n}\n", nil},
+		{"unknown type", "create \"postgres_view\" \"v\" {\n  schema = \"public\"\n}\n",
+			[]string{`resource type "postgres_view" is not defined in the provider schema`}},
+		{"missing required", "create \"postgres_table\" \"users\" {\n  unlogged = true\n}\n",
+			[]string{`required attribute "schema" is missing`}},
+		{"unknown attribute", "create \"postgres_table\" \"users\" {\n  schema = \"public\"\n  owner = \"app\"\n}\n",
+			[]string{`attribute "owner" is not defined in the config schema`}},
+		{"wrong types", "create \"postgres_table\" \"users\" {\n  schema = \"public\"\n  unlogged = \"yes\"\n  fill = 1.5\n}\n",
+			[]string{`attribute "fill" must be of type integer`, `attribute "unlogged" must be of type boolean`}},
+		{"enum", "create \"postgres_table\" \"users\" {\n  schema = \"public\"\n  tier = \"warm\"\n}\n",
+			[]string{`attribute "tier" has value warm which is not one of [hot cold]`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks, err := ParseExampleHCL(tt.hcl)
+			require.NoError(t, err)
+			require.Len(t, blocks, 1)
+			assert.Equal(t, tt.problems, ValidateExampleBlock(schema, blocks[0]))
+		})
+	}
+}
+
+// TestMockBackend validates the create, read, discover and delete round trip
+func TestMockBackend(t *testing.T) {
+	backend := NewMockBackend()
+	ctx := context.Background()
+	call := func(function, input string) map[string]interface{} {
+		t.Helper()
+		output, err := backend.CallFunction(ctx, function, json.RawMessage(input))
+		require.NoError(t, err)
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(output, &resp))
+		return resp
+	}
+
+	created := call("CreateResource", `{"resource_type":"table","name":"users","config":{"schema":"public"}}`)
+	assert.Equal(t, "table.users", created["resource_id"])
+
+	_, err := backend.CallFunction(ctx, "CreateResource", json.RawMessage(`{"resource_type":"table","name":"users"}`))
+	assert.Error(t, err, "creating an existing resource must fail")
+
+	read := call("ReadResource", `{"resource_type":"table","name":"users"}`)
+	assert.Equal(t, false, read["not_found"])
+	assert.Equal(t, map[string]interface{}{"schema": "public"}, read["state"])
+
+	discovered := call("DiscoverResources", `{"resource_type":"table"}`)
+	assert.Len(t, discovered["objects"], 1)
+
+	call("DeleteResource", `{"resource_type":"table","name":"users"}`)
+	assert.Equal(t, true, call("ReadResource", `{"resource_type":"table","name":"users"}`)["not_found"])
+
+	_, err = backend.CallFunction(ctx, "Ping", json.RawMessage(`not json`))
+	assert.Error(t, err)
+
+	assert.Len(t, backend.Calls(), 6)
+	backend.Reset()
+	assert.Empty(t, backend.Calls())
+	assert.Equal(t, true, call("ReadResource", `{"resource_type":"table","name":"users"}`)["not_found"])
+}
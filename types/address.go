@@ -0,0 +1,170 @@
+// Package types provides shared value types used across the Kolumn SDK
+package types
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Address identifies a resource instance. Its canonical form is
+//
+//	provider.type.name[index]
+//
+// where the index is optional and is either an integer (count) or a quoted string key
+// (for_each), e.g. postgres.table.users, postgres.table.shard[2] or
+// s3.bucket.logs["us-east-1"]. The relative form type.name[index] omits the provider
+// and matches HCL references such as postgres_table.users. Addresses encode as their
+// canonical string.
+type Address struct {
+	Provider string
+	Type     string
+	Name     string
+	Index    *AddressIndex
+}
+
+// AddressIndex is the instance key of a counted or for_each resource
+type AddressIndex struct {
+	Int   int
+	Key   string
+	IsKey bool
+}
+
+// IntIndex returns a count index
+func IntIndex(i int) *AddressIndex {
+	return &AddressIndex{Int: i}
+}
+
+// KeyIndex returns a for_each key index
+func KeyIndex(key string) *AddressIndex {
+	return &AddressIndex{Key: key, IsKey: true}
+}
+
+// String renders the index with its brackets
+func (i *AddressIndex) String() string {
+	if i == nil {
+		return ""
+	}
+	if i.IsKey {
+		return "[" + strconv.Quote(i.Key) + "]"
+	}
+	return "[" + strconv.Itoa(i.Int) + "]"
+}
+
+// NewAddress returns the address of a resource without an index
+func NewAddress(provider, resourceType, name string) Address {
+	return Address{Provider: provider, Type: resourceType, Name: name}
+}
+
+// WithIndex returns a copy of the address with the given instance index
+func (a Address) WithIndex(index *AddressIndex) Address {
+	a.Index = index
+	return a
+}
+
+// Resource returns the address without its instance index
+func (a Address) Resource() Address {
+	a.Index = nil
+	return a
+}
+
+// Relative returns the address without its provider
+func (a Address) Relative() Address {
+	a.Provider = ""
+	return a
+}
+
+// String renders the address in canonical form
+func (a Address) String() string {
+	var b strings.Builder
+	if a.Provider != "" {
+		b.WriteString(a.Provider)
+		b.WriteByte('.')
+	}
+	b.WriteString(a.Type)
+	if a.Name != "" {
+		b.WriteByte('.')
+		b.WriteString(a.Name)
+	}
+	b.WriteString(a.Index.String())
+	return b.String()
+}
+
+// Equal reports whether two addresses refer to the same instance
+func (a Address) Equal(other Address) bool {
+	return a.String() == other.String()
+}
+
+// MarshalText renders the address in canonical form
+func (a Address) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText parses a canonical or relative address; empty text is the zero address
+func (a *Address) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*a = Address{}
+		return nil
+	}
+	parsed, err := ParseAddress(string(text))
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+var (
+	addressSegment = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+	addressIndex   = regexp.MustCompile(`^(.*?)\[(\d+|"(?:[^"\\]|\\.)*")\]$`)
+)
+
+// ParseAddress parses provider.type.name[index] or the relative type.name[index]
+func ParseAddress(s string) (Address, error) {
+	var addr Address
+	body := strings.TrimSpace(s)
+	if m := addressIndex.FindStringSubmatch(body); m != nil {
+		body = m[1]
+		if strings.HasPrefix(m[2], `"`) {
+			key, err := strconv.Unquote(m[2])
+			if err != nil {
+				return Address{}, fmt.Errorf("invalid resource address %q: bad index key: %w", s, err)
+			}
+			addr.Index = KeyIndex(key)
+		} else {
+			n, err := strconv.Atoi(m[2])
+			if err != nil {
+				return Address{}, fmt.Errorf("invalid resource address %q: bad index: %w", s, err)
+			}
+			addr.Index = IntIndex(n)
+		}
+	} else if strings.ContainsAny(body, "[]") {
+		return Address{}, fmt.Errorf("invalid resource address %q: malformed index", s)
+	}
+
+	parts := strings.Split(body, ".")
+	switch len(parts) {
+	case 2:
+		addr.Type, addr.Name = parts[0], parts[1]
+	case 3:
+		addr.Provider, addr.Type, addr.Name = parts[0], parts[1], parts[2]
+	default:
+		return Address{}, fmt.Errorf("invalid resource address %q: expected provider.type.name or type.name", s)
+	}
+	for _, part := range parts {
+		if !addressSegment.MatchString(part) {
+			return Address{}, fmt.Errorf("invalid resource address %q: %q is not a valid identifier", s, part)
+		}
+	}
+	return addr, nil
+}
+
+// MustParseAddress is ParseAddress for addresses known to be valid; it panics otherwise
+func MustParseAddress(s string) Address {
+	addr, err := ParseAddress(s)
+	if err != nil {
+		panic(err)
+	}
+	return addr
+}
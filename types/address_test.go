@@ -0,0 +1,56 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestParseAddress validates canonical and relative addresses round-trip
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Address
+	}{
+		{"postgres.table.users", NewAddress("postgres", "table", "users")},
+		{"postgres_table.users", Address{Type: "postgres_table", Name: "users"}},
+		{"postgres.table.shard[2]", NewAddress("postgres", "table", "shard").WithIndex(IntIndex(2))},
+		{`s3.bucket.logs["us-east-1"]`, NewAddress("s3", "bucket", "logs").WithIndex(KeyIndex("us-east-1"))},
+	}
+	for _, tt := range tests {
+		got, err := ParseAddress(tt.in)
+		if err != nil {
+			t.Fatalf("ParseAddress(%q): %v", tt.in, err)
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseAddress(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+		if got.String() != tt.in {
+			t.Errorf("round trip of %q produced %q", tt.in, got.String())
+		}
+	}
+
+	for _, bad := range []string{"", "users", "a.b.c.d", "postgres.table.1users", "t.n[", `t.n["x]`, "t.n[-1]"} {
+		if _, err := ParseAddress(bad); err == nil {
+			t.Errorf("ParseAddress(%q) should fail", bad)
+		}
+	}
+}
+
+// TestAddressJSON validates addresses marshal as their canonical string
+func TestAddressJSON(t *testing.T) {
+	addr := NewAddress("postgres", "table", "users").WithIndex(KeyIndex("a"))
+	data, err := json.Marshal(map[string]Address{"address": addr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"address":"postgres.table.users[\"a\"]"}` {
+		t.Errorf("unexpected JSON: %s", data)
+	}
+	var decoded map[string]Address
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !decoded["address"].Equal(addr) {
+		t.Errorf("decoded %s, want %s", decoded["address"], addr)
+	}
+}
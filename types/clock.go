@@ -0,0 +1,79 @@
+package types
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock supplies the current time. Packages that record timestamps read them from a
+// replaceable Clock so tests can substitute a fake one.
+type Clock interface {
+	Now() time.Time
+}
+
+// TimerClock is a Clock that also schedules waits, so a fake clock can fire retries and
+// backoffs without real delays
+type TimerClock interface {
+	Clock
+	After(d time.Duration) <-chan time.Time
+}
+
+// ClockFunc adapts a function to the Clock interface
+type ClockFunc func() time.Time
+
+// Now calls f
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// SystemClock reads the wall clock
+var SystemClock Clock = ClockFunc(time.Now)
+
+// ClockVar holds a package's replaceable clock. The zero value uses SystemClock and it
+// is safe for concurrent use.
+type ClockVar struct {
+	mu    sync.RWMutex
+	clock Clock
+}
+
+// Now returns the current time of the installed clock
+func (v *ClockVar) Now() time.Time {
+	v.mu.RLock()
+	clock := v.clock
+	v.mu.RUnlock()
+	if clock == nil {
+		return time.Now()
+	}
+	return clock.Now()
+}
+
+// Since returns the time elapsed since t according to the installed clock
+func (v *ClockVar) Since(t time.Time) time.Duration {
+	return v.Now().Sub(t)
+}
+
+// After waits for d on the installed clock when it is a TimerClock, and on the wall
+// clock otherwise
+func (v *ClockVar) After(d time.Duration) <-chan time.Time {
+	v.mu.RLock()
+	clock := v.clock
+	v.mu.RUnlock()
+	if timer, ok := clock.(TimerClock); ok {
+		return timer.After(d)
+	}
+	return time.After(d)
+}
+
+// Set installs clock, or SystemClock when nil, and returns a function restoring the
+// previous clock
+func (v *ClockVar) Set(clock Clock) (restore func()) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	previous := v.clock
+	v.clock = clock
+	return func() {
+		v.mu.Lock()
+		defer v.mu.Unlock()
+		v.clock = previous
+	}
+}
@@ -0,0 +1,63 @@
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+// TestClockVar validates installed clocks are used and restored
+func TestClockVar(t *testing.T) {
+	var v ClockVar
+	if time.Since(v.Now()) > time.Minute {
+		t.Fatal("zero ClockVar must read the system clock")
+	}
+
+	fixed := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	restore := v.Set(ClockFunc(func() time.Time { return fixed }))
+	if !v.Now().Equal(fixed) {
+		t.Fatalf("Now() = %s, want %s", v.Now(), fixed)
+	}
+	if got := v.Since(fixed.Add(-time.Hour)); got != time.Hour {
+		t.Fatalf("Since() = %s, want 1h", got)
+	}
+
+	restore()
+	if v.Now().Equal(fixed) {
+		t.Fatal("restore must reinstate the previous clock")
+	}
+}
+
+// firingClock fires every wait at once and records its duration
+type firingClock struct {
+	ClockFunc
+	waited time.Duration
+}
+
+func (c *firingClock) After(d time.Duration) <-chan time.Time {
+	c.waited = d
+	ch := make(chan time.Time, 1)
+	ch <- c.Now()
+	return ch
+}
+
+// TestClockVarAfter validates waits use a TimerClock when one is installed
+func TestClockVarAfter(t *testing.T) {
+	var v ClockVar
+	fake := &firingClock{ClockFunc: time.Now}
+	restore := v.Set(fake)
+	select {
+	case <-v.After(time.Hour):
+	case <-time.After(5 * time.Second):
+		t.Fatal("After must wait on the installed TimerClock")
+	}
+	if fake.waited != time.Hour {
+		t.Errorf("TimerClock waited %s, want 1h", fake.waited)
+	}
+
+	restore()
+	select {
+	case <-v.After(time.Millisecond):
+	case <-time.After(5 * time.Second):
+		t.Fatal("After must fall back to the wall clock")
+	}
+}
@@ -0,0 +1,54 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// StateSeparator separates a state name from a resource address in an external
+// dependency target, e.g. network::aws.vpc.main
+const StateSeparator = "::"
+
+// Dependency records that From must exist before To's dependents can proceed: From
+// depends on To. When State is set, To lives in that other state rather than in the
+// state that manages From.
+type Dependency struct {
+	From  Address `json:"from"`
+	To    Address `json:"to"`
+	State string  `json:"state,omitempty"`
+}
+
+// IsExternal reports whether the dependency targets a resource in another state
+func (d Dependency) IsExternal() bool {
+	return d.State != ""
+}
+
+// Target renders the dependency target, prefixed with its state when external
+func (d Dependency) Target() string {
+	if d.IsExternal() {
+		return d.State + StateSeparator + d.To.String()
+	}
+	return d.To.String()
+}
+
+// String renders the dependency as "from -> target"
+func (d Dependency) String() string {
+	return d.From.String() + " -> " + d.Target()
+}
+
+// ParseDependencyTarget parses "address" or "state::address", returning the state name
+// (empty for a same-state target) and the address
+func ParseDependencyTarget(s string) (string, Address, error) {
+	stateName, rest := "", s
+	if i := strings.Index(s, StateSeparator); i >= 0 {
+		stateName, rest = strings.TrimSpace(s[:i]), s[i+len(StateSeparator):]
+		if stateName == "" {
+			return "", Address{}, fmt.Errorf("invalid dependency target %q: empty state name", s)
+		}
+	}
+	addr, err := ParseAddress(rest)
+	if err != nil {
+		return "", Address{}, err
+	}
+	return stateName, addr, nil
+}
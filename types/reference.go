@@ -0,0 +1,198 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// OutputsAttribute is the path segment introducing a resource output in a reference,
+// e.g. postgres.database.main.outputs.connection_string
+const OutputsAttribute = "outputs"
+
+// Reference points at a value of another resource: its canonical address followed by
+// an attribute path, optionally in another state. Its string form is
+//
+//	[state::]provider.type.name[index].attribute[0].nested
+//
+// Path holds map keys and, for list elements, decimal indexes.
+type Reference struct {
+	State   string   `json:"state,omitempty"`
+	Address Address  `json:"address"`
+	Path    []string `json:"path"`
+}
+
+// NewReference creates a reference to an attribute path of a resource
+func NewReference(addr Address, path ...string) Reference {
+	return Reference{Address: addr, Path: path}
+}
+
+// NewOutputReference creates a reference to a named output of a resource
+func NewOutputReference(addr Address, output string) Reference {
+	return Reference{Address: addr, Path: []string{OutputsAttribute, output}}
+}
+
+// IsOutput reports whether the reference names a resource output rather than an attribute
+func (r Reference) IsOutput() bool {
+	return len(r.Path) == 2 && r.Path[0] == OutputsAttribute
+}
+
+// Attribute returns the top-level attribute the reference reads
+func (r Reference) Attribute() string {
+	if len(r.Path) == 0 {
+		return ""
+	}
+	return r.Path[0]
+}
+
+// String renders the reference, writing list indexes in brackets
+func (r Reference) String() string {
+	var b strings.Builder
+	if r.State != "" {
+		b.WriteString(r.State)
+		b.WriteString(StateSeparator)
+	}
+	b.WriteString(r.Address.String())
+	for _, part := range r.Path {
+		if isIndex(part) {
+			b.WriteString("[" + part + "]")
+		} else {
+			b.WriteString("." + part)
+		}
+	}
+	return b.String()
+}
+
+// Expression renders the reference as an interpolation, e.g. ${postgres.table.users.id}
+func (r Reference) Expression() string {
+	return "${" + r.String() + "}"
+}
+
+// Dependency returns the dependency of from on the referenced resource
+func (r Reference) Dependency(from Address) Dependency {
+	return Dependency{From: from, To: r.Address, State: r.State}
+}
+
+// ParseReference parses a reference in string form, with or without the surrounding ${}.
+// The address must be canonical (provider.type.name) so the attribute path that follows
+// is unambiguous.
+func ParseReference(s string) (Reference, error) {
+	body := strings.TrimSpace(s)
+	if strings.HasPrefix(body, "${") && strings.HasSuffix(body, "}") {
+		body = strings.TrimSpace(body[2 : len(body)-1])
+	}
+
+	var ref Reference
+	if i := strings.Index(body, StateSeparator); i >= 0 {
+		ref.State, body = strings.TrimSpace(body[:i]), body[i+len(StateSeparator):]
+		if ref.State == "" {
+			return Reference{}, fmt.Errorf("invalid reference %q: empty state name", s)
+		}
+	}
+
+	// the address is the first three segments, plus an index directly after the name
+	end, dots := 0, 0
+	for end < len(body) && body[end] != '[' {
+		if body[end] == '.' {
+			if dots++; dots == 3 {
+				break
+			}
+		}
+		end++
+	}
+	if end < len(body) && body[end] == '[' {
+		closer := "]"
+		if strings.HasPrefix(body[end:], `["`) {
+			closer = `"]` // a key may itself contain dots and brackets
+		}
+		closing := strings.Index(body[end+1:], closer)
+		if closing < 0 {
+			return Reference{}, fmt.Errorf("invalid reference %q: unterminated index", s)
+		}
+		end += 1 + closing + len(closer)
+	}
+	addr, err := ParseAddress(body[:end])
+	if err != nil {
+		return Reference{}, fmt.Errorf("invalid reference %q: %w", s, err)
+	}
+	if addr.Provider == "" {
+		return Reference{}, fmt.Errorf("invalid reference %q: address must be provider.type.name", s)
+	}
+	ref.Address = addr
+
+	path, err := parsePath(body[end:])
+	if err != nil {
+		return Reference{}, fmt.Errorf("invalid reference %q: %w", s, err)
+	}
+	if len(path) == 0 {
+		return Reference{}, fmt.Errorf("invalid reference %q: missing attribute", s)
+	}
+	ref.Path = path
+	return ref, nil
+}
+
+// Lookup follows the reference path into a resource's data
+func (r Reference) Lookup(data map[string]interface{}) (interface{}, bool) {
+	var value interface{} = data
+	for _, part := range r.Path {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			next, ok := v[part]
+			if !ok {
+				return nil, false
+			}
+			value = next
+		case []interface{}:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			value = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// parsePath splits ".a[0].b" into its segments
+func parsePath(s string) ([]string, error) {
+	var path []string
+	for len(s) > 0 {
+		switch s[0] {
+		case '.':
+			end := strings.IndexAny(s[1:], ".[")
+			if end < 0 {
+				end = len(s) - 1
+			}
+			part := s[1 : end+1]
+			if !addressSegment.MatchString(part) {
+				return nil, fmt.Errorf("%q is not a valid attribute name", part)
+			}
+			path = append(path, part)
+			s = s[end+1:]
+		case '[':
+			end := strings.IndexByte(s, ']')
+			if end < 0 || !isIndex(s[1:end]) {
+				return nil, fmt.Errorf("malformed index in %q", s)
+			}
+			path = append(path, s[1:end])
+			s = s[end+1:]
+		default:
+			return nil, fmt.Errorf("unexpected %q in attribute path", s)
+		}
+	}
+	return path, nil
+}
+
+func isIndex(part string) bool {
+	if part == "" {
+		return false
+	}
+	for _, c := range part {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
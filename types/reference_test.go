@@ -0,0 +1,56 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestParseReference validates references round-trip and split address from path
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Reference
+	}{
+		{"postgres.table.users.row_count", NewReference(NewAddress("postgres", "table", "users"), "row_count")},
+		{"postgres.table.users.columns[0].name", NewReference(NewAddress("postgres", "table", "users"), "columns", "0", "name")},
+		{"postgres.table.shard[2].id", NewReference(NewAddress("postgres", "table", "shard").WithIndex(IntIndex(2)), "id")},
+		{`s3.bucket.logs["eu.west[1]"].arn`, NewReference(NewAddress("s3", "bucket", "logs").WithIndex(KeyIndex("eu.west[1]")), "arn")},
+		{"network::aws.vpc.main.outputs.vpc_id", Reference{State: "network", Address: NewAddress("aws", "vpc", "main"), Path: []string{"outputs", "vpc_id"}}},
+	}
+	for _, tt := range tests {
+		got, err := ParseReference(tt.in)
+		if err != nil {
+			t.Fatalf("ParseReference(%q): %v", tt.in, err)
+		}
+		if got.String() != tt.in || got.State != tt.want.State || !got.Address.Equal(tt.want.Address) || !reflect.DeepEqual(got.Path, tt.want.Path) {
+			t.Errorf("ParseReference(%q) = %#v, want %#v", tt.in, got, tt.want)
+		}
+		if again, err := ParseReference(got.Expression()); err != nil || again.String() != tt.in {
+			t.Errorf("expression form of %q did not parse: %v", tt.in, err)
+		}
+	}
+
+	if ref, _ := ParseReference("aws.vpc.main.outputs.vpc_id"); !ref.IsOutput() {
+		t.Error("outputs reference should be recognized")
+	}
+	for _, bad := range []string{"postgres.table.users", "table.users.id", "postgres.table.users.cols[x]", "postgres.table.users..id", "::aws.vpc.main.id"} {
+		if _, err := ParseReference(bad); err == nil {
+			t.Errorf("ParseReference(%q) should fail", bad)
+		}
+	}
+}
+
+// TestReferenceLookup validates paths descend into maps and lists
+func TestReferenceLookup(t *testing.T) {
+	data := map[string]interface{}{
+		"columns": []interface{}{map[string]interface{}{"name": "id"}},
+	}
+	ref := NewReference(NewAddress("postgres", "table", "users"), "columns", "0", "name")
+	if value, ok := ref.Lookup(data); !ok || value != "id" {
+		t.Errorf("Lookup = %v, %v", value, ok)
+	}
+	ref.Path[1] = "3"
+	if _, ok := ref.Lookup(data); ok {
+		t.Error("out of range index should not resolve")
+	}
+}